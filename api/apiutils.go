@@ -18,6 +18,8 @@ package apiutils
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +33,8 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
 	"text/template"
 	"time"
 
@@ -77,13 +81,189 @@ const (
 `
 )
 
-// NewHTTPClient returns a *http.Client created with all required scopes and permissions.
-// tokenFilepath can be set to "" if not otherwise defined.
-func NewHTTPClient(ctx context.Context, clientSecretKeyFile []byte, tokenFilepath string) (*http.Client, error) {
-	config, err := google.ConfigFromJSON(clientSecretKeyFile, builderAPIScope)
+// Scopes of Google APIs which are only used by some commands. They aren't requested by
+// "gactions login", but by NewHTTPClient when a command needs them, so users only grant access
+// to the features they use.
+const (
+	// SheetsScope allows to read Google Sheets shared with the user, e.g. to import types.
+	SheetsScope = "https://www.googleapis.com/auth/spreadsheets.readonly"
+	// LoggingWriteScope and LoggingReadScope allow to record the audit trail of the CLI in Cloud
+	// Logging, and to read it and the logs of cloud functions back.
+	LoggingWriteScope = "https://www.googleapis.com/auth/logging.write"
+	LoggingReadScope  = "https://www.googleapis.com/auth/logging.read"
+	// CloudPlatformReadOnlyScope allows to check the IAM permissions of the user on a project, and
+	// to read the quota of the Actions API.
+	CloudPlatformReadOnlyScope = "https://www.googleapis.com/auth/cloud-platform.read-only"
+	// CloudPlatformScope allows to synthesize the speech of prompts with the Cloud Text-to-Speech
+	// API, which has no narrower scope.
+	CloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// ActionsScope is the scope of the Actions API, which is requested by "gactions login". Programs
+// which embed the SDK need tokens with this scope.
+const ActionsScope = builderAPIScope
+
+// CredentialsEnv is the environment variable with the path of a service account key, or of the
+// configuration of an external account of Workload Identity Federation. When it is set, the CLI
+// authenticates as the service account instead of with the token of "gactions login".
+const CredentialsEnv = "GACTIONS_CREDENTIALS"
+
+// NewTransport returns the transport of requests to Google APIs, which uses the proxy at the URL
+// proxy and trusts the CAs in the file caBundle in addition to the CAs of the system, e.g. the CA
+// of a proxy which intercepts TLS. If proxy is empty, the proxy is set by the HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY environment variables.
+func NewTransport(proxy, caBundle string) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("proxy must be a URL, e.g. http://proxy.example.com:3128, got %q", proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if caBundle != "" {
+		b, err := ioutil.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("can not read the CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			// The system pool isn't available on some platforms, e.g. older versions of Windows.
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("%v doesn't contain PEM certificates", caBundle)
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return t, nil
+}
+
+// WithTransport returns a copy of ctx which makes the functions of this package send their
+// requests with t, both the requests of the clients and the requests for tokens.
+func WithTransport(ctx context.Context, t http.RoundTripper) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: t})
+}
+
+// withTransport returns ctx which makes oauth2 send requests with the transport set by
+// WithTransport, or with the transport of NewTransport without a proxy or a CA bundle if ctx
+// doesn't carry one.
+func withTransport(ctx context.Context) (context.Context, error) {
+	if _, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		return ctx, nil
+	}
+	t, err := NewTransport("", "")
+	if err != nil {
+		return nil, err
+	}
+	return WithTransport(ctx, t), nil
+}
+
+// scopes are the OAuth2 scopes requested by "gactions login".
+var scopes = []string{builderAPIScope}
+
+// isServiceAccountKey returns whether b is a JSON key of a service account.
+func isServiceAccountKey(b []byte) bool {
+	var k struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(b, &k) == nil && k.Type == "service_account"
+}
+
+// isExternalAccount returns whether b is the JSON configuration of an external account of
+// Workload Identity Federation, e.g. one created by "gcloud iam workload-identity-pools
+// create-cred-config".
+func isExternalAccount(b []byte) bool {
+	var k struct {
+		Type string `json:"type"`
+	}
+	return json.Unmarshal(b, &k) == nil && k.Type == "external_account"
+}
+
+// withScopes returns the union of the scopes of the CLI and extra, without duplicates.
+func withScopes(extra ...[]string) []string {
+	res := append([]string{}, scopes...)
+	for _, l := range extra {
+		for _, v := range l {
+			if !containsScope(res, v) {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+func containsScope(l []string, scope string) bool {
+	for _, v := range l {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceAccountClient returns a *http.Client which mints tokens for the service account of key
+// with the JWT flow.
+func serviceAccountClient(ctx context.Context, key []byte, scopes []string) (*http.Client, error) {
+	config, err := google.JWTConfigFromJSON(key, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	return config.Client(ctx), nil
+}
+
+// externalAccountClient returns a *http.Client which exchanges the credentials of the external
+// account of config, such as the OIDC token of a CI job, for tokens of Google.
+func externalAccountClient(ctx context.Context, config []byte, scopes []string) (*http.Client, error) {
+	creds, err := google.CredentialsFromJSON(ctx, config, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(ctx, creds.TokenSource), nil
+}
+
+// findDefaultCredentials looks up Application Default Credentials.
+var findDefaultCredentials = google.FindDefaultCredentials
+
+// defaultCredentialsClient returns a *http.Client authorized with Application Default Credentials.
+func defaultCredentialsClient(ctx context.Context, scopes []string) (*http.Client, error) {
+	creds, err := findDefaultCredentials(ctx, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("can not find Application Default Credentials: %v", err)
+	}
+	log.Infoln("Using Application Default Credentials.")
+	return oauth2.NewClient(ctx, creds.TokenSource), nil
+}
+
+// NewHTTPClient returns a *http.Client created with all required scopes and permissions, and
+// extraScopes needed by the command, such as SheetsScope.
+// tokenFilepath can be set to "" if not otherwise defined. If the environment variable
+// CredentialsEnv is set, or tokenFilepath holds a service account key saved by
+// AuthServiceAccount or an external account saved by AuthExternalAccount, the client
+// authenticates as the service account. If there is no token
+// and useADC is true, the client uses Application Default Credentials. If the saved token of the
+// user wasn't granted extraScopes, the user is asked for access to them, and the token is
+// replaced with a token for both the scopes granted before and extraScopes.
+func NewHTTPClient(ctx context.Context, clientSecretKeyFile []byte, tokenFilepath string, useADC bool, extraScopes ...string) (*http.Client, error) {
+	ctx, err := withTransport(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if f := os.Getenv(CredentialsEnv); f != "" {
+		key, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("can not read the service account key set by %v: %v", CredentialsEnv, err)
+		}
+		if isExternalAccount(key) {
+			log.Infof("Using the external account %v set by %v.\n", f, CredentialsEnv)
+			return externalAccountClient(ctx, key, withScopes(extraScopes))
+		}
+		if !isServiceAccountKey(key) {
+			return nil, fmt.Errorf("%v set by %v is not a service account key or an external account", f, CredentialsEnv)
+		}
+		log.Infof("Using the service account key %v set by %v.\n", f, CredentialsEnv)
+		return serviceAccountClient(ctx, key, withScopes(extraScopes))
+	}
 	tokenCacheFilename := ""
 	if tokenFilepath == "" {
 		tokenCacheFilename, err = tokenCacheFile()
@@ -95,67 +275,238 @@ func NewHTTPClient(ctx context.Context, clientSecretKeyFile []byte, tokenFilepat
 	}
 	if !exists(tokenCacheFilename) {
 		log.Infoln("Could not locate OAuth2 token")
+		if useADC {
+			return defaultCredentialsClient(ctx, withScopes(extraScopes))
+		}
 		return nil, errors.New(`command requires authentication. try to run "gactions login" first`)
 	}
-	tok, err := tokenFromFile(tokenCacheFilename)
+	b, err := readTokenFile(tokenCacheFilename)
+	if err != nil {
+		return nil, err
+	}
+	if isServiceAccountKey(b) {
+		log.Infof("Using the service account key saved in %v.\n", tokenCacheFilename)
+		return serviceAccountClient(ctx, b, withScopes(extraScopes))
+	}
+	if isExternalAccount(b) {
+		log.Infof("Using the external account saved in %v.\n", tokenCacheFilename)
+		return externalAccountClient(ctx, b, withScopes(extraScopes))
+	}
+	// The token must be refreshed by the same client it was issued to.
+	savedSecret := tokenClientSecret(b)
+	if savedSecret != nil {
+		log.Infof("Using the OAuth client saved in %v.\n", tokenCacheFilename)
+		clientSecretKeyFile = savedSecret
+	}
+	granted := tokenScopes(b)
+	config, err := google.ConfigFromJSON(clientSecretKeyFile, withScopes(granted, extraScopes)...)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := parseToken(b)
 	if err != nil {
 		return nil, err
 	}
+	if missing := missingScopes(granted, extraScopes); len(missing) > 0 {
+		log.Outf("This command needs access to more of your Google account: %v\n", strings.Join(missing, ", "))
+		if tok, err = grantToken(ctx, config); err != nil {
+			return nil, err
+		}
+		if err := writeToken(tokenCacheFilename, tok, savedSecret, config.Scopes); err != nil {
+			return nil, err
+		}
+	}
 	return config.Client(ctx, tok), nil
 }
 
+// tokenScopes returns the scopes granted to the token saved in b. Tokens saved before the scopes
+// were recorded were granted the scopes of "gactions login".
+func tokenScopes(b []byte) []string {
+	var t struct {
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(b, &t); err != nil || t.Scopes == nil {
+		return scopes
+	}
+	return t.Scopes
+}
+
+// missingScopes returns the scopes of want which aren't in granted.
+func missingScopes(granted, want []string) []string {
+	var res []string
+	for _, v := range want {
+		if !containsScope(granted, v) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
 // Auth prompts user for authentication token and writes it to disc.
-func Auth(ctx context.Context, clientSecretKeyFile []byte) error {
-	config, err := google.ConfigFromJSON(clientSecretKeyFile, []string{builderAPIScope}...)
+// tokenFilepath can be set to "" if not otherwise defined.
+func Auth(ctx context.Context, clientSecretKeyFile []byte, tokenFilepath string) error {
+	return auth(ctx, clientSecretKeyFile, tokenFilepath, false)
+}
+
+// AuthWithClient is like Auth, but clientSecretKeyFile is an OAuth client of the user instead of
+// the client of the CLI. The client secret is saved with the token, so that the token is
+// refreshed with the same client.
+func AuthWithClient(ctx context.Context, clientSecretKeyFile []byte, tokenFilepath string) error {
+	return auth(ctx, clientSecretKeyFile, tokenFilepath, true)
+}
+
+func auth(ctx context.Context, clientSecretKeyFile []byte, tokenFilepath string, saveClient bool) error {
+	ctx, err := withTransport(ctx)
+	if err != nil {
+		return err
+	}
+	config, err := google.ConfigFromJSON(clientSecretKeyFile, scopes...)
 	if err != nil {
 		return err
 	}
 	// Get OAuth2 token from the user. It will be written into cacheFilename.
-	tokenCacheFilename, err := tokenCacheFile()
+	tokenCacheFilename := tokenFilepath
+	if tokenCacheFilename == "" {
+		tokenCacheFilename, err = tokenCacheFile()
+		if err != nil {
+			return err
+		}
+	}
+	tok, err := token(ctx, config, tokenCacheFilename, launchBrowser())
 	if err != nil {
 		return err
 	}
-	// Check the shell is appropriate for use of launched browsers, otherwise present the copy/paste
-	// flow.
-	nonSSH := checkShell()
-	notWindows := runtime.GOOS != "windows"
-	tok, err := token(ctx, config, tokenCacheFilename, nonSSH && notWindows)
+	var secret []byte
+	if saveClient {
+		secret = clientSecretKeyFile
+	}
+	if err := saveToken(tokenCacheFilename, tok, secret, config.Scopes); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AuthServiceAccount checks that key is a service account key which can mint tokens, and saves
+// it in place of the token of a user, so that the CLI authenticates as the service account.
+// tokenFilepath can be set to "" if not otherwise defined.
+func AuthServiceAccount(ctx context.Context, key []byte, tokenFilepath string) error {
+	if !isServiceAccountKey(key) {
+		return errors.New("the key is not a JSON key of a service account")
+	}
+	tokenCacheFilename := tokenFilepath
+	if tokenCacheFilename == "" {
+		var err error
+		if tokenCacheFilename, err = tokenCacheFile(); err != nil {
+			return err
+		}
+	}
+	if exists(tokenCacheFilename) {
+		return errors.New(`already logged in. run "gactions logout" first`)
+	}
+	ctx, err := withTransport(ctx)
 	if err != nil {
 		return err
 	}
-	if err := saveToken(tokenCacheFilename, tok); err != nil {
+	if err := checkServiceAccountKey(ctx, key); err != nil {
+		return err
+	}
+	log.Infof("Saving the service account key to: %s\n", tokenCacheFilename)
+	return writeTokenFile(tokenCacheFilename, key)
+}
+
+// checkServiceAccountKey mints a token with key, to find invalid or revoked keys at login.
+var checkServiceAccountKey = func(ctx context.Context, key []byte) error {
+	config, err := google.JWTConfigFromJSON(key, scopes...)
+	if err != nil {
 		return err
 	}
+	if _, err := config.TokenSource(ctx).Token(); err != nil {
+		return fmt.Errorf("can not get a token for the service account: %v", err)
+	}
+	return nil
+}
+
+// AuthExternalAccount checks that config is the configuration of an external account of Workload
+// Identity Federation which can be exchanged for tokens, and saves it in place of the token of a
+// user, so that the CLI authenticates through the external account, e.g. from a CI job without
+// a long-lived key. tokenFilepath can be set to "" if not otherwise defined.
+func AuthExternalAccount(ctx context.Context, config []byte, tokenFilepath string) error {
+	if !isExternalAccount(config) {
+		return errors.New(`the credential source is not the configuration of an external account (of type "external_account")`)
+	}
+	tokenCacheFilename := tokenFilepath
+	if tokenCacheFilename == "" {
+		var err error
+		if tokenCacheFilename, err = tokenCacheFile(); err != nil {
+			return err
+		}
+	}
+	if exists(tokenCacheFilename) {
+		return errors.New(`already logged in. run "gactions logout" first`)
+	}
+	ctx, err := withTransport(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkExternalAccount(ctx, config); err != nil {
+		return err
+	}
+	log.Infof("Saving the external account configuration to: %s\n", tokenCacheFilename)
+	return writeTokenFile(tokenCacheFilename, config)
+}
+
+// checkExternalAccount exchanges the credentials of config for a token, to find misconfigured
+// workload identity pools at login.
+var checkExternalAccount = func(ctx context.Context, config []byte) error {
+	creds, err := google.CredentialsFromJSON(ctx, config, scopes...)
+	if err != nil {
+		return err
+	}
+	if _, err := creds.TokenSource.Token(); err != nil {
+		return fmt.Errorf("can not get a token for the external account: %v", err)
+	}
 	return nil
 }
 
 // RemoveToken deletes the stored token
-func RemoveToken() error {
+func RemoveToken(ctx context.Context) error {
 	s, err := tokenCacheFile()
 	if err != nil {
 		return err
 	}
-	return RemoveTokenWithFilename(s)
+	return RemoveTokenWithFilename(ctx, s)
 }
 
-func RemoveTokenWithFilename(filename string) error {
+// RemoveTokenWithFilename deletes the token stored in filename, and revokes it with the
+// transport set by WithTransport on ctx.
+func RemoveTokenWithFilename(ctx context.Context, filename string) error {
 	if !exists(filename) {
 		log.Outf("Already logged out.")
 		return errors.New("already logged out")
 	}
-	b, err := ioutil.ReadFile(filename)
+	raw, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return err
 	}
+	b, err := readTokenFile(filename)
+	if err != nil {
+		// The token can't be revoked, but it's still removed.
+		log.Warnf("Can not read the token to revoke it: %v\n", err)
+	}
 	log.Infof("Removing %s\n", filename)
-	if err := os.Remove(filename); err != nil {
+	if err := removeTokenFile(filename, raw); err != nil {
 		return err
 	}
 	log.Infof("Successfully removed %s\n", filename)
-	return revokeToken(b)
+	if b == nil || isServiceAccountKey(b) || isExternalAccount(b) {
+		// Keys of service accounts are revoked by deleting them from the service account, and
+		// external accounts have no long-lived credentials.
+		return nil
+	}
+	return revokeToken(ctx, b)
 }
 
-var revokeToken = func(file []byte) error {
+var revokeToken = func(ctx context.Context, file []byte) error {
 	type tokenFile struct {
 		AccessToken  string `json:"access_token"`
 		RefreshToken string `json:"refresh_token"`
@@ -164,6 +515,11 @@ var revokeToken = func(file []byte) error {
 	if err := json.Unmarshal(file, &out); err != nil {
 		return err
 	}
+	ctx, err := withTransport(ctx)
+	if err != nil {
+		return err
+	}
+	client := ctx.Value(oauth2.HTTPClient).(*http.Client)
 	// Revokes an access token or if it's expired, revokes the refresh token
 	// If the token has expired, been tampered with, or had its permissions revoked,
 	// Google's authorization server returns an error message in the JSON object.
@@ -179,10 +535,15 @@ var revokeToken = func(file []byte) error {
 		}
 		log.Infof("Attempt %v: revoking a token.\n", i)
 		url := fmt.Sprintf("https://accounts.google.com/o/oauth2/revoke?token=%s", token)
-		resp, err := http.Get(url)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			return err
 		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
 		if resp.StatusCode == 200 {
 			log.Infof("Attempt %v: successfully revoked a token.\n", i)
 			break
@@ -208,25 +569,46 @@ var token = func(ctx context.Context, config *oauth2.Config, tokenCacheFilename
 	return tok, err
 }
 
+// grantToken prompts the user for a new token with the scopes of config, e.g. when a command
+// needs a scope which wasn't granted at login.
+var grantToken = func(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	if launchBrowser() {
+		return interactiveTokenWeb(ctx, config)
+	}
+	return interactiveTokenCopyPaste(ctx, config)
+}
+
+// launchBrowser returns whether the user can be sent to a browser launched by the CLI, rather
+// than copy and paste the URL and the authorization code.
+func launchBrowser() bool {
+	// Check the shell is appropriate for use of launched browsers, otherwise present the copy/paste
+	// flow.
+	return checkShell() && runtime.GOOS != "windows"
+}
+
 // Checks if the shell is not SSH.
 func checkShell() bool {
 	// https://en.wikibooks.org/wiki/OpenSSH/Client_Applications
 	return len(os.Getenv("SSH_CLIENT")) == 0
 }
 
-// tokenFromFile retrieves a Token from a given file path.
-// It returns the retrieved Token and any read error encountered.
+// tokenFromFile retrieves a Token from a given file path, or from the keyring if the file refers
+// to it. It returns the retrieved Token and any read error encountered.
 func tokenFromFile(file string) (*oauth2.Token, error) {
-	b, err := ioutil.ReadFile(file)
+	b, err := readTokenFile(file)
 	if err != nil {
 		return nil, err
 	}
+	return parseToken(b)
+}
+
+// parseToken returns the token saved in b.
+func parseToken(b []byte) (*oauth2.Token, error) {
 	t := &oauth2.Token{}
-	err = json.Unmarshal(b, t)
-	if err != nil {
+	if err := json.Unmarshal(b, t); err != nil {
 		return nil, err
 	}
-	return t, err
+	return t, nil
 }
 
 // interactiveToken gets OAuth2 token from an authorization code received from the user.
@@ -357,17 +739,39 @@ var interactiveTokenWeb = func(ctx context.Context, configIn *oauth2.Config) (*o
 }
 
 // saveToken uses a file path to create a file and store the
-// token in it.
-func saveToken(file string, token *oauth2.Token) error {
+// token in it. An existing file is kept.
+func saveToken(file string, token *oauth2.Token, clientSecret []byte, scopes []string) error {
 	if exists(file) {
 		return nil
 	}
+	return writeToken(file, token, clientSecret, scopes)
+}
+
+// writeToken writes token and the scopes granted to it to file, or to the keyring as
+// writeTokenFile does. If clientSecret isn't nil, it's saved with the token.
+func writeToken(file string, token *oauth2.Token, clientSecret []byte, scopes []string) error {
 	log.Infof("Saving credential file to: %s\n", file)
-	tokenJSON, err := json.Marshal(token)
+	tokenJSON, err := json.Marshal(struct {
+		*oauth2.Token
+		ClientSecret json.RawMessage `json:"clientSecret,omitempty"`
+		Scopes       []string        `json:"scopes,omitempty"`
+	}{token, clientSecret, scopes})
 	if err != nil {
 		return fmt.Errorf("unable to marshal token into json: %v", err)
 	}
-	return ioutil.WriteFile(file, tokenJSON, 0644)
+	return writeTokenFile(file, tokenJSON)
+}
+
+// tokenClientSecret returns the client secret saved with the token in b by AuthWithClient, or
+// nil if the token was issued to the client of the CLI.
+func tokenClientSecret(b []byte) []byte {
+	var t struct {
+		ClientSecret json.RawMessage `json:"clientSecret"`
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil
+	}
+	return t.ClientSecret
 }
 
 // exists returns whether the given file or directory exists or not
@@ -378,6 +782,50 @@ func exists(path string) bool {
 	return true
 }
 
+// ProfileTokenFile returns the path of the file with the token of the login profile. Tokens of
+// profiles are kept in ~/.credentials/gactions/<profile>.json. If profile is empty, it returns
+// the path of the default token.
+func ProfileTokenFile(profile string) (string, error) {
+	if profile == "" {
+		return tokenCacheFile()
+	}
+	if strings.ContainsAny(profile, `/\`) || profile == "." || profile == ".." {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+	def, err := tokenCacheFile()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(def), "gactions")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profile+".json"), nil
+}
+
+// Profiles returns the names of the login profiles with a saved token, sorted by name. The
+// default credentials are returned as the profile "", if they are saved.
+func Profiles() ([]string, error) {
+	def, err := tokenCacheFile()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	if exists(def) {
+		res = append(res, "")
+	}
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(def), "gactions", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".json"))
+	}
+	sort.Strings(names)
+	return append(res, names...), nil
+}
+
 // tokenCacheFile generates credential file path/filename.
 // It returns the generated credential path/filename.
 var tokenCacheFile = func() (string, error) {
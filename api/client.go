@@ -0,0 +1,122 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client lets Go programs push, deploy and pull Actions SDK projects like the gactions
+// CLI does. Unlike the CLI, the client doesn't print anything, and returns the results of the
+// requests instead.
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Options configure a Client.
+type Options struct {
+	// TokenSource provides the OAuth2 tokens of the requests, which must have the scope
+	// apiutils.ActionsScope. If it's nil, Application Default Credentials are used.
+	TokenSource oauth2.TokenSource
+	// Endpoint is the URL of the Actions API, e.g. "https://actions.googleapis.com". It must use
+	// HTTPS, unless it's on localhost. If it's empty, the production API is called.
+	Endpoint string
+	// Consumer identifies the caller to Google, like the --consumer flag of the CLI.
+	Consumer string
+	// UserAgent replaces the user agent of the CLI in the requests, if it's not empty.
+	UserAgent string
+}
+
+// Client calls the Actions API on behalf of a Go program.
+type Client struct {
+	hc  *http.Client
+	cfg *sdk.Config
+}
+
+// Preview is the result of WritePreview.
+type Preview struct {
+	// SimulatorURL is the URL to test the preview in the simulator. It's empty if the files were
+	// only validated.
+	SimulatorURL string
+	// Issues are the issues the server found in the files.
+	Issues []sdk.ValidationIssue
+}
+
+// New returns a Client configured with opts.
+func New(ctx context.Context, opts Options) (*Client, error) {
+	ts := opts.TokenSource
+	if ts == nil {
+		var err error
+		if ts, err = google.DefaultTokenSource(ctx, apiutils.ActionsScope); err != nil {
+			return nil, err
+		}
+	}
+	cfg := sdk.NewConfig()
+	if opts.Endpoint != "" {
+		if err := cfg.SetAPIEndpoint(opts.Endpoint); err != nil {
+			return nil, err
+		}
+	}
+	cfg.Consumer = opts.Consumer
+	cfg.UserAgent = opts.UserAgent
+	base, err := cfg.NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	hc := &http.Client{Transport: &oauth2.Transport{Source: ts, Base: base}}
+	return &Client{hc: hc, cfg: cfg}, nil
+}
+
+// LoadProject returns the project in the directory root. If projectID is empty, the ID is read
+// from settings/settings.yaml.
+func LoadProject(root, projectID string) (project.Project, error) {
+	p := studio.New(nil, root)
+	if err := p.SetProjectID(projectID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WriteDraft writes the files of proj to the draft of the project, or only validates them if
+// validateOnly is true. It returns the issues found by the server.
+func (c *Client) WriteDraft(ctx context.Context, proj project.Project, validateOnly bool) ([]sdk.ValidationIssue, error) {
+	return sdk.WriteDraft(sdk.WithConfig(ctx, c.cfg), c.hc, proj, validateOnly)
+}
+
+// WritePreview deploys the files of proj for preview, or only validates them if validateOnly is
+// true. The preview uses the sandbox for transactions if sandbox is true.
+func (c *Client) WritePreview(ctx context.Context, proj project.Project, sandbox, validateOnly bool) (*Preview, error) {
+	simulatorURL, issues, err := sdk.WritePreview(sdk.WithConfig(ctx, c.cfg), c.hc, proj, sandbox, validateOnly)
+	if err != nil {
+		return nil, err
+	}
+	return &Preview{SimulatorURL: simulatorURL, Issues: issues}, nil
+}
+
+// CreateVersion creates a version of the project from the files of proj, and deploys it to
+// channel, e.g. sdk.ProdChannel. It returns the ID of the version.
+func (c *Client) CreateVersion(ctx context.Context, proj project.Project, channel string) (string, error) {
+	return sdk.CreateVersion(sdk.WithConfig(ctx, c.cfg), c.hc, proj, channel)
+}
+
+// ReadDraft returns the files of the draft of the project with projectID, keyed by their paths
+// in the project.
+func (c *Client) ReadDraft(ctx context.Context, projectID string) (map[string][]byte, error) {
+	return sdk.ReadDraft(sdk.WithConfig(ctx, c.cfg), c.hc, projectID)
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/oauth2"
+)
+
+// newTestServer returns a server of the Actions API which responds to draft:write and draft:read,
+// and records the headers of the last request in got.
+func newTestServer(t *testing.T, got *http.Header) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/projects/my-project/draft:write", func(w http.ResponseWriter, r *http.Request) {
+		*got = r.Header.Clone()
+		ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, `{"name": "projects/my-project/draft", "validationResults": {"results": [{"validationMessage": "Your app must have a 32x32 logo", "validationContext": {"languageCode": "en"}}]}}`)
+	})
+	mux.HandleFunc("/v2/projects/my-project/draft:read", func(w http.ResponseWriter, r *http.Request) {
+		*got = r.Header.Clone()
+		fmt.Fprint(w, `[{"files": {"configFiles": {"configFiles": [{"filePath": "settings/settings.yaml", "settings": {"projectId": "my-project"}}]}}}]`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T, endpoint string) *Client {
+	t.Helper()
+	c, err := New(context.Background(), Options{
+		TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+		Endpoint:    endpoint,
+		Consumer:    "release-tool",
+		UserAgent:   "release-tool/1.0",
+	})
+	if err != nil {
+		t.Fatalf("New returned %v, want %v", err, nil)
+	}
+	return c
+}
+
+func TestWriteDraft(t *testing.T) {
+	var got http.Header
+	srv := newTestServer(t, &got)
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"manifest.yaml":          "version: \"1.0\"\n",
+		"settings/settings.yaml": "projectId: my-project\n",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proj, err := LoadProject(root, "")
+	if err != nil {
+		t.Fatalf("LoadProject returned %v, want %v", err, nil)
+	}
+	issues, err := newTestClient(t, srv.URL).WriteDraft(context.Background(), proj, false)
+	if err != nil {
+		t.Fatalf("WriteDraft returned %v, want %v", err, nil)
+	}
+	want := []sdk.ValidationIssue{{Locale: "en", Message: "Your app must have a 32x32 logo"}}
+	if diff := cmp.Diff(want, issues); diff != "" {
+		t.Errorf("WriteDraft returned incorrect issues: diff (-want, +got)\n%s", diff)
+	}
+	for k, v := range map[string]string{
+		"Authorization":     "Bearer token",
+		"Gactions-Consumer": "release-tool",
+		"User-Agent":        "release-tool/1.0",
+	} {
+		if got.Get(k) != v {
+			t.Errorf("WriteDraft sent %v header %q, want %q", k, got.Get(k), v)
+		}
+	}
+}
+
+func TestReadDraft(t *testing.T) {
+	var got http.Header
+	srv := newTestServer(t, &got)
+	files, err := newTestClient(t, srv.URL).ReadDraft(context.Background(), "my-project")
+	if err != nil {
+		t.Fatalf("ReadDraft returned %v, want %v", err, nil)
+	}
+	want := map[string][]byte{"settings/settings.yaml": []byte("projectId: my-project\n")}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("ReadDraft returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestNewInvalidEndpoint(t *testing.T) {
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})
+	for _, endpoint := range []string{"actions.googleapis.com", "ftp://example.com", "https://", "http://example.com"} {
+		if _, err := New(context.Background(), Options{TokenSource: ts, Endpoint: endpoint}); err == nil {
+			t.Errorf("New with endpoint %q returned %v, want an error", endpoint, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/actions-on-google/gactions/log"
+)
+
+// gzipRejected holds the hosts which rejected a compressed request, so the following requests to
+// them are sent uncompressed.
+var gzipRejected sync.Map
+
+// gzipBody compresses body with gzip while it's read. Closing it also closes body, which
+// unblocks the writer of a streamed body.
+type gzipBody struct {
+	*io.PipeReader
+	body io.ReadCloser
+}
+
+func newGzipBody(body io.ReadCloser) *gzipBody {
+	r, w := io.Pipe()
+	go func() {
+		// Data files are base64 encoded in the requests, so even the compressed formats, such as
+		// PNG or MP3, shrink by about a quarter. The best speed keeps the compression from
+		// slowing down the upload on fast links.
+		zw, err := gzip.NewWriterLevel(w, gzip.BestSpeed)
+		if err == nil {
+			_, err = io.Copy(zw, body)
+		}
+		if err == nil {
+			err = zw.Close()
+		}
+		w.CloseWithError(err)
+	}()
+	return &gzipBody{PipeReader: r, body: body}
+}
+
+func (b *gzipBody) Close() error {
+	b.body.Close()
+	return b.PipeReader.Close()
+}
+
+// compressRequest returns a copy of req, a streamed request of files, which sends its body
+// compressed with gzip.
+func compressRequest(req *http.Request) *http.Request {
+	creq := req.Clone(req.Context())
+	creq.Body = newGzipBody(req.Body)
+	creq.GetBody = func() (io.ReadCloser, error) {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		return newGzipBody(body), nil
+	}
+	creq.Header.Set("Content-Encoding", "gzip")
+	return creq
+}
+
+// doStream sends req, a streamed request of files whose GetBody streams the files again, with
+// client. The body is compressed with gzip if CompressUploads of the Config of the context of req
+// is set, unless the host rejected
+// a compressed request before. If the server rejects the compressed body with HTTP 415, as
+// RFC 7694 recommends, the files are sent again uncompressed.
+func doStream(client *http.Client, req *http.Request) (*http.Response, error) {
+	if _, rejected := gzipRejected.Load(req.URL.Host); !ConfigFrom(req.Context()).CompressUploads || rejected || req.GetBody == nil {
+		return client.Do(req)
+	}
+	resp, err := client.Do(compressRequest(req))
+	if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		return resp, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	// The files may still be written to the body if the server responded early; closing it
+	// unblocks the writer.
+	req.Body.Close()
+	gzipRejected.Store(req.URL.Host, true)
+	log.Infof("Server doesn't accept compressed requests. Sending the files uncompressed.\n")
+	next := req.Clone(req.Context())
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return client.Do(next)
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type sentBody struct {
+	Encoding string
+	Body     string
+}
+
+// newEncodingServer returns a server which records the bodies it receives, decompressed, and
+// rejects compressed bodies with 415 unless acceptGzip is true.
+func newEncodingServer(t *testing.T, acceptGzip bool, got *[]sentBody) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := r.Header.Get("Content-Encoding")
+		var body io.Reader = r.Body
+		if enc == "gzip" {
+			if !acceptGzip {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("server received an invalid gzip body: %v", err)
+				return
+			}
+			body = zr
+		}
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Errorf("server couldn't read the body: %v", err)
+		}
+		*got = append(*got, sentBody{Encoding: enc, Body: string(b)})
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		u, _ := url.Parse(srv.URL)
+		gzipRejected.Delete(u.Host)
+	})
+	return srv
+}
+
+func TestDoStream(t *testing.T) {
+	write := func(w *io.PipeWriter) error {
+		_, err := w.Write([]byte("[chunk]"))
+		w.Close()
+		return err
+	}
+	tests := []struct {
+		name       string
+		compress   bool
+		acceptGzip bool
+		want       []sentBody
+	}{
+		{
+			name:       "compressed",
+			compress:   true,
+			acceptGzip: true,
+			want:       []sentBody{{Encoding: "gzip", Body: "[chunk]"}, {Encoding: "gzip", Body: "[chunk]"}},
+		},
+		{
+			name:     "sent uncompressed after 415",
+			compress: true,
+			want:     []sentBody{{Body: "[chunk]"}, {Body: "[chunk]"}},
+		},
+		{
+			name:       "compression disabled",
+			acceptGzip: true,
+			want:       []sentBody{{Body: "[chunk]"}, {Body: "[chunk]"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := WithConfig(context.Background(), &Config{Env: Prod, CompressUploads: tc.compress})
+			var got []sentBody
+			srv := newEncodingServer(t, tc.acceptGzip, &got)
+			// The second request checks that a rejection of gzip is remembered.
+			for i := 0; i < 2; i++ {
+				getBody := streamBody(write)
+				body, err := getBody()
+				if err != nil {
+					t.Fatal(err)
+				}
+				req, err := http.NewRequestWithContext(ctx, "POST", srv.URL, body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.GetBody = getBody
+				resp, err := doStream(srv.Client(), req)
+				if err != nil {
+					t.Fatalf("doStream returned %v, want %v", err, nil)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("doStream returned status %v, want %v", resp.StatusCode, http.StatusOK)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("doStream sent incorrect bodies: diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+// gzipRejectingTransport answers compressed requests with 415 without reading them, and reads
+// uncompressed requests in full.
+type gzipRejectingTransport struct {
+	encodings []string
+}
+
+func (t *gzipRejectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	enc := req.Header.Get("Content-Encoding")
+	t.encodings = append(t.encodings, enc)
+	if enc == "gzip" {
+		return &http.Response{StatusCode: http.StatusUnsupportedMediaType, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	if _, err := ioutil.ReadAll(req.Body); err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(`{"name": "projects/my-project/draft"}`)), Request: req}, nil
+}
+
+func TestWriteDraftFallsBackToUncompressed(t *testing.T) {
+	p := NewMock(map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: my-project"),
+		"manifest.yaml":          []byte("version: 1.0"),
+	})
+	tr := &gzipRejectingTransport{}
+	// The mock project is pushed to a host of its own, so the rejection isn't remembered for the
+	// hosts of other tests.
+	ctx := WithConfig(context.Background(), &Config{Env: Prod, APIEndpoint: "http://localhost:1", CompressUploads: true})
+	defer gzipRejected.Delete("localhost:1")
+	if _, err := writeDraft(ctx, &http.Client{Transport: tr}, "my-project", p, false); err != nil {
+		t.Fatalf("writeDraft returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff([]string{"gzip", ""}, tr.encodings); diff != "" {
+		t.Errorf("writeDraft sent requests with incorrect encodings: diff (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/versions"
+)
+
+// APIEndpointEnv is the environment variable with the URL of the Actions API which replaces the
+// API of the environment, like the --api-endpoint flag.
+const APIEndpointEnv = "GACTIONS_API_ENDPOINT"
+
+// Config holds the environment the requests are sent to, and how the caller is identified to
+// Google. Each command of the CLI, and each Client of the client package, has its own Config,
+// which is carried by the context of its requests, so several environments can be used in one
+// process.
+type Config struct {
+	// Env selects the hosts of the Actions API and the Actions Console, e.g. Prod.
+	Env string
+	// APIEndpoint is the URL of the Actions API which replaces the API of Env, e.g. of a staging
+	// backend or of a mock server. It's set by SetAPIEndpoint.
+	APIEndpoint string
+	// Consumer identifies the caller to Google in the Gactions-Consumer header, if it isn't empty.
+	Consumer string
+	// UserAgent is the user agent of the requests. If it's empty, the user agent of the CLI is
+	// used.
+	UserAgent string
+	// Profile is the login profile whose credentials are used instead of the profile bound to
+	// the project in .gactionsrc.yaml, if it isn't empty.
+	Profile string
+	// UseADC makes the requests fall back to Application Default Credentials, such as the
+	// credentials of gcloud, the metadata server of GCE or workload identity, when no token of
+	// "gactions login" is cached.
+	UseADC bool
+	// MaxAttempts is the maximum number of times a request is sent when the server rejects it
+	// with a transient error.
+	MaxAttempts int
+	// CompressUploads makes pushes and deploys send the files compressed with gzip to servers
+	// which accept it. It's off by default, as the Actions API isn't known to accept compressed
+	// requests.
+	CompressUploads bool
+	// Proxy is the URL of the proxy of the requests. If it's empty, the proxy is set by the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	Proxy string
+	// CABundle is a file of PEM certificates of CAs trusted in addition to the CAs of the system,
+	// e.g. the CA of a proxy which intercepts TLS.
+	CABundle string
+}
+
+// defaultMaxAttempts is the MaxAttempts of NewConfig.
+const defaultMaxAttempts = 5
+
+// NewConfig returns a Config of the production environment.
+func NewConfig() *Config {
+	return &Config{Env: Prod, MaxAttempts: defaultMaxAttempts}
+}
+
+type configKey struct{}
+
+// WithConfig returns a copy of ctx which carries cfg to the requests made with it.
+func WithConfig(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, configKey{}, cfg)
+}
+
+// ConfigFrom returns the Config carried by ctx, or a new Config of the production environment if
+// ctx doesn't carry one.
+func ConfigFrom(ctx context.Context) *Config {
+	if cfg, ok := ctx.Value(configKey{}).(*Config); ok {
+		return cfg
+	}
+	return NewConfig()
+}
+
+// SetAPIEndpoint sets the URL of the Actions API which replaces the API of Env. The URL must use
+// HTTPS, unless its host is localhost or a loopback address, e.g. http://localhost:8080 of
+// "gactions mock-server".
+func (c *Config) SetAPIEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("API endpoint must be a URL, e.g. https://actions.googleapis.com, got %q", endpoint)
+	}
+	if u.Scheme == "http" && !isLoopback(u.Hostname()) {
+		return fmt.Errorf("API endpoint must use HTTPS unless it's on localhost, got %q", endpoint)
+	}
+	c.APIEndpoint = strings.TrimSuffix(u.String(), "/")
+	return nil
+}
+
+// NewTransport returns the transport of the requests, which uses Proxy and trusts the CAs in
+// CABundle.
+func (c *Config) NewTransport() (*http.Transport, error) {
+	return apiutils.NewTransport(c.Proxy, c.CABundle)
+}
+
+// WithTransport returns a copy of ctx which makes the requests for tokens and the clients of
+// apiutils use the transport of the Config carried by ctx.
+func WithTransport(ctx context.Context) (context.Context, error) {
+	t, err := ConfigFrom(ctx).NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	return apiutils.WithTransport(ctx, t), nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// apiHost returns the host of the Actions API.
+func (c *Config) apiHost() string {
+	if c.APIEndpoint != "" {
+		if u, err := url.Parse(c.APIEndpoint); err == nil {
+			return u.Host
+		}
+	}
+	return urlMap[c.Env]["apiURL"]
+}
+
+// apiAddr returns the URL of the Actions API, without a trailing slash.
+func (c *Config) apiAddr() string {
+	if c.APIEndpoint != "" {
+		return c.APIEndpoint
+	}
+	return "https://" + urlMap[c.Env]["apiURL"]
+}
+
+// consoleAddr returns the URL of the Actions Console, without a trailing slash.
+func (c *Config) consoleAddr() string {
+	return "https://" + urlMap[c.Env]["consoleURL"]
+}
+
+func (c *Config) userAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return fmt.Sprintf("gactions/%s (%s %s)", versions.CliVersion, runtime.GOOS, runtime.GOARCH)
+}
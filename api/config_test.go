@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestConfigFrom(t *testing.T) {
+	if got := ConfigFrom(context.Background()); got.Env != Prod {
+		t.Errorf("ConfigFrom returned Env %q for a context without a Config, want %q", got.Env, Prod)
+	}
+	cfg := &Config{Env: "staging"}
+	if got := ConfigFrom(WithConfig(context.Background(), cfg)); got != cfg {
+		t.Errorf("ConfigFrom returned %v, want %v", got, cfg)
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	proxy := "http://proxy.example.com:3128"
+	ctx, err := WithTransport(WithConfig(context.Background(), &Config{Env: Prod, Proxy: proxy}))
+	if err != nil {
+		t.Fatalf("WithTransport returned %v, want %v", err, nil)
+	}
+	hc, ok := ctx.Value(oauth2.HTTPClient).(*http.Client)
+	if !ok {
+		t.Fatalf("WithTransport returned a context without a client for oauth2")
+	}
+	req, err := http.NewRequest("GET", "https://actions.googleapis.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err := hc.Transport.(*http.Transport).Proxy(req); err != nil || u.String() != proxy {
+		t.Errorf("Proxy of the transport returned (%v, %v), want (%v, %v)", u, err, proxy, nil)
+	}
+	if _, err := WithTransport(WithConfig(context.Background(), &Config{Env: Prod, Proxy: "proxy.example.com"})); err == nil {
+		t.Errorf("WithTransport returned %v for a proxy which isn't a URL, but want an error", err)
+	}
+}
+
+func TestHTTPAddr(t *testing.T) {
+	urlMap["staging"] = map[string]string{"apiURL": "staging-actions.example.com", "consoleURL": "staging-console.example.com"}
+	defer delete(urlMap, "staging")
+	prod := context.Background()
+	staging := WithConfig(context.Background(), &Config{Env: "staging"})
+	if got, want := httpAddr(prod, "v2/sampleProjects"), "https://"+actionsProdURL+"/v2/sampleProjects"; got != want {
+		t.Errorf("httpAddr returned %v for prod, want %v", got, want)
+	}
+	if got, want := httpAddr(staging, "v2/sampleProjects"), "https://staging-actions.example.com/v2/sampleProjects"; got != want {
+		t.Errorf("httpAddr returned %v for staging, want %v", got, want)
+	}
+}
+
+func TestAddClientHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          *Config
+		wantConsumer string
+		wantUA       string
+	}{
+		{
+			name:   "default",
+			cfg:    NewConfig(),
+			wantUA: "gactions/",
+		},
+		{
+			name:         "consumer and user agent",
+			cfg:          &Config{Env: Prod, Consumer: "release-tool", UserAgent: "release-tool/1.0"},
+			wantConsumer: "release-tool",
+			wantUA:       "release-tool/1.0",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(WithConfig(context.Background(), tc.cfg), "GET", "https://example.com", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			addClientHeaders(req)
+			if got := req.Header.Get("Gactions-Consumer"); got != tc.wantConsumer {
+				t.Errorf("addClientHeaders set Gactions-Consumer to %q, want %q", got, tc.wantConsumer)
+			}
+			if got := req.Header.Get("User-Agent"); !strings.HasPrefix(got, tc.wantUA) {
+				t.Errorf("addClientHeaders set User-Agent to %q, want prefix %q", got, tc.wantUA)
+			}
+		})
+	}
+}
+
+func TestSetAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{endpoint: "https://staging-actions.example.com/", want: "https://staging-actions.example.com"},
+		{endpoint: "http://localhost:8080", want: "http://localhost:8080"},
+		{endpoint: "http://127.0.0.1:8080", want: "http://127.0.0.1:8080"},
+		{endpoint: "http://[::1]:8080", want: "http://[::1]:8080"},
+		{endpoint: "http://staging-actions.example.com", wantErr: true},
+		{endpoint: "staging-actions.example.com", wantErr: true},
+		{endpoint: "ftp://localhost", wantErr: true},
+	}
+	for _, tc := range tests {
+		cfg := NewConfig()
+		err := cfg.SetAPIEndpoint(tc.endpoint)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("SetAPIEndpoint(%q) returned %v, want error %v", tc.endpoint, err, tc.wantErr)
+			continue
+		}
+		if cfg.APIEndpoint != tc.want {
+			t.Errorf("SetAPIEndpoint(%q) set APIEndpoint to %q, want %q", tc.endpoint, cfg.APIEndpoint, tc.want)
+		}
+	}
+}
+
+func TestAPIEndpointHosts(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.SetAPIEndpoint("http://localhost:8080"); err != nil {
+		t.Fatalf("SetAPIEndpoint returned %v, want %v", err, nil)
+	}
+	ctx := WithConfig(context.Background(), cfg)
+	if got, want := httpAddr(ctx, "v2/sampleProjects"), "http://localhost:8080/v2/sampleProjects"; got != want {
+		t.Errorf("httpAddr returned %v, want %v", got, want)
+	}
+	if got := Hosts(ctx); got[0] != "localhost:8080" {
+		t.Errorf("Hosts returned %v, want the API host localhost:8080", got)
+	}
+}
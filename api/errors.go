@@ -0,0 +1,130 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrorKind is the class of failure of an error returned by the SDK, so callers can tell, e.g.,
+// a missing login from an unreachable server without parsing the message.
+type ErrorKind int
+
+const (
+	// UnknownError is any error which doesn't fall in the classes below.
+	UnknownError ErrorKind = iota
+	// AuthError is a missing login, an expired or revoked token, or a denied request.
+	AuthError
+	// ValidationError is a request or files rejected by the server as invalid, or validation
+	// issues found by the server when the caller asked to fail on them.
+	ValidationError
+	// NetworkError is a server which can't be reached, or a connection which failed.
+	NetworkError
+	// QuotaError is a request rejected because a quota of the project was exceeded.
+	QuotaError
+	// NotFoundError is a project, version or release channel which doesn't exist.
+	NotFoundError
+)
+
+// String returns the name of k shown to users.
+func (k ErrorKind) String() string {
+	switch k {
+	case AuthError:
+		return "authentication"
+	case ValidationError:
+		return "validation"
+	case NetworkError:
+		return "network"
+	case QuotaError:
+		return "quota"
+	case NotFoundError:
+		return "not found"
+	}
+	return "unknown"
+}
+
+// Error is an error of the SDK classified by its Kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// statusKind returns the kind of an error response of the server with the HTTP status code. A
+// bad request isn't a validation error by its status alone, see publicErrorKind.
+func statusKind(code int) ErrorKind {
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return AuthError
+	case http.StatusNotFound:
+		return NotFoundError
+	case http.StatusTooManyRequests:
+		return QuotaError
+	}
+	return UnknownError
+}
+
+// publicErrorKind returns the kind of the error response e of the server. A bad request is a
+// validation error only if the server reported what's invalid in the details of the error,
+// e.g. the field violations of the files. Other bad requests, such as a request the CLI built
+// incorrectly, are unknown errors.
+func publicErrorKind(e *PublicError) ErrorKind {
+	if e.Error.Code != http.StatusBadRequest {
+		return statusKind(e.Error.Code)
+	}
+	for _, d := range e.Error.Details {
+		t, _ := d["@type"].(string)
+		if strings.HasSuffix(t, "google.rpc.BadRequest") || strings.Contains(t, "ValidationResult") {
+			return ValidationError
+		}
+	}
+	return UnknownError
+}
+
+// KindOf returns the kind of err. Errors which aren't an *Error are classified by their type:
+// failed token refreshes are auth errors, and other failed connections are network errors.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	var ve *ValidationFailedError
+	if errors.As(err, &ve) {
+		return ValidationError
+	}
+	// A failed token refresh is returned by the transport, so it must be checked before other
+	// errors of the connection.
+	var re *oauth2.RetrieveError
+	if errors.As(err, &re) {
+		return AuthError
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return NetworkError
+	}
+	return UnknownError
+}
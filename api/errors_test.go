@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "plain", err: errors.New("failed"), want: UnknownError},
+		{name: "typed", err: &Error{Kind: QuotaError, Err: errors.New("quota")}, want: QuotaError},
+		{name: "wrapped", err: fmt.Errorf("deploy: %w", &Error{Kind: NotFoundError, Err: errors.New("not found")}), want: NotFoundError},
+		{name: "validation issues", err: CheckValidationIssues([]ValidationIssue{{Message: "Your app must have a 32x32 logo"}}), want: ValidationError},
+		{name: "connection", err: &url.Error{Op: "Post", URL: "https://actions.googleapis.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: NetworkError},
+		{name: "token refresh", err: &url.Error{Op: "Post", URL: "https://actions.googleapis.com", Err: &oauth2.RetrieveError{}}, want: AuthError},
+	}
+	for _, tc := range tests {
+		if got := KindOf(tc.err); got != tc.want {
+			t.Errorf("KindOf returned %v for the %v error, want %v", got, tc.name, tc.want)
+		}
+	}
+}
+
+func TestParseErrorKind(t *testing.T) {
+	tests := []struct {
+		body string
+		want ErrorKind
+	}{
+		{body: `{"error": {"code": 400, "message": "Invalid argument", "details": [{"@type": "type.googleapis.com/google.rpc.BadRequest", "fieldViolations": [{"description": "Invalid intent"}]}]}}`, want: ValidationError},
+		{body: `{"error": {"code": 400, "message": "Invalid argument"}}`, want: UnknownError},
+		{body: `{"error": {"code": 401, "message": "Unauthenticated"}}`, want: AuthError},
+		{body: `{"error": {"code": 403, "message": "Permission denied"}}`, want: AuthError},
+		{body: `{"error": {"code": 404, "message": "Not found"}}`, want: NotFoundError},
+		{body: `{"error": {"code": 429, "message": "Quota exceeded"}}`, want: QuotaError},
+		{body: `{"error": {"code": 500, "message": "Internal error"}}`, want: UnknownError},
+		{body: "<html>Not found</html>", want: UnknownError},
+	}
+	for _, tc := range tests {
+		if got := KindOf(parseError([]byte(tc.body))); got != tc.want {
+			t.Errorf("parseError(%v) returned an error of kind %v, want %v", tc.body, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+)
+
+func auditLogName(projectID string) string {
+	return fmt.Sprintf("projects/%v/logs/%v", projectID, auditLogID)
+}
+
+// recordHistory records an operation on proj in the local history, and in Cloud Logging if it is
+// enabled in the CLI config. The operation already succeeded, so failures are only reported.
+func recordHistory(ctx context.Context, client *http.Client, proj project.Project, operation, version, channel string) {
+	files, err := proj.FileRefs()
+	if err != nil {
+		log.Warnf("Failed to record this %v in the history: %v\n", operation, err)
+		return
+	}
+	digests, err := studio.FileDigests(files)
+	if err != nil {
+		log.Warnf("Failed to record this %v in the history: %v\n", operation, err)
+		return
+	}
+	recordHistoryEntry(ctx, client, proj, studio.NewHistoryEntryDigests(operation, proj.ProjectID(), version, channel, digests))
+}
+
+// recordHistoryFiles is like recordHistory, but records files instead of the files of proj.
+func recordHistoryFiles(ctx context.Context, client *http.Client, proj project.Project, files map[string][]byte, operation, version, channel string) {
+	recordHistoryEntry(ctx, client, proj, studio.NewHistoryEntry(operation, proj.ProjectID(), version, channel, files))
+}
+
+// recordHistoryEntry appends e to the local history of proj and, if enabled, to Cloud Logging.
+func recordHistoryEntry(ctx context.Context, client *http.Client, proj project.Project, e studio.HistoryEntry) {
+	operation := e.Operation
+	if proj.ProjectRoot() != "" {
+		if history, err := studio.ReadHistory(proj.ProjectRoot()); err == nil {
+			e.SetChanges(history)
+		} else {
+			log.Warnf("Failed to read the history, so the changes of this %v are not recorded: %v\n", operation, err)
+		}
+		if err := studio.AppendHistory(proj.ProjectRoot(), e); err != nil {
+			log.Warnf("Failed to record this %v in the history: %v\n", operation, err)
+		}
+	}
+	cfg, err := studio.ReadCLIConfig()
+	if err != nil {
+		log.Warnf("Failed to read %v: %v\n", project.ConfigName, err)
+		return
+	}
+	if !cfg.CloudAuditLog {
+		return
+	}
+	if err := writeCloudHistory(ctx, client, e); err != nil {
+		log.Warnf("Failed to record this %v in Cloud Logging: %v\n", operation, err)
+	}
+}
+
+// postLogging sends a request to Cloud Logging on behalf of the project with projectID.
+func postLogging(ctx context.Context, client *http.Client, projectID, endpoint string, body interface{}) ([]byte, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://"+loggingURL+"/"+endpoint, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Goog-User-Project", projectID)
+	addClientHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	res, err := readBodyWithTimeout(resp.Body, responseBodyReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == 403 {
+		log.Errorf(`Can't access Cloud Logging of the project %q. Check that the Cloud Logging API is enabled. If you logged in before Cloud Logging support was added, run "gactions logout" and "gactions login" to grant access to Cloud Logging.`, projectID)
+	}
+	if resp.StatusCode != 200 {
+		return nil, parseError(res)
+	}
+	return res, nil
+}
+
+func writeCloudHistory(ctx context.Context, client *http.Client, e studio.HistoryEntry) error {
+	body := map[string]interface{}{
+		"logName": auditLogName(e.ProjectID),
+		"resource": map[string]interface{}{
+			"type":   "global",
+			"labels": map[string]string{"project_id": e.ProjectID},
+		},
+		"entries": []interface{}{
+			map[string]interface{}{
+				"timestamp":   e.Time,
+				"severity":    "NOTICE",
+				"jsonPayload": e,
+			},
+		},
+	}
+	_, err := postLogging(ctx, client, e.ProjectID, "v2/entries:write", body)
+	return err
+}
+
+func parseCloudHistory(body []byte) ([]studio.HistoryEntry, error) {
+	type logEntry struct {
+		JSONPayload studio.HistoryEntry `json:"jsonPayload"`
+	}
+	type listResponse struct {
+		Entries []logEntry `json:"entries"`
+	}
+	r := listResponse{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	var res []studio.HistoryEntry
+	// Entries are listed newest first, but the history is kept oldest first.
+	for i := len(r.Entries) - 1; i >= 0; i-- {
+		res = append(res, r.Entries[i].JSONPayload)
+	}
+	return res, nil
+}
+
+// ReadCloudHistoryJSON returns up to limit of the latest entries of the history of proj recorded
+// in Cloud Logging, oldest entry first.
+func ReadCloudHistoryJSON(ctx context.Context, proj project.Project, limit int) ([]studio.HistoryEntry, error) {
+	client, err := setupClient(ctx, proj, apiutils.LoggingReadScope)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	body, err := postLogging(ctx, client, projectID, "v2/entries:list", map[string]interface{}{
+		"resourceNames": []string{"projects/" + projectID},
+		"filter":        fmt.Sprintf("logName=%q", auditLogName(projectID)),
+		"orderBy":       "timestamp desc",
+		"pageSize":      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseCloudHistory(body)
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCloudHistory(t *testing.T) {
+	body := []byte(`{
+  "entries": [
+    {"logName": "projects/hello-world/logs/gactions-audit", "jsonPayload": {"operation": "deploy", "projectId": "hello-world", "version": "2", "channel": "prod"}},
+    {"logName": "projects/hello-world/logs/gactions-audit", "jsonPayload": {"operation": "push", "projectId": "hello-world", "version": "draft"}}
+  ]
+}`)
+	want := []studio.HistoryEntry{
+		{Operation: "push", ProjectID: "hello-world", Version: "draft"},
+		{Operation: "deploy", ProjectID: "hello-world", Version: "2", Channel: "prod"},
+	}
+	got, err := parseCloudHistory(body)
+	if err != nil {
+		t.Errorf("parseCloudHistory returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseCloudHistory returned incorrect entries: diff (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/actions-on-google/gactions/log"
+)
+
+// Keyring is a credential store of the operating system: the macOS Keychain, the Windows
+// Credential Manager or a Secret Service of libsecret. A token kept in the keyring is saved under
+// the path of its token file, and the file holds a reference to the keyring instead of the token.
+type Keyring interface {
+	// Name is the name of the keyring in messages, e.g. "macOS Keychain".
+	Name() string
+	// Get returns the secret saved under key.
+	Get(key string) ([]byte, error)
+	// Set saves secret under key, replacing the secret saved before.
+	Set(key string, secret []byte) error
+	// Delete removes the secret saved under key.
+	Delete(key string) error
+}
+
+// OSKeyring is the keyring of the operating system, or nil if it isn't available, e.g. because
+// secret-tool of libsecret isn't installed.
+var OSKeyring = newOSKeyring()
+
+// UseKeyring makes login save tokens in OSKeyring instead of their token files. If the keyring
+// isn't available or fails, tokens are saved in the files.
+var UseKeyring = false
+
+// keyringService is the service the tokens are saved under in the keyring.
+const keyringService = "gactions"
+
+// keyringRef is saved in a token file in place of the token kept in the keyring.
+type keyringRef struct {
+	Keyring string `json:"keyring"`
+}
+
+// isKeyringRef returns whether b, the content of a token file, refers to the keyring.
+func isKeyringRef(b []byte) bool {
+	var r keyringRef
+	return json.Unmarshal(b, &r) == nil && r.Keyring != ""
+}
+
+// readTokenFile returns the token saved in file, which is read from the keyring if file refers
+// to it.
+func readTokenFile(file string) ([]byte, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil || !isKeyringRef(b) {
+		return b, err
+	}
+	if OSKeyring == nil {
+		return nil, fmt.Errorf("the token of %v is kept in the keyring of the operating system, which isn't available. try to run \"gactions logout\" and \"gactions login\" again", file)
+	}
+	return OSKeyring.Get(file)
+}
+
+// writeTokenFile saves b, a token or a service account key, in file. It's saved in the keyring
+// instead if UseKeyring is set, or if the token in file was already kept in the keyring. Only the
+// user can read the file.
+func writeTokenFile(file string, b []byte) error {
+	if old, err := ioutil.ReadFile(file); UseKeyring || (err == nil && isKeyringRef(old)) {
+		if OSKeyring == nil {
+			log.Warnf("The keyring of the operating system isn't available, so the token is saved in %v.\n", file)
+		} else if err := OSKeyring.Set(file, b); err != nil {
+			log.Warnf("Failed to save the token in %v, so it's saved in %v: %v\n", OSKeyring.Name(), file, err)
+		} else {
+			log.Infof("Saved the token of %v in %v.\n", file, OSKeyring.Name())
+			ref, err := json.Marshal(keyringRef{Keyring: OSKeyring.Name()})
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(file, ref, 0600)
+		}
+	}
+	// The token grants access to the Google account, so only the user can read it.
+	if err := ioutil.WriteFile(file, b, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, e.g. of a token saved by an older version.
+	return os.Chmod(file, 0600)
+}
+
+// removeTokenFile deletes file, and the token in the keyring it refers to.
+func removeTokenFile(file string, b []byte) error {
+	if isKeyringRef(b) && OSKeyring != nil {
+		if err := OSKeyring.Delete(file); err != nil {
+			log.Warnf("Failed to delete the token of %v from %v: %v\n", file, OSKeyring.Name(), err)
+		}
+	}
+	return os.Remove(file)
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychain saves the tokens in the macOS Keychain with the security command.
+type keychain struct{}
+
+func newOSKeyring() Keyring {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil
+	}
+	return keychain{}
+}
+
+func (keychain) Name() string {
+	return "macOS Keychain"
+}
+
+func (keychain) Get(key string) ([]byte, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keyringService, "-a", key, "-w").Output()
+	if err != nil {
+		return nil, fmt.Errorf("can not read the token of %v from the keychain: %v", key, err)
+	}
+	return bytes.TrimSuffix(out, []byte("\n")), nil
+}
+
+func (k keychain) Set(key string, secret []byte) error {
+	// The secret is sent in hex on stdin of the interactive mode, so it isn't visible in the
+	// arguments of the process.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %q -X %s\n", keyringService, key, hex.EncodeToString(secret)))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	// The interactive mode doesn't fail when a command fails, so the secret is read back.
+	if got, err := k.Get(key); err != nil || !bytes.Equal(got, secret) {
+		return errors.New(strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (keychain) Delete(key string) error {
+	if out, err := exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", key).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
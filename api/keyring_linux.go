@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutils
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// secretService saves the tokens in a Secret Service, such as GNOME Keyring or KWallet, with
+// secret-tool of libsecret.
+type secretService struct{}
+
+func newOSKeyring() Keyring {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil
+	}
+	return secretService{}
+}
+
+func (secretService) Name() string {
+	return "Secret Service"
+}
+
+func (secretService) Get(key string) ([]byte, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", keyringService, "account", key).Output()
+	if err != nil {
+		return nil, fmt.Errorf("can not read the token of %v from the Secret Service: %v", key, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("the token of %v isn't in the Secret Service", key)
+	}
+	return out, nil
+}
+
+func (secretService) Set(key string, secret []byte) error {
+	// secret-tool reads the secret from stdin, so it isn't visible in the arguments of the process.
+	cmd := exec.Command("secret-tool", "store", "--label", "gactions token", "service", keyringService, "account", key)
+	cmd.Stdin = bytes.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+func (secretService) Delete(key string) error {
+	if out, err := exec.Command("secret-tool", "clear", "service", keyringService, "account", key).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
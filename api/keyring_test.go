@@ -0,0 +1,174 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutils
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/oauth2"
+)
+
+// fakeKeyring keeps the secrets in memory. It fails to save them if failSet is true.
+type fakeKeyring struct {
+	secrets map[string][]byte
+	failSet bool
+}
+
+func (k *fakeKeyring) Name() string {
+	return "fake keyring"
+}
+
+func (k *fakeKeyring) Get(key string) ([]byte, error) {
+	b, ok := k.secrets[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return b, nil
+}
+
+func (k *fakeKeyring) Set(key string, secret []byte) error {
+	if k.failSet {
+		return errors.New("keyring is locked")
+	}
+	k.secrets[key] = secret
+	return nil
+}
+
+func (k *fakeKeyring) Delete(key string) error {
+	delete(k.secrets, key)
+	return nil
+}
+
+func withKeyring(t *testing.T, k Keyring, use bool) {
+	t.Helper()
+	ogKeyring, ogUse, ogRevoke := OSKeyring, UseKeyring, revokeToken
+	t.Cleanup(func() {
+		OSKeyring, UseKeyring, revokeToken = ogKeyring, ogUse, ogRevoke
+	})
+	OSKeyring, UseKeyring = k, use
+	revokeToken = func(context.Context, []byte) error { return nil }
+}
+
+func TestWriteTokenKeyring(t *testing.T) {
+	k := &fakeKeyring{secrets: map[string][]byte{}}
+	withKeyring(t, k, true)
+	file := filepath.Join(t.TempDir(), "token.json")
+	want := &oauth2.Token{AccessToken: "123", RefreshToken: "456"}
+	if err := writeToken(file, want, nil, scopes); err != nil {
+		t.Fatalf("writeToken returned %v, want %v", err, nil)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read %v: %v", file, err)
+	}
+	if !isKeyringRef(b) {
+		t.Errorf("writeToken wrote %s to the token file, want a reference to the keyring", b)
+	}
+	if _, ok := k.secrets[file]; !ok {
+		t.Errorf("writeToken didn't save the token in the keyring")
+	}
+	got, err := tokenFromFile(file)
+	if err != nil {
+		t.Fatalf("tokenFromFile returned %v, want %v", err, nil)
+	}
+	if !cmp.Equal(got, want, cmpopts.IgnoreUnexported(oauth2.Token{})) {
+		t.Errorf("tokenFromFile returned %v, want %v", got, want)
+	}
+	// A token refreshed with more scopes stays in the keyring without UseKeyring.
+	UseKeyring = false
+	if err := writeToken(file, want, nil, withScopes(scopes, []string{SheetsScope})); err != nil {
+		t.Fatalf("writeToken returned %v, want %v", err, nil)
+	}
+	if b, _ := ioutil.ReadFile(file); !isKeyringRef(b) {
+		t.Errorf("writeToken moved the token out of the keyring to %v", file)
+	}
+	if err := RemoveTokenWithFilename(context.Background(), file); err != nil {
+		t.Fatalf("RemoveTokenWithFilename returned %v, want %v", err, nil)
+	}
+	if _, ok := k.secrets[file]; ok {
+		t.Errorf("RemoveTokenWithFilename didn't delete the token from the keyring")
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("RemoveTokenWithFilename didn't delete %v", file)
+	}
+}
+
+func TestWriteTokenFallsBackToFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyring Keyring
+	}{
+		{name: "no keyring", keyring: nil},
+		{name: "keyring fails", keyring: &fakeKeyring{secrets: map[string][]byte{}, failSet: true}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			withKeyring(t, tc.keyring, true)
+			file := filepath.Join(t.TempDir(), "token.json")
+			if err := writeToken(file, &oauth2.Token{AccessToken: "123"}, nil, scopes); err != nil {
+				t.Fatalf("writeToken returned %v, want %v", err, nil)
+			}
+			got, err := tokenFromFile(file)
+			if err != nil {
+				t.Fatalf("tokenFromFile returned %v, want %v", err, nil)
+			}
+			if got.AccessToken != "123" {
+				t.Errorf("tokenFromFile returned access token %q, want %q", got.AccessToken, "123")
+			}
+		})
+	}
+}
+
+func TestWriteTokenMode(t *testing.T) {
+	withKeyring(t, nil, false)
+	file := filepath.Join(t.TempDir(), "token.json")
+	// A token saved by an older version could be read by other users.
+	if err := ioutil.WriteFile(file, []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write %v: %v", file, err)
+	}
+	if err := writeToken(file, &oauth2.Token{AccessToken: "123"}, nil, scopes); err != nil {
+		t.Fatalf("writeToken returned %v, want %v", err, nil)
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Failed to stat %v: %v", file, err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("writeToken saved the token with mode %v, want %v", fi.Mode().Perm(), os.FileMode(0600))
+	}
+}
+
+func TestNewHTTPClientKeyring(t *testing.T) {
+	k := &fakeKeyring{secrets: map[string][]byte{}}
+	withKeyring(t, k, true)
+	file := filepath.Join(t.TempDir(), "token.json")
+	if err := writeToken(file, &oauth2.Token{AccessToken: "123", RefreshToken: "456"}, nil, scopes); err != nil {
+		t.Fatalf("writeToken returned %v, want %v", err, nil)
+	}
+	if _, err := NewHTTPClient(context.Background(), []byte(`{"installed":{"redirect_uris":["http://localhost"]}}`), file, false); err != nil {
+		t.Errorf("NewHTTPClient returned %v with the token in the keyring, want %v", err, nil)
+	}
+	OSKeyring = nil
+	if _, err := NewHTTPClient(context.Background(), []byte(`{"installed":{"redirect_uris":["http://localhost"]}}`), file, false); err == nil {
+		t.Errorf("NewHTTPClient returned %v when the keyring isn't available, want an error", err)
+	}
+}
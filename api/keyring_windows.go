@@ -0,0 +1,117 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiutils
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	// credMaxBlobSize is the maximum size of the secret of a generic credential.
+	credMaxBlobSize = 5 * 512
+)
+
+// credential is CREDENTIALW of wincred.h.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// credentialManager saves the tokens as generic credentials of the Windows Credential Manager.
+type credentialManager struct{}
+
+func newOSKeyring() Keyring {
+	if advapi32.Load() != nil {
+		return nil
+	}
+	return credentialManager{}
+}
+
+func (credentialManager) Name() string {
+	return "Windows Credential Manager"
+}
+
+// target returns the name of the credential of key.
+func target(key string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keyringService + ":" + key)
+}
+
+func (credentialManager) Get(key string) ([]byte, error) {
+	t, err := target(key)
+	if err != nil {
+		return nil, err
+	}
+	var c *credential
+	if r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(t)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&c))); r == 0 {
+		return nil, fmt.Errorf("can not read the token of %v from the Credential Manager: %v", key, err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(c)))
+	blob := (*[credMaxBlobSize]byte)(unsafe.Pointer(c.CredentialBlob))[:c.CredentialBlobSize:c.CredentialBlobSize]
+	return append([]byte{}, blob...), nil
+}
+
+func (credentialManager) Set(key string, secret []byte) error {
+	if len(secret) == 0 || len(secret) > credMaxBlobSize {
+		return fmt.Errorf("the token is %v bytes, but the Credential Manager keeps 1 to %v bytes", len(secret), credMaxBlobSize)
+	}
+	t, err := target(key)
+	if err != nil {
+		return err
+	}
+	c := credential{
+		Type:               credTypeGeneric,
+		TargetName:         t,
+		CredentialBlobSize: uint32(len(secret)),
+		CredentialBlob:     &secret[0],
+		Persist:            credPersistLocalMachine,
+	}
+	if r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&c)), 0); r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (credentialManager) Delete(key string) error {
+	t, err := target(key)
+	if err != nil {
+		return err
+	}
+	if r, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(t)), credTypeGeneric, 0); r == 0 {
+		return err
+	}
+	return nil
+}
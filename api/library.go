@@ -0,0 +1,92 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/actions-on-google/gactions/api/request"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// The functions below are used by Go programs which embed the SDK through the client package.
+// Unlike the functions used by the commands, they take the HTTP client to send the requests
+// with, and return their results instead of printing them.
+
+// silentKey marks the context of a request which must not print anything.
+type silentKey struct{}
+
+func withSilent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, silentKey{}, true)
+}
+
+// silent reports whether the request of ctx must not print anything.
+func silent(ctx context.Context) bool {
+	v, _ := ctx.Value(silentKey{}).(bool)
+	return v
+}
+
+// WriteDraft writes the files of proj to the draft of the project, or only validates them if
+// validateOnly is true. It returns the issues found by the server.
+func WriteDraft(ctx context.Context, client *http.Client, proj project.Project, validateOnly bool) ([]ValidationIssue, error) {
+	results, err := writeDraft(withSilent(ctx), client, proj.ProjectID(), proj, validateOnly)
+	if err != nil {
+		return nil, err
+	}
+	return validationIssues(results), nil
+}
+
+// WritePreview deploys the files of proj for preview, or only validates them if validateOnly is
+// true. It returns the URL of the simulator, which is empty if the files were only validated, and
+// the issues found by the server.
+func WritePreview(ctx context.Context, client *http.Client, proj project.Project, sandbox, validateOnly bool) (string, []ValidationIssue, error) {
+	simulatorURL, results, err := writePreview(withSilent(ctx), client, proj.ProjectID(), proj, sandbox, validateOnly)
+	if err != nil {
+		return "", nil, err
+	}
+	return simulatorURL, validationIssues(results), nil
+}
+
+// CreateVersion creates a version of the project from the files of proj, and deploys it to
+// channel. It returns the ID of the version.
+func CreateVersion(ctx context.Context, client *http.Client, proj project.Project, channel string) (string, error) {
+	ctx = withSilent(ctx)
+	src, err := withDecryptedValues(ctx, client, proj)
+	if err != nil {
+		return "", err
+	}
+	return createVersion(ctx, client, proj.ProjectID(), src, channel)
+}
+
+// ReadDraft returns the files of the draft of the project with projectID, keyed by their paths
+// in the project. The client secret of account linking is returned encrypted.
+func ReadDraft(ctx context.Context, client *http.Client, projectID string) (map[string][]byte, error) {
+	body, err := json.Marshal(request.ReadDraft(projectID, ""))
+	if err != nil {
+		return nil, err
+	}
+	stream, err := openStream(ctx, client, httpAddr(ctx, readDraftHTTPEndpoint(projectID)), body, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	files, _, err := filesFromStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// LogQuery selects the entries written to Cloud Logging by the inline cloud functions of a project.
+type LogQuery struct {
+	// Functions are the names of the cloud functions.
+	Functions []string
+	// Since is the time of the oldest entry.
+	Since time.Time
+	// Severity is the lowest severity of the entries, e.g. ERROR. All entries match if it's empty.
+	Severity string
+	// Text is searched for in all fields of the entries, e.g. the ID of a conversation.
+	Text string
+}
+
+// LogEntry is an entry written to Cloud Logging by a cloud function.
+type LogEntry struct {
+	InsertID    string
+	Timestamp   time.Time
+	Severity    string
+	Function    string
+	ExecutionID string
+	Message     string
+}
+
+// logFilter returns the Cloud Logging filter of the entries selected by q.
+func logFilter(q LogQuery) string {
+	var names []string
+	for _, v := range q.Functions {
+		names = append(names, strconv.Quote(v))
+	}
+	res := []string{
+		`resource.type="cloud_function"`,
+		fmt.Sprintf("resource.labels.function_name=(%v)", strings.Join(names, " OR ")),
+		fmt.Sprintf("timestamp>=%q", q.Since.UTC().Format(time.RFC3339Nano)),
+	}
+	if q.Severity != "" {
+		res = append(res, "severity>="+q.Severity)
+	}
+	if q.Text != "" {
+		res = append(res, strconv.Quote(q.Text))
+	}
+	return strings.Join(res, " AND ")
+}
+
+// parseLogEntries returns the entries and the next page token of a response of entries:list.
+func parseLogEntries(body []byte) ([]LogEntry, string, error) {
+	type logEntry struct {
+		InsertID    string                 `json:"insertId"`
+		Timestamp   time.Time              `json:"timestamp"`
+		Severity    string                 `json:"severity"`
+		TextPayload string                 `json:"textPayload"`
+		JSONPayload map[string]interface{} `json:"jsonPayload"`
+		Labels      map[string]string      `json:"labels"`
+		Resource    struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"resource"`
+	}
+	type listResponse struct {
+		Entries       []logEntry `json:"entries"`
+		NextPageToken string     `json:"nextPageToken"`
+	}
+	r := listResponse{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, "", err
+	}
+	var res []LogEntry
+	for _, v := range r.Entries {
+		msg := v.TextPayload
+		if v.JSONPayload != nil {
+			// Structured logs of the functions framework keep the text in the message field.
+			if s, ok := v.JSONPayload["message"].(string); ok {
+				msg = s
+			} else if b, err := json.Marshal(v.JSONPayload); err == nil {
+				msg = string(b)
+			}
+		}
+		severity := v.Severity
+		if severity == "" {
+			severity = "DEFAULT"
+		}
+		res = append(res, LogEntry{
+			InsertID:    v.InsertID,
+			Timestamp:   v.Timestamp,
+			Severity:    severity,
+			Function:    v.Resource.Labels["function_name"],
+			ExecutionID: v.Labels["execution_id"],
+			Message:     strings.TrimRight(msg, "\n"),
+		})
+	}
+	return res, r.NextPageToken, nil
+}
+
+// ListLogsJSON returns the entries of Cloud Logging of proj selected by q, oldest entry first.
+func ListLogsJSON(ctx context.Context, proj project.Project, q LogQuery) ([]LogEntry, error) {
+	client, err := setupClient(ctx, proj, apiutils.LoggingReadScope)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	var res []LogEntry
+	pageToken := ""
+	for {
+		req := map[string]interface{}{
+			"resourceNames": []string{"projects/" + projectID},
+			"filter":        logFilter(q),
+			"orderBy":       "timestamp asc",
+			"pageSize":      1000,
+		}
+		if pageToken != "" {
+			req["pageToken"] = pageToken
+		}
+		body, err := postLogging(ctx, client, projectID, "v2/entries:list", req)
+		if err != nil {
+			return nil, err
+		}
+		entries, next, err := parseLogEntries(body)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entries...)
+		if next == "" {
+			return res, nil
+		}
+		pageToken = next
+	}
+}
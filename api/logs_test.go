@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogFilter(t *testing.T) {
+	q := LogQuery{
+		Functions: []string{"ActionsOnGoogleFulfillment", "other"},
+		Since:     time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC),
+		Severity:  "ERROR",
+		Text:      "ABwppHE",
+	}
+	want := `resource.type="cloud_function" AND resource.labels.function_name=("ActionsOnGoogleFulfillment" OR "other") AND timestamp>="2021-03-01T10:00:00Z" AND severity>=ERROR AND "ABwppHE"`
+	if got := logFilter(q); got != want {
+		t.Errorf("logFilter returned %v, want %v", got, want)
+	}
+	q.Severity, q.Text = "", ""
+	want = `resource.type="cloud_function" AND resource.labels.function_name=("ActionsOnGoogleFulfillment" OR "other") AND timestamp>="2021-03-01T10:00:00Z"`
+	if got := logFilter(q); got != want {
+		t.Errorf("logFilter returned %v, want %v", got, want)
+	}
+}
+
+func TestParseLogEntries(t *testing.T) {
+	body := []byte(`{
+  "entries": [
+    {"insertId": "a", "timestamp": "2021-03-01T10:00:00.5Z", "textPayload": "Function execution started", "resource": {"labels": {"function_name": "fulfillment"}}, "labels": {"execution_id": "x1"}},
+    {"insertId": "b", "timestamp": "2021-03-01T10:00:01Z", "severity": "ERROR", "jsonPayload": {"message": "TypeError: conv is undefined\n"}, "resource": {"labels": {"function_name": "fulfillment"}}},
+    {"insertId": "c", "timestamp": "2021-03-01T10:00:02Z", "severity": "INFO", "jsonPayload": {"scene": "Start"}}
+  ],
+  "nextPageToken": "next"
+}`)
+	want := []LogEntry{
+		{InsertID: "a", Timestamp: time.Date(2021, 3, 1, 10, 0, 0, 500000000, time.UTC), Severity: "DEFAULT", Function: "fulfillment", ExecutionID: "x1", Message: "Function execution started"},
+		{InsertID: "b", Timestamp: time.Date(2021, 3, 1, 10, 0, 1, 0, time.UTC), Severity: "ERROR", Function: "fulfillment", Message: "TypeError: conv is undefined"},
+		{InsertID: "c", Timestamp: time.Date(2021, 3, 1, 10, 0, 2, 0, time.UTC), Severity: "INFO", Message: `{"scene":"Start"}`},
+	}
+	got, next, err := parseLogEntries(body)
+	if err != nil {
+		t.Errorf("parseLogEntries returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseLogEntries returned incorrect entries: diff (-want, +got)\n%s", diff)
+	}
+	if next != "next" {
+		t.Errorf("parseLogEntries returned page token %q, want %q", next, "next")
+	}
+}
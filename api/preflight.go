@@ -0,0 +1,225 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/api/request"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+)
+
+// actionsPermissions are the IAM permissions checked by preflight, and the commands which need them.
+var actionsPermissions = []struct {
+	name   string
+	usedBy string
+}{
+	{"actions.agent.get", "pull"},
+	{"actions.agent.update", "push, deploy preview"},
+	{"actions.agentVersions.create", "deploy"},
+	{"actions.agentVersions.list", "versions list"},
+}
+
+// PreflightCheck is the result of a check which runs before commands that call the Actions API.
+type PreflightCheck struct {
+	Name   string
+	Passed bool
+	// Message describes the result of the check.
+	Message string
+	// Remediation is a command or an action which fixes a failed check.
+	Remediation string
+}
+
+// errorReason returns the reason of the error in an error response of a Google API, e.g.
+// SERVICE_DISABLED, or an empty string if the response has no reason.
+func errorReason(body []byte) string {
+	e := PublicError{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return ""
+	}
+	for _, d := range e.Error.Details {
+		if r, ok := d["reason"].(string); ok && r != "" {
+			return r
+		}
+	}
+	return ""
+}
+
+// doPreflightRequest sends a request for a preflight check, and returns the status code and the
+// body of the response.
+func doPreflightRequest(ctx context.Context, client *http.Client, method, requestURL string, body []byte, userProject string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	if userProject != "" {
+		req.Header.Add("X-Goog-User-Project", userProject)
+	}
+	addClientHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	res, err := readBodyWithTimeout(resp.Body, responseBodyReadTimeout)
+	if err != nil {
+		return 0, nil, err
+	}
+	log.Debugln(string(res))
+	return resp.StatusCode, res, nil
+}
+
+func loginCommand(profile string) string {
+	if profile != "" {
+		return fmt.Sprintf("gactions logout --profile %v && gactions login --profile %v", profile, profile)
+	}
+	return "gactions logout && gactions login"
+}
+
+func checkActionsAPI(ctx context.Context, client *http.Client, projectID string) PreflightCheck {
+	c := PreflightCheck{Name: "Actions API"}
+	code, body, err := doPreflightRequest(ctx, client, "GET", httpAddr(ctx, listReleaseChannelsHTTPEndpoint(projectID)), nil, projectID)
+	switch {
+	case err != nil:
+		c.Message = fmt.Sprintf("Can't reach %v: %v", ConfigFrom(ctx).apiHost(), err)
+		c.Remediation = "Check your network connection and proxy settings."
+	case code == http.StatusOK:
+		c.Passed = true
+		c.Message = fmt.Sprintf("The Actions API is enabled on %v.", projectID)
+	case code == http.StatusForbidden && errorReason(body) == "SERVICE_DISABLED":
+		c.Message = fmt.Sprintf("The Actions API is not enabled on %v.", projectID)
+		c.Remediation = fmt.Sprintf("gcloud services enable actions.googleapis.com --project=%v", projectID)
+	case code == http.StatusForbidden:
+		// The API is enabled, but the user can't list the release channels, which is reported
+		// by the check of the IAM permissions.
+		c.Passed = true
+		c.Message = fmt.Sprintf("The Actions API is enabled on %v.", projectID)
+	case code == http.StatusNotFound:
+		c.Message = fmt.Sprintf("The project %v doesn't exist.", projectID)
+		c.Remediation = "Check projectId in settings/settings.yaml."
+	default:
+		c.Message = fmt.Sprintf("The Actions API returned HTTP %v.\n%v", code, parseError(body))
+	}
+	return c
+}
+
+func checkPermissions(ctx context.Context, client *http.Client, projectID, profile string) PreflightCheck {
+	c := PreflightCheck{Name: "IAM permissions"}
+	var names []string
+	for _, v := range actionsPermissions {
+		names = append(names, v.name)
+	}
+	b, err := json.Marshal(map[string][]string{"permissions": names})
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	requestURL := fmt.Sprintf("https://%v/v1/projects/%v:testIamPermissions", resourceManagerURL, url.PathEscape(projectID))
+	code, body, err := doPreflightRequest(ctx, client, "POST", requestURL, b, "")
+	switch {
+	case err != nil:
+		c.Message = fmt.Sprintf("Can't reach %v: %v", resourceManagerURL, err)
+		c.Remediation = "Check your network connection and proxy settings."
+		return c
+	case code == http.StatusForbidden && errorReason(body) == "ACCESS_TOKEN_SCOPE_INSUFFICIENT":
+		c.Message = "The CLI is not allowed to check your IAM permissions, because you logged in before preflight was added."
+		c.Remediation = loginCommand(profile)
+		return c
+	case code != http.StatusOK:
+		c.Message = fmt.Sprintf("Can't check the IAM permissions on %v.\n%v", projectID, parseError(body))
+		return c
+	}
+	var r struct {
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	granted := map[string]bool{}
+	for _, v := range r.Permissions {
+		granted[v] = true
+	}
+	var missing []string
+	role := "roles/actions.Viewer"
+	for _, v := range actionsPermissions {
+		if !granted[v.name] {
+			missing = append(missing, fmt.Sprintf("%v (%v)", v.name, v.usedBy))
+			if v.name == "actions.agent.update" || v.name == "actions.agentVersions.create" {
+				role = "roles/actions.Admin"
+			}
+		}
+	}
+	if len(missing) == 0 {
+		c.Passed = true
+		c.Message = fmt.Sprintf("You have the permissions to push and deploy %v.", projectID)
+		return c
+	}
+	c.Message = fmt.Sprintf("You don't have these permissions on %v: %v.", projectID, strings.Join(missing, ", "))
+	c.Remediation = fmt.Sprintf("gcloud projects add-iam-policy-binding %v --member=user:<your account> --role=%v", projectID, role)
+	return c
+}
+
+func checkDraftEndpoint(ctx context.Context, client *http.Client, projectID string) PreflightCheck {
+	c := PreflightCheck{Name: "Draft endpoint"}
+	body, err := json.Marshal(request.ReadDraft(projectID, ""))
+	if err != nil {
+		c.Message = err.Error()
+		return c
+	}
+	requestURL := httpAddr(ctx, readDraftHTTPEndpoint(projectID))
+	// Only the status of the response is checked, so the draft isn't read.
+	respBody, err := openStream(ctx, client, requestURL, body, projectID)
+	if err != nil {
+		c.Message = fmt.Sprintf("Can't read the draft of %v: %v", projectID, err)
+		c.Remediation = "Run the command with --verbose to see the full response."
+		return c
+	}
+	respBody.Close()
+	c.Passed = true
+	c.Message = fmt.Sprintf("The draft of %v is reachable.", projectID)
+	return c
+}
+
+// PreflightJSON checks that the Actions API is enabled on proj, the user has the IAM permissions
+// to push and deploy, and the draft endpoint is reachable.
+func PreflightJSON(ctx context.Context, proj project.Project) ([]PreflightCheck, error) {
+	client, err := setupClient(ctx, proj, apiutils.CloudPlatformReadOnlyScope)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := studio.ReadCLIConfig()
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	return []PreflightCheck{
+		checkActionsAPI(ctx, client, projectID),
+		checkPermissions(ctx, client, projectID, activeProfile(ctx, cfg)),
+		checkDraftEndpoint(ctx, client, projectID),
+	}, nil
+}
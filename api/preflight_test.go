@@ -0,0 +1,110 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type responseTransport struct {
+	status int
+	body   string
+}
+
+func (r responseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: r.status, Body: ioutil.NopCloser(strings.NewReader(r.body)), Request: req}, nil
+}
+
+func TestCheckActionsAPI(t *testing.T) {
+	tests := []struct {
+		name            string
+		resp            responseTransport
+		wantPassed      bool
+		wantRemediation string
+	}{
+		{
+			name:       "enabled",
+			resp:       responseTransport{status: http.StatusOK, body: "{}"},
+			wantPassed: true,
+		},
+		{
+			name:            "disabled",
+			resp:            responseTransport{status: http.StatusForbidden, body: `{"error": {"code": 403, "message": "Actions API has not been used in project", "details": [{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "SERVICE_DISABLED"}]}}`},
+			wantRemediation: "gcloud services enable actions.googleapis.com --project=my-project",
+		},
+		{
+			name:       "permission denied",
+			resp:       responseTransport{status: http.StatusForbidden, body: `{"error": {"code": 403, "message": "The caller does not have permission"}}`},
+			wantPassed: true,
+		},
+		{
+			name:            "not found",
+			resp:            responseTransport{status: http.StatusNotFound, body: `{"error": {"code": 404}}`},
+			wantRemediation: "Check projectId in settings/settings.yaml.",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := checkActionsAPI(context.Background(), &http.Client{Transport: tc.resp}, "my-project")
+			if got.Passed != tc.wantPassed || got.Remediation != tc.wantRemediation {
+				t.Errorf("checkActionsAPI returned %+v, want Passed %v and Remediation %q", got, tc.wantPassed, tc.wantRemediation)
+			}
+		})
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	tests := []struct {
+		name            string
+		resp            responseTransport
+		profile         string
+		wantPassed      bool
+		wantRemediation string
+	}{
+		{
+			name:       "all granted",
+			resp:       responseTransport{status: http.StatusOK, body: `{"permissions": ["actions.agent.get", "actions.agent.update", "actions.agentVersions.create", "actions.agentVersions.list"]}`},
+			wantPassed: true,
+		},
+		{
+			name:            "viewer",
+			resp:            responseTransport{status: http.StatusOK, body: `{"permissions": ["actions.agent.get"]}`},
+			wantRemediation: "gcloud projects add-iam-policy-binding my-project --member=user:<your account> --role=roles/actions.Admin",
+		},
+		{
+			name:            "missing list",
+			resp:            responseTransport{status: http.StatusOK, body: `{"permissions": ["actions.agent.get", "actions.agent.update", "actions.agentVersions.create"]}`},
+			wantRemediation: "gcloud projects add-iam-policy-binding my-project --member=user:<your account> --role=roles/actions.Viewer",
+		},
+		{
+			name:            "insufficient scope",
+			resp:            responseTransport{status: http.StatusForbidden, body: `{"error": {"code": 403, "details": [{"reason": "ACCESS_TOKEN_SCOPE_INSUFFICIENT"}]}}`},
+			profile:         "work",
+			wantRemediation: "gactions logout --profile work && gactions login --profile work",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := checkPermissions(context.Background(), &http.Client{Transport: tc.resp}, "my-project", tc.profile)
+			if got.Passed != tc.wantPassed || got.Remediation != tc.wantRemediation {
+				t.Errorf("checkPermissions returned %+v, want Passed %v and Remediation %q", got, tc.wantPassed, tc.wantRemediation)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// QuotaUsage is a quota limit of the Actions API on a project, and how much of it is used.
+type QuotaUsage struct {
+	// Metric is the name of the quota metric, e.g. actions.googleapis.com/default_requests.
+	Metric      string
+	DisplayName string
+	// Unit is the unit of the limit, e.g. 1/min/{project} or 1/d/{project}.
+	Unit string
+	// Limit is -1 if the quota is unlimited.
+	Limit int64
+	Usage int64
+}
+
+// PerDay returns true if the limit is reset daily, and false if it's a rate per minute.
+func (q QuotaUsage) PerDay() bool {
+	return strings.Contains(q.Unit, "/d/")
+}
+
+// getJSON sends a GET request to a Google API, and decodes the JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, requestURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return err
+	}
+	addClientHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := readBodyWithTimeout(resp.Body, responseBodyReadTimeout)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != 200 {
+		return parseError(body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// parseQuotaLimits returns the limits of the default buckets in a response of the
+// consumerQuotaMetrics endpoint of the Service Usage API.
+func parseQuotaLimits(body []byte) ([]QuotaUsage, error) {
+	type bucket struct {
+		EffectiveLimit string            `json:"effectiveLimit"`
+		Dimensions     map[string]string `json:"dimensions"`
+	}
+	type limit struct {
+		Unit         string   `json:"unit"`
+		Metric       string   `json:"metric"`
+		QuotaBuckets []bucket `json:"quotaBuckets"`
+	}
+	type metric struct {
+		Metric              string  `json:"metric"`
+		DisplayName         string  `json:"displayName"`
+		ConsumerQuotaLimits []limit `json:"consumerQuotaLimits"`
+	}
+	var r struct {
+		Metrics []metric `json:"metrics"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	var res []QuotaUsage
+	for _, m := range r.Metrics {
+		for _, l := range m.ConsumerQuotaLimits {
+			for _, b := range l.QuotaBuckets {
+				// Buckets with dimensions apply to a region or zone only.
+				if len(b.Dimensions) > 0 {
+					continue
+				}
+				// The limit is an int64, which is a string in JSON.
+				n, err := strconv.ParseInt(b.EffectiveLimit, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("limit of %v is not a number: %q", m.Metric, b.EffectiveLimit)
+				}
+				res = append(res, QuotaUsage{Metric: m.Metric, DisplayName: m.DisplayName, Unit: l.Unit, Limit: n})
+			}
+		}
+	}
+	return res, nil
+}
+
+// parseQuotaUsage returns the latest usage of each quota metric in a response of the timeSeries
+// endpoint of the Cloud Monitoring API.
+func parseQuotaUsage(body []byte) (map[string]int64, error) {
+	type point struct {
+		Value struct {
+			Int64Value string `json:"int64Value"`
+		} `json:"value"`
+	}
+	type series struct {
+		Metric struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metric"`
+		Points []point `json:"points"`
+	}
+	var r struct {
+		TimeSeries []series `json:"timeSeries"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	res := map[string]int64{}
+	for _, s := range r.TimeSeries {
+		// Points are listed newest first.
+		if len(s.Points) == 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(s.Points[0].Value.Int64Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("usage of %v is not a number: %q", s.Metric.Labels["quota_metric"], s.Points[0].Value.Int64Value)
+		}
+		res[s.Metric.Labels["quota_metric"]] += n
+	}
+	return res, nil
+}
+
+// quotaDayStart returns the start of the day of t in Pacific Time, when daily quotas are reset.
+func quotaDayStart(t time.Time) time.Time {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		// The time zone database is missing, so daylight saving time is ignored.
+		loc = time.FixedZone("PST", -8*60*60)
+	}
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// quotaUsageURL returns the URL which lists the usage of the quotas of the Actions API on the
+// project with projectID from start to end, summed up by quota metric.
+func quotaUsageURL(projectID string, start, end time.Time) string {
+	period := int64(end.Sub(start).Seconds())
+	if period < 60 {
+		period = 60
+	}
+	q := url.Values{}
+	q.Set("filter", `metric.type="serviceruntime.googleapis.com/quota/rate/net_usage" AND resource.type="consumer_quota" AND resource.label.service="`+actionsProdURL+`"`)
+	q.Set("interval.startTime", start.UTC().Format(time.RFC3339))
+	q.Set("interval.endTime", end.UTC().Format(time.RFC3339))
+	q.Set("aggregation.alignmentPeriod", fmt.Sprintf("%ds", period))
+	q.Set("aggregation.perSeriesAligner", "ALIGN_SUM")
+	q.Set("aggregation.crossSeriesReducer", "REDUCE_SUM")
+	q.Set("aggregation.groupByFields", "metric.label.quota_metric")
+	return fmt.Sprintf("https://%v/v3/projects/%v/timeSeries?%v", monitoringURL, url.PathEscape(projectID), q.Encode())
+}
+
+// ReadQuotaJSON returns the quota limits of the Actions API on proj, with the usage of the
+// current day for daily limits, and of the last minute for rates per minute. If the usage
+// can't be read from Cloud Monitoring, the limits are returned with a warning.
+func ReadQuotaJSON(ctx context.Context, proj project.Project) ([]QuotaUsage, error) {
+	client, err := setupClient(ctx, proj, apiutils.CloudPlatformReadOnlyScope)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	requestURL := fmt.Sprintf("https://%v/v1beta1/projects/%v/services/%v/consumerQuotaMetrics?view=BASIC", serviceUsageURL, url.PathEscape(projectID), actionsProdURL)
+	var raw json.RawMessage
+	if err := getJSON(ctx, client, requestURL, &raw); err != nil {
+		return nil, fmt.Errorf("can't read the quotas of %v: %v", projectID, err)
+	}
+	res, err := parseQuotaLimits(raw)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	daily, err := readQuotaUsage(ctx, client, quotaUsageURL(projectID, quotaDayStart(now), now))
+	if err != nil {
+		log.Warnf("Can't read the quota usage from Cloud Monitoring of %v, so only the limits are shown: %v\n", projectID, err)
+		return res, nil
+	}
+	perMinute, err := readQuotaUsage(ctx, client, quotaUsageURL(projectID, now.Add(-time.Minute), now))
+	if err != nil {
+		log.Warnf("Can't read the quota usage from Cloud Monitoring of %v, so only the limits are shown: %v\n", projectID, err)
+		return res, nil
+	}
+	for i, v := range res {
+		if v.PerDay() {
+			res[i].Usage = daily[v.Metric]
+		} else {
+			res[i].Usage = perMinute[v.Metric]
+		}
+	}
+	return res, nil
+}
+
+func readQuotaUsage(ctx context.Context, client *http.Client, requestURL string) (map[string]int64, error) {
+	var raw json.RawMessage
+	if err := getJSON(ctx, client, requestURL, &raw); err != nil {
+		return nil, err
+	}
+	return parseQuotaUsage(raw)
+}
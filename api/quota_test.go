@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseQuotaLimits(t *testing.T) {
+	body := `{
+  "metrics": [
+    {
+      "metric": "actions.googleapis.com/default_requests",
+      "displayName": "Requests",
+      "consumerQuotaLimits": [
+        {
+          "unit": "1/min/{project}",
+          "metric": "actions.googleapis.com/default_requests",
+          "quotaBuckets": [{"effectiveLimit": "600", "defaultLimit": "600"}]
+        },
+        {
+          "unit": "1/d/{project}",
+          "metric": "actions.googleapis.com/default_requests",
+          "quotaBuckets": [
+            {"effectiveLimit": "-1"},
+            {"effectiveLimit": "100", "dimensions": {"region": "us-central1"}}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	got, err := parseQuotaLimits([]byte(body))
+	if err != nil {
+		t.Fatalf("parseQuotaLimits returned %v, want %v", err, nil)
+	}
+	want := []QuotaUsage{
+		{Metric: "actions.googleapis.com/default_requests", DisplayName: "Requests", Unit: "1/min/{project}", Limit: 600},
+		{Metric: "actions.googleapis.com/default_requests", DisplayName: "Requests", Unit: "1/d/{project}", Limit: -1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseQuotaLimits returned diff (-want, +got)\n%s", diff)
+	}
+	if got[0].PerDay() || !got[1].PerDay() {
+		t.Errorf("PerDay returned %v and %v, want false and true", got[0].PerDay(), got[1].PerDay())
+	}
+	if _, err := parseQuotaLimits([]byte(`{"metrics": [{"consumerQuotaLimits": [{"quotaBuckets": [{"effectiveLimit": "many"}]}]}]}`)); err == nil {
+		t.Errorf("parseQuotaLimits returned nil for an invalid limit, want an error")
+	}
+}
+
+func TestParseQuotaUsage(t *testing.T) {
+	body := `{
+  "timeSeries": [
+    {
+      "metric": {"labels": {"quota_metric": "actions.googleapis.com/default_requests"}},
+      "points": [{"value": {"int64Value": "42"}}, {"value": {"int64Value": "7"}}]
+    },
+    {
+      "metric": {"labels": {"quota_metric": "actions.googleapis.com/write_requests"}},
+      "points": []
+    }
+  ]
+}`
+	got, err := parseQuotaUsage([]byte(body))
+	if err != nil {
+		t.Fatalf("parseQuotaUsage returned %v, want %v", err, nil)
+	}
+	want := map[string]int64{"actions.googleapis.com/default_requests": 42}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseQuotaUsage returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestQuotaDayStart(t *testing.T) {
+	now := time.Date(2021, 3, 1, 5, 30, 0, 0, time.UTC)
+	got := quotaDayStart(now)
+	// 05:30 UTC is still the previous day in Pacific Time.
+	if got.Day() != 28 || got.Hour() != 0 || now.Sub(got) <= 0 || now.Sub(got) > 24*time.Hour {
+		t.Errorf("quotaDayStart(%v) returned %v, want midnight of Feb 28 in Pacific Time", now, got)
+	}
+}
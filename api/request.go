@@ -16,15 +16,23 @@
 package request
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"mime"
+	"io"
+	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/actions-on-google/gactions/api/yamlutils"
 	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
 )
 
@@ -63,19 +71,29 @@ func ReadDraft(name, keyVersion string) map[string]interface{} {
 }
 
 // WriteDraft returns a map representing a WriteDraft request populated with name field.
-func WriteDraft(name string) map[string]interface{} {
-	return map[string]interface{}{
+// If validateOnly is true, the server validates the files without writing the draft.
+func WriteDraft(name string, validateOnly bool) map[string]interface{} {
+	v := map[string]interface{}{
 		"parent": fmt.Sprintf("projects/%v", name),
 	}
+	if validateOnly {
+		v["validateOnly"] = true
+	}
+	return v
 }
 
 // WritePreview returns a map representing a WriteDraft request populated with name and sandbox fields.
-func WritePreview(name string, sandbox bool) map[string]interface{} {
+// If validateOnly is true, the server validates the files without writing the preview or
+// deploying the cloud function.
+func WritePreview(name string, sandbox, validateOnly bool) map[string]interface{} {
 	v := map[string]interface{}{}
 	v["parent"] = fmt.Sprintf("projects/%v", name)
 	v["previewSettings"] = map[string]interface{}{
 		"sandbox": sandbox,
 	}
+	if validateOnly {
+		v["validateOnly"] = true
+	}
 	return v
 }
 
@@ -166,44 +184,130 @@ func addConfigFiles(req map[string]interface{}, configFiles map[string][]byte, r
 	return nil
 }
 
+// DataFile refers to a data file sent to SDK server. Payload of the file is read from disk
+// only when a request with the file is written, so the payloads of the files waiting in a
+// stream aren't held in memory.
+type DataFile struct {
+	// Path is a location of the file on disk. It's empty when the payload is kept in memory.
+	Path string
+	// Size is a size of the payload in bytes.
+	Size    int64
+	payload []byte
+}
+
+// NewDataFile returns a DataFile which refers to a file of the given size on disk.
+func NewDataFile(path string, size int64) DataFile {
+	return DataFile{Path: path, Size: size}
+}
+
+// InMemoryDataFile returns a DataFile with the payload held in memory. This is used for the
+// files generated by CLI, which don't exist on disk (i.e. zipped inline cloud functions).
+func InMemoryDataFile(payload []byte) DataFile {
+	return DataFile{Size: int64(len(payload)), payload: payload}
+}
+
+// InMemoryDataFiles converts each of the files into a DataFile with the payload held in memory.
+func InMemoryDataFiles(files map[string][]byte) map[string]DataFile {
+	m := map[string]DataFile{}
+	for k, v := range files {
+		m[k] = InMemoryDataFile(v)
+	}
+	return m
+}
+
+func (d DataFile) open() (io.ReadCloser, error) {
+	if d.Path == "" {
+		return ioutil.NopCloser(bytes.NewReader(d.payload)), nil
+	}
+	return os.Open(d.Path)
+}
+
+// MarshalJSON encodes the payload of d as a base-64 encoded string, same as for []byte.
+func (d DataFile) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('"')
+	if err := d.writeBase64(&b); err != nil {
+		return nil, err
+	}
+	b.WriteByte('"')
+	return b.Bytes(), nil
+}
+
+// copyBufPool holds buffers used to copy payloads of data files into requests.
+var copyBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 64*1024)
+		return &b
+	},
+}
+
+func (d DataFile) writeBase64(w io.Writer) error {
+	r, err := d.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	buf := copyBufPool.Get().(*[]byte)
+	defer copyBufPool.Put(buf)
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	// Hide io.WriterTo of r, so the pooled buffer is used for copying.
+	if _, err := io.CopyBuffer(enc, struct{ io.Reader }{r}, *buf); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// contentTypes maps the extensions of data files to their content types.
+var contentTypes = map[string]string{
+	".zip":  "application/zip;zip_type=cloud_function",
+	".flr":  "x-world/x-vrml",
+	".mp3":  "audio/mpeg",
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+}
+
+// SetContentType sets the content type of data files with the extension ext, such as ".glb".
+// It must not be called while files are streamed.
+func SetContentType(ext, contentType string) error {
+	if !strings.HasPrefix(ext, ".") || strings.Contains(ext, "/") {
+		return fmt.Errorf("%q is not a file extension, e.g. \".glb\"", ext)
+	}
+	if contentType == "" {
+		return fmt.Errorf("content type of %q is empty", ext)
+	}
+	contentTypes[strings.ToLower(ext)] = contentType
+	return nil
+}
+
+// ContentType returns the content type of the data file filename, and false if its extension
+// has no content type.
+func ContentType(filename string) (string, bool) {
+	v, ok := contentTypes[strings.ToLower(path.Ext(filename))]
+	return v, ok
+}
+
 // addDataFiles adds a data files from the chunk to a request.
-func addDataFiles(req map[string]interface{}, chunk map[string][]byte, root string) error {
+func addDataFiles(req map[string]interface{}, chunk map[string]DataFile, root string) error {
 	dfs := map[string][]interface{}{}
 	for filename, content := range chunk {
 		log.Infof("Adding %v to dataFiles request\n", filepath.Join(root, filename))
-		if path.Ext(filename) == ".zip" {
-			m := map[string]interface{}{
-				"filePath":    filename,
-				"contentType": "application/zip;zip_type=cloud_function",
-				"payload":     content,
-			}
-			dfs["dataFiles"] = append(dfs["dataFiles"], m)
-			continue
-		}
-		if path.Ext(filename) == ".flr" {
-			m := map[string]interface{}{
-				"filePath":    filename,
-				"contentType": "x-world/x-vrml",
-				"payload":     content,
-			}
-			dfs["dataFiles"] = append(dfs["dataFiles"], m)
-			continue
+		ct, ok := ContentType(filename)
+		if !ok {
+			return fmt.Errorf("can't determine the content type of %v. Add its extension to contentTypes in %v, e.g. %q: %q",
+				filepath.Join(root, filename), project.ConfigName, path.Ext(filename), "application/octet-stream")
 		}
-		mime := mime.TypeByExtension(path.Ext(filename))
-		switch mime {
-		case "audio/mpeg", "image/jpeg", "image/png", "audio/wav", "audio/x-wav":
-			{
-				m := map[string]interface{}{
-					"filePath":    filename,
-					"contentType": mime,
-					"payload":     content,
-				}
-				dfs["dataFiles"] = append(dfs["dataFiles"], m)
-			}
-		default:
-			log.Warnf("Can't recognize an extension for %v. The supported extensions are audio/mpeg, image/jpeg, " +
-				"image/png, audio/wav, audio/x-wav found %v", filepath.Join(root, filename), mime)
+		m := map[string]interface{}{
+			"filePath":    filename,
+			"contentType": ct,
+			"payload":     content,
 		}
+		dfs["dataFiles"] = append(dfs["dataFiles"], m)
 	}
 	if len(dfs) > 0 {
 		req["files"] = map[string]interface{}{
@@ -213,11 +317,95 @@ func addDataFiles(req map[string]interface{}, chunk map[string][]byte, root stri
 	return nil
 }
 
+// payloadPlaceholder prefixes strings that stand in for payloads while a request is marshalled
+// to JSON. A file path can't contain a NUL character, so the placeholder can't clash with it.
+const payloadPlaceholder = "\x00payload"
+
+// stripPayloads returns a copy of v where every payload (i.e. []byte or DataFile value) is
+// replaced with a placeholder string. The replaced values are appended to payloads in the
+// order of placeholder indices.
+func stripPayloads(v interface{}, payloads *[]DataFile) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		*payloads = append(*payloads, InMemoryDataFile(t))
+		return fmt.Sprintf("%s%d", payloadPlaceholder, len(*payloads)-1)
+	case DataFile:
+		*payloads = append(*payloads, t)
+		return fmt.Sprintf("%s%d", payloadPlaceholder, len(*payloads)-1)
+	case map[string]interface{}:
+		cp := map[string]interface{}{}
+		for k, v2 := range t {
+			cp[k] = stripPayloads(v2, payloads)
+		}
+		return cp
+	case map[string][]interface{}:
+		cp := map[string]interface{}{}
+		for k, v2 := range t {
+			cp[k] = stripPayloads(v2, payloads)
+		}
+		return cp
+	case []interface{}:
+		cp := make([]interface{}, len(t))
+		for i, v2 := range t {
+			cp[i] = stripPayloads(v2, payloads)
+		}
+		return cp
+	default:
+		return v
+	}
+}
+
+// WriteJSON writes JSON encoding of req to w and returns the number of bytes written.
+// It produces the same output as json.Marshal, except that payloads of data files are
+// read and base-64 encoded directly into w, instead of being held in memory.
+func WriteJSON(w io.Writer, req map[string]interface{}) (int64, error) {
+	var payloads []DataFile
+	b, err := json.Marshal(stripPayloads(req, &payloads))
+	if err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: w}
+	for i, p := range payloads {
+		// json.Marshal escapes NUL character as \u0000.
+		ph := []byte(fmt.Sprintf(`"\u0000payload%d"`, i))
+		pos := bytes.Index(b, ph)
+		if pos < 0 {
+			return cw.n, fmt.Errorf("payload %d is missing in the request", i)
+		}
+		if _, err := cw.Write(b[:pos+1]); err != nil {
+			return cw.n, err
+		}
+		if err := p.writeBase64(cw); err != nil {
+			return cw.n, err
+		}
+		// Keep the closing quote of the placeholder.
+		b = b[pos+len(ph)-1:]
+	}
+	_, err = cw.Write(b)
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
 // SDKStreamer provides an interface to obtain the next JSON request that needs to be sent to
 // SDK server during HTTP stream. SDK, ESF and GFE each have their own requirements on the
 // payload and this type implements them.
 type SDKStreamer struct {
-	files           map[string][]byte
+	// Silent disables the messages printed when the config files and the resources start
+	// to be sent.
+	Silent bool
+
+	configFiles     map[string][]byte
+	dataFiles       map[string]DataFile
 	sizes           map[string]int // sizes contains a size that a file occupies in a JSON request
 	dataFilenames   []string
 	configFilenames []string
@@ -230,34 +418,31 @@ type SDKStreamer struct {
 
 // NewStreamer returns an instance of SDKStreamer, initialized with all of the variables
 // from its arguments. Function expects configFiles to have at least base settings and manifest files.
-func NewStreamer(configFiles map[string][]byte, dataFiles map[string][]byte, makeRequest func() map[string]interface{}, root string, chunkSize int) SDKStreamer {
-	files := map[string][]byte{}
+func NewStreamer(configFiles map[string][]byte, dataFiles map[string]DataFile, makeRequest func() map[string]interface{}, root string, chunkSize int) SDKStreamer {
 	sizes := map[string]int{}
 	var cfgnames, dfnames []string
 
 	for k, v := range configFiles {
-		files[k] = v
 		cfgnames = append(cfgnames, k)
 		sizes[k] = len(v)
 	}
 	for k, v := range dataFiles {
-		files[k] = v
 		dfnames = append(dfnames, k)
-		// Marshal function of JSON library (https://golang.org/pkg/encoding/json/#Marshal) encodes
-		// []byte as a base-64 encoded string. This adds an extra memory overhead when the map is
-		// converted to JSON. Each DataFile is []byte, so this is a good approximation.
-		sizes[k] = len(base64.StdEncoding.EncodeToString(v))
+		// Payload of a data file is sent as a base-64 encoded string (see WriteJSON), which
+		// takes 4*ceil(n/3) bytes for n bytes of content.
+		sizes[k] = base64.StdEncoding.EncodedLen(int(v.Size))
 	}
 	// We need to sort config files and datafiles based on their size in bytes.
 	// However, settings and manifest files must be inside of the first request,
 	// so these two files take precedence.
-	sortConfigFiles(cfgnames, files, sizes)
+	sortConfigFiles(cfgnames, sizes)
 	sort.Slice(dfnames, func(i int, j int) bool {
 		return sizes[dfnames[i]] < sizes[dfnames[j]]
 	})
 
 	return SDKStreamer{
-		files:           files,
+		configFiles:     configFiles,
+		dataFiles:       dataFiles,
 		dataFilenames:   dfnames,
 		configFilenames: cfgnames,
 		makeRequest:     makeRequest,
@@ -267,7 +452,7 @@ func NewStreamer(configFiles map[string][]byte, dataFiles map[string][]byte, mak
 	}
 }
 
-func sortConfigFiles(cfgnames []string, files map[string][]byte, sizes map[string]int) {
+func sortConfigFiles(cfgnames []string, sizes map[string]int) {
 	var pos []int
 	for i, v := range cfgnames {
 		if studio.IsSettings(v) || studio.IsManifest(v) {
@@ -292,37 +477,98 @@ func moveToFront(a []string, ps []int) {
 
 // HasNext returns true if there is still another request in the stream.
 func (s SDKStreamer) HasNext() bool {
-	return (s.i + s.j) < len(s.files)
+	return (s.i + s.j) < len(s.configFiles)+len(s.dataFiles)
 }
 
-// nextChunk returns the next "chunk" of config files such that
-// the sum of the size of each individual config file in the chunk
+// Size returns the number of bytes of the files sent by the streamer, as they are encoded in
+// the requests, and the number of the files.
+func (s SDKStreamer) Size() (int64, int) {
+	var n int64
+	for _, v := range s.sizes {
+		n += int64(v)
+	}
+	return n, len(s.configFilenames) + len(s.dataFilenames)
+}
+
+// maxReportedFiles is the number of the largest files listed by CheckSizes.
+const maxReportedFiles = 10
+
+// CheckSizes returns an error if any file doesn't fit in a request of the stream on its own,
+// so a push fails before anything is sent instead of in the middle of the stream. The error
+// lists the files over the limit and the largest files of the project, as they are encoded
+// in the requests.
+func (s SDKStreamer) CheckSizes() error {
+	names := make([]string, 0, len(s.sizes))
+	for k := range s.sizes {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.sizes[names[i]] != s.sizes[names[j]] {
+			return s.sizes[names[i]] > s.sizes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	var over []string
+	for _, v := range names {
+		if s.sizes[v] > s.chunkSize {
+			over = append(over, v)
+		}
+	}
+	if len(over) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v file(s) exceed the limit of %v per file:\n", len(over), log.FormatBytes(int64(s.chunkSize)))
+	for _, v := range over {
+		fmt.Fprintf(&b, "  %v (%v)\n", v, log.FormatBytes(int64(s.sizes[v])))
+	}
+	if len(names) > maxReportedFiles {
+		names = names[:maxReportedFiles]
+	}
+	fmt.Fprintf(&b, "Largest files, as encoded in the requests:\n")
+	for _, v := range names {
+		fmt.Fprintf(&b, "  %10v  %v\n", log.FormatBytes(int64(s.sizes[v])), v)
+	}
+	b.WriteString("Reduce the size of the files above, e.g. by compressing the images and audio, or host them elsewhere and refer to them by URL.")
+	return errors.New(b.String())
+}
+
+// Sent returns the number of files in the requests returned by Next so far.
+func (s SDKStreamer) Sent() int {
+	return s.i + s.j
+}
+
+// nextChunk returns names of the files in the next "chunk" such that
+// the sum of the size of each individual file in the chunk
 // is less than s.chunkSize.
-func (s *SDKStreamer) nextChunk(a []string, next int) map[string][]byte {
-	chunk := map[string][]byte{}
+func (s *SDKStreamer) nextChunk(a []string, next int) []string {
+	var chunk []string
 	curSize := 0
 	i := 0
 	for curSize < s.chunkSize && i+next < len(a) {
 		name := a[next+i]
-		content := s.files[name]
 		curSize += s.sizes[name]
 		if curSize > s.chunkSize {
 			break
 		}
-		chunk[name] = content
+		chunk = append(chunk, name)
 		i++
 	}
 	return chunk
 }
 
 func (s *SDKStreamer) nextConfigFiles(req map[string]interface{}) error {
-	if s.i == 0 {
+	if s.i == 0 && !s.Silent {
 		log.Outln("Sending configuration files...")
 	}
-	chunk := s.nextChunk(s.configFilenames, s.i)
-	if len(chunk) == 0 {
+	names := s.nextChunk(s.configFilenames, s.i)
+	if len(names) == 0 {
 		return fmt.Errorf("%v exceeds the limit of %v bytes", s.configFilenames[s.i], s.chunkSize)
 	}
+	chunk := map[string][]byte{}
+	for _, v := range names {
+		chunk[v] = s.configFiles[v]
+	}
 	if err := addConfigFiles(req, chunk, s.root); err != nil {
 		return err
 	}
@@ -331,13 +577,17 @@ func (s *SDKStreamer) nextConfigFiles(req map[string]interface{}) error {
 }
 
 func (s *SDKStreamer) nextDataFiles(req map[string]interface{}) error {
-	if s.j == 0 {
+	if s.j == 0 && !s.Silent {
 		log.Outln("Sending resources...")
 	}
-	chunk := s.nextChunk(s.dataFilenames, s.j)
-	if len(chunk) == 0 {
+	names := s.nextChunk(s.dataFilenames, s.j)
+	if len(names) == 0 {
 		return fmt.Errorf("%v exceeds the limit of %v bytes", s.dataFilenames[s.j], s.chunkSize)
 	}
+	chunk := map[string]DataFile{}
+	for _, v := range names {
+		chunk[v] = s.dataFiles[v]
+	}
 	if err := addDataFiles(req, chunk, s.root); err != nil {
 		return err
 	}
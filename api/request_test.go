@@ -15,13 +15,16 @@
 package request
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"mime"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/actions-on-google/gactions/api/testutils"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/protolambda/messagediff"
@@ -37,11 +40,17 @@ func TestWritePreview(t *testing.T) {
 			"sandbox": sandbox,
 		},
 	}
-	got := WritePreview(projectID, sandbox)
+	got := WritePreview(projectID, sandbox, false)
 	diff, equal := messagediff.DeepDiff(want, got)
 	if !equal {
 		t.Errorf("WritePreview returned an incorrect value; diff (want -> got)\n%s", diff)
 	}
+	want["validateOnly"] = true
+	got = WritePreview(projectID, sandbox, true)
+	diff, equal = messagediff.DeepDiff(want, got)
+	if !equal {
+		t.Errorf("WritePreview returned an incorrect value with validateOnly; diff (want -> got)\n%s", diff)
+	}
 }
 
 func TestWriteDraft(t *testing.T) {
@@ -49,10 +58,16 @@ func TestWriteDraft(t *testing.T) {
 	want := map[string]interface{}{
 		"parent": fmt.Sprintf("projects/%v", projectID),
 	}
-	got := WriteDraft(projectID)
+	got := WriteDraft(projectID, false)
 	diff, equal := messagediff.DeepDiff(want, got)
 	if !equal {
-		t.Errorf("WritePreview returned an incorrect value; diff (want -> got)\n%s", diff)
+		t.Errorf("WriteDraft returned an incorrect value; diff (want -> got)\n%s", diff)
+	}
+	want["validateOnly"] = true
+	got = WriteDraft(projectID, true)
+	diff, equal = messagediff.DeepDiff(want, got)
+	if !equal {
+		t.Errorf("WriteDraft returned an incorrect value with validateOnly; diff (want -> got)\n%s", diff)
 	}
 }
 
@@ -306,17 +321,17 @@ func TestAddDataFiles(t *testing.T) {
 							map[string]interface{}{
 								"filePath":    "audio1.mp3",
 								"payload":     []byte("abc123"),
-								"contentType": mime.TypeByExtension(filepath.Ext("audio1.mp3")),
+								"contentType": "audio/mpeg",
 							},
 							map[string]interface{}{
 								"filePath":    "image1.jpg",
 								"payload":     []byte("abc123"),
-								"contentType": mime.TypeByExtension(filepath.Ext("image1.jpg")),
+								"contentType": "image/jpeg",
 							},
 							map[string]interface{}{
 								"filePath":    "audio2.wav",
 								"payload":     []byte("abc123"),
-								"contentType": mime.TypeByExtension(filepath.Ext("audio2.wav")),
+								"contentType": "audio/wav",
 							},
 							map[string]interface{}{
 								"filePath":    "animation1.flr",
@@ -334,7 +349,7 @@ func TestAddDataFiles(t *testing.T) {
 				"audio1.xyz": []byte("abc123"),
 			},
 			want: map[string]interface{}{},
-			err:  nil,
+			err:  errors.New("can't determine the content type of audio1.xyz"),
 		},
 		{
 			files: map[string][]byte{
@@ -358,10 +373,12 @@ func TestAddDataFiles(t *testing.T) {
 	}
 	for _, tc := range tests {
 		req := map[string]interface{}{}
-		if err := addDataFiles(req, tc.files, "."); err != nil {
+		if err := addDataFiles(req, InMemoryDataFiles(tc.files), "."); err != nil {
 			if tc.err == nil {
 				t.Errorf("addDataFiles returned %v, want %v, input (files: %v)", err, tc.err, tc.files)
 			}
+		} else if tc.err != nil {
+			t.Errorf("addDataFiles returned %v, want %v, input (files: %v)", err, tc.err, tc.files)
 		}
 		type dataFile struct {
 			Filepath    string `json:"filePath"`
@@ -420,7 +437,7 @@ func TestNewStreamer(t *testing.T) {
 	}
 	root := "."
 	chunkSize := 1024
-	s := NewStreamer(cfgs, dfs, makeRequest, root, chunkSize)
+	s := NewStreamer(cfgs, InMemoryDataFiles(dfs), makeRequest, root, chunkSize)
 
 	// This is in correct sorted order
 	wantCfgnames := []string{"settings/settings.yaml", "manifest.yaml", "settings/en/settings.yaml",
@@ -514,7 +531,11 @@ func TestNextWithTwoFiles(t *testing.T) {
 	}
 	// Sets chunkSize to the sum of the first two request. Thus,
 	// streamer is guaranteed to return two requests.
-	s := NewStreamer(cfgs, dfs, mkreq, ".", len(out))
+	s := NewStreamer(cfgs, InMemoryDataFiles(dfs), mkreq, ".", len(out))
+	wantSize := int64(len(cfgs["settings/settings.yaml"]) + len(cfgs["manifest.yaml"]) + len(out))
+	if size, files := s.Size(); size != wantSize || files != 3 {
+		t.Errorf("Size returned (%v, %v), want (%v, %v)", size, files, wantSize, 3)
+	}
 	req1, err := s.Next()
 	if err != nil {
 		t.Errorf("SDKStreamer.Next failed to return the 1st request: %v", err)
@@ -527,6 +548,9 @@ func TestNextWithTwoFiles(t *testing.T) {
 	if hasNext := s.HasNext(); !hasNext {
 		t.Errorf("HasNext returned %v, but want %v", hasNext, true)
 	}
+	if sent := s.Sent(); sent != 2 {
+		t.Errorf("Sent returned %v after the 1st request, want %v", sent, 2)
+	}
 	req2, err := s.Next()
 	if err != nil {
 		t.Errorf("SDKStreamer.Next failed to return the 1st request: %v", err)
@@ -559,9 +583,193 @@ func TestNextWhenChunkSizeTooSmall(t *testing.T) {
 	mkreq := func() map[string]interface{} {
 		return map[string]interface{}{}
 	}
-	s := NewStreamer(cfgs, dfs, mkreq, ".", 1)
+	s := NewStreamer(cfgs, InMemoryDataFiles(dfs), mkreq, ".", 1)
 	req1, err := s.Next()
 	if err == nil {
 		t.Errorf("SDKStreamer.Next returned %v, but needs an error: %v", req1, err)
 	}
 }
+
+func TestCheckSizes(t *testing.T) {
+	cfgs := map[string][]byte{
+		"settings/settings.yaml": []byte(`projectId: hello-world`),
+		"manifest.yaml":          []byte(`version: 1.0`),
+	}
+	dfs := map[string][]byte{}
+	for i := 0; i < 12; i++ {
+		dfs[fmt.Sprintf("resources/images/%02d.png", i)] = make([]byte, 30*(i+1))
+	}
+	mkreq := func() map[string]interface{} {
+		return map[string]interface{}{}
+	}
+	if err := NewStreamer(cfgs, InMemoryDataFiles(dfs), mkreq, ".", 1024).CheckSizes(); err != nil {
+		t.Errorf("CheckSizes returned %v, want %v", err, nil)
+	}
+	err := NewStreamer(cfgs, InMemoryDataFiles(dfs), mkreq, ".", 400).CheckSizes()
+	if err == nil {
+		t.Fatalf("CheckSizes returned %v, want an error", err)
+	}
+	want := `2 file(s) exceed the limit of 400 B per file:
+  resources/images/11.png (480 B)
+  resources/images/10.png (440 B)
+Largest files, as encoded in the requests:
+       480 B  resources/images/11.png
+       440 B  resources/images/10.png
+       400 B  resources/images/09.png
+       360 B  resources/images/08.png
+       320 B  resources/images/07.png
+       280 B  resources/images/06.png
+       240 B  resources/images/05.png
+       200 B  resources/images/04.png
+       160 B  resources/images/03.png
+       120 B  resources/images/02.png
+Reduce the size of the files above, e.g. by compressing the images and audio, or host them elsewhere and refer to them by URL.`
+	if diff := cmp.Diff(want, err.Error()); diff != "" {
+		t.Errorf("CheckSizes returned an incorrect error: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	tests := []map[string]interface{}{
+		map[string]interface{}{
+			"parent": "projects/123",
+		},
+		map[string]interface{}{
+			"parent": "projects/123",
+			"files": map[string]interface{}{
+				"dataFiles": map[string][]interface{}{
+					"dataFiles": {
+						map[string]interface{}{
+							"filePath":    "resources/audio/audio1.mp3",
+							"payload":     []byte("abc1234"),
+							"contentType": "audio/mpeg",
+						},
+						map[string]interface{}{
+							"filePath":    "resources/images/image1.png",
+							"payload":     []byte{0, 1, 2, 255},
+							"contentType": "image/png",
+						},
+						map[string]interface{}{
+							"filePath":    "resources/images/empty.png",
+							"payload":     []byte{},
+							"contentType": "image/png",
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		want, err := json.Marshal(tc)
+		if err != nil {
+			t.Fatalf("json.Marshal(%v) returned %v", tc, err)
+		}
+		var b bytes.Buffer
+		n, err := WriteJSON(&b, tc)
+		if err != nil {
+			t.Errorf("WriteJSON(%v) returned %v, want %v", tc, err, nil)
+		}
+		if diff := cmp.Diff(string(want), b.String()); diff != "" {
+			t.Errorf("WriteJSON(%v) didn't write expected JSON: diff (-want, +got)\n%s", tc, diff)
+		}
+		if n != int64(len(want)) {
+			t.Errorf("WriteJSON(%v) returned %v bytes, want %v", tc, n, len(want))
+		}
+	}
+}
+
+func TestDataFileFromDisk(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	content := []byte{0, 1, 2, 3, 255, 'a', 'b', 'c'}
+	fp := filepath.Join(dirName, "image1.png")
+	if err := ioutil.WriteFile(fp, content, 0640); err != nil {
+		t.Fatalf("Can't write %v: %v", fp, err)
+	}
+	df := NewDataFile(fp, int64(len(content)))
+	want, err := json.Marshal(map[string]interface{}{"payload": content})
+	if err != nil {
+		t.Fatalf("json.Marshal returned %v", err)
+	}
+	var b bytes.Buffer
+	if _, err := WriteJSON(&b, map[string]interface{}{"payload": df}); err != nil {
+		t.Errorf("WriteJSON returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(string(want), b.String()); diff != "" {
+		t.Errorf("WriteJSON didn't write the payload from disk: diff (-want, +got)\n%s", diff)
+	}
+	got, err := json.Marshal(map[string]interface{}{"payload": df})
+	if err != nil {
+		t.Errorf("json.Marshal returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("DataFile.MarshalJSON didn't encode the payload from disk: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func BenchmarkNewStreamer(b *testing.B) {
+	cfgs := map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: 123"),
+		"manifest.yaml":          []byte("version: 1.0"),
+	}
+	dfs := map[string][]byte{}
+	for i := 0; i < 100; i++ {
+		dfs[fmt.Sprintf("resources/audio/audio%d.mp3", i)] = bytes.Repeat([]byte{byte(i)}, 100*1024)
+	}
+	makeRequest := func() map[string]interface{} {
+		return map[string]interface{}{}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := NewStreamer(cfgs, InMemoryDataFiles(dfs), makeRequest, ".", MaxChunkSizeBytes-Padding)
+		for s.HasNext() {
+			req, err := s.Next()
+			if err != nil {
+				b.Fatalf("SDKStreamer.Next returned %v, want %v", err, nil)
+			}
+			if _, err := WriteJSON(ioutil.Discard, req); err != nil {
+				b.Fatalf("WriteJSON returned %v, want %v", err, nil)
+			}
+		}
+	}
+}
+
+func TestSetContentType(t *testing.T) {
+	og := contentTypes
+	t.Cleanup(func() {
+		contentTypes = og
+	})
+	contentTypes = map[string]string{".png": "image/png"}
+	if err := SetContentType(".GLB", "model/gltf-binary"); err != nil {
+		t.Errorf("SetContentType returned %v, want %v", err, nil)
+	}
+	if err := SetContentType(".png", "image/apng"); err != nil {
+		t.Errorf("SetContentType returned %v, want %v", err, nil)
+	}
+	for _, ext := range []string{"glb", "a/.glb", ""} {
+		if err := SetContentType(ext, "model/gltf-binary"); err == nil {
+			t.Errorf("SetContentType(%q) returned %v, want an error", ext, err)
+		}
+	}
+	if err := SetContentType(".obj", ""); err == nil {
+		t.Errorf("SetContentType with an empty content type returned %v, want an error", err)
+	}
+	tests := []struct {
+		filename string
+		want     string
+		ok       bool
+	}{
+		{filename: "resources/models/cube.glb", want: "model/gltf-binary", ok: true},
+		{filename: "resources/images/logo.PNG", want: "image/apng", ok: true},
+		{filename: "resources/models/cube.obj", want: "", ok: false},
+	}
+	for _, tc := range tests {
+		got, ok := ContentType(tc.filename)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("ContentType(%q) returned (%q, %v), want (%q, %v)", tc.filename, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/actions-on-google/gactions/log"
+)
+
+var (
+	// retryBaseDelay is the backoff before the first retry, doubled for each next retry up to
+	// retryMaxDelay.
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 32 * time.Second
+	// maxRetryAfter is the longest Retry-After of the server the CLI waits for. A longer one
+	// fails the request instead of leaving the CLI hanging.
+	maxRetryAfter = 2 * time.Minute
+	// sleep waits for d, or until ctx is done.
+	sleep = func(ctx context.Context, d time.Duration) error {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			return nil
+		}
+	}
+)
+
+// retryableStatus returns true if a response with code to a request with method is a transient
+// error, for which the same request may succeed later. An exceeded quota or an unavailable
+// backend means the request wasn't handled, so it's retried for all methods. A bad gateway or a
+// gateway timeout may come after the backend handled the request, so it's only retried for
+// idempotent methods: sending e.g. versions:create again could create a second version.
+func retryableStatus(method string, code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+// idempotent returns true if sending a request with method more than once has the same effect
+// as sending it once.
+func idempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay asked for in the Retry-After header of resp, which is either a
+// number of seconds or an HTTP date, and false if there is no such header.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// backoff returns a random delay before the retry after attempt (starting at 1). The delay is
+// between a half and the whole of an exponentially growing bound, so concurrent CLIs don't
+// retry in lockstep.
+func backoff(attempt int) time.Duration {
+	d := retryMaxDelay
+	if attempt < 30 {
+		if v := retryBaseDelay << uint(attempt-1); v < retryMaxDelay {
+			d = v
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryTransport sends a request again when the server responds with a transient error, up to
+// maxAttempts times. Only requests whose body can be recreated by GetBody are retried; streamed
+// requests of files set GetBody to stream the files again.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !replayable || attempt >= t.maxAttempts || !retryableStatus(req.Method, resp.StatusCode) {
+			return resp, err
+		}
+		d, ok := retryAfter(resp, time.Now())
+		if !ok {
+			d = backoff(attempt)
+		}
+		if d > maxRetryAfter {
+			return resp, nil
+		}
+		// The body must be read to the end to reuse the connection.
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		// A streamed body may still be written if the server responded early; closing it
+		// unblocks the writer.
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		log.Infof("Server returned %v for %v. Retrying in %v (attempt %v of %v).\n", resp.Status, req.URL.Path, d.Round(time.Millisecond), attempt+1, t.maxAttempts)
+		if err := sleep(req.Context(), d); err != nil {
+			return nil, err
+		}
+		next := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		req = next
+	}
+}
+
+// streamBody returns a GetBody function of a streamed request, which writes the body again
+// with write into a new pipe, so the request can be retried. If write fails, the retried
+// request fails with its error.
+func streamBody(write func(w *io.PipeWriter) error) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		r, w := io.Pipe()
+		go func() {
+			if err := write(w); err != nil {
+				w.CloseWithError(err)
+			}
+		}()
+		return r, nil
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// sequenceTransport responds with the statuses in order, and records the bodies of the requests.
+type sequenceTransport struct {
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (s *sequenceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		s.bodies = append(s.bodies, string(b))
+	}
+	code := s.statuses[0]
+	s.statuses = s.statuses[1:]
+	h := http.Header{}
+	if s.retryAfter != "" {
+		h.Set("Retry-After", s.retryAfter)
+	}
+	return &http.Response{StatusCode: code, Status: http.StatusText(code), Header: h, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	og := sleep
+	t.Cleanup(func() { sleep = og })
+	var slept []time.Duration
+	sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return &slept
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		statuses    []int
+		retryAfter  string
+		wantStatus  int
+		wantBodies  []string
+		wantSleeps  int
+		wantSlept   []time.Duration
+		maxAttempts int
+	}{
+		{
+			name:        "retries transient errors until success",
+			statuses:    []int{503, 429, 200},
+			wantStatus:  200,
+			wantBodies:  []string{"files", "files", "files"},
+			wantSleeps:  2,
+			maxAttempts: 5,
+		},
+		{
+			name:        "gives up after max attempts",
+			statuses:    []int{503, 503, 503},
+			wantStatus:  503,
+			wantBodies:  []string{"files", "files"},
+			wantSleeps:  1,
+			maxAttempts: 2,
+		},
+		{
+			name:        "doesn't retry a bad gateway of a POST",
+			statuses:    []int{502, 200},
+			wantStatus:  502,
+			wantBodies:  []string{"files"},
+			maxAttempts: 5,
+		},
+		{
+			name:        "retries a gateway timeout of a PUT",
+			method:      "PUT",
+			statuses:    []int{504, 200},
+			wantStatus:  200,
+			wantBodies:  []string{"files", "files"},
+			wantSleeps:  1,
+			maxAttempts: 5,
+		},
+		{
+			name:        "doesn't retry other errors",
+			statuses:    []int{400},
+			wantStatus:  400,
+			wantBodies:  []string{"files"},
+			maxAttempts: 5,
+		},
+		{
+			name:        "honors Retry-After",
+			statuses:    []int{429, 200},
+			retryAfter:  "7",
+			wantStatus:  200,
+			wantBodies:  []string{"files", "files"},
+			wantSleeps:  1,
+			wantSlept:   []time.Duration{7 * time.Second},
+			maxAttempts: 5,
+		},
+		{
+			name:        "fails instead of waiting for a long Retry-After",
+			statuses:    []int{429, 200},
+			retryAfter:  "3600",
+			wantStatus:  429,
+			wantBodies:  []string{"files"},
+			maxAttempts: 5,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			slept := stubSleep(t)
+			base := &sequenceTransport{statuses: tc.statuses, retryAfter: tc.retryAfter}
+			method := tc.method
+			if method == "" {
+				method = "POST"
+			}
+			req, err := http.NewRequest(method, "https://actions.googleapis.com/v2/projects/p/draft:write", bytes.NewReader([]byte("files")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&retryTransport{base: base, maxAttempts: tc.maxAttempts}).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip returned %v, want %v", err, nil)
+			}
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("RoundTrip returned status %v, want %v", resp.StatusCode, tc.wantStatus)
+			}
+			if diff := cmp.Diff(tc.wantBodies, base.bodies); diff != "" {
+				t.Errorf("RoundTrip sent incorrect bodies: diff (-want, +got)\n%s", diff)
+			}
+			if len(*slept) != tc.wantSleeps {
+				t.Errorf("RoundTrip waited %v times, want %v", len(*slept), tc.wantSleeps)
+			}
+			if tc.wantSlept != nil {
+				if diff := cmp.Diff(tc.wantSlept, *slept); diff != "" {
+					t.Errorf("RoundTrip waited incorrectly: diff (-want, +got)\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransportStreamedBody(t *testing.T) {
+	stubSleep(t)
+	write := func(w *io.PipeWriter) error {
+		_, err := w.Write([]byte("[chunk]"))
+		w.Close()
+		return err
+	}
+	tests := []struct {
+		name       string
+		getBody    bool
+		wantBodies []string
+	}{
+		{
+			name:       "streamed again with GetBody",
+			getBody:    true,
+			wantBodies: []string{"[chunk]", "[chunk]"},
+		},
+		{
+			name:       "not retried without GetBody",
+			wantBodies: []string{"[chunk]"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, w := io.Pipe()
+			go write(w)
+			req, err := http.NewRequest("POST", "https://actions.googleapis.com/v2/projects/p/preview:write", r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.getBody {
+				req.GetBody = streamBody(write)
+			}
+			base := &sequenceTransport{statuses: []int{503, 200}}
+			if _, err := (&retryTransport{base: base, maxAttempts: 3}).RoundTrip(req); err != nil {
+				t.Errorf("RoundTrip returned %v, want %v", err, nil)
+			}
+			if diff := cmp.Diff(tc.wantBodies, base.bodies); diff != "" {
+				t.Errorf("RoundTrip sent incorrect bodies: diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStreamBodyFails(t *testing.T) {
+	wantErr := errors.New("file removed")
+	body, err := streamBody(func(w *io.PipeWriter) error {
+		return wantErr
+	})()
+	if err != nil {
+		t.Fatalf("GetBody returned %v, want %v", err, nil)
+	}
+	if _, err := ioutil.ReadAll(body); err != wantErr {
+		t.Errorf("reading the body returned %v, want %v", err, wantErr)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{header: "", want: 0, wantOK: false},
+		{header: "30", want: 30 * time.Second, wantOK: true},
+		{header: "Thu, 04 Mar 2021 05:07:07 GMT", want: time.Minute, wantOK: true},
+		{header: "Thu, 04 Mar 2021 05:00:00 GMT", want: 0, wantOK: true},
+		{header: "soon", want: 0, wantOK: false},
+	}
+	for _, tc := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tc.header != "" {
+			resp.Header.Set("Retry-After", tc.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("retryAfter(%q) returned (%v, %v), want (%v, %v)", tc.header, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 40; attempt++ {
+		bound := retryMaxDelay
+		if attempt < 7 {
+			bound = retryBaseDelay << uint(attempt-1)
+		}
+		if got := backoff(attempt); got < bound/2 || got > bound {
+			t.Errorf("backoff(%v) returned %v, want between %v and %v", attempt, got, bound/2, bound)
+		}
+	}
+}
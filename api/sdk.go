@@ -31,7 +31,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
@@ -40,7 +43,6 @@ import (
 	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
-	"github.com/actions-on-google/gactions/versions"
 	"gopkg.in/yaml.v2"
 )
 
@@ -50,7 +52,16 @@ const (
 	encryptEndpoint            = "v2:encryptSecret"
 	decryptEndpoint            = "v2:decryptSecret"
 	listSampleProjectsEndpoint = "v2/sampleProjects"
-	// Prod version of CurEnv
+	listProjectsEndpoint       = "v2/projects"
+	sheetsURL                  = "sheets.googleapis.com"
+	loggingURL                 = "logging.googleapis.com"
+	resourceManagerURL         = "cloudresourcemanager.googleapis.com"
+	serviceUsageURL            = "serviceusage.googleapis.com"
+	monitoringURL              = "monitoring.googleapis.com"
+	textToSpeechURL            = "texttospeech.googleapis.com"
+	// auditLogID is the ID of the log in Cloud Logging that keeps the audit trail of the CLI.
+	auditLogID = "gactions-audit"
+	// Prod is the Env of the production Actions API and Actions Console
 	Prod = "prod"
 	// ProdChannel of AoG release
 	ProdChannel = "actions.channels.Production"
@@ -61,11 +72,8 @@ const (
 )
 
 var (
-	// CurEnv determines which version of the Actions API to call.
-	CurEnv      = Prod
-	consoleAddr = "https://" + urlMap[CurEnv]["consoleURL"]
-	// Consumer holds the string identifying the caller to Google. This is based on a command line flag.
-	Consumer = ""
+	// pullWriteWorkers is the number of files written to disk in parallel during pull.
+	pullWriteWorkers = runtime.NumCPU()
 	// responseBodyReadTimeout is a time limit to read body of HTTP response after response object is received.
 	responseBodyReadTimeout = 5 * time.Second
 	BuiltInReleaseChannels = map[string]string{
@@ -149,8 +157,15 @@ type streamRecord struct {
 	} `json:"files"`
 }
 
-func httpAddr(endpoint string) string {
-	return "https://" + urlMap[CurEnv]["apiURL"] + "/" + endpoint
+func httpAddr(ctx context.Context, endpoint string) string {
+	return ConfigFrom(ctx).apiAddr() + "/" + endpoint
+}
+
+// Hosts returns the hosts of the Actions API and the Actions Console the CLI connects to with
+// the Config of ctx.
+func Hosts(ctx context.Context) []string {
+	cfg := ConfigFrom(ctx)
+	return []string{cfg.apiHost(), urlMap[cfg.Env]["consoleURL"]}
 }
 
 func writeDraftHTTPEndpoint(projectID string) string {
@@ -195,19 +210,24 @@ func check(cfgs map[string][]byte) error {
 	return nil
 }
 
-func printSize(req map[string]interface{}) {
-	b, err := json.Marshal(req)
+// setContentTypes registers the content types of resource files set in the CLI config.
+func setContentTypes() error {
+	cfg, err := studio.ReadCLIConfig()
 	if err != nil {
-		log.Infof("Tried marshalling request into JSON: %v\n", err)
-		return
+		return err
 	}
-	log.Infof("Total request size is %v bytes.", len(b))
+	for ext, ct := range cfg.ContentTypes {
+		if err := request.SetContentType(ext, ct); err != nil {
+			return fmt.Errorf("contentTypes in %v: %v", project.ConfigName, err)
+		}
+	}
+	return nil
 }
 
 // sendFilesToServerJSON will stream series of requests based on proj to w.
 // The function performs client-side streaming via HTTP/JSON. This is done by
 // sending an array of JSON requests.
-func sendFilesToServerJSON(p project.Project, w *io.PipeWriter, makeRequest func() map[string]interface{}) (err error) {
+func sendFilesToServerJSON(ctx context.Context, p project.Project, w *io.PipeWriter, makeRequest func() map[string]interface{}) (err error) {
 	// Important - must close w to avoid deadlock for the reader end of the pipe.
 	defer func() {
 		// Don't want to overwrite other errors raised in the func.
@@ -217,38 +237,44 @@ func sendFilesToServerJSON(p project.Project, w *io.PipeWriter, makeRequest func
 			err = err2
 		}
 	}()
-	files, err := p.Files()
+	configFiles, err := studio.ReadConfigFiles(p)
 	if err != nil {
 		return err
 	}
-	configFiles := studio.ConfigFiles(files)
-	dataFiles, err := studio.DataFiles(p)
+	dataFiles, err := studio.DataFileRefs(p)
 	if err != nil {
 		return err
 	}
 	if err := check(configFiles); err != nil {
 		return err
 	}
-	encoder := json.NewEncoder(w)
+	if err := setContentTypes(); err != nil {
+		return err
+	}
+	refs, err := dataFileRefs(dataFiles)
+	if err != nil {
+		return err
+	}
+	streamer := request.NewStreamer(configFiles, refs, makeRequest, p.ProjectRoot(), request.MaxChunkSizeBytes-request.Padding)
+	streamer.Silent = silent(ctx)
+	if err := streamer.CheckSizes(); err != nil {
+		return err
+	}
 	_, err = w.Write([]byte("["))
 	if err != nil {
 		return err
 	}
-	streamer := request.NewStreamer(configFiles, dataFiles, makeRequest, p.ProjectRoot(), request.MaxChunkSizeBytes-request.Padding)
+	total, count := streamer.Size()
+	progress := log.NewProgress("Sending", total, count)
+	defer progress.Done()
+	pw := progressWriter{w: w, p: progress}
 	for streamer.HasNext() {
+		sent := streamer.Sent()
 		req, err := streamer.Next()
 		if err != nil {
 			return err
 		}
-		printSize(req)
-		if err = encoder.Encode(req); err != nil {
-			// Ignore this error because it's possible for this error
-			// to happen when server closed the connection (i.e. the read end of the pipe gets closed)
-			// due to a failing internal server logic after processing of configuration files.
-			log.Infof("Failed to send previous request: %v\n", err)
-			return nil
-		}
-		if streamer.HasNext() {
+		if sent > 0 {
 			if _, err = w.Write([]byte(",")); err != nil {
 				// Ignore this error because it's possible for this error
 				// to happen when server closed the connection (i.e. the read end of the pipe gets closed)
@@ -257,6 +283,16 @@ func sendFilesToServerJSON(p project.Project, w *io.PipeWriter, makeRequest func
 				return nil
 			}
 		}
+		n, err := request.WriteJSON(pw, req)
+		if err != nil {
+			// Ignore this error because it's possible for this error
+			// to happen when server closed the connection (i.e. the read end of the pipe gets closed)
+			// due to a failing internal server logic after processing of configuration files.
+			log.Infof("Failed to send previous request: %v\n", err)
+			return nil
+		}
+		log.Infof("Total request size is %v bytes.", n)
+		progress.Add(0, streamer.Sent()-sent)
 	}
 	if _, err = w.Write([]byte("]")); err != nil {
 		// Ignore this error because it's possible for this error
@@ -268,6 +304,130 @@ func sendFilesToServerJSON(p project.Project, w *io.PipeWriter, makeRequest func
 	return err
 }
 
+// newFilesRequest returns a POST request to requestURL which streams the files of src, split
+// into the requests of makeRequest. The body of each attempt, the first one and the retries, is
+// streamed by GetBody, so the files are never written into the body of an attempt which the
+// transport gave up on, e.g. after the server answered before reading it. waitMsg is printed
+// once the files of the latest attempt are sent. The returned function returns the error of the
+// stream of the latest attempt, e.g. a file which can't be read.
+func newFilesRequest(ctx context.Context, requestURL, projectID string, src project.Project, waitMsg string, makeRequest func() map[string]interface{}) (*http.Request, func() error, error) {
+	var (
+		mu      sync.Mutex
+		attempt int
+		lastErr error
+	)
+	getBody := func() (io.ReadCloser, error) {
+		mu.Lock()
+		attempt++
+		n := attempt
+		lastErr = nil
+		mu.Unlock()
+		return streamBody(func(w *io.PipeWriter) error {
+			err := sendFilesToServerJSON(ctx, src, w, makeRequest)
+			mu.Lock()
+			latest := n == attempt
+			if latest {
+				lastErr = err
+			}
+			mu.Unlock()
+			if err == nil && latest && !silent(ctx) {
+				log.Outf(waitMsg)
+			}
+			return err
+		})()
+	}
+	body, err := getBody()
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, body)
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	req.GetBody = getBody
+	req.Header.Add("Content-Type", "application/json")
+	// This is done to help server select the quota attributed to a
+	// projectID (i.e. developer's project), instead of the CLI project.
+	// https://cloud.google.com/storage/docs/xml-api/reference-headers#xgooguserproject
+	req.Header.Add("X-Goog-User-Project", projectID)
+	addClientHeaders(req)
+	streamErr := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return lastErr
+	}
+	return req, streamErr, nil
+}
+
+// requestError returns the error of the stream of a request which failed with err, if the
+// stream failed, as it explains the failure better than the error of the transport.
+func requestError(err error, streamErr func() error) error {
+	if serr := streamErr(); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// dataFileRefs converts dataFiles for the streamer, so the payloads of the files on disk are
+// read only when being sent. Files that don't exist on disk (e.g. files generated by CLI) stay
+// in memory.
+func dataFileRefs(dataFiles map[string]project.File) (map[string]request.DataFile, error) {
+	refs := map[string]request.DataFile{}
+	for k, f := range dataFiles {
+		if f.Path != "" {
+			refs[k] = request.NewDataFile(f.Path, f.Size)
+			continue
+		}
+		b, err := f.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		refs[k] = request.InMemoryDataFile(b)
+	}
+	return refs, nil
+}
+
+// progressWriteSize is the largest write reported to a progress bar at once. Larger writes are
+// split to keep the bar moving while a chunk is sent.
+const progressWriteSize = 64 * 1024
+
+// progressWriter reports the bytes written to w to a progress bar.
+type progressWriter struct {
+	w io.Writer
+	p *log.Progress
+}
+
+func (pw progressWriter) Write(b []byte) (int, error) {
+	n := 0
+	for len(b) > 0 {
+		k := len(b)
+		if k > progressWriteSize {
+			k = progressWriteSize
+		}
+		m, err := pw.w.Write(b[:k])
+		n += m
+		pw.p.Add(int64(m), 0)
+		if err != nil {
+			return n, err
+		}
+		b = b[k:]
+	}
+	return n, nil
+}
+
+// progressReader reports the bytes read from r to a progress bar.
+type progressReader struct {
+	r io.Reader
+	p *log.Progress
+}
+
+func (pr progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.p.Add(int64(n), 0)
+	return n, err
+}
+
 // readBodyWithTimeout reads content from body until EOF is encountered, or timer expired.
 // Timer starts when this function starts execution.
 func readBodyWithTimeout(body io.Reader, timeout time.Duration) ([]byte, error) {
@@ -328,7 +488,10 @@ func parseError(body []byte) error {
 		// one platform returns an HTML response. In this case, we print the HTML and disregard the json decoding error.
 		return fmt.Errorf(string(body))
 	}
-	return fmt.Errorf("Server did not return HTTP 200.\n%v", errorMessage(publicError))
+	return &Error{
+		Kind: publicErrorKind(publicError),
+		Err:  fmt.Errorf("Server did not return HTTP 200.\n%v", errorMessage(publicError)),
+	}
 }
 
 func errorMessage(in *PublicError) string {
@@ -356,7 +519,7 @@ func errorMessage(in *PublicError) string {
 	return string(b)
 }
 
-func printValidationResults(results []validationResult) {
+func printValidationResults(root string, results []validationResult) {
 	w := new(tabwriter.Writer)
 	w.Init(os.Stdout, 2, 4, 2, ' ', 0)
 	fmt.Fprintln(w, "  Locale\tValidation Result\t")
@@ -365,240 +528,544 @@ func printValidationResults(results []validationResult) {
 	}
 	fmt.Fprint(w)
 	w.Flush()
+	annotateValidationResults(root, results)
+}
+
+// validationFileRegExp matches a path of a project file in a validation message.
+var validationFileRegExp = regexp.MustCompile(`\b(?:(?:custom|settings|actions|webhooks|resources|verticals)/[\w./-]*\w\.yaml|manifest\.yaml)\b`)
+
+// annotateValidationResults reports validation results as warnings of a GitHub Actions
+// workflow. Results only contain a message, so a file is annotated when the message names it.
+func annotateValidationResults(root string, results []validationResult) {
+	for _, v := range results {
+		file := validationFileRegExp.FindString(v.ValidationMessage)
+		if file != "" && root != "" {
+			file = filepath.Join(root, filepath.FromSlash(file))
+		}
+		msg := v.ValidationMessage
+		if v.ValidationContext.LanguageCode != "" {
+			msg = fmt.Sprintf("[%v] %v", v.ValidationContext.LanguageCode, msg)
+		}
+		log.Annotate("warning", file, msg)
+	}
+}
+
+// validationIssuesHeader is printed before the validation results of a write request.
+func validationIssuesHeader(validateOnly bool) string {
+	if validateOnly {
+		return "Server found validation issues (the files were only validated, so nothing was changed):"
+	}
+	return "Server found validation issues (however, your files were still pushed):"
+}
+
+// printValidationIssues prints the issues the server found in the files of the project in root.
+func printValidationIssues(root string, results []validationResult, validateOnly bool) {
+	if len(results) == 0 {
+		return
+	}
+	log.Warnln(validationIssuesHeader(validateOnly))
+	printValidationResults(root, results)
 }
 
-func procWriteDraftResponse(body []byte) error {
+func procWriteDraftResponse(body []byte) ([]validationResult, error) {
 	resp := &WriteDraftHTTPResponse{}
 	if err := json.NewDecoder(bytes.NewReader(body)).Decode(resp); err != nil {
-		return errors.New(string(body))
+		return nil, errors.New(string(body))
+	}
+	return resp.ValidationResults.Results, nil
+}
+
+// loadPushState returns the state recorded by the last successful push of proj. It returns nil
+// when files need to be pushed in full: the state is missing, can't be read, or was recorded
+// for a different project.
+func loadPushState(proj project.Project) *studio.PushState {
+	s, err := studio.ReadPushState(proj.ProjectRoot())
+	if err != nil {
+		log.Warnf("Can not read the state of the last push, so all files will be pushed: %v\n", err)
+		return nil
 	}
-	if len(resp.ValidationResults.Results) > 0 {
-		log.Warnln("Server found validation issues (however, your files were still pushed):")
-		printValidationResults(resp.ValidationResults.Results)
+	if s == nil {
+		log.Outln("State of the last push was not found, so all files will be pushed.")
+		return nil
 	}
-	return nil
+	if s.ProjectID != proj.ProjectID() {
+		log.Outf("Last push was made to the project %q, so all files will be pushed.\n", s.ProjectID)
+		return nil
+	}
+	return s
 }
 
-// WriteDraftJSON implements WriteDraft functionality of the SDK server via HTTP/JSON streaming.
-func WriteDraftJSON(ctx context.Context, proj project.Project) error {
-	clientSecret, err := proj.ClientSecretJSON()
+// pushDigests returns the digests of the files of proj which are sent by a push.
+func pushDigests(proj project.Project) (map[string]string, error) {
+	configFiles, err := studio.ReadConfigFiles(proj)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	client, err := apiutils.NewHTTPClient(
-		ctx,
-		clientSecret,
-		"",
-	)
+	pushed, err := studio.DataFileRefs(proj)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	projectID := proj.ProjectID()
-	log.Outf("Pushing files in the project %q to Actions Console. This may take a few minutes.\n", projectID)
-	requestURL := httpAddr(writeDraftHTTPEndpoint(projectID))
-	r, w := io.Pipe()
-	errCh := make(chan error, 1)
-	// This goroutine will exit after HTTP call is finished.
-	// The sendFilesToServerJSON below and client.Post communicate via the pipe
-	// and former will keep writing stream of bytes, which client post will
-	// keep reading in a blocking fashion. sendFilesToServerJSON is guaranteed
-	// to close the writer end of the pipe, thus unblocking the reader and allowing
-	// the goroutine to exit.
-	go func() {
-		req, err := http.NewRequest("POST", requestURL, r)
-		if err != nil {
-			errCh <- err
-			return
+	for k, v := range configFiles {
+		pushed[k] = project.InMemoryFile(v)
+	}
+	return studio.FileDigests(pushed)
+}
+
+// unchangedSincePush returns true if digests of the files of a project are the digests recorded
+// by the push in prev. The draft is replaced by the files of each push, so the files are pushed
+// in full unless none of them changed.
+func unchangedSincePush(digests map[string]string, prev *studio.PushState) bool {
+	if len(digests) != len(prev.Digests) {
+		return false
+	}
+	for k, v := range digests {
+		if prev.Digests[k] != v {
+			return false
 		}
-		req.Header.Add("Content-Type", "application/json")
-		// This is done to help server to select the quota attributed to a
-		// projectID (i.e. developer's project), instead of the CLI project.
-		req.Header.Add("X-Goog-User-Project", projectID)
-		addClientHeaders(req)
+	}
+	return true
+}
 
-		resp, err := client.Do(req)
+// savePushState records digests of the files of proj, so the next incremental push can skip
+// the upload if none of the files changed.
+func savePushState(proj project.Project, digests map[string]string) error {
+	return studio.WritePushState(proj.ProjectRoot(), studio.PushState{ProjectID: proj.ProjectID(), Digests: digests})
+}
 
+// writeDraft sends the files of src to the draft of the project with projectID, and returns
+// the validation results of the server. If validateOnly is true, the draft isn't written.
+func writeDraft(ctx context.Context, client *http.Client, projectID string, src project.Project, validateOnly bool) ([]validationResult, error) {
+	src, err := withDecryptedValues(ctx, client, src)
+	if err != nil {
+		return nil, err
+	}
+	requestURL := httpAddr(ctx, writeDraftHTTPEndpoint(projectID))
+	req, streamErr, err := newFilesRequest(ctx, requestURL, projectID, src, "Waiting for server to respond...", func() map[string]interface{} {
+		return request.WriteDraft(projectID, validateOnly)
+	})
+	if err != nil {
+		return nil, err
+	}
+	errCh := make(chan error, 1)
+	// results is set before the error is sent to errCh.
+	var results []validationResult
+	// This goroutine will exit after HTTP call is finished.
+	go func() {
+		resp, err := doStream(client, req)
 		if err != nil {
-			errCh <- err
+			errCh <- requestError(err, streamErr)
 			return
 		}
 		defer resp.Body.Close()
 		postprocessJSONResponse(resp, errCh, func(body []byte) error {
-			return procWriteDraftResponse(body)
+			var err error
+			results, err = procWriteDraftResponse(body)
+			return err
 		})
 	}()
-	if err := sendFilesToServerJSON(proj, w, func() map[string]interface{} {
-		return request.WriteDraft(projectID)
-	}); err != nil {
-		return err
+	if err := <-errCh; err != nil {
+		return nil, err
 	}
-	log.Outf("Waiting for server to respond...")
-	err = <-errCh
-	if err != nil {
-		return err
+	if !silent(ctx) {
+		printValidationIssues(src.ProjectRoot(), results, validateOnly)
 	}
-	log.DoneMsgln(fmt.Sprintf(`Files were pushed to Actions Console, and you can now view your project with this URL: %v/project/%v/overview. If you want to test your changes, run "gactions deploy preview", or navigate to the Test section in the Console.`, consoleAddr, projectID))
-	return nil
+	return results, nil
 }
 
-func procWritePreviewResponse(body []byte) (string, error) {
-	resp := &WritePreviewHTTPResponse{}
-	if err := json.NewDecoder(bytes.NewReader(body)).Decode(resp); err != nil {
-		return "", errors.New(string(body))
-	}
-	if len(resp.ValidationResults.Results) > 0 {
-		log.Warnln("Server found validation issues (however, your files were still pushed):")
-		printValidationResults(resp.ValidationResults.Results)
+// previewServerTimeout is how long the server may take to write the preview.
+const previewServerTimeout = 180 * time.Second
+
+// serverTimeout returns the X-Server-Timeout header, in seconds, of a request which may take d
+// on the server. The server doesn't need to work past the deadline of ctx, if it's sooner.
+func serverTimeout(ctx context.Context, d time.Duration) string {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		d = time.Until(deadline)
 	}
-	simulatorURL := resp.SimulatorURL
-	if simulatorURL == "" {
-		log.Warnf("The API response body doesn't contain the simulator link.")
+	sec := int(d.Seconds())
+	if sec < 1 {
+		sec = 1
 	}
-	return simulatorURL, nil
+	return strconv.Itoa(sec)
 }
 
-// WritePreviewJSON implements WritePreview functionality of the SDK server via HTTP/JSON streaming.
-func WritePreviewJSON(ctx context.Context, proj project.Project, sandbox bool) error {
-	clientSecret, err := proj.ClientSecretJSON()
+// WriteDraftJSON implements WriteDraft functionality of the SDK server via HTTP/JSON streaming,
+// and returns the issues found by the server. If incremental is true, the files aren't sent
+// when none of them changed since the last successful push.
+func WriteDraftJSON(ctx context.Context, proj project.Project, incremental bool) ([]ValidationIssue, error) {
+	results, err := pushDraft(ctx, proj, incremental)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return validationIssues(results), nil
+}
+
+// ValidationIssue is an issue found by the server in the files of a project.
+type ValidationIssue struct {
+	Locale string `json:"locale,omitempty"`
+	// File is the project file named in the message, if any.
+	File    string `json:"file,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationFailedError is returned when the server found issues in the files of a project, and
+// the caller asked to fail on them, e.g. with the --strict flag of push.
+type ValidationFailedError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationFailedError) Error() string {
+	if len(e.Issues) == 1 {
+		return "server found 1 validation issue"
 	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+	return fmt.Sprintf("server found %v validation issues", len(e.Issues))
+}
+
+// CheckValidationIssues returns a *ValidationFailedError with issues, or nil if there are none.
+func CheckValidationIssues(issues []ValidationIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return &ValidationFailedError{Issues: issues}
+}
+
+// ValidateOnlyDraftJSON sends the files of proj to the draft with validateOnly set, so the
+// server validates them without writing the draft, and returns the issues it found.
+func ValidateOnlyDraftJSON(ctx context.Context, proj project.Project) ([]ValidationIssue, error) {
+	client, err := setupClient(ctx, proj)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	projectID := proj.ProjectID()
-	log.Outf("Deploying files in the project %q to Actions Console for preview. This may take a few minutes.\n", projectID)
-	requestURL := httpAddr(previewHTTPEndpoint(projectID))
-	r, w := io.Pipe()
-	errCh := make(chan error, 1)
-	var simulatorURL string
-	// This goroutine will exit after HTTP call is finished.
-	// The sendFilesToServerJSON below and client.Post communicate via the pipe
-	// and former will keep writing stream of bytes, which client post will
-	// keep reading in a blocking fashion. sendFilesToServerJSON is guaranteed
-	// to close the writer end of the pipe, thus unblocking the reader and allowing
-	// the goroutine to exit.
-	go func() {
-		req, err := http.NewRequest("POST", requestURL, r)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		req.Header.Add("Content-Type", "application/json")
-		// This is done to help server select the quota attributed to a
-		// projectID (i.e. developer's project), instead of the CLI project.
-		// https://cloud.google.com/storage/docs/xml-api/reference-headers#xgooguserproject
-		req.Header.Add("X-Goog-User-Project", projectID)
-		// Sets timeout because Cloud Function deployment can take 1-2 minutes.
-		const timeoutSec = "180"
-		req.Header.Add("X-Server-Timeout", fmt.Sprintf("%v", timeoutSec))
-		addClientHeaders(req)
+	log.Outf("Validating files in the project %q with Actions Console. The draft is not changed.\n", projectID)
+	results, err := writeDraft(ctx, client, projectID, proj, true)
+	if err != nil {
+		return nil, err
+	}
+	log.DoneMsgln(validationDoneMsg(results))
+	return validationIssues(results), nil
+}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		defer resp.Body.Close()
-		postprocessJSONResponse(resp, errCh, func(body []byte) error {
-			v, err := procWritePreviewResponse(body)
-			simulatorURL = v
-			return err
+// validationIssues converts the validation results of the server to issues.
+func validationIssues(results []validationResult) []ValidationIssue {
+	var res []ValidationIssue
+	for _, v := range results {
+		res = append(res, ValidationIssue{
+			Locale:  v.ValidationContext.LanguageCode,
+			File:    validationFileRegExp.FindString(v.ValidationMessage),
+			Message: v.ValidationMessage,
 		})
-	}()
-	if err := sendFilesToServerJSON(proj, w, func() map[string]interface{} {
-		return request.WritePreview(projectID, sandbox)
-	}); err != nil {
-		return err
 	}
-	log.Outf("Waiting for server to respond. It could take up to 1 minute if your cloud function needs to be redeployed.")
-	err = <-errCh
-	if err != nil {
-		return err
+	return res
+}
+
+// validationDoneMsg summarizes the results of a request which only validated the files.
+func validationDoneMsg(results []validationResult) string {
+	if len(results) == 0 {
+		return "Server validated the files and found no issues."
 	}
-	log.DoneMsgln(fmt.Sprintf("You can now test your changes in Simulator with this URL: %s", simulatorURL))
-	return nil
+	return fmt.Sprintf("Server validated the files and found %v issues.", len(results))
 }
 
-func procCreateVersionResponse(channel string, body []byte) (string, error) {
-	resp := &CreateVersionHTTPResponse{}
-	if err := json.NewDecoder(bytes.NewReader(body)).Decode(resp); err != nil {
-		return "", errors.New(string(body))
+func pushDraft(ctx context.Context, proj project.Project, incremental bool) ([]validationResult, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return nil, err
 	}
-	versionIDRegExp := regexp.MustCompile("^projects/[^//]+/versions/(?P<versionID>[^//]+)$")
-	if versionIDMatch := versionIDRegExp.FindStringSubmatch(resp.Name); versionIDMatch == nil {
-		log.Debugln(fmt.Sprintf("version id absent in the response %s returned from the server ", resp.Name))
-		return "", nil
+	projectID := proj.ProjectID()
+	// A project without a root, such as an archive read from stdin, has nowhere to keep the state.
+	saveState := proj.ProjectRoot() != ""
+	// The digests are taken before the upload, so a file edited during the push is recorded as
+	// changed, and sent again by the next push.
+	var digests map[string]string
+	if incremental || saveState {
+		if digests, err = pushDigests(proj); err != nil {
+			return nil, err
+		}
 	}
-	return versionIDRegExp.FindStringSubmatch(resp.Name)[versionIDRegExp.SubexpIndex("versionID")], nil
+	if incremental {
+		if prev := loadPushState(proj); prev != nil {
+			if unchangedSincePush(digests, prev) {
+				log.DoneMsgln("Files didn't change since the last push, so the draft is up to date.")
+				return nil, nil
+			}
+		}
+	}
+	log.Outf("Pushing files in the project %q to Actions Console. This may take a few minutes.\n", projectID)
+	results, err := writeDraft(ctx, client, projectID, proj, false)
+	if err != nil {
+		return nil, err
+	}
+	if saveState {
+		if err := savePushState(proj, digests); err != nil {
+			log.Warnf("Failed to save the state of this push; the next incremental push will send all files: %v\n", err)
+		}
+	}
+	recordHistory(ctx, client, proj, "push", "draft", "")
+	log.DoneMsgln(fmt.Sprintf(`Files were pushed to Actions Console, and you can now view your project with this URL: %v/project/%v/overview. If you want to test your changes, run "gactions deploy preview", or navigate to the Test section in the Console.`, ConfigFrom(ctx).consoleAddr(), projectID))
+	return results, nil
 }
 
-// CreateVersionJSON implements CreateVersion functionality of the SDK server via HTTP/JSON streaming.
-func CreateVersionJSON(ctx context.Context, proj project.Project, channel string) error {
-	clientSecret, err := proj.ClientSecretJSON()
+// SnapshotDraftJSON reads the draft of proj, and writes its files to dir, which is laid out like
+// a project root. Local files of proj are not changed.
+func SnapshotDraftJSON(ctx context.Context, proj project.Project, dir string) error {
+	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return err
 	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+	clientSecret, err := proj.ClientSecretJSON()
 	if err != nil {
 		return err
 	}
 	projectID := proj.ProjectID()
-	log.Outf("Deploying files in the project %q to the %q release channel...", projectID, channel)
-	requestURL := httpAddr(versionHTTPEndpoint(projectID))
-	r, w := io.Pipe()
-	errCh := make(chan error, 1)
-	var versionID string
-	// This goroutine will exit after HTTP call is finished.
-	// The sendFilesToServerJSON below and client.Post communicate via the pipe
-	// and former will keep writing stream of bytes, which client post will
-	// keep reading in a blocking fashion. sendFilesToServerJSON is guaranteed
-	// to close the writer end of the pipe, thus unblocking the reader and allowing
-	// the goroutine to exit.
-	go func() {
-		req, err := http.NewRequest("POST", requestURL, r)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		req.Header.Add("Content-Type", "application/json")
-		// This is done to help server select the quota attributed to a
-		// projectID (i.e. developer's project), instead of the CLI project.
-		// https://cloud.google.com/storage/docs/xml-api/reference-headers#xgooguserproject
-		req.Header.Add("X-Goog-User-Project", projectID)
-		addClientHeaders(req)
-
-		resp, err := client.Do(req)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		defer resp.Body.Close()
-		// TODO: Change signature of postProcessJSONResponse to return an error, and pipe that error to channel here.
-		postprocessJSONResponse(resp, errCh, func(body []byte) error {
-			v, err := procCreateVersionResponse(channel, body)
-			versionID = v
-			return err
-		})
-	}()
-	if err := sendFilesToServerJSON(proj, w, func() map[string]interface{} {
-		return request.CreateVersion(projectID, channel)
-	}); err != nil {
+	files, err := proj.Files()
+	if err != nil {
 		return err
 	}
-	log.Outf("Waiting for server to respond...")
-	if err := <-errCh; err != nil {
+	body, err := json.Marshal(request.ReadDraft(projectID, parseEncryptionKeyVersion(files)))
+	if err != nil {
 		return err
 	}
-	if _, ok := BuiltInReleaseChannels[channel]; ok {
-		channel = BuiltInReleaseChannels[channel]
+	respBody, err := openStream(ctx, client, httpAddr(ctx, readDraftHTTPEndpoint(projectID)), body, projectID)
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	return receiveStream(studio.New(clientSecret, dir), respBody, true, map[string]bool{}, nil)
+}
+
+// RestoreDraftJSON replaces the draft of proj with the files in dir (i.e. a snapshot taken by
+// SnapshotDraftJSON). The state of the last push of proj is removed, because the draft no longer
+// matches it.
+func RestoreDraftJSON(ctx context.Context, proj project.Project, dir string) error {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return err
+	}
+	clientSecret, err := proj.ClientSecretJSON()
+	if err != nil {
+		return err
+	}
+	projectID := proj.ProjectID()
+	src := studio.New(clientSecret, dir)
+	log.Outf("Restoring the draft of the project %q from %v. This may take a few minutes.\n", projectID, dir)
+	if _, err := writeDraft(ctx, client, projectID, src, false); err != nil {
+		return err
+	}
+	if err := studio.RemovePushState(proj.ProjectRoot()); err != nil {
+		log.Warnf("Failed to remove the state of the last push; run push without --incremental to send all files: %v\n", err)
+	}
+	files, err := src.Files()
+	if err != nil {
+		log.Warnf("Failed to record this restore in the history: %v\n", err)
+	} else {
+		recordHistoryFiles(ctx, client, proj, files, "restore", "draft", "")
+	}
+	log.DoneMsgln(fmt.Sprintf("The draft was restored, and you can now view your project with this URL: %v/project/%v/overview.", ConfigFrom(ctx).consoleAddr(), projectID))
+	return nil
+}
+
+func procWritePreviewResponse(body []byte) (string, []validationResult, error) {
+	resp := &WritePreviewHTTPResponse{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(resp); err != nil {
+		return "", nil, errors.New(string(body))
+	}
+	return resp.SimulatorURL, resp.ValidationResults.Results, nil
+}
+
+// WritePreviewJSON implements WritePreview functionality of the SDK server via HTTP/JSON streaming,
+// and returns the issues found by the server.
+func WritePreviewJSON(ctx context.Context, proj project.Project, sandbox bool) ([]ValidationIssue, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	log.Outf("Deploying files in the project %q to Actions Console for preview. This may take a few minutes.\n", projectID)
+	simulatorURL, results, err := writePreview(ctx, client, projectID, proj, sandbox, false)
+	if err != nil {
+		return nil, err
+	}
+	recordHistory(ctx, client, proj, "deploy", "preview", "")
+	if err := log.SetStepOutput("simulator-url", simulatorURL); err != nil {
+		log.Warnf("Failed to set the simulator-url output of the workflow step: %v\n", err)
+	}
+	log.DoneMsgln(fmt.Sprintf("You can now test your changes in Simulator with this URL: %s", simulatorURL))
+	return validationIssues(results), nil
+}
+
+// WriteDraftAndPreviewJSON pushes the files of proj to the draft, and then deploys them for
+// preview, as WriteDraftJSON and WritePreviewJSON do. The project files are listed once for both
+// requests. It returns the issues found by the server in the preview, which validates the same
+// files as the draft.
+func WriteDraftAndPreviewJSON(ctx context.Context, proj project.Project, sandbox bool) ([]ValidationIssue, error) {
+	cached, err := newCachedProject(proj)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := WriteDraftJSON(ctx, cached, false); err != nil {
+		return nil, err
+	}
+	return WritePreviewJSON(ctx, cached, sandbox)
+}
+
+// ValidateOnlyPreviewJSON sends the files of proj to the preview with validateOnly set, so the
+// server runs the full validation of a preview without writing the draft or redeploying the
+// cloud function, and returns the issues it found.
+func ValidateOnlyPreviewJSON(ctx context.Context, proj project.Project, sandbox bool) ([]ValidationIssue, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	log.Outf("Validating files in the project %q for preview with Actions Console. The draft and the preview are not changed.\n", projectID)
+	_, results, err := writePreview(ctx, client, projectID, proj, sandbox, true)
+	if err != nil {
+		return nil, err
+	}
+	log.DoneMsgln(validationDoneMsg(results))
+	return validationIssues(results), nil
+}
+
+// writePreview sends the files of src to the preview of the project with projectID, and returns
+// the URL of the simulator and the validation results of the server. If validateOnly is true,
+// the preview isn't written.
+func writePreview(ctx context.Context, client *http.Client, projectID string, src project.Project, sandbox, validateOnly bool) (string, []validationResult, error) {
+	src, err := withDecryptedValues(ctx, client, src)
+	if err != nil {
+		return "", nil, err
+	}
+	requestURL := httpAddr(ctx, previewHTTPEndpoint(projectID))
+	waitMsg := "Waiting for server to respond. It could take up to 1 minute if your cloud function needs to be redeployed."
+	if validateOnly {
+		waitMsg = "Waiting for server to respond..."
+	}
+	req, streamErr, err := newFilesRequest(ctx, requestURL, projectID, src, waitMsg, func() map[string]interface{} {
+		return request.WritePreview(projectID, sandbox, validateOnly)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	// Sets timeout because Cloud Function deployment can take 1-2 minutes.
+	req.Header.Add("X-Server-Timeout", serverTimeout(ctx, previewServerTimeout))
+	errCh := make(chan error, 1)
+	// simulatorURL and results are set before the error is sent to errCh.
+	var simulatorURL string
+	var results []validationResult
+	// This goroutine will exit after HTTP call is finished.
+	go func() {
+		resp, err := doStream(client, req)
+		if err != nil {
+			errCh <- requestError(err, streamErr)
+			return
+		}
+		defer resp.Body.Close()
+		postprocessJSONResponse(resp, errCh, func(body []byte) error {
+			var err error
+			simulatorURL, results, err = procWritePreviewResponse(body)
+			return err
+		})
+	}()
+	if err := <-errCh; err != nil {
+		return "", nil, err
+	}
+	if silent(ctx) {
+		return simulatorURL, results, nil
+	}
+	printValidationIssues(src.ProjectRoot(), results, validateOnly)
+	// Nothing is deployed to the simulator when the files are only validated.
+	if simulatorURL == "" && !validateOnly {
+		log.Warnf("The API response body doesn't contain the simulator link.")
+	}
+	return simulatorURL, results, nil
+}
+
+func procCreateVersionResponse(channel string, body []byte) (string, error) {
+	resp := &CreateVersionHTTPResponse{}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(resp); err != nil {
+		return "", errors.New(string(body))
+	}
+	versionIDRegExp := regexp.MustCompile("^projects/[^//]+/versions/(?P<versionID>[^//]+)$")
+	if versionIDMatch := versionIDRegExp.FindStringSubmatch(resp.Name); versionIDMatch == nil {
+		log.Debugln(fmt.Sprintf("version id absent in the response %s returned from the server ", resp.Name))
+		return "", nil
+	}
+	return versionIDRegExp.FindStringSubmatch(resp.Name)[versionIDRegExp.SubexpIndex("versionID")], nil
+}
+
+// CreateVersionJSON implements CreateVersion functionality of the SDK server via HTTP/JSON streaming.
+// The Actions API doesn't store release notes, so non-empty releaseNotes are recorded with the
+// version in the history.
+func CreateVersionJSON(ctx context.Context, proj project.Project, channel, releaseNotes string) error {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return err
+	}
+	projectID := proj.ProjectID()
+	src, err := withDecryptedValues(ctx, client, proj)
+	if err != nil {
+		return err
+	}
+	log.Outf("Deploying files in the project %q to the %q release channel...", projectID, channel)
+	versionID, err := createVersion(ctx, client, projectID, src, channel)
+	if err != nil {
+		return err
+	}
+	if releaseNotes == "" {
+		recordHistory(ctx, client, proj, "deploy", versionID, channel)
+	} else if files, err := proj.Files(); err != nil {
+		log.Warnf("Failed to record this deploy in the history: %v\n", err)
+	} else {
+		e := studio.NewHistoryEntry("deploy", projectID, versionID, channel, files)
+		e.ReleaseNotes = releaseNotes
+		recordHistoryEntry(ctx, client, proj, e)
+	}
+	if _, ok := BuiltInReleaseChannels[channel]; ok {
+		channel = BuiltInReleaseChannels[channel]
+	}
+	if err := log.SetStepOutput("version-id", versionID); err != nil {
+		log.Warnf("Failed to set the version-id output of the workflow step: %v\n", err)
 	}
 
 	log.DoneMsgln(fmt.Sprintf("Version %s has been successfully created and submitted for deployment to %s channel. ", versionID, channel))
 	return nil
 }
 
+// createVersion sends the files of src to create a version of the project with projectID on
+// channel, and returns the ID of the version.
+func createVersion(ctx context.Context, client *http.Client, projectID string, src project.Project, channel string) (string, error) {
+	requestURL := httpAddr(ctx, versionHTTPEndpoint(projectID))
+	req, streamErr, err := newFilesRequest(ctx, requestURL, projectID, src, "Waiting for server to respond...", func() map[string]interface{} {
+		return request.CreateVersion(projectID, channel)
+	})
+	if err != nil {
+		return "", err
+	}
+	errCh := make(chan error, 1)
+	var versionID string
+	// This goroutine will exit after HTTP call is finished.
+	go func() {
+		resp, err := doStream(client, req)
+		if err != nil {
+			errCh <- requestError(err, streamErr)
+			return
+		}
+		defer resp.Body.Close()
+		// TODO: Change signature of postProcessJSONResponse to return an error, and pipe that error to channel here.
+		postprocessJSONResponse(resp, errCh, func(body []byte) error {
+			v, err := procCreateVersionResponse(channel, body)
+			versionID = v
+			return err
+		})
+	}()
+	if err := <-errCh; err != nil {
+		return "", err
+	}
+	return versionID, nil
+}
+
 func keyInConfigResp(path string) (string, error) {
 	var k string
 	switch {
@@ -636,32 +1103,123 @@ func keyInConfigResp(path string) (string, error) {
 	return k, nil
 }
 
-func receiveConfigFiles(proj project.Project, cfgs *configFiles, force bool, seen map[string]bool) error {
+// writeJob is a file received from server, which was confirmed to be written to disk.
+type writeJob struct {
+	path        string
+	contentType string
+	payload     []byte
+}
+
+// diskWriter writes files received from server using several workers. Overwrites of the
+// existing files are confirmed before the files are queued, so the user is asked about the
+// files in the same order as they arrive in the stream.
+type diskWriter struct {
+	proj  project.Project
+	force bool
+	jobs  chan writeJob
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	err   error
+}
+
+// newDiskWriter returns a diskWriter which writes up to n files in parallel.
+func newDiskWriter(proj project.Project, force bool, n int) *diskWriter {
+	if n < 1 {
+		n = 1
+	}
+	d := &diskWriter{proj: proj, force: force, jobs: make(chan writeJob, n)}
+	for i := 0; i < n; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for j := range d.jobs {
+				if d.firstErr() != nil {
+					// Drain the queue, so write isn't blocked.
+					continue
+				}
+				// Overwrite was already confirmed by write.
+				if err := studio.WriteToDisk(d.proj, j.path, j.contentType, j.payload, true); err != nil {
+					d.setErr(err)
+				}
+			}
+		}()
+	}
+	return d
+}
+
+func (d *diskWriter) firstErr() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *diskWriter) setErr(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// write queues the file to be written to disk, once the user confirms an overwrite of the
+// existing file. It returns an error if any of the previously queued files failed to be written.
+func (d *diskWriter) write(path string, contentType string, payload []byte) error {
+	if err := d.firstErr(); err != nil {
+		return err
+	}
+	ok, err := studio.ConfirmOverwrite(d.proj, path, contentType, d.force)
+	if err != nil || !ok {
+		return err
+	}
+	d.jobs <- writeJob{path: path, contentType: contentType, payload: payload}
+	return nil
+}
+
+// wait waits for all of the queued files to be written. It returns the first error
+// encountered by the workers. diskWriter can't be used after this call.
+func (d *diskWriter) wait() error {
+	close(d.jobs)
+	d.wg.Wait()
+	return d.firstErr()
+}
+
+// configFileYAML returns the path and the YAML content of a config file received from server.
+func configFileYAML(cfg map[string]interface{}) (string, []byte, error) {
+	p, ok := cfg["filePath"]
+	if !ok {
+		return "", nil, fmt.Errorf("%v doesn't have required filePath field", cfg)
+	}
+	path, ok := p.(string)
+	if !ok {
+		return "", nil, fmt.Errorf("%v has a key of %v of incorrect type %T, want string", cfg, p, p)
+	}
+	k, err := keyInConfigResp(path)
+	if err != nil {
+		return "", nil, err
+	}
+	v := cfg[k]
+	// Transform v into YAML.
+	mp, ok := v.(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("%v has a key %v of incorrect type %T", cfg, v, v)
+	}
+	b, err := yaml.Marshal(mp)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, b, nil
+}
+
+func receiveConfigFiles(w *diskWriter, cfgs *configFiles, seen map[string]bool, filter studio.PathFilter) error {
 	for _, cfg := range cfgs.ConfigFiles {
-		p, ok := cfg["filePath"]
-		if !ok {
-			return fmt.Errorf("%v doesn't have required filePath field", cfg)
-		}
-		path, ok := p.(string)
-		if !ok {
-			return fmt.Errorf("%v has a key of %v of incorrect type %T, want string", cfg, p, p)
-		}
-		k, err := keyInConfigResp(path)
+		path, b, err := configFileYAML(cfg)
 		if err != nil {
 			return err
 		}
-		v := cfg[k]
-		// Transform v into YAML.
-		mp, ok := v.(map[string]interface{})
-		if !ok {
-			return fmt.Errorf("%v has a key %v of incorrect type %T", cfg, v, v)
-		}
-		b, err := yaml.Marshal(mp)
-		if err != nil {
-			return err
+		if !filter.Match(path) {
+			continue
 		}
-		// TODO: Can be spun as go-routine.
-		if err := studio.WriteToDisk(proj, path, "", b, force); err != nil {
+		if err := w.write(path, "", b); err != nil {
 			return err
 		}
 		seen[path] = true
@@ -669,9 +1227,21 @@ func receiveConfigFiles(proj project.Project, cfgs *configFiles, force bool, see
 	return nil
 }
 
-func receiveDataFiles(proj project.Project, dfs *dataFiles, force bool, seen map[string]bool) error {
+// pulledDataFile returns true if filter matches the data file with filePath and contentType. A
+// cloud function is matched by its zip or by the directory it's extracted to.
+func pulledDataFile(filter studio.PathFilter, filePath, contentType string) bool {
+	if filter.Match(filePath) {
+		return true
+	}
+	return contentType == "application/zip;zip_type=cloud_function" && filter.Match(strings.TrimSuffix(filePath, ".zip"))
+}
+
+func receiveDataFiles(w *diskWriter, dfs *dataFiles, seen map[string]bool, filter studio.PathFilter) error {
 	for _, df := range dfs.DataFiles {
-		if err := studio.WriteToDisk(proj, df.Filepath, df.ContentType, df.Payload, force); err != nil {
+		if !pulledDataFile(filter, df.Filepath, df.ContentType) {
+			continue
+		}
+		if err := w.write(df.Filepath, df.ContentType, df.Payload); err != nil {
 			return err
 		}
 		if df.ContentType != "application/zip;zip_type=cloud_function" {
@@ -691,8 +1261,20 @@ func receiveDataFiles(proj project.Project, dfs *dataFiles, force bool, seen map
 	return nil
 }
 
-func receiveStream(proj project.Project, body io.Reader, force bool, seen map[string]bool) error {
-	dec := json.NewDecoder(body)
+// receiveStream writes the files in the streamed response body to the disk, and records their
+// paths in seen. Only the files matched by filter are written.
+func receiveStream(proj project.Project, body io.Reader, force bool, seen map[string]bool, filter studio.PathFilter) (err error) {
+	w := newDiskWriter(proj, force, pullWriteWorkers)
+	defer func() {
+		// Files are still being written if the stream failed to be processed,
+		// so wait for workers to finish, but report the error of the stream first.
+		if err2 := w.wait(); err == nil {
+			err = err2
+		}
+	}()
+	progress := log.NewProgress("Receiving", 0, 0)
+	defer progress.Done()
+	dec := json.NewDecoder(progressReader{r: body, p: progress})
 	log.Debugln("Starts processing the stream")
 	// Reads "[".
 	t, err := dec.Token()
@@ -707,16 +1289,18 @@ func receiveStream(proj project.Project, body io.Reader, force bool, seen map[st
 		if err := dec.Decode(&rec); err != nil {
 			return err
 		}
+		received := len(seen)
 		if rec.Files.ConfigFiles != nil {
-			if err := receiveConfigFiles(proj, rec.Files.ConfigFiles, force, seen); err != nil {
+			if err := receiveConfigFiles(w, rec.Files.ConfigFiles, seen, filter); err != nil {
 				return err
 			}
 		}
 		if rec.Files.DataFiles != nil {
-			if err := receiveDataFiles(proj, rec.Files.DataFiles, force, seen); err != nil {
+			if err := receiveDataFiles(w, rec.Files.DataFiles, seen, filter); err != nil {
 				return err
 			}
 		}
+		progress.Add(0, len(seen)-received)
 	}
 	// Reads "]".
 	t, err = dec.Token()
@@ -761,11 +1345,11 @@ func findExtra(a map[string][]byte, b map[string]bool) []string {
 }
 
 func addClientHeaders(req *http.Request) {
-	if Consumer != "" {
-		req.Header.Add("Gactions-Consumer", Consumer)
+	cfg := ConfigFrom(req.Context())
+	if cfg.Consumer != "" {
+		req.Header.Add("Gactions-Consumer", cfg.Consumer)
 	}
-	ua := fmt.Sprintf("gactions/%s (%s %s)", versions.CliVersion, runtime.GOOS, runtime.GOARCH)
-	req.Header.Add("User-Agent", ua)
+	req.Header.Add("User-Agent", cfg.userAgent())
 }
 
 func parseEncryptionKeyVersion(files map[string][]byte) string {
@@ -783,15 +1367,16 @@ func parseEncryptionKeyVersion(files map[string][]byte) string {
 	return f.EncryptionKeyVersion
 }
 
-// ReadDraftJSON implements ReadDraft functionality of SDK server.
-func ReadDraftJSON(ctx context.Context, proj project.Project, force bool, clean bool) error {
+// ReadDraftJSON implements ReadDraft functionality of SDK server. Only the files matched by
+// filter are pulled.
+func ReadDraftJSON(ctx context.Context, proj project.Project, force, clean, dryRun bool, filter studio.PathFilter) error {
 	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return err
 	}
 	projectID := proj.ProjectID()
 	log.Outf("Pulling files in the project %q from Actions Console...\n", projectID)
-	requestURL := httpAddr(readDraftHTTPEndpoint(projectID))
+	requestURL := httpAddr(ctx, readDraftHTTPEndpoint(projectID))
 	warn := "%v is not present in the draft of your Action"
 	files, err := proj.Files()
 	if err != nil {
@@ -801,10 +1386,17 @@ func ReadDraftJSON(ctx context.Context, proj project.Project, force bool, clean
 	if err != nil {
 		return err
 	}
-	return sendRequest(client, requestURL, body, files, proj, warn, force, clean)
+	if err := sendRequest(ctx, client, requestURL, body, files, proj, warn, force, clean, dryRun, filter); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	recordHistory(ctx, client, proj, "pull", "draft", "")
+	return nil
 }
 
-func procEncryptSecretResponse(proj project.Project, body []byte) error {
+func procEncryptSecretResponse(proj project.Project, body []byte, env string) error {
 	r := EncryptSecretHTTPResponse{}
 	if err := json.Unmarshal(body, &r); err != nil {
 		return err
@@ -813,20 +1405,18 @@ func procEncryptSecretResponse(proj project.Project, body []byte) error {
 	if err != nil {
 		return err
 	}
-	if err := studio.WriteToDisk(proj, "settings/accountLinkingSecret.yaml", "", b, false); err != nil {
+	p := studio.AccountLinkingSecretPath(env)
+	if err := studio.WriteToDisk(proj, p, "", b, false); err != nil {
 		return err
 	}
-	log.DoneMsgln(fmt.Sprintf("Encrypted secret is in %s", filepath.Join(proj.ProjectRoot(), "settings", "accountLinkingSecret.yaml")))
+	log.DoneMsgln(fmt.Sprintf("Encrypted secret is in %s", filepath.Join(proj.ProjectRoot(), filepath.FromSlash(p))))
 	return nil
 }
 
-// EncryptSecretJSON implements Encrypt functionality of SDK server.
-func EncryptSecretJSON(ctx context.Context, proj project.Project, secret string) error {
-	clientSecret, err := proj.ClientSecretJSON()
-	if err != nil {
-		return err
-	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+// EncryptSecretJSON implements Encrypt functionality of SDK server. The encrypted secret is
+// written to the account linking secret file of env, or to the default one if env is empty.
+func EncryptSecretJSON(ctx context.Context, proj project.Project, secret, env string) error {
+	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return err
 	}
@@ -836,12 +1426,12 @@ func EncryptSecretJSON(ctx context.Context, proj project.Project, secret string)
 	// Should to refactor postprocessJSONResponse to avoid channels.
 	errCh := make(chan error, 1)
 	go func() {
-		requestURL := httpAddr(encryptEndpoint)
+		requestURL := httpAddr(ctx, encryptEndpoint)
 		body, err := json.Marshal(request.EncryptSecret(secret))
 		if err != nil {
 			errCh <- err
 		}
-		req, err := http.NewRequest("POST", requestURL, bytes.NewReader(body))
+		req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewReader(body))
 		if err != nil {
 			errCh <- err
 		}
@@ -853,7 +1443,7 @@ func EncryptSecretJSON(ctx context.Context, proj project.Project, secret string)
 		}
 		defer resp.Body.Close()
 		postprocessJSONResponse(resp, errCh, func(body []byte) error {
-			return procEncryptSecretResponse(proj, body)
+			return procEncryptSecretResponse(proj, body, env)
 		})
 	}()
 	if err := <-errCh; err != nil {
@@ -862,76 +1452,300 @@ func EncryptSecretJSON(ctx context.Context, proj project.Project, secret string)
 	return nil
 }
 
-func procDecryptSecretResponse(proj project.Project, body []byte, out string) error {
-	type resp struct {
-		ClientSecret string `json:"clientSecret"`
-	}
-	r := resp{}
-	if err := json.Unmarshal(body, &r); err != nil {
-		return err
-	}
-	rel, err := filepath.Rel(proj.ProjectRoot(), out)
-	if err != nil {
-		return err
-	}
-	if err := studio.WriteToDisk(proj, rel, "", []byte(r.ClientSecret), false); err != nil {
-		return err
-	}
-	log.Warnf("Decrypted key will be stored at %s. Committing this file to source control is not recommend.\n", out)
-	log.DoneMsgln(fmt.Sprintf("Decrypted client secret key is in %s.", out))
-	return nil
-}
-
-// DecryptSecretJSON implements Decrypt functionality of SDK server.
-func DecryptSecretJSON(ctx context.Context, proj project.Project, secret string, out string) error {
-	clientSecret, err := proj.ClientSecretJSON()
-	if err != nil {
-		return err
-	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+// EncryptSecret returns the contents of an account linking secret file which holds secret
+// encrypted by the SDK server.
+func EncryptSecret(ctx context.Context, proj project.Project, secret string) ([]byte, error) {
+	client, err := setupClient(ctx, proj)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	log.Outf("Decrypting your client secret...")
-	requestURL := httpAddr(decryptEndpoint)
-	body, err := json.Marshal(request.DecryptSecret(secret))
+	body, err := json.Marshal(request.EncryptSecret(secret))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	req, err := http.NewRequest("POST", requestURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", httpAddr(ctx, encryptEndpoint), bytes.NewReader(body))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	addClientHeaders(req)
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
-	// Using a channel and goroutine is not ideal here, but this allows one to
-	// reuse postprocessJSONResponse function.
-	// Should to refactor postprocessJSONResponse to avoid channels.
 	errCh := make(chan error, 1)
+	var res []byte
 	postprocessJSONResponse(resp, errCh, func(body []byte) error {
-		return procDecryptSecretResponse(proj, body, out)
+		r := EncryptSecretHTTPResponse{}
+		if err := json.Unmarshal(body, &r); err != nil {
+			return err
+		}
+		res, err = yaml.Marshal(r.AccountLinkingSecret)
+		return err
 	})
-	return <-errCh
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
-func sendListRequest(pageToken, requestURL string, client *http.Client) ([]byte, error) {
-	// List API must not have a body, so encoding request fields into a URL.
-	u, err := url.Parse(requestURL)
+// accountLinkingSecret is the content of an account linking secret file.
+type accountLinkingSecret struct {
+	EncryptedClientSecret string `yaml:"encryptedClientSecret"`
+	EncryptionKeyVersion  string `yaml:"encryptionKeyVersion"`
+}
+
+func parseAccountLinkingSecret(b []byte) (accountLinkingSecret, error) {
+	var r accountLinkingSecret
+	if err := yaml.Unmarshal(b, &r); err != nil {
+		return r, err
+	}
+	if r.EncryptedClientSecret == "" {
+		return r, errors.New("encryptedClientSecret is missing")
+	}
+	return r, nil
+}
+
+// RotateSecretJSON re-encrypts the account linking secret of env with the latest encryption key
+// of the SDK server, and rewrites the secret file. The plain text is only held in memory. It
+// returns the versions of the key the secret was encrypted with before and after.
+func RotateSecretJSON(ctx context.Context, proj project.Project, env string) (from, to string, err error) {
+	client, err := setupClient(ctx, proj)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
-	q := u.Query()
-	q.Set("pageToken", pageToken)
-	u.RawQuery = q.Encode()
-	requestURL = u.String()
-	req, err := http.NewRequest("GET", requestURL, nil)
+	p := studio.AccountLinkingSecretPath(env)
+	fp := filepath.Join(proj.ProjectRoot(), filepath.FromSlash(p))
+	b, err := ioutil.ReadFile(fp)
 	if err != nil {
-		return nil, err
+		return "", "", fmt.Errorf("can not read the client secret: %v. Run \"gactions encrypt\" to encrypt one", err)
+	}
+	old, err := parseAccountLinkingSecret(b)
+	if err != nil {
+		return "", "", fmt.Errorf("%v is invalid: %v", fp, err)
+	}
+	log.Outf("Re-encrypting the client secret in %v...\n", fp)
+	plain, err := decryptSecret(ctx, client, old.EncryptedClientSecret)
+	if err != nil {
+		return "", "", err
+	}
+	b, err = EncryptSecret(ctx, proj, plain)
+	if err != nil {
+		return "", "", err
+	}
+	rotated, err := parseAccountLinkingSecret(b)
+	if err != nil {
+		return "", "", fmt.Errorf("server returned an invalid secret: %v", err)
+	}
+	if err := studio.WriteToDisk(proj, p, "", b, true); err != nil {
+		return "", "", err
+	}
+	return old.EncryptionKeyVersion, rotated.EncryptionKeyVersion, nil
+}
+
+// EncryptValue returns value encrypted by the SDK server, tagged to be put in a config file.
+// Encrypted values are decrypted when the files are pushed.
+func EncryptValue(ctx context.Context, proj project.Project, value string) (string, error) {
+	b, err := EncryptSecret(ctx, proj, value)
+	if err != nil {
+		return "", err
+	}
+	r, err := parseAccountLinkingSecret(b)
+	if err != nil {
+		return "", fmt.Errorf("server did not return an encrypted value: %v", err)
+	}
+	return studio.EncryptedValue(r.EncryptedClientSecret), nil
+}
+
+// decryptedProject is a project whose YAML files have their encrypted values replaced with
+// the plain text.
+type decryptedProject struct {
+	project.Project
+	yamls map[string][]byte
+}
+
+func (p decryptedProject) Files() (map[string][]byte, error) {
+	files, err := p.Project.Files()
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]byte{}
+	for k, v := range files {
+		res[k] = v
+	}
+	for k, v := range p.yamls {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (p decryptedProject) FileRefs() (map[string]project.File, error) {
+	files, err := p.Project.FileRefs()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range p.yamls {
+		files[k] = project.InMemoryFile(v)
+	}
+	return files, nil
+}
+
+// cachedProject is a project whose files are listed once, so several requests with the same
+// files don't walk the project directory again. The contents of data files are still read from
+// disk when they are sent.
+type cachedProject struct {
+	project.Project
+	refs  map[string]project.File
+	once  sync.Once
+	files map[string][]byte
+	err   error
+}
+
+func newCachedProject(proj project.Project) (*cachedProject, error) {
+	refs, err := proj.FileRefs()
+	if err != nil {
+		return nil, err
+	}
+	return &cachedProject{Project: proj, refs: refs}, nil
+}
+
+func (p *cachedProject) Files() (map[string][]byte, error) {
+	p.once.Do(func() {
+		p.files, p.err = studio.ReadFiles(p.refs, func(string) bool { return true })
+	})
+	if p.err != nil {
+		return nil, p.err
+	}
+	res := map[string][]byte{}
+	for k, v := range p.files {
+		res[k] = v
+	}
+	return res, nil
+}
+
+// FileRefs returns a copy of the files, because callers may change the map.
+func (p *cachedProject) FileRefs() (map[string]project.File, error) {
+	res := map[string]project.File{}
+	for k, v := range p.refs {
+		res[k] = v
+	}
+	return res, nil
+}
+
+// withDecryptedValues returns proj with the encrypted values of its config files decrypted by
+// the SDK server, or proj itself if it has no encrypted values. It's only used to send the files,
+// so the plain text isn't recorded in the push state or the history.
+func withDecryptedValues(ctx context.Context, client *http.Client, proj project.Project) (project.Project, error) {
+	refs, err := proj.FileRefs()
+	if err != nil {
+		return nil, err
+	}
+	// Only YAML files can hold encrypted values, so the data files aren't read.
+	files, err := studio.ReadFiles(refs, func(filename string) bool {
+		return path.Ext(filename) == ".yaml"
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, n, err := studio.DecryptValues(files, func(c string) (string, error) {
+		return decryptSecret(ctx, client, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return proj, nil
+	}
+	log.Infof("Decrypted %v encrypted values in the config files.\n", n)
+	return decryptedProject{Project: proj, yamls: res}, nil
+}
+
+func procDecryptSecretResponse(body []byte) (string, error) {
+	type resp struct {
+		ClientSecret string `json:"clientSecret"`
+	}
+	r := resp{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return "", err
+	}
+	return r.ClientSecret, nil
+}
+
+// DecryptSecret returns the plain text of a client secret encrypted by the SDK server.
+func DecryptSecret(ctx context.Context, proj project.Project, secret string) (string, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return "", err
+	}
+	return decryptSecret(ctx, client, secret)
+}
+
+func decryptSecret(ctx context.Context, client *http.Client, secret string) (string, error) {
+	requestURL := httpAddr(ctx, decryptEndpoint)
+	body, err := json.Marshal(request.DecryptSecret(secret))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	addClientHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	// Using a channel and goroutine is not ideal here, but this allows one to
+	// reuse postprocessJSONResponse function.
+	// Should to refactor postprocessJSONResponse to avoid channels.
+	errCh := make(chan error, 1)
+	var plain string
+	postprocessJSONResponse(resp, errCh, func(body []byte) error {
+		v, err := procDecryptSecretResponse(body)
+		plain = v
+		return err
+	})
+	if err := <-errCh; err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+// DecryptSecretJSON implements Decrypt functionality of SDK server.
+func DecryptSecretJSON(ctx context.Context, proj project.Project, secret string, out string) error {
+	log.Outf("Decrypting your client secret...")
+	plain, err := DecryptSecret(ctx, proj, secret)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(proj.ProjectRoot(), out)
+	if err != nil {
+		return err
+	}
+	if err := studio.WriteToDisk(proj, rel, "", []byte(plain), false); err != nil {
+		return err
+	}
+	log.Warnf("Decrypted key will be stored at %s. Committing this file to source control is not recommend.\n", out)
+	log.DoneMsgln(fmt.Sprintf("Decrypted client secret key is in %s.", out))
+	return nil
+}
+
+func sendListRequest(ctx context.Context, pageToken, requestURL string, client *http.Client) ([]byte, error) {
+	// List API must not have a body, so encoding request fields into a URL.
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("pageToken", pageToken)
+	u.RawQuery = q.Encode()
+	requestURL = u.String()
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, err
 	}
 	addClientHeaders(req)
 	resp, err := client.Do(req)
@@ -951,20 +1765,16 @@ func sendListRequest(pageToken, requestURL string, client *http.Client) ([]byte,
 
 // ListSampleProjectsJSON implements ListSampleProjects endpoint of SDK server.
 func ListSampleProjectsJSON(ctx context.Context, proj project.Project) ([]project.SampleProject, error) {
-	clientSecret, err := proj.ClientSecretJSON()
-	if err != nil {
-		return nil, err
-	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return nil, err
 	}
-	requestURL := httpAddr(listSampleProjectsEndpoint)
+	requestURL := httpAddr(ctx, listSampleProjectsEndpoint)
 	var res []project.SampleProject
 	pageToken := ""
 
 	for {
-		body, err := sendListRequest(pageToken, requestURL, client)
+		body, err := sendListRequest(ctx, pageToken, requestURL, client)
 		if err != nil {
 			return nil, err
 		}
@@ -989,8 +1799,48 @@ func ListSampleProjectsJSON(ctx context.Context, proj project.Project) ([]projec
 	return res, nil
 }
 
-// ReadVersionJSON implements ReadVersion functionality of SDK server.
-func ReadVersionJSON(ctx context.Context, proj project.Project, force bool, clean bool, versionID string) error {
+// ListProjectsJSON implements ListProjects endpoint of SDK server, which returns the Actions
+// projects the user has access to.
+func ListProjectsJSON(ctx context.Context, proj project.Project) ([]project.ActionsProject, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	requestURL := httpAddr(ctx, listProjectsEndpoint)
+	var res []project.ActionsProject
+	pageToken := ""
+
+	for {
+		body, err := sendListRequest(ctx, pageToken, requestURL, client)
+		if err != nil {
+			return nil, err
+		}
+		type listProjectsResponse struct {
+			Projects      []project.ActionsProject `json:"projects"`
+			NextPageToken string                   `json:"nextPageToken"`
+		}
+		r := listProjectsResponse{}
+		if err = json.Unmarshal(body, &r); err != nil {
+			return nil, err
+		}
+		pageToken = r.NextPageToken
+		for _, v := range r.Projects {
+			if v.ProjectID == "" {
+				// API returns projects/{projectID}.
+				v.ProjectID = strings.TrimPrefix(v.Name, "projects/")
+			}
+			res = append(res, v)
+		}
+		if pageToken == "" {
+			break
+		}
+	}
+	return res, nil
+}
+
+// ReadVersionJSON implements ReadVersion functionality of SDK server. Only the files matched by
+// filter are pulled.
+func ReadVersionJSON(ctx context.Context, proj project.Project, force, clean, dryRun bool, versionID string, filter studio.PathFilter) error {
 	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return err
@@ -998,7 +1848,7 @@ func ReadVersionJSON(ctx context.Context, proj project.Project, force bool, clea
 
 	projectID := proj.ProjectID()
 	log.Outf("Pulling version %q of the project %q from Actions Console...\n", versionID, projectID)
-	requestURL := httpAddr(readVersionHTTPEndpoint(projectID, versionID))
+	requestURL := httpAddr(ctx, readVersionHTTPEndpoint(projectID, versionID))
 	warning := "%v is not present in the version of your Action"
 
 	files, err := proj.Files()
@@ -1010,27 +1860,101 @@ func ReadVersionJSON(ctx context.Context, proj project.Project, force bool, clea
 		return err
 	}
 
-	return sendRequest(client, requestURL, body, files, proj, warning, force, clean)
+	if err := sendRequest(ctx, client, requestURL, body, files, proj, warning, force, clean, dryRun, filter); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	recordHistory(ctx, client, proj, "pull", versionID, "")
+	return nil
 }
 
-func setupClient(ctx context.Context, proj project.Project) (*http.Client, error) {
+// setupClient returns a client authorized with the credentials of the login profile bound to
+// the project in the CLI config, or with the default credentials if no profile is bound. scopes
+// are the scopes of other Google APIs the command needs, in addition to the Actions API.
+func setupClient(ctx context.Context, proj project.Project, scopes ...string) (*http.Client, error) {
 	clientSecret, err := proj.ClientSecretJSON()
 	if err != nil {
 		return nil, err
 	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+	cfg, err := studio.ReadCLIConfig()
+	if err != nil {
+		return nil, err
+	}
+	profile := activeProfile(ctx, cfg)
+	tokenFile, err := apiutils.ProfileTokenFile(profile)
+	if err != nil {
+		return nil, err
+	}
+	useADC := ConfigFrom(ctx).UseADC || cfg.UseADC
+	if cfg.CloudAuditLog {
+		// Pushes, pulls and deploys are recorded in Cloud Logging of the project.
+		scopes = append(scopes, apiutils.LoggingWriteScope)
+	}
+	if profile != "" && os.Getenv(apiutils.CredentialsEnv) == "" && !useADC {
+		if _, err := os.Stat(tokenFile); os.IsNotExist(err) {
+			if ConfigFrom(ctx).Profile != "" {
+				return nil, &Error{Kind: AuthError, Err: fmt.Errorf(`command requires authentication to the profile %q. try to run "gactions login --profile %v" first`, profile, profile)}
+			}
+			return nil, &Error{Kind: AuthError, Err: fmt.Errorf(`command requires authentication to the profile %q, which is bound to the project in %v. try to run "gactions login --profile %v" first`, profile, project.ConfigName, profile)}
+		}
+		log.Infof("Using the credentials of the profile %q.\n", profile)
+	}
+	ctx, err = WithTransport(ctx)
 	if err != nil {
 		return nil, err
 	}
+	client, err := apiutils.NewHTTPClient(ctx, clientSecret, tokenFile, useADC, scopes...)
+	if err != nil {
+		// The token is missing or can't be read, or there are no default credentials.
+		return nil, &Error{Kind: AuthError, Err: err}
+	}
+	retry := &retryTransport{base: client.Transport, maxAttempts: ConfigFrom(ctx).MaxAttempts}
+	client.Transport = &accessChecker{base: retry, profile: profile, projectID: proj.ProjectID()}
 	return client, nil
 }
 
-func sendRequest(client *http.Client, requestURL string, body []byte, files map[string][]byte, proj project.Project, warning string, force, clean bool) error {
-	projectID := proj.ProjectID()
+// activeProfile returns the login profile of the command: Profile of the Config of ctx if it's
+// set, or the profile bound to the project in cfg otherwise.
+func activeProfile(ctx context.Context, cfg project.CLIConfig) string {
+	if p := ConfigFrom(ctx).Profile; p != "" {
+		return p
+	}
+	return cfg.Profile
+}
 
-	req, err := http.NewRequest("POST", requestURL, bytes.NewReader(body))
+// accessChecker warns the user once if a request is denied, which usually means that the
+// account the CLI is logged in with doesn't have access to the project.
+type accessChecker struct {
+	base      http.RoundTripper
+	profile   string
+	projectID string
+	once      sync.Once
+}
+
+func (c *accessChecker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusForbidden && c.projectID != "" {
+		c.once.Do(func() {
+			account := "The account you are logged in with"
+			login := "gactions login"
+			if c.profile != "" {
+				account = fmt.Sprintf("The account of the profile %q", c.profile)
+				login = fmt.Sprintf("gactions login --profile %v", c.profile)
+			}
+			log.Warnf("%v doesn't have access to the project %q. Check the projectId in settings/settings.yaml and the profile in %v, or run %q with an account that has access to the project.\n", account, c.projectID, project.ConfigName, login)
+		})
+	}
+	return resp, err
+}
+
+// openStream sends a request to a read endpoint of SDK server, and returns the body of the
+// streamed response. The caller must close the body.
+func openStream(ctx context.Context, client *http.Client, requestURL string, body []byte, projectID string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", requestURL, bytes.NewReader(body))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req.Header.Add("Content-Type", "application/json")
 	// This is done to help server select the quota attributed to a
@@ -1040,30 +1964,56 @@ func sendRequest(client *http.Client, requestURL string, body []byte, files map[
 	addClientHeaders(req)
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
 		// In case of an error, it's okay to read entire response body because
 		// it will be small.
 		body, err := readBodyWithTimeout(resp.Body, responseBodyReadTimeout)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		log.Debugln(string(body))
 		publicErrors := []PublicError{}
 		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&publicErrors); err != nil {
 			// This means the error is not a JSON. This happens when the API URL is malformed, and
 			// one platform returns an HTML response. In this case, we print the HTML and disregard the json decoding error.
-			return fmt.Errorf(string(body))
+			return nil, &Error{Kind: statusKind(resp.StatusCode), Err: fmt.Errorf(string(body))}
 		}
 		if len(publicErrors) > 0 {
-			return fmt.Errorf("server did not return HTTP 200\n%v", errorMessage(&publicErrors[0]))
+			return nil, &Error{Kind: publicErrorKind(&publicErrors[0]), Err: fmt.Errorf("server did not return HTTP 200\n%v", errorMessage(&publicErrors[0]))}
 		}
-		return errors.New("server did not return HTTP 200")
+		return nil, &Error{Kind: statusKind(resp.StatusCode), Err: errors.New("server did not return HTTP 200")}
+	}
+	return resp.Body, nil
+}
+
+func sendRequest(ctx context.Context, client *http.Client, requestURL string, body []byte, files map[string][]byte, proj project.Project, warning string, force, clean, dryRun bool, filter studio.PathFilter) error {
+	respBody, err := openStream(ctx, client, requestURL, body, proj.ProjectID())
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	// Local files outside of the filter are neither overwritten nor removed by --clean.
+	files = filterFiles(files, filter)
+	if dryRun {
+		pulled, functionDirs, err := filesFromStream(respBody)
+		if err != nil {
+			return err
+		}
+		pulled = filterFiles(pulled, filter)
+		var dirs []string
+		for _, d := range functionDirs {
+			if filter.Match(d) {
+				dirs = append(dirs, d)
+			}
+		}
+		printPullPlan(planPull(proj.ProjectRoot(), files, pulled, dirs, clean))
+		return nil
 	}
 	seen := map[string]bool{}
-	if err := receiveStream(proj, resp.Body, force, seen); err != nil {
+	if err := receiveStream(proj, respBody, force, seen, filter); err != nil {
 		return err
 	}
 	extra := findExtra(files, seen)
@@ -1082,22 +2032,108 @@ func sendRequest(client *http.Client, requestURL string, body []byte, files map[
 	return nil
 }
 
+// filterFiles returns the files matched by filter.
+func filterFiles(files map[string][]byte, filter studio.PathFilter) map[string][]byte {
+	if filter == nil {
+		return files
+	}
+	res := map[string][]byte{}
+	for k, v := range files {
+		if filter.Match(k) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// PullPlan lists the local files a pull would change, by their paths relative to the project
+// root.
+type PullPlan struct {
+	// Write are the files which don't exist locally.
+	Write []string
+	// Overwrite are the local files with a different content.
+	Overwrite []string
+	// Delete are the local files which aren't pulled, and are removed by --clean or because
+	// they are in the directory of a cloud function, which is replaced as a whole.
+	Delete []string
+	// Keep are the local files which aren't pulled, and are kept without --clean.
+	Keep []string
+}
+
+// planPull returns the changes to the local files of the project in root if the pulled files
+// were written to disk.
+func planPull(root string, local, pulled map[string][]byte, functionDirs []string, clean bool) PullPlan {
+	var res PullPlan
+	for k, v := range pulled {
+		if b, ok := local[k]; ok {
+			if !bytes.Equal(b, v) {
+				res.Overwrite = append(res.Overwrite, k)
+			}
+			continue
+		}
+		// Hidden and ignored files aren't project files, but they are overwritten all the same.
+		if root != "" {
+			if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(k))); err == nil {
+				res.Overwrite = append(res.Overwrite, k)
+				continue
+			}
+		}
+		res.Write = append(res.Write, k)
+	}
+	for k := range local {
+		if _, ok := pulled[k]; ok {
+			continue
+		}
+		replaced := false
+		for _, d := range functionDirs {
+			if strings.HasPrefix(k, d+"/") {
+				replaced = true
+			}
+		}
+		if clean || replaced {
+			res.Delete = append(res.Delete, k)
+		} else {
+			res.Keep = append(res.Keep, k)
+		}
+	}
+	sort.Strings(res.Write)
+	sort.Strings(res.Overwrite)
+	sort.Strings(res.Delete)
+	sort.Strings(res.Keep)
+	return res
+}
+
+func printPullPlan(plan PullPlan) {
+	for _, v := range []struct {
+		label string
+		files []string
+	}{
+		{"write", plan.Write},
+		{"overwrite", plan.Overwrite},
+		{"delete", plan.Delete},
+	} {
+		for _, f := range v.files {
+			log.Outf("  %-10v %v\n", v.label, f)
+		}
+	}
+	for _, f := range plan.Keep {
+		log.Outf("  %-10v %v (not pulled; run pull with --clean to delete it)\n", "keep", f)
+	}
+	log.Outf("%d to write, %d to overwrite, %d to delete. No files were changed, because --dry-run was set.\n", len(plan.Write), len(plan.Overwrite), len(plan.Delete))
+}
+
 // ListReleaseChannelsJSON implements ListReleaseChannels endpoint of SDK server.
 func ListReleaseChannelsJSON(ctx context.Context, proj project.Project) ([]project.ReleaseChannel, error) {
-	clientSecret, err := proj.ClientSecretJSON()
-	if err != nil {
-		return nil, err
-	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return nil, err
 	}
-	requestURL := httpAddr(listReleaseChannelsHTTPEndpoint(proj.ProjectID()))
+	requestURL := httpAddr(ctx, listReleaseChannelsHTTPEndpoint(proj.ProjectID()))
 	var res []project.ReleaseChannel
 	pageToken := ""
 
 	for {
-		body, err := sendListRequest(pageToken, requestURL, client)
+		body, err := sendListRequest(ctx, pageToken, requestURL, client)
 		if err != nil {
 			return nil, err
 		}
@@ -1124,20 +2160,16 @@ func ListReleaseChannelsJSON(ctx context.Context, proj project.Project) ([]proje
 
 // ListVersionsJSON implements ListVersions endpoint of SDK server.
 func ListVersionsJSON(ctx context.Context, proj project.Project) ([]project.Version, error) {
-	clientSecret, err := proj.ClientSecretJSON()
-	if err != nil {
-		return nil, err
-	}
-	client, err := apiutils.NewHTTPClient(ctx, clientSecret, "")
+	client, err := setupClient(ctx, proj)
 	if err != nil {
 		return nil, err
 	}
-	requestURL := httpAddr(listVersionsHTTPEndpoint(proj.ProjectID()))
+	requestURL := httpAddr(ctx, listVersionsHTTPEndpoint(proj.ProjectID()))
 	var res []project.Version
 	pageToken := ""
 
 	for {
-		body, err := sendListRequest(pageToken, requestURL, client)
+		body, err := sendListRequest(ctx, pageToken, requestURL, client)
 		if err != nil {
 			return nil, err
 		}
@@ -1161,3 +2193,170 @@ func ListVersionsJSON(ctx context.Context, proj project.Project) ([]project.Vers
 	}
 	return res, nil
 }
+
+// configFilesFromStream returns the YAML content of the config files in a streamed response of
+// a read endpoint for which keep returns true, by path. Data files are skipped.
+func configFilesFromStream(body io.Reader, keep func(path string) bool) (map[string][]byte, error) {
+	dec := json.NewDecoder(body)
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if t != json.Delim('[') {
+		return nil, fmt.Errorf("expected [ got %v", t)
+	}
+	res := map[string][]byte{}
+	for dec.More() {
+		var rec streamRecord
+		if err := dec.Decode(&rec); err != nil {
+			return nil, err
+		}
+		if rec.Files.ConfigFiles == nil {
+			continue
+		}
+		for _, cfg := range rec.Files.ConfigFiles.ConfigFiles {
+			if p, _ := cfg["filePath"].(string); !keep(p) {
+				continue
+			}
+			path, b, err := configFileYAML(cfg)
+			if err != nil {
+				return nil, err
+			}
+			res[path] = b
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// filesFromZip returns the files in a zipped cloud function, by their paths under dir.
+func filesFromZip(dir string, content []byte) (map[string][]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]byte{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		res[path.Join(dir, f.Name)] = b
+	}
+	return res, nil
+}
+
+// filesFromStream returns the files in a streamed response of a read endpoint, by path, laid
+// out the same way as pull writes them to disk: config files are YAML, and cloud functions are
+// unzipped. It also returns the directories of the cloud functions, which pull replaces as a
+// whole.
+func filesFromStream(body io.Reader) (map[string][]byte, []string, error) {
+	dec := json.NewDecoder(body)
+	t, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if t != json.Delim('[') {
+		return nil, nil, fmt.Errorf("expected [ got %v", t)
+	}
+	res := map[string][]byte{}
+	var functionDirs []string
+	for dec.More() {
+		var rec streamRecord
+		if err := dec.Decode(&rec); err != nil {
+			return nil, nil, err
+		}
+		if rec.Files.ConfigFiles != nil {
+			for _, cfg := range rec.Files.ConfigFiles.ConfigFiles {
+				path, b, err := configFileYAML(cfg)
+				if err != nil {
+					return nil, nil, err
+				}
+				res[path] = b
+			}
+		}
+		if rec.Files.DataFiles != nil {
+			for _, df := range rec.Files.DataFiles.DataFiles {
+				if df.ContentType != "application/zip;zip_type=cloud_function" {
+					res[df.Filepath] = df.Payload
+					continue
+				}
+				dir := strings.TrimSuffix(df.Filepath, ".zip")
+				files, err := filesFromZip(dir, df.Payload)
+				if err != nil {
+					return nil, nil, err
+				}
+				for k, v := range files {
+					res[k] = v
+				}
+				functionDirs = append(functionDirs, dir)
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return res, functionDirs, nil
+}
+
+// ReadDraftFilesJSON returns the files of the draft of proj, by path, without writing them to
+// disk.
+func ReadDraftFilesJSON(ctx context.Context, proj project.Project) (map[string][]byte, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	files, err := proj.Files()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(request.ReadDraft(projectID, parseEncryptionKeyVersion(files)))
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := openStream(ctx, client, httpAddr(ctx, readDraftHTTPEndpoint(projectID)), body, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	res, _, err := filesFromStream(respBody)
+	return res, err
+}
+
+// ReadSettingsJSON returns the settings files of proj, by path, without writing them to disk.
+// The settings are read from the version with versionID, or from the draft if versionID is empty.
+func ReadSettingsJSON(ctx context.Context, proj project.Project, versionID string) (map[string][]byte, error) {
+	client, err := setupClient(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+	projectID := proj.ProjectID()
+	requestURL := httpAddr(ctx, readDraftHTTPEndpoint(projectID))
+	req := request.ReadDraft(projectID, "")
+	if versionID != "" {
+		requestURL = httpAddr(ctx, readVersionHTTPEndpoint(projectID, versionID))
+		req = request.ReadVersion(projectID, versionID)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := openStream(ctx, client, requestURL, body, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer respBody.Close()
+	return configFilesFromStream(respBody, studio.IsSettings)
+}
+
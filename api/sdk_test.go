@@ -15,11 +15,19 @@
 package sdk
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	stdlog "log"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
@@ -32,6 +40,7 @@ import (
 	"github.com/actions-on-google/gactions/api/request"
 	"github.com/actions-on-google/gactions/api/testutils"
 	"github.com/actions-on-google/gactions/api/yamlutils"
+	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/google/go-cmp/cmp"
@@ -72,6 +81,10 @@ func (p MockStudio) Files() (map[string][]byte, error) {
 	return p.files, nil
 }
 
+func (p MockStudio) FileRefs() (map[string]project.File, error) {
+	return project.InMemoryFiles(p.files), nil
+}
+
 func (MockStudio) ClientSecretJSON() ([]byte, error) {
 	return []byte{}, nil
 }
@@ -301,10 +314,10 @@ func TestSendFilesToServerJSON(t *testing.T) {
 			ch <- b
 			errCh <- err
 		}()
-		err := sendFilesToServerJSON(p, w, func() map[string]interface{} {
+		err := sendFilesToServerJSON(context.Background(), p, w, func() map[string]interface{} {
 			// TODO: Parametrize this to enable testing of various requests.
 			// This will remove need for request tests in request_test.
-			return request.WriteDraft("placeholder_project")
+			return request.WriteDraft("placeholder_project", false)
 		})
 		gotBytes := <-ch
 		if err := <-errCh; err != nil {
@@ -404,7 +417,7 @@ func TestProcWritePreviewResponse(t *testing.T) {
 		},
 	}
 	for _, tc := range tests {
-		gotURL, err := procWritePreviewResponse(tc.in)
+		gotURL, _, err := procWritePreviewResponse(tc.in)
 		if err != nil {
 			t.Errorf("procWritePreviewResponse returned %v, but want %v, input %v", err, nil, tc.in)
 		}
@@ -446,7 +459,7 @@ func TestProcWriteDraftResponse(t *testing.T) {
 		},
 	}
 	for _, tc := range tests {
-		if err := procWriteDraftResponse([]byte(tc.body)); err != nil {
+		if _, err := procWriteDraftResponse([]byte(tc.body)); err != nil {
 			t.Errorf("procWriteDraftResponse returned %v, but want %v", err, nil)
 		}
 	}
@@ -661,7 +674,7 @@ func TestReceiveStream(t *testing.T) {
 			}()
 			proj := studio.New([]byte("secret"), dirName)
 			seen := map[string]bool{}
-			if err := receiveStream(proj, strings.NewReader(tc.body), false, seen); err != nil {
+			if err := receiveStream(proj, strings.NewReader(tc.body), false, seen, nil); err != nil {
 				t.Errorf("receiveStream returned %v, but expected to return %v", err, nil)
 			}
 			for _, v := range tc.wantFiles {
@@ -679,6 +692,52 @@ func TestReceiveStream(t *testing.T) {
 	}
 }
 
+func TestReceiveStreamFilter(t *testing.T) {
+	body := `[
+  {
+    "files": {
+      "configFiles": {
+        "configFiles": [
+          {"filePath": "settings/settings.yaml", "settings": {"category": "GAMES_AND_TRIVIA"}},
+          {"filePath": "custom/intents/yes.yaml", "intent": {"trainingPhrases": ["yes"]}}
+        ]
+      }
+    }
+  },
+  {
+    "files": {
+      "dataFiles": {
+        "dataFiles": [
+          {"filePath": "resources/images/foo.png", "contentType": "images/png", "payload": ""}
+        ]
+      }
+    }
+  }
+]`
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	filter, err := studio.NewPathFilter([]string{"custom/intents/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj := studio.New([]byte("secret"), dirName)
+	seen := map[string]bool{}
+	if err := receiveStream(proj, strings.NewReader(body), false, seen, filter); err != nil {
+		t.Fatalf("receiveStream returned %v, but expected to return %v", err, nil)
+	}
+	if want := map[string]bool{"custom/intents/yes.yaml": true}; !cmp.Equal(seen, want) {
+		t.Errorf("receiveStream marked %v as seen, want %v", seen, want)
+	}
+	for _, v := range []string{"settings/settings.yaml", "resources/images/foo.png"} {
+		if _, err := os.Stat(filepath.Join(dirName, filepath.FromSlash(v))); !os.IsNotExist(err) {
+			t.Errorf("receiveStream wrote %v, which isn't matched by the filter", v)
+		}
+	}
+}
+
 func TestFindExtra(t *testing.T) {
 	tests := []struct {
 		a    map[string][]byte
@@ -732,3 +791,591 @@ func TestFindExtra(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkSendFilesToServerJSON(b *testing.B) {
+	files := map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: placeholder_project"),
+		"manifest.yaml":          []byte("version: 1.0"),
+	}
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf("resources/audio/audio%d.mp3", i)] = bytes.Repeat([]byte{byte(i)}, 100*1024)
+	}
+	p := NewMock(files)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r, w := io.Pipe()
+		errCh := make(chan error)
+		go func() {
+			_, err := io.Copy(ioutil.Discard, r)
+			errCh <- err
+		}()
+		if err := sendFilesToServerJSON(context.Background(), p, w, func() map[string]interface{} {
+			return request.WriteDraft("placeholder_project", false)
+		}); err != nil {
+			b.Fatalf("sendFilesToServerJSON returned %v, want %v", err, nil)
+		}
+		if err := <-errCh; err != nil {
+			b.Fatalf("Unable to read from pipe: %v", err)
+		}
+	}
+}
+
+func TestAnnotateValidationResults(t *testing.T) {
+	old := log.OutLogger
+	defer func() { log.OutLogger = old }()
+	var b bytes.Buffer
+	log.OutLogger = stdlog.New(&b, "", 0)
+	for k, v := range map[string]string{"GITHUB_ACTIONS": "true", "GITHUB_WORKSPACE": ""} {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		defer func(k string) {
+			if ok {
+				os.Setenv(k, old)
+			} else {
+				os.Unsetenv(k)
+			}
+		}(k)
+	}
+	var results []validationResult
+	for _, v := range []struct {
+		locale string
+		msg    string
+	}{
+		{locale: "en", msg: "Scene 'Order' in custom/scenes/Order.yaml has no transitions."},
+		{msg: "Display name is missing."},
+	} {
+		r := validationResult{ValidationMessage: v.msg}
+		r.ValidationContext.LanguageCode = v.locale
+		results = append(results, r)
+	}
+	annotateValidationResults("", results)
+	want := "::warning file=custom/scenes/Order.yaml::[en] Scene 'Order' in custom/scenes/Order.yaml has no transitions.\n" +
+		"::warning::Display name is missing.\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("annotateValidationResults printed incorrect commands: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestConfigFilesFromStream(t *testing.T) {
+	body := `[
+  {"files": {"configFiles": {"configFiles": [
+    {"filePath": "settings/settings.yaml", "settings": {"defaultLocale": "en", "projectId": "hello-world"}},
+    {"filePath": "settings/fr/settings.yaml", "settings": {"localizedSettings": {"displayName": "Bonjour"}}},
+    {"filePath": "custom/global/actions.intent.MAIN.yaml", "globalIntentEvent": {"transitionToScene": "Main"}}
+  ]}}},
+  {"files": {"dataFiles": {"dataFiles": [{"filePath": "resources/images/a.png", "contentType": "image/png", "payload": "cG5n"}]}}}
+]`
+	want := map[string][]byte{
+		"settings/settings.yaml":    []byte("defaultLocale: en\nprojectId: hello-world\n"),
+		"settings/fr/settings.yaml": []byte("localizedSettings:\n  displayName: Bonjour\n"),
+	}
+	got, err := configFilesFromStream(strings.NewReader(body), studio.IsSettings)
+	if err != nil {
+		t.Errorf("configFilesFromStream returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("configFilesFromStream returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestFilesFromStream(t *testing.T) {
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for name, content := range map[string]string{"index.js": "exports.f = 1;", "package.json": "{}"} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Can't add %v to zip: %v", name, err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Can't close zip: %v", err)
+	}
+	body := fmt.Sprintf(`[
+  {"files": {"configFiles": {"configFiles": [
+    {"filePath": "settings/settings.yaml", "settings": {"defaultLocale": "en", "projectId": "hello-world"}}
+  ]}}},
+  {"files": {"dataFiles": {"dataFiles": [
+    {"filePath": "resources/images/a.png", "contentType": "image/png", "payload": "cG5n"},
+    {"filePath": "webhooks/ActionsOnGoogleFulfillment.zip", "contentType": "application/zip;zip_type=cloud_function", "payload": %q}
+  ]}}}
+]`, base64.StdEncoding.EncodeToString(zipped.Bytes()))
+	want := map[string][]byte{
+		"settings/settings.yaml":                           []byte("defaultLocale: en\nprojectId: hello-world\n"),
+		"resources/images/a.png":                           []byte("png"),
+		"webhooks/ActionsOnGoogleFulfillment/index.js":     []byte("exports.f = 1;"),
+		"webhooks/ActionsOnGoogleFulfillment/package.json": []byte("{}"),
+	}
+	got, dirs, err := filesFromStream(strings.NewReader(body))
+	if err != nil {
+		t.Errorf("filesFromStream returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("filesFromStream returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"webhooks/ActionsOnGoogleFulfillment"}, dirs); diff != "" {
+		t.Errorf("filesFromStream returned incorrect function directories: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestIncrementalPushSendsAllFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "gactions")
+	if err != nil {
+		t.Fatalf("Can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(root)
+	files := map[string]string{
+		"settings/settings.yaml":   "projectId: my-project\n",
+		"manifest.yaml":            "version: \"1.0\"\n",
+		"actions/actions.yaml":     "custom: {}\n",
+		"resources/images/a.png":   "png",
+		"custom/intents/main.yaml": "trainingPhrases:\n- hi\n",
+	}
+	write := func(name, content string) {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+			t.Fatalf("Can't create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0640); err != nil {
+			t.Fatalf("Can't write file: %v", err)
+		}
+	}
+	for k, v := range files {
+		write(k, v)
+	}
+	proj := studio.New([]byte("secret"), root)
+	digests, err := pushDigests(proj)
+	if err != nil {
+		t.Fatalf("pushDigests returned %v, want %v", err, nil)
+	}
+	if err := savePushState(proj, digests); err != nil {
+		t.Fatalf("savePushState returned %v, want %v", err, nil)
+	}
+	prev, err := studio.ReadPushState(root)
+	if err != nil {
+		t.Fatalf("ReadPushState returned %v, want %v", err, nil)
+	}
+	if !unchangedSincePush(digests, prev) {
+		t.Errorf("unchangedSincePush returned %v, want %v", false, true)
+	}
+
+	write("custom/intents/main.yaml", "trainingPhrases:\n- hello\n")
+	digests, err = pushDigests(proj)
+	if err != nil {
+		t.Fatalf("pushDigests returned %v, want %v", err, nil)
+	}
+	if unchangedSincePush(digests, prev) {
+		t.Errorf("unchangedSincePush returned %v after a file changed, want %v", true, false)
+	}
+	// The draft is replaced by the files of the push, so the unchanged files must be sent too.
+	r, w := io.Pipe()
+	ch := make(chan []byte)
+	go func() {
+		b, _ := ioutil.ReadAll(r)
+		ch <- b
+	}()
+	if err := sendFilesToServerJSON(context.Background(), proj, w, func() map[string]interface{} {
+		return request.WriteDraft("my-project", false)
+	}); err != nil {
+		t.Fatalf("sendFilesToServerJSON returned %v, want %v", err, nil)
+	}
+	var records []streamRecord
+	if err := json.Unmarshal(<-ch, &records); err != nil {
+		t.Fatalf("json.Unmarshal returned %v, want %v", err, nil)
+	}
+	var got []string
+	for _, r := range records {
+		if r.Files.ConfigFiles != nil {
+			for _, v := range r.Files.ConfigFiles.ConfigFiles {
+				got = append(got, v["filePath"].(string))
+			}
+		}
+		if r.Files.DataFiles != nil {
+			for _, v := range r.Files.DataFiles.DataFiles {
+				got = append(got, v.Filepath)
+			}
+		}
+	}
+	var want []string
+	for k := range files {
+		want = append(want, k)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("sendFilesToServerJSON sent diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestPlanPull(t *testing.T) {
+	root, err := ioutil.TempDir("", "gactions")
+	if err != nil {
+		t.Fatalf("Can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(root)
+	// Ignored files aren't in the project files, but still exist on disk.
+	if err := ioutil.WriteFile(filepath.Join(root, "ignored.yaml"), []byte("a"), 0640); err != nil {
+		t.Fatalf("Can't write file: %v", err)
+	}
+	local := map[string][]byte{
+		"settings/settings.yaml":                       []byte("projectId: a\n"),
+		"actions/actions.yaml":                         []byte("same\n"),
+		"resources/images/old.png":                     []byte("png"),
+		"webhooks/ActionsOnGoogleFulfillment/stale.js": []byte("x"),
+	}
+	pulled := map[string][]byte{
+		"settings/settings.yaml":                       []byte("projectId: b\n"),
+		"actions/actions.yaml":                         []byte("same\n"),
+		"custom/intents/new.yaml":                      []byte("new\n"),
+		"ignored.yaml":                                 []byte("b"),
+		"webhooks/ActionsOnGoogleFulfillment/index.js": []byte("y"),
+	}
+	dirs := []string{"webhooks/ActionsOnGoogleFulfillment"}
+	tests := []struct {
+		clean bool
+		want  PullPlan
+	}{
+		{
+			clean: false,
+			want: PullPlan{
+				Write:     []string{"custom/intents/new.yaml", "webhooks/ActionsOnGoogleFulfillment/index.js"},
+				Overwrite: []string{"ignored.yaml", "settings/settings.yaml"},
+				Delete:    []string{"webhooks/ActionsOnGoogleFulfillment/stale.js"},
+				Keep:      []string{"resources/images/old.png"},
+			},
+		},
+		{
+			clean: true,
+			want: PullPlan{
+				Write:     []string{"custom/intents/new.yaml", "webhooks/ActionsOnGoogleFulfillment/index.js"},
+				Overwrite: []string{"ignored.yaml", "settings/settings.yaml"},
+				Delete:    []string{"resources/images/old.png", "webhooks/ActionsOnGoogleFulfillment/stale.js"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		got := planPull(root, local, pulled, dirs, tc.clean)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("planPull(clean=%v) returned incorrect plan: diff (-want, +got)\n%s", tc.clean, diff)
+		}
+	}
+}
+
+type statusTransport int
+
+func (s statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: int(s), Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestAccessChecker(t *testing.T) {
+	old := log.WarnLogger
+	defer func() { log.WarnLogger = old }()
+	tests := []struct {
+		status  int
+		profile string
+		want    string
+	}{
+		{
+			status: http.StatusOK,
+			want:   "",
+		},
+		{
+			status: http.StatusForbidden,
+			want:   "The account you are logged in with doesn't have access to the project \"my-project\"",
+		},
+		{
+			status:  http.StatusForbidden,
+			profile: "work",
+			want:    "The account of the profile \"work\" doesn't have access to the project \"my-project\"",
+		},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		log.WarnLogger = stdlog.New(&b, "", 0)
+		client := &http.Client{Transport: &accessChecker{base: statusTransport(tc.status), profile: tc.profile, projectID: "my-project"}}
+		// The warning is only printed once per client.
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get("https://actions.googleapis.com/v2/projects/my-project/versions")
+			if err != nil {
+				t.Fatalf("Get returned %v", err)
+			}
+			resp.Body.Close()
+		}
+		got := b.String()
+		if tc.want == "" && got != "" {
+			t.Errorf("accessChecker with status %v warned %q, want no warning", tc.status, got)
+		}
+		if tc.want != "" && (!strings.Contains(got, tc.want) || strings.Count(got, "\n") != 1) {
+			t.Errorf("accessChecker with status %v and profile %q warned %q, want one warning containing %q", tc.status, tc.profile, got, tc.want)
+		}
+	}
+}
+
+func TestWithDecryptedValues(t *testing.T) {
+	client := &http.Client{Transport: responseTransport{status: http.StatusOK, body: `{"clientSecret": "plain"}`}}
+	files := map[string][]byte{
+		"webhooks/ActionsOnGoogleFulfillment.yaml": []byte("httpsEndpoint:\n  apiKey: !encrypted Q2lRQQ==\n"),
+	}
+	proj := NewMock(files)
+	got, err := withDecryptedValues(context.Background(), client, proj)
+	if err != nil {
+		t.Fatalf("withDecryptedValues returned %v, want %v", err, nil)
+	}
+	gotFiles, err := got.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{
+		"webhooks/ActionsOnGoogleFulfillment.yaml": []byte("httpsEndpoint:\n  apiKey: \"plain\"\n"),
+	}
+	if diff := cmp.Diff(want, gotFiles); diff != "" {
+		t.Errorf("withDecryptedValues returned diff (-want, +got)\n%s", diff)
+	}
+	if got.ProjectID() != proj.ProjectID() {
+		t.Errorf("withDecryptedValues returned a project with ID %q, want %q", got.ProjectID(), proj.ProjectID())
+	}
+	plain := NewMock(map[string][]byte{"settings/settings.yaml": []byte("projectId: my-project\n")})
+	got, err = withDecryptedValues(context.Background(), client, plain)
+	if err != nil {
+		t.Fatalf("withDecryptedValues returned %v, want %v", err, nil)
+	}
+	if _, ok := got.(MockStudio); !ok {
+		t.Errorf("withDecryptedValues returned %T for a project without encrypted values, want %T", got, plain)
+	}
+}
+
+func TestServerTimeout(t *testing.T) {
+	if got := serverTimeout(context.Background(), 3*time.Minute); got != "180" {
+		t.Errorf("serverTimeout returned %v without a deadline, want %v", got, "180")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if got := serverTimeout(ctx, 3*time.Minute); got != "59" && got != "60" {
+		t.Errorf("serverTimeout returned %v with a deadline in 1m, want %v", got, "59")
+	}
+	if got := serverTimeout(ctx, 10*time.Second); got != "10" {
+		t.Errorf("serverTimeout returned %v with a later deadline, want %v", got, "10")
+	}
+}
+
+func TestRequestsStopWhenContextIsDone(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		var v map[string]interface{}
+		errCh <- getJSON(ctx, srv.Client(), srv.URL, &v)
+	}()
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Errorf("getJSON returned %v after the context was done, but want an error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("getJSON didn't return after the context was done")
+	}
+}
+
+// recordingTransport records the first request of the streams it receives, decompressed if they
+// are sent with gzip, and responds with body.
+type recordingTransport struct {
+	body  string
+	first map[string]interface{}
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = zr
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	var reqs []map[string]interface{}
+	if err := json.Unmarshal(b, &reqs); err != nil {
+		return nil, err
+	}
+	if len(reqs) > 0 {
+		r.first = reqs[0]
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(r.body)), Request: req}, nil
+}
+
+func TestValidateOnlyRequests(t *testing.T) {
+	p := NewMock(map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: my-project"),
+		"manifest.yaml":          []byte("version: 1.0"),
+	})
+	body := `{"validationResults": {"results": [{"validationMessage": "Your app must have a 32x32 logo"}]}}`
+	tests := []struct {
+		name  string
+		write func(client *http.Client, validateOnly bool) ([]validationResult, error)
+	}{
+		{
+			name: "draft",
+			write: func(client *http.Client, validateOnly bool) ([]validationResult, error) {
+				return writeDraft(context.Background(), client, "my-project", p, validateOnly)
+			},
+		},
+		{
+			name: "preview",
+			write: func(client *http.Client, validateOnly bool) ([]validationResult, error) {
+				_, results, err := writePreview(context.Background(), client, "my-project", p, true, validateOnly)
+				return results, err
+			},
+		},
+	}
+	for _, tc := range tests {
+		for _, validateOnly := range []bool{false, true} {
+			tr := &recordingTransport{body: body}
+			results, err := tc.write(&http.Client{Transport: tr}, validateOnly)
+			if err != nil {
+				t.Errorf("write %v with validateOnly %v returned %v, want %v", tc.name, validateOnly, err, nil)
+				continue
+			}
+			if len(results) != 1 {
+				t.Errorf("write %v with validateOnly %v returned %v results, want 1", tc.name, validateOnly, len(results))
+			}
+			if got, _ := tr.first["validateOnly"].(bool); got != validateOnly {
+				t.Errorf("write %v with validateOnly %v sent validateOnly %v in %v, want %v", tc.name, validateOnly, got, tr.first, validateOnly)
+			}
+		}
+	}
+}
+
+// earlyStatusTransport answers the requests with statuses, in order. Like a server which rejects
+// a request before reading it, requests answered with an error aren't read. Other requests are
+// read in full, and answered with body.
+type earlyStatusTransport struct {
+	statuses []int
+	body     string
+	// bodies are the bodies of the requests which were read.
+	bodies []string
+}
+
+func (t *earlyStatusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := t.statuses[0]
+	t.statuses = t.statuses[1:]
+	if status != http.StatusOK {
+		return &http.Response{StatusCode: status, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	t.bodies = append(t.bodies, string(b))
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(t.body)), Request: req}, nil
+}
+
+func TestWriteDraftRetriesEarlyResponse(t *testing.T) {
+	stubSleep(t)
+	p := NewMock(map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: my-project"),
+		"manifest.yaml":          []byte("version: 1.0"),
+	})
+	tr := &earlyStatusTransport{statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, body: `{"name": "projects/my-project/draft"}`}
+	client := &http.Client{Transport: &retryTransport{base: tr, maxAttempts: defaultMaxAttempts}}
+	if _, err := writeDraft(context.Background(), client, "my-project", p, false); err != nil {
+		t.Fatalf("writeDraft returned %v, want %v", err, nil)
+	}
+	if len(tr.statuses) != 0 {
+		t.Errorf("writeDraft sent %v requests, want 2", 2-len(tr.statuses))
+	}
+	if len(tr.bodies) != 1 {
+		t.Fatalf("writeDraft sent %v bodies in full, want 1", len(tr.bodies))
+	}
+	var reqs []map[string]interface{}
+	if err := json.Unmarshal([]byte(tr.bodies[0]), &reqs); err != nil || len(reqs) == 0 {
+		t.Errorf("writeDraft sent %q in the retry, want a JSON array of requests", tr.bodies[0])
+	}
+}
+
+func TestWriteDraftReturnsStreamError(t *testing.T) {
+	p := NewMock(map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: my-project"),
+	})
+	tr := &earlyStatusTransport{statuses: []int{http.StatusOK}}
+	_, err := writeDraft(context.Background(), &http.Client{Transport: tr}, "my-project", p, false)
+	if err == nil || strings.Contains(err.Error(), "Post") {
+		t.Errorf("writeDraft without a manifest returned %v, want the error of the stream", err)
+	}
+}
+
+func TestCheckValidationIssues(t *testing.T) {
+	if err := CheckValidationIssues(nil); err != nil {
+		t.Errorf("CheckValidationIssues(nil) returned %v, want %v", err, nil)
+	}
+	issues := []ValidationIssue{{Message: "Your app must have a 32x32 logo"}, {Message: "Description is missing"}}
+	err := CheckValidationIssues(issues)
+	var ve *ValidationFailedError
+	if !errors.As(err, &ve) || len(ve.Issues) != 2 {
+		t.Fatalf("CheckValidationIssues returned %v, want a *ValidationFailedError with 2 issues", err)
+	}
+	if want := "server found 2 validation issues"; err.Error() != want {
+		t.Errorf("CheckValidationIssues returned %q, want %q", err.Error(), want)
+	}
+}
+
+// countingProject counts the calls to FileRefs of the project.
+type countingProject struct {
+	project.Project
+	refs *int
+}
+
+func (p countingProject) FileRefs() (map[string]project.File, error) {
+	*p.refs++
+	return p.Project.FileRefs()
+}
+
+func TestCachedProject(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml":   []byte("projectId: my-project"),
+		"resources/images/foo.png": []byte("png"),
+	}
+	n := 0
+	p, err := newCachedProject(countingProject{Project: NewMock(files), refs: &n})
+	if err != nil {
+		t.Fatalf("newCachedProject returned %v, want %v", err, nil)
+	}
+	for i := 0; i < 2; i++ {
+		refs, err := p.FileRefs()
+		if err != nil {
+			t.Fatalf("FileRefs returned %v, want %v", err, nil)
+		}
+		// Callers such as decryptedProject change the returned map.
+		delete(refs, "resources/images/foo.png")
+		got, err := p.Files()
+		if err != nil {
+			t.Fatalf("Files returned %v, want %v", err, nil)
+		}
+		if diff := cmp.Diff(files, got); diff != "" {
+			t.Errorf("Files returned incorrect files: diff (-want, +got)\n%s", diff)
+		}
+	}
+	if n != 1 {
+		t.Errorf("cachedProject listed the files of the project %v times, want 1", n)
+	}
+}
+
+func TestParseAccountLinkingSecret(t *testing.T) {
+	got, err := parseAccountLinkingSecret([]byte("encryptedClientSecret: abc\nencryptionKeyVersion: \"2\"\n"))
+	if err != nil {
+		t.Fatalf("parseAccountLinkingSecret returned %v, want %v", err, nil)
+	}
+	if want := (accountLinkingSecret{EncryptedClientSecret: "abc", EncryptionKeyVersion: "2"}); got != want {
+		t.Errorf("parseAccountLinkingSecret returned %+v, want %+v", got, want)
+	}
+	if _, err := parseAccountLinkingSecret([]byte("encryptionKeyVersion: \"2\"\n")); err == nil {
+		t.Errorf("parseAccountLinkingSecret returned %v for a file without a secret, want an error", err)
+	}
+}
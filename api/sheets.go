@@ -0,0 +1,74 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+func sheetValuesEndpoint(sheetID, tab string) string {
+	return fmt.Sprintf("v4/spreadsheets/%s/values/%s", url.PathEscape(sheetID), url.PathEscape(tab))
+}
+
+// ReadSheetJSON returns the rows of a tab of a Google Sheet. The sheet is read with the OAuth
+// token of the user, so it must be shared with the user.
+func ReadSheetJSON(ctx context.Context, proj project.Project, sheetID, tab string) ([][]string, error) {
+	client, err := setupClient(ctx, proj, apiutils.SheetsScope)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+sheetsURL+"/"+sheetValuesEndpoint(sheetID, tab), nil)
+	if err != nil {
+		return nil, err
+	}
+	addClientHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == 403 {
+		log.Errorf(`Can't read sheet %v. Check that the sheet is shared with you. If you logged in before Google Sheets support was added, run "gactions logout" and "gactions login" to grant access to your sheets.`, sheetID)
+	}
+	if resp.StatusCode != 200 {
+		return nil, parseError(body)
+	}
+	return parseSheetValues(body)
+}
+
+func parseSheetValues(body []byte) ([][]string, error) {
+	// Cells are strings, because the API formats values by default.
+	type valueRange struct {
+		Values [][]string `json:"values"`
+	}
+	r := valueRange{}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	return r.Values, nil
+}
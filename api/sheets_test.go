@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSheetValues(t *testing.T) {
+	body := []byte(`{
+  "range": "types!A1:D3",
+  "majorDimension": "ROWS",
+  "values": [
+    ["type", "value", "locale", "synonyms"],
+    ["size", "large", "", "large; big"],
+    ["size", "small"]
+  ]
+}`)
+	want := [][]string{
+		{"type", "value", "locale", "synonyms"},
+		{"size", "large", "", "large; big"},
+		{"size", "small"},
+	}
+	got, err := parseSheetValues(body)
+	if err != nil {
+		t.Errorf("parseSheetValues returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseSheetValues returned incorrect rows: diff (-want, +got)\n%s", diff)
+	}
+	if got, want := sheetValuesEndpoint("abc", "my types"), "v4/spreadsheets/abc/values/my%20types"; got != want {
+		t.Errorf("sheetValuesEndpoint returned %v, want %v", got, want)
+	}
+}
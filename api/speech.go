@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// speechInput returns the input of a synthesize request for the speech of a prompt, which is
+// SSML if it's wrapped in a speak element.
+func speechInput(speech string) map[string]string {
+	if s := strings.TrimSpace(speech); strings.HasPrefix(s, "<speak") {
+		return map[string]string{"ssml": s}
+	}
+	return map[string]string{"text": speech}
+}
+
+// SynthesizeSpeechJSON returns the speech of a prompt as MP3 audio, synthesized by the Cloud
+// Text-to-Speech API in languageCode with the OAuth token of the user. If voice is empty, the API
+// picks a voice for languageCode. The quota of the project of proj is used, if it is set.
+func SynthesizeSpeechJSON(ctx context.Context, proj project.Project, speech, languageCode, voice string) ([]byte, error) {
+	client, err := setupClient(ctx, proj, apiutils.CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	v := map[string]string{"languageCode": languageCode}
+	if voice != "" {
+		v["name"] = voice
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"input":       speechInput(speech),
+		"voice":       v,
+		"audioConfig": map[string]string{"audioEncoding": "MP3"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://"+textToSpeechURL+"/v1/text:synthesize", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if pid := proj.ProjectID(); pid != "" {
+		req.Header.Add("X-Goog-User-Project", pid)
+	}
+	addClientHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := readBodyWithTimeout(resp.Body, responseBodyReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == 403 {
+		log.Errorf(`Can't synthesize speech. Check that the Cloud Text-to-Speech API is enabled on the project. If you logged in before speech synthesis was added, run "gactions logout" and "gactions login" to grant access to Google Cloud.`)
+	}
+	if resp.StatusCode != 200 {
+		return nil, parseError(body)
+	}
+	var r struct {
+		AudioContent []byte `json:"audioContent"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, err
+	}
+	return r.AudioContent, nil
+}
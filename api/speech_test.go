@@ -0,0 +1,36 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpeechInput(t *testing.T) {
+	tests := []struct {
+		speech string
+		want   map[string]string
+	}{
+		{"Hello.", map[string]string{"text": "Hello."}},
+		{" <speak>Hi <break time=\"1s\"/></speak>\n", map[string]string{"ssml": "<speak>Hi <break time=\"1s\"/></speak>"}},
+	}
+	for _, tc := range tests {
+		if diff := cmp.Diff(tc.want, speechInput(tc.speech)); diff != "" {
+			t.Errorf("speechInput(%q) returned diff (-want, +got)\n%s", tc.speech, diff)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/actions-on-google/gactions/project"
+)
+
+// releaseChannelID returns the ID of a release channel from its resource name
+// (i.e. projects/{projectID}/releaseChannels/{releaseChannelID}).
+func releaseChannelID(name string) string {
+	if i := strings.LastIndex(name, "/releaseChannels/"); i >= 0 {
+		return name[i+len("/releaseChannels/"):]
+	}
+	return strings.TrimPrefix(name, "releaseChannels/")
+}
+
+// findReleaseChannel returns the release channel with name, which is either the ID of the
+// channel (i.e. actions.channels.Production), its short name (i.e. prod) or the ID without the
+// "actions.channels." prefix (i.e. Production).
+func findReleaseChannel(channels []project.ReleaseChannel, name string) (project.ReleaseChannel, error) {
+	for _, v := range channels {
+		id := releaseChannelID(v.Name)
+		if id == name || id == "actions.channels."+name || BuiltInReleaseChannels[id] == name {
+			return v, nil
+		}
+	}
+	return project.ReleaseChannel{}, fmt.Errorf("release channel %q was not found", name)
+}
+
+// ReadReleaseChannelJSON returns the details of the release channel of proj with name. API
+// doesn't have an endpoint to get a single release channel, so the channel is looked up in the
+// list of the release channels.
+func ReadReleaseChannelJSON(ctx context.Context, proj project.Project, name string) (project.ReleaseChannel, error) {
+	channels, err := ListReleaseChannelsJSON(ctx, proj)
+	if err != nil {
+		return project.ReleaseChannel{}, err
+	}
+	return findReleaseChannel(channels, name)
+}
+
+// VersionDetails is a version with the release channels it's current or pending on.
+type VersionDetails struct {
+	project.Version
+	// CurrentOn and PendingOn are the names of the release channels, i.e.
+	// projects/{projectID}/releaseChannels/{releaseChannelID}.
+	CurrentOn []string
+	PendingOn []string
+}
+
+// findVersion returns the version with versionID from versions, with the release channels
+// which have it as their current or pending version.
+func findVersion(versions []project.Version, channels []project.ReleaseChannel, versionID string) (VersionDetails, error) {
+	for _, v := range versions {
+		if v.ID != versionID && !strings.HasSuffix(v.ID, "/versions/"+versionID) {
+			continue
+		}
+		res := VersionDetails{Version: v}
+		for _, c := range channels {
+			if c.CurrentVersion == v.ID {
+				res.CurrentOn = append(res.CurrentOn, c.Name)
+			}
+			if c.PendingVersion == v.ID {
+				res.PendingOn = append(res.PendingOn, c.Name)
+			}
+		}
+		return res, nil
+	}
+	return VersionDetails{}, fmt.Errorf("version %q was not found", versionID)
+}
+
+// GetVersionJSON returns the metadata of the version of proj with versionID. API doesn't have
+// an endpoint to get a single version, so the version is looked up in the list of the versions,
+// and its release channels in the list of the release channels.
+func GetVersionJSON(ctx context.Context, proj project.Project, versionID string) (VersionDetails, error) {
+	versions, err := ListVersionsJSON(ctx, proj)
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	channels, err := ListReleaseChannelsJSON(ctx, proj)
+	if err != nil {
+		return VersionDetails{}, err
+	}
+	return findVersion(versions, channels, versionID)
+}
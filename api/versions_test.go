@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"testing"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindReleaseChannel(t *testing.T) {
+	channels := []project.ReleaseChannel{
+		{Name: "projects/hello-world/releaseChannels/actions.channels.Production", CurrentVersion: "projects/hello-world/versions/3"},
+		{Name: "projects/hello-world/releaseChannels/actions.channels.ClosedBeta", CurrentVersion: "projects/hello-world/versions/4"},
+	}
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "actions.channels.Production", want: channels[0].Name},
+		{name: "prod", want: channels[0].Name},
+		{name: "ClosedBeta", want: channels[1].Name},
+		{name: "Alpha", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := findReleaseChannel(channels, tc.name)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("findReleaseChannel(%v) returned %v, want error %v", tc.name, err, tc.wantErr)
+		}
+		if got.Name != tc.want {
+			t.Errorf("findReleaseChannel(%v) returned %v, want %v", tc.name, got.Name, tc.want)
+		}
+	}
+}
+
+func TestFindVersion(t *testing.T) {
+	versions := []project.Version{
+		{ID: "projects/hello-world/versions/3", State: project.VersionState{State: "APPROVED"}},
+		{ID: "projects/hello-world/versions/4", State: project.VersionState{State: "UNDER_REVIEW"}},
+		{ID: "projects/hello-world/versions/5"},
+	}
+	channels := []project.ReleaseChannel{
+		{Name: "projects/hello-world/releaseChannels/actions.channels.Production", CurrentVersion: "projects/hello-world/versions/3", PendingVersion: "projects/hello-world/versions/4"},
+		{Name: "projects/hello-world/releaseChannels/actions.channels.ClosedBeta", CurrentVersion: "projects/hello-world/versions/4"},
+	}
+	tests := []struct {
+		versionID string
+		want      VersionDetails
+		wantErr   bool
+	}{
+		{
+			versionID: "3",
+			want: VersionDetails{
+				Version:   versions[0],
+				CurrentOn: []string{channels[0].Name},
+			},
+		},
+		{
+			versionID: "projects/hello-world/versions/4",
+			want: VersionDetails{
+				Version:   versions[1],
+				CurrentOn: []string{channels[1].Name},
+				PendingOn: []string{channels[0].Name},
+			},
+		},
+		{
+			versionID: "5",
+			want:      VersionDetails{Version: versions[2]},
+		},
+		{
+			versionID: "6",
+			wantErr:   true,
+		},
+	}
+	for _, tc := range tests {
+		got, err := findVersion(versions, channels, tc.versionID)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("findVersion(%v) returned %v, want error %v", tc.versionID, err, tc.wantErr)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("findVersion(%v) returned diff (-want, +got)\n%s", tc.versionID, diff)
+		}
+	}
+}
@@ -16,8 +16,11 @@
 package yamlutils
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -100,3 +103,107 @@ func fix(in interface{}) interface{} {
 		return in
 	}
 }
+
+// keyLineRegExp matches a line of a block mapping, i.e. "  key: value # comment", capturing the
+// indentation with an optional "- " of a sequence item, the key, and the rest of the line.
+var keyLineRegExp = regexp.MustCompile(`^([ \t]*(?:- +)?)([^\s#'"{}\[\],&*!|>%@` + "`" + `][^:#]*?|"[^"]*"|'[^']*'):(?:[ \t]+(.*?))?[ \t]*$`)
+
+// SetScalar replaces the scalar value at path, e.g. []string{"localizedSettings", "displayName"},
+// in the YAML document data, and returns the new document. Unlike a round trip through Unmarshal
+// and Marshal, the rest of the document is kept as it is, including comments, the order of the
+// keys and the comment at the end of the replaced line. SetScalar returns false if data doesn't
+// have a scalar at path; keys in flow mappings and in multi-line values aren't found.
+func SetScalar(data []byte, path []string, value string) ([]byte, bool, error) {
+	if len(path) == 0 {
+		return nil, false, errors.New("path must not be empty")
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return nil, false, fmt.Errorf("the value of %v must be a single line", strings.Join(path, "."))
+	}
+	v, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, false, err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	type key struct {
+		indent int
+		name   string
+	}
+	var stack []key
+	// blockIndent is the indentation of a key with a block scalar, e.g. "key: |", whose lines
+	// are skipped, or -1 outside of block scalars.
+	blockIndent := -1
+	for i, l := range lines {
+		line := strings.TrimRight(string(l), "\r\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if blockIndent >= 0 && indent > blockIndent {
+			continue
+		}
+		blockIndent = -1
+		idx := keyLineRegExp.FindStringSubmatchIndex(line)
+		if idx == nil {
+			continue
+		}
+		m := make([]string, 4)
+		for j := range m {
+			if idx[2*j] >= 0 {
+				m[j] = line[idx[2*j]:idx[2*j+1]]
+			}
+		}
+		indent = len(m[1])
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, key{indent: indent, name: strings.Trim(strings.TrimSpace(m[2]), `"'`)})
+		val := m[3]
+		if strings.HasPrefix(val, "|") || strings.HasPrefix(val, ">") {
+			blockIndent = indent
+		}
+		if len(stack) != len(path) {
+			continue
+		}
+		match := true
+		for j, k := range stack {
+			if k.name != path[j] {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		val, comment := splitComment(val)
+		if val == "" || blockIndent >= 0 {
+			// The value is a mapping, a sequence or a block scalar.
+			return data, false, nil
+		}
+		repl := line[:idx[6]] + strings.TrimSuffix(string(v), "\n") + comment
+		lines[i] = append([]byte(repl), l[len(line):]...)
+		return bytes.Join(lines, nil), true, nil
+	}
+	return data, false, nil
+}
+
+// splitComment splits the value of a key line into the value and the comment after it, which
+// keeps the spaces before the "#".
+func splitComment(val string) (string, string) {
+	quote := byte(0)
+	for i := 0; i < len(val); i++ {
+		switch c := val[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && i > 0 && (val[i-1] == ' ' || val[i-1] == '\t'):
+			v := strings.TrimRight(val[:i], " \t")
+			return v, val[len(v):]
+		}
+	}
+	return val, ""
+}
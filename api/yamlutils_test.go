@@ -15,6 +15,7 @@
 package yamlutils
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/protolambda/messagediff"
@@ -80,3 +81,78 @@ i: &i [*h,*h,*h,*h,*h,*h,*h,*h,*h]
 		t.Errorf("DOS YAML successfully parsed into build %v.", b)
 	}
 }
+
+const settings = `# Settings of the sample.
+projectId: placeholder_project # Replace with your project ID.
+defaultLocale: en
+description: |
+  projectId: not a key
+localizedSettings:
+  displayName: Facts about Google
+  pronunciation: "Facts about Google"
+surfaceRequirements:
+  minimumRequirements:
+  - capability: SPEECH
+`
+
+func TestSetScalar(t *testing.T) {
+	tests := []struct {
+		path  []string
+		value string
+		want  string
+		ok    bool
+	}{
+		{
+			path:  []string{"projectId"},
+			value: "my-project",
+			want:  strings.Replace(settings, "placeholder_project", "my-project", 1),
+			ok:    true,
+		},
+		{
+			path:  []string{"localizedSettings", "displayName"},
+			value: "Trivia: $1 edition",
+			want:  strings.Replace(settings, "  displayName: Facts about Google", `  displayName: 'Trivia: $1 edition'`, 1),
+			ok:    true,
+		},
+		{
+			path:  []string{"localizedSettings", "pronunciation"},
+			value: "Trivia",
+			want:  strings.Replace(settings, `"Facts about Google"`, "Trivia", 1),
+			ok:    true,
+		},
+		{
+			path:  []string{"surfaceRequirements", "minimumRequirements", "capability"},
+			value: "WEB_BROWSER",
+			want:  strings.Replace(settings, "SPEECH", "WEB_BROWSER", 1),
+			ok:    true,
+		},
+		{
+			path:  []string{"displayName"},
+			value: "Trivia",
+			want:  settings,
+		},
+		{
+			path:  []string{"localizedSettings"},
+			value: "Trivia",
+			want:  settings,
+		},
+		{
+			path:  []string{"category"},
+			value: "GAMES_AND_TRIVIA",
+			want:  settings,
+		},
+	}
+	for _, tc := range tests {
+		got, ok, err := SetScalar([]byte(settings), tc.path, tc.value)
+		if err != nil {
+			t.Errorf("SetScalar(%v, %q) returned %v, want %v", tc.path, tc.value, err, nil)
+			continue
+		}
+		if string(got) != tc.want || ok != tc.ok {
+			t.Errorf("SetScalar(%v, %q) returned (%q, %v), want (%q, %v)", tc.path, tc.value, got, ok, tc.want, tc.ok)
+		}
+	}
+	if _, _, err := SetScalar([]byte(settings), []string{"projectId"}, "a\nb"); err == nil {
+		t.Errorf("SetScalar with a multi-line value returned %v, want an error", err)
+	}
+}
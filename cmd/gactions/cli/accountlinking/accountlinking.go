@@ -0,0 +1,135 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package accountlinking provides an implementation of "gactions account-linking" command.
+package accountlinking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// httpClient is used to call the OAuth endpoints of the project.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// AddCommand adds the account-linking sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	accountLinking := &cobra.Command{
+		Use:   "account-linking",
+		Short: "This is the main command for working with the account linking of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the account linking of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	test := &cobra.Command{
+		Use:   "test",
+		Short: "Check the OAuth endpoints configured for account linking.",
+		Long: "This command checks the account linking settings of the project, and calls the configured authorization and token endpoints the way Google does, " +
+			"using the decrypted client secret. It checks that the endpoints accept the redirect URI of the project and the client credentials. " +
+			"To also check the token responses and scopes, sign in at the printed authorization URL and pass the code from the redirect to --code.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			code, err := cmd.Flags().GetString("code")
+			if err != nil {
+				return err
+			}
+			return runTest(ctx, proj, code)
+		},
+	}
+	test.Flags().String("code", "", "Authorization code obtained by signing in at the printed authorization URL. If set, the code is exchanged for tokens, and the tokens are refreshed.")
+	accountLinking.AddCommand(test)
+	root.AddCommand(accountLinking)
+}
+
+func runTest(ctx context.Context, proj project.Project, code string) error {
+	studioProj, ok := proj.(studio.Studio)
+	if !ok {
+		return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+	}
+	if err := (&studioProj).SetProjectID(""); err != nil {
+		return err
+	}
+	files, err := studioProj.Files()
+	if err != nil {
+		return err
+	}
+	c, err := readConfig(files)
+	if err != nil {
+		return err
+	}
+	if !c.usesOAuth() {
+		log.DoneMsgln(fmt.Sprintf("Account linking type is %v, which doesn't use OAuth endpoints, so there is nothing to test.", c.LinkingType))
+		return nil
+	}
+	redirect := fmt.Sprintf(redirectURLFormat, studioProj.ProjectID())
+	res := checkConfig(c)
+	if failed(res) {
+		return report(res)
+	}
+	res = append(res, checkAuthorization(httpClient, c, redirect))
+	if c.AuthGrantType == "AUTH_CODE" {
+		secret, err := sdk.DecryptSecret(ctx, studioProj, c.encryptedSecret)
+		if err != nil {
+			return err
+		}
+		res = append(res, checkTokenProbe(httpClient, c, secret, redirect))
+		if code != "" {
+			res = append(res, checkTokenExchange(httpClient, c, secret, redirect, code)...)
+		}
+	}
+	if u, err := authorizationRequestURL(c, redirect); err == nil && code == "" {
+		log.Outf("To check the token responses, sign in at %v and run this command again with --code set to the code parameter of the redirect.\n", u)
+	}
+	return report(res)
+}
+
+func failed(res []result) bool {
+	for _, r := range res {
+		if r.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// report prints the results, and returns an error if any check failed.
+func report(res []result) error {
+	for _, r := range res {
+		switch {
+		case r.err != nil:
+			log.Outf("FAIL  %v: %v\n", r.name, r.err)
+		case r.warning != "":
+			log.Outf("WARN  %v: %v\n", r.name, r.warning)
+		default:
+			log.Outf("PASS  %v\n", r.name)
+		}
+	}
+	if failed(res) {
+		return errors.New("account linking test failed")
+	}
+	log.DoneMsgln("Account linking test passed.")
+	return nil
+}
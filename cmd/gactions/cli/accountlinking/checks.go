@@ -0,0 +1,322 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accountlinking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// redirectURLFormat is the redirect URI that Google uses for the account linking of a project.
+	redirectURLFormat = "https://oauth-redirect.googleusercontent.com/r/%s"
+	// probeCode is sent to the token endpoint when no authorization code is given. A correctly
+	// configured endpoint rejects it with an invalid_grant error.
+	probeCode = "gactions-account-linking-test"
+)
+
+// config is the account linking configuration of a project.
+type config struct {
+	LinkingType        string   `yaml:"linkingType"`
+	AuthGrantType      string   `yaml:"authGrantType"`
+	AppClientID        string   `yaml:"appClientId"`
+	AuthorizationURL   string   `yaml:"authorizationUrl"`
+	TokenURL           string   `yaml:"tokenUrl"`
+	Scopes             []string `yaml:"scopes"`
+	UseBasicAuthHeader bool     `yaml:"useBasicAuthHeader"`
+	// encryptedSecret is the client secret from settings/accountLinkingSecret.yaml.
+	encryptedSecret string
+}
+
+// usesOAuth returns true if users link their accounts through the OAuth endpoints.
+func (c config) usesOAuth() bool {
+	return c.LinkingType == "OAUTH" || c.LinkingType == "OAUTH_AND_GOOGLE_SIGN_IN"
+}
+
+func readConfig(files map[string][]byte) (config, error) {
+	var set struct {
+		AccountLinking *config `yaml:"accountLinking"`
+	}
+	b, ok := files["settings/settings.yaml"]
+	if !ok {
+		return config{}, errors.New("settings/settings.yaml not found in project files")
+	}
+	if err := yaml.Unmarshal(b, &set); err != nil {
+		return config{}, fmt.Errorf("settings/settings.yaml has incorrect syntax: %v", err)
+	}
+	if set.AccountLinking == nil {
+		return config{}, errors.New("account linking is not configured in settings/settings.yaml")
+	}
+	c := *set.AccountLinking
+	if b, ok := files["settings/accountLinkingSecret.yaml"]; ok {
+		var secret struct {
+			EncryptedClientSecret string `yaml:"encryptedClientSecret"`
+		}
+		if err := yaml.Unmarshal(b, &secret); err != nil {
+			return config{}, fmt.Errorf("settings/accountLinkingSecret.yaml has incorrect syntax: %v", err)
+		}
+		c.encryptedSecret = secret.EncryptedClientSecret
+	}
+	return c, nil
+}
+
+// result is the outcome of a check. A check passes if err is nil; warning points out
+// something that doesn't fail the linking, but may fail the review.
+type result struct {
+	name    string
+	err     error
+	warning string
+}
+
+func checkURL(name, raw string) result {
+	r := result{name: name}
+	u, err := url.Parse(raw)
+	switch {
+	case raw == "":
+		r.err = errors.New("URL is missing")
+	case err != nil:
+		r.err = err
+	case u.Scheme != "https" || u.Host == "":
+		r.err = fmt.Errorf("%q must be an absolute https URL", raw)
+	}
+	return r
+}
+
+// checkConfig checks the account linking settings without calling the endpoints.
+func checkConfig(c config) []result {
+	var res []result
+	r := result{name: "Client ID"}
+	if c.AppClientID == "" {
+		r.err = errors.New("appClientId is missing")
+	}
+	res = append(res, r)
+	res = append(res, checkURL("Authorization URL", c.AuthorizationURL))
+	r = result{name: "Grant type"}
+	switch c.AuthGrantType {
+	case "AUTH_CODE":
+		res = append(res, r, checkURL("Token URL", c.TokenURL))
+		r = result{name: "Client secret"}
+		if c.encryptedSecret == "" {
+			r.err = errors.New(`settings/accountLinkingSecret.yaml is missing, run "gactions encrypt" first`)
+		}
+	case "IMPLICIT":
+		r.warning = "the implicit flow can't refresh tokens, consider the authorization code flow"
+	default:
+		r.err = fmt.Errorf("authGrantType %q is not supported, use AUTH_CODE or IMPLICIT", c.AuthGrantType)
+	}
+	res = append(res, r)
+	r = result{name: "Scopes"}
+	if len(c.Scopes) == 0 {
+		r.warning = "no scopes are requested"
+	}
+	return append(res, r)
+}
+
+// authorizationRequestURL returns the URL that Google opens to start the linking.
+func authorizationRequestURL(c config, redirect string) (string, error) {
+	u, err := url.Parse(c.AuthorizationURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("client_id", c.AppClientID)
+	q.Set("redirect_uri", redirect)
+	q.Set("state", "gactions")
+	if c.AuthGrantType == "IMPLICIT" {
+		q.Set("response_type", "token")
+	} else {
+		q.Set("response_type", "code")
+	}
+	if len(c.Scopes) > 0 {
+		q.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// checkAuthorization sends the request that starts the linking to the authorization endpoint.
+// The endpoint should show a sign-in page or redirect to one, rather than reject the request.
+func checkAuthorization(client *http.Client, c config, redirect string) result {
+	r := result{name: "Authorization endpoint"}
+	u, err := authorizationRequestURL(c, redirect)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	// Redirects are inspected, not followed.
+	cl := *client
+	cl.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := cl.Get(u)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		loc, err := resp.Location()
+		if err != nil {
+			r.err = fmt.Errorf("redirect without a valid location: %v", err)
+			return r
+		}
+		if strings.HasPrefix(loc.String(), redirect) {
+			// The endpoint redirected back to Google right away, which it only does to report an error,
+			// or when the user is already signed in.
+			params := loc.Query()
+			if loc.Fragment != "" {
+				params, _ = url.ParseQuery(loc.Fragment)
+			}
+			if e := params.Get("error"); e != "" {
+				r.err = fmt.Errorf("endpoint returned error %q: %v", e, params.Get("error_description"))
+			} else if params.Get("state") != "gactions" {
+				r.err = errors.New("endpoint didn't return the state parameter to the redirect URI")
+			}
+		}
+	case resp.StatusCode >= 400:
+		r.err = fmt.Errorf("endpoint returned %v; check that %v is an allowed redirect URI of client %q", resp.Status, redirect, c.AppClientID)
+	}
+	return r
+}
+
+// tokenResponse is a response of a token endpoint, as defined in RFC 6749.
+type tokenResponse struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	ExpiresIn    json.Number `json:"expires_in"`
+	RefreshToken string      `json:"refresh_token"`
+	Scope        string      `json:"scope"`
+	Error        string      `json:"error"`
+}
+
+func postToken(client *http.Client, c config, secret string, form url.Values) (int, *tokenResponse, error) {
+	if c.UseBasicAuthHeader {
+		form.Del("client_id")
+	} else {
+		form.Set("client_id", c.AppClientID)
+		form.Set("client_secret", secret)
+	}
+	req, err := http.NewRequest("POST", c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if c.UseBasicAuthHeader {
+		req.SetBasicAuth(url.QueryEscape(c.AppClientID), url.QueryEscape(secret))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	t := &tokenResponse{}
+	if err := json.Unmarshal(body, t); err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("endpoint returned %v without a JSON body; responses must be JSON (RFC 6749, section 5)", resp.Status)
+	}
+	return resp.StatusCode, t, nil
+}
+
+// checkTokenProbe exchanges a made-up authorization code. The endpoint should accept the client
+// credentials, and reject the code with an invalid_grant error.
+func checkTokenProbe(client *http.Client, c config, secret, redirect string) result {
+	r := result{name: "Token endpoint"}
+	status, t, err := postToken(client, c, secret, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {probeCode},
+		"redirect_uri": {redirect},
+	})
+	switch {
+	case err != nil:
+		r.err = err
+	case t.Error == "invalid_client" || status == http.StatusUnauthorized:
+		r.err = fmt.Errorf("endpoint rejected the client credentials (%v); check the client ID and secret", status)
+	case t.Error == "invalid_grant":
+	case t.Error != "":
+		r.err = fmt.Errorf("endpoint returned error %q for an invalid code, want \"invalid_grant\"", t.Error)
+	default:
+		r.err = fmt.Errorf("endpoint returned %v for an invalid code, want an invalid_grant error", status)
+	}
+	return r
+}
+
+// checkTokenExchange exchanges an authorization code obtained by signing in, and refreshes the
+// returned token.
+func checkTokenExchange(client *http.Client, c config, secret, redirect, code string) []result {
+	r := result{name: "Token exchange"}
+	status, t, err := postToken(client, c, secret, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirect},
+	})
+	if err == nil && status != http.StatusOK {
+		err = fmt.Errorf("endpoint returned %v (error %q)", status, t.Error)
+	}
+	if err != nil {
+		r.err = err
+		return []result{r}
+	}
+	var warnings []string
+	switch {
+	case t.AccessToken == "":
+		r.err = errors.New("response doesn't contain access_token")
+	case !strings.EqualFold(t.TokenType, "bearer"):
+		r.err = fmt.Errorf("token_type is %q, want \"bearer\"", t.TokenType)
+	case t.RefreshToken == "":
+		r.err = errors.New("response doesn't contain refresh_token, so Google can't refresh the access token")
+	}
+	if t.ExpiresIn == "" {
+		warnings = append(warnings, "response doesn't contain expires_in")
+	}
+	if t.Scope != "" {
+		granted := map[string]bool{}
+		for _, v := range strings.Fields(t.Scope) {
+			granted[v] = true
+		}
+		for _, v := range c.Scopes {
+			if !granted[v] {
+				warnings = append(warnings, fmt.Sprintf("scope %q was not granted", v))
+			}
+		}
+	}
+	r.warning = strings.Join(warnings, "; ")
+	res := []result{r}
+	if r.err != nil {
+		return res
+	}
+	r = result{name: "Token refresh"}
+	status, rt, err := postToken(client, c, secret, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.RefreshToken},
+	})
+	switch {
+	case err != nil:
+		r.err = err
+	case status != http.StatusOK:
+		r.err = fmt.Errorf("endpoint returned %v (error %q)", status, rt.Error)
+	case rt.AccessToken == "":
+		r.err = errors.New("response doesn't contain access_token")
+	}
+	return append(res, r)
+}
@@ -0,0 +1,262 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accountlinking
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testRedirect = "https://oauth-redirect.googleusercontent.com/r/my-project"
+
+func TestReadConfig(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml": []byte(`projectId: my-project
+accountLinking:
+  linkingType: OAUTH
+  authGrantType: AUTH_CODE
+  appClientId: my-client
+  authorizationUrl: https://example.com/auth
+  tokenUrl: https://example.com/token
+  scopes:
+  - profile
+`),
+		"settings/accountLinkingSecret.yaml": []byte("encryptedClientSecret: abc\n"),
+	}
+	got, err := readConfig(files)
+	if err != nil {
+		t.Fatalf("readConfig returned %v, want nil", err)
+	}
+	want := config{
+		LinkingType:      "OAUTH",
+		AuthGrantType:    "AUTH_CODE",
+		AppClientID:      "my-client",
+		AuthorizationURL: "https://example.com/auth",
+		TokenURL:         "https://example.com/token",
+		Scopes:           []string{"profile"},
+		encryptedSecret:  "abc",
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(config{})); diff != "" {
+		t.Errorf("readConfig returned diff (-want, +got)\n%s", diff)
+	}
+	if _, err := readConfig(map[string][]byte{"settings/settings.yaml": []byte("projectId: my-project\n")}); err == nil {
+		t.Error("readConfig returned nil for settings without account linking, want error")
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		c          config
+		wantFailed []string
+	}{
+		{
+			name: "valid auth code",
+			c: config{
+				AuthGrantType:    "AUTH_CODE",
+				AppClientID:      "my-client",
+				AuthorizationURL: "https://example.com/auth",
+				TokenURL:         "https://example.com/token",
+				encryptedSecret:  "abc",
+			},
+		},
+		{
+			name: "http urls and no secret",
+			c: config{
+				AuthGrantType:    "AUTH_CODE",
+				AppClientID:      "my-client",
+				AuthorizationURL: "http://example.com/auth",
+				TokenURL:         "http://example.com/token",
+			},
+			wantFailed: []string{"Authorization URL", "Token URL", "Client secret"},
+		},
+		{
+			name: "implicit without client id",
+			c: config{
+				AuthGrantType:    "IMPLICIT",
+				AuthorizationURL: "https://example.com/auth",
+			},
+			wantFailed: []string{"Client ID"},
+		},
+	}
+	for _, tc := range tests {
+		var got []string
+		for _, r := range checkConfig(tc.c) {
+			if r.err != nil {
+				got = append(got, r.name)
+			}
+		}
+		if diff := cmp.Diff(tc.wantFailed, got); diff != "" {
+			t.Errorf("checkConfig returned failed checks diff for %v (-want, +got)\n%s", tc.name, diff)
+		}
+	}
+}
+
+func TestCheckAuthorization(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr bool
+	}{
+		{
+			name: "sign-in page",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "<html>Sign in</html>")
+			},
+		},
+		{
+			name: "redirect to login",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/login", http.StatusFound)
+			},
+		},
+		{
+			name: "error redirect",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, r.URL.Query().Get("redirect_uri")+"?error=unauthorized_client&state=gactions", http.StatusFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "redirect uri rejected",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		ts := httptest.NewTLSServer(tc.handler)
+		c := config{AuthGrantType: "AUTH_CODE", AppClientID: "my-client", AuthorizationURL: ts.URL + "/auth"}
+		r := checkAuthorization(ts.Client(), c, testRedirect)
+		ts.Close()
+		if gotErr := r.err != nil; gotErr != tc.wantErr {
+			t.Errorf("checkAuthorization returned %v for %v, want error %v", r.err, tc.name, tc.wantErr)
+		}
+	}
+}
+
+func TestCheckTokenProbe(t *testing.T) {
+	tests := []struct {
+		name     string
+		basic    bool
+		status   int
+		body     string
+		wantErr  bool
+		wantAuth bool
+	}{
+		{
+			name:   "invalid grant",
+			status: http.StatusBadRequest,
+			body:   `{"error": "invalid_grant"}`,
+		},
+		{
+			name:     "invalid grant with basic auth",
+			basic:    true,
+			status:   http.StatusBadRequest,
+			body:     `{"error": "invalid_grant"}`,
+			wantAuth: true,
+		},
+		{
+			name:    "invalid client",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": "invalid_client"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			status:  http.StatusBadRequest,
+			body:    "bad request",
+			wantErr: true,
+		},
+		{
+			name:    "code accepted",
+			status:  http.StatusOK,
+			body:    `{"access_token": "a", "token_type": "bearer"}`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		var gotAuth bool
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, secret, ok := r.BasicAuth()
+			gotAuth = ok && id == "my-client" && secret == "my-secret"
+			if !ok && (r.FormValue("client_id") != "my-client" || r.FormValue("client_secret") != "my-secret") {
+				t.Errorf("Token request for %v has no client credentials", tc.name)
+			}
+			w.WriteHeader(tc.status)
+			fmt.Fprint(w, tc.body)
+		}))
+		c := config{AppClientID: "my-client", TokenURL: ts.URL + "/token", UseBasicAuthHeader: tc.basic}
+		r := checkTokenProbe(ts.Client(), c, "my-secret", testRedirect)
+		ts.Close()
+		if gotErr := r.err != nil; gotErr != tc.wantErr {
+			t.Errorf("checkTokenProbe returned %v for %v, want error %v", r.err, tc.name, tc.wantErr)
+		}
+		if gotAuth != tc.wantAuth {
+			t.Errorf("checkTokenProbe sent basic auth %v for %v, want %v", gotAuth, tc.name, tc.wantAuth)
+		}
+	}
+}
+
+func TestCheckTokenExchange(t *testing.T) {
+	tests := []struct {
+		name        string
+		exchange    string
+		wantErr     bool
+		wantWarning string
+	}{
+		{
+			name:     "valid",
+			exchange: `{"access_token": "a", "token_type": "Bearer", "expires_in": 3600, "refresh_token": "r", "scope": "profile email"}`,
+		},
+		{
+			name:        "missing expiry and scope",
+			exchange:    `{"access_token": "a", "token_type": "bearer", "refresh_token": "r", "scope": "profile"}`,
+			wantWarning: `response doesn't contain expires_in; scope "email" was not granted`,
+		},
+		{
+			name:     "no refresh token",
+			exchange: `{"access_token": "a", "token_type": "bearer", "expires_in": 3600}`,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.FormValue("grant_type") {
+			case "authorization_code":
+				fmt.Fprint(w, tc.exchange)
+			case "refresh_token":
+				fmt.Fprint(w, `{"access_token": "b", "token_type": "bearer", "expires_in": 3600}`)
+			}
+		}))
+		c := config{AppClientID: "my-client", TokenURL: ts.URL + "/token", Scopes: []string{"profile", "email"}}
+		res := checkTokenExchange(ts.Client(), c, "my-secret", testRedirect, "code")
+		ts.Close()
+		if gotErr := failed(res); gotErr != tc.wantErr {
+			t.Errorf("checkTokenExchange failed %v for %v, want %v", gotErr, tc.name, tc.wantErr)
+		}
+		if res[0].warning != tc.wantWarning {
+			t.Errorf("checkTokenExchange returned warning %q for %v, want %q", res[0].warning, tc.name, tc.wantWarning)
+		}
+		if !tc.wantErr && len(res) != 2 {
+			t.Errorf("checkTokenExchange returned %d results for %v, want 2", len(res), tc.name)
+		}
+	}
+}
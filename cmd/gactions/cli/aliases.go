@@ -0,0 +1,124 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// userArgs returns args expanded with the aliases and default flags from the user config, or nil
+// if the user config doesn't define any.
+func userArgs(root *cobra.Command, args []string) ([]string, error) {
+	p, err := studio.UserConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := studio.ReadUserConfig(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Aliases) == 0 && len(cfg.Defaults) == 0 {
+		return nil, nil
+	}
+	res, err := expandArgs(root, args, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", p, err)
+	}
+	return res, nil
+}
+
+// expandArgs replaces an alias from cfg in the command position of args with the command it
+// stands for, and inserts the default flags of the command from cfg before the flags in args,
+// so the flags in args take priority.
+func expandArgs(root *cobra.Command, args []string, cfg project.UserConfig) ([]string, error) {
+	for i, a := range args {
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		v, ok := cfg.Aliases[a]
+		if !ok {
+			break
+		}
+		if c, _, err := root.Find([]string{a}); err == nil && c != root {
+			return nil, fmt.Errorf("alias %q can't replace the command %q", a, c.CommandPath())
+		}
+		exp, err := splitArgs(v)
+		if err != nil {
+			return nil, fmt.Errorf("alias %q is invalid: %v", a, err)
+		}
+		res := append([]string{}, args[:i]...)
+		res = append(res, exp...)
+		args = append(res, args[i+1:]...)
+		break
+	}
+	cmd, rest, err := root.Find(args)
+	if err != nil || cmd == root {
+		// Cobra reports unknown commands when parsing args.
+		return args, nil
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), root.Name()+" ")
+	v, ok := cfg.Defaults[path]
+	if !ok {
+		return args, nil
+	}
+	defs, err := splitArgs(v)
+	if err != nil {
+		return nil, fmt.Errorf("defaults of %q are invalid: %v", path, err)
+	}
+	res := strings.Fields(path)
+	res = append(res, defs...)
+	return append(res, rest...), nil
+}
+
+// splitArgs splits s into arguments separated by spaces. Like in a shell, an argument can be
+// quoted with single or double quotes to include spaces.
+func splitArgs(s string) ([]string, error) {
+	var res []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				res = append(res, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		res = append(res, cur.String())
+	}
+	return res, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+)
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "gactions"}
+	root.PersistentFlags().BoolP(verboseFlagName, "v", false, "")
+	deploy := &cobra.Command{Use: "deploy"}
+	deploy.AddCommand(&cobra.Command{Use: "preview", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(deploy)
+	root.AddCommand(&cobra.Command{Use: "push", Run: func(*cobra.Command, []string) {}})
+	return root
+}
+
+func TestExpandArgs(t *testing.T) {
+	cfg := project.UserConfig{
+		Aliases: map[string]string{
+			"dp":   "deploy preview --sandbox=false",
+			"push": "deploy preview",
+		},
+		Defaults: map[string]string{
+			"push":           "--incremental",
+			"deploy preview": `--sandbox=true -m "a message"`,
+		},
+	}
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			args: []string{"dp"},
+			want: []string{"deploy", "preview", `--sandbox=true`, "-m", "a message", "--sandbox=false"},
+		},
+		{
+			args: []string{"-v", "push", "--incremental=false"},
+			want: []string{"push", "--incremental", "-v", "--incremental=false"},
+		},
+		{
+			args: []string{"deploy"},
+			want: []string{"deploy"},
+		},
+		{
+			args: []string{"unknown", "--flag"},
+			want: []string{"unknown", "--flag"},
+		},
+	}
+	for _, tc := range tests {
+		// An alias can't replace a command.
+		c := cfg
+		c.Aliases = map[string]string{"dp": cfg.Aliases["dp"]}
+		got, err := expandArgs(testRoot(), tc.args, c)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("expandArgs(%v) returned %v, want error %v", tc.args, err, tc.wantErr)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("expandArgs(%v) returned diff (-want, +got)\n%s", tc.args, diff)
+		}
+	}
+	if _, err := expandArgs(testRoot(), []string{"push"}, cfg); err == nil {
+		t.Errorf("expandArgs with an alias named after a command returned nil, want an error")
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "deploy preview  --sandbox=false", want: []string{"deploy", "preview", "--sandbox=false"}},
+		{in: `-m "two words" --x='a b'`, want: []string{"-m", "two words", "--x=a b"}},
+		{in: `-m ""`, want: []string{"-m", ""}},
+		{in: "", want: nil},
+		{in: `-m "open`, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := splitArgs(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("splitArgs(%q) returned %v, want error %v", tc.in, err, tc.wantErr)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("splitArgs(%q) returned diff (-want, +got)\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestExecuteExpandsAliasesOfArgs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	p, err := os.UserConfigDir()
+	if err != nil {
+		t.Skipf("os.UserConfigDir returned %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(p, "gactions"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(p, "gactions", "config.yaml"), []byte("aliases:\n  dp: deploy preview\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	root := testRoot()
+	ran := false
+	preview, _, err := root.Find([]string{"deploy", "preview"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	preview.Run = func(*cobra.Command, []string) { ran = true }
+	// The args are expanded, not the args of the process.
+	if code := Execute(root, []string{"dp"}); code != 0 {
+		t.Errorf("Execute returned %v, want %v", code, 0)
+	}
+	if !ran {
+		t.Errorf("Execute didn't run the command of the alias")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const timeoutFlagName = "timeout"
+
+var (
+	// cancelMu guards the state below, which is set by the timer of --timeout and by the
+	// handler of Ctrl+C while the command runs.
+	cancelMu sync.Mutex
+	// cancelCommand cancels the context passed to the commands.
+	cancelCommand context.CancelFunc = func() {}
+	// canceledBy is the reason the command was canceled, or nil if it wasn't.
+	canceledBy error
+)
+
+// withCancel returns a context which is canceled by the --timeout flag or by Ctrl+C, so
+// requests in flight stop and the command returns.
+func withCancel(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	cancelMu.Lock()
+	defer cancelMu.Unlock()
+	cancelCommand = cancel
+	canceledBy = nil
+	return ctx
+}
+
+// cancel cancels the command because of reason, unless it was already canceled.
+func cancel(reason error) {
+	cancelMu.Lock()
+	defer cancelMu.Unlock()
+	if canceledBy == nil {
+		canceledBy = reason
+	}
+	cancelCommand()
+}
+
+// canceled returns the reason the command was canceled, or nil.
+func canceled() error {
+	cancelMu.Lock()
+	defer cancelMu.Unlock()
+	return canceledBy
+}
+
+// setTimeout cancels the command once the duration of the --timeout flag passes.
+func setTimeout(cmd *cobra.Command) error {
+	d, err := cmd.Flags().GetDuration(timeoutFlagName)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("--%s must not be negative, got %v", timeoutFlagName, d)
+	}
+	if d > 0 {
+		time.AfterFunc(d, func() {
+			cancel(fmt.Errorf("command didn't finish in %v set by --%s", d, timeoutFlagName))
+		})
+	}
+	return nil
+}
+
+// handleInterrupt cancels the command on the first Ctrl+C. Commands which don't stop on
+// cancellation are ended by the second Ctrl+C.
+func handleInterrupt() func() {
+	c := make(chan os.Signal, 2)
+	signal.Notify(c, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-c:
+		case <-done:
+			return
+		}
+		cancel(errors.New("command was interrupted"))
+		select {
+		case <-c:
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canvas provides an implementation of "gactions canvas" command.
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the canvas sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	canvas := &cobra.Command{
+		Use:   "canvas",
+		Short: "This is the main command for working with the Interactive Canvas web app of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the Interactive Canvas web app of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	deploy := &cobra.Command{
+		Use:   "deploy",
+		Short: "Build and deploy the Interactive Canvas web app, and use its URL in the project.",
+		Long: fmt.Sprintf("This command builds the Interactive Canvas web app in the %q directory of the project, deploys it to Firebase Hosting or a Cloud Storage bucket, "+
+			"and sets the URL of the deployed web app in the canvas prompts of the scenes, global intent handlers and static prompts. "+
+			"The web app is built with \"npm run build\" if its package.json has a build script. Run \"gactions push\" afterwards to push the updated files.", studio.CanvasDir),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			return doDeploy(ctx, cmd, proj)
+		},
+	}
+	deploy.Flags().String("public-dir", "", "Directory with the built web app, relative to the canvas directory. By default, the first of build, dist and public that exists is used, or the canvas directory itself.")
+	deploy.Flags().Bool("skip-build", false, "Deploy the web app without building it.")
+	deploy.Flags().String("target", "firebase", "Where to deploy the web app: \"firebase\" for Firebase Hosting, or a Cloud Storage location like gs://bucket/path.")
+	deploy.Flags().String("site", "", "Firebase Hosting site to deploy to. The default site of the project is used if empty.")
+	deploy.Flags().Bool("update-prompts", true, "Set the URL of the deployed web app in the canvas prompts of the project.")
+	deploy.Flags().Bool("force", false, "Overwrite project files without asking.")
+	canvas.AddCommand(deploy)
+	root.AddCommand(canvas)
+}
+
+func doDeploy(ctx context.Context, cmd *cobra.Command, proj project.Project) error {
+	publicDir, err := cmd.Flags().GetString("public-dir")
+	if err != nil {
+		return err
+	}
+	skipBuild, err := cmd.Flags().GetBool("skip-build")
+	if err != nil {
+		return err
+	}
+	target, err := cmd.Flags().GetString("target")
+	if err != nil {
+		return err
+	}
+	site, err := cmd.Flags().GetString("site")
+	if err != nil {
+		return err
+	}
+	updatePrompts, err := cmd.Flags().GetBool("update-prompts")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	studioProj, ok := proj.(studio.Studio)
+	if !ok {
+		return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+	}
+	if err := (&studioProj).SetProjectID(""); err != nil {
+		return err
+	}
+	dir := filepath.Join(studioProj.ProjectRoot(), studio.CanvasDir)
+	if !isDir(dir) {
+		return fmt.Errorf("can not find an Interactive Canvas web app: %v is not a directory", dir)
+	}
+	if !skipBuild {
+		if err := build(ctx, dir); err != nil {
+			return err
+		}
+	}
+	if publicDir == "" {
+		publicDir = defaultPublicDir(dir)
+	}
+	public := filepath.Join(dir, publicDir)
+	if !isDir(public) {
+		return fmt.Errorf("%v is not a directory", public)
+	}
+	var url string
+	if strings.HasPrefix(target, "gs://") {
+		url, err = deployToBucket(ctx, public, target)
+	} else if target == "firebase" {
+		url, err = deployToHosting(ctx, studioProj.ProjectRoot(), public, studioProj.ProjectID(), site)
+	} else {
+		return fmt.Errorf("unsupported target %q: use firebase or a gs:// location", target)
+	}
+	if err != nil {
+		return err
+	}
+	log.Outf("Deployed the Interactive Canvas web app to %v\n", url)
+	if !updatePrompts {
+		log.DoneMsgln(fmt.Sprintf("Deployed the web app to %v.", url))
+		return nil
+	}
+	files, err := studioProj.Files()
+	if err != nil {
+		return err
+	}
+	out, err := setCanvasURL(files, url)
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		log.Warnf("No canvas prompt found in the project. Add a canvas prompt with the URL %v to a scene or a prompt.\n", url)
+	}
+	var names []string
+	for k := range out {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		log.Outf("Setting the canvas URL in %v\n", k)
+		if err := studio.WriteToDisk(studioProj, k, "", out[k], force); err != nil {
+			return err
+		}
+	}
+	log.DoneMsgln(fmt.Sprintf(`Deployed the web app to %v. Run "gactions push" to push the updated files.`, url))
+	return nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// defaultPublicDir returns the directory of the built web app relative to dir.
+func defaultPublicDir(dir string) string {
+	for _, v := range []string{"build", "dist", "public"} {
+		if isDir(filepath.Join(dir, v)) {
+			return v
+		}
+	}
+	return "."
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+// runCommand runs an external tool in dir, and shows its output to the user.
+var runCommand = func(ctx context.Context, dir string, name string, args ...string) error {
+	log.Infof("Running %v %v in %v\n", name, strings.Join(args, " "), dir)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v %v failed: %v", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// build builds the web app in dir with its build script, if it has one.
+func build(ctx context.Context, dir string) error {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if os.IsNotExist(err) {
+		log.Infof("%v doesn't have package.json, so the web app is deployed as is.\n", dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return fmt.Errorf("%v has incorrect syntax: %v", filepath.Join(dir, "package.json"), err)
+	}
+	if _, ok := pkg.Scripts["build"]; !ok {
+		log.Infof("package.json in %v doesn't have a build script, so the web app is deployed as is.\n", dir)
+		return nil
+	}
+	log.Outf("Building the Interactive Canvas web app in %v\n", dir)
+	if _, err := os.Stat(filepath.Join(dir, "node_modules")); os.IsNotExist(err) {
+		if err := runCommand(ctx, dir, "npm", "install"); err != nil {
+			return err
+		}
+	}
+	return runCommand(ctx, dir, "npm", "run", "build")
+}
+
+// deployToBucket copies the web app in public to a Cloud Storage location, and returns
+// the URL of the web app.
+func deployToBucket(ctx context.Context, public, target string) (string, error) {
+	target = strings.TrimSuffix(target, "/")
+	log.Outf("Copying %v to %v\n", public, target)
+	if err := runCommand(ctx, public, "gsutil", "-m", "rsync", "-r", ".", target); err != nil {
+		return "", err
+	}
+	return "https://storage.googleapis.com/" + strings.TrimPrefix(target, "gs://") + "/index.html", nil
+}
+
+// deployToHosting deploys the web app in public to Firebase Hosting, and returns the URL of the web app.
+func deployToHosting(ctx context.Context, root, public, projectID, site string) (string, error) {
+	rel, err := filepath.Rel(root, public)
+	if err != nil {
+		return "", err
+	}
+	cfgPath := filepath.Join(root, "firebase.json")
+	existing, err := ioutil.ReadFile(cfgPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	cfg, changed, err := hostingConfig(existing, filepath.ToSlash(rel), site)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		log.Outf("Setting the public directory of Firebase Hosting in %v to %v\n", cfgPath, filepath.ToSlash(rel))
+		if err := ioutil.WriteFile(cfgPath, cfg, 0640); err != nil {
+			return "", err
+		}
+	}
+	only := "hosting"
+	if site != "" {
+		only += ":" + site
+	}
+	log.Outf("Deploying %v to Firebase Hosting\n", public)
+	if err := runCommand(ctx, root, "firebase", "deploy", "--only", only, "--project", projectID); err != nil {
+		return "", err
+	}
+	// The default site of a Firebase project is named after the project.
+	if site == "" {
+		site = projectID
+	}
+	return "https://" + site + ".web.app/", nil
+}
+
+// hostingConfig returns the content of firebase.json, updated so that Firebase Hosting serves
+// the files in public. It returns false if the content didn't need to change.
+func hostingConfig(existing []byte, public, site string) ([]byte, bool, error) {
+	cfg := map[string]interface{}{}
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &cfg); err != nil {
+			return nil, false, fmt.Errorf("firebase.json has incorrect syntax: %v", err)
+		}
+	}
+	var hosting map[string]interface{}
+	switch v := cfg["hosting"].(type) {
+	case nil:
+		hosting = map[string]interface{}{}
+		if site != "" {
+			hosting["site"] = site
+		}
+		cfg["hosting"] = hosting
+	case map[string]interface{}:
+		hosting = v
+	case []interface{}:
+		// Several sites are configured, so use the configuration of the site.
+		for _, h := range v {
+			if m, ok := h.(map[string]interface{}); ok && site != "" && m["site"] == site {
+				hosting = m
+			}
+		}
+		if hosting == nil {
+			return nil, false, fmt.Errorf("firebase.json configures several sites of Firebase Hosting, select one with --site")
+		}
+	default:
+		return nil, false, fmt.Errorf("firebase.json has incorrect hosting field")
+	}
+	if hosting["public"] == public {
+		return existing, false, nil
+	}
+	hosting["public"] = public
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, false, err
+	}
+	return append(b, '\n'), true, nil
+}
+
+// setCanvasURL sets url in the canvas prompts of scenes, global intent handlers and static prompts
+// in files, and returns the files that changed.
+func setCanvasURL(files map[string][]byte, url string) (map[string][]byte, error) {
+	out := map[string][]byte{}
+	for k, v := range files {
+		if !studio.IsScene(k) && !studio.IsGlobal(k) && !studio.IsPrompt(k) {
+			continue
+		}
+		if !strings.Contains(string(v), "canvas") {
+			continue
+		}
+		var doc interface{}
+		if err := yaml.Unmarshal(v, &doc); err != nil {
+			return nil, fmt.Errorf("%v has incorrect syntax: %v", k, err)
+		}
+		if !replaceCanvasURL(doc, url) {
+			continue
+		}
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = b
+	}
+	return out, nil
+}
+
+// replaceCanvasURL sets url in all canvas prompts within v, and returns true if any URL changed.
+func replaceCanvasURL(v interface{}, url string) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		for k, e := range t {
+			if c, ok := e.(map[interface{}]interface{}); ok && k == "canvas" {
+				if c["url"] != url {
+					c["url"] = url
+					changed = true
+				}
+			}
+			if replaceCanvasURL(e, url) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, e := range t {
+			if replaceCanvasURL(e, url) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
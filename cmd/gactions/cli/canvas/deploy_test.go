@@ -0,0 +1,178 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/testutils"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		want        []string
+	}{
+		{
+			name: "no package.json",
+		},
+		{
+			name:        "no build script",
+			packageJSON: `{"scripts": {"start": "serve"}}`,
+		},
+		{
+			name:        "build script",
+			packageJSON: `{"scripts": {"build": "webpack"}}`,
+			want:        []string{"npm install", "npm run build"},
+		},
+	}
+	old := runCommand
+	defer func() { runCommand = old }()
+	for _, tc := range tests {
+		dir, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+		if err != nil {
+			t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+		}
+		defer os.RemoveAll(dir)
+		if tc.packageJSON != "" {
+			if err := ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte(tc.packageJSON), 0640); err != nil {
+				t.Fatalf("Can't write package.json: %v", err)
+			}
+		}
+		var got []string
+		runCommand = func(ctx context.Context, d string, name string, args ...string) error {
+			got = append(got, strings.Join(append([]string{name}, args...), " "))
+			return nil
+		}
+		if err := build(context.Background(), dir); err != nil {
+			t.Errorf("build returned %v for %v, want %v", err, tc.name, nil)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("build ran incorrect commands for %v: diff (-want, +got)\n%s", tc.name, diff)
+		}
+	}
+}
+
+func TestHostingConfig(t *testing.T) {
+	tests := []struct {
+		existing    string
+		site        string
+		want        string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			want:        `{"hosting": {"public": "canvas/build"}}`,
+			wantChanged: true,
+		},
+		{
+			existing:    `{"functions": {"source": "webhooks/a"}, "hosting": {"public": "public", "ignore": ["firebase.json"]}}`,
+			want:        `{"functions": {"source": "webhooks/a"}, "hosting": {"public": "canvas/build", "ignore": ["firebase.json"]}}`,
+			wantChanged: true,
+		},
+		{
+			existing: `{"hosting": {"public": "canvas/build"}}`,
+			want:     `{"hosting": {"public": "canvas/build"}}`,
+		},
+		{
+			existing:    `{"hosting": [{"site": "a", "public": "x"}, {"site": "b", "public": "y"}]}`,
+			site:        "b",
+			want:        `{"hosting": [{"site": "a", "public": "x"}, {"site": "b", "public": "canvas/build"}]}`,
+			wantChanged: true,
+		},
+		{
+			existing: `{"hosting": [{"site": "a", "public": "x"}, {"site": "b", "public": "y"}]}`,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		b, changed, err := hostingConfig([]byte(tc.existing), "canvas/build", tc.site)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("hostingConfig returned %v for %q, but wantErr is %v", err, tc.existing, tc.wantErr)
+		}
+		if tc.wantErr {
+			continue
+		}
+		if changed != tc.wantChanged {
+			t.Errorf("hostingConfig returned changed = %v for %q, want %v", changed, tc.existing, tc.wantChanged)
+		}
+		var got, want interface{}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("hostingConfig returned invalid JSON %q: %v", b, err)
+		}
+		if err := json.Unmarshal([]byte(tc.want), &want); err != nil {
+			t.Fatalf("Can't unmarshal %q: %v", tc.want, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("hostingConfig returned incorrect config for %q: diff (-want, +got)\n%s", tc.existing, diff)
+		}
+	}
+}
+
+func TestSetCanvasURL(t *testing.T) {
+	const url = "https://foo.web.app/"
+	files := map[string][]byte{
+		"custom/scenes/Game.yaml": []byte(`onEnter:
+  staticPrompt:
+    candidates:
+    - promptResponse:
+        canvas:
+          data:
+          - level: 1
+          url: https://old.example.com/
+`),
+		"custom/prompts/welcome.yaml": []byte(`candidates:
+- promptResponse:
+    canvas:
+      url: https://foo.web.app/
+`),
+		"custom/scenes/Plain.yaml": []byte("onEnter:\n  webhookHandler: start\n"),
+		"settings/settings.yaml":   []byte("canvas: {}\n"),
+	}
+	got, err := setCanvasURL(files, url)
+	if err != nil {
+		t.Fatalf("setCanvasURL returned %v, want %v", err, nil)
+	}
+	if len(got) != 1 {
+		t.Errorf("setCanvasURL changed %v files, want 1", len(got))
+	}
+	var g, w interface{}
+	if err := yaml.Unmarshal(got["custom/scenes/Game.yaml"], &g); err != nil {
+		t.Fatalf("setCanvasURL returned a scene with incorrect syntax: %v", err)
+	}
+	if err := yaml.Unmarshal([]byte(`onEnter:
+  staticPrompt:
+    candidates:
+    - promptResponse:
+        canvas:
+          data:
+          - level: 1
+          url: https://foo.web.app/
+`), &w); err != nil {
+		t.Fatalf("Can't unmarshal the scene: %v", err)
+	}
+	if diff := cmp.Diff(w, g); diff != "" {
+		t.Errorf("setCanvasURL returned incorrect scene: diff (-want, +got)\n%s", diff)
+	}
+}
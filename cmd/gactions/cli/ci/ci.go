@@ -0,0 +1,290 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ci provides an implementation of "gactions ci" command.
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/lsp"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// Statuses of the steps of a verification.
+const (
+	statusPassed  = "passed"
+	statusFailed  = "failed"
+	statusSkipped = "skipped"
+)
+
+// Severities of issues.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// issue is a problem found by a step of a verification.
+type issue struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// step is the result of one of the checks of a verification.
+type step struct {
+	Name   string  `json:"name"`
+	Status string  `json:"status"`
+	Issues []issue `json:"issues,omitempty"`
+	// Error is set if the step couldn't run, or the reason it was skipped.
+	Error string `json:"error,omitempty"`
+}
+
+// report is the machine-readable result of "gactions ci verify".
+type report struct {
+	Passed bool   `json:"passed"`
+	Steps  []step `json:"steps"`
+}
+
+// verifier runs the steps of a verification. Its fields are replaced in tests.
+type verifier struct {
+	files       func() (map[string][]byte, error)
+	pushDraft   func() ([]sdk.ValidationIssue, error)
+	runTests    func(command string) error
+	push        bool
+	testCommand string
+}
+
+// AddCommand adds the ci sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	ci := &cobra.Command{
+		Use:   "ci",
+		Short: "This is the main command for checking a project in continuous integration pipelines. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for checking a project in continuous integration pipelines. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	verify := &cobra.Command{
+		Use:   "verify",
+		Short: "Run the checks of the project for a pull request, and write a single report.",
+		Long: "This command checks the config files for syntax errors and undefined references, checks that the strings and intents of the default locale are translated to the other locales, " +
+			"and optionally sends the files to the server to collect its validation results, as \"gactions push --validate-only\" does (--push), and runs the conversation tests of the project (--test-command). " +
+			"The results are written as a JSON report, and the command fails if any of the checks fails. " +
+			"The server only validates the files, so the draft in Actions Console is not changed. Steps after a failed step that depend on it are skipped.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			out, err := cmd.Flags().GetString("report")
+			if err != nil {
+				return err
+			}
+			v := verifier{
+				files: proj.Files,
+				runTests: func(command string) error {
+					return runTests(proj.ProjectRoot(), command)
+				},
+			}
+			if v.push, err = cmd.Flags().GetBool("push"); err != nil {
+				return err
+			}
+			if v.testCommand, err = cmd.Flags().GetString("test-command"); err != nil {
+				return err
+			}
+			if v.push {
+				pid, err := cmd.Flags().GetString("project-id")
+				if err != nil {
+					return err
+				}
+				if err := (&studioProj).SetProjectID(pid); err != nil {
+					return err
+				}
+				v.pushDraft = func() ([]sdk.ValidationIssue, error) {
+					return sdk.ValidateOnlyDraftJSON(ctx, studioProj)
+				}
+			}
+			stdout, stderr := log.Output()
+			w := stdout
+			if out == "-" {
+				// Keep the report the only output in stdout, so it can be piped into other tools.
+				log.SetOutput(stderr, stderr)
+				defer log.SetOutput(stdout, stderr)
+			} else {
+				f, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			r := v.run()
+			if err := writeReport(w, r); err != nil {
+				return err
+			}
+			if !r.Passed {
+				return errors.New("verification failed")
+			}
+			log.DoneMsgln("All checks passed.")
+			return nil
+		},
+	}
+	verify.Flags().String("report", "-", "Write the JSON report to this file. Defaults to stdout, in which case the rest of the output is written to stderr.")
+	verify.Flags().Bool("push", false, "Send the files to the server with validateOnly set, as \"gactions push --validate-only\" does, and report the validation results of the server. The draft in Actions Console is not changed.")
+	verify.Flags().String("project-id", "", "Validate the files with the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	verify.Flags().String("test-command", "", "Command which runs the conversation tests of the project, e.g. \"npm test\". It's run by the shell in the project root, and the tests pass if it exits with 0.")
+	ci.AddCommand(verify)
+	root.AddCommand(ci)
+}
+
+func checkRoot(proj project.Project) error {
+	if proj.ProjectRoot() == "" {
+		log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+		return errors.New("can not determine project root")
+	}
+	return nil
+}
+
+// runTests runs command in dir with the shell of the platform, and shows its output in stderr.
+func runTests(dir, command string) error {
+	name, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		name, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(name, flag, command)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v failed: %v", command, err)
+	}
+	return nil
+}
+
+// newStep returns a step with the given issues, which fails if any of them is an error.
+func newStep(name string, issues []issue) step {
+	s := step{Name: name, Status: statusPassed, Issues: issues}
+	for _, v := range issues {
+		if v.Severity == severityError {
+			s.Status = statusFailed
+		}
+	}
+	return s
+}
+
+func skipped(name, reason string) step {
+	return step{Name: name, Status: statusSkipped, Error: reason}
+}
+
+func failed(name string, err error) step {
+	return step{Name: name, Status: statusFailed, Error: err.Error()}
+}
+
+// run runs the steps of the verification. Validation by the server is skipped if the files
+// have problems, and the tests are skipped if the server found issues in the files.
+func (v verifier) run() report {
+	var r report
+	add := func(s step) bool {
+		r.Steps = append(r.Steps, s)
+		log.Outf("%v: %v\n", s.Name, s.Status)
+		return s.Status != statusFailed
+	}
+	files, err := v.files()
+	if err != nil {
+		add(failed("validate", err))
+		return r
+	}
+	var issues []issue
+	for _, p := range lsp.Check(files) {
+		sev := severityError
+		if p.Warning {
+			sev = severityWarning
+		}
+		issues = append(issues, issue{File: p.File, Line: p.Line, Severity: sev, Message: p.Message})
+	}
+	valid := add(newStep("validate", issues))
+
+	if ls, err := checkLocales(files); err != nil {
+		add(failed("locales", err))
+	} else {
+		add(newStep("locales", ls))
+	}
+
+	pushed := true
+	switch {
+	case !v.push:
+		add(skipped("push", "--push is not set"))
+		pushed = false
+	case !valid:
+		add(skipped("push", "validate failed"))
+		pushed = false
+	default:
+		res, err := v.pushDraft()
+		if err != nil {
+			pushed = add(failed("push", err))
+		} else {
+			var issues []issue
+			for _, i := range res {
+				issues = append(issues, issue{File: i.File, Locale: i.Locale, Severity: severityError, Message: i.Message})
+			}
+			pushed = add(newStep("push", issues))
+		}
+	}
+
+	switch {
+	case v.testCommand == "":
+		add(skipped("tests", "--test-command is not set"))
+	case v.push && !pushed:
+		add(skipped("tests", "push failed"))
+	default:
+		if err := v.runTests(v.testCommand); err != nil {
+			add(failed("tests", err))
+		} else {
+			add(newStep("tests", nil))
+		}
+	}
+
+	r.Passed = true
+	for _, s := range r.Steps {
+		if s.Status == statusFailed {
+			r.Passed = false
+		}
+	}
+	return r
+}
+
+func writeReport(w io.Writer, r report) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
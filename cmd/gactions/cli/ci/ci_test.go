@@ -0,0 +1,148 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ci
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/google/go-cmp/cmp"
+)
+
+var validFiles = map[string][]byte{
+	"settings/settings.yaml":      []byte("defaultLocale: en\nprojectId: hello-world\n"),
+	"custom/scenes/Main.yaml":     []byte("onEnter:\n  staticPromptName: welcome\n"),
+	"custom/prompts/welcome.yaml": []byte("candidates: []\n"),
+}
+
+func statuses(r report) map[string]string {
+	res := map[string]string{}
+	for _, s := range r.Steps {
+		res[s.Name] = s.Status
+	}
+	return res
+}
+
+func TestRun(t *testing.T) {
+	invalid := map[string][]byte{}
+	for k, v := range validFiles {
+		invalid[k] = v
+	}
+	delete(invalid, "custom/prompts/welcome.yaml")
+	tests := []struct {
+		name        string
+		files       map[string][]byte
+		push        bool
+		pushErr     error
+		pushIssues  []sdk.ValidationIssue
+		testCommand string
+		testErr     error
+		want        map[string]string
+		passed      bool
+	}{
+		{
+			name:   "local checks only",
+			files:  validFiles,
+			want:   map[string]string{"validate": statusPassed, "locales": statusPassed, "push": statusSkipped, "tests": statusSkipped},
+			passed: true,
+		},
+		{
+			name:        "all steps",
+			files:       validFiles,
+			push:        true,
+			testCommand: "npm test",
+			want:        map[string]string{"validate": statusPassed, "locales": statusPassed, "push": statusPassed, "tests": statusPassed},
+			passed:      true,
+		},
+		{
+			name:        "undefined prompt",
+			files:       invalid,
+			push:        true,
+			testCommand: "npm test",
+			want:        map[string]string{"validate": statusFailed, "locales": statusPassed, "push": statusSkipped, "tests": statusSkipped},
+		},
+		{
+			name:        "server issues",
+			files:       validFiles,
+			push:        true,
+			pushIssues:  []sdk.ValidationIssue{{Locale: "en", Message: "Invalid scene"}},
+			testCommand: "npm test",
+			want:        map[string]string{"validate": statusPassed, "locales": statusPassed, "push": statusFailed, "tests": statusSkipped},
+		},
+		{
+			name:        "push error",
+			files:       validFiles,
+			push:        true,
+			pushErr:     errors.New("permission denied"),
+			testCommand: "npm test",
+			want:        map[string]string{"validate": statusPassed, "locales": statusPassed, "push": statusFailed, "tests": statusSkipped},
+		},
+		{
+			name:        "failing tests",
+			files:       validFiles,
+			testCommand: "npm test",
+			testErr:     errors.New("exit status 1"),
+			want:        map[string]string{"validate": statusPassed, "locales": statusPassed, "push": statusSkipped, "tests": statusFailed},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := verifier{
+				files: func() (map[string][]byte, error) { return tc.files, nil },
+				pushDraft: func() ([]sdk.ValidationIssue, error) {
+					return tc.pushIssues, tc.pushErr
+				},
+				runTests: func(command string) error {
+					if command != tc.testCommand {
+						t.Errorf("runTests was called with %q, want %q", command, tc.testCommand)
+					}
+					return tc.testErr
+				},
+				push:        tc.push,
+				testCommand: tc.testCommand,
+			}
+			r := v.run()
+			if diff := cmp.Diff(tc.want, statuses(r)); diff != "" {
+				t.Errorf("run returned incorrect statuses: diff (-want, +got)\n%s", diff)
+			}
+			if r.Passed != tc.passed {
+				t.Errorf("run returned passed = %v, want %v", r.Passed, tc.passed)
+			}
+		})
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	r := report{
+		Steps: []step{
+			{Name: "validate", Status: statusFailed, Issues: []issue{{File: "custom/scenes/Main.yaml", Line: 2, Severity: severityError, Message: `prompt "welcome" is not defined in the project`}}},
+			{Name: "push", Status: statusSkipped, Error: "validate failed"},
+		},
+	}
+	var b bytes.Buffer
+	if err := writeReport(&b, r); err != nil {
+		t.Fatalf("writeReport returned %v, want %v", err, nil)
+	}
+	var got report
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("writeReport wrote invalid JSON: %v", err)
+	}
+	if diff := cmp.Diff(r, got); diff != "" {
+		t.Errorf("writeReport wrote diff (-want, +got)\n%s", diff)
+	}
+}
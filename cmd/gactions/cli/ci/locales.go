@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ci
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	stringsDir = "resources/strings"
+	intentsDir = "custom/intents"
+)
+
+// locales returns the locales which have settings in the project, other than the default one.
+func locales(files map[string][]byte) []string {
+	var res []string
+	for k := range files {
+		parts := strings.Split(k, "/")
+		if len(parts) == 3 && parts[0] == "settings" && parts[2] == "settings.yaml" {
+			res = append(res, parts[1])
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// localizedDirs returns the locales which have localized strings or intents in the project.
+func localizedDirs(files map[string][]byte) map[string]string {
+	res := map[string]string{}
+	for k := range files {
+		for _, dir := range []string{stringsDir, intentsDir} {
+			rest := strings.TrimPrefix(k, dir+"/")
+			if rest == k {
+				continue
+			}
+			if parts := strings.Split(rest, "/"); len(parts) == 2 {
+				res[parts[0]] = k
+			}
+		}
+	}
+	return res
+}
+
+// topLevelKeys returns the keys of a YAML file, or nil if the file has incorrect syntax, which
+// is reported by the validation step.
+func topLevelKeys(b []byte) map[string]bool {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	res := map[string]bool{}
+	for k := range m {
+		res[k] = true
+	}
+	return res
+}
+
+// checkLocales reports the strings and intents of the default locale which are not translated
+// to the other locales of the project, and localized files of locales without settings.
+func checkLocales(files map[string][]byte) ([]issue, error) {
+	def, err := studio.DefaultLocale(files)
+	if err != nil {
+		return nil, err
+	}
+	ls := locales(files)
+	var res []issue
+	known := map[string]bool{def: true}
+	for _, l := range ls {
+		known[l] = true
+	}
+	dirs := localizedDirs(files)
+	var unknown []string
+	for l := range dirs {
+		if !known[l] {
+			unknown = append(unknown, l)
+		}
+	}
+	sort.Strings(unknown)
+	for _, l := range unknown {
+		res = append(res, issue{File: dirs[l], Locale: l, Severity: severityError, Message: fmt.Sprintf("locale %v has localized files, but settings/%v/settings.yaml is missing", l, l)})
+	}
+	var names []string
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, l := range ls {
+		if l == def {
+			continue
+		}
+		for _, k := range names {
+			switch dir, base := path.Split(k); {
+			case dir == stringsDir+"/" && path.Ext(k) == ".yaml":
+				res = append(res, checkStrings(files, k, path.Join(stringsDir, l, base), l)...)
+			case dir == intentsDir+"/" && path.Ext(k) == ".yaml":
+				loc := path.Join(intentsDir, l, base)
+				if _, ok := files[loc]; !ok && topLevelKeys(files[k])["trainingPhrases"] {
+					res = append(res, issue{File: loc, Locale: l, Severity: severityError, Message: fmt.Sprintf("intent %q has no training phrases in %v", strings.TrimSuffix(base, ".yaml"), l)})
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// checkStrings reports the strings of the bundle base which are missing from its translation loc.
+func checkStrings(files map[string][]byte, base, loc, locale string) []issue {
+	b, ok := files[loc]
+	if !ok {
+		return []issue{{File: loc, Locale: locale, Severity: severityError, Message: fmt.Sprintf("%v is not translated to %v", base, locale)}}
+	}
+	have := topLevelKeys(b)
+	if have == nil {
+		return nil
+	}
+	var missing []string
+	for k := range topLevelKeys(files[base]) {
+		if !have[k] {
+			missing = append(missing, k)
+		}
+	}
+	sort.Strings(missing)
+	var res []issue
+	for _, k := range missing {
+		res = append(res, issue{File: loc, Locale: locale, Severity: severityError, Message: fmt.Sprintf("string %q is not translated to %v", k, locale)})
+	}
+	return res
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckLocales(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml":           []byte("defaultLocale: en\nprojectId: hello-world\n"),
+		"settings/fr/settings.yaml":        []byte("displayName: Bonjour\n"),
+		"settings/de/settings.yaml":        []byte("displayName: Hallo\n"),
+		"resources/strings/bundle.yaml":    []byte("greeting: Hi\nbye: Bye\n"),
+		"resources/strings/fr/bundle.yaml": []byte("greeting: Salut\nbye: Au revoir\n"),
+		"resources/strings/de/bundle.yaml": []byte("greeting: Hallo\n"),
+		"resources/strings/es/bundle.yaml": []byte("greeting: Hola\nbye: Adios\n"),
+		"custom/intents/order.yaml":        []byte("trainingPhrases:\n- order\n"),
+		"custom/intents/fr/order.yaml":     []byte("trainingPhrases:\n- commander\n"),
+		"custom/intents/yes.yaml":          []byte("parameters: []\n"),
+		"custom/scenes/Main.yaml":          []byte("onEnter: {}\n"),
+		"resources/images/fr/logo.png":     []byte("abc"),
+	}
+	got, err := checkLocales(files)
+	if err != nil {
+		t.Fatalf("checkLocales returned %v, want %v", err, nil)
+	}
+	want := []issue{
+		{File: "resources/strings/es/bundle.yaml", Locale: "es", Severity: severityError, Message: "locale es has localized files, but settings/es/settings.yaml is missing"},
+		{File: "custom/intents/de/order.yaml", Locale: "de", Severity: severityError, Message: `intent "order" has no training phrases in de`},
+		{File: "resources/strings/de/bundle.yaml", Locale: "de", Severity: severityError, Message: `string "bye" is not translated to de`},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("checkLocales returned diff (-want, +got)\n%s", diff)
+	}
+	delete(files, "settings/settings.yaml")
+	if _, err := checkLocales(files); err == nil {
+		t.Errorf("checkLocales returned %v without the default locale, want an error", err)
+	}
+}
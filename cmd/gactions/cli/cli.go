@@ -17,33 +17,81 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 
+	"github.com/actions-on-google/gactions/api/apiutils"
 	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/accountlinking"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/canvas"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/ci"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/decrypt"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/deploy"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/dev"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/diff"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/docs"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/encrypt"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/ginit"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/gproject"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/history"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/importer"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/intents"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/locales"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/login"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/logout"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/logs"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/lsp"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/mockserver"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/notices"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/ping"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/preflight"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/prompts"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/pull"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/push"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/quota"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/releasechannels"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/resources"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/schema"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/settings"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/snapshot"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/submitcheck"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/types"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/validate"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/version"
 	"github.com/actions-on-google/gactions/cmd/gactions/cli/versions"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/webhook"
 	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
 )
 
 const (
-	verboseFlagName  = "verbose"
-	consumerFlagName = "consumer"
+	verboseFlagName         = "verbose"
+	consumerFlagName        = "consumer"
+	compressUploadsFlagName = "compress-uploads"
+	useADCFlagName          = "use-adc"
+	formatFlagName          = "format"
+	maxAttemptsFlagName     = "max-attempts"
+	profileFlagName         = "profile"
+	quietFlagName           = "quiet"
+	proxyFlagName           = "proxy"
+	caBundleFlagName        = "ca-bundle"
+	logFileFlagName         = "log-file"
+	apiEndpointFlagName     = "api-endpoint"
 )
 
+// closeLogFile stops the tee to the file of --log-file and closes it. Execute calls it after
+// the error of the command is logged.
+var closeLogFile = func() {}
+
 // Command returns a *cobra.Command setup with the common set of commands
 // and configuration already done.
 func Command(ctx context.Context, name string, debug bool, ver string) *cobra.Command {
+	// The flags set the Config of the commands before they run.
+	cfg := sdk.ConfigFrom(ctx)
+	ctx = sdk.WithConfig(withCancel(ctx), cfg)
 	root := &cobra.Command{
 		Use:           name,
 		Short:         "Command Line Interface for Google Actions SDK",
@@ -51,10 +99,22 @@ func Command(ctx context.Context, name string, debug bool, ver string) *cobra.Co
 		SilenceErrors: true, // Would like to print errors ourselves.
 	}
 	root.PersistentFlags().BoolP(verboseFlagName, "v", false, "Display additional error information")
+	root.PersistentFlags().BoolP(quietFlagName, "q", false, "Don't display progress bars while files are sent to or received from Actions Console, or the warning of decrypt --stdout. Progress bars are also hidden when the output isn't a terminal")
+	root.PersistentFlags().String(logFileFlagName, "", "Append all output of the command to the file, including info and debug messages which aren't displayed")
 
 	root.PersistentFlags().String(consumerFlagName, "", "String identifying the caller to Google")
 	// This field is hidden as it's not documented and only used by tooling partners using the CLI.
 	root.PersistentFlags().MarkHidden(consumerFlagName)
+	root.PersistentFlags().Bool(compressUploadsFlagName, cfg.CompressUploads, "Compress the files uploaded to Actions Console with gzip. Files are sent again uncompressed if the server rejects compressed requests with HTTP 415")
+	root.PersistentFlags().Duration(timeoutFlagName, 0, "Cancel the command if it doesn't finish in this time, e.g. 10m. By default, commands don't time out")
+	root.PersistentFlags().Int(maxAttemptsFlagName, cfg.MaxAttempts, "Maximum number of times a request to Google APIs is sent when it fails with a transient error (HTTP 429, 502, 503 or 504). Retries wait with a growing backoff or for the Retry-After of the server")
+	root.PersistentFlags().String(proxyFlagName, "", "URL of the proxy of requests to Google APIs, e.g. http://proxy.example.com:3128. By default, the proxy is set by the HTTPS_PROXY and NO_PROXY environment variables")
+	root.PersistentFlags().String(apiEndpointFlagName, "", fmt.Sprintf("URL of the Actions API to send requests to instead of the production API, e.g. of a staging backend, or http://localhost:8080 of mock-server. Only localhost may use HTTP. Can also be set by the %v environment variable", sdk.APIEndpointEnv))
+	root.PersistentFlags().String(caBundleFlagName, "", "File of PEM certificates of CAs to trust in addition to the CAs of the system, e.g. the CA of a proxy which intercepts TLS")
+	root.PersistentFlags().Bool(useADCFlagName, false, fmt.Sprintf("Use Application Default Credentials (e.g. of gcloud, the GCE metadata server or workload identity) when not logged in. Can also be set by \"useADC: true\" in %v", project.ConfigName))
+	root.PersistentFlags().String(profileFlagName, "", fmt.Sprintf("Use the credentials of the login profile instead of the profile set by the \"profile\" key in %v. login and logout log in to and out of the profile, so that separate accounts can be used for different projects, and project migrate binds it to the project", project.ConfigName))
+	root.PersistentFlags().String(formatFlagName, log.TextFormat, fmt.Sprintf("Output format of list commands (release-channels, versions list and init): %v", strings.Join(log.Formats, ", ")))
+	addProfilingFlags(root)
 
 	projectRoot, err := studio.FindProjectRoot()
 	if err != nil {
@@ -70,21 +130,80 @@ func Command(ctx context.Context, name string, debug bool, ver string) *cobra.Co
 	push.AddCommand(ctx, root, project)
 	deploy.AddCommand(ctx, root, project)
 	login.AddCommand(ctx, root, project)
-	logout.AddCommand(root, project)
+	logout.AddCommand(ctx, root, project)
+	logs.AddCommand(ctx, root, project)
 	pull.AddCommand(ctx, root, project)
 	encrypt.AddCommand(ctx, root, project)
 	decrypt.AddCommand(ctx, root, project)
-	version.AddCommand(root)
+	version.AddCommand(ctx, root)
 	notices.AddCommand(root)
 	releasechannels.AddCommand(ctx, root, project)
 	versions.AddCommand(ctx, root, project)
+	importer.AddCommand(ctx, root, project)
+	intents.AddCommand(ctx, root, project)
+	types.AddCommand(ctx, root, project)
+	prompts.AddCommand(ctx, root, project)
+	webhook.AddCommand(ctx, root, project)
+	canvas.AddCommand(ctx, root, project)
+	accountlinking.AddCommand(ctx, root, project)
+	history.AddCommand(ctx, root, project)
+	docs.AddCommand(root, project)
+	schema.AddCommand(root)
+	lsp.AddCommand(root, project)
+	mockserver.AddCommand(root)
+	settings.AddCommand(ctx, root, project)
+	snapshot.AddCommand(ctx, root, project)
+	resources.AddCommand(ctx, root, project)
+	ci.AddCommand(ctx, root, project)
+	diff.AddCommand(ctx, root, project)
+	dev.AddCommand(ctx, root, project)
+	gproject.AddCommand(ctx, root, project)
+	locales.AddCommand(root, project)
+	ping.AddCommand(ctx, root)
+	preflight.AddCommand(ctx, root, project)
+	quota.AddCommand(ctx, root, project)
+	submitcheck.AddCommand(root, project)
+	validate.AddCommand(root, project)
 
 	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
 		// Init logging first since functions below may call log.
 		if err := initLogging(cmd, debug); err != nil {
 			return err
 		}
-		if err := setConsumer(cmd); err != nil {
+		if err := setLogFile(cmd); err != nil {
+			return err
+		}
+		if err := setQuiet(cmd); err != nil {
+			return err
+		}
+		if err := setConsumer(cmd, cfg); err != nil {
+			return err
+		}
+		if err := setAPIEndpoint(cmd, cfg); err != nil {
+			return err
+		}
+		if err := setCompressUploads(cmd, cfg); err != nil {
+			return err
+		}
+		if err := setMaxAttempts(cmd, cfg); err != nil {
+			return err
+		}
+		if err := setTimeout(cmd); err != nil {
+			return err
+		}
+		if err := setNetwork(cmd, cfg); err != nil {
+			return err
+		}
+		if err := setUseADC(cmd, cfg); err != nil {
+			return err
+		}
+		if err := setFormat(cmd); err != nil {
+			return err
+		}
+		if err := setProfile(cmd, cfg); err != nil {
+			return err
+		}
+		if err := startProfiling(cmd); err != nil {
 			return err
 		}
 		return nil
@@ -92,16 +211,128 @@ func Command(ctx context.Context, name string, debug bool, ver string) *cobra.Co
 	return root
 }
 
-func setConsumer(cmd *cobra.Command) error {
+func setQuiet(cmd *cobra.Command) error {
+	quiet, err := cmd.Flags().GetBool(quietFlagName)
+	if err != nil {
+		return err
+	}
+	log.Quiet = quiet
+	return nil
+}
+
+func setConsumer(cmd *cobra.Command, cfg *sdk.Config) error {
 	consumer, err := cmd.Flags().GetString(consumerFlagName)
 	if err != nil {
 		return err
 	}
-	sdk.Consumer = consumer
+	cfg.Consumer = consumer
 	log.Debugf("Set consumer to %s\n", consumer)
 	return nil
 }
 
+func setAPIEndpoint(cmd *cobra.Command, cfg *sdk.Config) error {
+	endpoint, err := cmd.Flags().GetString(apiEndpointFlagName)
+	if err != nil {
+		return err
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv(sdk.APIEndpointEnv)
+	}
+	if endpoint == "" {
+		return nil
+	}
+	if err := cfg.SetAPIEndpoint(endpoint); err != nil {
+		return err
+	}
+	log.Infof("Sending requests of the Actions API to %v.\n", cfg.APIEndpoint)
+	return nil
+}
+
+func setCompressUploads(cmd *cobra.Command, cfg *sdk.Config) error {
+	b, err := cmd.Flags().GetBool(compressUploadsFlagName)
+	if err != nil {
+		return err
+	}
+	cfg.CompressUploads = b
+	log.Debugf("Set compress uploads to %v\n", b)
+	return nil
+}
+
+func setMaxAttempts(cmd *cobra.Command, cfg *sdk.Config) error {
+	n, err := cmd.Flags().GetInt(maxAttemptsFlagName)
+	if err != nil {
+		return err
+	}
+	if n < 1 {
+		return fmt.Errorf("--%s must be at least 1, got %v", maxAttemptsFlagName, n)
+	}
+	cfg.MaxAttempts = n
+	log.Debugf("Set max attempts to %v\n", n)
+	return nil
+}
+
+// setNetwork sets the proxy and the CAs of requests to Google APIs. The transport is built
+// right away, so an invalid proxy or CA bundle fails the command before any request.
+func setNetwork(cmd *cobra.Command, cfg *sdk.Config) error {
+	proxy, err := cmd.Flags().GetString(proxyFlagName)
+	if err != nil {
+		return err
+	}
+	bundle, err := cmd.Flags().GetString(caBundleFlagName)
+	if err != nil {
+		return err
+	}
+	cfg.Proxy = proxy
+	cfg.CABundle = bundle
+	if _, err := cfg.NewTransport(); err != nil {
+		return fmt.Errorf("--%s or --%s is invalid: %v", proxyFlagName, caBundleFlagName, err)
+	}
+	return nil
+}
+
+func setUseADC(cmd *cobra.Command, cfg *sdk.Config) error {
+	use, err := cmd.Flags().GetBool(useADCFlagName)
+	if err != nil {
+		return err
+	}
+	cfg.UseADC = use
+	return nil
+}
+
+// setFormat sets the output format from the global --format flag. Commands with their own
+// --format flag, such as "locales export", keep the default output format.
+func setFormat(cmd *cobra.Command) error {
+	if cmd.Flags().Lookup(formatFlagName) != cmd.Root().PersistentFlags().Lookup(formatFlagName) {
+		return nil
+	}
+	format, err := cmd.Flags().GetString(formatFlagName)
+	if err != nil {
+		return err
+	}
+	for _, f := range log.Formats {
+		if format == f {
+			log.Format = format
+			return nil
+		}
+	}
+	return fmt.Errorf("--%s must be one of %v, got %q", formatFlagName, strings.Join(log.Formats, ", "), format)
+}
+
+// setProfile sets the login profile from the global --profile flag.
+func setProfile(cmd *cobra.Command, cfg *sdk.Config) error {
+	profile, err := cmd.Flags().GetString(profileFlagName)
+	if err != nil {
+		return err
+	}
+	if profile != "" {
+		if _, err := apiutils.ProfileTokenFile(profile); err != nil {
+			return err
+		}
+	}
+	cfg.Profile = profile
+	return nil
+}
+
 func initLogging(cmd *cobra.Command, debug bool) error {
 	isVerbose, err := cmd.Flags().GetBool(verboseFlagName)
 	if err != nil {
@@ -117,11 +348,78 @@ func initLogging(cmd *cobra.Command, debug bool) error {
 	return nil
 }
 
-// Execute runs the command and displays errors. Returns the exit code for the CLI.
-func Execute(cmd *cobra.Command) int {
-	if err := cmd.Execute(); err != nil {
+// setLogFile tees the output to the file of the --log-file flag.
+func setLogFile(cmd *cobra.Command) error {
+	fn, err := cmd.Flags().GetString(logFileFlagName)
+	if err != nil {
+		return err
+	}
+	if fn == "" {
+		return nil
+	}
+	f, err := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0640)
+	if err != nil {
+		return fmt.Errorf("can't open the file of --%s: %v", logFileFlagName, err)
+	}
+	log.SetLogFile(f)
+	closeLogFile = func() {
+		log.SetLogFile(nil)
+		f.Close()
+		closeLogFile = func() {}
+	}
+	log.Debugf("Running %v\n", cmd.CommandPath())
+	return nil
+}
+
+// Execute runs the command with args and displays errors. Aliases and default flags from the
+// user config are expanded in args before cobra parses them. Returns the exit code for the CLI.
+func Execute(cmd *cobra.Command, args []string) int {
+	if expanded, err := userArgs(cmd, args); err != nil {
+		log.Warnf("Ignoring the user config: %v\n", err)
+	} else if expanded != nil {
+		args = expanded
+	}
+	cmd.SetArgs(args)
+	stopInterrupt := handleInterrupt()
+	err := cmd.Execute()
+	stopInterrupt()
+	stopProfiling()
+	if reason := canceled(); err != nil && reason != nil {
+		err = fmt.Errorf("%v: %v", reason, err)
+	}
+	defer closeLogFile()
+	if err != nil {
 		log.Error(err)
-		return 1
+		log.Annotate("error", "", err.Error())
+		return exitCode(err)
 	}
 	return 0
 }
+
+// Exit codes of failed commands, by the class of the error, so automation can tell them apart.
+// They are documented in README.md.
+const (
+	exitFailed           = 1
+	exitValidationFailed = 2
+	exitAuthFailed       = 3
+	exitNetworkFailed    = 4
+	exitQuotaExceeded    = 5
+	exitNotFound         = 6
+)
+
+// exitCode returns the exit code for err, which isn't nil.
+func exitCode(err error) int {
+	switch sdk.KindOf(err) {
+	case sdk.ValidationError:
+		return exitValidationFailed
+	case sdk.AuthError:
+		return exitAuthFailed
+	case sdk.NetworkError:
+		return exitNetworkFailed
+	case sdk.QuotaError:
+		return exitQuotaExceeded
+	case sdk.NotFoundError:
+		return exitNotFound
+	}
+	return exitFailed
+}
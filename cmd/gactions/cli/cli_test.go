@@ -16,7 +16,11 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log/log"
@@ -33,7 +37,7 @@ func TestCommandDebugSet(t *testing.T) {
 	cmd.RunE = func(*cobra.Command, []string) error {
 		return nil
 	}
-	_ = Execute(cmd)
+	_ = Execute(cmd, []string{})
 	if log.Severity != log.DebugLevel {
 		t.Errorf("Command set severity to %v, but want %v", log.Severity, log.DebugLevel)
 	}
@@ -49,15 +53,14 @@ func TestCommandDebugNotSet(t *testing.T) {
 	cmd.RunE = func(*cobra.Command, []string) error {
 		return nil
 	}
-	_ = Execute(cmd)
+	_ = Execute(cmd, []string{})
 	if log.Severity != log.WarnLevel {
 		t.Errorf("Command set severity to %v, but want %v", log.Severity, log.WarnLevel)
 	}
 	// check debug flags
 	debugFlags := []string{"--env=foo", "--cookie=abc"}
 	for _, v := range debugFlags {
-		cmd.SetArgs([]string{v})
-		code := Execute(cmd)
+		code := Execute(cmd, []string{v})
 		if code != 1 {
 			t.Errorf("Executed returned %v, but want %v when %v flag is set.", code, 1, v)
 		}
@@ -65,31 +68,100 @@ func TestCommandDebugNotSet(t *testing.T) {
 }
 
 func TestCommandEnvFlagDebugSet(t *testing.T) {
-	old := sdk.CurEnv
-	defer func() {
-		sdk.CurEnv = old
-	}()
-	cmd := Command(context.Background(), "gactions", true, "")
+	cfg := sdk.NewConfig()
+	cmd := Command(sdk.WithConfig(context.Background(), cfg), "gactions", true, "")
 	// CLI sets logging at runtime, so need to simulate execution
 	cmd.RunE = func(*cobra.Command, []string) error {
 		return nil
 	}
 	// case 1
-	cmd.SetArgs([]string{"--env=prod"})
-	code := Execute(cmd)
+	code := Execute(cmd, []string{"--env=prod"})
 	if code != 0 {
 		t.Errorf("Execute returned %v, but want %v", code, 0)
 	}
-	if sdk.CurEnv != "prod" {
-		t.Errorf("Expected to set CurEnv to %v, but got %v", "prod", sdk.CurEnv)
+	if cfg.Env != "prod" {
+		t.Errorf("Expected to set Env to %v, but got %v", "prod", cfg.Env)
 	}
 	// case 2
-	cmd.SetArgs([]string{"--env=foo"})
-	code = Execute(cmd)
+	code = Execute(cmd, []string{"--env=foo"})
 	if code != 1 {
 		t.Errorf("Executed returned %v, but want %v", code, 1)
 	}
-	if sdk.CurEnv != "prod" {
-		t.Errorf("Expected CurEnv to remain %v, but got %v", "prod", sdk.CurEnv)
+	if cfg.Env != "prod" {
+		t.Errorf("Expected Env to remain %v, but got %v", "prod", cfg.Env)
+	}
+}
+
+func TestAPIEndpointFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		env      string
+		wantCode int
+		want     string
+	}{
+		{args: []string{"--api-endpoint=http://localhost:8080"}, want: "http://localhost:8080"},
+		{env: "https://staging-actions.example.com", want: "https://staging-actions.example.com"},
+		{args: []string{"--api-endpoint=http://localhost:8080"}, env: "https://staging-actions.example.com", want: "http://localhost:8080"},
+		{args: []string{"--api-endpoint=http://staging-actions.example.com"}, wantCode: 1},
+	}
+	for _, tc := range tests {
+		t.Setenv(sdk.APIEndpointEnv, tc.env)
+		cfg := sdk.NewConfig()
+		cmd := Command(sdk.WithConfig(context.Background(), cfg), "gactions", false, "")
+		cmd.RunE = func(*cobra.Command, []string) error {
+			return nil
+		}
+		if code := Execute(cmd, tc.args); code != tc.wantCode {
+			t.Errorf("Execute(%v) with %v=%q returned %v, want %v", tc.args, sdk.APIEndpointEnv, tc.env, code, tc.wantCode)
+		}
+		if cfg.APIEndpoint != tc.want {
+			t.Errorf("Execute(%v) with %v=%q set the API endpoint to %q, want %q", tc.args, sdk.APIEndpointEnv, tc.env, cfg.APIEndpoint, tc.want)
+		}
+	}
+}
+
+func TestTimeoutCancelsCommand(t *testing.T) {
+	ctx := withCancel(context.Background())
+	cmd := &cobra.Command{}
+	cmd.Flags().Duration(timeoutFlagName, 0, "")
+	if err := cmd.Flags().Set(timeoutFlagName, "10ms"); err != nil {
+		t.Fatal(err)
+	}
+	if err := setTimeout(cmd); err != nil {
+		t.Fatalf("setTimeout returned %v, want %v", err, nil)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("context wasn't canceled after the timeout")
+	}
+	if canceled() == nil {
+		t.Errorf("canceled returned %v after the timeout, but want an error", nil)
+	}
+	if err := cmd.Flags().Set(timeoutFlagName, "-1s"); err != nil {
+		t.Fatal(err)
+	}
+	if err := setTimeout(cmd); err == nil {
+		t.Errorf("setTimeout returned %v for a negative timeout, but want an error", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: errors.New("failed"), want: exitFailed},
+		{err: sdk.CheckValidationIssues([]sdk.ValidationIssue{{Message: "Your app must have a 32x32 logo"}}), want: exitValidationFailed},
+		{err: fmt.Errorf("canceled: %w", &sdk.ValidationFailedError{}), want: exitValidationFailed},
+		{err: &sdk.Error{Kind: sdk.AuthError, Err: errors.New("command requires authentication")}, want: exitAuthFailed},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: exitNetworkFailed},
+		{err: &sdk.Error{Kind: sdk.QuotaError, Err: errors.New("quota exceeded")}, want: exitQuotaExceeded},
+		{err: &sdk.Error{Kind: sdk.NotFoundError, Err: errors.New("not found")}, want: exitNotFound},
+	}
+	for _, tc := range tests {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%v) returned %v, but want %v", tc.err, got, tc.want)
+		}
 	}
 }
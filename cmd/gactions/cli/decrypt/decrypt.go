@@ -19,7 +19,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -27,19 +29,21 @@ import (
 	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 )
 
-func parseClientSecret(files map[string][]byte) (string, error) {
+func parseClientSecret(files map[string][]byte, env string) (string, error) {
 	type secretFile struct {
 		EncryptedClientSecret string `yaml:"encryptedClientSecret"`
 	}
-	in, ok := files["settings/accountLinkingSecret.yaml"]
+	p := studio.AccountLinkingSecretPath(env)
+	in, ok := files[p]
 	if !ok {
-		log.Infoln("accountLinkingSecret.yaml not found in project files")
-		return "", errors.New("accountLinkingSecret.yaml not found in project files. " +
-			"Try encrypting your client secret first, or pulling an existing project with a client secret")
+		log.Infof("%v not found in project files\n", p)
+		return "", fmt.Errorf("%v not found in project files. "+
+			"Try encrypting your client secret first, or pulling an existing project with a client secret", path.Base(p))
 	}
 	f := secretFile{}
 	if err := yaml.Unmarshal(in, &f); err != nil {
@@ -76,37 +80,101 @@ func normPath(p, root string) string {
 	return norm
 }
 
+// envLine returns plain as a line of an env file, which sets the variable name to plain. The
+// value is single-quoted, so it can also be evaluated by a POSIX shell.
+func envLine(name, plain string) string {
+	return fmt.Sprintf("%s='%s'\n", name, strings.ReplaceAll(plain, "'", `'\''`))
+}
+
+// writeSecret writes plain to w, as is or as a line of an env file if envName is not empty.
+func writeSecret(w io.Writer, plain, envName string) error {
+	out := plain
+	if envName != "" {
+		out = envLine(envName, plain)
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// warnPlainText warns that the client secret is printed in plain text, unless --quiet is set.
+// The warning is printed to stderr, so it doesn't mix with the secret printed to stdout.
+func warnPlainText() {
+	if log.Quiet {
+		return
+	}
+	log.Warnf("The decrypted client secret is printed in plain text. Make sure the output isn't logged or stored.\n")
+}
+
 // AddCommand adds decrypt sub-command to the passed in root command.
 func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
 	decrypt := &cobra.Command{
-		Use:   "decrypt <plaint-text-file>",
+		Use:   "decrypt [<plaint-text-file>]",
 		Short: "Decrypt client secret.",
-		Long:  "This command decrypts the client secret key used in Account Linking. Specify a file path for the decrypt output. This can be a relative or absolute path.",
+		Long: "This command decrypts the client secret key used in Account Linking. Specify a file path for the decrypt output. This can be a relative or absolute path. " +
+			"Alternatively, use --stdout to print the client secret, so it can be piped into another tool without being stored on disk.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if proj.ProjectRoot() == "" {
 				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
 				return errors.New("can not determine project root")
 			}
+			toStdout, err := cmd.Flags().GetBool("stdout")
+			if err != nil {
+				return err
+			}
+			envFormat, err := cmd.Flags().GetBool("env-format")
+			if err != nil {
+				return err
+			}
+			envName, err := cmd.Flags().GetString("env-name")
+			if err != nil {
+				return err
+			}
+			env, err := cmd.Flags().GetString("env")
+			if err != nil {
+				return err
+			}
 			files, err := proj.Files()
 			if err != nil {
 				return err
 			}
-			s, err := parseClientSecret(files)
+			s, err := parseClientSecret(files, env)
+			if err != nil {
+				return err
+			}
+			if !toStdout {
+				out := normPath(args[0], proj.ProjectRoot())
+				return sdk.DecryptSecretJSON(ctx, proj, s, out)
+			}
+			warnPlainText()
+			plain, err := sdk.DecryptSecret(ctx, proj, s)
 			if err != nil {
 				return err
 			}
-			out := normPath(args[0], proj.ProjectRoot())
-			return sdk.DecryptSecretJSON(ctx, proj, s, out)
+			if !envFormat {
+				envName = ""
+			}
+			return writeSecret(os.Stdout, plain, envName)
 		},
 		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 1 {
+			toStdout, err := cmd.Flags().GetBool("stdout")
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("env-format") && !toStdout {
+				return errors.New("--env-format can only be used with --stdout")
+			}
+			if len(args) > 1 || (toStdout && len(args) > 0) {
 				return fmt.Errorf("unexpected arguments: %v", args)
 			}
-			if len(args) < 1 {
-				return fmt.Errorf(`<plain-text-file> argument is missing. Try "gactions decrypt <pathToPlainTextFile>"`)
+			if len(args) < 1 && !toStdout {
+				return fmt.Errorf(`<plain-text-file> argument is missing. Try "gactions decrypt <pathToPlainTextFile>" or "gactions decrypt --stdout"`)
 			}
 			return nil
 		},
 	}
+	decrypt.Flags().Bool("stdout", false, "Print the decrypted client secret to stdout instead of writing it to a file.")
+	decrypt.Flags().Bool("env-format", false, "Print the decrypted client secret with --stdout as a line of an env file, which sets the variable specified by --env-name.")
+	decrypt.Flags().String("env", "", "Environment of the client secret, such as staging. The secret is read from settings/accountLinkingSecret.<env>.yaml.")
+	decrypt.Flags().String("env-name", "CLIENT_SECRET", "Name of the variable printed with --env-format.")
 	root.AddCommand(decrypt)
 }
@@ -15,10 +15,13 @@
 package decrypt
 
 import (
+	"bytes"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/actions-on-google/gactions/log"
 )
 
 func osAbs(p string) string {
@@ -61,3 +64,88 @@ func TestNormPath(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteSecret(t *testing.T) {
+	tests := []struct {
+		plain   string
+		envName string
+		want    string
+	}{
+		{
+			plain: "my-secret",
+			want:  "my-secret",
+		},
+		{
+			plain:   "my-secret",
+			envName: "CLIENT_SECRET",
+			want:    "CLIENT_SECRET='my-secret'\n",
+		},
+		{
+			plain:   "it's $HOME",
+			envName: "STAGING_SECRET",
+			want:    `STAGING_SECRET='it'\''s $HOME'` + "\n",
+		},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		if err := writeSecret(&b, tc.plain, tc.envName); err != nil {
+			t.Errorf("writeSecret(%q, %q) returned %v", tc.plain, tc.envName, err)
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("writeSecret(%q, %q) wrote %q, but want %q", tc.plain, tc.envName, got, tc.want)
+		}
+	}
+}
+
+func TestParseClientSecret(t *testing.T) {
+	files := map[string][]byte{
+		"settings/accountLinkingSecret.yaml":         []byte("encryptedClientSecret: prod\n"),
+		"settings/accountLinkingSecret.staging.yaml": []byte("encryptedClientSecret: staging\n"),
+	}
+	tests := []struct {
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{env: "", want: "prod"},
+		{env: "staging", want: "staging"},
+		{env: "dev", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseClientSecret(files, tc.env)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseClientSecret(%q) returned %v, want error %v", tc.env, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("parseClientSecret(%q) returned %v, want %v", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestWarnPlainText(t *testing.T) {
+	oldOut, oldErr := log.Output()
+	oldQuiet := log.Quiet
+	defer func() {
+		log.SetOutput(oldOut, oldErr)
+		log.Quiet = oldQuiet
+	}()
+	tests := []struct {
+		quiet bool
+		want  bool
+	}{
+		{quiet: false, want: true},
+		{quiet: true, want: false},
+	}
+	for _, tc := range tests {
+		var stdout, stderr bytes.Buffer
+		log.SetOutput(&stdout, &stderr)
+		log.Quiet = tc.quiet
+		warnPlainText()
+		if got := strings.Contains(stderr.String(), "plain text"); got != tc.want {
+			t.Errorf("warnPlainText with quiet %v printed %q to stderr, want the warning %v", tc.quiet, stderr.String(), tc.want)
+		}
+		if stdout.Len() > 0 {
+			t.Errorf("warnPlainText printed %q to stdout, want nothing", stdout.String())
+		}
+	}
+}
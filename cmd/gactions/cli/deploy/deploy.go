@@ -17,15 +17,21 @@ package deploy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/preflight"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/webhook"
+	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
 )
 
-func setProjectID(project *project.Project) error {
+// setProjectID sets the project ID of project from its settings, and selects the environment
+// set by the --env flag of cmd.
+func setProjectID(project *project.Project, cmd *cobra.Command) error {
 	studioProj, ok := (*project).(studio.Studio)
 	if !ok {
 		return fmt.Errorf("can not convert %T to %T", project, studio.Studio{})
@@ -33,10 +39,71 @@ func setProjectID(project *project.Project) error {
 	if err := (&studioProj).SetProjectID(""); err != nil {
 		return err
 	}
+	env, err := cmd.Flags().GetString("env")
+	if err != nil {
+		return err
+	}
+	if err := (&studioProj).SetEnv(env); err != nil {
+		return err
+	}
 	*project = studioProj
 	return nil
 }
 
+// runPreflight runs the preflight checks on project if the --preflight flag of cmd is set.
+func runPreflight(ctx context.Context, project project.Project, cmd *cobra.Command) error {
+	run, err := cmd.Flags().GetBool("preflight")
+	if err != nil || !run {
+		return err
+	}
+	return preflight.Run(ctx, project)
+}
+
+// runPing pings the deployed inline cloud functions of project if the --ping flag of cmd is set.
+func runPing(ctx context.Context, project project.Project, cmd *cobra.Command) error {
+	run, err := cmd.Flags().GetBool("ping")
+	if err != nil || !run {
+		return err
+	}
+	handler, err := cmd.Flags().GetString("ping-handler")
+	if err != nil {
+		return err
+	}
+	region, err := cmd.Flags().GetString("ping-region")
+	if err != nil {
+		return err
+	}
+	return webhook.Ping(ctx, project, region, handler)
+}
+
+// checkStrict returns an error if the --strict flag of cmd is set and the server found issues.
+func checkStrict(cmd *cobra.Command, issues []sdk.ValidationIssue) error {
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil || !strict {
+		return err
+	}
+	return sdk.CheckValidationIssues(issues)
+}
+
+// releaseNotes returns the release notes assembled from the git revisions set by the
+// --release-notes-from-git flag of cmd, or "" if the flag isn't set.
+func releaseNotes(ctx context.Context, project project.Project, cmd *cobra.Command) (string, error) {
+	revisions, err := cmd.Flags().GetString("release-notes-from-git")
+	if err != nil || revisions == "" {
+		return "", err
+	}
+	notes, err := releaseNotesFromGit(ctx, project.ProjectRoot(), revisions)
+	if err != nil {
+		return "", err
+	}
+	if notes == "" {
+		log.Warnf("No commits in %v change the project, so the version has no release notes.\n", revisions)
+		return "", nil
+	}
+	log.Outf("Release notes:\n%v", notes)
+	return notes, nil
+}
+
 // AddCommand adds the deploy sub-command to the passed in root command.
 func AddCommand(ctx context.Context, root *cobra.Command, project project.Project) {
 	deploy := &cobra.Command{
@@ -51,23 +118,65 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 		Long:  "This command deploys an Action to preview, so you can test your Action in the simulator.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			sandbox, _ := cmd.Flags().GetBool("sandbox")
-			if err := setProjectID(&project); err != nil {
+			validateOnly, _ := cmd.Flags().GetBool("validate-only")
+			pushFirst, _ := cmd.Flags().GetBool("push-first")
+			if ping, _ := cmd.Flags().GetBool("ping"); ping && validateOnly {
+				return errors.New("--ping can not be used with --validate-only, because nothing is deployed")
+			}
+			if pushFirst && validateOnly {
+				return errors.New("--push-first can not be used with --validate-only, because nothing is pushed")
+			}
+			if err := setProjectID(&project, cmd); err != nil {
+				return err
+			}
+			if err := runPreflight(ctx, project, cmd); err != nil {
+				return err
+			}
+			if validateOnly {
+				issues, err := sdk.ValidateOnlyPreviewJSON(ctx, project, sandbox)
+				if err != nil {
+					return err
+				}
+				return checkStrict(cmd, issues)
+			}
+			write := sdk.WritePreviewJSON
+			if pushFirst {
+				write = sdk.WriteDraftAndPreviewJSON
+			}
+			issues, err := write(ctx, project, sandbox)
+			if err != nil {
 				return err
 			}
-			return sdk.WritePreviewJSON(ctx, project, sandbox)
+			if err := runPing(ctx, project, cmd); err != nil {
+				return err
+			}
+			return checkStrict(cmd, issues)
 		},
 	}
 	preview.Flags().Bool("sandbox", true,
 		"Indicates whether or not to run certain operations, such as transactions, in sandbox mode. The default value is set to true")
+	preview.Flags().Bool("validate-only", false, "Only validate the files on the server, which returns the same validation results as a deploy, without writing the draft or redeploying the cloud function. Useful as a fast check in CI.")
+	preview.Flags().Bool("push-first", false, "Push the files to the draft before deploying them for preview, as \"gactions push\" does. The project files are read once for both.")
+	preview.Flags().Bool("strict", false, "Fail with exit code 2 if the server found validation issues, e.g. a missing logo. The files are still deployed.")
+	preview.Flags().Bool("ping", false, "After deploying, invoke the inline cloud functions with a ping request and report their cold start latency and errors.")
+	preview.Flags().String("ping-handler", "ping", "Name of the webhook handler invoked by the ping request. The ping fails unless the function responds with a 2xx status.")
+	preview.Flags().String("ping-region", "us-central1", "Region where the inline cloud functions are deployed.")
 	alpha := &cobra.Command{
 		Use:   "alpha",
 		Short: "Deploy to alpha channel.",
 		Long:  "This command deploys to alpha channel.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := setProjectID(&project); err != nil {
+			if err := setProjectID(&project, cmd); err != nil {
+				return err
+			}
+			if err := runPreflight(ctx, project, cmd); err != nil {
+				return err
+			}
+			notes, err := releaseNotes(ctx, project, cmd)
+			if err != nil {
 				return err
 			}
-			return sdk.CreateVersionJSON(ctx, project, sdk.AlphaChannel)
+			return sdk.CreateVersionJSON(ctx, project, sdk.AlphaChannel, notes)
 		},
 	}
 	beta := &cobra.Command{
@@ -75,10 +184,17 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 		Short: "Deploy to beta channel.",
 		Long:  "This command deploys to beta channel.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := setProjectID(&project); err != nil {
+			if err := setProjectID(&project, cmd); err != nil {
+				return err
+			}
+			if err := runPreflight(ctx, project, cmd); err != nil {
+				return err
+			}
+			notes, err := releaseNotes(ctx, project, cmd)
+			if err != nil {
 				return err
 			}
-			return sdk.CreateVersionJSON(ctx, project, sdk.BetaChannel)
+			return sdk.CreateVersionJSON(ctx, project, sdk.BetaChannel, notes)
 		},
 	}
 	prod := &cobra.Command{
@@ -86,12 +202,24 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 		Short: "Deploy to production channel.",
 		Long:  "This command deploys to production channel.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := setProjectID(&project); err != nil {
+			if err := setProjectID(&project, cmd); err != nil {
+				return err
+			}
+			if err := runPreflight(ctx, project, cmd); err != nil {
 				return err
 			}
-			return sdk.CreateVersionJSON(ctx, project, sdk.ProdChannel)
+			notes, err := releaseNotes(ctx, project, cmd)
+			if err != nil {
+				return err
+			}
+			return sdk.CreateVersionJSON(ctx, project, sdk.ProdChannel, notes)
 		},
 	}
+	deploy.PersistentFlags().String("env", "", "Environment to deploy, such as staging. The account linking secret of the environment in settings/accountLinkingSecret.<env>.yaml is deployed in place of settings/accountLinkingSecret.yaml.")
+	deploy.PersistentFlags().Bool("preflight", false, "Check that the Actions API is enabled, you have the IAM permissions to deploy, and the draft endpoint is reachable before deploying.")
+	for _, c := range []*cobra.Command{alpha, beta, prod} {
+		c.Flags().String("release-notes-from-git", "", "Range of git revisions, such as v1.0..HEAD, whose commits changing the project are listed as release notes of the version. The Actions API doesn't store release notes, so they are recorded with the version in the history (see \"gactions history show\").")
+	}
 	deploy.AddCommand(preview)
 	deploy.AddCommand(alpha)
 	deploy.AddCommand(beta)
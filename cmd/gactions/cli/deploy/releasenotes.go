@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitLog runs "git log" with args in dir and returns its output.
+var gitLog = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"log"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git log failed: %v", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("can not run git, check that it is installed: %v", err)
+	}
+	return out, nil
+}
+
+// releaseNotesFromGit returns release notes listing the commits in revisions (e.g. "v1.0..HEAD")
+// which touch files under root, one per line, newest first. Merge commits are left out.
+func releaseNotesFromGit(ctx context.Context, root, revisions string) (string, error) {
+	if strings.HasPrefix(revisions, "-") {
+		return "", fmt.Errorf("invalid revision range %q", revisions)
+	}
+	out, err := gitLog(ctx, root, "--no-merges", "--format=%h %s", revisions, "--", ".")
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		hash, subject := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			hash, subject = line[:i], line[i+1:]
+		}
+		fmt.Fprintf(&b, "- %v (%v)\n", subject, hash)
+	}
+	return b.String(), nil
+}
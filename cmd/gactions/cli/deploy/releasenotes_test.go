@@ -0,0 +1,56 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReleaseNotesFromGit(t *testing.T) {
+	var gotDir string
+	var gotArgs []string
+	gitLog = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+		gotDir, gotArgs = dir, args
+		return []byte("1a2b3c4 Add a greeting scene\n5d6e7f8 Fix typo in prompts\n"), nil
+	}
+	notes, err := releaseNotesFromGit(context.Background(), "/tmp/sdk", "v1.0..HEAD")
+	if err != nil {
+		t.Fatalf("releaseNotesFromGit returned %v, want %v", err, nil)
+	}
+	want := "- Add a greeting scene (1a2b3c4)\n- Fix typo in prompts (5d6e7f8)\n"
+	if diff := cmp.Diff(want, notes); diff != "" {
+		t.Errorf("releaseNotesFromGit returned incorrect notes: diff (-want, +got)\n%s", diff)
+	}
+	if gotDir != "/tmp/sdk" {
+		t.Errorf("releaseNotesFromGit ran git in %v, want %v", gotDir, "/tmp/sdk")
+	}
+	wantArgs := []string{"--no-merges", "--format=%h %s", "v1.0..HEAD", "--", "."}
+	if diff := cmp.Diff(wantArgs, gotArgs); diff != "" {
+		t.Errorf("releaseNotesFromGit ran git log with incorrect args: diff (-want, +got)\n%s", diff)
+	}
+
+	gitLog = func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+		return []byte("\n"), nil
+	}
+	if notes, err := releaseNotesFromGit(context.Background(), "/tmp/sdk", "HEAD~1..HEAD"); notes != "" || err != nil {
+		t.Errorf("releaseNotesFromGit returned %q, %v without commits, want %q, %v", notes, err, "", nil)
+	}
+	if _, err := releaseNotesFromGit(context.Background(), "/tmp/sdk", "--output=x"); err == nil {
+		t.Errorf("releaseNotesFromGit returned %v for an option, but want an error", err)
+	}
+}
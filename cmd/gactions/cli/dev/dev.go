@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dev provides an implementation of "gactions dev" command.
+package dev
+
+import (
+	"context"
+	"errors"
+
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/webhook"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the dev sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	dev := &cobra.Command{
+		Use:   "dev",
+		Short: "Serve the inline cloud function locally with Node.js.",
+		Long: "This command serves the inline cloud function of the project (webhooks/<name>/) on a local HTTP endpoint, by running its code with Node.js like Cloud Functions does. " +
+			"Restart the command to pick up changes of the code, instead of deploying the cloud function. " +
+			"With --public-url, the project is deployed for preview with the webhook pointing to the URL, which must forward to the local endpoint (e.g. a tunnel). " +
+			"Run \"gactions deploy preview\" to restore the inline cloud function in the preview.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			name, err := cmd.Flags().GetString("webhook")
+			if err != nil {
+				return err
+			}
+			port, err := cmd.Flags().GetInt("port")
+			if err != nil {
+				return err
+			}
+			publicURL, err := cmd.Flags().GetString("public-url")
+			if err != nil {
+				return err
+			}
+			sandbox, err := cmd.Flags().GetBool("sandbox")
+			if err != nil {
+				return err
+			}
+			return webhook.Dev(ctx, proj, name, port, publicURL, sandbox)
+		},
+	}
+	dev.Flags().String("webhook", "", "Name of the inline cloud function to serve (i.e. the name of its file in the webhooks directory). Required if the project has several inline cloud functions.")
+	dev.Flags().Int("port", 8080, "Port of localhost to serve the webhook on.")
+	dev.Flags().String("public-url", "", "Public HTTPS URL that forwards to the port, e.g. a tunnel to http://localhost:8080. If set, the preview is deployed with webhook requests sent to this URL.")
+	dev.Flags().Bool("sandbox", true, "Indicates whether or not to run certain operations, such as transactions, in sandbox mode. The default value is set to true")
+	root.AddCommand(dev)
+}
@@ -0,0 +1,350 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff provides an implementation of "gactions diff" command.
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/api/yamlutils"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// AddCommand adds the diff sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	diff := &cobra.Command{
+		Use:   "diff [--local <dirA> <dirB>]",
+		Short: "Compare the local project with the draft in Actions Console, or the files of two projects.",
+		Long: "This command compares the local project with the draft in Actions Console, without writing the files of the draft to disk, and prints the changes a push would make: a unified diff of each modified config file, and the data files added, removed and modified. " +
+			"With --local, it compares the projects in two directories, e.g. exports of two branches, and prints the files added, removed and modified in dirB compared to dirA, with the path of each changed key of config files. " +
+			"Config files are compared by their content rather than their text, so changes in the order of keys, indentation and quoting are ignored. " +
+			fmt.Sprintf("If a directory has %v, the project is read from its sdkPath.", project.ConfigName),
+		Args: func(cmd *cobra.Command, args []string) error {
+			local, err := cmd.Flags().GetBool("local")
+			if err != nil {
+				return err
+			}
+			if !local {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			local, err := cmd.Flags().GetBool("local")
+			if err != nil {
+				return err
+			}
+			var diffs []fileDiff
+			if local {
+				a, err := readProject(args[0])
+				if err != nil {
+					return err
+				}
+				b, err := readProject(args[1])
+				if err != nil {
+					return err
+				}
+				diffs = compareProjects(a, b)
+				if len(diffs) > 0 {
+					printDiffs(log.OutLogger.Writer(), diffs)
+				}
+			} else {
+				if proj.ProjectRoot() == "" {
+					log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+					return errors.New("can not determine project root")
+				}
+				studioProj, ok := proj.(studio.Studio)
+				if !ok {
+					return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+				}
+				pid, err := cmd.Flags().GetString("project-id")
+				if err != nil {
+					return err
+				}
+				if err := (&studioProj).SetProjectID(pid); err != nil {
+					return err
+				}
+				draft, err := sdk.ReadDraftFilesJSON(ctx, studioProj)
+				if err != nil {
+					return err
+				}
+				files, err := studioProj.Files()
+				if err != nil {
+					return err
+				}
+				diffs = compareProjects(draft, files)
+				if len(diffs) > 0 {
+					printDraftDiffs(log.OutLogger.Writer(), diffs, draft, files)
+				}
+			}
+			if len(diffs) == 0 {
+				log.Outln("The projects have no differences.")
+				return nil
+			}
+			exitCode, err := cmd.Flags().GetBool("exit-code")
+			if err != nil {
+				return err
+			}
+			if exitCode {
+				return errors.New("the projects differ")
+			}
+			return nil
+		},
+	}
+	diff.Flags().Bool("local", false, "Compare the projects in two local directories instead of the local project with the draft.")
+	diff.Flags().Bool("exit-code", false, "Fail if the projects differ, like diff(1).")
+	diff.Flags().String("project-id", "", "Compare with the draft of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	root.AddCommand(diff)
+}
+
+// readProject returns the files of the project in dir, or in the sdkPath of its CLI config.
+func readProject(dir string) (map[string][]byte, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%v is not a directory", dir)
+	}
+	root := dir
+	b, err := ioutil.ReadFile(filepath.Join(dir, project.ConfigName))
+	if err == nil {
+		var cfg project.CLIConfig
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("%v has incorrect syntax: %v", filepath.Join(dir, project.ConfigName), err)
+		}
+		if cfg.SdkPath != "" {
+			root = filepath.Join(dir, filepath.FromSlash(cfg.SdkPath))
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return studio.New(nil, root).Files()
+}
+
+// Kinds of differences between files.
+const (
+	added    = "A"
+	removed  = "D"
+	modified = "M"
+)
+
+// fileDiff is a difference between the files of two projects.
+type fileDiff struct {
+	kind string
+	name string
+	// changes are the changes in the content of a modified config file. It's empty if the file
+	// isn't a config file, or if it has incorrect syntax.
+	changes []change
+}
+
+// change is a difference between the contents of two config files, at the path of a key.
+type change struct {
+	path     string
+	old, new interface{}
+	// hasOld and hasNew are false when the key is added and removed, respectively.
+	hasOld, hasNew bool
+}
+
+// compareProjects returns the differences between files of projects a and b, ordered by name.
+func compareProjects(a, b map[string][]byte) []fileDiff {
+	names := map[string]bool{}
+	for k := range a {
+		names[k] = true
+	}
+	for k := range b {
+		names[k] = true
+	}
+	var sorted []string
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	var res []fileDiff
+	for _, k := range sorted {
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case !inA:
+			res = append(res, fileDiff{kind: added, name: k})
+		case !inB:
+			res = append(res, fileDiff{kind: removed, name: k})
+		case path.Ext(k) == ".yaml":
+			if changes, ok := compareYAML(va, vb); !ok {
+				if !bytes.Equal(va, vb) {
+					res = append(res, fileDiff{kind: modified, name: k})
+				}
+			} else if len(changes) > 0 {
+				res = append(res, fileDiff{kind: modified, name: k, changes: changes})
+			}
+		case !bytes.Equal(va, vb):
+			res = append(res, fileDiff{kind: modified, name: k})
+		}
+	}
+	return res
+}
+
+// compareYAML returns the changes between the contents of YAML files a and b. It returns false
+// if either of them has incorrect syntax, in which case they can only be compared as text.
+func compareYAML(a, b []byte) ([]change, bool) {
+	ma, err := yamlutils.UnmarshalYAMLToMap(a)
+	if err != nil {
+		return nil, false
+	}
+	mb, err := yamlutils.UnmarshalYAMLToMap(b)
+	if err != nil {
+		return nil, false
+	}
+	var res []change
+	compareValues("", ma, mb, &res)
+	return res, true
+}
+
+func joinKey(p, k string) string {
+	if p == "" {
+		return k
+	}
+	return p + "." + k
+}
+
+// compareValues appends the changes between a and b at path p to res. Maps are compared by
+// their keys, and lists by their indices, because the order of items in lists matters.
+func compareValues(p string, a, b interface{}, res *[]change) {
+	switch ta := a.(type) {
+	case map[string]interface{}:
+		tb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var keys []string
+		for k := range ta {
+			keys = append(keys, k)
+		}
+		for k := range tb {
+			if _, ok := ta[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			va, inA := ta[k]
+			vb, inB := tb[k]
+			switch {
+			case !inA:
+				*res = append(*res, change{path: joinKey(p, k), new: vb, hasNew: true})
+			case !inB:
+				*res = append(*res, change{path: joinKey(p, k), old: va, hasOld: true})
+			default:
+				compareValues(joinKey(p, k), va, vb, res)
+			}
+		}
+		return
+	case []interface{}:
+		tb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(ta) || i < len(tb); i++ {
+			ip := fmt.Sprintf("%v[%d]", p, i)
+			switch {
+			case i >= len(ta):
+				*res = append(*res, change{path: ip, new: tb[i], hasNew: true})
+			case i >= len(tb):
+				*res = append(*res, change{path: ip, old: ta[i], hasOld: true})
+			default:
+				compareValues(ip, ta[i], tb[i], res)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*res = append(*res, change{path: p, old: a, new: b, hasOld: true, hasNew: true})
+	}
+}
+
+// format returns a compact, single-line representation of a value of a config file.
+func format(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func printDiffs(w io.Writer, diffs []fileDiff) {
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%v %v\n", d.kind, d.name)
+		for _, c := range d.changes {
+			switch {
+			case !c.hasOld:
+				fmt.Fprintf(w, "    + %v: %v\n", c.path, format(c.new))
+			case !c.hasNew:
+				fmt.Fprintf(w, "    - %v: %v\n", c.path, format(c.old))
+			default:
+				fmt.Fprintf(w, "    ~ %v: %v -> %v\n", c.path, format(c.old), format(c.new))
+			}
+		}
+	}
+	counts := map[string]int{}
+	for _, d := range diffs {
+		counts[d.kind]++
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d modified\n", counts[added], counts[removed], counts[modified])
+}
+
+// normalizeYAML returns the content of a config file marshaled again, so files with the same
+// content have the same text. Content with incorrect syntax is returned as is.
+func normalizeYAML(b []byte) []byte {
+	m, err := yamlutils.UnmarshalYAMLToMap(b)
+	if err != nil {
+		return b
+	}
+	res, err := yaml.Marshal(m)
+	if err != nil {
+		return b
+	}
+	return res
+}
+
+// printDraftDiffs writes the differences between the files of the draft and the local
+// project: a unified diff of each modified config file, and a line for each other difference.
+func printDraftDiffs(w io.Writer, diffs []fileDiff, draft, local map[string][]byte) {
+	counts := map[string]int{}
+	for _, d := range diffs {
+		counts[d.kind]++
+		if d.kind == modified && path.Ext(d.name) == ".yaml" {
+			printUnified(w, d.name, "draft", "local", normalizeYAML(draft[d.name]), normalizeYAML(local[d.name]))
+			continue
+		}
+		fmt.Fprintf(w, "%v %v\n", d.kind, d.name)
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d modified\n", counts[added], counts[removed], counts[modified])
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareProjects(t *testing.T) {
+	a := map[string][]byte{
+		"settings/settings.yaml":     []byte("projectId: hello-world\ndefaultLocale: en\n"),
+		"custom/scenes/Main.yaml":    []byte("onEnter:\n  staticPromptName: welcome\nintentEvents:\n- intent: order\n  transitionToScene: Order\n- intent: help\n  transitionToScene: Help\n"),
+		"custom/intents/help.yaml":   []byte("trainingPhrases:\n- help\n"),
+		"custom/global/broken.yaml":  []byte("intent: [a\n"),
+		"resources/images/logo.png":  []byte("abc"),
+		"resources/images/other.png": []byte("abc"),
+	}
+	b := map[string][]byte{
+		// Only the order of keys and the quoting changed.
+		"settings/settings.yaml":     []byte("defaultLocale: \"en\"\nprojectId: 'hello-world'\n"),
+		"custom/scenes/Main.yaml":    []byte("intentEvents:\n  - transitionToScene: Order\n    intent: order\n  - intent: help\n    transitionToScene: Main\n  - intent: cancel\n    transitionToScene: actions.scene.END_CONVERSATION\nonEnter: {}\n"),
+		"custom/intents/order.yaml":  []byte("trainingPhrases:\n- order\n"),
+		"custom/global/broken.yaml":  []byte("intent: [b\n"),
+		"resources/images/logo.png":  []byte("xyz"),
+		"resources/images/other.png": []byte("abc"),
+	}
+	want := []fileDiff{
+		{kind: modified, name: "custom/global/broken.yaml"},
+		{kind: removed, name: "custom/intents/help.yaml"},
+		{kind: added, name: "custom/intents/order.yaml"},
+		{kind: modified, name: "custom/scenes/Main.yaml", changes: []change{
+			{path: "intentEvents[1].transitionToScene", old: "Help", new: "Main", hasOld: true, hasNew: true},
+			{path: "intentEvents[2]", new: map[string]interface{}{"intent": "cancel", "transitionToScene": "actions.scene.END_CONVERSATION"}, hasNew: true},
+			{path: "onEnter.staticPromptName", old: "welcome", hasOld: true},
+		}},
+		{kind: modified, name: "resources/images/logo.png"},
+	}
+	got := compareProjects(a, b)
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(fileDiff{}, change{})); diff != "" {
+		t.Errorf("compareProjects returned diff (-want, +got)\n%s", diff)
+	}
+	if got := compareProjects(a, a); len(got) != 0 {
+		t.Errorf("compareProjects returned %v for the same projects, want none", got)
+	}
+}
+
+func TestPrintDiffs(t *testing.T) {
+	diffs := []fileDiff{
+		{kind: added, name: "custom/intents/order.yaml"},
+		{kind: modified, name: "custom/scenes/Main.yaml", changes: []change{
+			{path: "intentEvents[1].transitionToScene", old: "Help", new: "Main", hasOld: true, hasNew: true},
+			{path: "intentEvents[2]", new: map[string]interface{}{"intent": "cancel"}, hasNew: true},
+			{path: "onEnter.staticPromptName", old: "welcome", hasOld: true},
+		}},
+	}
+	want := `A custom/intents/order.yaml
+M custom/scenes/Main.yaml
+    ~ intentEvents[1].transitionToScene: "Help" -> "Main"
+    + intentEvents[2]: {"intent":"cancel"}
+    - onEnter.staticPromptName: "welcome"
+1 added, 0 removed, 1 modified
+`
+	var b bytes.Buffer
+	printDiffs(&b, diffs)
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printDiffs wrote diff (-want, +got)\n%s", diff)
+	}
+}
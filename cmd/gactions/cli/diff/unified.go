@@ -0,0 +1,168 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// contextLines is the number of unchanged lines shown around changes.
+	contextLines = 3
+	// maxDiffCells bounds the lines of a and b multiplied, above which files are too large to
+	// be diffed line by line.
+	maxDiffCells = 1 << 24
+)
+
+// edit is a line of an edit script: an unchanged (' '), removed ('-') or added ('+') line.
+type edit struct {
+	op   byte
+	line string
+}
+
+func splitLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// editScript returns the edits which turn a into b, based on their longest common subsequence.
+func editScript(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var res []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			res = append(res, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			res = append(res, edit{'-', a[i]})
+			i++
+		default:
+			res = append(res, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		res = append(res, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		res = append(res, edit{'+', b[j]})
+	}
+	return res
+}
+
+// hunkRange formats the start and length of a hunk like diff -u, where an empty range starts
+// at the line before it.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// printUnified writes a unified diff between the contents a and b of the file name, labeled
+// with the prefixes of the compared projects. It writes nothing if the contents are equal.
+func printUnified(w io.Writer, name, labelA, labelB string, a, b []byte) {
+	la, lb := splitLines(a), splitLines(b)
+	if len(la)*len(lb) > maxDiffCells {
+		fmt.Fprintf(w, "--- %v/%v\n+++ %v/%v\n@@ too large to diff @@\n", labelA, name, labelB, name)
+		return
+	}
+	edits := editScript(la, lb)
+	header := false
+	for start := 0; start < len(edits); {
+		// Finds the next change, and the end of the hunk around it.
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		end := first
+		for gap := 0; end < len(edits) && gap <= 2*contextLines; end++ {
+			if edits[end].op == ' ' {
+				gap++
+			} else {
+				gap = 0
+			}
+		}
+		// Trims trailing context of the hunk to contextLines.
+		for end > first && edits[end-1].op == ' ' {
+			end--
+		}
+		from := first - contextLines
+		if from < start {
+			from = start
+		}
+		to := end + contextLines
+		if to > len(edits) {
+			to = len(edits)
+		}
+		// Line numbers of the hunk in a and b are the lines before it, plus one.
+		lineA, lineB := 1, 1
+		for _, e := range edits[:from] {
+			if e.op != '+' {
+				lineA++
+			}
+			if e.op != '-' {
+				lineB++
+			}
+		}
+		nA, nB := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				nA++
+			}
+			if e.op != '-' {
+				nB++
+			}
+		}
+		if !header {
+			fmt.Fprintf(w, "--- %v/%v\n+++ %v/%v\n", labelA, name, labelB, name)
+			header = true
+		}
+		fmt.Fprintf(w, "@@ -%v +%v @@\n", hunkRange(lineA, nA), hunkRange(lineB, nB))
+		for _, e := range edits[from:to] {
+			fmt.Fprintf(w, "%c%v\n", e.op, e.line)
+		}
+		start = to
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrintUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "one hunk",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want: `--- draft/f.yaml
++++ local/f.yaml
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "two hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			want: `--- draft/f.yaml
++++ local/f.yaml
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`,
+		},
+		{
+			name: "new content",
+			a:    "",
+			b:    "a\n",
+			want: `--- draft/f.yaml
++++ local/f.yaml
+@@ -0,0 +1 @@
++a
+`,
+		},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		printUnified(&b, "f.yaml", "draft", "local", []byte(tc.a), []byte(tc.b))
+		if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+			t.Errorf("printUnified wrote incorrect diff for %v: diff (-want, +got)\n%s", tc.name, diff)
+		}
+	}
+}
+
+func TestPrintDraftDiffs(t *testing.T) {
+	draft := map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: hello\ndefaultLocale: en\n"),
+		"resources/images/a.png": []byte("png"),
+	}
+	local := map[string][]byte{
+		"settings/settings.yaml": []byte("defaultLocale: en\nprojectId: hello-world\n"),
+		"resources/images/a.png": []byte("png2"),
+		"resources/audio/a.mp3":  []byte("mp3"),
+	}
+	want := `A resources/audio/a.mp3
+M resources/images/a.png
+--- draft/settings/settings.yaml
++++ local/settings/settings.yaml
+@@ -1,2 +1,2 @@
+ defaultLocale: en
+-projectId: hello
++projectId: hello-world
+1 added, 0 removed, 2 modified
+`
+	var b bytes.Buffer
+	printDraftDiffs(&b, compareProjects(draft, local), draft, local)
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printDraftDiffs wrote diff (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package docs provides an implementation of "gactions docs" command.
+package docs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+var extensions = map[string]string{
+	"markdown": "md",
+	"html":     "html",
+}
+
+// AddCommand adds the docs sub-command to the passed in root command.
+func AddCommand(root *cobra.Command, proj project.Project) {
+	docs := &cobra.Command{
+		Use:   "docs",
+		Short: "This is the main command for generating documentation of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for generating documentation of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	generate := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a readable overview of the project as Markdown or HTML.",
+		Long: "This command renders the local project into a single Markdown or HTML page for readers who don't read YAML: " +
+			"the invocations, the scenes with their transitions and prompts, the intents with sample phrases, and the static prompts of each locale.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			format, err := cmd.Flags().GetString("doc-format")
+			if err != nil {
+				return err
+			}
+			out, err := cmd.Flags().GetString("out")
+			if err != nil {
+				return err
+			}
+			maxPhrases, err := cmd.Flags().GetInt("max-phrases")
+			if err != nil {
+				return err
+			}
+			return generateDocs(proj, format, out, maxPhrases)
+		},
+	}
+	generate.Flags().String("doc-format", "markdown", "Format of the documentation: markdown or html.")
+	generate.Flags().String("out", "docs", "Directory to write the documentation to. It's created if it doesn't exist.")
+	generate.Flags().Int("max-phrases", 5, "Maximum number of training phrases and prompt variants shown per locale. Set to 0 to show all of them.")
+	docs.AddCommand(generate)
+	root.AddCommand(docs)
+}
+
+func generateDocs(proj project.Project, format, out string, maxPhrases int) error {
+	ext, ok := extensions[format]
+	if !ok {
+		return fmt.Errorf("unsupported --doc-format %q: use markdown or html", format)
+	}
+	if maxPhrases < 0 {
+		return fmt.Errorf("--max-phrases must not be negative, got %v", maxPhrases)
+	}
+	files, err := proj.Files()
+	if err != nil {
+		return err
+	}
+	m, err := buildModel(files, maxPhrases)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	if err := render(&b, m, format); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0750); err != nil {
+		return err
+	}
+	fp := filepath.Join(out, "index."+ext)
+	if err := ioutil.WriteFile(fp, b.Bytes(), 0640); err != nil {
+		return err
+	}
+	log.DoneMsgln(fmt.Sprintf("Documentation of the project was written to %v.", fp))
+	return nil
+}
@@ -0,0 +1,379 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	mainIntent = "actions.intent.MAIN"
+	endScene   = "actions.scene.END_CONVERSATION"
+)
+
+// annotationRegExp matches a parameter annotation in a training phrase; the
+// annotation is replaced by its text when the phrase is shown.
+var annotationRegExp = regexp.MustCompile(`\(\$[A-Za-z0-9_]+\s+'((?:[^'\\]|\\.)*)'(?:\s+auto=(?:true|false))?\s*\)`)
+
+// model is the content of a project, as shown in the generated documentation.
+type model struct {
+	DisplayName   string
+	ProjectID     string
+	DefaultLocale string
+	Locales       []string
+	Invocations   []invocation
+	Intents       []intent
+	Scenes        []scene
+	Prompts       []prompt
+}
+
+// invocation is a way for users to start a conversation with the Action.
+type invocation struct {
+	Intent string
+	// Phrases are samples of what users say to invoke the Action in the default locale.
+	Phrases []string
+	Scene   string
+}
+
+// localized holds texts in a locale.
+type localized struct {
+	Locale string
+	Texts  []string
+	// More is the number of texts that were left out.
+	More int
+}
+
+type intent struct {
+	Name       string
+	Parameters []parameter
+	Phrases    []localized
+}
+
+type parameter struct {
+	Name string
+	Type string
+}
+
+type transition struct {
+	Trigger string
+	Target  string
+}
+
+type scene struct {
+	Name        string
+	Slots       []parameter
+	Transitions []transition
+	Prompts     []localized
+}
+
+type prompt struct {
+	Name     string
+	Variants []localized
+}
+
+// localizedName returns the name of the file of a localized resource in dir, without the
+// extension, and its locale. The locale is defaultLocale for the files outside a locale directory.
+func localizedName(dir, filename, defaultLocale string) (string, string) {
+	rel := strings.TrimPrefix(filename, dir+"/")
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	if d := path.Dir(rel); d != "." {
+		return name, d
+	}
+	return name, defaultLocale
+}
+
+func unmarshal(files map[string][]byte, k string, v interface{}) error {
+	if err := yaml.Unmarshal(files[k], v); err != nil {
+		return fmt.Errorf("%v has incorrect syntax: %v", k, err)
+	}
+	return nil
+}
+
+// sampleTexts returns up to max of texts for locale.
+func sampleTexts(locale string, texts []string, max int) localized {
+	l := localized{Locale: locale, Texts: texts}
+	if max > 0 && len(texts) > max {
+		l.Texts = texts[:max]
+		l.More = len(texts) - max
+	}
+	return l
+}
+
+// sortLocalized sorts texts by locale, with the default locale first.
+func sortLocalized(ls []localized, defaultLocale string) {
+	sort.Slice(ls, func(i, j int) bool {
+		if (ls[i].Locale == defaultLocale) != (ls[j].Locale == defaultLocale) {
+			return ls[i].Locale == defaultLocale
+		}
+		return ls[i].Locale < ls[j].Locale
+	})
+}
+
+func sortedKeys(files map[string][]byte, include func(string) bool) []string {
+	var res []string
+	for k := range files {
+		if include(k) {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// speech returns the speech of all simple prompts within v, in order of appearance.
+func speech(v interface{}) []string {
+	var res []string
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		if s, ok := t["speech"].(string); ok {
+			res = append(res, s)
+		}
+		var keys []string
+		for k := range t {
+			if s, ok := k.(string); ok && s != "speech" {
+				keys = append(keys, s)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			res = append(res, speech(t[k])...)
+		}
+	case []interface{}:
+		for _, e := range t {
+			res = append(res, speech(e)...)
+		}
+	}
+	return res
+}
+
+func targetName(s string) string {
+	if s == endScene {
+		return "End conversation"
+	}
+	return s
+}
+
+// buildModel reads the content of the project from files. At most maxPhrases training phrases
+// and prompt variants are kept per locale, or all of them if maxPhrases is 0.
+func buildModel(files map[string][]byte, maxPhrases int) (*model, error) {
+	defaultLocale, err := studio.DefaultLocale(files)
+	if err != nil {
+		return nil, err
+	}
+	m := &model{DefaultLocale: defaultLocale}
+	locales := map[string]bool{defaultLocale: true}
+	for _, k := range sortedKeys(files, studio.IsSettings) {
+		var s struct {
+			ProjectID         string `yaml:"projectId"`
+			LocalizedSettings struct {
+				DisplayName string `yaml:"displayName"`
+			} `yaml:"localizedSettings"`
+		}
+		if err := unmarshal(files, k, &s); err != nil {
+			return nil, err
+		}
+		if k == path.Join("settings", "settings.yaml") {
+			m.ProjectID = s.ProjectID
+			m.DisplayName = s.LocalizedSettings.DisplayName
+		} else {
+			locales[path.Base(path.Dir(k))] = true
+		}
+	}
+
+	// Intents, with phrases per locale.
+	intents := map[string]*intent{}
+	var intentNames []string
+	for _, k := range sortedKeys(files, studio.IsIntent) {
+		name, locale := localizedName(path.Join("custom", "intents"), k, defaultLocale)
+		locales[locale] = true
+		var in struct {
+			Parameters []struct {
+				Name string `yaml:"name"`
+				Type struct {
+					Name string `yaml:"name"`
+				} `yaml:"type"`
+			} `yaml:"parameters"`
+			TrainingPhrases []string `yaml:"trainingPhrases"`
+		}
+		if err := unmarshal(files, k, &in); err != nil {
+			return nil, err
+		}
+		it, ok := intents[name]
+		if !ok {
+			it = &intent{Name: name}
+			intents[name] = it
+			intentNames = append(intentNames, name)
+		}
+		if locale == defaultLocale {
+			for _, p := range in.Parameters {
+				it.Parameters = append(it.Parameters, parameter{Name: p.Name, Type: p.Type.Name})
+			}
+		}
+		var phrases []string
+		for _, p := range in.TrainingPhrases {
+			phrases = append(phrases, annotationRegExp.ReplaceAllStringFunc(p, func(s string) string {
+				return strings.ReplaceAll(annotationRegExp.FindStringSubmatch(s)[1], `\'`, `'`)
+			}))
+		}
+		if len(phrases) > 0 {
+			it.Phrases = append(it.Phrases, sampleTexts(locale, phrases, maxPhrases))
+		}
+	}
+	sort.Strings(intentNames)
+	for _, name := range intentNames {
+		sortLocalized(intents[name].Phrases, defaultLocale)
+		m.Intents = append(m.Intents, *intents[name])
+	}
+
+	// Global intent handlers.
+	global := map[string]string{}
+	for _, k := range sortedKeys(files, studio.IsGlobal) {
+		name, _ := localizedName(path.Join("custom", "global"), k, defaultLocale)
+		var g struct {
+			TransitionToScene string `yaml:"transitionToScene"`
+		}
+		if err := unmarshal(files, k, &g); err != nil {
+			return nil, err
+		}
+		global[name] = g.TransitionToScene
+	}
+
+	// Invocations are the main invocation and the deep links declared in actions.yaml.
+	for _, k := range sortedKeys(files, studio.IsActions) {
+		var a struct {
+			Custom map[string]interface{} `yaml:"custom"`
+		}
+		if err := unmarshal(files, k, &a); err != nil {
+			return nil, err
+		}
+		for name := range a.Custom {
+			inv := invocation{Intent: name, Scene: targetName(global[name])}
+			if name == mainIntent && m.DisplayName != "" {
+				inv.Phrases = []string{"Talk to " + m.DisplayName}
+			}
+			if it, ok := intents[name]; ok && len(it.Phrases) > 0 && it.Phrases[0].Locale == defaultLocale {
+				inv.Phrases = it.Phrases[0].Texts
+			}
+			m.Invocations = append(m.Invocations, inv)
+		}
+	}
+	sort.Slice(m.Invocations, func(i, j int) bool {
+		// The main invocation comes first.
+		if (m.Invocations[i].Intent == mainIntent) != (m.Invocations[j].Intent == mainIntent) {
+			return m.Invocations[i].Intent == mainIntent
+		}
+		return m.Invocations[i].Intent < m.Invocations[j].Intent
+	})
+
+	// Scenes, with their transitions and inline prompts per locale.
+	scenes := map[string]*scene{}
+	var sceneNames []string
+	for _, k := range sortedKeys(files, studio.IsScene) {
+		name, locale := localizedName(path.Join("custom", "scenes"), k, defaultLocale)
+		locales[locale] = true
+		var doc interface{}
+		if err := unmarshal(files, k, &doc); err != nil {
+			return nil, err
+		}
+		sc, ok := scenes[name]
+		if !ok {
+			sc = &scene{Name: name}
+			scenes[name] = sc
+			sceneNames = append(sceneNames, name)
+		}
+		if s := speech(doc); len(s) > 0 {
+			sc.Prompts = append(sc.Prompts, sampleTexts(locale, s, maxPhrases))
+		}
+		if locale != defaultLocale {
+			continue
+		}
+		var s struct {
+			Slots []struct {
+				Name string `yaml:"name"`
+				Type struct {
+					Name string `yaml:"name"`
+				} `yaml:"type"`
+			} `yaml:"slots"`
+			IntentEvents []struct {
+				Intent            string `yaml:"intent"`
+				TransitionToScene string `yaml:"transitionToScene"`
+			} `yaml:"intentEvents"`
+			ConditionalEvents []struct {
+				Condition         string `yaml:"condition"`
+				TransitionToScene string `yaml:"transitionToScene"`
+			} `yaml:"conditionalEvents"`
+		}
+		if err := unmarshal(files, k, &s); err != nil {
+			return nil, err
+		}
+		for _, v := range s.Slots {
+			sc.Slots = append(sc.Slots, parameter{Name: v.Name, Type: v.Type.Name})
+		}
+		for _, v := range s.IntentEvents {
+			if v.TransitionToScene != "" {
+				sc.Transitions = append(sc.Transitions, transition{Trigger: "Intent " + v.Intent, Target: targetName(v.TransitionToScene)})
+			}
+		}
+		for _, v := range s.ConditionalEvents {
+			if v.TransitionToScene != "" {
+				sc.Transitions = append(sc.Transitions, transition{Trigger: "Condition " + v.Condition, Target: targetName(v.TransitionToScene)})
+			}
+		}
+	}
+	sort.Strings(sceneNames)
+	for _, name := range sceneNames {
+		sortLocalized(scenes[name].Prompts, defaultLocale)
+		m.Scenes = append(m.Scenes, *scenes[name])
+	}
+
+	// Static prompts, with variants per locale.
+	prompts := map[string]*prompt{}
+	var promptNames []string
+	for _, k := range sortedKeys(files, studio.IsPrompt) {
+		name, locale := localizedName(path.Join("custom", "prompts"), k, defaultLocale)
+		locales[locale] = true
+		var doc interface{}
+		if err := unmarshal(files, k, &doc); err != nil {
+			return nil, err
+		}
+		p, ok := prompts[name]
+		if !ok {
+			p = &prompt{Name: name}
+			prompts[name] = p
+			promptNames = append(promptNames, name)
+		}
+		if s := speech(doc); len(s) > 0 {
+			p.Variants = append(p.Variants, sampleTexts(locale, s, maxPhrases))
+		}
+	}
+	sort.Strings(promptNames)
+	for _, name := range promptNames {
+		sortLocalized(prompts[name].Variants, defaultLocale)
+		m.Prompts = append(m.Prompts, *prompts[name])
+	}
+
+	for k := range locales {
+		m.Locales = append(m.Locales, k)
+	}
+	sort.Strings(m.Locales)
+	return m, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testFiles = map[string][]byte{
+	"settings/settings.yaml": []byte(`projectId: pizza-bot
+defaultLocale: en
+localizedSettings:
+  displayName: Pizza Bot
+`),
+	"settings/fr/settings.yaml": []byte(`localizedSettings:
+  displayName: Pizza Bot
+`),
+	"actions/actions.yaml": []byte(`custom:
+  actions.intent.MAIN: {}
+  order_pizza:
+    engagement:
+      title: Order
+`),
+	"custom/global/actions.intent.MAIN.yaml": []byte("transitionToScene: Welcome\n"),
+	"custom/global/order_pizza.yaml":         []byte("transitionToScene: Order\n"),
+	"custom/intents/order_pizza.yaml": []byte(`parameters:
+- name: size
+  type:
+    name: size
+trainingPhrases:
+- order a ($size 'large' auto=true) pizza
+- I want pizza
+- pizza please
+`),
+	"custom/intents/fr/order_pizza.yaml": []byte(`trainingPhrases:
+- je veux une pizza
+`),
+	"custom/scenes/Welcome.yaml": []byte(`onEnter:
+  staticPrompt:
+    candidates:
+    - promptResponse:
+        firstSimple:
+          variants:
+          - speech: Welcome to Pizza Bot!
+intentEvents:
+- intent: order_pizza
+  transitionToScene: Order
+- intent: actions.intent.CANCEL
+  transitionToScene: actions.scene.END_CONVERSATION
+`),
+	"custom/scenes/Order.yaml": []byte(`slots:
+- name: size
+  type:
+    name: size
+conditionalEvents:
+- condition: scene.slots.status == "FINAL"
+  transitionToScene: actions.scene.END_CONVERSATION
+`),
+	"custom/prompts/goodbye.yaml": []byte(`candidates:
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Bye!
+      - speech: See you!
+`),
+	"custom/prompts/fr/goodbye.yaml": []byte(`candidates:
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Au revoir !
+`),
+}
+
+func TestBuildModel(t *testing.T) {
+	want := &model{
+		DisplayName:   "Pizza Bot",
+		ProjectID:     "pizza-bot",
+		DefaultLocale: "en",
+		Locales:       []string{"en", "fr"},
+		Invocations: []invocation{
+			{Intent: "actions.intent.MAIN", Phrases: []string{"Talk to Pizza Bot"}, Scene: "Welcome"},
+			{Intent: "order_pizza", Phrases: []string{"order a large pizza", "I want pizza"}, Scene: "Order"},
+		},
+		Intents: []intent{
+			{
+				Name:       "order_pizza",
+				Parameters: []parameter{{Name: "size", Type: "size"}},
+				Phrases: []localized{
+					{Locale: "en", Texts: []string{"order a large pizza", "I want pizza"}, More: 1},
+					{Locale: "fr", Texts: []string{"je veux une pizza"}},
+				},
+			},
+		},
+		Scenes: []scene{
+			{
+				Name:  "Order",
+				Slots: []parameter{{Name: "size", Type: "size"}},
+				Transitions: []transition{
+					{Trigger: `Condition scene.slots.status == "FINAL"`, Target: "End conversation"},
+				},
+			},
+			{
+				Name: "Welcome",
+				Transitions: []transition{
+					{Trigger: "Intent order_pizza", Target: "Order"},
+					{Trigger: "Intent actions.intent.CANCEL", Target: "End conversation"},
+				},
+				Prompts: []localized{{Locale: "en", Texts: []string{"Welcome to Pizza Bot!"}}},
+			},
+		},
+		Prompts: []prompt{
+			{
+				Name: "goodbye",
+				Variants: []localized{
+					{Locale: "en", Texts: []string{"Bye!", "See you!"}},
+					{Locale: "fr", Texts: []string{"Au revoir !"}},
+				},
+			},
+		},
+	}
+	got, err := buildModel(testFiles, 2)
+	if err != nil {
+		t.Fatalf("buildModel returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("buildModel returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestRender(t *testing.T) {
+	m, err := buildModel(testFiles, 0)
+	if err != nil {
+		t.Fatalf("buildModel returned %v, want %v", err, nil)
+	}
+	m.Prompts[0].Variants[0].Texts = []string{"<b>Bye</b> *now*"}
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			format: "markdown",
+			want: []string{
+				"# Pizza Bot",
+				"Starts the scene [Welcome](#scene-welcome).",
+				"| Intent order\\_pizza | Order |",
+				`* "\<b\>Bye\</b\> \*now\*"`,
+			},
+		},
+		{
+			format: "html",
+			want: []string{
+				"<h1>Pizza Bot</h1>",
+				`<a href="#scene-welcome">Welcome</a>`,
+				"<td>Intent order_pizza</td><td>Order</td>",
+				"&lt;b&gt;Bye&lt;/b&gt; *now*",
+			},
+		},
+	}
+	for _, tc := range tests {
+		var b bytes.Buffer
+		if err := render(&b, m, tc.format); err != nil {
+			t.Fatalf("render returned %v for %v, want %v", err, tc.format, nil)
+		}
+		for _, w := range tc.want {
+			if !strings.Contains(b.String(), w) {
+				t.Errorf("render returned %v output without %q:\n%s", tc.format, w, b.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docs
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// mdEscaper escapes the characters of a text that Markdown would interpret.
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "\n", " ",
+)
+
+// anchor returns the ID of the heading of a scene, intent or prompt named s.
+func anchor(kind, s string) string {
+	return kind + "-" + strings.NewReplacer(".", "-", " ", "-", "/", "-").Replace(strings.ToLower(s))
+}
+
+var funcs = map[string]interface{}{
+	"md":     mdEscaper.Replace,
+	"anchor": anchor,
+}
+
+const markdownTemplate = `# {{if .DisplayName}}{{md .DisplayName}}{{else}}{{md .ProjectID}}{{end}}
+
+| | |
+|---|---|
+| Project | {{md .ProjectID}} |
+| Default locale | {{.DefaultLocale}} |
+| Locales | {{range $i, $l := .Locales}}{{if $i}}, {{end}}{{$l}}{{end}} |
+
+## Contents
+
+* [Invocations](#invocations)
+* [Scenes](#scenes)
+* [Intents](#intents)
+* [Prompts](#prompts)
+
+## Invocations
+{{if not .Invocations}}
+No invocations are declared.
+{{end}}{{range .Invocations}}
+### {{md .Intent}}
+
+Starts the scene {{if .Scene}}[{{md .Scene}}](#{{anchor "scene" .Scene}}){{else}}(none){{end}}.
+{{if .Phrases}}
+Users can say:
+{{range .Phrases}}
+* "{{md .}}"{{end}}
+{{end}}{{end}}
+## Scenes
+{{if not .Scenes}}
+No scenes are declared.
+{{end}}{{range .Scenes}}
+### <a id="{{anchor "scene" .Name}}"></a>{{md .Name}}
+{{if .Slots}}
+Slots: {{range $i, $s := .Slots}}{{if $i}}, {{end}}{{md $s.Name}} ({{md $s.Type}}){{end}}
+{{end}}{{if .Transitions}}
+| Trigger | Goes to |
+|---|---|
+{{range .Transitions}}| {{md .Trigger}} | {{md .Target}} |
+{{end}}{{end}}{{range .Prompts}}
+Prompts ({{.Locale}}):
+{{range .Texts}}
+* "{{md .}}"{{end}}{{if .More}}
+* ... and {{.More}} more{{end}}
+{{end}}{{end}}
+## Intents
+{{if not .Intents}}
+No intents are declared.
+{{end}}{{range .Intents}}
+### <a id="{{anchor "intent" .Name}}"></a>{{md .Name}}
+{{if .Parameters}}
+Parameters: {{range $i, $p := .Parameters}}{{if $i}}, {{end}}{{md $p.Name}} ({{md $p.Type}}){{end}}
+{{end}}{{range .Phrases}}
+Sample phrases ({{.Locale}}):
+{{range .Texts}}
+* "{{md .}}"{{end}}{{if .More}}
+* ... and {{.More}} more{{end}}
+{{end}}{{end}}
+## Prompts
+{{if not .Prompts}}
+No static prompts are declared.
+{{end}}{{range .Prompts}}
+### <a id="{{anchor "prompt" .Name}}"></a>{{md .Name}}
+{{range .Variants}}
+Variants ({{.Locale}}):
+{{range .Texts}}
+* "{{md .}}"{{end}}{{if .More}}
+* ... and {{.More}} more{{end}}
+{{end}}{{end}}`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .DisplayName}}{{.DisplayName}}{{else}}{{.ProjectID}}{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; color: #202124; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #dadce0; padding: 0.3em 0.6em; text-align: left; }
+h3 { margin-top: 2em; }
+.locale { color: #5f6368; }
+</style>
+</head>
+<body>
+<h1>{{if .DisplayName}}{{.DisplayName}}{{else}}{{.ProjectID}}{{end}}</h1>
+<table>
+<tr><th>Project</th><td>{{.ProjectID}}</td></tr>
+<tr><th>Default locale</th><td>{{.DefaultLocale}}</td></tr>
+<tr><th>Locales</th><td>{{range $i, $l := .Locales}}{{if $i}}, {{end}}{{$l}}{{end}}</td></tr>
+</table>
+<ul>
+<li><a href="#invocations">Invocations</a></li>
+<li><a href="#scenes">Scenes</a></li>
+<li><a href="#intents">Intents</a></li>
+<li><a href="#prompts">Prompts</a></li>
+</ul>
+{{define "texts"}}{{range .}}<p class="locale">{{.Locale}}</p>
+<ul>{{range .Texts}}
+<li>&ldquo;{{.}}&rdquo;</li>{{end}}{{if .More}}
+<li>&hellip; and {{.More}} more</li>{{end}}
+</ul>
+{{end}}{{end}}
+<h2 id="invocations">Invocations</h2>
+{{if not .Invocations}}<p>No invocations are declared.</p>
+{{end}}{{range .Invocations}}<h3>{{.Intent}}</h3>
+<p>Starts the scene {{if .Scene}}<a href="#{{anchor "scene" .Scene}}">{{.Scene}}</a>{{else}}(none){{end}}.</p>
+{{if .Phrases}}<p>Users can say:</p>
+<ul>{{range .Phrases}}
+<li>&ldquo;{{.}}&rdquo;</li>{{end}}
+</ul>
+{{end}}{{end}}
+<h2 id="scenes">Scenes</h2>
+{{if not .Scenes}}<p>No scenes are declared.</p>
+{{end}}{{range .Scenes}}<h3 id="{{anchor "scene" .Name}}">{{.Name}}</h3>
+{{if .Slots}}<p>Slots: {{range $i, $s := .Slots}}{{if $i}}, {{end}}{{$s.Name}} ({{$s.Type}}){{end}}</p>
+{{end}}{{if .Transitions}}<table>
+<tr><th>Trigger</th><th>Goes to</th></tr>{{range .Transitions}}
+<tr><td>{{.Trigger}}</td><td>{{.Target}}</td></tr>{{end}}
+</table>
+{{end}}{{if .Prompts}}<p>Prompts:</p>
+{{template "texts" .Prompts}}{{end}}{{end}}
+<h2 id="intents">Intents</h2>
+{{if not .Intents}}<p>No intents are declared.</p>
+{{end}}{{range .Intents}}<h3 id="{{anchor "intent" .Name}}">{{.Name}}</h3>
+{{if .Parameters}}<p>Parameters: {{range $i, $p := .Parameters}}{{if $i}}, {{end}}{{$p.Name}} ({{$p.Type}}){{end}}</p>
+{{end}}{{if .Phrases}}<p>Sample phrases:</p>
+{{template "texts" .Phrases}}{{end}}{{end}}
+<h2 id="prompts">Prompts</h2>
+{{if not .Prompts}}<p>No static prompts are declared.</p>
+{{end}}{{range .Prompts}}<h3 id="{{anchor "prompt" .Name}}">{{.Name}}</h3>
+{{template "texts" .Variants}}{{end}}
+</body>
+</html>
+`
+
+var (
+	mdTmpl   = template.Must(template.New("markdown").Funcs(funcs).Parse(markdownTemplate))
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Funcs(funcs).Parse(htmlTemplate))
+)
+
+// render writes the documentation of m to w in format, which is "markdown" or "html".
+func render(w io.Writer, m *model, format string) error {
+	if format == "html" {
+		return htmlTmpl.Execute(w, m)
+	}
+	return mdTmpl.Execute(w, m)
+}
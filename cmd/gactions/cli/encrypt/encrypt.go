@@ -18,42 +18,136 @@ package encrypt
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
 	"syscall"
 
 	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/golang/crypto/ssh/terminal"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// stdin is read for the secret when it isn't a terminal. It's replaced in tests.
+	stdin io.Reader = os.Stdin
+	// stdinIsTerminal returns true if the secret is typed by the user. It's replaced in tests.
+	stdinIsTerminal = func() bool {
+		return terminal.IsTerminal(int(syscall.Stdin))
+	}
+)
+
 func askForSecret() (string, error) {
 	log.Outf("Write your secret: ")
 	secret, err := terminal.ReadPassword(int(syscall.Stdin))
 	if err != nil {
 		return "", err
 	}
+	log.Outln()
 	return string(secret), nil
 }
 
+// readSecret returns the secret in the file of the --secret-file flag of cmd, or piped into
+// stdin, so CI jobs can encrypt secrets. The user is asked for the secret if stdin is a terminal.
+func readSecret(cmd *cobra.Command) (string, error) {
+	fn, err := cmd.Flags().GetString("secret-file")
+	if err != nil {
+		return "", err
+	}
+	var b []byte
+	switch {
+	case fn != "":
+		if b, err = ioutil.ReadFile(fn); err != nil {
+			return "", fmt.Errorf("can not read the secret: %v", err)
+		}
+	case !stdinIsTerminal():
+		if b, err = ioutil.ReadAll(stdin); err != nil {
+			return "", fmt.Errorf("can not read the secret from stdin: %v", err)
+		}
+	default:
+		return askForSecret()
+	}
+	// Files and the output of commands like echo end with a newline, which isn't part of the secret.
+	secret := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+	if secret == "" {
+		return "", errors.New("the secret is empty")
+	}
+	return secret, nil
+}
+
 // AddCommand adds encrypt sub-command to the passed in root command.
 func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
 	encrypt := &cobra.Command{
 		Use:   "encrypt",
 		Short: "Encrypt client secret.",
-		Long:  "This commands encrypts the client secret key used in Account linking.",
+		Long:  "This commands encrypts the client secret key used in Account linking, or with --value, a secret to put in a config file.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := cmd.Flags().GetBool("value")
+			if err != nil {
+				return err
+			}
+			if rotate, _ := cmd.Flags().GetBool("rotate"); value && rotate {
+				return errors.New("--rotate can not be used with --value")
+			}
+			if value {
+				s, err := readSecret(cmd)
+				if err != nil {
+					return err
+				}
+				v, err := sdk.EncryptValue(ctx, proj, s)
+				if err != nil {
+					return err
+				}
+				log.Outln(v)
+				return nil
+			}
 			if proj.ProjectRoot() == "" {
 				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
 				return errors.New("can not determine project root")
 			}
-			s, err := askForSecret()
+			env, err := cmd.Flags().GetString("env")
+			if err != nil {
+				return err
+			}
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			if err := (&studioProj).SetEnv(env); err != nil {
+				return err
+			}
+			rotate, err := cmd.Flags().GetBool("rotate")
+			if err != nil {
+				return err
+			}
+			if rotate {
+				from, to, err := sdk.RotateSecretJSON(ctx, studioProj, env)
+				if err != nil {
+					return err
+				}
+				if from == to {
+					log.DoneMsgln(fmt.Sprintf("The client secret is already encrypted with the latest key version %v. It was encrypted again with the same key.", to))
+					return nil
+				}
+				log.DoneMsgln(fmt.Sprintf("The client secret was encrypted with key version %v, and is now encrypted with key version %v.", from, to))
+				return nil
+			}
+			s, err := readSecret(cmd)
 			if err != nil {
 				return err
 			}
-			return sdk.EncryptSecretJSON(ctx, proj, s)
+			return sdk.EncryptSecretJSON(ctx, studioProj, s, env)
 		},
 		Args: cobra.NoArgs,
 	}
+	encrypt.Flags().String("env", "", "Environment of the client secret, such as staging. The encrypted secret is written to settings/accountLinkingSecret.<env>.yaml, which is pushed in place of settings/accountLinkingSecret.yaml when the environment is selected with the --env flag of push or deploy.")
+	encrypt.Flags().Bool("value", false, fmt.Sprintf("Print the secret as an encrypted value for config files (e.g. \"apiKey: %v ...\") instead of writing it to the account linking secret file. Encrypted values are decrypted when the files are pushed or deployed.", studio.EncryptedTag))
+	encrypt.Flags().String("secret-file", "", "Read the secret from this file instead of asking for it. The secret is also read from stdin when it isn't a terminal, e.g. \"echo $SECRET | gactions encrypt\". A trailing newline isn't part of the secret.")
+	encrypt.Flags().Bool("rotate", false, "Re-encrypt the existing client secret with the latest encryption key, without asking for the secret. The secret file is rewritten, and the key versions before and after are printed.")
 	root.AddCommand(encrypt)
 }
@@ -0,0 +1,72 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encrypt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestReadSecret(t *testing.T) {
+	ogStdin, ogIsTerminal := stdin, stdinIsTerminal
+	defer func() {
+		stdin, stdinIsTerminal = ogStdin, ogIsTerminal
+	}()
+	stdinIsTerminal = func() bool { return false }
+
+	dir, err := ioutil.TempDir("", "gactions-encrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "secret.txt")
+	if err := ioutil.WriteFile(fn, []byte("from-file\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		file    string
+		stdin   string
+		want    string
+		wantErr bool
+	}{
+		{name: "stdin", stdin: "from-stdin\n", want: "from-stdin"},
+		{name: "stdin without newline", stdin: "from-stdin", want: "from-stdin"},
+		{name: "file", file: fn, stdin: "from-stdin\n", want: "from-file"},
+		{name: "empty", stdin: "\n", wantErr: true},
+		{name: "missing file", file: filepath.Join(dir, "missing.txt"), wantErr: true},
+	}
+	for _, tc := range tests {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("secret-file", "", "")
+		if err := cmd.Flags().Set("secret-file", tc.file); err != nil {
+			t.Fatal(err)
+		}
+		stdin = strings.NewReader(tc.stdin)
+		got, err := readSecret(cmd)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("readSecret of %v returned error %v, want error: %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("readSecret of %v returned %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
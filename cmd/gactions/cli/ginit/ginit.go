@@ -19,7 +19,9 @@ package ginit
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"text/tabwriter"
 
 	"github.com/actions-on-google/gactions/api/sdk"
@@ -42,14 +44,37 @@ func isValidProject(projectTitle string) bool {
 	return false
 }
 
-func printSamples(samples []project.SampleProject) {
-	w := new(tabwriter.Writer)
-	w.Init(os.Stdout, 0, 4, 0, '\t', 0)
-	for i, v := range samples {
-		fmt.Fprintf(w, "%v) %v\t\n", i+1, v.Name)
+// sampleByName returns the sample named projectTitle.
+func sampleByName(projectTitle string) project.SampleProject {
+	var s project.SampleProject
+	for _, v := range samples {
+		if v.Name == projectTitle {
+			s = v
+		}
+	}
+	return s
+}
+
+// sampleOutput is a sample project in the json and yaml output formats.
+type sampleOutput struct {
+	Name      string `json:"name" yaml:"name"`
+	HostedURL string `json:"hostedUrl" yaml:"hostedUrl"`
+}
+
+func printSamples(out io.Writer, samples []project.SampleProject) error {
+	res := []sampleOutput{}
+	for _, v := range samples {
+		res = append(res, sampleOutput{Name: v.Name, HostedURL: v.HostedURL})
 	}
-	fmt.Fprintln(w)
-	w.Flush()
+	return log.Render(out, res, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 4, 0, '\t', 0)
+		for i, v := range res {
+			fmt.Fprintf(w, "%v) %v\t\n", i+1, v.Name)
+		}
+		fmt.Fprintln(w)
+		return w.Flush()
+	})
 }
 
 var availableProjects = func(ctx context.Context, project project.Project) ([]project.SampleProject, error) {
@@ -61,47 +86,149 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 	init := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a directory for a new project.",
-		Long:  "This command places sample Actions SDK project files into the current directory. You can choose from a list of sample projects. Current directory must be empty.",
+		Long:  "This command places sample Actions SDK project files into the current directory. You can choose from a list of sample projects, or scaffold the project from any Git archive or local template directory with --from-git. Current directory must be empty. With --format json or yaml and no sample, the command lists the samples. With --project-id, --locale and --display-name, the command writes them to settings/settings.yaml, keeping its comments. With --interactive, the command asks for the sample, the directory, the project ID, the default locale and the display name, and writes them to settings/settings.yaml.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doInit(cmd, args, project)
+			src, _ := cmd.Flags().GetString("from-git")
+			if src != "" {
+				s, err := templateSample(src)
+				if err != nil {
+					return err
+				}
+				return doInit(cmd, s, project)
+			}
+			if len(args) > 0 {
+				return doInit(cmd, sampleByName(args[0]), project)
+			}
+			if interactive, _ := cmd.Flags().GetBool("interactive"); interactive {
+				s, err := askSample(samples)
+				if err != nil {
+					return err
+				}
+				return doInit(cmd, s, project)
+			}
+			return printSamples(cmd.OutOrStdout(), samples)
 		},
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 1 {
 				return fmt.Errorf("unexpected arguments: %v", args)
 			}
+			// Templates aren't in the list of samples, so it isn't needed.
+			if src, _ := cmd.Flags().GetString("from-git"); src != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("can't pass both a sample and --from-git, got %v", args[0])
+				}
+				return nil
+			}
 			l, err := availableProjects(ctx, project)
 			if err != nil {
 				return err
 			}
 			samples = l
+			// Scripts list the samples with a machine-readable format and no sample, and
+			// --interactive asks for the sample.
+			interactive, _ := cmd.Flags().GetBool("interactive")
+			if len(args) < 1 && (log.Format != log.TextFormat || interactive) {
+				return nil
+			}
 			if len(args) < 1 || !isValidProject(args[0]) {
-				log.Outf("Invalid sample specified: %v. Please select one of the following:\n\n", args)
-				printSamples(samples)
+				if log.Format == log.TextFormat {
+					log.Outf("Invalid sample specified: %v. Please select one of the following:\n\n", args)
+				}
+				if err := printSamples(cmd.OutOrStdout(), samples); err != nil {
+					return err
+				}
 				return fmt.Errorf("invalid sample specified: %v", args)
 			}
 			return nil
 		},
 	}
 	init.Flags().String("dest", ".", `Specify a directory for placing the project files (the default directory is ".")`)
+	init.Flags().Bool("git", false, "Place the project files in a Git repository. The repository of the sample is cloned with its history, unless --git-history=false is set.")
+	init.Flags().Bool("git-history", true, "With --git, clone the repository of the sample with its history, and name its remote \"upstream\". If false, start a new repository with a .gitignore and an initial commit of the sample files.")
+	init.Flags().String("from-git", "", "Scaffold the project from a template instead of a sample: a URL of a GitHub repository, a URL of a zip archive of a Git repository (e.g. https://github.com/org/repo/archive/main.zip), or a local directory.")
+	init.Flags().String("project-id", "", fmt.Sprintf("Set the project ID in settings/settings.yaml, and replace %q in the other project files with it.", projectIDPlaceholder))
+	init.Flags().String("locale", "", "Set the default locale of the project in settings/settings.yaml, e.g. en-US.")
+	init.Flags().String("display-name", "", "Set the display name of the default locale in settings/settings.yaml.")
+	init.Flags().Bool("interactive", false, "Ask for the sample, the directory, the project ID, the default locale and the display name of the project, using the values of the flags as defaults.")
 	root.AddCommand(init)
 }
 
-func doInit(cmd *cobra.Command, args []string, proj project.Project) error {
+func doInit(cmd *cobra.Command, s project.SampleProject, proj project.Project) error {
 	destination, _ := cmd.Flags().GetString("dest")
+	projectID, _ := cmd.Flags().GetString("project-id")
+	var settings projectSettings
+	settings.DefaultLocale, _ = cmd.Flags().GetString("locale")
+	settings.LocalizedSettings.DisplayName, _ = cmd.Flags().GetString("display-name")
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	if interactive {
+		var err error
+		if destination, err = ask("Directory for the project files", destination); err != nil {
+			return err
+		}
+		if projectID, err = ask("Project ID (leave empty to set it later)", projectID); err != nil {
+			return err
+		}
+	}
 	if alreadySetup := proj.AlreadySetup(destination); alreadySetup {
 		log.Outf("%s is not empty. Make sure to create an empty directory and run \"gactions init\" from there.", destination)
 		return fmt.Errorf("%s is not empty", destination)
 	}
-	log.Outf("Writing sample files for %v to %s\n", args[0], destination)
-	var s project.SampleProject
-	for _, v := range samples {
-		if v.Name == args[0] {
-			s = v
+	log.Outf("Writing sample files for %v to %s\n", s.Name, destination)
+	useGit, _ := cmd.Flags().GetBool("git")
+	history, _ := cmd.Flags().GetBool("git-history")
+	if useGit {
+		if err := findGit(); err != nil {
+			return err
 		}
 	}
-	if err := proj.Download(s, destination); err != nil {
-		return err
+	configure := func() error {
+		settings.ProjectID = projectID
+		// The project ID is also written to the settings, so it's fine if the files don't
+		// have the placeholder.
+		if projectID != "" {
+			if _, err := replacePlaceholder(destination, projectID); err != nil {
+				return err
+			}
+		}
+		if interactive {
+			return askSettings(destination, settings)
+		}
+		return setSettings(destination, settings)
+	}
+	if useGit && history {
+		if err := cloneSample(s, destination); err != nil {
+			return err
+		}
+		if err := configure(); err != nil {
+			return err
+		}
+	} else {
+		if err := proj.Download(s, destination); err != nil {
+			return err
+		}
+		// Configure the project before the initial commit, so it's part of it.
+		if err := configure(); err != nil {
+			return err
+		}
+		if useGit {
+			if err := initRepo(s, destination); err != nil {
+				return err
+			}
+		}
 	}
 	log.DoneMsgln("Please checkout the following documentation - https://developers.google.com/assistant/conversational/build on the next steps on how to get started.")
 	return nil
 }
+
+// setSettings writes the values of s which are set by flags to the settings of the project in
+// dest.
+func setSettings(dest string, s projectSettings) error {
+	if s == (projectSettings{}) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(dest, settingsPath)); os.IsNotExist(err) {
+		log.Warnf("The sample has no %v. Set the project ID, default locale and display name in the settings of the project.\n", settingsPath)
+		return nil
+	}
+	return writeSettings(dest, s)
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
 	"github.com/spf13/cobra"
 )
@@ -43,6 +44,10 @@ func (p MockStudio) Files() (map[string][]byte, error) {
 	return map[string][]byte{}, nil
 }
 
+func (p MockStudio) FileRefs() (map[string]project.File, error) {
+	return map[string]project.File{}, nil
+}
+
 func (p MockStudio) ClientSecretJSON() ([]byte, error) {
 	return []byte{}, nil
 }
@@ -120,3 +125,32 @@ func TestInitWithValidArgs(t *testing.T) {
 		}
 	}
 }
+
+func TestInitListsSamplesWithFormat(t *testing.T) {
+	og := availableProjects
+	availableProjects = func(ctx context.Context, p project.Project) ([]project.SampleProject, error) {
+		return []project.SampleProject{
+			project.SampleProject{"question", "https://google.com"},
+		}, nil
+	}
+	ogFormat := log.Format
+	defer func() {
+		availableProjects = og
+		log.Format = ogFormat
+	}()
+	log.Format = log.JSONFormat
+	got, err := execute("init")
+	if err != nil {
+		t.Errorf("init returned %v with json format and no sample, want %v", err, nil)
+	}
+	want := `[
+  {
+    "name": "question",
+    "hostedUrl": "https://google.com"
+  }
+]
+`
+	if got != want {
+		t.Errorf("init printed %q with json format and no sample, want %q", got, want)
+	}
+}
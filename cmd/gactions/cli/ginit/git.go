@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ginit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+)
+
+// gitignore is written to the repositories of new projects, unless the sample has one.
+const gitignore = `# Local state of gactions, e.g. snapshots and history of pushes.
+` + studio.StateDir + `/
+node_modules/
+`
+
+// githubArchiveRegExp matches a URL of a zip archive of a GitHub repository, which is how
+// samples are hosted, e.g. https://github.com/actions-on-google/repo/archive/master.zip.
+var githubArchiveRegExp = regexp.MustCompile(`^https://github\.com/([\w.-]+/[\w.-]+)/archive/(?:refs/heads/)?([\w./-]+)\.zip$`)
+
+// gitRepo returns the URL of the Git repository and the branch of a sample.
+func gitRepo(sample project.SampleProject) (string, string, bool) {
+	m := githubArchiveRegExp.FindStringSubmatch(sample.HostedURL)
+	if m == nil {
+		return "", "", false
+	}
+	return fmt.Sprintf("https://github.com/%v.git", m[1]), m[2], true
+}
+
+// findGit returns an error if git isn't installed.
+var findGit = func() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("can't find git in PATH, which is required by --git: %v", err)
+	}
+	return nil
+}
+
+// runGit runs git with args in dir.
+var runGit = func(dir string, args ...string) error {
+	log.Infof("Running git %v in %v\n", strings.Join(args, " "), dir)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %v failed: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// cloneSample clones the repository of sample into dest, keeping its history. The remote of
+// the sample is named "upstream", so "origin" can be set to the repository of the project.
+func cloneSample(sample project.SampleProject, dest string) error {
+	url, branch, ok := gitRepo(sample)
+	if !ok {
+		return fmt.Errorf("sample %v is not hosted in a Git repository. Pass --git-history=false to start a new repository instead", sample.Name)
+	}
+	if err := os.MkdirAll(dest, 0750); err != nil {
+		return err
+	}
+	log.Outf("Cloning %v to %s\n", url, dest)
+	args := []string{"clone", "--origin", "upstream"}
+	// Archives of HEAD are of the default branch, which clone checks out without --branch.
+	if branch != "HEAD" {
+		args = append(args, "--branch", branch)
+	}
+	return runGit(dest, append(args, url, ".")...)
+}
+
+// initRepo starts a new repository in dest, which holds the files of sample, and commits them.
+func initRepo(sample project.SampleProject, dest string) error {
+	if err := writeGitignore(dest); err != nil {
+		return err
+	}
+	if err := runGit(dest, "init"); err != nil {
+		return err
+	}
+	if err := runGit(dest, "add", "--all"); err != nil {
+		return err
+	}
+	return runGit(dest, "commit", "--quiet", "-m", fmt.Sprintf("Initial commit from the %v sample", sample.Name))
+}
+
+// writeGitignore writes .gitignore to dir, unless it already has one.
+func writeGitignore(dir string) error {
+	fp := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(fp); err == nil {
+		log.Infof("Keeping %v of the sample.\n", fp)
+		return nil
+	}
+	return ioutil.WriteFile(fp, []byte(gitignore), 0640)
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ginit
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGitRepo(t *testing.T) {
+	tests := []struct {
+		url    string
+		repo   string
+		branch string
+		ok     bool
+	}{
+		{
+			url:    "https://github.com/actions-on-google/actions-builder-facts-about-google-nodejs/archive/master.zip",
+			repo:   "https://github.com/actions-on-google/actions-builder-facts-about-google-nodejs.git",
+			branch: "master",
+			ok:     true,
+		},
+		{
+			url:    "https://github.com/actions-on-google/hello-world/archive/refs/heads/main.zip",
+			repo:   "https://github.com/actions-on-google/hello-world.git",
+			branch: "main",
+			ok:     true,
+		},
+		{url: "https://example.com/sample.zip"},
+	}
+	for _, tc := range tests {
+		repo, branch, ok := gitRepo(project.SampleProject{Name: "sample", HostedURL: tc.url})
+		if repo != tc.repo || branch != tc.branch || ok != tc.ok {
+			t.Errorf("gitRepo(%q) returned (%q, %q, %v), want (%q, %q, %v)", tc.url, repo, branch, ok, tc.repo, tc.branch, tc.ok)
+		}
+	}
+}
+
+func TestInitWithGit(t *testing.T) {
+	ogProjects, ogFind, ogRun := availableProjects, findGit, runGit
+	t.Cleanup(func() {
+		availableProjects, findGit, runGit = ogProjects, ogFind, ogRun
+	})
+	availableProjects = func(ctx context.Context, p project.Project) ([]project.SampleProject, error) {
+		return []project.SampleProject{
+			{Name: "question", HostedURL: "https://github.com/actions-on-google/question/archive/master.zip"},
+		}, nil
+	}
+	findGit = func() error { return nil }
+	tests := []struct {
+		flags []string
+		want  []string
+	}{
+		{
+			flags: []string{"--git"},
+			want:  []string{"clone --origin upstream --branch master https://github.com/actions-on-google/question.git ."},
+		},
+		{
+			flags: []string{"--git", "--git-history=false"},
+			want:  []string{"init", "add --all", "commit --quiet -m Initial commit from the question sample"},
+		},
+		{
+			flags: nil,
+			want:  nil,
+		},
+	}
+	for _, tc := range tests {
+		dest, err := ioutil.TempDir("", "gactions-init")
+		if err != nil {
+			t.Fatalf("ioutil.TempDir returned %v", err)
+		}
+		defer os.RemoveAll(dest)
+		var got []string
+		runGit = func(dir string, args ...string) error {
+			if dir != dest {
+				t.Errorf("git %v was run in %v, want %v", args, dir, dest)
+			}
+			got = append(got, strings.Join(args, " "))
+			return nil
+		}
+		args := append([]string{"init", "question", "--dest", dest}, tc.flags...)
+		if _, err := execute(args...); err != nil {
+			t.Errorf("init %v returned %v, want %v", tc.flags, err, nil)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("init %v ran incorrect git commands: diff (-want, +got)\n%s", tc.flags, diff)
+		}
+		_, err = os.Stat(filepath.Join(dest, ".gitignore"))
+		if wantIgnore := len(tc.flags) == 2; (err == nil) != wantIgnore {
+			t.Errorf("init %v wrote .gitignore: %v, want %v", tc.flags, err == nil, wantIgnore)
+		}
+	}
+}
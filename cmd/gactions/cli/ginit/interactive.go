@@ -0,0 +1,171 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ginit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/yamlutils"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"gopkg.in/yaml.v2"
+)
+
+// input is where the answers of --interactive are read from. It's replaced in tests.
+var input = bufio.NewReader(os.Stdin)
+
+// ask prints question and returns the line the user answers, or def if the answer is empty.
+func ask(question, def string) (string, error) {
+	if def != "" {
+		log.Outf("%v [%v]: ", question, def)
+	} else {
+		log.Outf("%v: ", question)
+	}
+	ans, err := input.ReadString('\n')
+	if err != nil && (err != io.EOF || ans == "") {
+		return "", fmt.Errorf("can't read the answer to %q: %v", question, err)
+	}
+	if ans = strings.TrimSpace(ans); ans != "" {
+		return ans, nil
+	}
+	return def, nil
+}
+
+// askSample asks the user to select one of samples by its number or name.
+func askSample(samples []project.SampleProject) (project.SampleProject, error) {
+	log.Outln("Samples:")
+	for i, v := range samples {
+		log.Outf("%v) %v\n", i+1, v.Name)
+	}
+	for {
+		ans, err := ask(fmt.Sprintf("Select a sample (1-%v)", len(samples)), "1")
+		if err != nil {
+			return project.SampleProject{}, err
+		}
+		if n, err := strconv.Atoi(ans); err == nil && n >= 1 && n <= len(samples) {
+			return samples[n-1], nil
+		}
+		for _, v := range samples {
+			if v.Name == ans {
+				return v, nil
+			}
+		}
+		log.Outf("%q is not one of the samples.\n", ans)
+	}
+}
+
+// settingsPath is the path of the settings file of the default locale, relative to the
+// project root.
+var settingsPath = filepath.Join("settings", "settings.yaml")
+
+// projectSettings are the values of the settings file which --interactive asks for.
+type projectSettings struct {
+	ProjectID         string `yaml:"projectId"`
+	DefaultLocale     string `yaml:"defaultLocale"`
+	LocalizedSettings struct {
+		DisplayName string `yaml:"displayName"`
+	} `yaml:"localizedSettings"`
+}
+
+// readSettings reads the settings of the project in dir.
+func readSettings(dir string) (projectSettings, error) {
+	var s projectSettings
+	b, err := ioutil.ReadFile(filepath.Join(dir, settingsPath))
+	if err != nil {
+		return s, err
+	}
+	err = yaml.Unmarshal(b, &s)
+	return s, err
+}
+
+// writeSettings sets the project ID, default locale and display name of the default locale
+// in the settings of the project in dir, keeping the comments of the file. Empty values are
+// left as they are in the file.
+func writeSettings(dir string, s projectSettings) error {
+	fp := filepath.Join(dir, settingsPath)
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return err
+	}
+	if s.DefaultLocale != "" {
+		warnLocaleClash(dir, b, s.DefaultLocale)
+	}
+	for _, kv := range []struct {
+		path  []string
+		value string
+	}{
+		{[]string{"projectId"}, s.ProjectID},
+		{[]string{"defaultLocale"}, s.DefaultLocale},
+		{[]string{"localizedSettings", "displayName"}, s.LocalizedSettings.DisplayName},
+	} {
+		if kv.value == "" {
+			continue
+		}
+		var ok bool
+		if b, ok, err = yamlutils.SetScalar(b, kv.path, kv.value); err != nil {
+			return err
+		}
+		if !ok {
+			log.Warnf("Can't find %v in %v. Set it to %q there.\n", strings.Join(kv.path, "."), fp, kv.value)
+		}
+	}
+	log.Infof("Writing %v\n", fp)
+	return ioutil.WriteFile(fp, b, 0640)
+}
+
+// warnLocaleClash warns if the project in dir with the settings b already has files for locale,
+// which becomes its default locale. Files of the default locale aren't in directories of the
+// locale, so they become files of the new default locale, which clash with the files the sample
+// has for that locale.
+func warnLocaleClash(dir string, b []byte, locale string) {
+	var s projectSettings
+	if err := yaml.Unmarshal(b, &s); err != nil || s.DefaultLocale == locale {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(dir, "settings", locale)); err == nil {
+		log.Warnf("The sample already has files for %v, e.g. in settings/%v, which clash with the files of the default locale. Merge them into the files of the default locale.\n", locale, locale)
+	}
+}
+
+// askSettings asks the user for the default locale and the display name of the project in
+// dir, offering the values of flags, or of the sample if they aren't set, and writes them with
+// the project ID of flags to its settings.
+func askSettings(dir string, flags projectSettings) error {
+	s, err := readSettings(dir)
+	if err != nil {
+		return fmt.Errorf("can't read the settings of the sample: %v", err)
+	}
+	if flags.DefaultLocale != "" {
+		s.DefaultLocale = flags.DefaultLocale
+	}
+	if flags.LocalizedSettings.DisplayName != "" {
+		s.LocalizedSettings.DisplayName = flags.LocalizedSettings.DisplayName
+	}
+	if s.DefaultLocale, err = ask("Default locale", s.DefaultLocale); err != nil {
+		return err
+	}
+	if s.LocalizedSettings.DisplayName, err = ask("Display name", s.LocalizedSettings.DisplayName); err != nil {
+		return err
+	}
+	s.ProjectID = flags.ProjectID
+	return writeSettings(dir, s)
+}
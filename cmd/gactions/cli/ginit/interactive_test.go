@@ -0,0 +1,139 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ginit
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+const sampleSettings = `# Settings of the sample.
+projectId: placeholder_project
+defaultLocale: en
+localizedSettings:
+  displayName: Facts about Google
+  pronunciation: Facts about Google
+`
+
+// sampleStudio downloads a sample with settings/settings.yaml.
+type sampleStudio struct {
+	MockStudio
+}
+
+func (sampleStudio) Download(sample project.SampleProject, dest string) error {
+	if err := os.MkdirAll(filepath.Join(dest, "settings"), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dest, "settings", "settings.yaml"), []byte(sampleSettings), 0640)
+}
+
+func setInput(t *testing.T, answers ...string) {
+	t.Helper()
+	og := input
+	t.Cleanup(func() { input = og })
+	input = bufio.NewReader(strings.NewReader(strings.Join(answers, "\n") + "\n"))
+}
+
+func TestAskSample(t *testing.T) {
+	samples := []project.SampleProject{{Name: "question"}, {Name: "facts"}}
+	tests := []struct {
+		answers []string
+		want    string
+	}{
+		{answers: []string{""}, want: "question"},
+		{answers: []string{"2"}, want: "facts"},
+		{answers: []string{"facts"}, want: "facts"},
+		{answers: []string{"3", "trivia", "1"}, want: "question"},
+	}
+	for _, tc := range tests {
+		setInput(t, tc.answers...)
+		got, err := askSample(samples)
+		if err != nil {
+			t.Errorf("askSample with answers %q returned %v, want %v", tc.answers, err, nil)
+			continue
+		}
+		if got.Name != tc.want {
+			t.Errorf("askSample with answers %q returned %v, want %v", tc.answers, got.Name, tc.want)
+		}
+	}
+}
+
+func TestInitInteractive(t *testing.T) {
+	og := availableProjects
+	t.Cleanup(func() { availableProjects = og })
+	availableProjects = func(ctx context.Context, p project.Project) ([]project.SampleProject, error) {
+		return []project.SampleProject{{Name: "question"}, {Name: "facts"}}, nil
+	}
+	dest := filepath.Join(t.TempDir(), "facts")
+	// Sample, directory, project ID, default locale and display name.
+	setInput(t, "facts", dest, "my-project", "en-US", "Facts of Life")
+	cmd := &cobra.Command{}
+	AddCommand(context.Background(), cmd, sampleStudio{})
+	cmd.SetArgs([]string{"init", "--interactive"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init --interactive returned %v, want %v", err, nil)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dest, "settings", "settings.yaml"))
+	if err != nil {
+		t.Fatalf("init --interactive didn't write the settings: %v", err)
+	}
+	want := `# Settings of the sample.
+projectId: my-project
+defaultLocale: en-US
+localizedSettings:
+  displayName: Facts of Life
+  pronunciation: Facts about Google
+`
+	if string(b) != want {
+		t.Errorf("init --interactive wrote %q, want %q", b, want)
+	}
+}
+
+func TestInitWithSettingsFlags(t *testing.T) {
+	og := availableProjects
+	t.Cleanup(func() { availableProjects = og })
+	availableProjects = func(ctx context.Context, p project.Project) ([]project.SampleProject, error) {
+		return []project.SampleProject{{Name: "facts"}}, nil
+	}
+	dest := filepath.Join(t.TempDir(), "facts")
+	cmd := &cobra.Command{}
+	AddCommand(context.Background(), cmd, sampleStudio{})
+	cmd.SetArgs([]string{"init", "facts", "--dest", dest, "--project-id", "my-project", "--locale", "en-US", "--display-name", "Trivia: $1 edition"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init returned %v, want %v", err, nil)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dest, "settings", "settings.yaml"))
+	if err != nil {
+		t.Fatalf("init didn't write the settings: %v", err)
+	}
+	want := `# Settings of the sample.
+projectId: my-project
+defaultLocale: en-US
+localizedSettings:
+  displayName: 'Trivia: $1 edition'
+  pronunciation: Facts about Google
+`
+	if string(b) != want {
+		t.Errorf("init wrote %q, want %q", b, want)
+	}
+}
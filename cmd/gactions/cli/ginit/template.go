@@ -0,0 +1,95 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ginit
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// projectIDPlaceholder is the project ID in the settings of the samples, which users replace
+// with the ID of their project.
+const projectIDPlaceholder = "placeholder_project"
+
+// githubRepoRegExp matches a URL of a GitHub repository, e.g.
+// https://github.com/actions-on-google/repo or https://github.com/actions-on-google/repo.git.
+var githubRepoRegExp = regexp.MustCompile(`^https://github\.com/([\w.-]+/[\w-]+(?:\.[\w-]+)*?)(?:\.git)?/?$`)
+
+// templateSample returns the sample to scaffold a project from with --from-git. src is a local
+// template directory, a URL of a GitHub repository, whose default branch is downloaded, or a URL
+// of a zip archive holding the project in a single top directory.
+func templateSample(src string) (project.SampleProject, error) {
+	if fi, err := os.Stat(src); err == nil {
+		if !fi.IsDir() {
+			return project.SampleProject{}, fmt.Errorf("%v is not a directory", src)
+		}
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return project.SampleProject{}, err
+		}
+		return project.SampleProject{Name: filepath.Base(abs), HostedURL: abs}, nil
+	}
+	if m := githubRepoRegExp.FindStringSubmatch(src); m != nil {
+		return project.SampleProject{Name: m[1], HostedURL: fmt.Sprintf("https://github.com/%v/archive/HEAD.zip", m[1])}, nil
+	}
+	if strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://") {
+		return project.SampleProject{Name: src, HostedURL: src}, nil
+	}
+	return project.SampleProject{}, fmt.Errorf("%v is neither a directory nor a URL of a Git archive", src)
+}
+
+// replacePlaceholder replaces the project ID placeholder with projectID in the text files under
+// dir, and returns the paths of the files it changed.
+func replacePlaceholder(dir, projectID string) ([]string, error) {
+	var changed []string
+	err := filepath.Walk(dir, func(fp string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return err
+		}
+		// Binary files, e.g. images, are left as they are.
+		if bytes.IndexByte(b, 0) >= 0 || !bytes.Contains(b, []byte(projectIDPlaceholder)) {
+			return nil
+		}
+		log.Infof("Setting the project ID in %v\n", fp)
+		b = bytes.ReplaceAll(b, []byte(projectIDPlaceholder), []byte(projectID))
+		if err := ioutil.WriteFile(fp, b, info.Mode().Perm()); err != nil {
+			return err
+		}
+		changed = append(changed, fp)
+		return nil
+	})
+	return changed, err
+}
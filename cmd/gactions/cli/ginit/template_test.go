@@ -0,0 +1,144 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ginit
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplateSample(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "manifest.yaml")
+	if err := ioutil.WriteFile(file, []byte("version: 1.0\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		src     string
+		want    project.SampleProject
+		wantErr bool
+	}{
+		{
+			src:  dir,
+			want: project.SampleProject{Name: filepath.Base(dir), HostedURL: dir},
+		},
+		{
+			src:  "https://github.com/my-org/my-template",
+			want: project.SampleProject{Name: "my-org/my-template", HostedURL: "https://github.com/my-org/my-template/archive/HEAD.zip"},
+		},
+		{
+			src:  "https://github.com/my-org/my.template.git",
+			want: project.SampleProject{Name: "my-org/my.template", HostedURL: "https://github.com/my-org/my.template/archive/HEAD.zip"},
+		},
+		{
+			src:  "https://example.com/templates/quiz.zip",
+			want: project.SampleProject{Name: "https://example.com/templates/quiz.zip", HostedURL: "https://example.com/templates/quiz.zip"},
+		},
+		{src: file, wantErr: true},
+		{src: "git@github.com:my-org/my-template.git", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := templateSample(tc.src)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("templateSample(%q) returned error %v, want error %v", tc.src, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("templateSample(%q) returned %v, want %v", tc.src, got, tc.want)
+		}
+	}
+}
+
+func TestReplacePlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"settings/settings.yaml":        "projectId: placeholder_project\n",
+		"webhooks/ActionsOnGoogle/a.js": "// placeholder_project\nconst id = 'placeholder_project';\n",
+		"manifest.yaml":                 "version: \"1.0\"\n",
+		"resources/images/logo.png":     "\x89PNG\x00placeholder_project",
+		".git/config":                   "placeholder_project",
+	}
+	for name, content := range files {
+		fp := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changed, err := replacePlaceholder(dir, "my-project")
+	if err != nil {
+		t.Fatalf("replacePlaceholder returned %v, want %v", err, nil)
+	}
+	wantChanged := []string{
+		filepath.Join(dir, "settings", "settings.yaml"),
+		filepath.Join(dir, "webhooks", "ActionsOnGoogle", "a.js"),
+	}
+	if diff := cmp.Diff(wantChanged, changed); diff != "" {
+		t.Errorf("replacePlaceholder changed incorrect files: diff (-want, +got)\n%s", diff)
+	}
+	want := map[string]string{
+		"settings/settings.yaml":        "projectId: my-project\n",
+		"webhooks/ActionsOnGoogle/a.js": "// my-project\nconst id = 'my-project';\n",
+		"manifest.yaml":                 "version: \"1.0\"\n",
+		"resources/images/logo.png":     "\x89PNG\x00placeholder_project",
+		".git/config":                   "placeholder_project",
+	}
+	for name, content := range want {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("replacePlaceholder wrote %q to %v, want %q", b, name, content)
+		}
+	}
+}
+
+func TestInitFromGit(t *testing.T) {
+	ogProjects, ogFind, ogRun := availableProjects, findGit, runGit
+	t.Cleanup(func() {
+		availableProjects, findGit, runGit = ogProjects, ogFind, ogRun
+	})
+	availableProjects = func(ctx context.Context, p project.Project) ([]project.SampleProject, error) {
+		t.Error("init --from-git listed the samples")
+		return nil, nil
+	}
+	findGit = func() error { return nil }
+	dest := t.TempDir()
+	var got []string
+	runGit = func(dir string, args ...string) error {
+		got = append(got, strings.Join(args, " "))
+		return nil
+	}
+	if _, err := execute("init", "--from-git", "https://github.com/my-org/my-template", "--git", "--dest", dest); err != nil {
+		t.Errorf("init --from-git returned %v, want %v", err, nil)
+	}
+	want := []string{"clone --origin upstream https://github.com/my-org/my-template.git ."}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("init --from-git ran incorrect git commands: diff (-want, +got)\n%s", diff)
+	}
+	if _, err := execute("init", "question", "--from-git", "https://github.com/my-org/my-template", "--dest", dest); err == nil {
+		t.Errorf("init with a sample and --from-git returned %v, want an error", err)
+	}
+}
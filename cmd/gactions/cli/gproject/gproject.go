@@ -0,0 +1,317 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gproject provides an implementation of "gactions project" command. It isn't named
+// "project", so it doesn't clash with the project package.
+package gproject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	projectIDRegExp = regexp.MustCompile(`^[a-z0-9][a-z0-9.:-]*$`)
+	// settingsIDRegExp matches the projectId key of a settings file, so it can be replaced
+	// without changing the rest of the file.
+	settingsIDRegExp = regexp.MustCompile(`(?m)^(projectId:[ \t]*)(["']?)[^"'#\s]*(["']?)`)
+	profileRegExp    = regexp.MustCompile(`(?m)^profile:.*$`)
+)
+
+// AddCommand adds the project sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	projectCmd := &cobra.Command{
+		Use:     "project",
+		Aliases: []string{"projects"},
+		Short:   "This is the main command for managing the local project. See below for a complete list of sub-commands.",
+		Long:    "This is the main command for managing the local project. See below for a complete list of sub-commands.",
+		Args:    cobra.MinimumNArgs(1),
+	}
+	migrateCmd := &cobra.Command{
+		Use:   "migrate --to <new project ID>",
+		Short: "Move the local project to another Google Cloud project.",
+		Long: "This command checks that you have access to the new project, encrypts the account linking secrets again, and replaces projectId in the settings files. " +
+			fmt.Sprintf("If --profile is set, the login profile in %v is also changed, and used to check the access. ", project.ConfigName) +
+			"The files are only changed if all of the steps succeed. The draft of the new project is not changed; run \"gactions push\" afterwards.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			to, err := cmd.Flags().GetString("to")
+			if err != nil {
+				return err
+			}
+			if !projectIDRegExp.MatchString(to) {
+				return fmt.Errorf("%q is not a valid project ID", to)
+			}
+			profile, err := cmd.Flags().GetString("profile")
+			if err != nil {
+				return err
+			}
+			from, err := studio.ProjectID(proj)
+			if err != nil {
+				return err
+			}
+			if from == to {
+				return fmt.Errorf("the project already uses %v", to)
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			if err := (&studioProj).SetProjectID(to); err != nil {
+				return err
+			}
+			m := migrator{
+				checkAccess: func() error {
+					_, err := sdk.ListReleaseChannelsJSON(ctx, studioProj)
+					return err
+				},
+				decrypt: func(secret string) (string, error) {
+					return sdk.DecryptSecret(ctx, proj, secret)
+				},
+				encrypt: func(secret string) ([]byte, error) {
+					return sdk.EncryptSecret(ctx, proj, secret)
+				},
+			}
+			log.Outf("Migrating the project from %q to %q\n", from, to)
+			changed, err := m.migrate(proj.ProjectRoot(), files, to, profile)
+			if err != nil {
+				return err
+			}
+			for _, v := range changed {
+				log.Outf("Updated %v\n", v)
+			}
+			log.DoneMsgln(fmt.Sprintf(`The project now uses %q. Run "gactions push" to write its files to the draft of the new project.`, to))
+			return nil
+		},
+	}
+	migrateCmd.Flags().String("to", "", "ID of the Google Cloud project to move the local project to.")
+	migrateCmd.MarkFlagRequired("to")
+	projectCmd.AddCommand(migrateCmd)
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the Actions projects you have access to.",
+		Long:  "This command lists the Actions projects you have access to, with their project IDs, display names and the time of their last deployment. The project IDs can be passed to --project-id of other commands.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			res, err := sdk.ListProjectsJSON(ctx, proj)
+			if err != nil {
+				return err
+			}
+			return printProjects(os.Stdout, res)
+		},
+	}
+	projectCmd.AddCommand(listCmd)
+	root.AddCommand(projectCmd)
+}
+
+// projectOutput is a project in the json and yaml output formats. LastDeploy keeps the RFC 3339
+// timestamp of the API, which scripts can parse.
+type projectOutput struct {
+	Name        string `json:"name" yaml:"name"`
+	ProjectID   string `json:"projectId" yaml:"projectId"`
+	DisplayName string `json:"displayName" yaml:"displayName"`
+	LastDeploy  string `json:"lastDeploy" yaml:"lastDeploy"`
+}
+
+func printProjects(out io.Writer, projects []project.ActionsProject) error {
+	res := []projectOutput{}
+	for _, p := range projects {
+		res = append(res, projectOutput{
+			Name:        p.Name,
+			ProjectID:   p.ProjectID,
+			DisplayName: p.DisplayName,
+			LastDeploy:  p.LastDeployTime,
+		})
+	}
+	return log.Render(out, res, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		w.Init(out, 20, 8, 1, '\t', 0)
+		fmt.Fprintln(w, "Name\tProject ID\tDisplay Name\tLast Deploy\t")
+		for _, p := range res {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t\n", p.Name, p.ProjectID, orNA(p.DisplayName), formatDeployTime(p.LastDeploy))
+		}
+		return w.Flush()
+	})
+}
+
+// formatDeployTime returns the time of a deployment in the table output, i.e. 2021-03-01 10:00:00
+// for 2021-03-01T10:00:00.000000Z.
+func formatDeployTime(s string) string {
+	if s == "" {
+		return "Never"
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func orNA(s string) string {
+	if s == "" {
+		return "N/A"
+	}
+	return s
+}
+
+// migrator moves a project to another Google Cloud project. Its fields are replaced in tests.
+type migrator struct {
+	// checkAccess returns an error if the user can't access the new project.
+	checkAccess func() error
+	decrypt     func(secret string) (string, error)
+	encrypt     func(secret string) ([]byte, error)
+}
+
+// migrate changes the files of the project in root to use the project with ID to, and returns
+// the paths of the changed files. If profile is not empty, it's set in the CLI config before
+// the access is checked, and the CLI config is restored if the migration fails.
+func (m migrator) migrate(root string, files map[string][]byte, to, profile string) (changed []string, err error) {
+	if profile != "" {
+		cfgPath, restore, perr := setProfile(root, profile)
+		if perr != nil {
+			return nil, perr
+		}
+		defer func() {
+			if err != nil {
+				if err2 := restore(); err2 != nil {
+					log.Errorf("Failed to restore %v: %v\n", cfgPath, err2)
+				}
+				return
+			}
+			changed = append(changed, cfgPath)
+		}()
+	}
+	if err := m.checkAccess(); err != nil {
+		return nil, fmt.Errorf("can't access the project %v: %v", to, err)
+	}
+	writes := map[string][]byte{}
+	var names []string
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		switch {
+		case studio.IsAccountLinkingSecret(k):
+			b, err := m.reencrypt(k, files[k])
+			if err != nil {
+				return nil, err
+			}
+			if b != nil {
+				writes[k] = b
+			}
+		case studio.IsSettings(k):
+			if settingsIDRegExp.Match(files[k]) {
+				writes[k] = settingsIDRegExp.ReplaceAll(files[k], []byte("${1}${2}"+to+"${3}"))
+			}
+		}
+	}
+	if _, ok := writes["settings/settings.yaml"]; !ok {
+		return nil, errors.New("projectId is not present in settings/settings.yaml")
+	}
+	for _, k := range names {
+		b, ok := writes[k]
+		if !ok {
+			continue
+		}
+		fp := filepath.Join(root, filepath.FromSlash(k))
+		if err := ioutil.WriteFile(fp, b, 0640); err != nil {
+			return nil, err
+		}
+		changed = append(changed, fp)
+	}
+	return changed, nil
+}
+
+// reencrypt returns the contents of the account linking secret file name with the secret
+// encrypted again, or nil if the file has no secret.
+func (m migrator) reencrypt(name string, b []byte) ([]byte, error) {
+	var f struct {
+		EncryptedClientSecret string `yaml:"encryptedClientSecret"`
+	}
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%v has incorrect syntax: %v", name, err)
+	}
+	if f.EncryptedClientSecret == "" {
+		return nil, nil
+	}
+	log.Outf("Encrypting the client secret in %v again\n", name)
+	plain, err := m.decrypt(f.EncryptedClientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt the client secret in %v: %v", name, err)
+	}
+	return m.encrypt(plain)
+}
+
+// setProfile sets the login profile in the CLI config, and returns a function which restores
+// the previous contents of the CLI config. The CLI config is created in root if it's missing.
+func setProfile(root, profile string) (string, func() error, error) {
+	fp, err := studio.CLIConfigPath()
+	if err != nil {
+		fp = filepath.Join(root, project.ConfigName)
+	}
+	old, err := ioutil.ReadFile(fp)
+	existed := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, err
+	}
+	line := "profile: " + profile
+	var b []byte
+	switch {
+	case !existed:
+		b = []byte("sdkPath: .\n" + line + "\n")
+	case profileRegExp.Match(old):
+		b = profileRegExp.ReplaceAll(old, []byte(line))
+	default:
+		b = append([]byte{}, old...)
+		if len(b) > 0 && b[len(b)-1] != '\n' {
+			b = append(b, '\n')
+		}
+		b = append(b, line+"\n"...)
+	}
+	if err := ioutil.WriteFile(fp, b, 0640); err != nil {
+		return "", nil, err
+	}
+	restore := func() error {
+		if !existed {
+			return os.Remove(fp)
+		}
+		return ioutil.WriteFile(fp, old, 0640)
+	}
+	return fp, restore, nil
+}
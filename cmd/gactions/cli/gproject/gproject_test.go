@@ -0,0 +1,246 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gproject
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeFiles(t *testing.T, root string, files map[string][]byte) {
+	t.Helper()
+	for k, v := range files {
+		fp := filepath.Join(root, filepath.FromSlash(k))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fp, v, 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFiles(t *testing.T, root string, names []string) map[string]string {
+	t.Helper()
+	res := map[string]string{}
+	for _, k := range names {
+		b, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(k)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res[k] = string(b)
+	}
+	return res
+}
+
+func fakeMigrator(accessErr error) migrator {
+	return migrator{
+		checkAccess: func() error { return accessErr },
+		decrypt: func(secret string) (string, error) {
+			return "plain-" + secret, nil
+		},
+		encrypt: func(secret string) ([]byte, error) {
+			return []byte("encryptedClientSecret: new-" + secret + "\n"), nil
+		},
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml":             []byte("# Settings\nprojectId: \"old-project\" # ID\ndefaultLocale: en\n"),
+		"settings/en-GB/settings.yaml":       []byte("localizedSettings:\n  displayName: Test\n"),
+		"settings/accountLinkingSecret.yaml": []byte("encryptedClientSecret: abc\n"),
+		"manifest.yaml":                      []byte("version: \"1.0\"\n"),
+	}
+	tests := []struct {
+		name      string
+		profile   string
+		accessErr error
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name: "rewrites settings and secret",
+			want: map[string]string{
+				"settings/settings.yaml":             "# Settings\nprojectId: \"new-project\" # ID\ndefaultLocale: en\n",
+				"settings/en-GB/settings.yaml":       "localizedSettings:\n  displayName: Test\n",
+				"settings/accountLinkingSecret.yaml": "encryptedClientSecret: new-plain-abc\n",
+				".gactionsrc.yaml":                   "sdkPath: .\nprofile: work\n",
+			},
+		},
+		{
+			name:    "sets profile",
+			profile: "personal",
+			want: map[string]string{
+				"settings/settings.yaml":             "# Settings\nprojectId: \"new-project\" # ID\ndefaultLocale: en\n",
+				"settings/accountLinkingSecret.yaml": "encryptedClientSecret: new-plain-abc\n",
+				".gactionsrc.yaml":                   "sdkPath: .\nprofile: personal\n",
+			},
+		},
+		{
+			name:      "no access",
+			profile:   "personal",
+			accessErr: errors.New("permission denied"),
+			want: map[string]string{
+				"settings/settings.yaml":             "# Settings\nprojectId: \"old-project\" # ID\ndefaultLocale: en\n",
+				"settings/accountLinkingSecret.yaml": "encryptedClientSecret: abc\n",
+				".gactionsrc.yaml":                   "sdkPath: .\nprofile: work\n",
+			},
+			wantErr: true,
+		},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "gproject")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			writeFiles(t, root, files)
+			writeFiles(t, root, map[string][]byte{".gactionsrc.yaml": []byte("sdkPath: .\nprofile: work\n")})
+			if err := os.Chdir(root); err != nil {
+				t.Fatal(err)
+			}
+			_, err = fakeMigrator(tc.accessErr).migrate(root, files, "new-project", tc.profile)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("migrate returned %v, want error %v", err, tc.wantErr)
+			}
+			var names []string
+			for k := range tc.want {
+				names = append(names, k)
+			}
+			if diff := cmp.Diff(tc.want, readFiles(t, root, names)); diff != "" {
+				t.Errorf("migrate wrote incorrect files: diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMigrateWithoutProjectID(t *testing.T) {
+	root, err := ioutil.TempDir("", "gproject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	files := map[string][]byte{"settings/settings.yaml": []byte("defaultLocale: en\n")}
+	writeFiles(t, root, files)
+	if _, err := fakeMigrator(nil).migrate(root, files, "new-project", ""); err == nil {
+		t.Errorf("migrate returned nil, want an error")
+	}
+}
+
+func TestSetProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		want string
+	}{
+		{
+			name: "replaces profile",
+			old:  "sdkPath: .\nprofile: work\ncloudAuditLog: true\n",
+			want: "sdkPath: .\nprofile: personal\ncloudAuditLog: true\n",
+		},
+		{
+			name: "appends profile",
+			old:  "sdkPath: .",
+			want: "sdkPath: .\nprofile: personal\n",
+		},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "gproject")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			writeFiles(t, root, map[string][]byte{".gactionsrc.yaml": []byte(tc.old)})
+			if err := os.Chdir(root); err != nil {
+				t.Fatal(err)
+			}
+			_, restore, err := setProfile(root, "personal")
+			if err != nil {
+				t.Fatalf("setProfile returned %v, want %v", err, nil)
+			}
+			got := readFiles(t, root, []string{".gactionsrc.yaml"})[".gactionsrc.yaml"]
+			if got != tc.want {
+				t.Errorf("setProfile wrote %q, want %q", got, tc.want)
+			}
+			if err := restore(); err != nil {
+				t.Fatalf("restore returned %v, want %v", err, nil)
+			}
+			got = readFiles(t, root, []string{".gactionsrc.yaml"})[".gactionsrc.yaml"]
+			if got != tc.old {
+				t.Errorf("restore wrote %q, want %q", got, tc.old)
+			}
+		})
+	}
+}
+
+func TestPrintProjects(t *testing.T) {
+	projects := []project.ActionsProject{
+		{Name: "projects/my-project", ProjectID: "my-project", DisplayName: "My Action", LastDeployTime: "2021-03-01T10:00:00.000000Z"},
+		{Name: "projects/new-project", ProjectID: "new-project"},
+	}
+	og := log.Format
+	defer func() { log.Format = og }()
+
+	log.Format = log.YAMLFormat
+	var b bytes.Buffer
+	if err := printProjects(&b, projects); err != nil {
+		t.Errorf("printProjects returned %v in %v format, want %v", err, log.Format, nil)
+	}
+	want := `- name: projects/my-project
+  projectId: my-project
+  displayName: My Action
+  lastDeploy: "2021-03-01T10:00:00.000000Z"
+- name: projects/new-project
+  projectId: new-project
+  displayName: ""
+  lastDeploy: ""
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printProjects wrote incorrect YAML: diff (-want, +got)\n%s", diff)
+	}
+
+	log.Format = log.TextFormat
+	b.Reset()
+	if err := printProjects(&b, projects); err != nil {
+		t.Errorf("printProjects returned %v, want %v", err, nil)
+	}
+	for _, want := range []string{"My Action\t\t2021-03-01 10:00:00\t", "N/A\t\t\tNever\t"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("printProjects wrote %q, want it to contain %q", b.String(), want)
+		}
+	}
+}
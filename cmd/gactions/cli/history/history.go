@@ -0,0 +1,203 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history provides an implementation of "gactions history" command.
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the history sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	history := &cobra.Command{
+		Use:   "history",
+		Short: "Show the pushes, pulls and deploys performed by the CLI.",
+		Long: "This command shows the pushes, pulls and deploys of the project performed by the CLI, with who performed them, when, the number of files they added, modified and removed, and a digest of the project files. " +
+			fmt.Sprintf("The history is kept in the %v directory of the project. ", studio.StateDir) +
+			fmt.Sprintf("If cloudAuditLog is set to true in %v, the history is also recorded in Cloud Logging of the project, and can be shown with --cloud.", project.ConfigName),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			cloud, err := cmd.Flags().GetBool("cloud")
+			if err != nil {
+				return err
+			}
+			limit, err := cmd.Flags().GetInt("limit")
+			if err != nil {
+				return err
+			}
+			op, err := cmd.Flags().GetString("operation")
+			if err != nil {
+				return err
+			}
+			if limit <= 0 {
+				return fmt.Errorf("--limit must be positive, got %v", limit)
+			}
+			var entries []studio.HistoryEntry
+			if cloud {
+				studioProj, ok := proj.(studio.Studio)
+				if !ok {
+					return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+				}
+				if err := (&studioProj).SetProjectID(""); err != nil {
+					return err
+				}
+				entries, err = sdk.ReadCloudHistoryJSON(ctx, studioProj, limit)
+			} else {
+				entries, err = studio.ReadHistory(proj.ProjectRoot())
+			}
+			if err != nil {
+				return err
+			}
+			// Only entries of the local history are numbered, and can be shown with "history show".
+			return printHistory(os.Stdout, entries, filter(entries, op, limit), !cloud)
+		},
+	}
+	show := &cobra.Command{
+		Use:   "show <n>",
+		Short: "Show an entry of the local history, with the files it changed.",
+		Long: "This command shows the entry number n of the local history, as numbered by \"gactions history\". " +
+			"The files which were added, modified or removed since the previous entry of the project are listed, so for a push they are the changes the push sent.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("<n> must be a number, got %q", args[0])
+			}
+			entries, err := studio.ReadHistory(proj.ProjectRoot())
+			if err != nil {
+				return err
+			}
+			if n < 1 || n > len(entries) {
+				return fmt.Errorf("entry %v was not found; the history has %v entries", n, len(entries))
+			}
+			return printEntry(os.Stdout, n, entries[n-1])
+		},
+	}
+	history.AddCommand(show)
+	history.Flags().Bool("cloud", false, "Show the history recorded in Cloud Logging of the project, instead of the local history.")
+	history.Flags().Int("limit", 20, "Maximum number of the latest entries to show.")
+	history.Flags().String("operation", "", "Only show entries of this operation: push, pull or deploy.")
+	root.AddCommand(history)
+}
+
+func checkRoot(proj project.Project) error {
+	if proj.ProjectRoot() == "" {
+		log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+		return errors.New("can not determine project root")
+	}
+	return nil
+}
+
+// filter returns the indexes of up to limit of the latest entries of the operation op, or of
+// all operations if op is empty.
+func filter(entries []studio.HistoryEntry, op string, limit int) []int {
+	var res []int
+	for i, e := range entries {
+		if op == "" || e.Operation == op {
+			res = append(res, i)
+		}
+	}
+	if len(res) > limit {
+		res = res[len(res)-limit:]
+	}
+	return res
+}
+
+// printHistory prints the entries at indexes. If numbered is true, the entries are numbered by
+// their position in the history, starting at 1.
+func printHistory(out io.Writer, entries []studio.HistoryEntry, indexes []int, numbered bool) error {
+	if len(indexes) == 0 {
+		log.Outln("No history found.")
+		return nil
+	}
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTime\tOperation\tProject\tVersion\tChannel\tUser\tChanges\tDigest\t")
+	for _, i := range indexes {
+		e := entries[i]
+		n := "-"
+		if numbered {
+			n = strconv.Itoa(i + 1)
+		}
+		channel := e.Channel
+		if channel == "" {
+			channel = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", n, e.Time.Local().Format(time.RFC3339), e.Operation, e.ProjectID, e.Version, channel, e.User, changesSummary(e.Changes), e.Digest()[:12])
+	}
+	return w.Flush()
+}
+
+// changesSummary returns the number of added, modified and removed files, e.g. "+1 ~2 -0".
+func changesSummary(c *studio.Changes) string {
+	if c == nil {
+		return "-"
+	}
+	return fmt.Sprintf("+%d ~%d -%d", len(c.Added), len(c.Modified), len(c.Removed))
+}
+
+func printEntry(out io.Writer, n int, e studio.HistoryEntry) error {
+	fmt.Fprintf(out, "Entry:     %v\n", n)
+	fmt.Fprintf(out, "Time:      %v\n", e.Time.Local().Format(time.RFC3339))
+	fmt.Fprintf(out, "Operation: %v\n", e.Operation)
+	fmt.Fprintf(out, "Project:   %v\n", e.ProjectID)
+	fmt.Fprintf(out, "Version:   %v\n", e.Version)
+	if e.Channel != "" {
+		fmt.Fprintf(out, "Channel:   %v\n", e.Channel)
+	}
+	fmt.Fprintf(out, "User:      %v\n", e.User)
+	fmt.Fprintf(out, "Digest:    %v\n", e.Digest())
+	fmt.Fprintf(out, "Files:     %v\n", len(e.Digests))
+	if e.ReleaseNotes != "" {
+		fmt.Fprintf(out, "\nRelease notes:\n%v\n", strings.TrimRight(e.ReleaseNotes, "\n"))
+	}
+	switch {
+	case e.Changes == nil:
+		fmt.Fprintln(out, "\nThe changes of this entry were not recorded.")
+	case e.Changes.Empty():
+		fmt.Fprintln(out, "\nNo files changed since the previous entry of the project.")
+	default:
+		fmt.Fprintln(out, "\nChanges since the previous entry of the project:")
+		for _, v := range []struct {
+			mark  string
+			files []string
+		}{{"A", e.Changes.Added}, {"M", e.Changes.Modified}, {"D", e.Changes.Removed}} {
+			for _, f := range v.files {
+				fmt.Fprintf(out, "  %v %v\n", v.mark, f)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilter(t *testing.T) {
+	entries := []studio.HistoryEntry{
+		{Operation: "push", Version: "draft"},
+		{Operation: "deploy", Version: "preview"},
+		{Operation: "push", Version: "draft"},
+		{Operation: "deploy", Version: "3"},
+	}
+	tests := []struct {
+		op    string
+		limit int
+		want  []int
+	}{
+		{
+			limit: 20,
+			want:  []int{0, 1, 2, 3},
+		},
+		{
+			limit: 2,
+			want:  []int{2, 3},
+		},
+		{
+			op:    "deploy",
+			limit: 20,
+			want:  []int{1, 3},
+		},
+		{
+			op:    "pull",
+			limit: 20,
+		},
+	}
+	for _, tc := range tests {
+		got := filter(entries, tc.op, tc.limit)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("filter(%q, %v) returned diff (-want, +got)\n%s", tc.op, tc.limit, diff)
+		}
+	}
+}
+
+func TestPrintEntry(t *testing.T) {
+	e := studio.HistoryEntry{
+		Time:      time.Date(2021, 3, 1, 10, 0, 0, 0, time.Local),
+		Operation: "push",
+		ProjectID: "my-project",
+		User:      "user@host",
+		Version:   "draft",
+		Digests:   map[string]string{"manifest.yaml": "a", "custom/intents/a.yaml": "b"},
+		Changes: &studio.Changes{
+			Added:    []string{"custom/intents/a.yaml"},
+			Modified: []string{"manifest.yaml"},
+			Removed:  []string{"custom/intents/b.yaml"},
+		},
+	}
+	var b bytes.Buffer
+	if err := printEntry(&b, 3, e); err != nil {
+		t.Fatalf("printEntry returned %v", err)
+	}
+	want := "Entry:     3\n" +
+		"Time:      " + e.Time.Format(time.RFC3339) + "\n" +
+		"Operation: push\n" +
+		"Project:   my-project\n" +
+		"Version:   draft\n" +
+		"User:      user@host\n" +
+		"Digest:    " + e.Digest() + "\n" +
+		"Files:     2\n" +
+		"\n" +
+		"Changes since the previous entry of the project:\n" +
+		"  A custom/intents/a.yaml\n" +
+		"  M manifest.yaml\n" +
+		"  D custom/intents/b.yaml\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printEntry returned diff (-want, +got)\n%s", diff)
+	}
+}
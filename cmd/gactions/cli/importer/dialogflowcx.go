@@ -0,0 +1,785 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	cxWelcomeIntent  = "Default Welcome Intent"
+	cxNegativeIntent = "Default Negative Intent"
+	endConversation  = "actions.scene.END_CONVERSATION"
+)
+
+// cxSystemTypes maps Dialogflow CX system entity types onto system types of Actions SDK.
+var cxSystemTypes = map[string]string{
+	"@sys.number":         "actions.type.Number",
+	"@sys.number-integer": "actions.type.Number",
+	"@sys.date":           "actions.type.Date",
+	"@sys.time":           "actions.type.Time",
+	"@sys.date-time":      "actions.type.DateTime",
+	"@sys.any":            "actions.type.FreeText",
+}
+
+var (
+	nonWordRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+	// slotsStatusRe matches the CX condition that is true once all form parameters are filled.
+	slotsStatusRe = regexp.MustCompile(`^\$page\.params\.status\s*=\s*"FINAL"$`)
+)
+
+type cxAgent struct {
+	DisplayName            string   `json:"displayName"`
+	DefaultLanguageCode    string   `json:"defaultLanguageCode"`
+	SupportedLanguageCodes []string `json:"supportedLanguageCodes"`
+}
+
+type cxTrainingPhrase struct {
+	Parts []struct {
+		Text        string `json:"text"`
+		ParameterID string `json:"parameterId"`
+	} `json:"parts"`
+	LanguageCode string `json:"languageCode"`
+}
+
+type cxIntent struct {
+	DisplayName string `json:"displayName"`
+	Parameters  []struct {
+		ID         string `json:"id"`
+		EntityType string `json:"entityType"`
+		IsList     bool   `json:"isList"`
+	} `json:"parameters"`
+	TrainingPhrases []cxTrainingPhrase `json:"trainingPhrases"`
+	IsFallback      bool               `json:"isFallback"`
+}
+
+type cxEntity struct {
+	Value        string   `json:"value"`
+	Synonyms     []string `json:"synonyms"`
+	LanguageCode string   `json:"languageCode"`
+}
+
+type cxEntityType struct {
+	DisplayName string     `json:"displayName"`
+	Kind        string     `json:"kind"`
+	Entities    []cxEntity `json:"entities"`
+}
+
+type cxFulfillment struct {
+	Messages            []map[string]json.RawMessage `json:"messages"`
+	Webhook             string                       `json:"webhook"`
+	Tag                 string                       `json:"tag"`
+	SetParameterActions []json.RawMessage            `json:"setParameterActions"`
+	ConditionalCases    []json.RawMessage            `json:"conditionalCases"`
+}
+
+type cxRoute struct {
+	Intent             string         `json:"intent"`
+	Condition          string         `json:"condition"`
+	TriggerFulfillment *cxFulfillment `json:"triggerFulfillment"`
+	TargetPage         string         `json:"targetPage"`
+	TargetFlow         string         `json:"targetFlow"`
+}
+
+type cxEventHandler struct {
+	Event string `json:"event"`
+}
+
+type cxFormParameter struct {
+	DisplayName  string `json:"displayName"`
+	EntityType   string `json:"entityType"`
+	Required     bool   `json:"required"`
+	IsList       bool   `json:"isList"`
+	FillBehavior struct {
+		InitialPromptFulfillment *cxFulfillment `json:"initialPromptFulfillment"`
+	} `json:"fillBehavior"`
+}
+
+type cxPage struct {
+	DisplayName      string         `json:"displayName"`
+	EntryFulfillment *cxFulfillment `json:"entryFulfillment"`
+	Form             struct {
+		Parameters []cxFormParameter `json:"parameters"`
+	} `json:"form"`
+	TransitionRoutes      []cxRoute        `json:"transitionRoutes"`
+	EventHandlers         []cxEventHandler `json:"eventHandlers"`
+	TransitionRouteGroups []string         `json:"transitionRouteGroups"`
+}
+
+type cxFlow struct {
+	DisplayName           string           `json:"displayName"`
+	TransitionRoutes      []cxRoute        `json:"transitionRoutes"`
+	EventHandlers         []cxEventHandler `json:"eventHandlers"`
+	TransitionRouteGroups []string         `json:"transitionRouteGroups"`
+	pages                 []cxPage
+}
+
+// cxConverter converts an exported Dialogflow CX agent into files of an Actions SDK project.
+type cxConverter struct {
+	files   map[string][]byte // files of the agent export, keyed by slash separated path
+	agent   cxAgent
+	intents map[string]string            // CX intent display name -> SDK intent name
+	scenes  map[string]map[string]string // CX flow display name -> CX page display name -> SDK scene name
+	out     map[string][]byte
+	report  []string
+}
+
+// ReadAgentExport reads files of an exported Dialogflow CX agent from a directory,
+// or a zip archive. Returned paths are slash separated and relative to agent.json.
+func ReadAgentExport(name string) (map[string][]byte, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	if info.IsDir() {
+		err := filepath.Walk(name, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(name, p)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)], err = ioutil.ReadFile(p)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, fmt.Errorf("%v is neither a directory, nor a zip archive: %v", name, err)
+		}
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			files[f.Name], err = ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Agent may be exported inside of a directory, so agent.json closest to the top is used.
+	agent := ""
+	for k := range files {
+		if path.Base(k) == "agent.json" && (agent == "" || strings.Count(k, "/") < strings.Count(agent, "/")) {
+			agent = k
+		}
+	}
+	if agent == "" {
+		return nil, fmt.Errorf("agent.json is not found in %v", name)
+	}
+	prefix := path.Dir(agent)
+	if prefix == "." {
+		return files, nil
+	}
+	trimmed := map[string][]byte{}
+	for k, v := range files {
+		if strings.HasPrefix(k, prefix+"/") {
+			trimmed[strings.TrimPrefix(k, prefix+"/")] = v
+		}
+	}
+	return trimmed, nil
+}
+
+// ConvertDialogflowCX converts files of an exported Dialogflow CX agent into files of an
+// Actions SDK project. It returns the converted files keyed by their path in the project,
+// and a report listing the parts of the agent that need manual attention.
+func ConvertDialogflowCX(files map[string][]byte) (map[string][]byte, []string, error) {
+	c := &cxConverter{
+		files:   files,
+		intents: map[string]string{},
+		scenes:  map[string]map[string]string{},
+		out:     map[string][]byte{},
+	}
+	if err := c.convert(); err != nil {
+		return nil, nil, err
+	}
+	return c.out, c.report, nil
+}
+
+func (c *cxConverter) reportf(format string, a ...interface{}) {
+	c.report = append(c.report, fmt.Sprintf(format, a...))
+}
+
+// names returns sorted names of the directories directly under dir.
+func (c *cxConverter) names(dir string) []string {
+	seen := map[string]bool{}
+	for k := range c.files {
+		if !strings.HasPrefix(k, dir+"/") {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(k, dir+"/"), "/")
+		if len(parts) > 1 {
+			seen[parts[0]] = true
+		}
+	}
+	var res []string
+	for k := range seen {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// filesIn returns sorted paths of the JSON files directly under dir.
+func (c *cxConverter) filesIn(dir string) []string {
+	var res []string
+	for k := range c.files {
+		if path.Dir(k) == dir && path.Ext(k) == ".json" {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (c *cxConverter) unmarshal(name string, v interface{}) error {
+	b, ok := c.files[name]
+	if !ok {
+		return fmt.Errorf("%v is not found in the agent export", name)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%v has incorrect syntax: %v", name, err)
+	}
+	return nil
+}
+
+func (c *cxConverter) write(name string, v interface{}) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.out[name] = b
+	return nil
+}
+
+func (c *cxConverter) convert() error {
+	if err := c.unmarshal("agent.json", &c.agent); err != nil {
+		return err
+	}
+	if c.agent.DefaultLanguageCode == "" {
+		return errors.New("agent.json doesn't specify defaultLanguageCode")
+	}
+	if err := c.convertSettings(); err != nil {
+		return err
+	}
+	if err := c.convertTypes(); err != nil {
+		return err
+	}
+	if err := c.convertIntents(); err != nil {
+		return err
+	}
+	if err := c.convertFlows(); err != nil {
+		return err
+	}
+	for _, v := range c.filesIn("webhooks") {
+		c.reportf("%v: webhooks are not converted; define the webhook under webhooks/ and use the tags of its handlers", v)
+	}
+	return nil
+}
+
+func (c *cxConverter) convertSettings() error {
+	if err := c.write("manifest.yaml", map[string]interface{}{"version": "1.0"}); err != nil {
+		return err
+	}
+	settings := map[string]interface{}{
+		"projectId":     "placeholder_project",
+		"defaultLocale": c.agent.DefaultLanguageCode,
+		"localizedSettings": map[string]interface{}{
+			"displayName":   c.agent.DisplayName,
+			"pronunciation": c.agent.DisplayName,
+		},
+	}
+	c.reportf("settings/settings.yaml: replace placeholder_project with the ID of your project")
+	if err := c.write("settings/settings.yaml", settings); err != nil {
+		return err
+	}
+	for _, v := range c.agent.SupportedLanguageCodes {
+		if v == c.agent.DefaultLanguageCode {
+			continue
+		}
+		localized := map[string]interface{}{
+			"localizedSettings": map[string]interface{}{
+				"displayName":   c.agent.DisplayName,
+				"pronunciation": c.agent.DisplayName,
+			},
+		}
+		if err := c.write(path.Join("settings", v, "settings.yaml"), localized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sdkName turns a display name of a CX resource into a name that can be used for SDK files.
+func sdkName(displayName string) string {
+	return strings.Trim(nonWordRe.ReplaceAllString(displayName, "_"), "_")
+}
+
+func (c *cxConverter) sdkType(entityType string, where string) string {
+	if t, ok := cxSystemTypes[entityType]; ok {
+		return t
+	}
+	if strings.HasPrefix(entityType, "@sys.") {
+		c.reportf("%v: system entity type %v has no equivalent; actions.type.FreeText is used instead", where, entityType)
+		return "actions.type.FreeText"
+	}
+	return sdkName(strings.TrimPrefix(entityType, "@"))
+}
+
+// entitiesByLanguage reads entities of the entity type, which may be stored inline or per language.
+func (c *cxConverter) entitiesByLanguage(dir string, et cxEntityType) (map[string][]cxEntity, error) {
+	res := map[string][]cxEntity{}
+	for _, e := range et.Entities {
+		lang := e.LanguageCode
+		if lang == "" {
+			lang = c.agent.DefaultLanguageCode
+		}
+		res[lang] = append(res[lang], e)
+	}
+	for _, v := range c.filesIn(path.Join(dir, "entities")) {
+		var in struct {
+			Entities []cxEntity `json:"entities"`
+		}
+		if err := c.unmarshal(v, &in); err != nil {
+			return nil, err
+		}
+		lang := strings.TrimSuffix(path.Base(v), ".json")
+		res[lang] = append(res[lang], in.Entities...)
+	}
+	return res, nil
+}
+
+func (c *cxConverter) convertTypes() error {
+	for _, v := range c.names("entityTypes") {
+		dir := path.Join("entityTypes", v)
+		var et cxEntityType
+		if err := c.unmarshal(path.Join(dir, v+".json"), &et); err != nil {
+			return err
+		}
+		name := sdkName(et.DisplayName)
+		if et.Kind == "KIND_REGEXP" {
+			c.reportf("%v: regexp entity types are not converted; define type %v manually", dir, name)
+			continue
+		}
+		byLang, err := c.entitiesByLanguage(dir, et)
+		if err != nil {
+			return err
+		}
+		for lang, entities := range byLang {
+			m := map[string]interface{}{}
+			for _, e := range entities {
+				synonyms := e.Synonyms
+				if et.Kind == "KIND_LIST" || len(synonyms) == 0 {
+					synonyms = []string{e.Value}
+				}
+				m[e.Value] = map[string]interface{}{"synonyms": synonyms}
+			}
+			fp := path.Join("custom", "types", name+".yaml")
+			if lang != c.agent.DefaultLanguageCode {
+				fp = path.Join("custom", "types", lang, name+".yaml")
+			}
+			if err := c.write(fp, map[string]interface{}{"synonym": map[string]interface{}{"entities": m}}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// trainingPhrase returns a training phrase in the format of SDK, where parts annotated with
+// a parameter look like ($param 'text' auto=true).
+func trainingPhrase(tp cxTrainingPhrase) string {
+	var sb strings.Builder
+	for _, p := range tp.Parts {
+		if p.ParameterID == "" {
+			sb.WriteString(p.Text)
+			continue
+		}
+		fmt.Fprintf(&sb, "($%v '%v' auto=true)", p.ParameterID, strings.ReplaceAll(p.Text, "'", "\\'"))
+	}
+	return sb.String()
+}
+
+func (c *cxConverter) convertIntents() error {
+	for _, v := range c.names("intents") {
+		dir := path.Join("intents", v)
+		var in cxIntent
+		if err := c.unmarshal(path.Join(dir, v+".json"), &in); err != nil {
+			return err
+		}
+		if in.DisplayName == cxWelcomeIntent {
+			c.intents[in.DisplayName] = "actions.intent.MAIN"
+			continue
+		}
+		if in.IsFallback || in.DisplayName == cxNegativeIntent {
+			c.reportf("%v: %q has no equivalent in Actions SDK and was skipped", dir, in.DisplayName)
+			continue
+		}
+		name := sdkName(in.DisplayName)
+		c.intents[in.DisplayName] = name
+		byLang := map[string][]string{}
+		for _, tp := range in.TrainingPhrases {
+			lang := tp.LanguageCode
+			if lang == "" {
+				lang = c.agent.DefaultLanguageCode
+			}
+			byLang[lang] = append(byLang[lang], trainingPhrase(tp))
+		}
+		for _, f := range c.filesIn(path.Join(dir, "trainingPhrases")) {
+			var tps struct {
+				TrainingPhrases []cxTrainingPhrase `json:"trainingPhrases"`
+			}
+			if err := c.unmarshal(f, &tps); err != nil {
+				return err
+			}
+			lang := strings.TrimSuffix(path.Base(f), ".json")
+			for _, tp := range tps.TrainingPhrases {
+				byLang[lang] = append(byLang[lang], trainingPhrase(tp))
+			}
+		}
+		var params []interface{}
+		for _, p := range in.Parameters {
+			t := map[string]interface{}{"name": c.sdkType(p.EntityType, dir)}
+			if p.IsList {
+				t["list"] = true
+			}
+			params = append(params, map[string]interface{}{"name": p.ID, "type": t})
+		}
+		base := map[string]interface{}{"trainingPhrases": byLang[c.agent.DefaultLanguageCode]}
+		if len(params) > 0 {
+			base["parameters"] = params
+		}
+		if err := c.write(path.Join("custom", "intents", name+".yaml"), base); err != nil {
+			return err
+		}
+		for lang, tps := range byLang {
+			if lang == c.agent.DefaultLanguageCode {
+				continue
+			}
+			if err := c.write(path.Join("custom", "intents", lang, name+".yaml"), map[string]interface{}{"trainingPhrases": tps}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *cxConverter) readFlows() ([]*cxFlow, error) {
+	var flows []*cxFlow
+	for _, v := range c.names("flows") {
+		dir := path.Join("flows", v)
+		f := &cxFlow{}
+		if err := c.unmarshal(path.Join(dir, v+".json"), f); err != nil {
+			return nil, err
+		}
+		for _, p := range c.filesIn(path.Join(dir, "pages")) {
+			var page cxPage
+			if err := c.unmarshal(p, &page); err != nil {
+				return nil, err
+			}
+			f.pages = append(f.pages, page)
+		}
+		flows = append(flows, f)
+	}
+	return flows, nil
+}
+
+// nameScenes assigns a scene to each flow (i.e. the start page of a flow) and to each page.
+// A page is prefixed with the name of its flow, if its name is already taken.
+func (c *cxConverter) nameScenes(flows []*cxFlow) {
+	taken := map[string]bool{}
+	for _, f := range flows {
+		name := sdkName(f.DisplayName)
+		c.scenes[f.DisplayName] = map[string]string{"": name}
+		taken[name] = true
+	}
+	count := map[string]int{}
+	for _, f := range flows {
+		for _, p := range f.pages {
+			count[sdkName(p.DisplayName)]++
+		}
+	}
+	for _, f := range flows {
+		for _, p := range f.pages {
+			name := sdkName(p.DisplayName)
+			if taken[name] || count[name] > 1 {
+				name = sdkName(f.DisplayName + "_" + p.DisplayName)
+			}
+			c.scenes[f.DisplayName][p.DisplayName] = name
+		}
+	}
+}
+
+func (c *cxConverter) convertFlows() error {
+	flows, err := c.readFlows()
+	if err != nil {
+		return err
+	}
+	c.nameScenes(flows)
+	for _, f := range flows {
+		where := path.Join("flows", f.DisplayName)
+		scene := map[string]interface{}{}
+		routes := f.TransitionRoutes
+		// Welcome intent of a flow becomes the main invocation of the Action.
+		var rest []cxRoute
+		for _, r := range routes {
+			if r.Intent != cxWelcomeIntent {
+				rest = append(rest, r)
+				continue
+			}
+			main := map[string]interface{}{}
+			if h := c.handler(r.TriggerFulfillment, where); h != nil {
+				main["handler"] = h
+			}
+			main["transitionToScene"] = c.scenes[f.DisplayName][""]
+			if t := c.target(f.DisplayName, r, where); t != "" {
+				main["transitionToScene"] = t
+			}
+			if err := c.write(path.Join("custom", "global", "actions.intent.MAIN.yaml"), main); err != nil {
+				return err
+			}
+			actions := map[string]interface{}{"custom": map[string]interface{}{"actions.intent.MAIN": map[string]interface{}{}}}
+			if err := c.write(path.Join("actions", "actions.yaml"), actions); err != nil {
+				return err
+			}
+		}
+		c.addRoutes(scene, f.DisplayName, rest, where)
+		c.reportUnconverted(where, f.EventHandlers, f.TransitionRouteGroups)
+		if err := c.write(path.Join("custom", "scenes", c.scenes[f.DisplayName][""]+".yaml"), scene); err != nil {
+			return err
+		}
+		for _, p := range f.pages {
+			if err := c.convertPage(f.DisplayName, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *cxConverter) convertPage(flow string, p cxPage) error {
+	where := path.Join("flows", flow, "pages", p.DisplayName)
+	scene := map[string]interface{}{}
+	if h := c.handler(p.EntryFulfillment, where); h != nil {
+		scene["onEnter"] = h
+	}
+	var slots []interface{}
+	for _, fp := range p.Form.Parameters {
+		t := map[string]interface{}{"name": c.sdkType(fp.EntityType, where)}
+		if fp.IsList {
+			t["list"] = true
+		}
+		slot := map[string]interface{}{
+			"name":     sdkName(fp.DisplayName),
+			"type":     t,
+			"required": fp.Required,
+		}
+		if h := c.handler(fp.FillBehavior.InitialPromptFulfillment, where); h != nil {
+			slot["promptSettings"] = map[string]interface{}{"initialPrompt": h}
+		}
+		slots = append(slots, slot)
+	}
+	if len(slots) > 0 {
+		scene["slots"] = slots
+	}
+	c.addRoutes(scene, flow, p.TransitionRoutes, where)
+	c.reportUnconverted(where, p.EventHandlers, p.TransitionRouteGroups)
+	return c.write(path.Join("custom", "scenes", c.scenes[flow][p.DisplayName]+".yaml"), scene)
+}
+
+func (c *cxConverter) reportUnconverted(where string, handlers []cxEventHandler, groups []string) {
+	for _, h := range handlers {
+		c.reportf("%v: event handler for %q is not converted; use system intents (e.g. actions.intent.NO_MATCH_1) of the scene", where, h.Event)
+	}
+	for _, g := range groups {
+		c.reportf("%v: transition route group %q is not converted; add its routes to the scene", where, g)
+	}
+}
+
+// addRoutes adds the CX transition routes into the scene as intent and conditional events.
+func (c *cxConverter) addRoutes(scene map[string]interface{}, flow string, routes []cxRoute, where string) {
+	var intentEvents, conditionalEvents []interface{}
+	for _, r := range routes {
+		event := map[string]interface{}{}
+		if h := c.handler(r.TriggerFulfillment, where); h != nil {
+			event["handler"] = h
+		}
+		if t := c.target(flow, r, where); t != "" {
+			event["transitionToScene"] = t
+		}
+		if r.Intent != "" {
+			intent, ok := c.intents[r.Intent]
+			if !ok {
+				c.reportf("%v: route for intent %q is skipped, because the intent wasn't converted", where, r.Intent)
+				continue
+			}
+			if r.Condition != "" {
+				c.reportf("%v: condition %q of the route for intent %q is dropped; check it in the handler", where, r.Condition, r.Intent)
+			}
+			event["intent"] = intent
+			intentEvents = append(intentEvents, event)
+			continue
+		}
+		event["condition"] = c.condition(r.Condition, where)
+		conditionalEvents = append(conditionalEvents, event)
+	}
+	if len(intentEvents) > 0 {
+		scene["intentEvents"] = intentEvents
+	}
+	if len(conditionalEvents) > 0 {
+		scene["conditionalEvents"] = conditionalEvents
+	}
+}
+
+func (c *cxConverter) condition(cond string, where string) string {
+	cond = strings.TrimSpace(cond)
+	switch {
+	case cond == "" || cond == "true":
+		return "true"
+	case slotsStatusRe.MatchString(cond):
+		return `scene.slots.status == "FINAL"`
+	default:
+		c.reportf("%v: condition %q is copied as is; rewrite it using the syntax of Actions SDK", where, cond)
+		return cond
+	}
+}
+
+// target returns a scene to transition to after the route, or an empty string if
+// the route doesn't transition.
+func (c *cxConverter) target(flow string, r cxRoute, where string) string {
+	if r.TargetFlow != "" {
+		if s, ok := c.scenes[r.TargetFlow]; ok {
+			return s[""]
+		}
+		c.reportf("%v: target flow %q is not found", where, r.TargetFlow)
+		return ""
+	}
+	switch r.TargetPage {
+	case "":
+		return ""
+	case "END_SESSION":
+		return endConversation
+	case "START_PAGE":
+		return c.scenes[flow][""]
+	case "END_FLOW", "PREVIOUS_PAGE", "CURRENT_PAGE":
+		c.reportf("%v: transition to %v has no equivalent; set the scene to transition to manually", where, r.TargetPage)
+		return ""
+	}
+	if s, ok := c.scenes[flow][r.TargetPage]; ok {
+		return s
+	}
+	c.reportf("%v: target page %q is not found", where, r.TargetPage)
+	return ""
+}
+
+// handler converts a CX fulfillment into an SDK event handler. It returns nil if there
+// is nothing to handle.
+func (c *cxConverter) handler(f *cxFulfillment, where string) map[string]interface{} {
+	if f == nil {
+		return nil
+	}
+	h := map[string]interface{}{}
+	if f.Tag != "" {
+		h["webhookHandler"] = f.Tag
+		c.reportf("%v: calls a webhook with tag %q; implement the handler in your webhook", where, f.Tag)
+	} else if f.Webhook != "" {
+		c.reportf("%v: calls a webhook without a tag; add a webhook handler to the scene manually", where)
+	}
+	// CX sends each of the messages, where each message has alternative variants of a text.
+	var messages [][]string
+	for _, m := range f.Messages {
+		raw, ok := m["text"]
+		if !ok {
+			for k := range m {
+				c.reportf("%v: %v response message is not converted", where, k)
+			}
+			continue
+		}
+		var text struct {
+			Text []string `json:"text"`
+		}
+		if err := json.Unmarshal(raw, &text); err != nil || len(text.Text) == 0 {
+			continue
+		}
+		messages = append(messages, text.Text)
+	}
+	var speech []string
+	switch {
+	case len(messages) == 1:
+		speech = messages[0]
+	case len(messages) > 1:
+		// Variants of a prompt are alternatives, so messages are joined into a single variant.
+		var parts []string
+		for _, v := range messages {
+			if len(v) > 1 {
+				c.reportf("%v: only the first variant of %q is kept, because the prompt has several messages", where, v)
+			}
+			parts = append(parts, v[0])
+		}
+		speech = []string{strings.Join(parts, " ")}
+	}
+	if len(speech) > 0 {
+		var variants []interface{}
+		for _, s := range speech {
+			variants = append(variants, map[string]interface{}{"speech": s})
+		}
+		h["staticPrompt"] = map[string]interface{}{
+			"candidates": []interface{}{
+				map[string]interface{}{
+					"promptResponse": map[string]interface{}{
+						"firstSimple": map[string]interface{}{"variants": variants},
+					},
+				},
+			},
+		}
+	}
+	if len(f.SetParameterActions) > 0 {
+		c.reportf("%v: parameter presets are not converted; set the session parameters in your webhook", where)
+	}
+	if len(f.ConditionalCases) > 0 {
+		c.reportf("%v: conditional responses are not converted", where)
+	}
+	if len(h) == 0 {
+		return nil
+	}
+	return h
+}
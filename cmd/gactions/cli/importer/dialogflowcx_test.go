@@ -0,0 +1,246 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importer
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+var cxAgentFiles = map[string][]byte{
+	"agent.json": []byte(`{
+  "displayName": "Pizza",
+  "defaultLanguageCode": "en",
+  "supportedLanguageCodes": ["fr"]
+}`),
+	"intents/Default Welcome Intent/Default Welcome Intent.json":   []byte(`{"displayName": "Default Welcome Intent"}`),
+	"intents/Default Negative Intent/Default Negative Intent.json": []byte(`{"displayName": "Default Negative Intent"}`),
+	"intents/order.pizza/order.pizza.json": []byte(`{
+  "displayName": "order.pizza",
+  "parameters": [{"id": "size", "entityType": "@size"}, {"id": "count", "entityType": "@sys.number"}]
+}`),
+	"intents/order.pizza/trainingPhrases/en.json": []byte(`{
+  "trainingPhrases": [
+    {"parts": [{"text": "order a "}, {"text": "large", "parameterId": "size"}, {"text": " pizza"}]},
+    {"parts": [{"text": "I'd like "}, {"text": "two", "parameterId": "count"}]}
+  ]
+}`),
+	"intents/order.pizza/trainingPhrases/fr.json": []byte(`{
+  "trainingPhrases": [{"parts": [{"text": "une pizza"}]}]
+}`),
+	"entityTypes/size/size.json": []byte(`{"displayName": "size", "kind": "KIND_MAP"}`),
+	"entityTypes/size/entities/en.json": []byte(`{
+  "entities": [{"value": "large", "synonyms": ["large", "big"]}]
+}`),
+	"entityTypes/code/code.json": []byte(`{"displayName": "code", "kind": "KIND_REGEXP"}`),
+	"flows/Default Start Flow/Default Start Flow.json": []byte(`{
+  "displayName": "Default Start Flow",
+  "transitionRoutes": [
+    {
+      "intent": "Default Welcome Intent",
+      "triggerFulfillment": {"messages": [{"text": {"text": ["Welcome!"]}}]},
+      "targetPage": "Order"
+    }
+  ],
+  "eventHandlers": [{"event": "sys.no-match-default"}]
+}`),
+	"flows/Default Start Flow/pages/Order.json": []byte(`{
+  "displayName": "Order",
+  "entryFulfillment": {"messages": [{"text": {"text": ["What size?", "Which size?"]}}]},
+  "form": {
+    "parameters": [
+      {
+        "displayName": "size",
+        "entityType": "@size",
+        "required": true,
+        "fillBehavior": {"initialPromptFulfillment": {"messages": [{"text": {"text": ["Pick a size."]}}]}}
+      }
+    ]
+  },
+  "transitionRoutes": [
+    {"condition": "$page.params.status = \"FINAL\"", "targetPage": "END_SESSION"},
+    {"intent": "order.pizza", "triggerFulfillment": {"webhook": "w", "tag": "order"}},
+    {"condition": "$session.params.x > 1", "targetPage": "END_FLOW"}
+  ]
+}`),
+	"webhooks/w.json": []byte(`{"displayName": "w"}`),
+}
+
+func TestConvertDialogflowCX(t *testing.T) {
+	out, report, err := ConvertDialogflowCX(cxAgentFiles)
+	if err != nil {
+		t.Fatalf("ConvertDialogflowCX returned %v, want %v", err, nil)
+	}
+	var names []string
+	for k := range out {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	wantNames := []string{
+		"actions/actions.yaml",
+		"custom/global/actions.intent.MAIN.yaml",
+		"custom/intents/fr/order_pizza.yaml",
+		"custom/intents/order_pizza.yaml",
+		"custom/scenes/Default_Start_Flow.yaml",
+		"custom/scenes/Order.yaml",
+		"custom/types/size.yaml",
+		"manifest.yaml",
+		"settings/fr/settings.yaml",
+		"settings/settings.yaml",
+	}
+	if diff := cmp.Diff(wantNames, names); diff != "" {
+		t.Errorf("ConvertDialogflowCX returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "custom/global/actions.intent.MAIN.yaml",
+			want: `
+handler:
+  staticPrompt:
+    candidates:
+    - promptResponse:
+        firstSimple:
+          variants:
+          - speech: Welcome!
+transitionToScene: Order
+`,
+		},
+		{
+			name: "custom/intents/order_pizza.yaml",
+			want: `
+parameters:
+- name: size
+  type:
+    name: size
+- name: count
+  type:
+    name: actions.type.Number
+trainingPhrases:
+- order a ($size 'large' auto=true) pizza
+- I'd like ($count 'two' auto=true)
+`,
+		},
+		{
+			name: "custom/types/size.yaml",
+			want: `
+synonym:
+  entities:
+    large:
+      synonyms:
+      - large
+      - big
+`,
+		},
+		{
+			name: "custom/scenes/Order.yaml",
+			want: `
+conditionalEvents:
+- condition: scene.slots.status == "FINAL"
+  transitionToScene: actions.scene.END_CONVERSATION
+- condition: $session.params.x > 1
+intentEvents:
+- handler:
+    webhookHandler: order
+  intent: order_pizza
+onEnter:
+  staticPrompt:
+    candidates:
+    - promptResponse:
+        firstSimple:
+          variants:
+          - speech: What size?
+          - speech: Which size?
+slots:
+- name: size
+  promptSettings:
+    initialPrompt:
+      staticPrompt:
+        candidates:
+        - promptResponse:
+            firstSimple:
+              variants:
+              - speech: Pick a size.
+  required: true
+  type:
+    name: size
+`,
+		},
+	}
+	for _, tc := range tests {
+		var want, got interface{}
+		if err := yaml.Unmarshal([]byte(tc.want), &want); err != nil {
+			t.Fatalf("Can't unmarshal %v: %v", tc.want, err)
+		}
+		if err := yaml.Unmarshal(out[tc.name], &got); err != nil {
+			t.Errorf("ConvertDialogflowCX returned %v with incorrect syntax: %v", tc.name, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ConvertDialogflowCX incorrectly converted %v: diff (-want, +got)\n%s", tc.name, diff)
+		}
+	}
+	wantReport := []string{
+		"placeholder_project",
+		"code",
+		"Default Negative Intent",
+		"sys.no-match-default",
+		"tag \"order\"",
+		"$session.params.x > 1",
+		"END_FLOW",
+		"webhooks/w.json",
+	}
+	for _, v := range wantReport {
+		found := false
+		for _, r := range report {
+			if strings.Contains(r, v) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("ConvertDialogflowCX returned a report without %q: %v", v, report)
+		}
+	}
+}
+
+func TestConvertDialogflowCXWithoutDefaultLanguage(t *testing.T) {
+	files := map[string][]byte{
+		"agent.json": []byte(`{"displayName": "Pizza"}`),
+	}
+	if _, _, err := ConvertDialogflowCX(files); err == nil {
+		t.Errorf("ConvertDialogflowCX returned %v, but want an error", err)
+	}
+}
+
+func TestSdkName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "order.pizza", want: "order_pizza"},
+		{in: "Default Start Flow", want: "Default_Start_Flow"},
+		{in: " Ask for size? ", want: "Ask_for_size"},
+	}
+	for _, tc := range tests {
+		if got := sdkName(tc.in); got != tc.want {
+			t.Errorf("sdkName(%q) returned %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
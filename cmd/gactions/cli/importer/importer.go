@@ -0,0 +1,96 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package importer provides an implementation of "gactions import" command.
+// Note: "import" is a keyword, so it can't be used as a package name.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the import sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	imp := &cobra.Command{
+		Use:   "import",
+		Short: "Import an agent built with another tool into an Actions SDK project.",
+		Long:  "This command converts an agent built with another tool into the files of an Actions SDK project.",
+	}
+	cx := &cobra.Command{
+		Use:   "dialogflow-cx <agent-export>",
+		Short: "Convert an exported Dialogflow CX agent into an Actions SDK project.",
+		Long: "This command converts flows, pages, intents and entity types of an exported Dialogflow CX agent (directory or zip file in JSON package format) into scenes, intents and types of an Actions SDK project. " +
+			"Constructs of the agent that need your attention are listed in a migration report.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doImportDialogflowCX(cmd, args, proj)
+		},
+	}
+	cx.Flags().String("dest", "", "Specify a directory for placing the project files (the default directory is the root of the current project, or \".\" outside of a project)")
+	cx.Flags().Bool("force", false, "Overwrite existing project files without asking.")
+	imp.AddCommand(cx)
+	root.AddCommand(imp)
+}
+
+func doImportDialogflowCX(cmd *cobra.Command, args []string, proj project.Project) error {
+	dest, err := cmd.Flags().GetString("dest")
+	if err != nil {
+		return err
+	}
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	if dest == "" {
+		dest = proj.ProjectRoot()
+	}
+	if dest == "" {
+		dest = "."
+	}
+	files, err := ReadAgentExport(args[0])
+	if err != nil {
+		return err
+	}
+	out, report, err := ConvertDialogflowCX(files)
+	if err != nil {
+		return err
+	}
+	log.Outf("Writing %v files converted from %v to %v\n", len(out), args[0], dest)
+	var names []string
+	for k := range out {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	p := studio.New([]byte{}, dest)
+	for _, k := range names {
+		if err := studio.WriteToDisk(p, k, "", out[k], force); err != nil {
+			return err
+		}
+	}
+	if len(report) > 0 {
+		log.Outf("\nMigration report: %v items need manual attention.\n", len(report))
+		for i, v := range report {
+			log.Outf("%v) %v\n", i+1, v)
+		}
+	}
+	log.DoneMsgln(fmt.Sprintf("Converted the agent. Review the files in %v, then run \"gactions push\".", dest))
+	return nil
+}
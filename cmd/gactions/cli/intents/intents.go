@@ -0,0 +1,197 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package intents provides an implementation of "gactions intents" command.
+package intents
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// annotationRegExp matches a parameter annotation of a training phrase,
+// e.g. ($size 'large' auto=true).
+var annotationRegExp = regexp.MustCompile(`\(\$([A-Za-z0-9_]+)\s+'((?:[^'\\]|\\.)*)'(?:\s+auto=(?:true|false))?\s*\)`)
+
+// csvHeader is the first row of the exported CSV file.
+var csvHeader = []string{"intent", "locale", "training_phrase", "annotated_phrase", "parameters"}
+
+// AddCommand adds the intents sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	intents := &cobra.Command{
+		Use:   "intents",
+		Short: "This is the main command for working with the intents of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the intents of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export the training phrases of all intents.",
+		Long: "This command exports the training phrases of all intents in the local project, one row per intent, locale and training phrase. " +
+			"Each row contains the phrase without annotations, the phrase as written in the project, and the annotated parameters with their values.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			format, err := cmd.Flags().GetString("file-format")
+			if err != nil {
+				return err
+			}
+			if format != "csv" {
+				return fmt.Errorf("unsupported --file-format %q: only csv is supported", format)
+			}
+			out, err := cmd.Flags().GetString("out")
+			if err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			rows, err := phraseRows(files)
+			if err != nil {
+				return err
+			}
+			if out == "" {
+				return writeCSV(os.Stdout, rows)
+			}
+			f, err := os.Create(out)
+			if err != nil {
+				return err
+			}
+			if err := writeCSV(f, rows); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			log.DoneMsgln(fmt.Sprintf("Exported %v training phrases to %v.", len(rows), out))
+			return nil
+		},
+	}
+	export.Flags().String("file-format", "csv", "Format of the exported phrases. Only csv is supported.")
+	export.Flags().String("out", "", "Write the exported phrases to the file instead of the standard output.")
+	intents.AddCommand(export)
+	root.AddCommand(intents)
+}
+
+// phraseRow is a training phrase of an intent in a given locale.
+type phraseRow struct {
+	intent    string
+	locale    string
+	phrase    string
+	annotated string
+	params    []string
+}
+
+// phraseRows returns the training phrases of the intents in files, sorted by
+// intent and locale. Phrases keep the order of the intent file.
+func phraseRows(files map[string][]byte) ([]phraseRow, error) {
+	defaultLocale, err := studio.DefaultLocale(files)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for k := range files {
+		if studio.IsIntent(k) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	var rows []phraseRow
+	for _, k := range names {
+		intent, locale := intentLocale(k)
+		if locale == "" {
+			locale = defaultLocale
+		}
+		var in struct {
+			TrainingPhrases []string `yaml:"trainingPhrases"`
+		}
+		if err := yaml.Unmarshal(files[k], &in); err != nil {
+			return nil, fmt.Errorf("%v has incorrect syntax: %v", k, err)
+		}
+		for _, v := range in.TrainingPhrases {
+			phrase, params := parsePhrase(v)
+			rows = append(rows, phraseRow{
+				intent:    intent,
+				locale:    locale,
+				phrase:    phrase,
+				annotated: v,
+				params:    params,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].intent != rows[j].intent {
+			return rows[i].intent < rows[j].intent
+		}
+		return rows[i].locale < rows[j].locale
+	})
+	return rows, nil
+}
+
+// intentLocale returns the name of the intent declared in filename and its
+// locale. The locale is empty for the files of the default locale.
+func intentLocale(filename string) (string, string) {
+	rel := strings.TrimPrefix(filename, path.Join("custom", "intents")+"/")
+	name := strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	if dir := path.Dir(rel); dir != "." {
+		return name, dir
+	}
+	return name, ""
+}
+
+// parsePhrase returns the text of a training phrase without annotations, and
+// the annotated parameters with their values in the "name=value" form.
+func parsePhrase(s string) (string, []string) {
+	var params []string
+	text := annotationRegExp.ReplaceAllStringFunc(s, func(m string) string {
+		sub := annotationRegExp.FindStringSubmatch(m)
+		v := strings.ReplaceAll(sub[2], `\'`, `'`)
+		params = append(params, sub[1]+"="+v)
+		return v
+	})
+	return text, params
+}
+
+func writeCSV(w io.Writer, rows []phraseRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write([]string{r.intent, r.locale, r.phrase, r.annotated, strings.Join(r.params, "; ")}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intents
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePhrase(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantPhrase string
+		wantParams []string
+	}{
+		{
+			in:         "order a pizza",
+			wantPhrase: "order a pizza",
+		},
+		{
+			in:         "order a ($size 'large' auto=true) pizza",
+			wantPhrase: "order a large pizza",
+			wantParams: []string{"size=large"},
+		},
+		{
+			in:         "($count 'two' auto=false) ($size 'king\\'s size' auto=true) pizzas",
+			wantPhrase: "two king's size pizzas",
+			wantParams: []string{"count=two", "size=king's size"},
+		},
+	}
+	for _, tc := range tests {
+		phrase, params := parsePhrase(tc.in)
+		if phrase != tc.wantPhrase {
+			t.Errorf("parsePhrase(%q) returned phrase %q, want %q", tc.in, phrase, tc.wantPhrase)
+		}
+		if diff := cmp.Diff(tc.wantParams, params); diff != "" {
+			t.Errorf("parsePhrase(%q) returned incorrect params: diff (-want, +got)\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml": []byte("defaultLocale: en\nprojectId: foo\n"),
+		"custom/intents/order.yaml": []byte(`parameters:
+- name: size
+  type:
+    name: size
+trainingPhrases:
+- order a ($size 'large' auto=true) pizza
+- I'm hungry, "now"
+`),
+		"custom/intents/fr/order.yaml": []byte(`trainingPhrases:
+- une ($size 'grande' auto=true) pizza
+`),
+		"custom/intents/cancel.yaml": []byte(`trainingPhrases:
+- cancel
+`),
+		"custom/types/size.yaml": []byte("synonym: {}\n"),
+	}
+	rows, err := phraseRows(files)
+	if err != nil {
+		t.Fatalf("phraseRows returned %v, want %v", err, nil)
+	}
+	var b bytes.Buffer
+	if err := writeCSV(&b, rows); err != nil {
+		t.Fatalf("writeCSV returned %v, want %v", err, nil)
+	}
+	want := `intent,locale,training_phrase,annotated_phrase,parameters
+cancel,en,cancel,cancel,
+order,en,order a large pizza,order a ($size 'large' auto=true) pizza,size=large
+order,en,"I'm hungry, ""now""","I'm hungry, ""now""",
+order,fr,une grande pizza,une ($size 'grande' auto=true) pizza,size=grande
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeCSV returned incorrect output: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestPhraseRowsWithoutSettings(t *testing.T) {
+	files := map[string][]byte{
+		"custom/intents/cancel.yaml": []byte("trainingPhrases:\n- cancel\n"),
+	}
+	if _, err := phraseRows(files); err == nil {
+		t.Errorf("phraseRows returned %v, but want an error", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Kinds of the issues found by "locales check".
+const (
+	untranslated    = "untranslated"
+	orphaned        = "orphaned"
+	missingSettings = "missingSettings"
+)
+
+// translationIssue is an issue found by "locales check", in the table, json and yaml output
+// formats.
+type translationIssue struct {
+	Locale string `json:"locale" yaml:"locale"`
+	Kind   string `json:"kind" yaml:"kind"`
+	// File is the path of the file of the default locale with the key, or of the file of the
+	// locale for orphaned keys.
+	File string `json:"file" yaml:"file"`
+	// Key is the key of the resource bundle, or empty for issues of whole files.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+}
+
+func addCheckCommand(locales *cobra.Command, proj project.Project) {
+	check := &cobra.Command{
+		Use:   "check [<locale>...]",
+		Short: "Report the missing translations of the locales of the project.",
+		Long: "This command compares the resource bundles and prompts of the default locale with the ones of the other locales, or of the locales passed as arguments, and reports " +
+			"untranslated keys and prompts, which only the default locale has, orphaned keys and prompts, which the default locale doesn't have, and locales without settings/<locale>/settings.yaml. " +
+			"It fails if any issues are found, so CI pipelines can catch them.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			locales := args
+			if len(locales) == 0 {
+				l, err := projectLocales(files, def)
+				if err != nil {
+					return err
+				}
+				for _, v := range l {
+					if !v.Default {
+						locales = append(locales, v.Locale)
+					}
+				}
+			}
+			var issues []translationIssue
+			for _, l := range locales {
+				if l == def {
+					return fmt.Errorf("%v is the default locale, which the other locales are checked against", l)
+				}
+				res, err := checkLocale(files, def, l)
+				if err != nil {
+					return err
+				}
+				issues = append(issues, res...)
+			}
+			if err := printIssues(cmd.OutOrStdout(), issues); err != nil {
+				return err
+			}
+			if len(issues) > 0 {
+				return fmt.Errorf("found %d translation issues", len(issues))
+			}
+			log.DoneMsgln(fmt.Sprintf("Checked %d locales, no translation issues found.", len(locales)))
+			return nil
+		},
+	}
+	locales.AddCommand(check)
+}
+
+// checkLocale returns the translation issues of locale against the default locale def.
+func checkLocale(files map[string][]byte, def, locale string) ([]translationIssue, error) {
+	base, err := localizedFiles(files, def, def)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := localizedFiles(files, def, locale)
+	if err != nil {
+		return nil, err
+	}
+	var res []translationIssue
+	if _, ok := files[localize(path.Join(settingsDir, settingsFile), locale)]; !ok {
+		res = append(res, translationIssue{Locale: locale, Kind: missingSettings, File: localize(path.Join(settingsDir, settingsFile), locale)})
+	}
+	var names []string
+	for k := range base {
+		names = append(names, k)
+	}
+	for k := range loc {
+		if _, ok := base[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		isBundle := strings.HasPrefix(k, stringsDir+"/")
+		if !isBundle && !strings.HasPrefix(k, promptsDir+"/") {
+			continue
+		}
+		b, inBase := base[k]
+		l, inLocale := loc[k]
+		switch {
+		case !inLocale:
+			res = append(res, translationIssue{Locale: locale, Kind: untranslated, File: baseFile(files, def, k)})
+		case !inBase:
+			res = append(res, translationIssue{Locale: locale, Kind: orphaned, File: localize(k, locale)})
+		case isBundle:
+			baseKeys, err := bundleKeys(baseFile(files, def, k), b)
+			if err != nil {
+				return nil, err
+			}
+			locKeys, err := bundleKeys(localize(k, locale), l)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range missingKeys(baseKeys, locKeys) {
+				res = append(res, translationIssue{Locale: locale, Kind: untranslated, File: baseFile(files, def, k), Key: key})
+			}
+			for _, key := range missingKeys(locKeys, baseKeys) {
+				res = append(res, translationIssue{Locale: locale, Kind: orphaned, File: localize(k, locale), Key: key})
+			}
+		}
+	}
+	return res, nil
+}
+
+// baseFile returns the path of the file name, which is relative to a locale directory, in the
+// default locale def: the file which isn't localized, or the file in the directory of def.
+func baseFile(files map[string][]byte, def, name string) string {
+	if _, ok := files[name]; ok {
+		return name
+	}
+	return localize(name, def)
+}
+
+// bundleKeys returns the keys of the resource bundle name with the contents b, in the order of
+// the file.
+func bundleKeys(name string, b []byte) ([]string, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%v has incorrect syntax: %v", name, err)
+	}
+	var res []string
+	for _, item := range m {
+		res = append(res, fmt.Sprint(item.Key))
+	}
+	return res, nil
+}
+
+// missingKeys returns the keys of a which b doesn't have, in the order of a.
+func missingKeys(a, b []string) []string {
+	in := map[string]bool{}
+	for _, k := range b {
+		in[k] = true
+	}
+	var res []string
+	for _, k := range a {
+		if !in[k] {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+func printIssues(out io.Writer, issues []translationIssue) error {
+	if issues == nil {
+		issues = []translationIssue{}
+	}
+	return log.Render(out, issues, func(out io.Writer) error {
+		if len(issues) == 0 {
+			return nil
+		}
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "Locale\tIssue\tFile\tKey\t")
+		for _, v := range issues {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t\n", v.Locale, v.Kind, v.File, v.Key)
+		}
+		return w.Flush()
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckLocale(t *testing.T) {
+	files := map[string][]byte{}
+	for k, v := range testFiles {
+		files[k] = v
+	}
+	files["resources/strings/bundle.yaml"] = []byte("greeting: Hi\nfarewell: Bye\nhelp: Say yes or no\n")
+	files["resources/strings/fr/bundle.yaml"] = []byte("greeting: Salut\nfarewell: Au revoir\nhelp: Dites oui ou non\n")
+	files["resources/strings/de/bundle.yaml"] = []byte("greeting: Hallo\nthanks: Danke\n")
+	files["custom/prompts/de/extra.yaml"] = []byte("candidates:\n- first_simple:\n    variants:\n    - speech: Extra\n")
+	tests := []struct {
+		locale string
+		want   []translationIssue
+	}{
+		{
+			locale: "de",
+			want: []translationIssue{
+				{Locale: "de", Kind: untranslated, File: "custom/prompts/en/bye.yaml"},
+				{Locale: "de", Kind: orphaned, File: "custom/prompts/de/extra.yaml"},
+				{Locale: "de", Kind: untranslated, File: "resources/strings/bundle.yaml", Key: "farewell"},
+				{Locale: "de", Kind: untranslated, File: "resources/strings/bundle.yaml", Key: "help"},
+				{Locale: "de", Kind: orphaned, File: "resources/strings/de/bundle.yaml", Key: "thanks"},
+			},
+		},
+		{
+			locale: "fr",
+			want: []translationIssue{
+				{Locale: "fr", Kind: missingSettings, File: "settings/fr/settings.yaml"},
+				{Locale: "fr", Kind: untranslated, File: "custom/prompts/en/bye.yaml"},
+				{Locale: "fr", Kind: untranslated, File: "custom/prompts/welcome.yaml"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		got, err := checkLocale(files, "en", tc.locale)
+		if err != nil {
+			t.Fatalf("checkLocale(%v) returned %v, want %v", tc.locale, err, nil)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("checkLocale(%v) returned diff (-want, +got)\n%s", tc.locale, diff)
+		}
+	}
+}
+
+func TestPrintIssues(t *testing.T) {
+	og := log.Format
+	defer func() { log.Format = og }()
+	log.Format = log.JSONFormat
+	var b bytes.Buffer
+	issues := []translationIssue{
+		{Locale: "fr", Kind: missingSettings, File: "settings/fr/settings.yaml"},
+		{Locale: "fr", Kind: untranslated, File: "resources/strings/bundle.yaml", Key: "help"},
+	}
+	if err := printIssues(&b, issues); err != nil {
+		t.Fatalf("printIssues returned %v, want %v", err, nil)
+	}
+	want := `[
+  {
+    "locale": "fr",
+    "kind": "missingSettings",
+    "file": "settings/fr/settings.yaml"
+  },
+  {
+    "locale": "fr",
+    "kind": "untranslated",
+    "file": "resources/strings/bundle.yaml",
+    "key": "help"
+  }
+]
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printIssues wrote diff (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	promptsDir   = "custom/prompts"
+	intentsDir   = "custom/intents"
+	typesDir     = "custom/types"
+	stringsDir   = "resources/strings"
+	settingsDir  = "settings"
+	settingsFile = "settings.yaml"
+)
+
+// localizedDirs are the directories which have a sub-directory for each locale.
+var localizedDirs = []string{promptsDir, intentsDir, typesDir, stringsDir, settingsDir}
+
+// localizedKeys are the keys of the intents and types which are localized. The other keys of
+// the files of the default locale are not allowed in the files of other locales.
+var localizedKeys = map[string]string{
+	intentsDir: "trainingPhrases",
+	typesDir:   "synonym",
+}
+
+// localizedFiles returns the prompts, intents, types, resource bundles and localized settings of
+// locale, with paths relative to the locale directories (e.g. custom/prompts/welcome.yaml for
+// custom/prompts/en/welcome.yaml). def is the default locale of the project, whose files
+// are the ones which are not localized, unless the project also has a localized version.
+func localizedFiles(files map[string][]byte, def, locale string) (map[string][]byte, error) {
+	res := map[string][]byte{}
+	if locale == def {
+		for k, v := range files {
+			dir, _ := path.Split(k)
+			switch {
+			case (dir == promptsDir+"/" && studio.IsPrompt(k)) || (dir == stringsDir+"/" && studio.IsResourceBundle(k)):
+				res[k] = v
+			case (dir == intentsDir+"/" && studio.IsIntent(k)) || (dir == typesDir+"/" && studio.IsType(k)):
+				b, err := localizedKey(k, v, localizedKeys[path.Clean(dir)])
+				if err != nil {
+					return nil, err
+				}
+				if b != nil {
+					res[k] = b
+				}
+			}
+		}
+		b, err := localizedSettings(files[path.Join(settingsDir, settingsFile)])
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			res[path.Join(settingsDir, settingsFile)] = b
+		}
+	}
+	for k, v := range files {
+		for _, dir := range localizedDirs {
+			rest := strings.TrimPrefix(k, dir+"/"+locale+"/")
+			if rest == k || path.Ext(rest) != ".yaml" {
+				continue
+			}
+			if dir == settingsDir && rest != settingsFile {
+				continue
+			}
+			res[dir+"/"+rest] = v
+		}
+	}
+	return res, nil
+}
+
+// localizedSettings returns a localized settings file with the localizedSettings of the
+// settings b, or nil if b has no localizedSettings.
+func localizedSettings(b []byte) ([]byte, error) {
+	return localizedKey(path.Join(settingsDir, settingsFile), b, "localizedSettings")
+}
+
+// localizedKey returns a file with only key of the file name with the contents b, or nil if b
+// doesn't have key.
+func localizedKey(name string, b []byte, key string) ([]byte, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%v has incorrect syntax: %v", name, err)
+	}
+	for _, item := range m {
+		if item.Key == key {
+			return yaml.Marshal(yaml.MapSlice{item})
+		}
+	}
+	return nil, nil
+}
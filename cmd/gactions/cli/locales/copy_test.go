@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testFiles = map[string][]byte{
+	"manifest.yaml":                    []byte("version: \"1.0\"\n"),
+	"settings/settings.yaml":           []byte("defaultLocale: en\nprojectId: test\nlocalizedSettings:\n  displayName: Test\n  pronunciation: Test\n"),
+	"settings/de/settings.yaml":        []byte("localizedSettings:\n  displayName: Prüfung\n"),
+	"custom/prompts/welcome.yaml":      []byte("candidates:\n- first_simple:\n    variants:\n    - speech: Hi\n"),
+	"custom/prompts/en/bye.yaml":       []byte("candidates:\n- first_simple:\n    variants:\n    - speech: Bye\n"),
+	"custom/prompts/de/welcome.yaml":   []byte("candidates:\n- first_simple:\n    variants:\n    - speech: Hallo\n"),
+	"custom/scenes/Main.yaml":          []byte("onEnter:\n  staticPrompt: welcome\n"),
+	"resources/strings/bundle.yaml":    []byte("greeting: Hi\n"),
+	"resources/strings/de/bundle.yaml": []byte("greeting: Hallo\n"),
+	"resources/images/logo.png":        []byte("png"),
+}
+
+func TestLocalizedFiles(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   map[string]string
+	}{
+		{
+			locale: "en",
+			want: map[string]string{
+				"settings/settings.yaml":        "localizedSettings:\n  displayName: Test\n  pronunciation: Test\n",
+				"custom/prompts/welcome.yaml":   "candidates:\n- first_simple:\n    variants:\n    - speech: Hi\n",
+				"custom/prompts/bye.yaml":       "candidates:\n- first_simple:\n    variants:\n    - speech: Bye\n",
+				"resources/strings/bundle.yaml": "greeting: Hi\n",
+			},
+		},
+		{
+			locale: "de",
+			want: map[string]string{
+				"settings/settings.yaml":        "localizedSettings:\n  displayName: Prüfung\n",
+				"custom/prompts/welcome.yaml":   "candidates:\n- first_simple:\n    variants:\n    - speech: Hallo\n",
+				"resources/strings/bundle.yaml": "greeting: Hallo\n",
+			},
+		},
+		{
+			locale: "fr",
+			want:   map[string]string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.locale, func(t *testing.T) {
+			res, err := localizedFiles(testFiles, "en", tc.locale)
+			if err != nil {
+				t.Fatalf("localizedFiles returned %v, want %v", err, nil)
+			}
+			got := map[string]string{}
+			for k, v := range res {
+				got[k] = string(v)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("localizedFiles returned diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCopyLocale(t *testing.T) {
+	src, err := localizedFiles(testFiles, "en", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for k, v := range testFiles {
+		files[k] = v
+	}
+	files["custom/prompts/en-GB/welcome.yaml"] = []byte("candidates:\n- first_simple:\n    variants:\n    - speech: Hiya\n")
+	tests := []struct {
+		name        string
+		overwrite   bool
+		wantCopied  []string
+		wantSkipped []string
+		wantWelcome string
+	}{
+		{
+			name:        "keeps existing files",
+			wantCopied:  []string{"custom/prompts/en-GB/bye.yaml", "resources/strings/en-GB/bundle.yaml", "settings/en-GB/settings.yaml"},
+			wantSkipped: []string{"custom/prompts/en-GB/welcome.yaml"},
+			wantWelcome: "candidates:\n- first_simple:\n    variants:\n    - speech: Hiya\n",
+		},
+		{
+			name:        "overwrite",
+			overwrite:   true,
+			wantCopied:  []string{"custom/prompts/en-GB/bye.yaml", "custom/prompts/en-GB/welcome.yaml", "resources/strings/en-GB/bundle.yaml", "settings/en-GB/settings.yaml"},
+			wantWelcome: "candidates:\n- first_simple:\n    variants:\n    - speech: Hi\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "locales")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			welcome := filepath.Join(root, "custom", "prompts", "en-GB", "welcome.yaml")
+			if err := os.MkdirAll(filepath.Dir(welcome), 0750); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(welcome, files["custom/prompts/en-GB/welcome.yaml"], 0640); err != nil {
+				t.Fatal(err)
+			}
+			copied, skipped, err := copyLocale(root, files, src, "en-GB", tc.overwrite)
+			if err != nil {
+				t.Fatalf("copyLocale returned %v, want %v", err, nil)
+			}
+			if diff := cmp.Diff(tc.wantCopied, copied); diff != "" {
+				t.Errorf("copyLocale returned incorrect copied files: diff (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantSkipped, skipped); diff != "" {
+				t.Errorf("copyLocale returned incorrect skipped files: diff (-want, +got)\n%s", diff)
+			}
+			b, err := ioutil.ReadFile(welcome)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.wantWelcome {
+				t.Errorf("copyLocale wrote %q, want %q", b, tc.wantWelcome)
+			}
+			b, err = ioutil.ReadFile(filepath.Join(root, "settings", "en-GB", "settings.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "localizedSettings:\n  displayName: Test\n  pronunciation: Test\n"; string(b) != want {
+				t.Errorf("copyLocale wrote %q, want %q", b, want)
+			}
+		})
+	}
+}
+
+func TestCheckLocales(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      []string
+		wantErr bool
+	}{
+		{from: "en", to: []string{"en-GB", "en-AU"}},
+		{from: "en", to: []string{"en"}, wantErr: true},
+		{from: "en", to: []string{"en-GB", "en-GB"}, wantErr: true},
+		{from: "en", to: []string{"../en"}, wantErr: true},
+		{from: "EN", to: []string{"en-GB"}, wantErr: true},
+		{from: "en", to: nil, wantErr: true},
+	}
+	for _, tc := range tests {
+		if err := checkLocales(tc.from, tc.to); (err != nil) != tc.wantErr {
+			t.Errorf("checkLocales(%q, %v) returned %v, want error %v", tc.from, tc.to, err, tc.wantErr)
+		}
+	}
+}
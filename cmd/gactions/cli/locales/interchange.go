@@ -0,0 +1,513 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of "locales export" and "locales import".
+const (
+	xliffFormat = "xliff"
+	csvFormat   = "csv"
+)
+
+// itemIDRegExp matches the ID of an item of a list in a resource bundle, i.e. greetings[1].
+var itemIDRegExp = regexp.MustCompile(`^(.+)\[(\d+)\]$`)
+
+func addInterchangeCommands(locales *cobra.Command, proj project.Project) {
+	export := &cobra.Command{
+		Use:   "export [<locale>...]",
+		Short: "Export the resource bundles for translation, in XLIFF or CSV.",
+		Long: "This command exports the strings of the resource bundles of the default locale with their translations in the other locales, or in the locales passed as arguments, so localization vendors can translate them. " +
+			"With --format xliff, an XLIFF 1.2 file is written for each locale, named <locale>.xlf. With --format csv, a single strings.csv is written with a column for each locale. " +
+			"The strings keep the order of the bundles of the default locale, and the items of lists have IDs like greetings[0]. Import the translated files with \"locales import\".",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			out, err := cmd.Flags().GetString("out")
+			if err != nil {
+				return err
+			}
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			locales := args
+			if len(locales) == 0 {
+				l, err := projectLocales(files, def)
+				if err != nil {
+					return err
+				}
+				for _, v := range l {
+					if !v.Default {
+						locales = append(locales, v.Locale)
+					}
+				}
+			}
+			for _, l := range locales {
+				if !localeRegExp.MatchString(l) {
+					return fmt.Errorf("%q is not a valid locale", l)
+				}
+				if l == def {
+					return fmt.Errorf("%v is the default locale, whose strings are the source of the translations", l)
+				}
+			}
+			if len(locales) == 0 {
+				return fmt.Errorf("the project has no locales besides %v. Pass the locales to translate to, or add them with \"locales add\"", def)
+			}
+			bundles, err := readBundles(files, def, locales)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(out, 0750); err != nil {
+				return err
+			}
+			written, err := exportBundles(out, format, def, locales, bundles)
+			if err != nil {
+				return err
+			}
+			for _, fp := range written {
+				log.Outf("Wrote %v\n", fp)
+			}
+			log.DoneMsgln(fmt.Sprintf("Exported the strings of %d resource bundles for %v.", len(bundles), strings.Join(locales, ", ")))
+			return nil
+		},
+	}
+	export.Flags().String("format", xliffFormat, fmt.Sprintf("Format of the exported files: %v or %v.", xliffFormat, csvFormat))
+	export.Flags().String("out", ".", "Directory to write the exported files to.")
+	locales.AddCommand(export)
+	imp := &cobra.Command{
+		Use:   "import <file>...",
+		Short: "Import translated resource bundles from XLIFF or CSV files.",
+		Long: "This command writes the translations of XLIFF (.xlf or .xliff) or CSV (.csv) files exported by \"locales export\" to resources/strings/<locale>. " +
+			"Translations replace the strings of the bundles of the locales, and strings without a translation are kept. The keys are written in the order of the bundles of the default locale.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			tr := translations{}
+			for _, fp := range args {
+				if err := readTranslations(fp, def, tr); err != nil {
+					return err
+				}
+			}
+			written, err := importTranslations(proj.ProjectRoot(), files, def, tr)
+			if err != nil {
+				return err
+			}
+			for _, k := range written {
+				log.Outf("Wrote %v\n", k)
+			}
+			log.DoneMsgln(fmt.Sprintf("Imported the translations to %d resource bundles.", len(written)))
+			return nil
+		},
+	}
+	locales.AddCommand(imp)
+}
+
+// bundle is a resource bundle of the default locale with its translations.
+type bundle struct {
+	// name is the path of the bundle relative to the locale directories, i.e.
+	// resources/strings/bundle.yaml.
+	name string
+	// ids are the IDs of the strings of the bundle in the default locale, in the order of the
+	// file.
+	ids    []string
+	source map[string]string
+	// targets are the strings of the bundle by locale.
+	targets map[string]map[string]string
+}
+
+// translations are the imported strings by locale, bundle and ID.
+type translations map[string]map[string]map[string]string
+
+func (t translations) set(locale, bundle, id, s string) {
+	if t[locale] == nil {
+		t[locale] = map[string]map[string]string{}
+	}
+	if t[locale][bundle] == nil {
+		t[locale][bundle] = map[string]string{}
+	}
+	t[locale][bundle][id] = s
+}
+
+// flattenBundle returns the IDs of the strings of the resource bundle name with the contents b,
+// in the order of the file, and the strings by ID. The items of a list have the IDs key[i].
+// Values which are not strings or lists of strings can't be translated, and are skipped.
+func flattenBundle(name string, b []byte) ([]string, map[string]string, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, nil, fmt.Errorf("%v has incorrect syntax: %v", name, err)
+	}
+	var ids []string
+	res := map[string]string{}
+	for _, item := range m {
+		key := fmt.Sprint(item.Key)
+		switch v := item.Value.(type) {
+		case string:
+			ids = append(ids, key)
+			res[key] = v
+		case []interface{}:
+			for i, e := range v {
+				s, ok := e.(string)
+				if !ok {
+					log.Warnf("Skipping %v[%d] of %v, which is not a string.\n", key, i, name)
+					continue
+				}
+				id := fmt.Sprintf("%v[%d]", key, i)
+				ids = append(ids, id)
+				res[id] = s
+			}
+		default:
+			log.Warnf("Skipping %v of %v, which is not a string or a list of strings.\n", key, name)
+		}
+	}
+	return ids, res, nil
+}
+
+// unflattenBundle returns a resource bundle with the strings of ids which have a value in
+// values, in the order of ids. Items of lists are gathered in the list of their key.
+func unflattenBundle(ids []string, values map[string]string) ([]byte, error) {
+	var res yaml.MapSlice
+	index := map[string]int{}
+	for _, id := range ids {
+		s, ok := values[id]
+		if !ok {
+			continue
+		}
+		key, isItem := id, false
+		if m := itemIDRegExp.FindStringSubmatch(id); m != nil {
+			key, isItem = m[1], true
+		}
+		i, seen := index[key]
+		switch {
+		case !seen && isItem:
+			index[key] = len(res)
+			res = append(res, yaml.MapItem{Key: key, Value: []string{s}})
+		case !seen:
+			index[key] = len(res)
+			res = append(res, yaml.MapItem{Key: key, Value: s})
+		case isItem:
+			if l, ok := res[i].Value.([]string); ok {
+				res[i].Value = append(l, s)
+			}
+		}
+	}
+	return yaml.Marshal(res)
+}
+
+// sortIDs sorts the IDs of strings by their keys, and the items of a list by their index, so
+// that key[10] comes after key[9].
+func sortIDs(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, x := splitID(ids[i])
+		b, y := splitID(ids[j])
+		if a != b {
+			return a < b
+		}
+		return x < y
+	})
+}
+
+// splitID returns the key and the index of the item of a list with id, or -1 if id is the ID of
+// a string.
+func splitID(id string) (string, int) {
+	if m := itemIDRegExp.FindStringSubmatch(id); m != nil {
+		if n, err := strconv.Atoi(m[2]); err == nil {
+			return m[1], n
+		}
+	}
+	return id, -1
+}
+
+// readBundles returns the resource bundles of the default locale def, sorted by name, with
+// their translations in locales.
+func readBundles(files map[string][]byte, def string, locales []string) ([]bundle, error) {
+	base, err := localizedFiles(files, def, def)
+	if err != nil {
+		return nil, err
+	}
+	var res []bundle
+	for k, v := range base {
+		if !strings.HasPrefix(k, stringsDir+"/") {
+			continue
+		}
+		ids, source, err := flattenBundle(baseFile(files, def, k), v)
+		if err != nil {
+			return nil, err
+		}
+		b := bundle{name: k, ids: ids, source: source, targets: map[string]map[string]string{}}
+		for _, l := range locales {
+			b.targets[l] = map[string]string{}
+			if t, ok := files[localize(k, l)]; ok {
+				if _, b.targets[l], err = flattenBundle(localize(k, l), t); err != nil {
+					return nil, err
+				}
+			}
+		}
+		res = append(res, b)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res, nil
+}
+
+// exportBundles writes bundles in format to the directory out, and returns the paths of the
+// written files.
+func exportBundles(out, format, def string, locales []string, bundles []bundle) ([]string, error) {
+	switch format {
+	case xliffFormat:
+		var res []string
+		for _, l := range locales {
+			fp := filepath.Join(out, l+".xlf")
+			if err := writeFile(fp, func(w io.Writer) error { return writeXLIFF(w, def, l, bundles) }); err != nil {
+				return nil, err
+			}
+			res = append(res, fp)
+		}
+		return res, nil
+	case csvFormat:
+		fp := filepath.Join(out, "strings.csv")
+		if err := writeFile(fp, func(w io.Writer) error { return writeCSV(w, def, locales, bundles) }); err != nil {
+			return nil, err
+		}
+		return []string{fp}, nil
+	}
+	return nil, fmt.Errorf("--format must be %v or %v, got %q", xliffFormat, csvFormat, format)
+}
+
+func writeFile(fp string, write func(w io.Writer) error) error {
+	f, err := os.OpenFile(fp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// xliffDoc is an XLIFF 1.2 document.
+type xliffDoc struct {
+	XMLName xml.Name    `xml:"xliff"`
+	Xmlns   string      `xml:"xmlns,attr,omitempty"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source"`
+	Target string `xml:"target,omitempty"`
+}
+
+// writeXLIFF writes the strings of bundles in the default locale def, with their translations
+// in locale, as an XLIFF 1.2 document with a file for each bundle.
+func writeXLIFF(w io.Writer, def, locale string, bundles []bundle) error {
+	doc := xliffDoc{Xmlns: "urn:oasis:names:tc:xliff:document:1.2", Version: "1.2"}
+	for _, b := range bundles {
+		f := xliffFile{Original: b.name, SourceLanguage: def, TargetLanguage: locale, Datatype: "plaintext"}
+		for _, id := range b.ids {
+			f.Units = append(f.Units, xliffUnit{ID: id, Source: b.source[id], Target: b.targets[locale][id]})
+		}
+		doc.Files = append(doc.Files, f)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeCSV writes the strings of bundles in the default locale def, with their translations in
+// locales, as a CSV file with the columns file, key, def and locales.
+func writeCSV(w io.Writer, def string, locales []string, bundles []bundle) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"file", "key", def}, locales...)); err != nil {
+		return err
+	}
+	for _, b := range bundles {
+		for _, id := range b.ids {
+			row := []string{b.name, id, b.source[id]}
+			for _, l := range locales {
+				row = append(row, b.targets[l][id])
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readTranslations adds the translations of the XLIFF or CSV file fp to tr. Translations to
+// the default locale def are ignored.
+func readTranslations(fp, def string, tr translations) error {
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(fp)) {
+	case ".xlf", ".xliff":
+		var doc xliffDoc
+		if err := xml.Unmarshal(b, &doc); err != nil {
+			return fmt.Errorf("%v is not an XLIFF file: %v", fp, err)
+		}
+		for _, f := range doc.Files {
+			if err := checkImport(fp, f.Original, f.TargetLanguage); err != nil {
+				return err
+			}
+			if f.TargetLanguage == def {
+				continue
+			}
+			for _, u := range f.Units {
+				if u.Target != "" {
+					tr.set(f.TargetLanguage, f.Original, u.ID, u.Target)
+				}
+			}
+		}
+		return nil
+	case ".csv":
+		rows, err := csv.NewReader(strings.NewReader(string(b))).ReadAll()
+		if err != nil {
+			return fmt.Errorf("%v is not a CSV file: %v", fp, err)
+		}
+		if len(rows) == 0 || len(rows[0]) < 3 || rows[0][0] != "file" || rows[0][1] != "key" {
+			return fmt.Errorf("%v must start with a header of the columns file, key and the locales", fp)
+		}
+		header := rows[0]
+		for _, row := range rows[1:] {
+			for i := 2; i < len(header) && i < len(row); i++ {
+				if err := checkImport(fp, row[0], header[i]); err != nil {
+					return err
+				}
+				if header[i] != def && row[i] != "" {
+					tr.set(header[i], row[0], row[1], row[i])
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%v must be an XLIFF (.xlf or .xliff) or CSV (.csv) file", fp)
+}
+
+// checkImport returns an error if the bundle name or the locale of the file fp are not valid.
+func checkImport(fp, name, locale string) error {
+	if !localeRegExp.MatchString(locale) {
+		return fmt.Errorf("%v has translations to %q, which is not a valid locale", fp, locale)
+	}
+	if dir, _ := path.Split(name); dir != stringsDir+"/" || path.Ext(name) != ".yaml" || path.Clean(name) != name {
+		return fmt.Errorf("%v has translations of %q, which is not a resource bundle, e.g. %v/bundle.yaml", fp, name, stringsDir)
+	}
+	return nil
+}
+
+// importTranslations writes tr to the resource bundles of the project in root, and returns the
+// paths of the written bundles. The keys are written in the order of the bundles of the default
+// locale def, followed by the keys which only the bundles of the locales have.
+func importTranslations(root string, files map[string][]byte, def string, tr translations) ([]string, error) {
+	base, err := localizedFiles(files, def, def)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	var locales []string
+	for l := range tr {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	for _, l := range locales {
+		var names []string
+		for k := range tr[l] {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			var ids []string
+			if b, ok := base[k]; ok {
+				if ids, _, err = flattenBundle(baseFile(files, def, k), b); err != nil {
+					return nil, err
+				}
+			} else {
+				log.Warnf("The default locale has no %v, so the translations to %v are written in the order of the imported file.\n", k, l)
+			}
+			values := map[string]string{}
+			name := localize(k, l)
+			if b, ok := files[name]; ok {
+				var existing []string
+				if existing, values, err = flattenBundle(name, b); err != nil {
+					return nil, err
+				}
+				ids = append(ids, missingKeys(existing, ids)...)
+			}
+			var imported []string
+			for id, s := range tr[l][k] {
+				values[id] = s
+				imported = append(imported, id)
+			}
+			sortIDs(imported)
+			ids = append(ids, missingKeys(imported, ids)...)
+			b, err := unflattenBundle(ids, values)
+			if err != nil {
+				return nil, err
+			}
+			fp := filepath.Join(root, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+				return nil, err
+			}
+			log.Infof("Writing %v\n", fp)
+			if err := ioutil.WriteFile(fp, b, 0640); err != nil {
+				return nil, err
+			}
+			res = append(res, name)
+		}
+	}
+	return res, nil
+}
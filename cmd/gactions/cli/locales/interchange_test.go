@@ -0,0 +1,156 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var bundleFiles = map[string][]byte{
+	"settings/settings.yaml":           []byte("defaultLocale: en\nprojectId: test\n"),
+	"resources/strings/bundle.yaml":    []byte("greeting: Hi & welcome\ncolors:\n- red\n- green\nfarewell: Bye\n"),
+	"resources/strings/de/bundle.yaml": []byte("farewell: Tschüss\ngreeting: Hallo\n"),
+}
+
+func TestWriteXLIFF(t *testing.T) {
+	bundles, err := readBundles(bundleFiles, "en", []string{"de"})
+	if err != nil {
+		t.Fatalf("readBundles returned %v, want %v", err, nil)
+	}
+	var b bytes.Buffer
+	if err := writeXLIFF(&b, "en", "de", bundles); err != nil {
+		t.Fatalf("writeXLIFF returned %v, want %v", err, nil)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">
+  <file original="resources/strings/bundle.yaml" source-language="en" target-language="de" datatype="plaintext">
+    <body>
+      <trans-unit id="greeting">
+        <source>Hi &amp; welcome</source>
+        <target>Hallo</target>
+      </trans-unit>
+      <trans-unit id="colors[0]">
+        <source>red</source>
+      </trans-unit>
+      <trans-unit id="colors[1]">
+        <source>green</source>
+      </trans-unit>
+      <trans-unit id="farewell">
+        <source>Bye</source>
+        <target>Tschüss</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeXLIFF wrote diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	bundles, err := readBundles(bundleFiles, "en", []string{"de", "fr"})
+	if err != nil {
+		t.Fatalf("readBundles returned %v, want %v", err, nil)
+	}
+	var b bytes.Buffer
+	if err := writeCSV(&b, "en", []string{"de", "fr"}, bundles); err != nil {
+		t.Fatalf("writeCSV returned %v, want %v", err, nil)
+	}
+	want := `file,key,en,de,fr
+resources/strings/bundle.yaml,greeting,Hi & welcome,Hallo,
+resources/strings/bundle.yaml,colors[0],red,,
+resources/strings/bundle.yaml,colors[1],green,,
+resources/strings/bundle.yaml,farewell,Bye,Tschüss,
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeCSV wrote diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	xlf := filepath.Join(dir, "de.xlf")
+	if err := ioutil.WriteFile(xlf, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">
+  <file original="resources/strings/bundle.yaml" source-language="en" target-language="de" datatype="plaintext">
+    <body>
+      <trans-unit id="colors[1]"><source>green</source><target>grün</target></trans-unit>
+      <trans-unit id="colors[0]"><source>red</source><target>rot</target></trans-unit>
+      <trans-unit id="greeting"><source>Hi &amp; welcome</source><target>Hallo &amp; willkommen</target></trans-unit>
+    </body>
+  </file>
+</xliff>
+`), 0640); err != nil {
+		t.Fatal(err)
+	}
+	csvFile := filepath.Join(dir, "strings.csv")
+	if err := ioutil.WriteFile(csvFile, []byte("file,key,en,fr\nresources/strings/bundle.yaml,farewell,Bye,Au revoir\nresources/strings/bundle.yaml,greeting,Hi & welcome,\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	tr := translations{}
+	for _, fp := range []string{xlf, csvFile} {
+		if err := readTranslations(fp, "en", tr); err != nil {
+			t.Fatalf("readTranslations(%v) returned %v, want %v", fp, err, nil)
+		}
+	}
+	root := t.TempDir()
+	written, err := importTranslations(root, bundleFiles, "en", tr)
+	if err != nil {
+		t.Fatalf("importTranslations returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff([]string{"resources/strings/de/bundle.yaml", "resources/strings/fr/bundle.yaml"}, written); diff != "" {
+		t.Errorf("importTranslations returned diff (-want, +got)\n%s", diff)
+	}
+	want := map[string]string{
+		// The keys are in the order of the bundle of the default locale, and the existing
+		// translation of farewell is kept.
+		"resources/strings/de/bundle.yaml": "greeting: Hallo & willkommen\ncolors:\n- rot\n- grün\nfarewell: Tschüss\n",
+		"resources/strings/fr/bundle.yaml": "farewell: Au revoir\n",
+	}
+	for k, v := range want {
+		b, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(k)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(v, string(b)); diff != "" {
+			t.Errorf("importTranslations wrote diff to %v (-want, +got)\n%s", k, diff)
+		}
+	}
+}
+
+func TestReadTranslationsErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"bad.csv":   "key,de\ngreeting,Hallo\n",
+		"path.csv":  "file,key,en,de\n../settings/settings.yaml,projectId,test,evil\n",
+		"lang.xlf":  `<xliff version="1.2"><file original="resources/strings/bundle.yaml" target-language="../de"></file></xliff>`,
+		"notes.txt": "greeting: Hallo\n",
+	}
+	for name, content := range tests {
+		fp := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(fp, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+		if err := readTranslations(fp, "en", translations{}); err == nil {
+			t.Errorf("readTranslations(%v) returned %v, want an error", name, err)
+		}
+	}
+}
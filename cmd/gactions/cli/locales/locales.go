@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package locales provides an implementation of "gactions locales" command.
+package locales
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+var localeRegExp = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]+)*$`)
+
+// AddCommand adds the locales sub-command to the passed in root command.
+func AddCommand(root *cobra.Command, proj project.Project) {
+	locales := &cobra.Command{
+		Use:   "locales",
+		Short: "This is the main command for working with the locales of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the locales of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	cp := &cobra.Command{
+		Use:   "copy --from <locale> --to <locale>[,<locale>...]",
+		Short: "Copy the prompts, intents, types, resource bundles and localized settings of a locale to other locales.",
+		Long: "This command copies the prompts, intents, types, resource bundles and localized settings of a locale to other locales, as a starting point for regional variants (e.g. en-GB and en-AU from en). " +
+			"If --from is the default locale, the prompts and resource bundles which are not localized, the trainingPhrases of the intents, the synonyms of the types, and the localizedSettings of settings/settings.yaml are copied. " +
+			"Existing files of the target locales are kept unless --overwrite is set.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, err := cmd.Flags().GetString("from")
+			if err != nil {
+				return err
+			}
+			to, err := cmd.Flags().GetStringSlice("to")
+			if err != nil {
+				return err
+			}
+			overwrite, err := cmd.Flags().GetBool("overwrite")
+			if err != nil {
+				return err
+			}
+			if err := checkLocales(from, to); err != nil {
+				return err
+			}
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			src, err := localizedFiles(files, def, from)
+			if err != nil {
+				return err
+			}
+			if len(src) == 0 {
+				return fmt.Errorf("locale %v has no prompts, intents, types, resource bundles or localized settings", from)
+			}
+			for _, l := range to {
+				copied, skipped, err := copyLocale(proj.ProjectRoot(), files, src, l, overwrite)
+				if err != nil {
+					return err
+				}
+				printSummary(l, copied, skipped)
+			}
+			return nil
+		},
+	}
+	cp.Flags().String("from", "", "Locale to copy the files from, e.g. en.")
+	cp.MarkFlagRequired("from")
+	cp.Flags().StringSlice("to", nil, "Comma separated locales to copy the files to, e.g. en-GB,en-AU.")
+	cp.MarkFlagRequired("to")
+	cp.Flags().Bool("overwrite", false, "Replace files which already exist in the target locales.")
+	locales.AddCommand(cp)
+	addManageCommands(locales, proj)
+	addCheckCommand(locales, proj)
+	addInterchangeCommands(locales, proj)
+	root.AddCommand(locales)
+}
+
+// checkLocales returns an error if the locales passed to "locales copy" are not valid.
+func checkLocales(from string, to []string) error {
+	if !localeRegExp.MatchString(from) {
+		return fmt.Errorf("%q is not a valid locale", from)
+	}
+	if len(to) == 0 {
+		return errors.New("--to must have at least one locale")
+	}
+	seen := map[string]bool{}
+	for _, l := range to {
+		switch {
+		case !localeRegExp.MatchString(l):
+			return fmt.Errorf("%q is not a valid locale", l)
+		case l == from:
+			return fmt.Errorf("can't copy %v to itself", l)
+		case seen[l]:
+			return fmt.Errorf("%v is passed to --to more than once", l)
+		}
+		seen[l] = true
+	}
+	return nil
+}
+
+// copyLocale writes the files of src, which are relative to the locale directories, to the
+// directories of locale, and returns the paths of the copied and skipped files.
+func copyLocale(root string, files, src map[string][]byte, locale string, overwrite bool) (copied, skipped []string, err error) {
+	var names []string
+	for k := range src {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		name := localize(k, locale)
+		if _, ok := files[name]; ok && !overwrite {
+			skipped = append(skipped, name)
+			continue
+		}
+		fp := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			return nil, nil, err
+		}
+		log.Infof("Writing %v\n", fp)
+		if err := ioutil.WriteFile(fp, src[k], 0640); err != nil {
+			return nil, nil, err
+		}
+		copied = append(copied, name)
+	}
+	return copied, skipped, nil
+}
+
+func printSummary(locale string, copied, skipped []string) {
+	for _, k := range copied {
+		log.Outf("Copied %v\n", k)
+	}
+	for _, k := range skipped {
+		log.Outf("Skipped %v, which already exists\n", k)
+	}
+	msg := fmt.Sprintf("Copied %d files to %v.", len(copied), locale)
+	if len(skipped) > 0 {
+		msg += fmt.Sprintf(" Skipped %d existing files; use --overwrite to replace them.", len(skipped))
+	}
+	log.DoneMsgln(msg)
+}
+
+// localize returns the path of the file name, which is relative to a locale directory, in
+// the directory of locale. For example, custom/prompts/welcome.yaml is
+// custom/prompts/en-GB/welcome.yaml in en-GB.
+func localize(name, locale string) string {
+	for _, dir := range localizedDirs {
+		if rest := strings.TrimPrefix(name, dir+"/"); rest != name {
+			return dir + "/" + locale + "/" + rest
+		}
+	}
+	return name
+}
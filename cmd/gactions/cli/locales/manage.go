@@ -0,0 +1,261 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// addManageCommands adds the sub-commands which list, add and remove the locales of proj to
+// locales.
+func addManageCommands(locales *cobra.Command, proj project.Project) {
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the locales of the project.",
+		Long:  "This command lists the default locale and the locales which have files in settings/<locale>, resources/strings/<locale> or custom/*/<locale>, with the number of their prompts, intents, types, resource bundles and localized settings.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			l, err := projectLocales(files, def)
+			if err != nil {
+				return err
+			}
+			return printLocales(cmd.OutOrStdout(), l)
+		},
+	}
+	locales.AddCommand(list)
+	add := &cobra.Command{
+		Use:   "add <locale>...",
+		Short: "Add locales to the project, with the files of the default locale.",
+		Long: "This command scaffolds the directories of new locales (settings/<locale>, resources/strings/<locale> and custom/*/<locale>) with the files of the default locale: its localized settings, resource bundles and prompts, the trainingPhrases of its intents and the synonyms of its types. " +
+			"Translate the files afterwards. To add a locale from another locale than the default one, use \"locales copy\".",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			if err := checkNewLocales(files, def, args); err != nil {
+				return err
+			}
+			src, err := localizedFiles(files, def, def)
+			if err != nil {
+				return err
+			}
+			for _, l := range args {
+				copied, skipped, err := copyLocale(proj.ProjectRoot(), files, src, l, false)
+				if err != nil {
+					return err
+				}
+				printSummary(l, copied, skipped)
+			}
+			return nil
+		},
+	}
+	locales.AddCommand(add)
+	remove := &cobra.Command{
+		Use:   "remove <locale>...",
+		Short: "Remove locales from the project.",
+		Long:  "This command deletes the files of locales in settings/<locale>, resources/strings/<locale> and custom/*/<locale>, and the directories which are empty afterwards. The default locale can't be removed.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, def, err := projectFiles(proj)
+			if err != nil {
+				return err
+			}
+			for _, l := range args {
+				if l == def {
+					return fmt.Errorf("%v is the default locale, which can't be removed", l)
+				}
+				if len(filesOfLocale(files, l)) == 0 {
+					return fmt.Errorf("locale %v has no files", l)
+				}
+			}
+			for _, l := range args {
+				removed, err := removeLocale(proj.ProjectRoot(), files, l)
+				if err != nil {
+					return err
+				}
+				for _, k := range removed {
+					log.Outf("Removed %v\n", k)
+				}
+				log.DoneMsgln(fmt.Sprintf("Removed %d files of %v.", len(removed), l))
+			}
+			return nil
+		},
+	}
+	locales.AddCommand(remove)
+}
+
+// projectFiles returns the files and the default locale of proj.
+func projectFiles(proj project.Project) (map[string][]byte, string, error) {
+	if proj.ProjectRoot() == "" {
+		log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+		return nil, "", errors.New("can not determine project root")
+	}
+	files, err := proj.Files()
+	if err != nil {
+		return nil, "", err
+	}
+	def, err := studio.DefaultLocale(files)
+	if err != nil {
+		return nil, "", err
+	}
+	return files, def, nil
+}
+
+// localeOf returns the locale of the file name in a locale directory, i.e. de for
+// custom/prompts/de/welcome.yaml, or "" if name isn't in a locale directory.
+func localeOf(name string) string {
+	for _, dir := range localizedDirs {
+		rest := strings.TrimPrefix(name, dir+"/")
+		if rest == name {
+			continue
+		}
+		if i := strings.Index(rest, "/"); i > 0 && localeRegExp.MatchString(rest[:i]) {
+			return rest[:i]
+		}
+	}
+	return ""
+}
+
+// filesOfLocale returns the sorted paths of the files in the directories of locale.
+func filesOfLocale(files map[string][]byte, locale string) []string {
+	var res []string
+	for k := range files {
+		if localeOf(k) == locale {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// checkNewLocales returns an error if the locales passed to "locales add" are not valid, or
+// the project already has them.
+func checkNewLocales(files map[string][]byte, def string, locales []string) error {
+	seen := map[string]bool{}
+	for _, l := range locales {
+		switch {
+		case !localeRegExp.MatchString(l):
+			return fmt.Errorf("%q is not a valid locale", l)
+		case l == def:
+			return fmt.Errorf("%v is the default locale of the project", l)
+		case seen[l]:
+			return fmt.Errorf("%v is passed more than once", l)
+		case len(filesOfLocale(files, l)) > 0:
+			return fmt.Errorf("the project already has locale %v. Use \"locales copy --from %v --to %v\" to add the missing files", l, def, l)
+		}
+		seen[l] = true
+	}
+	return nil
+}
+
+// removeLocale deletes the files of locale from the project in root, and the directories which
+// are empty afterwards. It returns the paths of the deleted files.
+func removeLocale(root string, files map[string][]byte, locale string) ([]string, error) {
+	removed := filesOfLocale(files, locale)
+	dirs := map[string]bool{}
+	for _, k := range removed {
+		fp := filepath.Join(root, filepath.FromSlash(k))
+		log.Infof("Removing %v\n", fp)
+		if err := os.Remove(fp); err != nil {
+			return nil, err
+		}
+		for d := path.Dir(k); localeOf(d+"/") == locale; d = path.Dir(d) {
+			dirs[d] = true
+		}
+	}
+	var l []string
+	for d := range dirs {
+		l = append(l, d)
+	}
+	// Sub-directories are removed before their parents.
+	sort.Sort(sort.Reverse(sort.StringSlice(l)))
+	for _, d := range l {
+		fp := filepath.Join(root, filepath.FromSlash(d))
+		if entries, err := ioutil.ReadDir(fp); err == nil && len(entries) == 0 {
+			if err := os.Remove(fp); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// localeSummary is a locale of the project in the output of "locales list".
+type localeSummary struct {
+	Locale  string `json:"locale" yaml:"locale"`
+	Default bool   `json:"default" yaml:"default"`
+	// Files is the number of prompts, intents, types, resource bundles and localized settings
+	// of the locale.
+	Files int `json:"files" yaml:"files"`
+}
+
+// projectLocales returns the default locale def and the locales which have files in locale
+// directories, sorted by locale after the default locale.
+func projectLocales(files map[string][]byte, def string) ([]localeSummary, error) {
+	seen := map[string]bool{def: true}
+	var others []string
+	for k := range files {
+		if l := localeOf(k); l != "" && !seen[l] {
+			seen[l] = true
+			others = append(others, l)
+		}
+	}
+	sort.Strings(others)
+	var res []localeSummary
+	for _, l := range append([]string{def}, others...) {
+		src, err := localizedFiles(files, def, l)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, localeSummary{Locale: l, Default: l == def, Files: len(src)})
+	}
+	return res, nil
+}
+
+func printLocales(out io.Writer, locales []localeSummary) error {
+	return log.Render(out, locales, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		w.Init(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "Locale\tFiles\t")
+		for _, l := range locales {
+			name := l.Locale
+			if l.Default {
+				name += " (default)"
+			}
+			fmt.Fprintf(w, "%v\t%v\t\n", name, l.Files)
+		}
+		return w.Flush()
+	})
+}
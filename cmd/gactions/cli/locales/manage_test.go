@@ -0,0 +1,153 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package locales
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocaleOf(t *testing.T) {
+	tests := map[string]string{
+		"custom/prompts/de/welcome.yaml":      "de",
+		"custom/intents/pt-BR/yes.yaml":       "pt-BR",
+		"custom/types/fr/color.yaml":          "fr",
+		"resources/strings/de/bundle.yaml":    "de",
+		"settings/es-419/settings.yaml":       "es-419",
+		"custom/prompts/welcome.yaml":         "",
+		"custom/scenes/de/Main.yaml":          "",
+		"resources/strings/bundle.yaml":       "",
+		"settings/settings.yaml":              "",
+		"resources/images/de/logo.png":        "",
+		"custom/prompts/Greetings/hello.yaml": "",
+	}
+	for name, want := range tests {
+		if got := localeOf(name); got != want {
+			t.Errorf("localeOf(%q) returned %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLocalizedFilesOfIntentsAndTypes(t *testing.T) {
+	files := map[string][]byte{
+		"custom/intents/yes.yaml":    []byte("parameters:\n- name: answer\n  typeOverride:\n    name: answer\ntrainingPhrases:\n- \"yes\"\n- sure\n"),
+		"custom/intents/fr/yes.yaml": []byte("trainingPhrases:\n- oui\n"),
+		"custom/types/color.yaml":    []byte("synonym:\n  entities:\n    red:\n      synonyms:\n      - red\n"),
+		"custom/types/bool.yaml":     []byte("freeText: {}\n"),
+	}
+	tests := []struct {
+		locale string
+		want   map[string]string
+	}{
+		{
+			locale: "en",
+			want: map[string]string{
+				"custom/intents/yes.yaml": "trainingPhrases:\n- \"yes\"\n- sure\n",
+				"custom/types/color.yaml": "synonym:\n  entities:\n    red:\n      synonyms:\n      - red\n",
+			},
+		},
+		{
+			locale: "fr",
+			want: map[string]string{
+				"custom/intents/yes.yaml": "trainingPhrases:\n- oui\n",
+			},
+		},
+	}
+	for _, tc := range tests {
+		res, err := localizedFiles(files, "en", tc.locale)
+		if err != nil {
+			t.Fatalf("localizedFiles returned %v, want %v", err, nil)
+		}
+		got := map[string]string{}
+		for k, v := range res {
+			got[k] = string(v)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("localizedFiles(%v) returned diff (-want, +got)\n%s", tc.locale, diff)
+		}
+	}
+}
+
+func TestProjectLocales(t *testing.T) {
+	got, err := projectLocales(testFiles, "en")
+	if err != nil {
+		t.Fatalf("projectLocales returned %v, want %v", err, nil)
+	}
+	want := []localeSummary{
+		{Locale: "en", Default: true, Files: 4},
+		{Locale: "de", Files: 3},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("projectLocales returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestCheckNewLocales(t *testing.T) {
+	tests := []struct {
+		locales []string
+		wantErr bool
+	}{
+		{locales: []string{"fr", "es-419"}},
+		{locales: []string{"en"}, wantErr: true},
+		{locales: []string{"de"}, wantErr: true},
+		{locales: []string{"fr", "fr"}, wantErr: true},
+		{locales: []string{"../fr"}, wantErr: true},
+	}
+	for _, tc := range tests {
+		if err := checkNewLocales(testFiles, "en", tc.locales); (err != nil) != tc.wantErr {
+			t.Errorf("checkNewLocales(%v) returned %v, want error %v", tc.locales, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRemoveLocale(t *testing.T) {
+	root := t.TempDir()
+	for k, v := range testFiles {
+		fp := filepath.Join(root, filepath.FromSlash(k))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fp, v, 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files which aren't project files are kept with their directory.
+	notes := filepath.Join(root, "resources", "strings", "de", "NOTES.txt")
+	if err := ioutil.WriteFile(notes, []byte("Reviewed"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := removeLocale(root, testFiles, "de")
+	if err != nil {
+		t.Fatalf("removeLocale returned %v, want %v", err, nil)
+	}
+	want := []string{"custom/prompts/de/welcome.yaml", "resources/strings/de/bundle.yaml", "settings/de/settings.yaml"}
+	if diff := cmp.Diff(want, removed); diff != "" {
+		t.Errorf("removeLocale returned diff (-want, +got)\n%s", diff)
+	}
+	for _, d := range []string{"custom/prompts/de", "settings/de"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(d))); !os.IsNotExist(err) {
+			t.Errorf("removeLocale kept %v, want it removed", d)
+		}
+	}
+	for _, k := range []string{"resources/strings/de/NOTES.txt", "custom/prompts/welcome.yaml", "settings/settings.yaml"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(k))); err != nil {
+			t.Errorf("removeLocale removed %v, want it kept", k)
+		}
+	}
+}
@@ -17,10 +17,16 @@ package login
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 
 	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
 )
 
@@ -29,19 +35,127 @@ func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project)
 	login := &cobra.Command{
 		Use:   "login",
 		Short: "Authenticate gactions CLI to your Google account via web browser.",
-		Long:  "Authenticate gactions CLI to your Google account via web browser.",
+		Long: "Authenticate gactions CLI to your Google account via web browser, or with --service-account, as a service account for headless machines such as CI. " +
+			"With --credential-source, CI such as GitHub Actions or GitLab CI authenticates through Workload Identity Federation, without a long-lived key. " +
+			fmt.Sprintf("Alternatively, set the %v environment variable to the path of a service account key or an external account configuration to use it without logging in.", apiutils.CredentialsEnv),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			secret, err := proj.ClientSecretJSON()
+			profile, err := selectedProfile(cmd)
 			if err != nil {
 				return err
 			}
-			if err := apiutils.Auth(ctx, secret); err != nil {
+			tokenFile, err := apiutils.ProfileTokenFile(profile)
+			if err != nil {
+				return err
+			}
+			keyFile, err := cmd.Flags().GetString("service-account")
+			if err != nil {
+				return err
+			}
+			credSource, err := cmd.Flags().GetString("credential-source")
+			if err != nil {
+				return err
+			}
+			if apiutils.UseKeyring, err = cmd.Flags().GetBool("keyring"); err != nil {
+				return err
+			}
+			if keyFile != "" && cmd.Flags().Changed("client-secret-file") {
+				return errors.New("--service-account and --client-secret-file can not be used together")
+			}
+			if credSource != "" && (keyFile != "" || cmd.Flags().Changed("client-secret-file")) {
+				return errors.New("--credential-source can not be used with --service-account or --client-secret-file")
+			}
+			// The requests for tokens go through --proxy and trust --ca-bundle.
+			ctx, err := sdk.WithTransport(ctx)
+			if err != nil {
 				return err
 			}
+			if credSource != "" {
+				config, err := ioutil.ReadFile(credSource)
+				if err != nil {
+					return err
+				}
+				if err := apiutils.AuthExternalAccount(ctx, config, tokenFile); err != nil {
+					return err
+				}
+			} else if keyFile != "" {
+				key, err := ioutil.ReadFile(keyFile)
+				if err != nil {
+					return err
+				}
+				if err := apiutils.AuthServiceAccount(ctx, key, tokenFile); err != nil {
+					return err
+				}
+			} else {
+				secret, custom, err := clientSecret(cmd, proj)
+				if err != nil {
+					return err
+				}
+				if custom {
+					err = apiutils.AuthWithClient(ctx, secret, tokenFile)
+				} else {
+					err = apiutils.Auth(ctx, secret, tokenFile)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if profile != "" {
+				log.DoneMsgln(fmt.Sprintf("Successfully logged in to the profile %q.", profile))
+				return nil
+			}
 			log.DoneMsgln("Successfully logged in.")
 			return nil
 		},
 		Args: cobra.NoArgs,
 	}
+	login.Flags().String("service-account", "", "Path to a JSON key of a service account to log in with instead of a Google account. The key is saved with the credentials of the CLI, and tokens are minted from it without a browser.")
+	login.Flags().String("credential-source", "", "Path to the JSON configuration of an external account of Workload Identity Federation, e.g. created by \"gcloud iam workload-identity-pools create-cred-config\", to log in with from CI such as GitHub Actions or GitLab CI. The configuration is saved with the credentials of the CLI, and the credentials of the CI job are exchanged for tokens of Google on each command.")
+	login.Flags().String("client-secret-file", "", fmt.Sprintf("Path to the client secret JSON of your own OAuth client (of type Desktop app) to log in with instead of the client of the CLI, e.g. if your organization restricts OAuth clients. Can also be set by the \"clientSecretFile\" key in %v.", project.ConfigName))
+	login.Flags().Bool("keyring", false, "Save the credentials in the keyring of the operating system (macOS Keychain, Windows Credential Manager, or a Secret Service through secret-tool of libsecret) instead of a file readable only by you. The credentials are saved in the file if no keyring is available.")
 	root.AddCommand(login)
 }
+
+// clientSecret returns the client secret to log in with, and whether it's an OAuth client of
+// the user, set by --client-secret-file or the CLI config, instead of the client of the CLI.
+func clientSecret(cmd *cobra.Command, proj project.Project) ([]byte, bool, error) {
+	fp, err := cmd.Flags().GetString("client-secret-file")
+	if err != nil {
+		return nil, false, err
+	}
+	if fp == "" {
+		cfg, err := studio.ReadCLIConfig()
+		if err != nil {
+			return nil, false, err
+		}
+		if fp = cfg.ClientSecretFile; fp != "" && !filepath.IsAbs(fp) {
+			cfgPath, err := studio.CLIConfigPath()
+			if err != nil {
+				return nil, false, err
+			}
+			fp = filepath.Join(filepath.Dir(cfgPath), fp)
+		}
+	}
+	if fp == "" {
+		secret, err := proj.ClientSecretJSON()
+		return secret, false, err
+	}
+	secret, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return nil, false, fmt.Errorf("can not read the client secret: %v", err)
+	}
+	log.Infof("Logging in with the OAuth client in %v.\n", fp)
+	return secret, true, nil
+}
+
+// selectedProfile returns the login profile set by the --profile flag of cmd, or the profile bound to
+// the current project in the CLI config if the flag isn't set.
+func selectedProfile(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("profile") {
+		return cmd.Flags().GetString("profile")
+	}
+	cfg, err := studio.ReadCLIConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Profile, nil
+}
@@ -16,26 +16,109 @@
 package logout
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/actions-on-google/gactions/api/apiutils"
+	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
 )
 
 // AddCommand adds the push sub-command to the passed in root command.
-func AddCommand(root *cobra.Command, proj project.Project) {
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
 	logout := &cobra.Command{
 		Use:   "logout",
 		Short: "Log gactions CLI out of your Google Account.",
 		Long:  "Log gactions CLI out of your Google Account.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := apiutils.RemoveToken(); err != nil {
+			all, err := cmd.Flags().GetBool("all-profiles")
+			if err != nil {
+				return err
+			}
+			// The tokens are revoked through --proxy, trusting --ca-bundle.
+			ctx, err := sdk.WithTransport(ctx)
+			if err != nil {
+				return err
+			}
+			if all {
+				if cmd.Flags().Changed("profile") {
+					return errors.New("--profile and --all-profiles can not be used together")
+				}
+				return logoutAll(ctx)
+			}
+			profile, err := selectedProfile(cmd)
+			if err != nil {
 				return err
 			}
+			tokenFile, err := apiutils.ProfileTokenFile(profile)
+			if err != nil {
+				return err
+			}
+			if err := apiutils.RemoveTokenWithFilename(ctx, tokenFile); err != nil {
+				return err
+			}
+			if profile != "" {
+				log.DoneMsgln(fmt.Sprintf("Successfully logged out of the profile %q.", profile))
+				return nil
+			}
 			log.DoneMsgln("Successfully logged out.")
 			return nil
 		},
 		Args: cobra.NoArgs,
 	}
+	logout.Flags().Bool("all-profiles", false, "Log out of all profiles, including the default credentials.")
 	root.AddCommand(logout)
 }
+
+// logoutAll removes the tokens of all profiles. It keeps going if a token can't be removed, and
+// returns an error naming the profiles which weren't logged out.
+func logoutAll(ctx context.Context) error {
+	profiles, err := apiutils.Profiles()
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		log.Outf("Already logged out.")
+		return errors.New("already logged out")
+	}
+	var failed []string
+	for _, profile := range profiles {
+		name := profile
+		if name == "" {
+			name = "default"
+		}
+		tokenFile, err := apiutils.ProfileTokenFile(profile)
+		if err == nil {
+			err = apiutils.RemoveTokenWithFilename(ctx, tokenFile)
+		}
+		if err != nil {
+			log.Warnf("Failed to log out of the profile %q: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		log.Infof("Logged out of the profile %q.\n", name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to log out of the profiles %v", strings.Join(failed, ", "))
+	}
+	log.DoneMsgln(fmt.Sprintf("Successfully logged out of %d profiles.", len(profiles)))
+	return nil
+}
+
+// selectedProfile returns the login profile set by the --profile flag of cmd, or the profile bound to
+// the current project in the CLI config if the flag isn't set.
+func selectedProfile(cmd *cobra.Command) (string, error) {
+	if cmd.Flags().Changed("profile") {
+		return cmd.Flags().GetString("profile")
+	}
+	cfg, err := studio.ReadCLIConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Profile, nil
+}
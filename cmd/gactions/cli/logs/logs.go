@@ -0,0 +1,86 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logs provides an implementation of "gactions logs" command.
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/webhook"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the logs sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	logs := &cobra.Command{
+		Use:   "logs",
+		Short: "Print the logs of the inline cloud functions of a project.",
+		Long: "This command prints the entries written to Cloud Logging by the deployed inline cloud functions of the project, oldest first. " +
+			"Use --conversation-id with the ID of a conversation in the simulator to see only the entries that mention it, e.g. when the webhook logs the session ID.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			pid, err := cmd.Flags().GetString("project-id")
+			if err != nil {
+				return err
+			}
+			if err := (&studioProj).SetProjectID(pid); err != nil {
+				return err
+			}
+			name, err := cmd.Flags().GetString("webhook")
+			if err != nil {
+				return err
+			}
+			since, err := cmd.Flags().GetDuration("since")
+			if err != nil {
+				return err
+			}
+			severity, err := cmd.Flags().GetString("severity")
+			if err != nil {
+				return err
+			}
+			conversationID, err := cmd.Flags().GetString("conversation-id")
+			if err != nil {
+				return err
+			}
+			follow, err := cmd.Flags().GetBool("follow")
+			if err != nil {
+				return err
+			}
+			return webhook.Logs(ctx, studioProj, name, since, severity, conversationID, follow)
+		},
+	}
+	logs.Flags().String("project-id", "", "Print the logs of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	logs.Flags().String("webhook", "", "Name of the inline cloud function (i.e. the name of its file in the webhooks directory). By default, the logs of all inline cloud functions are printed.")
+	logs.Flags().Duration("since", time.Hour, "Print the entries written in this period, e.g. 10m or 24h.")
+	logs.Flags().String("severity", "", fmt.Sprintf("Print only the entries with at least this severity: %v.", strings.Join(webhook.Severities, ", ")))
+	logs.Flags().String("conversation-id", "", "Print only the entries which contain the ID of this conversation.")
+	logs.Flags().BoolP("follow", "f", false, "Keep printing new entries until interrupted.")
+	root.AddCommand(logs)
+}
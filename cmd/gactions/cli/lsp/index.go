@@ -0,0 +1,301 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Kinds of the elements of a project that config files refer to.
+const (
+	kindScene  = "scene"
+	kindIntent = "intent"
+	kindPrompt = "prompt"
+	kindType   = "type"
+	kindImage  = "images"
+	kindAudio  = "audio"
+	kindString = "strings"
+)
+
+var (
+	sceneRefRegExp    = regexp.MustCompile(`^\s*(?:-\s+)?transitionToScene:\s*["']?([A-Za-z0-9_.\-]+)`)
+	intentRefRegExp   = regexp.MustCompile(`^\s*(?:-\s+)?intent:\s*["']?([A-Za-z0-9_.\-]+)`)
+	promptRefRegExp   = regexp.MustCompile(`^\s*(?:-\s+)?staticPromptName:\s*["']?([A-Za-z0-9_.\-]+)`)
+	typeKeyRegExp     = regexp.MustCompile(`^(\s*)(?:-\s+)?type:\s*$`)
+	typeNameRegExp    = regexp.MustCompile(`^(\s*)name:\s*["']?([A-Za-z0-9_.\-]+)`)
+	resourceRefRegExp = regexp.MustCompile(`\$resources\.(images|audio|strings)\.([A-Za-z0-9_]+)`)
+	topLevelKeyRegExp = regexp.MustCompile(`^["']?([A-Za-z0-9_]+)["']?:`)
+	yamlLineRegExp    = regexp.MustCompile(`line (\d+)`)
+	nameRegExp        = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+)
+
+// systemPrefixes are the prefixes of names of elements built into Actions Builder, which are not
+// defined in the project.
+var systemPrefixes = map[string]string{
+	kindScene:  "actions.scene.",
+	kindIntent: "actions.intent.",
+	kindType:   "actions.type.",
+}
+
+// position is a position in a file, with a zero-based line and a character offset in UTF-16
+// code units, as in the Language Server Protocol.
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type span struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+func (s span) contains(p position) bool {
+	return s.Start.Line == p.Line && s.Start.Character <= p.Character && p.Character <= s.End.Character
+}
+
+// ref is a reference to an element of the project in a config file.
+type ref struct {
+	kind string
+	name string
+	file string
+	span span
+}
+
+// def is a definition of an element of the project.
+type def struct {
+	file string
+	line int
+}
+
+// problem is an issue found in a config file.
+type problem struct {
+	file     string
+	span     span
+	severity int
+	message  string
+}
+
+// index holds the references between the files of a project. Files are identified by their
+// paths relative to the project root, separated by "/".
+type index struct {
+	defs map[string]map[string][]def
+	refs []ref
+	// syntax holds the syntax errors by file.
+	syntax map[string]problem
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func lineSpan(line int, text string, start, end int) span {
+	return span{
+		Start: position{Line: line, Character: utf16Len(text[:start])},
+		End:   position{Line: line, Character: utf16Len(text[:end])},
+	}
+}
+
+// elementName returns the name of the element defined in file of a localized directory dir,
+// i.e. the filename without the extension.
+func elementName(dir, file string) (string, bool) {
+	if !strings.HasPrefix(file, dir+"/") {
+		return "", false
+	}
+	base := path.Base(file)
+	return strings.TrimSuffix(base, path.Ext(base)), true
+}
+
+// buildIndex indexes files, which hold the content of the YAML files of a project, and the names
+// of other files (i.e. resources) with nil content.
+func buildIndex(files map[string][]byte) *index {
+	idx := &index{defs: map[string]map[string][]def{}, syntax: map[string]problem{}}
+	addDef := func(kind, name string, d def) {
+		if idx.defs[kind] == nil {
+			idx.defs[kind] = map[string][]def{}
+		}
+		idx.defs[kind][name] = append(idx.defs[kind][name], d)
+	}
+	var names []string
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		isYAML := path.Ext(k) == ".yaml"
+		for _, d := range []struct{ kind, dir string }{
+			{kindScene, "custom/scenes"},
+			{kindIntent, "custom/intents"},
+			{kindPrompt, "custom/prompts"},
+			{kindType, "custom/types"},
+			{kindImage, "resources/images"},
+			{kindAudio, "resources/audio"},
+		} {
+			if name, ok := elementName(d.dir, k); ok && (isYAML || d.kind == kindImage || d.kind == kindAudio) {
+				addDef(d.kind, name, def{file: k})
+			}
+		}
+		if !isYAML {
+			continue
+		}
+		content := string(files[k])
+		if strings.HasPrefix(k, "resources/strings/") {
+			for i, line := range strings.Split(content, "\n") {
+				if m := topLevelKeyRegExp.FindStringSubmatch(line); m != nil {
+					addDef(kindString, m[1], def{file: k, line: i})
+				}
+			}
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(files[k], &v); err != nil {
+			line := 0
+			if m := yamlLineRegExp.FindStringSubmatch(err.Error()); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				line = n - 1
+			}
+			idx.syntax[k] = problem{file: k, span: span{Start: position{Line: line}, End: position{Line: line + 1}}, severity: severityError, message: err.Error()}
+		}
+		idx.refs = append(idx.refs, findRefs(k, content)...)
+	}
+	return idx
+}
+
+// findRefs returns the references in the content of a YAML file.
+func findRefs(file, content string) []ref {
+	var res []ref
+	typeIndent := -1
+	for i, line := range strings.Split(content, "\n") {
+		for _, r := range []struct {
+			kind string
+			re   *regexp.Regexp
+		}{
+			{kindScene, sceneRefRegExp},
+			{kindIntent, intentRefRegExp},
+			{kindPrompt, promptRefRegExp},
+		} {
+			if m := r.re.FindStringSubmatchIndex(line); m != nil {
+				res = append(res, ref{kind: r.kind, name: line[m[2]:m[3]], file: file, span: lineSpan(i, line, m[2], m[3])})
+			}
+		}
+		// The name of a type is on the line after "type:", indented deeper.
+		if m := typeNameRegExp.FindStringSubmatchIndex(line); m != nil && typeIndent >= 0 && m[3]-m[2] > typeIndent {
+			res = append(res, ref{kind: kindType, name: line[m[4]:m[5]], file: file, span: lineSpan(i, line, m[4], m[5])})
+		}
+		typeIndent = -1
+		if m := typeKeyRegExp.FindStringSubmatchIndex(line); m != nil {
+			typeIndent = m[3] - m[2]
+		}
+		for _, m := range resourceRefRegExp.FindAllStringSubmatchIndex(line, -1) {
+			res = append(res, ref{kind: line[m[2]:m[3]], name: line[m[4]:m[5]], file: file, span: lineSpan(i, line, m[4], m[5])})
+		}
+	}
+	return res
+}
+
+func isSystem(r ref) bool {
+	p, ok := systemPrefixes[r.kind]
+	return ok && strings.HasPrefix(r.name, p)
+}
+
+// problems returns the syntax errors and unresolved references in file.
+func (idx *index) problems(file string) []problem {
+	var res []problem
+	if p, ok := idx.syntax[file]; ok {
+		res = append(res, p)
+	}
+	for _, r := range idx.refs {
+		if r.file != file || isSystem(r) {
+			continue
+		}
+		if _, ok := idx.defs[r.kind][r.name]; ok {
+			continue
+		}
+		sev := severityError
+		if r.kind == kindImage || r.kind == kindAudio || r.kind == kindString {
+			// Resources may be provided by a locale directory, or added to the project later.
+			sev = severityWarning
+		}
+		res = append(res, problem{file: file, span: r.span, severity: sev, message: fmt.Sprintf("%v %q is not defined in the project", r.kind, r.name)})
+	}
+	return res
+}
+
+// refAt returns the reference in file at p, if any.
+func (idx *index) refAt(file string, p position) (ref, bool) {
+	for _, r := range idx.refs {
+		if r.file == file && r.span.contains(p) {
+			return r, true
+		}
+	}
+	return ref{}, false
+}
+
+// elementAt returns the element referred to at p in file, or defined by file itself, if any.
+func (idx *index) elementAt(file string, p position) (kind, name string, ok bool) {
+	if r, ok := idx.refAt(file, p); ok && !isSystem(r) {
+		return r.kind, r.name, true
+	}
+	for kind, defs := range idx.defs {
+		for name, ds := range defs {
+			for _, d := range ds {
+				if d.file == file && (kind != kindString || d.line == p.Line) {
+					return kind, name, true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Problem is a syntax error or an undefined reference found by Check.
+type Problem struct {
+	File string `json:"file"`
+	// Line is a one-based line number.
+	Line    int    `json:"line"`
+	Warning bool   `json:"warning,omitempty"`
+	Message string `json:"message"`
+}
+
+// Check returns the problems which the language server reports in the config files of a
+// project, ordered by file and line. This lets other commands check a project offline.
+func Check(files map[string][]byte) []Problem {
+	idx := buildIndex(files)
+	var names []string
+	for k := range files {
+		if path.Ext(k) == ".yaml" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	var res []Problem
+	for _, k := range names {
+		ps := idx.problems(k)
+		sort.SliceStable(ps, func(i, j int) bool {
+			return ps[i].span.Start.Line < ps[j].span.Start.Line
+		})
+		for _, p := range ps {
+			res = append(res, Problem{File: k, Line: p.span.Start.Line + 1, Warning: p.severity == severityWarning, Message: p.message})
+		}
+	}
+	return res
+}
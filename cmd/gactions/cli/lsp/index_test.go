@@ -0,0 +1,136 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testFiles = map[string][]byte{
+	"custom/scenes/Main.yaml": []byte(`onEnter:
+  staticPromptName: welcome
+intentEvents:
+- intent: order
+  transitionToScene: Order
+- intent: actions.intent.CANCEL
+  transitionToScene: actions.scene.END_CONVERSATION
+- intent: help
+  transitionToScene: Help
+`),
+	"custom/scenes/Order.yaml": []byte(`slots:
+- name: size
+  type:
+    name: size
+- name: when
+  type:
+    name: actions.type.DateTime
+onEnter:
+  staticPrompt:
+    candidates:
+    - promptResponse:
+        firstSimple:
+          variants:
+          - speech: $resources.strings.ask_size
+        content:
+          image:
+            url: $resources.images.pizza
+`),
+	"custom/intents/order.yaml":    []byte("trainingPhrases:\n- order\n"),
+	"custom/intents/fr/order.yaml": []byte("trainingPhrases:\n- commander\n"),
+	"custom/types/size.yaml":       []byte("synonym:\n  entities: {}\n"),
+	"custom/prompts/welcome.yaml":  []byte("candidates: []\n"),
+	"resources/strings/en.yaml":    []byte("greeting: Hi\nask_size: Which size?\n"),
+	"resources/images/pizza.png":   nil,
+	"custom/global/broken.yaml":    []byte("transitionToScene: [Main\n"),
+}
+
+func TestFindRefs(t *testing.T) {
+	want := []ref{
+		{kind: kindType, name: "size", file: "f", span: span{Start: position{Line: 3, Character: 10}, End: position{Line: 3, Character: 14}}},
+		{kind: kindType, name: "actions.type.DateTime", file: "f", span: span{Start: position{Line: 6, Character: 10}, End: position{Line: 6, Character: 31}}},
+		{kind: kindString, name: "ask_size", file: "f", span: span{Start: position{Line: 13, Character: 39}, End: position{Line: 13, Character: 47}}},
+		{kind: kindImage, name: "pizza", file: "f", span: span{Start: position{Line: 16, Character: 35}, End: position{Line: 16, Character: 40}}},
+	}
+	got := findRefs("f", string(testFiles["custom/scenes/Order.yaml"]))
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(ref{})); diff != "" {
+		t.Errorf("findRefs returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestProblems(t *testing.T) {
+	idx := buildIndex(testFiles)
+	var got []string
+	for _, p := range idx.problems("custom/scenes/Main.yaml") {
+		got = append(got, p.message)
+	}
+	want := []string{
+		`intent "help" is not defined in the project`,
+		`scene "Help" is not defined in the project`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("problems returned diff (-want, +got)\n%s", diff)
+	}
+	if got := idx.problems("custom/scenes/Order.yaml"); len(got) != 0 {
+		t.Errorf("problems returned %v for a file without problems, want none", got)
+	}
+	if got := idx.problems("custom/global/broken.yaml"); len(got) != 1 || got[0].severity != severityError {
+		t.Errorf("problems returned %v for a file with a syntax error, want one error", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var got []Problem
+	for _, p := range Check(testFiles) {
+		if p.File == "custom/global/broken.yaml" {
+			// The message of a syntax error comes from the YAML parser.
+			p.Message = ""
+		}
+		got = append(got, p)
+	}
+	want := []Problem{
+		{File: "custom/global/broken.yaml", Line: 1},
+		{File: "custom/scenes/Main.yaml", Line: 8, Message: `intent "help" is not defined in the project`},
+		{File: "custom/scenes/Main.yaml", Line: 9, Message: `scene "Help" is not defined in the project`},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Check returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestElementAt(t *testing.T) {
+	idx := buildIndex(testFiles)
+	tests := []struct {
+		file     string
+		pos      position
+		wantKind string
+		wantName string
+		wantOK   bool
+	}{
+		{file: "custom/scenes/Main.yaml", pos: position{Line: 4, Character: 22}, wantKind: kindScene, wantName: "Order", wantOK: true},
+		{file: "custom/scenes/Main.yaml", pos: position{Line: 3, Character: 11}, wantKind: kindIntent, wantName: "order", wantOK: true},
+		// A position outside references is in the definition of the scene itself.
+		{file: "custom/scenes/Main.yaml", pos: position{Line: 0, Character: 0}, wantKind: kindScene, wantName: "Main", wantOK: true},
+		{file: "resources/strings/en.yaml", pos: position{Line: 1, Character: 2}, wantKind: kindString, wantName: "ask_size", wantOK: true},
+		{file: "custom/global/broken.yaml", pos: position{Line: 0, Character: 0}},
+	}
+	for _, tc := range tests {
+		kind, name, ok := idx.elementAt(tc.file, tc.pos)
+		if kind != tc.wantKind || name != tc.wantName || ok != tc.wantOK {
+			t.Errorf("elementAt(%v, %v) returned (%v, %v, %v), want (%v, %v, %v)", tc.file, tc.pos, kind, name, ok, tc.wantKind, tc.wantName, tc.wantOK)
+		}
+	}
+}
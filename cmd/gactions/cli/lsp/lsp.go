@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lsp provides an implementation of "gactions lsp" command.
+package lsp
+
+import (
+	"errors"
+	"os"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the lsp sub-command to the passed in root command.
+func AddCommand(root *cobra.Command, proj project.Project) {
+	lsp := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a language server for the config files of the project.",
+		Long: "This command runs a Language Server Protocol server over the standard input and output, for editors to start. " +
+			"It reports YAML syntax errors and references to scenes, intents, prompts, types and resources that are not defined in the project, " +
+			"finds the definitions of references, and renames scenes, intents, prompts and types together with their references.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			// The standard output carries the protocol, so all logs go to the standard error.
+			_, stderr := log.Output()
+			log.SetOutput(stderr, stderr)
+			return newServer(proj.ProjectRoot(), os.Stdin, os.Stdout).serve()
+		},
+	}
+	root.AddCommand(lsp)
+}
@@ -0,0 +1,405 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	severityError   = 1
+	severityWarning = 2
+
+	// Error codes of JSON-RPC.
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeRequestFailed  = -32803
+
+	// maxMessageSize is the largest message the server reads. Messages carry the content of one
+	// config file at most, so a larger Content-Length means a broken client.
+	maxMessageSize = 32 * 1024 * 1024
+)
+
+// message is a request, response or notification of JSON-RPC.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type textDocument struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type textDocumentPosition struct {
+	TextDocument textDocument `json:"textDocument"`
+	Position     position     `json:"position"`
+}
+
+type location struct {
+	URI   string `json:"uri"`
+	Range span   `json:"range"`
+}
+
+type textEdit struct {
+	Range   span   `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type versionedDocument struct {
+	URI     string `json:"uri"`
+	Version *int   `json:"version"`
+}
+
+type documentEdit struct {
+	TextDocument versionedDocument `json:"textDocument"`
+	Edits        []textEdit        `json:"edits"`
+}
+
+type renameFile struct {
+	Kind   string `json:"kind"`
+	OldURI string `json:"oldUri"`
+	NewURI string `json:"newUri"`
+}
+
+type workspaceEdit struct {
+	DocumentChanges []interface{} `json:"documentChanges"`
+}
+
+type diagnostic struct {
+	Range    span   `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// server is a language server for the config files of a project.
+type server struct {
+	root string
+	in   *bufio.Reader
+	out  io.Writer
+	// open holds the content of the documents open in the editor, by file.
+	open map[string]string
+	idx  *index
+	// shutdown is true after the client asked the server to shut down.
+	shutdown bool
+}
+
+func newServer(root string, in io.Reader, out io.Writer) *server {
+	return &server{root: root, in: bufio.NewReader(in), out: out, open: map[string]string{}}
+}
+
+func (s *server) read() (*message, error) {
+	h, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(h.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %v", err)
+	}
+	if n < 0 || n > maxMessageSize {
+		return nil, fmt.Errorf("invalid Content-Length header: %v is not between 0 and %v", n, maxMessageSize)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(s.in, b); err != nil {
+		return nil, err
+	}
+	m := &message{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *server) write(m *message) error {
+	m.JSONRPC = "2.0"
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	return err
+}
+
+// serve handles messages until the client asks the server to exit.
+func (s *server) serve() error {
+	for {
+		m, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("language server exited without shutdown")
+			}
+			return nil
+		}
+		res, rerr := s.handle(m)
+		if m.ID == nil {
+			// Notifications don't get a response.
+			continue
+		}
+		resp := &message{ID: m.ID, Result: res, Error: rerr}
+		if rerr == nil && res == nil {
+			resp.Result = json.RawMessage("null")
+		}
+		if err := s.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) handle(m *message) (interface{}, *responseError) {
+	switch m.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// The full content of documents is sent on change.
+				"textDocumentSync":   1,
+				"definitionProvider": true,
+				"renameProvider":     true,
+			},
+			"serverInfo": map[string]string{"name": "gactions"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didClose":
+		return nil, s.syncDocument(m)
+	case "textDocument/definition":
+		return s.definition(m)
+	case "textDocument/rename":
+		return s.rename(m)
+	}
+	if m.ID != nil {
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", m.Method)}
+	}
+	return nil, nil
+}
+
+func (s *server) syncDocument(m *message) *responseError {
+	var p struct {
+		TextDocument   textDocument `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	if err := json.Unmarshal(m.Params, &p); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	file, ok := s.file(p.TextDocument.URI)
+	if !ok {
+		return nil
+	}
+	switch m.Method {
+	case "textDocument/didOpen":
+		s.open[file] = p.TextDocument.Text
+	case "textDocument/didChange":
+		if n := len(p.ContentChanges); n > 0 {
+			s.open[file] = p.ContentChanges[n-1].Text
+		}
+	case "textDocument/didClose":
+		delete(s.open, file)
+		// Clear the diagnostics of the closed document.
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": s.uri(file), "diagnostics": []diagnostic{}})
+	}
+	s.reindex()
+	return nil
+}
+
+// reindex indexes the project, with the open documents in place of the files on disk, and
+// publishes the diagnostics of the open documents.
+func (s *server) reindex() {
+	files := map[string][]byte{}
+	filepath.Walk(s.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if p != s.root && strings.HasPrefix(info.Name(), ".") || info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if path.Ext(rel) != ".yaml" {
+			files[rel] = nil
+			return nil
+		}
+		if b, err := ioutil.ReadFile(p); err == nil {
+			files[rel] = b
+		}
+		return nil
+	})
+	for k, v := range s.open {
+		files[k] = []byte(v)
+	}
+	s.idx = buildIndex(files)
+	var open []string
+	for k := range s.open {
+		open = append(open, k)
+	}
+	sort.Strings(open)
+	for _, k := range open {
+		diags := []diagnostic{}
+		for _, p := range s.idx.problems(k) {
+			diags = append(diags, diagnostic{Range: p.span, Severity: p.severity, Source: "gactions", Message: p.message})
+		}
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{"uri": s.uri(k), "diagnostics": diags})
+	}
+}
+
+func (s *server) notify(method string, params interface{}) {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	s.write(&message{Method: method, Params: b})
+}
+
+func (s *server) positionParams(m *message) (string, position, *responseError) {
+	var p textDocumentPosition
+	if err := json.Unmarshal(m.Params, &p); err != nil {
+		return "", position{}, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	file, ok := s.file(p.TextDocument.URI)
+	if !ok {
+		return "", position{}, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("%v is not in the project %v", p.TextDocument.URI, s.root)}
+	}
+	if s.idx == nil {
+		s.reindex()
+	}
+	return file, p.Position, nil
+}
+
+func (s *server) definition(m *message) (interface{}, *responseError) {
+	file, pos, rerr := s.positionParams(m)
+	if rerr != nil {
+		return nil, rerr
+	}
+	r, ok := s.idx.refAt(file, pos)
+	if !ok {
+		return nil, nil
+	}
+	var res []location
+	for _, d := range s.idx.defs[r.kind][r.name] {
+		res = append(res, location{URI: s.uri(d.file), Range: span{Start: position{Line: d.line}, End: position{Line: d.line}}})
+	}
+	return res, nil
+}
+
+// rename renames a scene, intent, static prompt or type: it updates the references, and renames
+// the files that define it in all locales.
+func (s *server) rename(m *message) (interface{}, *responseError) {
+	file, pos, rerr := s.positionParams(m)
+	if rerr != nil {
+		return nil, rerr
+	}
+	var p struct {
+		NewName string `json:"newName"`
+	}
+	if err := json.Unmarshal(m.Params, &p); err != nil {
+		return nil, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	kind, name, ok := s.idx.elementAt(file, pos)
+	if !ok {
+		return nil, &responseError{Code: codeRequestFailed, Message: "no scene, intent, prompt or type found at the position"}
+	}
+	if kind != kindScene && kind != kindIntent && kind != kindPrompt && kind != kindType {
+		return nil, &responseError{Code: codeRequestFailed, Message: fmt.Sprintf("renaming %v is not supported", kind)}
+	}
+	if !nameRegExp.MatchString(p.NewName) {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("%q is not a valid name", p.NewName)}
+	}
+	if _, ok := s.idx.defs[kind][p.NewName]; ok {
+		return nil, &responseError{Code: codeRequestFailed, Message: fmt.Sprintf("%v %q already exists", kind, p.NewName)}
+	}
+	edits := map[string][]textEdit{}
+	for _, r := range s.idx.refs {
+		if r.kind == kind && r.name == name {
+			edits[r.file] = append(edits[r.file], textEdit{Range: r.span, NewText: p.NewName})
+		}
+	}
+	var files []string
+	for k := range edits {
+		files = append(files, k)
+	}
+	sort.Strings(files)
+	res := workspaceEdit{DocumentChanges: []interface{}{}}
+	for _, k := range files {
+		res.DocumentChanges = append(res.DocumentChanges, documentEdit{TextDocument: versionedDocument{URI: s.uri(k)}, Edits: edits[k]})
+	}
+	for _, d := range s.idx.defs[kind][name] {
+		dst := path.Join(path.Dir(d.file), p.NewName+path.Ext(d.file))
+		res.DocumentChanges = append(res.DocumentChanges, renameFile{Kind: "rename", OldURI: s.uri(d.file), NewURI: s.uri(dst)})
+	}
+	return res, nil
+}
+
+// file returns the path of the document with uri relative to the project root, separated by "/".
+func (s *server) file(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	p := u.Path
+	if runtime.GOOS == "windows" {
+		p = strings.TrimPrefix(p, "/")
+	}
+	rel, err := filepath.Rel(s.root, filepath.FromSlash(p))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+func (s *server) uri(file string) string {
+	p := filepath.ToSlash(filepath.Join(s.root, filepath.FromSlash(file)))
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func frame(t *testing.T, msgs ...interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("json.Marshal returned %v", err)
+		}
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	}
+	return buf.Bytes()
+}
+
+func TestServe(t *testing.T) {
+	root, err := ioutil.TempDir("", "lsp")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir returned %v", err)
+	}
+	defer os.RemoveAll(root)
+	for k, v := range testFiles {
+		fp := filepath.Join(root, filepath.FromSlash(k))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatalf("os.MkdirAll returned %v", err)
+		}
+		if err := ioutil.WriteFile(fp, v, 0640); err != nil {
+			t.Fatalf("ioutil.WriteFile returned %v", err)
+		}
+	}
+	s := newServer(root, nil, nil)
+	mainURI := s.uri("custom/scenes/Main.yaml")
+	in := frame(t,
+		map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": mainURI, "text": "intentEvents:\n- intent: order\n  transitionToScene: Missing\n"},
+		}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "textDocument/definition", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": mainURI},
+			"position":     position{Line: 1, Character: 12},
+		}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "textDocument/rename", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": mainURI},
+			"position":     position{Line: 1, Character: 12},
+			"newName":      "buy",
+		}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": 4, "method": "shutdown"},
+		map[string]interface{}{"jsonrpc": "2.0", "method": "exit"},
+	)
+	var out bytes.Buffer
+	s = newServer(root, bytes.NewReader(in), &out)
+	if err := s.serve(); err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+
+	var got []interface{}
+	for _, b := range bytes.Split(out.Bytes(), []byte("Content-Length: "))[1:] {
+		var v interface{}
+		if err := json.Unmarshal(b[bytes.Index(b, []byte("\r\n\r\n"))+4:], &v); err != nil {
+			t.Fatalf("json.Unmarshal returned %v", err)
+		}
+		got = append(got, v)
+	}
+	uri := func(file string) string { return s.uri(file) }
+	var want []interface{}
+	for _, m := range []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"definitionProvider":true,"renameProvider":true,"textDocumentSync":1},"serverInfo":{"name":"gactions"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"diagnostics":[{"range":{"start":{"line":2,"character":21},"end":{"line":2,"character":28}},"severity":1,"source":"gactions","message":"scene \"Missing\" is not defined in the project"}],"uri":"` + mainURI + `"}}`,
+		`{"jsonrpc":"2.0","id":2,"result":[{"uri":"` + uri("custom/intents/fr/order.yaml") + `","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}}},{"uri":"` + uri("custom/intents/order.yaml") + `","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}}}]}`,
+		`{"jsonrpc":"2.0","id":3,"result":{"documentChanges":[{"textDocument":{"uri":"` + mainURI + `","version":null},"edits":[{"range":{"start":{"line":1,"character":10},"end":{"line":1,"character":15}},"newText":"buy"}]},{"kind":"rename","oldUri":"` + uri("custom/intents/fr/order.yaml") + `","newUri":"` + uri("custom/intents/fr/buy.yaml") + `"},{"kind":"rename","oldUri":"` + uri("custom/intents/order.yaml") + `","newUri":"` + uri("custom/intents/buy.yaml") + `"}]}}`,
+		`{"jsonrpc":"2.0","id":4,"result":null}`,
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(m), &v); err != nil {
+			t.Fatalf("json.Unmarshal returned %v", err)
+		}
+		want = append(want, v)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("serve returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestReadInvalidContentLength(t *testing.T) {
+	for _, n := range []string{"abc", "-1", fmt.Sprint(maxMessageSize + 1)} {
+		s := newServer("", bytes.NewReader([]byte("Content-Length: "+n+"\r\n\r\n{}")), ioutil.Discard)
+		if _, err := s.read(); err == nil {
+			t.Errorf("read returned %v for Content-Length %v, but want an error", err, n)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	s := newServer("/path/to/project", nil, nil)
+	tests := []struct {
+		uri    string
+		want   string
+		wantOK bool
+	}{
+		{uri: "file:///path/to/project/custom/scenes/Main.yaml", want: "custom/scenes/Main.yaml", wantOK: true},
+		{uri: "file:///path/to/project/resources/images/my%20image.png", want: "resources/images/my image.png", wantOK: true},
+		{uri: "file:///path/to/other/settings/settings.yaml"},
+		{uri: "untitled:Untitled-1"},
+	}
+	for _, tc := range tests {
+		got, ok := s.file(tc.uri)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("file(%v) returned (%v, %v), want (%v, %v)", tc.uri, got, ok, tc.want, tc.wantOK)
+		}
+		if ok {
+			if u := s.uri(got); u != tc.uri {
+				t.Errorf("uri(%v) returned %v, want %v", got, u, tc.uri)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mockserver provides an implementation of "gactions mock-server" command.
+package mockserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/actions-on-google/gactions/gactionstest"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the mock-server sub-command to the passed in root command.
+func AddCommand(root *cobra.Command) {
+	mock := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Run a local server that mocks the Actions API for offline testing.",
+		Long: "This command runs a server which implements the endpoints of the Actions API used by the CLI to write and read the draft, write the preview, and create, read and list versions. " +
+			"The projects are kept in memory, and are lost when the server stops. " +
+			"The server accepts the same streamed requests as the Actions API, so integration tests of the CLI and of CI pipelines can run without access to Google. " +
+			"With --record, the server forwards the requests to the Actions API instead, and saves its responses as fixtures, which --replay serves back.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			port, err := cmd.Flags().GetInt("port")
+			if err != nil {
+				return err
+			}
+			record, err := cmd.Flags().GetString("record")
+			if err != nil {
+				return err
+			}
+			replay, err := cmd.Flags().GetString("replay")
+			if err != nil {
+				return err
+			}
+			h, err := handler(record, replay)
+			if err != nil {
+				return err
+			}
+			return serve(port, h)
+		},
+	}
+	mock.Flags().Int("port", 8080, "Port to listen on. If 0, a free port is chosen.")
+	mock.Flags().String("record", "", fmt.Sprintf("Directory to save the responses of the Actions API at %v in. The requests are sent with the credentials of the commands which use the server", apiEndpoint))
+	mock.Flags().String("replay", "", "Directory of the responses saved with --record to serve instead of the projects in memory")
+	root.AddCommand(mock)
+}
+
+// apiEndpoint is the Actions API which --record forwards the requests to.
+const apiEndpoint = "https://actions.googleapis.com"
+
+func handler(record, replay string) (http.Handler, error) {
+	switch {
+	case record != "" && replay != "":
+		return nil, errors.New("--record and --replay can't be used together")
+	case record != "":
+		return gactionstest.NewRecorder(apiEndpoint, record)
+	case replay != "":
+		return gactionstest.NewReplayHandler(replay)
+	}
+	return gactionstest.NewHandler(), nil
+}
+
+func serve(port int, h http.Handler) error {
+	l, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: h}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(l)
+	}()
+	log.Outf("Mock server is listening on http://%v. Run commands with --api-endpoint http://%v to use it. Press Ctrl+C to stop.\n", l.Addr(), l.Addr())
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	select {
+	case err := <-errCh:
+		return err
+	case <-stop:
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	log.DoneMsgln("Mock server stopped.")
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ping provides an implementation of "gactions ping" command.
+package ping
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/spf13/cobra"
+)
+
+// requestTimeout is how long to wait for a single sample.
+const requestTimeout = 30 * time.Second
+
+// phases are the phases of a request whose latency is measured, in the order they happen.
+var phases = []string{"DNS", "Connect", "TLS", "First byte"}
+
+// sample is the latency of each phase of a request, measured from its start. Phases which
+// didn't happen, like DNS for an IP address, are left out.
+type sample map[string]time.Duration
+
+// measure sends a GET request to url with client, and returns the latency of its phases.
+// The status of the response doesn't matter, only how long it took to arrive.
+func measure(ctx context.Context, client *http.Client, url string) (sample, error) {
+	s := sample{}
+	var start, dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			s["DNS"] = time.Since(dnsStart)
+		},
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				s["Connect"] = time.Since(connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				s["TLS"] = time.Since(tlsStart)
+			}
+		},
+		GotFirstResponseByte: func() { s["First byte"] = time.Since(start) },
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return s, nil
+}
+
+// percentile returns the p-th percentile of sorted durations, by the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (p*len(sorted) + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return sorted[i-1]
+}
+
+// hostResult is the outcome of the samples taken for a host.
+type hostResult struct {
+	host    string
+	samples []sample
+	errs    []error
+}
+
+// printResults writes the 50th and 90th percentiles and maximum latency of each phase to w.
+func printResults(w io.Writer, res []hostResult) {
+	tw := new(tabwriter.Writer)
+	tw.Init(w, 0, 8, 2, ' ', 0)
+	header := false
+	for _, r := range res {
+		if len(r.samples) > 0 && !header {
+			fmt.Fprintln(tw, "Host\tPhase\tp50\tp90\tMax\tSamples\t")
+			header = true
+		}
+		for _, ph := range phases {
+			var d []time.Duration
+			for _, s := range r.samples {
+				if v, ok := s[ph]; ok {
+					d = append(d, v)
+				}
+			}
+			if len(d) == 0 {
+				continue
+			}
+			sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t\n", r.host, ph, round(percentile(d, 50)), round(percentile(d, 90)), round(d[len(d)-1]), len(d))
+		}
+	}
+	tw.Flush()
+	for _, r := range res {
+		if len(r.errs) > 0 {
+			fmt.Fprintf(w, "%v of %v requests to %v failed: %v\n", len(r.errs), len(r.errs)+len(r.samples), r.host, r.errs[len(r.errs)-1])
+		}
+	}
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
+
+// newClient returns a client which opens a new connection for every request, so each sample
+// includes DNS lookup, connection and TLS handshake.
+func newClient() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DisableKeepAlives = true
+	return &http.Client{Transport: t, Timeout: requestTimeout}
+}
+
+// AddCommand adds the ping sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command) {
+	ping := &cobra.Command{
+		Use:   "ping",
+		Short: "Measure the latency to the Actions API and Actions Console.",
+		Long: "This command measures the DNS lookup, connection, TLS handshake and first byte latency of requests to the Actions API and Actions Console from your network, " +
+			"and prints their percentiles, to tell problems of the local network from issues of the API when pushes are slow.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := cmd.Flags().GetInt("samples")
+			if err != nil {
+				return err
+			}
+			if n < 1 {
+				return errors.New("--samples must be at least 1")
+			}
+			client := newClient()
+			var res []hostResult
+			failed := false
+			for _, host := range sdk.Hosts(ctx) {
+				log.Outf("Sending %v requests to %v...\n", n, host)
+				r := hostResult{host: host}
+				for i := 0; i < n; i++ {
+					s, err := measure(ctx, client, "https://"+host+"/")
+					if err != nil {
+						r.errs = append(r.errs, err)
+						continue
+					}
+					r.samples = append(r.samples, s)
+				}
+				failed = failed || len(r.samples) == 0
+				res = append(res, r)
+			}
+			printResults(log.OutLogger.Writer(), res)
+			if failed {
+				return errors.New("can not reach some of the hosts")
+			}
+			return nil
+		},
+	}
+	ping.Flags().Int("samples", 5, "Number of requests sent to each host.")
+	root.AddCommand(ping)
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ping
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	d := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{50, 5},
+		{90, 9},
+		{100, 10},
+		{0, 1},
+	}
+	for _, tc := range tests {
+		if got := percentile(d, tc.p); got != tc.want {
+			t.Errorf("percentile(%v) returned %v, want %v", tc.p, got, tc.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no durations returned %v, want %v", got, 0)
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+	client := ts.Client()
+	client.Transport.(*http.Transport).DisableKeepAlives = true
+	for i := 0; i < 2; i++ {
+		s, err := measure(context.Background(), client, ts.URL)
+		if err != nil {
+			t.Fatalf("measure returned %v, want %v", err, nil)
+		}
+		// The server listens on an IP address, so there is no DNS lookup.
+		for _, ph := range []string{"Connect", "TLS", "First byte"} {
+			if _, ok := s[ph]; !ok {
+				t.Errorf("measure returned %v, want it to measure %v", s, ph)
+			}
+		}
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	res := []hostResult{
+		{
+			host:    "actions.googleapis.com",
+			samples: []sample{{"DNS": time.Millisecond, "First byte": 20 * time.Millisecond}, {"First byte": 40 * time.Millisecond}},
+			errs:    []error{errors.New("timeout")},
+		},
+	}
+	var b bytes.Buffer
+	printResults(&b, res)
+	for _, want := range []string{
+		"actions.googleapis.com  DNS         1ms   1ms   1ms   1",
+		"actions.googleapis.com  First byte  20ms  40ms  40ms  2",
+		"1 of 3 requests to actions.googleapis.com failed: timeout",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("printResults wrote\n%v\nwant it to contain %q", b.String(), want)
+		}
+	}
+}
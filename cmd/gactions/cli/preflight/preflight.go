@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preflight provides an implementation of "gactions preflight" command.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the preflight sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	preflight := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check that the project is ready to be pushed and deployed.",
+		Long: "This command checks that the Actions API is enabled on the project, your account has the IAM permissions to push and deploy, and the draft endpoint is reachable. " +
+			"For each failed check, it prints a command or an action which fixes it. The checks also run before deploy with --preflight.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			if err := (&studioProj).SetProjectID(""); err != nil {
+				return err
+			}
+			if err := Run(ctx, studioProj); err != nil {
+				return err
+			}
+			log.DoneMsgln("All preflight checks passed.")
+			return nil
+		},
+	}
+	root.AddCommand(preflight)
+}
+
+// Run runs the preflight checks on proj, prints their results, and returns an error if any of
+// the checks failed. The project ID of proj must be set.
+func Run(ctx context.Context, proj project.Project) error {
+	checks, err := sdk.PreflightJSON(ctx, proj)
+	if err != nil {
+		return err
+	}
+	if printChecks(log.OutLogger.Writer(), checks) {
+		return errors.New("preflight checks failed")
+	}
+	return nil
+}
+
+// printChecks writes the results of checks to w, and returns true if any check failed.
+func printChecks(w io.Writer, checks []sdk.PreflightCheck) bool {
+	failed := false
+	for _, c := range checks {
+		status := "PASS"
+		if !c.Passed {
+			status = "FAIL"
+			failed = true
+		}
+		fmt.Fprintf(w, "[%v] %v: %v\n", status, c.Name, c.Message)
+		if c.Remediation != "" {
+			fmt.Fprintf(w, "       To fix: %v\n", c.Remediation)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrintChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []sdk.PreflightCheck
+		want       string
+		wantFailed bool
+	}{
+		{
+			name: "passed",
+			checks: []sdk.PreflightCheck{
+				{Name: "Actions API", Passed: true, Message: "The Actions API is enabled on my-project."},
+			},
+			want: "[PASS] Actions API: The Actions API is enabled on my-project.\n",
+		},
+		{
+			name: "failed",
+			checks: []sdk.PreflightCheck{
+				{Name: "Actions API", Passed: true, Message: "The Actions API is enabled on my-project."},
+				{Name: "IAM permissions", Message: "You don't have these permissions on my-project: actions.agent.update (push, deploy preview).", Remediation: "gcloud projects add-iam-policy-binding my-project --member=user:<your account> --role=roles/actions.Admin"},
+			},
+			want: "[PASS] Actions API: The Actions API is enabled on my-project.\n" +
+				"[FAIL] IAM permissions: You don't have these permissions on my-project: actions.agent.update (push, deploy preview).\n" +
+				"       To fix: gcloud projects add-iam-policy-binding my-project --member=user:<your account> --role=roles/actions.Admin\n",
+			wantFailed: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if got := printChecks(&b, tc.checks); got != tc.wantFailed {
+				t.Errorf("printChecks returned %v, want %v", got, tc.wantFailed)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("printChecks wrote diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
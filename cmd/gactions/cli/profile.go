@@ -0,0 +1,120 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cpuProfileFlagName = "cpuprofile"
+	memProfileFlagName = "memprofile"
+	traceFlagName      = "trace"
+)
+
+// stopProfiling finishes the profiles started by startProfiling. Execute calls it
+// after the command exits, even if the command failed.
+var stopProfiling = func() {}
+
+func addProfilingFlags(root *cobra.Command) {
+	root.PersistentFlags().String(cpuProfileFlagName, "", "Write a CPU profile of the command to the file")
+	root.PersistentFlags().String(memProfileFlagName, "", "Write a memory profile to the file after the command exits")
+	root.PersistentFlags().String(traceFlagName, "", "Write an execution trace of the command to the file")
+	// These flags are hidden as they're only used to measure the performance of the CLI.
+	root.PersistentFlags().MarkHidden(cpuProfileFlagName)
+	root.PersistentFlags().MarkHidden(memProfileFlagName)
+	root.PersistentFlags().MarkHidden(traceFlagName)
+}
+
+// startProfiling starts the profiles requested by the flags of cmd.
+func startProfiling(cmd *cobra.Command) (err error) {
+	cpu, err := cmd.Flags().GetString(cpuProfileFlagName)
+	if err != nil {
+		return err
+	}
+	mem, err := cmd.Flags().GetString(memProfileFlagName)
+	if err != nil {
+		return err
+	}
+	tr, err := cmd.Flags().GetString(traceFlagName)
+	if err != nil {
+		return err
+	}
+	var stops []func()
+	stop := func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+	defer func() {
+		if err != nil {
+			stop()
+		}
+	}()
+	if cpu != "" {
+		f, err := os.Create(cpu)
+		if err != nil {
+			return err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return err
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		})
+	}
+	if tr != "" {
+		f, err := os.Create(tr)
+		if err != nil {
+			return err
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return err
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+	if mem != "" {
+		stops = append(stops, func() {
+			if err := writeMemProfile(mem); err != nil {
+				log.Warnf("Failed to write memory profile to %v: %v\n", mem, err)
+			}
+		})
+	}
+	stopProfiling = stop
+	return nil
+}
+
+func writeMemProfile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// Get up-to-date statistics of the allocations.
+	runtime.GC()
+	return pprof.WriteHeapProfile(f)
+}
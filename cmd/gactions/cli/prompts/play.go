@@ -0,0 +1,183 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/project"
+	"gopkg.in/yaml.v2"
+)
+
+// synthesizer returns the audio of speech in locale.
+type synthesizer func(ctx context.Context, speech, locale string) ([]byte, error)
+
+// cloudSynthesizer synthesizes speech with the Cloud Text-to-Speech API, using voice if set.
+func cloudSynthesizer(proj project.Project, voice string) synthesizer {
+	return func(ctx context.Context, speech, locale string) ([]byte, error) {
+		return sdk.SynthesizeSpeechJSON(ctx, proj, speech, locale, voice)
+	}
+}
+
+// commandSynthesizer synthesizes speech with a command, which reads the speech from stdin
+// and writes the audio to stdout. The locale is passed in the GACTIONS_LOCALE variable.
+func commandSynthesizer(command string) synthesizer {
+	return func(ctx context.Context, speech, locale string) ([]byte, error) {
+		args := strings.Fields(command)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), "GACTIONS_LOCALE="+locale)
+		cmd.Stdin = strings.NewReader(speech)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%v failed: %v: %v", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+}
+
+// promptFile returns the location, relative to the project root, of the prompt file arg in
+// locale. arg is a path to a prompt file, or the name of a prompt. A localized prompt file takes
+// precedence over the prompt file for all locales.
+func promptFile(files map[string][]byte, root, arg, locale, defaultLocale string) (string, error) {
+	var p string
+	if strings.HasSuffix(arg, ".yaml") {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("%v is not in the project at %v", arg, root)
+		}
+		p = filepath.ToSlash(rel)
+	} else {
+		p = promptPath(arg, "", defaultLocale)
+	}
+	if !strings.HasPrefix(p, "custom/prompts/") {
+		return "", fmt.Errorf("%v is not a prompt file", arg)
+	}
+	if locale != "" && locale != defaultLocale {
+		l := path.Join(path.Dir(p), locale, path.Base(p))
+		if _, ok := files[l]; ok {
+			return l, nil
+		}
+	}
+	if _, ok := files[p]; !ok {
+		return "", fmt.Errorf("prompt file %v is not found", p)
+	}
+	return p, nil
+}
+
+// speeches returns the speech of the variants of the simple responses of a prompt, in the
+// order of the candidates.
+func speeches(b []byte) ([]string, error) {
+	var prompt struct {
+		Candidates []struct {
+			PromptResponse struct {
+				FirstSimple struct {
+					Variants []struct {
+						Speech string `yaml:"speech"`
+					} `yaml:"variants"`
+				} `yaml:"firstSimple"`
+				LastSimple struct {
+					Variants []struct {
+						Speech string `yaml:"speech"`
+					} `yaml:"variants"`
+				} `yaml:"lastSimple"`
+			} `yaml:"promptResponse"`
+		} `yaml:"candidates"`
+	}
+	if err := yaml.Unmarshal(b, &prompt); err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, c := range prompt.Candidates {
+		for _, v := range c.PromptResponse.FirstSimple.Variants {
+			if v.Speech != "" {
+				res = append(res, v.Speech)
+			}
+		}
+		for _, v := range c.PromptResponse.LastSimple.Variants {
+			if v.Speech != "" {
+				res = append(res, v.Speech)
+			}
+		}
+	}
+	return res, nil
+}
+
+// outPath returns the file to save the i-th of n speeches to. With several speeches, the number
+// of the speech is appended to the name of out, e.g. greeting-2.mp3.
+func outPath(out string, i, n int) string {
+	if n == 1 {
+		return out
+	}
+	ext := filepath.Ext(out)
+	return fmt.Sprintf("%v-%d%v", strings.TrimSuffix(out, ext), i+1, ext)
+}
+
+// players are the commands tried, in order, to play audio when --player isn't set.
+var players = [][]string{
+	{"afplay"},
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	{"mpg123", "-q"},
+	{"play", "-q"},
+}
+
+// findPlayer returns the command to play audio with, from player or the first of players
+// installed.
+func findPlayer(player string) ([]string, error) {
+	if player != "" {
+		return strings.Fields(player), nil
+	}
+	for _, p := range players {
+		if _, err := exec.LookPath(p[0]); err == nil {
+			return p, nil
+		}
+	}
+	return nil, errors.New("can not find an audio player (afplay, ffplay, mpg123 or play); set one with --player, or save the audio with --out")
+}
+
+// play plays audio with player, which is passed the path of a temporary file with the audio.
+func play(ctx context.Context, player []string, audio []byte) error {
+	f, err := ioutil.TempFile("", "gactions-prompt-*.mp3")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(audio); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, player[0], append(player[1:], f.Name())...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompts
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpeeches(t *testing.T) {
+	b := []byte(`candidates:
+- selector:
+    surfaceCapabilities:
+      capabilities:
+      - SPEECH
+  promptResponse:
+    firstSimple:
+      variants:
+      - speech: <speak>Hi <break time="1s"/> there.</speak>
+      - text: Only text.
+    lastSimple:
+      variants:
+      - speech: What next?
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Hello.
+`)
+	got, err := speeches(b)
+	if err != nil {
+		t.Fatalf("speeches returned %v, want %v", err, nil)
+	}
+	want := []string{`<speak>Hi <break time="1s"/> there.</speak>`, "What next?", "Hello."}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("speeches returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestPromptFile(t *testing.T) {
+	root := t.TempDir()
+	files := map[string][]byte{
+		"custom/prompts/welcome.yaml":    nil,
+		"custom/prompts/fr/welcome.yaml": nil,
+		"custom/prompts/bye.yaml":        nil,
+	}
+	tests := []struct {
+		arg    string
+		locale string
+		want   string
+	}{
+		{"welcome", "en", "custom/prompts/welcome.yaml"},
+		{"welcome", "fr", "custom/prompts/fr/welcome.yaml"},
+		{"bye", "fr", "custom/prompts/bye.yaml"},
+		{filepath.Join(root, "custom", "prompts", "welcome.yaml"), "fr", "custom/prompts/fr/welcome.yaml"},
+	}
+	for _, tc := range tests {
+		got, err := promptFile(files, root, tc.arg, tc.locale, "en")
+		if err != nil {
+			t.Errorf("promptFile(%v, %v) returned %v, want %v", tc.arg, tc.locale, err, nil)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("promptFile(%v, %v) returned %v, want %v", tc.arg, tc.locale, got, tc.want)
+		}
+	}
+	for _, arg := range []string{"missing", filepath.Join(root, "settings", "settings.yaml"), filepath.Join(root, "..", "welcome.yaml")} {
+		if _, err := promptFile(files, root, arg, "en", "en"); err == nil {
+			t.Errorf("promptFile(%v) returned %v, but want an error", arg, err)
+		}
+	}
+}
+
+func TestOutPath(t *testing.T) {
+	if got := outPath("welcome.mp3", 0, 1); got != "welcome.mp3" {
+		t.Errorf("outPath returned %v for one speech, want %v", got, "welcome.mp3")
+	}
+	if got := outPath("out/welcome.mp3", 1, 3); got != "out/welcome-2.mp3" {
+		t.Errorf("outPath returned %v for the second speech, want %v", got, "out/welcome-2.mp3")
+	}
+}
+
+func TestCommandSynthesizer(t *testing.T) {
+	got, err := commandSynthesizer("cat")(context.Background(), "<speak>Hi</speak>", "en")
+	if err != nil {
+		t.Fatalf("commandSynthesizer returned %v, want %v", err, nil)
+	}
+	if string(got) != "<speak>Hi</speak>" {
+		t.Errorf("commandSynthesizer returned %q, want the output of the command %q", got, "<speak>Hi</speak>")
+	}
+}
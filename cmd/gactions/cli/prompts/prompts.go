@@ -0,0 +1,338 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prompts provides an implementation of "gactions prompts" command.
+package prompts
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var promptNameRegExp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// AddCommand adds the prompts sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	prompts := &cobra.Command{
+		Use:   "prompts",
+		Short: "This is the main command for working with the static prompts of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the static prompts of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	imp := &cobra.Command{
+		Use:   "import",
+		Short: "Create or update static prompts from a CSV file or a Google Sheet.",
+		Long: "This command creates or updates the static prompts in custom/prompts from a CSV file or a Google Sheet. " +
+			"The first row is a header with the columns \"prompt\" and \"speech\", and optionally \"locale\" and \"text\". " +
+			"Each row is a variant of the first candidate of a prompt; the variants replace the variants of the candidate in the project.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			fname, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+			sheet, err := cmd.Flags().GetString("from-sheet")
+			if err != nil {
+				return err
+			}
+			var rows [][]string
+			switch {
+			case fname != "" && sheet != "":
+				return errors.New("only one of --file and --from-sheet can be set")
+			case sheet != "":
+				tab, err := cmd.Flags().GetString("sheet-tab")
+				if err != nil {
+					return err
+				}
+				if rows, err = sdk.ReadSheetJSON(ctx, proj, sheet, tab); err != nil {
+					return err
+				}
+			case fname != "":
+				f, err := os.Open(fname)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if rows, err = csv.NewReader(f).ReadAll(); err != nil {
+					return fmt.Errorf("can not read %v: %v", fname, err)
+				}
+			default:
+				return errors.New("one of --file and --from-sheet must be set")
+			}
+			variants, err := variantsFromRows(rows)
+			if err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			out, err := mergeVariants(files, variants)
+			if err != nil {
+				return err
+			}
+			var names []string
+			for k := range out {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			for _, k := range names {
+				if _, ok := files[k]; ok {
+					log.Outf("Updating %v\n", k)
+				} else {
+					log.Outf("Creating %v\n", k)
+				}
+				if err := studio.WriteToDisk(proj, k, "", out[k], true); err != nil {
+					return err
+				}
+			}
+			log.DoneMsgln(fmt.Sprintf("Imported %v variants into %v prompt files.", len(variants), len(out)))
+			return nil
+		},
+	}
+	imp.Flags().String("file", "", "Path to the CSV file with the variants.")
+	imp.Flags().String("from-sheet", "", "ID of a Google Sheet with the variants, in the same columns as a CSV file. The sheet must be shared with your Google account.")
+	imp.Flags().String("sheet-tab", "prompts", "Name of the tab of the Google Sheet with the variants.")
+	prompts.AddCommand(imp)
+	play := &cobra.Command{
+		Use:   "play <prompt-file>",
+		Short: "Synthesize and play the speech of a static prompt.",
+		Long: "This command synthesizes the speech of the variants of a static prompt, so you can hear the SSML without deploying to the simulator. " +
+			"The argument is a prompt file, or the name of a prompt. The speech is synthesized with the Cloud Text-to-Speech API, which must be enabled on the project, " +
+			"or with the command set by --engine-command. The audio is played, or saved with --out.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			locale, err := cmd.Flags().GetString("locale")
+			if err != nil {
+				return err
+			}
+			voice, err := cmd.Flags().GetString("voice")
+			if err != nil {
+				return err
+			}
+			engine, err := cmd.Flags().GetString("engine-command")
+			if err != nil {
+				return err
+			}
+			player, err := cmd.Flags().GetString("player")
+			if err != nil {
+				return err
+			}
+			out, err := cmd.Flags().GetString("out")
+			if err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			defaultLocale, err := studio.DefaultLocale(files)
+			if err != nil {
+				return err
+			}
+			if locale == "" {
+				locale = defaultLocale
+			}
+			p, err := promptFile(files, proj.ProjectRoot(), args[0], locale, defaultLocale)
+			if err != nil {
+				return err
+			}
+			speech, err := speeches(files[p])
+			if err != nil {
+				return fmt.Errorf("%v has incorrect syntax: %v", p, err)
+			}
+			if len(speech) == 0 {
+				return fmt.Errorf("%v has no speech", p)
+			}
+			synthesize := cloudSynthesizer(proj, voice)
+			if strings.TrimSpace(engine) != "" {
+				synthesize = commandSynthesizer(engine)
+			}
+			var pl []string
+			if out == "" {
+				if pl, err = findPlayer(player); err != nil {
+					return err
+				}
+			}
+			for i, v := range speech {
+				log.Outf("Speech %v of %v in %v (%v): %v\n", i+1, len(speech), p, locale, v)
+				audio, err := synthesize(ctx, v, locale)
+				if err != nil {
+					return err
+				}
+				if out != "" {
+					f := outPath(out, i, len(speech))
+					if err := ioutil.WriteFile(f, audio, 0644); err != nil {
+						return err
+					}
+					log.Outf("Saved the audio to %v\n", f)
+					continue
+				}
+				if err := play(ctx, pl, audio); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	play.Flags().String("locale", "", "Locale of the prompt, which selects the localized prompt file and the language of the speech. Defaults to the default locale of the project.")
+	play.Flags().String("voice", "", "Name of the Cloud Text-to-Speech voice, e.g. en-US-Wavenet-D. By default, the API picks a voice for the locale.")
+	play.Flags().String("engine-command", "", "Command which synthesizes the speech instead of the Cloud Text-to-Speech API. It reads the speech from stdin, and writes the audio to stdout; the locale is in the GACTIONS_LOCALE environment variable.")
+	play.Flags().String("player", "", "Command which plays the audio, given the path of an audio file, e.g. \"mpv --really-quiet\". By default, the first of afplay, ffplay, mpg123 and play which is installed.")
+	play.Flags().String("out", "", "Save the audio to this file instead of playing it. With several variants, their number is appended to the name of the file.")
+	prompts.AddCommand(play)
+	root.AddCommand(prompts)
+}
+
+// variant is a variant of a static prompt in a given locale. An empty locale
+// stands for the default locale of the project.
+type variant struct {
+	prompt string
+	locale string
+	speech string
+	text   string
+}
+
+// variantsFromRows returns the variants of a table, where the first row is a header.
+func variantsFromRows(rows [][]string) ([]variant, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("header row is missing")
+	}
+	cols := map[string]int{}
+	for i, v := range rows[0] {
+		cols[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+	for _, v := range []string{"prompt", "speech"} {
+		if _, ok := cols[v]; !ok {
+			return nil, fmt.Errorf("column %q is missing from the header", v)
+		}
+	}
+	col := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var variants []variant
+	for i, rec := range rows[1:] {
+		v := variant{
+			prompt: col(rec, "prompt"),
+			locale: col(rec, "locale"),
+			speech: col(rec, "speech"),
+			text:   col(rec, "text"),
+		}
+		if !promptNameRegExp.MatchString(v.prompt) {
+			return nil, fmt.Errorf("row %v has invalid prompt name %q", i+2, v.prompt)
+		}
+		if v.speech == "" {
+			return nil, fmt.Errorf("row %v of prompt %v has no speech", i+2, v.prompt)
+		}
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// promptPath returns the location of a prompt file relative to the project root.
+func promptPath(name, locale, defaultLocale string) string {
+	if locale == "" || locale == defaultLocale {
+		return path.Join("custom", "prompts", name+".yaml")
+	}
+	return path.Join("custom", "prompts", locale, name+".yaml")
+}
+
+// mergeVariants replaces the variants of the first candidate in the prompt files
+// of a project, and returns the content of the prompt files that were created or updated.
+func mergeVariants(files map[string][]byte, variants []variant) (map[string][]byte, error) {
+	defaultLocale, err := studio.DefaultLocale(files)
+	if err != nil {
+		return nil, err
+	}
+	byPath := map[string][]interface{}{}
+	var paths []string
+	for _, v := range variants {
+		p := promptPath(v.prompt, v.locale, defaultLocale)
+		if _, ok := byPath[p]; !ok {
+			paths = append(paths, p)
+		}
+		out := map[string]string{"speech": v.speech}
+		if v.text != "" {
+			out["text"] = v.text
+		}
+		byPath[p] = append(byPath[p], out)
+	}
+	out := map[string][]byte{}
+	for _, p := range paths {
+		prompt := map[string]interface{}{}
+		if b, ok := files[p]; ok {
+			if err := yaml.Unmarshal(b, &prompt); err != nil {
+				return nil, fmt.Errorf("%v has incorrect syntax: %v", p, err)
+			}
+		}
+		if prompt["candidates"] == nil {
+			prompt["candidates"] = []interface{}{map[interface{}]interface{}{}}
+		}
+		cands, ok := prompt["candidates"].([]interface{})
+		if !ok || len(cands) == 0 {
+			return nil, fmt.Errorf("%v has incorrect candidates field", p)
+		}
+		cand, ok := cands[0].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v has incorrect candidates field", p)
+		}
+		if cand["promptResponse"] == nil {
+			cand["promptResponse"] = map[interface{}]interface{}{}
+		}
+		resp, ok := cand["promptResponse"].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v has incorrect promptResponse field", p)
+		}
+		if resp["firstSimple"] == nil {
+			resp["firstSimple"] = map[interface{}]interface{}{}
+		}
+		simple, ok := resp["firstSimple"].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v has incorrect firstSimple field", p)
+		}
+		simple["variants"] = byPath[p]
+		b, err := yaml.Marshal(prompt)
+		if err != nil {
+			return nil, err
+		}
+		out[p] = b
+	}
+	return out, nil
+}
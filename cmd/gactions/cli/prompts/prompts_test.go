@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompts
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestVariantsFromRowsInvalid(t *testing.T) {
+	tests := [][][]string{
+		{},
+		{{"prompt", "text"}, {"welcome", "Hi"}},
+		{{"prompt", "speech"}, {"wel come", "Hi"}},
+		{{"prompt", "speech"}, {"welcome", ""}},
+	}
+	for _, tc := range tests {
+		if _, err := variantsFromRows(tc); err == nil {
+			t.Errorf("variantsFromRows(%v) returned %v, but want an error", tc, err)
+		}
+	}
+}
+
+func TestMergeVariants(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml": []byte("defaultLocale: en\nprojectId: foo\n"),
+		"custom/prompts/welcome.yaml": []byte(`candidates:
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Hello.
+    suggestions:
+    - title: Order
+- selector:
+    surfaceCapabilities:
+      capabilities:
+      - SPEECH
+`),
+	}
+	rows := [][]string{
+		{"Prompt", "Locale", "Speech", "Text"},
+		{"welcome", "", "Hi!", ""},
+		{"welcome", "en", "Hello there!", "Hello there! 👋"},
+		{"welcome", "fr", "Salut !", ""},
+		{"bye", "", "Bye.", ""},
+	}
+	variants, err := variantsFromRows(rows)
+	if err != nil {
+		t.Fatalf("variantsFromRows returned %v, want %v", err, nil)
+	}
+	got, err := mergeVariants(files, variants)
+	if err != nil {
+		t.Fatalf("mergeVariants returned %v, want %v", err, nil)
+	}
+	want := map[string]string{
+		"custom/prompts/welcome.yaml": `
+candidates:
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Hi!
+      - speech: Hello there!
+        text: Hello there! 👋
+    suggestions:
+    - title: Order
+- selector:
+    surfaceCapabilities:
+      capabilities:
+      - SPEECH
+`,
+		"custom/prompts/fr/welcome.yaml": `
+candidates:
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Salut !
+`,
+		"custom/prompts/bye.yaml": `
+candidates:
+- promptResponse:
+    firstSimple:
+      variants:
+      - speech: Bye.
+`,
+	}
+	if len(got) != len(want) {
+		t.Errorf("mergeVariants returned %v files, want %v", len(got), len(want))
+	}
+	for k, v := range want {
+		var w, g interface{}
+		if err := yaml.Unmarshal([]byte(v), &w); err != nil {
+			t.Fatalf("Can't unmarshal %v: %v", v, err)
+		}
+		if err := yaml.Unmarshal(got[k], &g); err != nil {
+			t.Errorf("mergeVariants returned %v with incorrect syntax: %v", k, err)
+		}
+		if diff := cmp.Diff(w, g); diff != "" {
+			t.Errorf("mergeVariants returned incorrect %v: diff (-want, +got)\n%s", k, diff)
+		}
+	}
+}
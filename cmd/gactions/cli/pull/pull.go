@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log"
@@ -54,8 +55,12 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 					return err
 				}
 			}
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
 			// RC file will have a faulty path -- try to create it.
-			if !exists(studioProj.ProjectRoot()) {
+			if !dryRun && !exists(studioProj.ProjectRoot()) {
 				log.Infof("%q doesn't exist.", studioProj.ProjectRoot())
 				// 0750 sets permissions so that, (U)ser / owner can read,
 				// can write and can execute. (G)roup can read, can't write and can execute.
@@ -84,16 +89,30 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 			if err != nil {
 				return err
 			}
+			patterns, err := cmd.Flags().GetStringSlice("files")
+			if err != nil {
+				return err
+			}
+			filter, err := studio.NewPathFilter(patterns)
+			if err != nil {
+				return fmt.Errorf("--files is invalid: %v", err)
+			}
+			if filter != nil {
+				log.Outf("Pulling only the files matching %v.\n", strings.Join(patterns, ", "))
+			}
 			if versionID == "" {
-				if err := sdk.ReadDraftJSON(ctx, studioProj, force, clean); err != nil {
+				if err := sdk.ReadDraftJSON(ctx, studioProj, force, clean, dryRun, filter); err != nil {
 					return err
 				}
 			} else {
 				versionID = url.PathEscape(versionID)
-				if err := sdk.ReadVersionJSON(ctx, studioProj, force, clean, versionID); err != nil {
+				if err := sdk.ReadVersionJSON(ctx, studioProj, force, clean, dryRun, versionID, filter); err != nil {
 					return err
 				}
 			}
+			if dryRun {
+				return nil
+			}
 			log.DoneMsgln(fmt.Sprintf("You should see the files written in %s", studioProj.ProjectRoot()))
 			return nil
 		},
@@ -102,6 +121,8 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 	pull.Flags().String("project-id", "", "Pull from the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
 	pull.Flags().BoolP("force", "f", false, "Overwrite existing local files without asking.")
 	pull.Flags().Bool("clean", false, "Remove any local files that are not in the files pulled from Actions Builder.")
+	pull.Flags().Bool("dry-run", false, "List the local files that would be written, overwritten, or deleted (with --clean) without changing them.")
 	pull.Flags().String("version-id", "", "Pull the version specified by the ID.")
+	pull.Flags().StringSlice("files", nil, "Only pull the files matching these glob patterns, e.g. \"custom/intents/**,settings/*\". The syntax is the one of .gactionsignore. Other local files are neither overwritten nor removed by --clean.")
 	root.AddCommand(pull)
 }
@@ -19,6 +19,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/actions-on-google/gactions/api/sdk"
 	"github.com/actions-on-google/gactions/log"
@@ -34,6 +37,17 @@ func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project)
 		Short: "This command pushes changes in the local files to Actions Console.",
 		Long:  "This command pushes changes in the local files to Actions Console.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			env, err := cmd.Flags().GetString("env")
+			if err != nil {
+				return err
+			}
+			fromStdin, err := cmd.Flags().GetBool("from-stdin")
+			if err != nil {
+				return err
+			}
+			if fromStdin {
+				return pushArchive(ctx, cmd, args, proj, env)
+			}
 			if proj.ProjectRoot() == "" {
 				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
 				return errors.New("can not determine project root")
@@ -45,13 +59,109 @@ func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project)
 			if err := (&studioProj).SetProjectID(""); err != nil {
 				return err
 			}
+			if err := (&studioProj).SetEnv(env); err != nil {
+				return err
+			}
 			return doPush(ctx, cmd, args, studioProj)
 		},
 		Args: cobra.NoArgs,
 	}
+	push.Flags().Bool("incremental", false, "Skip the push if no file changed since the last successful push from this directory. The draft is replaced by the pushed files, so all files are pushed if any of them changed, or if the state of the last push is not found.")
+	push.Flags().Bool("validate-only", false, "Only validate the files on the server, which returns the same validation results as a push, without writing the draft.")
+	push.Flags().Bool("strict", false, "Fail with exit code 2 if the server found validation issues, e.g. a missing logo. The files are still pushed.")
+	push.Flags().Bool("watch", false, "Keep running, and push the files again each time they change. Several changes saved at once are pushed together.")
+	push.Flags().Bool("preview", false, "With --watch, deploy the files for preview instead of pushing them to the draft, as \"gactions deploy preview\" does, so the changes can be tested in the simulator right away.")
+	push.Flags().String("env", "", "Environment to push, such as staging. The account linking secret of the environment in settings/accountLinkingSecret.<env>.yaml is pushed in place of settings/accountLinkingSecret.yaml.")
+	push.Flags().Bool("from-stdin", false, "Read the project from an archive on stdin instead of the project directory, e.g. \"tar -C sdk -c . | gactions push --from-stdin\". Nothing is written to disk, so --incremental can't be used.")
+	push.Flags().String("archive-format", "tar", fmt.Sprintf("Format of the archive read with --from-stdin: %v.", strings.Join(studio.ArchiveFormats, " or ")))
 	root.AddCommand(push)
 }
 
+// stdin is read by push --from-stdin. It's replaced in tests.
+var stdin io.Reader = os.Stdin
+
+// pushArchive pushes the project in an archive read from stdin.
+func pushArchive(ctx context.Context, cmd *cobra.Command, args []string, proj project.Project, env string) error {
+	incremental, err := cmd.Flags().GetBool("incremental")
+	if err != nil {
+		return err
+	}
+	if incremental {
+		return errors.New("--incremental can not be used with --from-stdin, because the state of the last push is kept in the project directory")
+	}
+	format, err := cmd.Flags().GetString("archive-format")
+	if err != nil {
+		return err
+	}
+	secret, err := proj.ClientSecretJSON()
+	if err != nil {
+		return err
+	}
+	a, err := studio.NewArchive(stdin, format, secret, env)
+	if err != nil {
+		return fmt.Errorf("can not read the project from stdin: %v", err)
+	}
+	if err := (&a).SetProjectID(""); err != nil {
+		return err
+	}
+	return doPush(ctx, cmd, args, a)
+}
+
 var doPush = func(ctx context.Context, cmd *cobra.Command, args []string, proj project.Project) error {
-	return sdk.WriteDraftJSON(ctx, proj)
+	incremental, err := cmd.Flags().GetBool("incremental")
+	if err != nil {
+		return err
+	}
+	validateOnly, err := cmd.Flags().GetBool("validate-only")
+	if err != nil {
+		return err
+	}
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return err
+	}
+	watching, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return err
+	}
+	preview, err := cmd.Flags().GetBool("preview")
+	if err != nil {
+		return err
+	}
+	if validateOnly && incremental {
+		return errors.New("--incremental can not be used with --validate-only, because the server validates all files of the project")
+	}
+	if watching && incremental {
+		return errors.New("--incremental can not be used with --watch, because the files are only pushed again when they change")
+	}
+	if preview && !watching {
+		return errors.New("--preview can only be used with --watch. To deploy the files for preview, run \"gactions deploy preview\"")
+	}
+	if preview && validateOnly {
+		return errors.New("--preview can not be used with --validate-only, because all files are deployed for preview")
+	}
+	push := func() error {
+		var issues []sdk.ValidationIssue
+		var err error
+		switch {
+		case preview:
+			issues, err = sdk.WritePreviewJSON(ctx, proj, true)
+		case validateOnly:
+			issues, err = sdk.ValidateOnlyDraftJSON(ctx, proj)
+		default:
+			issues, err = sdk.WriteDraftJSON(ctx, proj, incremental)
+		}
+		if err != nil || !strict {
+			return err
+		}
+		return sdk.CheckValidationIssues(issues)
+	}
+	if watching {
+		// A project read from stdin has no root.
+		if proj.ProjectRoot() == "" {
+			return errors.New("--watch can not be used with --from-stdin, because there are no files to watch")
+		}
+		return watch(ctx, proj, push)
+	}
+	return push()
 }
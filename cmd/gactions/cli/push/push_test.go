@@ -15,6 +15,7 @@
 package push
 
 import (
+	"archive/tar"
 	"bytes"
 	"context"
 	"fmt"
@@ -57,3 +58,55 @@ func TestPush(t *testing.T) {
 		t.Errorf("push failed and returned %v, want %v", err.Error(), nil)
 	}
 }
+
+func TestPushFromStdin(t *testing.T) {
+	originalDoPush := doPush
+	originalStdin := stdin
+	defer func() {
+		doPush = originalDoPush
+		stdin = originalStdin
+	}()
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	settings := []byte("projectId: my-project\n")
+	if err := tw.WriteHeader(&tar.Header{Name: "settings/settings.yaml", Typeflag: tar.TypeReg, Mode: 0640, Size: int64(len(settings))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stdin = &b
+	var pushed project.Project
+	doPush = func(ctx context.Context, cmd *cobra.Command, args []string, proj project.Project) error {
+		pushed = proj
+		return nil
+	}
+	if _, err := execute("push", "--from-stdin"); err != nil {
+		t.Fatalf("push --from-stdin returned %v, want %v", err, nil)
+	}
+	if pushed == nil || pushed.ProjectID() != "my-project" || pushed.ProjectRoot() != "" {
+		t.Errorf("push --from-stdin pushed %+v, want an archive of my-project", pushed)
+	}
+	if _, err := execute("push", "--from-stdin", "--incremental"); err == nil {
+		t.Errorf("push --from-stdin --incremental returned nil, want an error")
+	}
+}
+
+func TestPushWatchIncremental(t *testing.T) {
+	root := &cobra.Command{}
+	proj := studio.New([]byte{}, ".")
+	AddCommand(context.Background(), root, proj)
+	cmd, _, err := root.Find([]string{"push"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags([]string{"--watch", "--incremental"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doPush(context.Background(), cmd, nil, proj); err == nil {
+		t.Errorf("push --watch --incremental returned nil, want an error")
+	}
+}
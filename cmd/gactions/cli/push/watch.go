@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// watchPollInterval is how often the project files are checked for changes with --watch.
+// Polling the modification times of the files is cheap, and doesn't need a file system
+// notification API on each platform.
+var watchPollInterval = time.Second
+
+// fileStamp identifies the content of a file on disk without reading it.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// projectStamps returns the stamps of the files of proj by path. Ignored files aren't
+// project files, so they don't trigger a push.
+func projectStamps(proj project.Project) (map[string]fileStamp, error) {
+	refs, err := proj.FileRefs()
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]fileStamp{}
+	for k, v := range refs {
+		s := fileStamp{size: v.Size}
+		if v.Path != "" {
+			if info, err := os.Stat(v.Path); err == nil {
+				s.modTime = info.ModTime()
+			}
+		}
+		res[k] = s
+	}
+	return res, nil
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		w, ok := b[k]
+		if !ok || v.size != w.size || !v.modTime.Equal(w.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// watch runs push, and runs it again each time the files of proj change, until ctx is done.
+// Changes are debounced: push runs once the files didn't change for a whole poll interval, so
+// saving several files at once pushes them together. Failed pushes are reported, and the
+// files are pushed again on the next change.
+func watch(ctx context.Context, proj project.Project, push func() error) error {
+	last, err := projectStamps(proj)
+	if err != nil {
+		return err
+	}
+	run := func() {
+		if err := push(); err != nil && ctx.Err() == nil {
+			log.Error(err)
+		}
+		if ctx.Err() == nil {
+			log.Outf("Watching %v for changes. Press Ctrl+C to stop.\n", proj.ProjectRoot())
+		}
+	}
+	run()
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchPollInterval):
+		}
+		cur, err := projectStamps(proj)
+		if err != nil {
+			// A file may be removed while the project is read, so it's read again on the next poll.
+			log.Infof("Failed to read the project files: %v\n", err)
+			continue
+		}
+		if !sameStamps(cur, last) {
+			last = cur
+			pending = true
+			continue
+		}
+		if pending {
+			pending = false
+			log.Outf("Files changed.\n")
+			run()
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/actions-on-google/gactions/project/studio"
+)
+
+func TestWatchPushesOnChange(t *testing.T) {
+	og := watchPollInterval
+	defer func() { watchPollInterval = og }()
+	watchPollInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "gactions-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest := filepath.Join(dir, "manifest.yaml")
+	if err := ioutil.WriteFile(manifest, []byte("version: 1.0\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	proj := studio.New([]byte("secret"), dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pushes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- watch(ctx, proj, func() error {
+			pushes <- struct{}{}
+			return nil
+		})
+	}()
+	select {
+	case <-pushes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch didn't push the files when it started")
+	}
+	if err := ioutil.WriteFile(manifest, []byte("version: 1.0.1\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-pushes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch didn't push the files after they changed")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watch returned %v after the context was canceled, want %v", err, nil)
+	}
+}
+
+func TestSameStamps(t *testing.T) {
+	now := time.Now()
+	a := map[string]fileStamp{"manifest.yaml": {size: 1, modTime: now}}
+	tests := []struct {
+		b    map[string]fileStamp
+		want bool
+	}{
+		{b: map[string]fileStamp{"manifest.yaml": {size: 1, modTime: now}}, want: true},
+		{b: map[string]fileStamp{"manifest.yaml": {size: 2, modTime: now}}, want: false},
+		{b: map[string]fileStamp{"manifest.yaml": {size: 1, modTime: now.Add(time.Second)}}, want: false},
+		{b: map[string]fileStamp{"settings/settings.yaml": {size: 1, modTime: now}}, want: false},
+		{b: map[string]fileStamp{}, want: false},
+	}
+	for _, tc := range tests {
+		if got := sameStamps(a, tc.b); got != tc.want {
+			t.Errorf("sameStamps(%v, %v) returned %v, want %v", a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quota provides an implementation of "gactions quota" command.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// warnPercent is the usage of a quota, in percent of its limit, from which a warning is printed.
+const warnPercent = 80
+
+// AddCommand adds the quota sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	quota := &cobra.Command{
+		Use:   "quota",
+		Short: "Show the quota limits of the Actions API on the project and how much of them is used.",
+		Long: "This command shows the quota limits of the Actions API on the project, and how much of them is used: requests of the current day for daily limits, " +
+			"which are reset at midnight Pacific Time, and requests of the last minute for limits per minute. The usage is read from Cloud Monitoring of the project.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			pid, err := cmd.Flags().GetString("project-id")
+			if err != nil {
+				return err
+			}
+			if err := (&studioProj).SetProjectID(pid); err != nil {
+				return err
+			}
+			res, err := sdk.ReadQuotaJSON(ctx, studioProj)
+			if err != nil {
+				return err
+			}
+			if len(res) == 0 {
+				log.Outf("The Actions API has no quotas on %v.\n", studioProj.ProjectID())
+				return nil
+			}
+			printQuota(os.Stdout, res)
+			for _, v := range res {
+				if p, ok := percent(v); ok && p >= warnPercent {
+					log.Warnf("%v per %v is at %d%% of its limit.\n", name(v), period(v), p)
+				}
+			}
+			return nil
+		},
+	}
+	quota.Flags().String("project-id", "", "Show the quota of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	root.AddCommand(quota)
+}
+
+func name(q sdk.QuotaUsage) string {
+	if q.DisplayName != "" {
+		return q.DisplayName
+	}
+	return q.Metric
+}
+
+func period(q sdk.QuotaUsage) string {
+	if q.PerDay() {
+		return "day"
+	}
+	return "minute"
+}
+
+// percent returns the usage of q in percent of its limit, and false if q is unlimited.
+func percent(q sdk.QuotaUsage) (int64, bool) {
+	switch {
+	case q.Limit < 0:
+		return 0, false
+	case q.Limit == 0:
+		return 100, true
+	}
+	return q.Usage * 100 / q.Limit, true
+}
+
+func printQuota(out io.Writer, quotas []sdk.QuotaUsage) {
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Quota\tPer\tUsed\tLimit\tUsage")
+	for _, v := range quotas {
+		limit, usage := "unlimited", "-"
+		if p, ok := percent(v); ok {
+			limit = fmt.Sprint(v.Limit)
+			usage = fmt.Sprintf("%d%%", p)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", name(v), period(v), v.Usage, limit, usage)
+	}
+	w.Flush()
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrintQuota(t *testing.T) {
+	in := []sdk.QuotaUsage{
+		{Metric: "actions.googleapis.com/default_requests", DisplayName: "Requests", Unit: "1/min/{project}", Limit: 600, Usage: 540},
+		{Metric: "actions.googleapis.com/default_requests", DisplayName: "Requests", Unit: "1/d/{project}", Limit: -1, Usage: 12000},
+		{Metric: "actions.googleapis.com/write_requests", Unit: "1/d/{project}", Limit: 0},
+	}
+	want := `Quota                                  Per     Used   Limit      Usage
+Requests                               minute  540    600        90%
+Requests                               day     12000  unlimited  -
+actions.googleapis.com/write_requests  day     0      0          100%
+`
+	var b bytes.Buffer
+	printQuota(&b, in)
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printQuota wrote diff (-want, +got)\n%s", diff)
+	}
+}
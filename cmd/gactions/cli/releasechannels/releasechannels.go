@@ -18,11 +18,13 @@ package releasechannels
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"text/tabwriter"
 
 	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
@@ -60,32 +62,144 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 			if err != nil {
 				return err
 			}
-			printReleaseChannels(res)
-			return nil
+			return printReleaseChannels(os.Stdout, res)
 		},
 	}
 	list.Flags().String("project-id", "", "List release channels of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
 	releaseChannels.AddCommand(list)
+	get := &cobra.Command{
+		Use:   "get <release channel>",
+		Short: "This command shows details of a release channel, and of its current and pending versions.",
+		Long:  "This command shows details of a release channel, and of its current and pending versions. The release channel can be specified by its ID (i.e. actions.channels.Production) or by its name shown by \"release-channels list\" (i.e. prod).",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			studioProj, ok := project.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", project, studio.Studio{})
+			}
+			pid, err := cmd.Flags().GetString("project-id")
+			if err != nil {
+				return err
+			}
+			if err := (&studioProj).SetProjectID(pid); err != nil {
+				return err
+			}
+			rc, err := sdk.ReadReleaseChannelJSON(ctx, studioProj, args[0])
+			if err != nil {
+				return err
+			}
+			versions, err := sdk.ListVersionsJSON(ctx, studioProj)
+			if err != nil {
+				return err
+			}
+			return printReleaseChannel(os.Stdout, rc, versions)
+		},
+	}
+	get.Flags().String("project-id", "", "Get the release channel of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	releaseChannels.AddCommand(get)
 	root.AddCommand(releaseChannels)
 }
 
-func printReleaseChannels(releaseChannels []project.ReleaseChannel) {
-	w := new(tabwriter.Writer)
-	// Format in tab-separated columns with a tab stop of 8.
-	w.Init(os.Stdout, 40, 8, 1, '\t', 0)
-	fmt.Fprintln(w, "Release Channel\tCurrent Version\tPending Version\t")
+// channelOutput is a release channel in the json and yaml output formats.
+type channelOutput struct {
+	Name           string `json:"name" yaml:"name"`
+	CurrentVersion string `json:"currentVersion,omitempty" yaml:"currentVersion,omitempty"`
+	PendingVersion string `json:"pendingVersion,omitempty" yaml:"pendingVersion,omitempty"`
+}
+
+// versionOutput is a version of a release channel in the json and yaml output formats.
+type versionOutput struct {
+	Version        string `json:"version" yaml:"version"`
+	Status         string `json:"status,omitempty" yaml:"status,omitempty"`
+	LastModifiedBy string `json:"lastModifiedBy,omitempty" yaml:"lastModifiedBy,omitempty"`
+	ModifiedOn     string `json:"modifiedOn,omitempty" yaml:"modifiedOn,omitempty"`
+}
+
+// channelDetailsOutput is a release channel with its versions in the json and yaml output formats.
+type channelDetailsOutput struct {
+	Name           string         `json:"name" yaml:"name"`
+	CurrentVersion *versionOutput `json:"currentVersion,omitempty" yaml:"currentVersion,omitempty"`
+	PendingVersion *versionOutput `json:"pendingVersion,omitempty" yaml:"pendingVersion,omitempty"`
+}
+
+func printReleaseChannels(out io.Writer, releaseChannels []project.ReleaseChannel) error {
+	res := []channelOutput{}
 	for _, releaseChannel := range releaseChannels {
-		fmt.Fprintf(w, "%v\t%v\t%v\t\n", releaseChannelName(releaseChannel.Name), versionID(releaseChannel.CurrentVersion), versionID(releaseChannel.PendingVersion))
+		res = append(res, channelOutput{
+			Name:           releaseChannelName(releaseChannel.Name),
+			CurrentVersion: versionID(releaseChannel.CurrentVersion),
+			PendingVersion: versionID(releaseChannel.PendingVersion),
+		})
+	}
+	return log.Render(out, res, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		// Format in tab-separated columns with a tab stop of 8.
+		w.Init(out, 40, 8, 1, '\t', 0)
+		fmt.Fprintln(w, "Release Channel\tCurrent Version\tPending Version\t")
+		for _, c := range res {
+			fmt.Fprintf(w, "%v\t%v\t%v\t\n", orNA(c.Name), orNA(c.CurrentVersion), orNA(c.PendingVersion))
+		}
+		fmt.Fprintf(w, "To learn more about release channels, visit https://developers.google.com/assistant/actionssdk/reference/rest/Shared.Types/ReleaseChannel.")
+		fmt.Fprintln(w)
+		return w.Flush()
+	})
+}
+
+func printReleaseChannel(out io.Writer, releaseChannel project.ReleaseChannel, versions []project.Version) error {
+	res := channelDetailsOutput{
+		Name:           releaseChannelName(releaseChannel.Name),
+		CurrentVersion: channelVersion(releaseChannel.CurrentVersion, versions),
+		PendingVersion: channelVersion(releaseChannel.PendingVersion, versions),
+	}
+	return log.Render(out, res, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		// Format in tab-separated columns with a tab stop of 8.
+		w.Init(out, 20, 8, 1, '\t', 0)
+		fmt.Fprintf(w, "Release Channel:\t%v\t\n", orNA(res.Name))
+		fmt.Fprintln(w, "\tVersion\tStatus\tLast Modified By\tModified On\t")
+		for _, v := range []struct {
+			label   string
+			version *versionOutput
+		}{
+			{"Current", res.CurrentVersion},
+			{"Pending", res.PendingVersion},
+		} {
+			if v.version == nil {
+				v.version = &versionOutput{}
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t\n", v.label, orNA(v.version.Version), orNA(v.version.Status), orNA(v.version.LastModifiedBy), orNA(v.version.ModifiedOn))
+		}
+		return w.Flush()
+	})
+}
+
+// channelVersion returns the details of version name from versions, or nil if
+// the release channel has no such version.
+func channelVersion(name string, versions []project.Version) *versionOutput {
+	id := versionID(name)
+	if id == "" {
+		return nil
+	}
+	res := &versionOutput{Version: id}
+	for _, version := range versions {
+		if version.ID == name {
+			res.Status, res.LastModifiedBy, res.ModifiedOn = version.State.Message, version.LastModifiedBy, version.ModifiedOn
+		}
+	}
+	return res
+}
+
+func orNA(s string) string {
+	if s == "" {
+		return "N/A"
 	}
-	fmt.Fprintf(w, "To learn more about release channels, visit https://developers.google.com/assistant/actionssdk/reference/rest/Shared.Types/ReleaseChannel.")
-	fmt.Fprintln(w)
-	w.Flush()
+	return s
 }
 
 func releaseChannelName(releaseChannel string) string {
 	releaseChannelMatch := releaseChannelNameRegExp.FindStringSubmatch(releaseChannel)
 	if releaseChannelMatch == nil {
-		return "N/A"
+		return ""
 	}
 	releaseChannelName := releaseChannelMatch[releaseChannelNameRegExp.SubexpIndex("releaseChannelName")]
 
@@ -105,7 +219,7 @@ func releaseChannelName(releaseChannel string) string {
 
 func versionID(version string) string {
 	if versionIDMatch := versionIDRegExp.FindStringSubmatch(version); versionIDMatch == nil {
-		return "N/A"
+		return ""
 	}
 	return versionIDRegExp.FindStringSubmatch(version)[versionIDRegExp.SubexpIndex("versionID")]
 }
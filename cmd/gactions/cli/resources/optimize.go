@@ -0,0 +1,305 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	imagesDir = "resources/images/"
+	audioDir  = "resources/audio/"
+)
+
+// size is the width and height of an image in pixels.
+type size struct {
+	w, h int
+}
+
+func (s size) String() string {
+	return fmt.Sprintf("%dx%d", s.w, s.h)
+}
+
+// parseSize parses a size formatted as <width>x<height>, e.g. 1920x1080.
+func parseSize(s string) (size, error) {
+	var sz size
+	if _, err := fmt.Sscanf(s, "%dx%d", &sz.w, &sz.h); err != nil || sz.w <= 0 || sz.h <= 0 || sz.String() != s {
+		return size{}, fmt.Errorf("%q is not a size in pixels, e.g. 1920x1080", s)
+	}
+	return sz, nil
+}
+
+// logoSizes contains the dimensions required for the logos set in the settings of a project.
+var logoSizes = map[string]size{
+	"smallLogoImage":   {192, 192},
+	"largeBannerImage": {1920, 1080},
+}
+
+// logoNames returns the names of image resources used as logos in the base and localized
+// settings, mapped to their required dimensions.
+func logoNames(files map[string][]byte) map[string]size {
+	names := map[string]size{}
+	for k, v := range files {
+		if !studio.IsSettings(k) {
+			continue
+		}
+		var s map[string]interface{}
+		if err := yaml.Unmarshal(v, &s); err != nil {
+			// Syntax errors are reported by push, so the file is skipped here.
+			continue
+		}
+		for key, sz := range logoSizes {
+			if ref, ok := s[key].(string); ok && strings.HasPrefix(ref, "$resources.images.") {
+				names[strings.TrimPrefix(ref, "$resources.images.")] = sz
+			}
+		}
+	}
+	return names
+}
+
+// resourceName returns the name of an image or audio resource, which is its filename without
+// the extension. Localized resources are kept in the directories of locales under the same name.
+func resourceName(filename string) string {
+	base := path.Base(filename)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// fit returns the size of an image scaled down to fit within max, keeping its aspect ratio.
+// Images within max are not scaled.
+func fit(src, max size) size {
+	if src.w <= max.w && src.h <= max.h {
+		return src
+	}
+	// Compare max.w/src.w and max.h/src.h without rounding.
+	if max.w*src.h <= max.h*src.w {
+		return size{max.w, maxInt(1, src.h*max.w/src.w)}
+	}
+	return size{maxInt(1, src.w*max.h/src.h), max.h}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// scale returns img resized to dst. Each pixel is the average of the pixels of img it covers,
+// which keeps the details of downscaled images better than picking the nearest pixel.
+func scale(img image.Image, dst size) *image.NRGBA {
+	b := img.Bounds()
+	res := image.NewNRGBA(image.Rect(0, 0, dst.w, dst.h))
+	for y := 0; y < dst.h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/dst.h
+		y1 := maxInt(y0+1, b.Min.Y+(y+1)*b.Dy()/dst.h)
+		for x := 0; x < dst.w; x++ {
+			x0 := b.Min.X + x*b.Dx()/dst.w
+			x1 := maxInt(x0+1, b.Min.X+(x+1)*b.Dx()/dst.w)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			res.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return res
+}
+
+// optimizeImage scales a PNG or JPEG image down to fit within max and encodes it again. It
+// returns nil if the image can't be made smaller, or if its format isn't supported.
+func optimizeImage(filename string, b []byte, max size, jpegQuality int) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		if err == image.ErrFormat {
+			log.Infof("Skipping %v, only PNG and JPEG images are optimized.\n", filename)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("can't decode %v: %v", filename, err)
+	}
+	src := size{img.Bounds().Dx(), img.Bounds().Dy()}
+	dst := fit(src, max)
+	scaled := dst != src
+	if scaled {
+		log.Infof("Scaling %v from %v to %v.\n", filename, src, dst)
+		img = scale(img, dst)
+	}
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case "png":
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	default:
+		log.Infof("Skipping %v, only PNG and JPEG images are optimized.\n", filename)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't encode %v: %v", filename, err)
+	}
+	// An image within the limits is kept as is unless encoding it again saves space.
+	if !scaled && buf.Len() >= len(b) {
+		return nil, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// findFFmpeg returns an error if ffmpeg, which transcodes audio, isn't installed. It's replaced in
+// tests.
+var findFFmpeg = func() error {
+	_, err := exec.LookPath("ffmpeg")
+	return err
+}
+
+// transcode converts the audio file src to dst, an MP3 file with the given bitrate. It's
+// replaced in tests.
+var transcode = func(ctx context.Context, src, dst, bitrate string) error {
+	args := []string{"-y", "-loglevel", "error", "-i", src, "-codec:a", "libmp3lame", "-b:a", bitrate, "-ar", "24000", dst}
+	log.Infof("Running ffmpeg %v\n", strings.Join(args, " "))
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg %v failed: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// optimizeAudio transcodes an MP3 or WAV file to MP3 with the given bitrate and sample rate of
+// 24 kHz, preferred by Assistant. It returns the path of the result, which is an MP3 file in
+// place of a WAV file, and nil if an MP3 file can't be made smaller.
+func optimizeAudio(ctx context.Context, filename string, b []byte, bitrate string) (string, []byte, error) {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext != ".mp3" && ext != ".wav" {
+		log.Infof("Skipping %v, only MP3 and WAV audio is optimized.\n", filename)
+		return "", nil, nil
+	}
+	dir, err := ioutil.TempDir("", "gactions-audio")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src"+ext)
+	if err := ioutil.WriteFile(src, b, 0640); err != nil {
+		return "", nil, err
+	}
+	dst := filepath.Join(dir, "dst.mp3")
+	if err := transcode(ctx, src, dst, bitrate); err != nil {
+		return "", nil, err
+	}
+	res, err := ioutil.ReadFile(dst)
+	if err != nil {
+		return "", nil, err
+	}
+	if ext == ".mp3" && len(res) >= len(b) {
+		return "", nil, nil
+	}
+	// References to resources omit the extension, so they still refer to the MP3 file.
+	return strings.TrimSuffix(filename, path.Ext(filename)) + ".mp3", res, nil
+}
+
+// result is an optimized resource file.
+type result struct {
+	from, to string // to differs from from when the format of the file changed
+	before   int
+	payload  []byte
+}
+
+// options of "gactions resources optimize".
+type options struct {
+	maxImageSize size
+	jpegQuality  int
+	audioBitrate string
+	skipAudio    bool
+}
+
+// optimize returns the optimized resources among the files of a project.
+func optimize(ctx context.Context, files map[string][]byte, opts options) ([]result, error) {
+	logos := logoNames(files)
+	var names []string
+	for k := range files {
+		if strings.HasPrefix(k, imagesDir) || strings.HasPrefix(k, audioDir) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	if !opts.skipAudio {
+		if err := findFFmpeg(); err != nil {
+			log.Warnf("Can't find ffmpeg in PATH, so audio files are not optimized. Install ffmpeg or pass --skip-audio.\n")
+			opts.skipAudio = true
+		}
+	}
+	var res []result
+	for _, k := range names {
+		b := files[k]
+		if strings.HasPrefix(k, imagesDir) {
+			max := opts.maxImageSize
+			if sz, ok := logos[resourceName(k)]; ok {
+				max = sz
+				checkAspectRatio(k, b, sz)
+			}
+			out, err := optimizeImage(k, b, max, opts.jpegQuality)
+			if err != nil {
+				return nil, err
+			}
+			if out != nil {
+				res = append(res, result{from: k, to: k, before: len(b), payload: out})
+			}
+			continue
+		}
+		if opts.skipAudio {
+			continue
+		}
+		to, out, err := optimizeAudio(ctx, k, b, opts.audioBitrate)
+		if err != nil {
+			return nil, err
+		}
+		if out != nil {
+			res = append(res, result{from: k, to: to, before: len(b), payload: out})
+		}
+	}
+	return res, nil
+}
+
+// checkAspectRatio warns if a logo doesn't have the aspect ratio it's required to have, because
+// scaling can't fix it without cropping the logo.
+func checkAspectRatio(filename string, b []byte, want size) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return
+	}
+	if cfg.Width*want.h != cfg.Height*want.w {
+		log.Warnf("%v is %dx%d, but it's used as a logo which needs to be %v. Crop it to the same aspect ratio.\n", filename, cfg.Width, cfg.Height, want)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSize(t *testing.T) {
+	if got, err := parseSize("1920x1080"); err != nil || got != (size{1920, 1080}) {
+		t.Errorf("parseSize returned (%v, %v), want (%v, %v)", got, err, size{1920, 1080}, nil)
+	}
+	for _, s := range []string{"", "1920", "0x10", "10x-1", "10x10px", "x10"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("parseSize(%q) returned %v, want an error", s, err)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		src, max, want size
+	}{
+		{src: size{100, 50}, max: size{192, 192}, want: size{100, 50}},
+		{src: size{400, 200}, max: size{192, 192}, want: size{192, 96}},
+		{src: size{200, 400}, max: size{192, 192}, want: size{96, 192}},
+		{src: size{3840, 2160}, max: size{1920, 1080}, want: size{1920, 1080}},
+		{src: size{5000, 1}, max: size{100, 100}, want: size{100, 1}},
+	}
+	for _, tc := range tests {
+		if got := fit(tc.src, tc.max); got != tc.want {
+			t.Errorf("fit(%v, %v) returned %v, want %v", tc.src, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestLogoNames(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml":       []byte("smallLogoImage: $resources.images.square\nlargeBannerImage: $resources.images.banner\n"),
+		"settings/fr/settings.yaml":    []byte("smallLogoImage: $resources.images.squareFr\n"),
+		"settings/de/settings.yaml":    []byte("smallLogoImage: https://example.com/logo.png\n"),
+		"custom/scenes/Main.yaml":      []byte("smallLogoImage: $resources.images.scene\n"),
+		"resources/images/square.png":  []byte("abc"),
+		"resources/images/banner.jpeg": []byte("abc"),
+	}
+	want := map[string]size{
+		"square":   {192, 192},
+		"squareFr": {192, 192},
+		"banner":   {1920, 1080},
+	}
+	if diff := cmp.Diff(want, logoNames(files), cmp.AllowUnexported(size{})); diff != "" {
+		t.Errorf("logoNames returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func encodePNG(t *testing.T, sz size) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, sz.w, sz.h))
+	for y := 0; y < sz.h; y++ {
+		for x := 0; x < sz.w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x ^ y), 255})
+		}
+	}
+	var b bytes.Buffer
+	// Images encoded with the best compression can't be made smaller without scaling them.
+	if err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&b, img); err != nil {
+		t.Fatalf("png.Encode returned %v", err)
+	}
+	return b.Bytes()
+}
+
+func decodedSize(t *testing.T, b []byte) size {
+	t.Helper()
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("image.DecodeConfig returned %v", err)
+	}
+	return size{cfg.Width, cfg.Height}
+}
+
+func TestOptimize(t *testing.T) {
+	ogFind, ogTranscode := findFFmpeg, transcode
+	t.Cleanup(func() {
+		findFFmpeg, transcode = ogFind, ogTranscode
+	})
+	findFFmpeg = func() error { return nil }
+	transcode = func(ctx context.Context, src, dst, bitrate string) error {
+		if bitrate != "48k" {
+			t.Errorf("transcode was called with bitrate %v, want 48k", bitrate)
+		}
+		return ioutil.WriteFile(dst, []byte("mp3"), 0640)
+	}
+	files := map[string][]byte{
+		"settings/settings.yaml":            []byte("smallLogoImage: $resources.images.square\n"),
+		"resources/images/square.png":       encodePNG(t, size{300, 300}),
+		"resources/images/fr/square.png":    encodePNG(t, size{150, 150}),
+		"resources/images/background.png":   encodePNG(t, size{400, 200}),
+		"resources/images/icon.png":         encodePNG(t, size{100, 100}),
+		"resources/images/animation.gif":    []byte("GIF89a"),
+		"resources/audio/welcome.wav":       []byte("RIFF....WAVE"),
+		"resources/audio/chime.mp3":         []byte("ID"),
+		"resources/strings/en/strings.yaml": []byte("hello: Hello\n"),
+	}
+	res, err := optimize(context.Background(), files, options{maxImageSize: size{200, 200}, jpegQuality: 85, audioBitrate: "48k"})
+	if err != nil {
+		t.Fatalf("optimize returned %v, want %v", err, nil)
+	}
+	got := map[string]string{}
+	sizes := map[string]size{}
+	for _, r := range res {
+		got[r.from] = r.to
+		if r.to != "resources/audio/welcome.mp3" {
+			sizes[r.to] = decodedSize(t, r.payload)
+		}
+	}
+	// The icon and the localized logo are within the limits, and the MP3 file can't be made smaller.
+	wantFiles := map[string]string{
+		"resources/images/square.png":     "resources/images/square.png",
+		"resources/images/background.png": "resources/images/background.png",
+		"resources/audio/welcome.wav":     "resources/audio/welcome.mp3",
+	}
+	if diff := cmp.Diff(wantFiles, got); diff != "" {
+		t.Errorf("optimize returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+	wantSizes := map[string]size{
+		"resources/images/square.png":     {192, 192},
+		"resources/images/background.png": {200, 100},
+	}
+	if diff := cmp.Diff(wantSizes, sizes, cmp.AllowUnexported(size{})); diff != "" {
+		t.Errorf("optimize returned images of incorrect sizes: diff (-want, +got)\n%s", diff)
+	}
+}
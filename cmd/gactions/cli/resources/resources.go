@@ -0,0 +1,193 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resources provides an implementation of "gactions resources" command.
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the resources sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	resources := &cobra.Command{
+		Use:   "resources",
+		Short: "This is the main command for working with the image and audio resources of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the image and audio resources of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	optimizeCmd := &cobra.Command{
+		Use:   "optimize",
+		Short: "Shrink the image and audio resources of the project.",
+		Long: "This command scales down PNG and JPEG images larger than --max-image-size and encodes them again, and transcodes MP3 and WAV audio to MP3 at 24 kHz with --audio-bitrate, to keep the project under the size limits. " +
+			"Images used as smallLogoImage or largeBannerImage in the settings are scaled down to the dimensions required for logos. " +
+			"WAV files are replaced with MP3 files of the same name, so $resources references to them keep working. Transcoding audio requires ffmpeg. " +
+			"The files are changed in place, unless --out-dir is set, in which case the project is copied to the directory with the optimized resources.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			opts, err := parseOptions(cmd)
+			if err != nil {
+				return err
+			}
+			outDir, err := cmd.Flags().GetString("out-dir")
+			if err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			res, err := optimize(ctx, files, opts)
+			if err != nil {
+				return err
+			}
+			if outDir != "" {
+				if err := writeOutDir(proj.ProjectRoot(), outDir, files, res); err != nil {
+					return err
+				}
+			} else if err := writeInPlace(proj.ProjectRoot(), res); err != nil {
+				return err
+			}
+			if len(res) == 0 {
+				log.Outln("The resources are already optimized.")
+				return nil
+			}
+			if err := printResults(os.Stdout, res); err != nil {
+				return err
+			}
+			log.DoneMsgln(fmt.Sprintf("Optimized %v resource files.", len(res)))
+			return nil
+		},
+	}
+	optimizeCmd.Flags().String("out-dir", "", "Copy the project with the optimized resources to this directory, instead of changing the files in place.")
+	optimizeCmd.Flags().String("max-image-size", "1920x1080", "Maximum width and height of the images which are not logos, in pixels.")
+	optimizeCmd.Flags().Int("jpeg-quality", 85, "Quality of the encoded JPEG images, from 1 to 100.")
+	optimizeCmd.Flags().String("audio-bitrate", "64k", "Bitrate of the transcoded audio. Assistant supports 24k to 96k.")
+	optimizeCmd.Flags().Bool("skip-audio", false, "Don't transcode audio, e.g. when ffmpeg is not installed.")
+	resources.AddCommand(optimizeCmd)
+	root.AddCommand(resources)
+}
+
+func checkRoot(proj project.Project) error {
+	if proj.ProjectRoot() == "" {
+		log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+		return errors.New("can not determine project root")
+	}
+	return nil
+}
+
+func parseOptions(cmd *cobra.Command) (options, error) {
+	var opts options
+	s, err := cmd.Flags().GetString("max-image-size")
+	if err != nil {
+		return opts, err
+	}
+	if opts.maxImageSize, err = parseSize(s); err != nil {
+		return opts, fmt.Errorf("--max-image-size: %v", err)
+	}
+	if opts.jpegQuality, err = cmd.Flags().GetInt("jpeg-quality"); err != nil {
+		return opts, err
+	}
+	if opts.jpegQuality < 1 || opts.jpegQuality > 100 {
+		return opts, fmt.Errorf("--jpeg-quality must be between 1 and 100, got %v", opts.jpegQuality)
+	}
+	if opts.audioBitrate, err = cmd.Flags().GetString("audio-bitrate"); err != nil {
+		return opts, err
+	}
+	opts.skipAudio, err = cmd.Flags().GetBool("skip-audio")
+	return opts, err
+}
+
+// writeInPlace replaces the resources of the project in root with their optimized versions.
+func writeInPlace(root string, res []result) error {
+	for _, r := range res {
+		if err := ioutil.WriteFile(filepath.Join(root, filepath.FromSlash(r.to)), r.payload, 0640); err != nil {
+			return err
+		}
+		if r.to != r.from {
+			if err := os.Remove(filepath.Join(root, filepath.FromSlash(r.from))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeOutDir writes files of the project in root to dir, with the resources replaced by
+// their optimized versions.
+func writeOutDir(root, dir string, files map[string][]byte, res []result) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(absRoot, absDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("--out-dir %v can't be inside of the project, because its files would be read as the files of the project", dir)
+	}
+	out := map[string][]byte{}
+	for k, v := range files {
+		out[k] = v
+	}
+	for _, r := range res {
+		delete(out, r.from)
+		out[r.to] = r.payload
+	}
+	for k, v := range out {
+		fp := filepath.Join(dir, filepath.FromSlash(k))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(fp, v, 0640); err != nil {
+			return err
+		}
+	}
+	log.Outf("Copied the project to %v\n", dir)
+	return nil
+}
+
+func printResults(out io.Writer, res []result) error {
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "File\tBefore\tAfter")
+	before, after := 0, 0
+	for _, r := range res {
+		name := r.from
+		if r.to != r.from {
+			name = fmt.Sprintf("%v -> %v", r.from, r.to)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\n", name, r.before, len(r.payload))
+		before += r.before
+		after += len(r.payload)
+	}
+	fmt.Fprintf(w, "Total\t%v\t%v\n", before, after)
+	return w.Flush()
+}
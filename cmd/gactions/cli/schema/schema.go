@@ -0,0 +1,122 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema provides an implementation of "gactions schema" command.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	draft07 = "http://json-schema.org/draft-07/schema#"
+	// mappingFilename is the file with the schema mapping for the YAML language server.
+	mappingFilename = "yaml-schemas.json"
+)
+
+// AddCommand adds the schema sub-command to the passed in root command.
+func AddCommand(root *cobra.Command) {
+	schema := &cobra.Command{
+		Use:   "schema",
+		Short: "This is the main command for working with the JSON Schemas of the config files. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the JSON Schemas of the config files. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Write JSON Schemas of the config files for editor tooling.",
+		Long: "This command writes a JSON Schema for each type of config files of an Actions SDK project, " +
+			fmt.Sprintf("and a %v file which maps the schemas to the files they apply to. ", mappingFilename) +
+			"Copy the \"yaml.schemas\" setting from that file to .vscode/settings.json to get autocomplete and validation with the YAML extension of VS Code, " +
+			"or to the settings of any editor that uses the YAML language server.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			out, err := cmd.Flags().GetString("output")
+			if err != nil {
+				return err
+			}
+			return export(out)
+		},
+	}
+	export.Flags().String("output", filepath.Join(".vscode", "schemas"), "Directory to write the schemas to, relative to the root of the editor workspace.")
+	schema.AddCommand(export)
+	root.AddCommand(schema)
+}
+
+// schemaFiles returns the content of the schema files by filename, and the mapping of the YAML
+// language server from the schema paths, relative to the workspace, to the globs of config files.
+func schemaFiles(dir string) (map[string][]byte, map[string][]string, error) {
+	files := map[string][]byte{}
+	mapping := map[string][]string{}
+	for _, fs := range fileSchemas() {
+		s := jsonSchema{
+			"$schema":     draft07,
+			"title":       fs.name,
+			"definitions": definitions(),
+		}
+		for k, v := range fs.schema {
+			s[k] = v
+		}
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return nil, nil, err
+		}
+		name := fs.name + ".schema.json"
+		files[name] = append(b, '\n')
+		var globs []string
+		for _, p := range fs.patterns {
+			// The project may be in a subdirectory of the workspace.
+			globs = append(globs, "**/"+p)
+		}
+		p := path.Join(filepath.ToSlash(dir), name)
+		if !filepath.IsAbs(dir) {
+			p = "./" + p
+		}
+		mapping[p] = globs
+	}
+	return files, mapping, nil
+}
+
+func export(dir string) error {
+	files, mapping, err := schemaFiles(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	for k, v := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, k), v, 0640); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(map[string]interface{}{"yaml.schemas": mapping}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fp := filepath.Join(dir, mappingFilename)
+	if err := ioutil.WriteFile(fp, append(b, '\n'), 0640); err != nil {
+		return err
+	}
+	log.DoneMsgln(fmt.Sprintf(`Wrote %d schemas to %v. Copy the "yaml.schemas" setting from %v to the settings of your editor.`, len(files), dir, fp))
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// refs returns the targets of all $ref keywords within v.
+func refs(v interface{}) []string {
+	var res []string
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if s, ok := e.(string); ok && k == "$ref" {
+				res = append(res, s)
+			}
+			res = append(res, refs(e)...)
+		}
+	case []interface{}:
+		for _, e := range t {
+			res = append(res, refs(e)...)
+		}
+	}
+	return res
+}
+
+func TestSchemaFilesRefsResolve(t *testing.T) {
+	files, _, err := schemaFiles(".vscode/schemas")
+	if err != nil {
+		t.Fatalf("schemaFiles returned %v, want nil", err)
+	}
+	for k, v := range files {
+		var s map[string]interface{}
+		if err := json.Unmarshal(v, &s); err != nil {
+			t.Fatalf("%v is not valid JSON: %v", k, err)
+		}
+		defs, _ := s["definitions"].(map[string]interface{})
+		for _, r := range refs(s) {
+			name := strings.TrimPrefix(r, "#/definitions/")
+			if _, ok := defs[name]; !ok {
+				t.Errorf("%v refers to %v, which is not defined", k, r)
+			}
+		}
+	}
+}
+
+func TestSchemaFilesMapping(t *testing.T) {
+	_, mapping, err := schemaFiles(".vscode/schemas")
+	if err != nil {
+		t.Fatalf("schemaFiles returned %v, want nil", err)
+	}
+	tests := map[string][]string{
+		"sdk/manifest.yaml":                            {"./.vscode/schemas/manifest.schema.json"},
+		"sdk/settings/settings.yaml":                   {"./.vscode/schemas/settings.schema.json"},
+		"sdk/settings/fr/settings.yaml":                {"./.vscode/schemas/localized-settings.schema.json"},
+		"sdk/actions/actions.yaml":                     {"./.vscode/schemas/actions.schema.json"},
+		"sdk/custom/intents/order.yaml":                {"./.vscode/schemas/intent.schema.json"},
+		"sdk/custom/intents/fr/order.yaml":             {"./.vscode/schemas/intent.schema.json"},
+		"sdk/custom/scenes/Main.yaml":                  {"./.vscode/schemas/scene.schema.json"},
+		"sdk/custom/prompts/fr/bye.yaml":               {"./.vscode/schemas/prompt.schema.json"},
+		"sdk/webhooks/ActionsOnGoogleFulfillment.yaml": {"./.vscode/schemas/webhook.schema.json"},
+		"sdk/settings/accountLinkingSecret.yaml":       {"./.vscode/schemas/account-linking-secret.schema.json"},
+		"sdk/webhooks/fn/package.json":                 nil,
+	}
+	for file, want := range tests {
+		var got []string
+		for schema, globs := range mapping {
+			for _, g := range globs {
+				if globRegExp(g).MatchString(file) {
+					got = append(got, schema)
+				}
+			}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mapping for %v has diff (-want, +got)\n%s", file, diff)
+		}
+	}
+}
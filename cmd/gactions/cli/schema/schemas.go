@@ -0,0 +1,329 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+// jsonSchema is a JSON Schema document, or a part of it.
+type jsonSchema map[string]interface{}
+
+// prop is a property of an object schema.
+type prop struct {
+	name   string
+	schema jsonSchema
+}
+
+// describe adds a description to s, unless desc is empty.
+func describe(s jsonSchema, desc string) jsonSchema {
+	if desc != "" {
+		s["description"] = desc
+	}
+	return s
+}
+
+func obj(desc string, props ...prop) jsonSchema {
+	p := map[string]interface{}{}
+	for _, v := range props {
+		p[v.name] = v.schema
+	}
+	return describe(jsonSchema{"type": "object", "properties": p}, desc)
+}
+
+func str(desc string) jsonSchema {
+	return describe(jsonSchema{"type": "string"}, desc)
+}
+
+func boolean(desc string) jsonSchema {
+	return describe(jsonSchema{"type": "boolean"}, desc)
+}
+
+func enum(desc string, values ...string) jsonSchema {
+	return describe(jsonSchema{"type": "string", "enum": values}, desc)
+}
+
+func arr(desc string, items jsonSchema) jsonSchema {
+	return describe(jsonSchema{"type": "array", "items": items}, desc)
+}
+
+// mapOf is an object with arbitrary keys and values of the same schema.
+func mapOf(desc string, values jsonSchema) jsonSchema {
+	return describe(jsonSchema{"type": "object", "additionalProperties": values}, desc)
+}
+
+// ref refers to a definition shared by the schemas, see definitions.
+func ref(name string) jsonSchema {
+	return jsonSchema{"$ref": "#/definitions/" + name}
+}
+
+// definitions are the types used by several config files.
+func definitions() map[string]interface{} {
+	simple := obj("A simple prompt with speech and text.",
+		prop{"variants", arr("Variants of the prompt; one of them is selected at random.", obj("",
+			prop{"speech", str("Speech of the prompt, as SSML or text.")},
+			prop{"text", str("Text shown in the chat bubble; speech is used if not set.")},
+		))},
+	)
+	image := obj("An image.",
+		prop{"url", str("URL of the image, or a reference to an image resource, e.g. $resources.images.logo.")},
+		prop{"alt", str("Accessibility text of the image.")},
+		prop{"height", jsonSchema{"type": "integer"}},
+		prop{"width", jsonSchema{"type": "integer"}},
+	)
+	return map[string]interface{}{
+		"staticPrompt": obj("A static prompt with candidates for different surfaces.",
+			prop{"candidates", arr("Candidates of the prompt; the first candidate whose selector matches the surface is used.", obj("",
+				prop{"selector", obj("Criteria for the surfaces the candidate applies to.",
+					prop{"surfaceCapabilities", obj("",
+						prop{"capabilities", arr("", enum("", "SPEECH", "RICH_RESPONSE", "LONG_FORM_AUDIO", "INTERACTIVE_CANVAS", "WEB_LINK", "HOME_STORAGE"))},
+					)},
+				)},
+				prop{"promptResponse", obj("The response of the candidate.",
+					prop{"firstSimple", simple},
+					prop{"lastSimple", simple},
+					prop{"content", obj("Rich content of the response, such as a card, image, table, media or list.",
+						prop{"card", obj("",
+							prop{"title", str("")},
+							prop{"subtitle", str("")},
+							prop{"text", str("")},
+							prop{"image", image},
+							prop{"imageFill", enum("", "UNSPECIFIED", "WHITE", "BLACK", "GRAY")},
+							prop{"button", obj("", prop{"name", str("")}, prop{"open", ref("openUrl")})},
+						)},
+						prop{"image", image},
+						prop{"table", jsonSchema{"type": "object"}},
+						prop{"media", jsonSchema{"type": "object"}},
+						prop{"collection", jsonSchema{"type": "object"}},
+						prop{"collectionBrowse", jsonSchema{"type": "object"}},
+						prop{"list", jsonSchema{"type": "object"}},
+					)},
+					prop{"suggestions", arr("Suggestion chips.", obj("", prop{"title", str("Text of the chip, up to 25 characters.")}))},
+					prop{"link", obj("A link to an Android app or a website.", prop{"name", str("")}, prop{"open", ref("openUrl")})},
+					prop{"canvas", obj("An Interactive Canvas response.",
+						prop{"url", str("URL of the Interactive Canvas web app.")},
+						prop{"data", arr("Data passed to the web app.", jsonSchema{})},
+						prop{"suppressMic", boolean("Don't open the mic after the response.")},
+						prop{"enableFullScreen", boolean("")},
+						prop{"continueTtsDuringTouch", boolean("")},
+					)},
+					prop{"override", boolean("Replace the prompts added so far instead of appending to them.")},
+				)},
+			))},
+		),
+		"openUrl": obj("",
+			prop{"url", str("")},
+			prop{"hint", enum("", "LINK_UNSPECIFIED", "AMP")},
+		),
+		"handler": obj("Actions taken when an event is triggered.",
+			prop{"webhookHandler", str("Name of the webhook handler to call.")},
+			prop{"staticPrompt", ref("staticPrompt")},
+			prop{"staticPromptName", str("Name of a static prompt in custom/prompts.")},
+		),
+		"intentEvent": obj("An event triggered when an intent is matched.",
+			prop{"intent", str("Name of the intent.")},
+			prop{"transitionToScene", str("Scene to go to, or actions.scene.END_CONVERSATION.")},
+			prop{"handler", ref("handler")},
+		),
+		"conditionalEvent": obj("An event triggered when a condition becomes true.",
+			prop{"condition", str(`Condition, e.g. scene.slots.status == "FINAL".`)},
+			prop{"transitionToScene", str("Scene to go to, or actions.scene.END_CONVERSATION.")},
+			prop{"handler", ref("handler")},
+		),
+		"localizedSettings": obj("Settings shown in the Assistant directory and used by the Assistant.",
+			prop{"displayName", str("Name of the Action, used to invoke it.")},
+			prop{"pronunciation", str("")},
+			prop{"shortDescription", str("")},
+			prop{"fullDescription", str("")},
+			prop{"smallLogoImage", str("")},
+			prop{"largeBannerImage", str("")},
+			prop{"developerName", str("")},
+			prop{"developerEmail", str("")},
+			prop{"termsOfServiceUrl", str("")},
+			prop{"voice", str("")},
+			prop{"voiceLocale", str("")},
+			prop{"privacyPolicyUrl", str("")},
+			prop{"sampleInvocations", arr("", str(""))},
+			prop{"themeCustomization", jsonSchema{"type": "object"}},
+		),
+	}
+}
+
+// fileSchema is the schema of a type of config files, with the patterns of the files it applies to.
+type fileSchema struct {
+	name     string
+	patterns []string
+	schema   jsonSchema
+}
+
+// fileSchemas returns the schemas of all types of config files of a project.
+func fileSchemas() []fileSchema {
+	return []fileSchema{
+		{
+			name:     "manifest",
+			patterns: []string{"manifest.yaml"},
+			schema:   obj("Manifest of an Actions project.", prop{"version", str("Version of the file format.")}),
+		},
+		{
+			name:     "settings",
+			patterns: []string{"settings/settings.yaml"},
+			schema: obj("Settings of an Actions project.",
+				prop{"projectId", str("ID of the Google Cloud project of the Action.")},
+				prop{"defaultLocale", str("Default locale of the project, e.g. en.")},
+				prop{"enabledRegions", arr("Regions where the Action is available.", str(""))},
+				prop{"disabledRegions", arr("Regions where the Action is not available.", str(""))},
+				prop{"category", enum("Category of the Action.", "CATEGORY_UNSPECIFIED", "BUSINESS_AND_FINANCE", "EDUCATION_AND_REFERENCE",
+					"FOOD_AND_DRINK", "GAMES_AND_TRIVIA", "HEALTH_AND_FITNESS", "KIDS_AND_FAMILY", "LIFESTYLE", "LOCAL", "MOVIES_AND_TV",
+					"MUSIC_AND_AUDIO", "NEWS", "NOVELTY_AND_HUMOR", "PRODUCTIVITY", "SHOPPING", "SOCIAL", "SPORTS", "TRAVEL_AND_TRANSPORTATION",
+					"UTILITIES", "WEATHER", "HOME_CONTROL")},
+				prop{"usesTransactionsApi", boolean("")},
+				prop{"usesDigitalPurchaseApi", boolean("")},
+				prop{"usesInteractiveCanvas", boolean("")},
+				prop{"usesHomeStorage", boolean("")},
+				prop{"designedForFamily", boolean("")},
+				prop{"containsAlcoholOrTobaccoContent", boolean("")},
+				prop{"keepsMicOpen", boolean("")},
+				prop{"surfaceRequirements", jsonSchema{"type": "object"}},
+				prop{"testingInstructions", str("Instructions for the reviewers of the Action.")},
+				prop{"localizedSettings", ref("localizedSettings")},
+				prop{"accountLinking", obj("Account linking settings.",
+					prop{"enableAccountCreation", boolean("Allow users to create an account by voice.")},
+					prop{"linkingType", enum("", "LINKING_TYPE_UNSPECIFIED", "GOOGLE_SIGN_IN", "OAUTH_AND_GOOGLE_SIGN_IN", "OAUTH")},
+					prop{"authGrantType", enum("", "AUTH_GRANT_TYPE_UNSPECIFIED", "AUTH_CODE", "IMPLICIT")},
+					prop{"appClientId", str("Client ID issued by your OAuth server to Google.")},
+					prop{"authorizationUrl", str("Endpoint of your sign-in page.")},
+					prop{"tokenUrl", str("Endpoint for token exchange.")},
+					prop{"scopes", arr("Scopes requested from users.", str(""))},
+					prop{"learnMoreUrl", str("")},
+					prop{"useBasicAuthHeader", boolean("Send the client credentials in the Authorization header.")},
+				)},
+				prop{"selectedAndroidApps", arr("", str(""))},
+			),
+		},
+		{
+			name:     "localized-settings",
+			patterns: []string{"settings/*/settings.yaml"},
+			schema:   obj("Settings of an Actions project for a locale.", prop{"localizedSettings", ref("localizedSettings")}),
+		},
+		{
+			name:     "actions",
+			patterns: []string{"actions/actions.yaml"},
+			schema: obj("Invocations of an Actions project.",
+				prop{"custom", mapOf("Intents that invoke the Action, by intent name.", obj("",
+					prop{"engagement", obj("Ways to re-engage users.",
+						prop{"title", str("")},
+						prop{"pushNotification", jsonSchema{"type": "object"}},
+						prop{"dailyUpdate", jsonSchema{"type": "object"}},
+						prop{"actionLink", obj("", prop{"title", str("")})},
+						prop{"assistantLink", obj("", prop{"title", str("")})},
+					)},
+				))},
+			),
+		},
+		{
+			name:     "intent",
+			patterns: []string{"custom/intents/**/*.yaml"},
+			schema: obj("An intent.",
+				prop{"parameters", arr("Parameters of the intent.", obj("",
+					prop{"name", str("Name of the parameter.")},
+					prop{"type", obj("", prop{"name", str("Name of the type.")})},
+					prop{"entitySetReferences", obj("", prop{"entitySetReferences", arr("", obj("", prop{"entitySet", str("")}))})},
+				))},
+				prop{"trainingPhrases", arr("Training phrases, with parameters annotated as ($name 'value' auto=true).", str(""))},
+			),
+		},
+		{
+			name:     "global",
+			patterns: []string{"custom/global/*.yaml"},
+			schema: obj("A global intent handler.",
+				prop{"handler", ref("handler")},
+				prop{"transitionToScene", str("Scene to go to, or actions.scene.END_CONVERSATION.")},
+			),
+		},
+		{
+			name:     "scene",
+			patterns: []string{"custom/scenes/*.yaml"},
+			schema: obj("A scene.",
+				prop{"onEnter", ref("handler")},
+				prop{"intentEvents", arr("", ref("intentEvent"))},
+				prop{"conditionalEvents", arr("", ref("conditionalEvent"))},
+				prop{"slots", arr("Slots to fill in the scene.", obj("",
+					prop{"name", str("")},
+					prop{"required", boolean("")},
+					prop{"type", obj("", prop{"name", str("Name of the type.")}, prop{"list", boolean("")})},
+					prop{"promptSettings", mapOf("Prompts used while filling the slot, e.g. initialPrompt.", ref("handler"))},
+					prop{"commitBehavior", obj("", prop{"writeSessionParam", str("")})},
+					prop{"config", jsonSchema{}},
+					prop{"defaultValue", obj("", prop{"sessionParam", str("")}, prop{"constant", jsonSchema{}})},
+				))},
+				prop{"onSlotUpdated", ref("handler")},
+			),
+		},
+		{
+			name:     "type",
+			patterns: []string{"custom/types/**/*.yaml"},
+			schema: obj("A type.",
+				prop{"synonym", obj("",
+					prop{"matchType", enum("", "UNSPECIFIED", "EXACT_MATCH", "FUZZY_MATCH")},
+					prop{"acceptUnknownValues", boolean("")},
+					prop{"entities", mapOf("Entities by key.", obj("",
+						prop{"display", jsonSchema{"type": "object"}},
+						prop{"synonyms", arr("", str(""))},
+					))},
+				)},
+				prop{"regularExpression", obj("",
+					prop{"entities", mapOf("Entities by key.", obj("",
+						prop{"display", jsonSchema{"type": "object"}},
+						prop{"regularExpressions", arr("", str(""))},
+					))},
+				)},
+				prop{"freeText", jsonSchema{"type": "object"}},
+				prop{"exclusions", obj("", prop{"phrases", arr("", str(""))})},
+			),
+		},
+		{
+			name:     "entity-set",
+			patterns: []string{"custom/entitySets/*.yaml"},
+			schema:   obj("An entity set.", prop{"entities", arr("", obj("", prop{"id", str("")}))}),
+		},
+		{
+			name:     "prompt",
+			patterns: []string{"custom/prompts/**/*.yaml"},
+			schema:   ref("staticPrompt"),
+		},
+		{
+			name:     "webhook",
+			patterns: []string{"webhooks/*.yaml"},
+			schema: obj("A webhook.",
+				prop{"handlers", arr("", obj("", prop{"name", str("")}))},
+				prop{"httpsEndpoint", obj("",
+					prop{"baseUrl", str("")},
+					prop{"headers", mapOf("", str(""))},
+					prop{"endpointApiVersion", jsonSchema{"type": "integer"}},
+				)},
+				prop{"inlineCloudFunction", obj("", prop{"executeFunction", str("Name of the exported function.")})},
+			),
+		},
+		{
+			name:     "resource-bundle",
+			patterns: []string{"resources/strings/**/*.yaml"},
+			schema:   jsonSchema{"type": "object", "description": "Localized strings, referenced as $resources.strings.<key>."},
+		},
+		{
+			name:     "account-linking-secret",
+			patterns: []string{"settings/accountLinkingSecret.yaml", "settings/accountLinkingSecret.*.yaml"},
+			schema: obj("Encrypted client secret for account linking, written by \"gactions encrypt\".",
+				prop{"encryptedClientSecret", str("")},
+				prop{"encryptionKeyVersion", jsonSchema{"type": "integer"}},
+			),
+		},
+	}
+}
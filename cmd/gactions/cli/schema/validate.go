@@ -0,0 +1,187 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Problem is a value of a config file which doesn't match the schema of the file.
+type Problem struct {
+	File string `json:"file"`
+	// Path is the location of the value in the file, e.g. candidates[0].promptResponse.
+	Path string `json:"path"`
+	// Warning is set for unknown fields, because the schemas don't describe every field the
+	// Actions API accepts.
+	Warning bool   `json:"warning,omitempty"`
+	Message string `json:"message"`
+}
+
+// globRegExp converts a glob of the YAML language server, where "**/" matches any number of
+// directories, to a regular expression.
+func globRegExp(glob string) *regexp.Regexp {
+	r := regexp.QuoteMeta(glob)
+	r = strings.ReplaceAll(r, `\*\*/`, `(.*/)?`)
+	r = strings.ReplaceAll(r, `\*`, `[^/]*`)
+	return regexp.MustCompile("^" + r + "$")
+}
+
+// schemaFor returns the schema of the config file called filename, if there is one.
+func schemaFor(filename string) (fileSchema, bool) {
+	for _, fs := range fileSchemas() {
+		for _, p := range fs.patterns {
+			if globRegExp(p).MatchString(filename) {
+				return fs, true
+			}
+		}
+	}
+	return fileSchema{}, false
+}
+
+// validator checks values against schemas which may refer to defs.
+type validator struct {
+	file     string
+	defs     map[string]interface{}
+	problems []Problem
+}
+
+func (v *validator) add(p string, warning bool, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{File: v.file, Path: p, Warning: warning, Message: fmt.Sprintf(format, args...)})
+}
+
+// resolve returns the schema s refers to, or s if it isn't a reference.
+func (v *validator) resolve(s jsonSchema) jsonSchema {
+	for {
+		r, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		s = v.defs[strings.TrimPrefix(r, "#/definitions/")].(jsonSchema)
+	}
+}
+
+// kind returns the JSON Schema type of a value decoded from YAML.
+func kind(val interface{}) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[interface{}]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+func join(p, key string) string {
+	if p == "" {
+		return key
+	}
+	return p + "." + key
+}
+
+// validate checks val at the path p against s. Null values are accepted, as the API treats
+// them as missing.
+func (v *validator) validate(s jsonSchema, val interface{}, p string) {
+	s = v.resolve(s)
+	if val == nil {
+		return
+	}
+	want, _ := s["type"].(string)
+	got := kind(val)
+	if want != "" && want != got && !(want == "number" && got == "integer") {
+		v.add(p, false, "want %v, got %v", want, got)
+		return
+	}
+	if values, ok := s["enum"].([]string); ok {
+		for _, e := range values {
+			if e == val {
+				return
+			}
+		}
+		v.add(p, false, "%q is not one of %v", val, strings.Join(values, ", "))
+		return
+	}
+	switch val := val.(type) {
+	case []interface{}:
+		items, ok := s["items"].(jsonSchema)
+		if !ok {
+			return
+		}
+		for i, e := range val {
+			v.validate(items, e, fmt.Sprintf("%v[%d]", p, i))
+		}
+	case map[interface{}]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		additional, _ := s["additionalProperties"].(jsonSchema)
+		var keys []string
+		for k := range val {
+			keys = append(keys, fmt.Sprint(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e := val[k]
+			switch {
+			case props[k] != nil:
+				v.validate(props[k].(jsonSchema), e, join(p, k))
+			case additional != nil:
+				v.validate(additional, e, join(p, k))
+			case props != nil:
+				v.add(join(p, k), true, "unknown field %q", k)
+			}
+		}
+	}
+}
+
+// Validate checks the config files in files against their schemas, and returns the problems
+// ordered by file. Files with syntax errors are skipped; "gactions lsp" and the validate command
+// report them.
+func Validate(files map[string][]byte) []Problem {
+	defs := definitions()
+	var names []string
+	for k := range files {
+		if path.Ext(k) == ".yaml" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	var res []Problem
+	for _, k := range names {
+		fs, ok := schemaFor(k)
+		if !ok {
+			continue
+		}
+		var val interface{}
+		if err := yaml.Unmarshal(files[k], &val); err != nil {
+			continue
+		}
+		v := &validator{file: k, defs: defs}
+		v.validate(fs.schema, val, "")
+		res = append(res, v.problems...)
+	}
+	return res
+}
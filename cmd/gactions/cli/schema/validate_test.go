@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGlobRegExp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"custom/intents/**/*.yaml", "custom/intents/order.yaml", true},
+		{"custom/intents/**/*.yaml", "custom/intents/fr/order.yaml", true},
+		{"custom/scenes/*.yaml", "custom/scenes/fr/Main.yaml", false},
+		{"settings/settings.yaml", "settings/fr/settings.yaml", false},
+		{"settings/accountLinkingSecret.*.yaml", "settings/accountLinkingSecret.staging.yaml", true},
+	}
+	for _, tc := range tests {
+		if got := globRegExp(tc.pattern).MatchString(tc.name); got != tc.want {
+			t.Errorf("globRegExp(%q) matches %q: %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	files := map[string][]byte{
+		"manifest.yaml": []byte("version: \"1.0\"\n"),
+		"settings/settings.yaml": []byte(`defaultLocale: en
+category: GAMES
+usesTransactionsApi: "yes"
+localizedSettings:
+  displayName: Trivia
+  sampleInvocations: Talk to Trivia
+`),
+		"custom/types/size.yaml": []byte(`synonym:
+  matchType: EXACT_MATCH
+  entities:
+    large:
+      synonyms: [large, big]
+    small:
+      synonym: [small]
+`),
+		"custom/intents/order.yaml":                      []byte("trainingPhrases:\n- order\n"),
+		"custom/global/broken.yaml":                      []byte("transitionToScene: [Main\n"),
+		"webhooks/ActionsOnGoogleFulfillment/index.yaml": []byte("not: a config file\n"),
+		"resources/strings/en.yaml":                      []byte("greeting: Hi\n"),
+		"custom/scenes/Main.yaml":                        []byte("- onEnter\n"),
+	}
+	want := []Problem{
+		{File: "custom/scenes/Main.yaml", Message: "want object, got array"},
+		{File: "custom/types/size.yaml", Path: "synonym.entities.small.synonym", Warning: true, Message: `unknown field "synonym"`},
+		{File: "settings/settings.yaml", Path: "category", Message: `"GAMES" is not one of ` + "CATEGORY_UNSPECIFIED, BUSINESS_AND_FINANCE, EDUCATION_AND_REFERENCE, FOOD_AND_DRINK, GAMES_AND_TRIVIA, HEALTH_AND_FITNESS, KIDS_AND_FAMILY, LIFESTYLE, LOCAL, MOVIES_AND_TV, MUSIC_AND_AUDIO, NEWS, NOVELTY_AND_HUMOR, PRODUCTIVITY, SHOPPING, SOCIAL, SPORTS, TRAVEL_AND_TRANSPORTATION, UTILITIES, WEATHER, HOME_CONTROL"},
+		{File: "settings/settings.yaml", Path: "localizedSettings.sampleInvocations", Message: "want array, got string"},
+		{File: "settings/settings.yaml", Path: "usesTransactionsApi", Message: "want boolean, got string"},
+	}
+	if diff := cmp.Diff(want, Validate(files)); diff != "" {
+		t.Errorf("Validate returned diff (-want, +got)\n%s", diff)
+	}
+}
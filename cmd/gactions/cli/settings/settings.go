@@ -0,0 +1,95 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package settings provides an implementation of "gactions settings" command.
+package settings
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the settings sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	settings := &cobra.Command{
+		Use:   "settings",
+		Short: "This is the main command for working with the settings of a project in Actions Console. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the settings of a project in Actions Console. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	get := &cobra.Command{
+		Use:   "get",
+		Short: "Print the settings of the draft or of a version of the project in Actions Console.",
+		Long: "This command prints the settings of the project in Actions Console, as they would be written to settings/settings.yaml by pull, without changing the local files. " +
+			"The settings are read from the draft, or from the version specified by --version-id.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			pid, err := cmd.Flags().GetString("project-id")
+			if err != nil {
+				return err
+			}
+			if err := (&studioProj).SetProjectID(pid); err != nil {
+				return err
+			}
+			versionID, err := cmd.Flags().GetString("version-id")
+			if err != nil {
+				return err
+			}
+			locale, err := cmd.Flags().GetString("locale")
+			if err != nil {
+				return err
+			}
+			files, err := sdk.ReadSettingsJSON(ctx, studioProj, versionID)
+			if err != nil {
+				return err
+			}
+			b, err := settingsFile(files, locale)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(b)
+			return err
+		},
+	}
+	get.Flags().String("project-id", "", "Get the settings of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	get.Flags().String("version-id", "", "Get the settings of the version specified by the ID instead of the draft.")
+	get.Flags().String("locale", "", "Get the settings of the locale instead of the base settings.")
+	settings.AddCommand(get)
+	root.AddCommand(settings)
+}
+
+// settingsFile returns the settings file of locale from files, or the base settings file if
+// locale is empty.
+func settingsFile(files map[string][]byte, locale string) ([]byte, error) {
+	p := path.Join("settings", locale, "settings.yaml")
+	b, ok := files[p]
+	if !ok {
+		if locale != "" {
+			return nil, fmt.Errorf("settings for the locale %q were not found", locale)
+		}
+		return nil, fmt.Errorf("%v was not found", p)
+	}
+	return b, nil
+}
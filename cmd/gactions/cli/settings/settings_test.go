@@ -0,0 +1,44 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"testing"
+)
+
+func TestSettingsFile(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml":    []byte("defaultLocale: en\n"),
+		"settings/fr/settings.yaml": []byte("localizedSettings:\n  displayName: Bonjour\n"),
+	}
+	tests := []struct {
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{locale: "", want: "defaultLocale: en\n"},
+		{locale: "fr", want: "localizedSettings:\n  displayName: Bonjour\n"},
+		{locale: "de", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := settingsFile(files, tc.locale)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("settingsFile(%q) returned %v, want error %v", tc.locale, err, tc.wantErr)
+		}
+		if string(got) != tc.want {
+			t.Errorf("settingsFile(%q) returned %q, want %q", tc.locale, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot provides an implementation of "gactions snapshot" command.
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the snapshot sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	snapshot := &cobra.Command{
+		Use:   "snapshot",
+		Short: "This is the main command for working with the snapshots of the draft of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the snapshots of the draft of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Save a copy of the draft of the project in Actions Console.",
+		Long: "This command saves a copy of the draft of the project in Actions Console, without changing the local files. " +
+			fmt.Sprintf("Snapshots are kept in the %v directory of the project, and the draft can be restored from a snapshot with \"gactions snapshot restore\".", studio.StateDir),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			studioProj, err := setup(proj, cmd)
+			if err != nil {
+				return err
+			}
+			msg, err := cmd.Flags().GetString("message")
+			if err != nil {
+				return err
+			}
+			return create(ctx, studioProj, msg)
+		},
+	}
+	create.Flags().String("project-id", "", "Save the draft of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	create.Flags().StringP("message", "m", "", "Description of the snapshot.")
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the snapshots of the project.",
+		Long:  "This command lists the snapshots of the project, oldest first.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			snapshots, err := studio.ListSnapshots(proj.ProjectRoot())
+			if err != nil {
+				return err
+			}
+			if len(snapshots) == 0 {
+				log.Outln(`No snapshots found. Run "gactions snapshot create" to take one.`)
+				return nil
+			}
+			return printSnapshots(os.Stdout, snapshots)
+		},
+	}
+	restore := &cobra.Command{
+		Use:   "restore <snapshot ID>",
+		Short: "Replace the draft of the project in Actions Console with a snapshot.",
+		Long: "This command pushes the files of a snapshot to the draft of the project the snapshot was taken from, replacing the current draft. " +
+			"The local files are not changed. Run \"gactions snapshot list\" to see the IDs of the snapshots.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			s, err := studio.ReadSnapshot(proj.ProjectRoot(), args[0])
+			if err != nil {
+				return err
+			}
+			studioProj, ok := proj.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+			}
+			if err := (&studioProj).SetProjectID(s.ProjectID); err != nil {
+				return err
+			}
+			return sdk.RestoreDraftJSON(ctx, studioProj, studio.SnapshotFilesDir(proj.ProjectRoot(), s.ID))
+		},
+	}
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove all but the latest snapshots of the project.",
+		Long: fmt.Sprintf("This command removes all but the latest snapshots of the project. The number of snapshots to keep is set by --keep, or by snapshotsToKeep in %v. ", project.ConfigName) +
+			"If snapshotsToKeep is set, \"gactions snapshot create\" also prunes the snapshots after saving a new one.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := checkRoot(proj); err != nil {
+				return err
+			}
+			keep, err := cmd.Flags().GetInt("keep")
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("keep") {
+				cfg, err := studio.ReadCLIConfig()
+				if err != nil {
+					return err
+				}
+				if cfg.SnapshotsToKeep <= 0 {
+					return fmt.Errorf("the number of snapshots to keep is not set; use --keep, or set snapshotsToKeep in %v", project.ConfigName)
+				}
+				keep = cfg.SnapshotsToKeep
+			}
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+			return prune(proj.ProjectRoot(), keep, dryRun)
+		},
+	}
+	prune.Flags().Int("keep", 0, fmt.Sprintf("Number of the latest snapshots to keep. Overrides snapshotsToKeep in %v.", project.ConfigName))
+	prune.Flags().Bool("dry-run", false, "List the snapshots which would be removed, without removing them.")
+	snapshot.AddCommand(create)
+	snapshot.AddCommand(list)
+	snapshot.AddCommand(restore)
+	snapshot.AddCommand(prune)
+	root.AddCommand(snapshot)
+}
+
+func checkRoot(proj project.Project) error {
+	if proj.ProjectRoot() == "" {
+		log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+		return errors.New("can not determine project root")
+	}
+	return nil
+}
+
+func setup(proj project.Project, cmd *cobra.Command) (studio.Studio, error) {
+	if err := checkRoot(proj); err != nil {
+		return studio.Studio{}, err
+	}
+	studioProj, ok := proj.(studio.Studio)
+	if !ok {
+		return studio.Studio{}, fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+	}
+	pid, err := cmd.Flags().GetString("project-id")
+	if err != nil {
+		return studio.Studio{}, err
+	}
+	if err := (&studioProj).SetProjectID(pid); err != nil {
+		return studio.Studio{}, err
+	}
+	return studioProj, nil
+}
+
+func create(ctx context.Context, proj studio.Studio, msg string) error {
+	root := proj.ProjectRoot()
+	s := studio.NewSnapshot(proj.ProjectID(), msg)
+	if _, err := studio.ReadSnapshot(root, s.ID); err == nil {
+		return fmt.Errorf("snapshot %q already exists, try again in a second", s.ID)
+	}
+	log.Outf("Saving the draft of the project %q...\n", s.ProjectID)
+	if err := sdk.SnapshotDraftJSON(ctx, proj, studio.SnapshotFilesDir(root, s.ID)); err != nil {
+		if err2 := studio.RemoveSnapshot(root, s.ID); err2 != nil {
+			log.Warnf("Failed to remove the incomplete snapshot %q: %v\n", s.ID, err2)
+		}
+		return err
+	}
+	if err := studio.WriteSnapshot(root, s); err != nil {
+		return err
+	}
+	log.DoneMsgln(fmt.Sprintf(`Snapshot %v was saved. To restore the draft from it, run "gactions snapshot restore %v".`, s.ID, s.ID))
+	cfg, err := studio.ReadCLIConfig()
+	if err != nil {
+		log.Warnf("Failed to read %v, so the old snapshots were not pruned: %v\n", project.ConfigName, err)
+		return nil
+	}
+	if cfg.SnapshotsToKeep > 0 {
+		return prune(root, cfg.SnapshotsToKeep, false)
+	}
+	return nil
+}
+
+func prune(root string, keep int, dryRun bool) error {
+	removed, err := studio.PruneSnapshots(root, keep, dryRun)
+	for _, s := range removed {
+		if dryRun {
+			log.Outf("Would remove snapshot %v.\n", s.ID)
+		} else {
+			log.Outf("Removed snapshot %v.\n", s.ID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		log.Outf("No snapshots to remove; the project has %v or fewer snapshots.\n", keep)
+	}
+	return nil
+}
+
+func printSnapshots(out io.Writer, snapshots []studio.Snapshot) error {
+	w := new(tabwriter.Writer)
+	w.Init(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Snapshot\tTime\tProject\tUser\tMessage\t")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t\n", s.ID, s.Time.Local().Format(time.RFC3339), s.ProjectID, s.User, s.Message)
+	}
+	return w.Flush()
+}
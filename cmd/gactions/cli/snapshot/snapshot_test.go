@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-on-google/gactions/project/studio"
+)
+
+func TestPrintSnapshots(t *testing.T) {
+	snapshots := []studio.Snapshot{
+		{ID: "20210301T100000Z", Time: time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC), ProjectID: "hello-world", User: "dschrute@scranton", Message: "before release"},
+		{ID: "20210302T100000Z", Time: time.Date(2021, 3, 2, 10, 0, 0, 0, time.UTC), ProjectID: "hello-world", User: "dschrute@scranton"},
+	}
+	var b bytes.Buffer
+	if err := printSnapshots(&b, snapshots); err != nil {
+		t.Fatalf("printSnapshots returned %v, want %v", err, nil)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("printSnapshots printed %d lines, want 3:\n%s", len(lines), b.String())
+	}
+	for i, want := range []string{"Snapshot", "20210301T100000Z", "20210302T100000Z"} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("printSnapshots printed %q in line %d, want a line starting with %q", lines[i], i, want)
+		}
+	}
+	if !strings.Contains(lines[1], "before release") {
+		t.Errorf("printSnapshots printed %q, want the message of the snapshot", lines[1])
+	}
+}
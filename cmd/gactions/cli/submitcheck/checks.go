@@ -0,0 +1,271 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package submitcheck
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	// Decoders of the image formats supported for logos.
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	imagesDir = "resources/images/"
+	// maxDisplayNameLen, maxShortDescriptionLen and maxFullDescriptionLen are the maximum
+	// lengths in characters accepted by the Actions Console.
+	maxDisplayNameLen      = 50
+	maxShortDescriptionLen = 80
+	maxFullDescriptionLen  = 4000
+	// maxSampleInvocations is the maximum number of sample invocations shown in the directory.
+	maxSampleInvocations = 5
+)
+
+// logoSize is the width and height in pixels required for a logo.
+type logoSize struct {
+	w, h int
+}
+
+// logoSizes contains the dimensions required for the logos of the localized settings.
+var logoSizes = []struct {
+	key  string
+	size logoSize
+}{
+	{"smallLogoImage", logoSize{192, 192}},
+	{"largeBannerImage", logoSize{1920, 1080}},
+}
+
+// result is the outcome of a submission check.
+type result struct {
+	name    string
+	passed  bool
+	message string
+}
+
+func pass(name, format string, v ...interface{}) result {
+	return result{name: name, passed: true, message: fmt.Sprintf(format, v...)}
+}
+
+func fail(name, format string, v ...interface{}) result {
+	return result{name: name, message: fmt.Sprintf(format, v...)}
+}
+
+// localizedSettings are the localized settings of a locale and the file they are read from.
+type localizedSettings struct {
+	locale   string
+	filename string
+	settings map[string]interface{}
+}
+
+// settingsFiles returns the settings of the project in files and its localized settings by
+// locale, sorted by locale with the default locale first.
+func settingsFiles(files map[string][]byte) (map[string]interface{}, []localizedSettings, error) {
+	var base map[string]interface{}
+	var localized []localizedSettings
+	for k, v := range files {
+		if !studio.IsSettings(k) {
+			continue
+		}
+		var mp map[string]interface{}
+		if err := yaml.Unmarshal(v, &mp); err != nil {
+			return nil, nil, fmt.Errorf("%v has incorrect syntax: %v", k, err)
+		}
+		ls, _ := mp["localizedSettings"].(map[interface{}]interface{})
+		settings := map[string]interface{}{}
+		for key, val := range ls {
+			settings[fmt.Sprint(key)] = val
+		}
+		dir := path.Base(path.Dir(k))
+		if dir == "settings" {
+			base = mp
+			dir = ""
+		}
+		localized = append(localized, localizedSettings{locale: dir, filename: k, settings: settings})
+	}
+	if base == nil {
+		return nil, nil, fmt.Errorf("settings/settings.yaml is not found")
+	}
+	def, _ := base["defaultLocale"].(string)
+	for i := range localized {
+		if localized[i].locale == "" {
+			localized[i].locale = def
+		}
+	}
+	sort.Slice(localized, func(i, j int) bool {
+		if (localized[i].locale == def) != (localized[j].locale == def) {
+			return localized[i].locale == def
+		}
+		return localized[i].locale < localized[j].locale
+	})
+	return base, localized, nil
+}
+
+// checkCategory checks that a category is set in the settings.
+func checkCategory(base map[string]interface{}) result {
+	const name = "Category"
+	c, _ := base["category"].(string)
+	if c == "" || c == "CATEGORY_UNSPECIFIED" {
+		return fail(name, "category is not set in settings/settings.yaml")
+	}
+	return pass(name, "%v", c)
+}
+
+// checkText checks that the value of key in ls is set and, if max is positive, has at most
+// max characters.
+func checkText(ls localizedSettings, key string, max int) result {
+	name := fmt.Sprintf("%v (%v)", key, ls.locale)
+	s, _ := ls.settings[key].(string)
+	n := utf8.RuneCountInString(strings.TrimSpace(s))
+	switch {
+	case n == 0:
+		return fail(name, "%v is not set in %v", key, ls.filename)
+	case max > 0 && n > max:
+		return fail(name, "%v has %v characters, but can have at most %v", key, n, max)
+	}
+	return pass(name, "%v characters", n)
+}
+
+// checkSampleInvocations checks the number of sample invocations in ls.
+func checkSampleInvocations(ls localizedSettings) result {
+	name := fmt.Sprintf("sampleInvocations (%v)", ls.locale)
+	inv, _ := ls.settings["sampleInvocations"].([]interface{})
+	switch {
+	case len(inv) == 0:
+		return fail(name, "at least one sample invocation is required in %v", ls.filename)
+	case len(inv) > maxSampleInvocations:
+		return fail(name, "%v sample invocations, but at most %v are allowed", len(inv), maxSampleInvocations)
+	}
+	return pass(name, "%v sample invocations", len(inv))
+}
+
+// imageResource returns the name and content of the image resource called res for locale.
+// A localized image takes precedence over the image for all locales.
+func imageResource(files map[string][]byte, res, locale string) (string, []byte, bool) {
+	var found string
+	for k := range files {
+		if !strings.HasPrefix(k, imagesDir) {
+			continue
+		}
+		base := path.Base(k)
+		if strings.TrimSuffix(base, path.Ext(base)) != res {
+			continue
+		}
+		switch path.Dir(k) {
+		case path.Join(imagesDir, locale):
+			return k, files[k], true
+		case strings.TrimSuffix(imagesDir, "/"):
+			found = k
+		}
+	}
+	if found == "" {
+		return "", nil, false
+	}
+	return found, files[found], true
+}
+
+// checkLogo checks that the logo set by key in ls is an image resource of the required size.
+func checkLogo(files map[string][]byte, ls localizedSettings, key string, want logoSize) result {
+	name := fmt.Sprintf("%v (%v)", key, ls.locale)
+	ref, _ := ls.settings[key].(string)
+	if ref == "" {
+		return fail(name, "%v is not set in %v, it must be a %dx%d image", key, ls.filename, want.w, want.h)
+	}
+	if !strings.HasPrefix(ref, "$resources.images.") {
+		return pass(name, "%v is not an image resource of the project, so its size is not checked", ref)
+	}
+	filename, b, ok := imageResource(files, strings.TrimPrefix(ref, "$resources.images."), ls.locale)
+	if !ok {
+		return fail(name, "image of %v is not found in %v", ref, imagesDir)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return fail(name, "can not decode %v: %v", filename, err)
+	}
+	if cfg.Width != want.w || cfg.Height != want.h {
+		return fail(name, "%v is %dx%d, but it must be %dx%d", filename, cfg.Width, cfg.Height, want.w, want.h)
+	}
+	return pass(name, "%v is %dx%d", filename, cfg.Width, cfg.Height)
+}
+
+// urlClient sends the requests checking that URLs are reachable.
+var urlClient = &http.Client{Timeout: 15 * time.Second}
+
+// checkURLReachable returns an error if u can't be fetched with a 2xx status.
+var checkURLReachable = func(u string) error {
+	resp, err := urlClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("returned %v", resp.Status)
+	}
+	return nil
+}
+
+// checkPrivacyPolicy checks that the privacy policy URL of ls is set, and if online, reachable.
+func checkPrivacyPolicy(ls localizedSettings, online bool) result {
+	name := fmt.Sprintf("privacyPolicyUrl (%v)", ls.locale)
+	s, _ := ls.settings["privacyPolicyUrl"].(string)
+	if s == "" {
+		return fail(name, "privacyPolicyUrl is not set in %v", ls.filename)
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fail(name, "%v is not an HTTPS URL", s)
+	}
+	if !online {
+		return pass(name, "%v (reachability not checked)", s)
+	}
+	if err := checkURLReachable(s); err != nil {
+		return fail(name, "%v is not reachable: %v", s, err)
+	}
+	return pass(name, "%v is reachable", s)
+}
+
+// runChecks runs the submission checks on files. URLs are fetched only if online is true.
+func runChecks(files map[string][]byte, online bool) ([]result, error) {
+	base, localized, err := settingsFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	res := []result{checkCategory(base)}
+	for _, ls := range localized {
+		res = append(res,
+			checkText(ls, "displayName", maxDisplayNameLen),
+			checkText(ls, "shortDescription", maxShortDescriptionLen),
+			checkText(ls, "fullDescription", maxFullDescriptionLen),
+			checkText(ls, "developerName", 0),
+			checkText(ls, "developerEmail", 0),
+			checkSampleInvocations(ls),
+			checkPrivacyPolicy(ls, online),
+		)
+		for _, l := range logoSizes {
+			res = append(res, checkLogo(files, ls, l.key, l.size))
+		}
+	}
+	return res, nil
+}
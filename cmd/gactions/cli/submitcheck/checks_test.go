@@ -0,0 +1,131 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package submitcheck
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func pngImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png.Encode returned %v", err)
+	}
+	return b.Bytes()
+}
+
+func TestRunChecks(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml": []byte(`defaultLocale: en
+category: GAMES_AND_TRIVIA
+localizedSettings:
+  displayName: Trivia Game
+  shortDescription: A quick trivia game
+  fullDescription: Answer questions about history and science.
+  developerName: Example
+  developerEmail: dev@example.com
+  privacyPolicyUrl: https://example.com/privacy
+  sampleInvocations:
+  - Talk to Trivia Game
+  smallLogoImage: $resources.images.square
+  largeBannerImage: $resources.images.banner
+`),
+		"settings/fr/settings.yaml": []byte(`localizedSettings:
+  displayName: Jeu de quiz
+  shortDescription: ` + strings.Repeat("a", 81) + `
+  fullDescription: Répondez aux questions.
+  developerName: Example
+  developerEmail: dev@example.com
+  privacyPolicyUrl: http://example.com/fr/privacy
+  smallLogoImage: $resources.images.square
+  largeBannerImage: $resources.images.missing
+`),
+		"resources/images/square.png":    pngImage(t, 192, 192),
+		"resources/images/fr/square.png": pngImage(t, 100, 100),
+		"resources/images/banner.png":    pngImage(t, 1920, 1080),
+	}
+	var fetched []string
+	checkURLReachable = func(u string) error {
+		fetched = append(fetched, u)
+		return nil
+	}
+	res, err := runChecks(files, true)
+	if err != nil {
+		t.Fatalf("runChecks returned %v, want %v", err, nil)
+	}
+	got := map[string]bool{}
+	for _, r := range res {
+		got[r.name] = r.passed
+	}
+	want := map[string]bool{
+		"Category":               true,
+		"displayName (en)":       true,
+		"shortDescription (en)":  true,
+		"fullDescription (en)":   true,
+		"developerName (en)":     true,
+		"developerEmail (en)":    true,
+		"sampleInvocations (en)": true,
+		"privacyPolicyUrl (en)":  true,
+		"smallLogoImage (en)":    true,
+		"largeBannerImage (en)":  true,
+		"displayName (fr)":       true,
+		"shortDescription (fr)":  false,
+		"fullDescription (fr)":   true,
+		"developerName (fr)":     true,
+		"developerEmail (fr)":    true,
+		"sampleInvocations (fr)": false,
+		"privacyPolicyUrl (fr)":  false,
+		"smallLogoImage (fr)":    false,
+		"largeBannerImage (fr)":  false,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("runChecks returned incorrect results: diff (-want, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"https://example.com/privacy"}, fetched); diff != "" {
+		t.Errorf("runChecks fetched incorrect URLs: diff (-want, +got)\n%s", diff)
+	}
+	if res[1].name != "displayName (en)" {
+		t.Errorf("runChecks returned %v after the category, want the default locale first", res[1].name)
+	}
+}
+
+func TestCheckPrivacyPolicyUnreachable(t *testing.T) {
+	checkURLReachable = func(u string) error {
+		return errors.New("returned 404 Not Found")
+	}
+	ls := localizedSettings{locale: "en", settings: map[string]interface{}{"privacyPolicyUrl": "https://example.com/privacy"}}
+	if r := checkPrivacyPolicy(ls, true); r.passed {
+		t.Errorf("checkPrivacyPolicy returned %+v for an unreachable URL, want a failure", r)
+	}
+	if r := checkPrivacyPolicy(ls, false); !r.passed {
+		t.Errorf("checkPrivacyPolicy returned %+v offline, want a pass", r)
+	}
+}
+
+func TestCheckCategory(t *testing.T) {
+	for _, c := range []string{"", "CATEGORY_UNSPECIFIED"} {
+		if r := checkCategory(map[string]interface{}{"category": c}); r.passed {
+			t.Errorf("checkCategory returned %+v for %q, want a failure", r, c)
+		}
+	}
+}
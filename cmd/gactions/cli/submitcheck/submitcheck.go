@@ -0,0 +1,78 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package submitcheck provides an implementation of "gactions submitcheck" command.
+package submitcheck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+// AddCommand adds the submitcheck sub-command to the passed in root command.
+func AddCommand(root *cobra.Command, proj project.Project) {
+	submitcheck := &cobra.Command{
+		Use:   "submitcheck",
+		Short: "Check the project against the requirements for submission to production.",
+		Long: "This command checks locally that the settings meet the requirements of the Actions Console for submission: " +
+			"the display name and descriptions are set within their maximum lengths, the developer name and email are set, " +
+			"the privacy policy URL is reachable, the logos have the required sizes, there are sample invocations, and the category is set. " +
+			"Run it before \"gactions deploy prod\".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			offline, err := cmd.Flags().GetBool("offline")
+			if err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			res, err := runChecks(files, !offline)
+			if err != nil {
+				return err
+			}
+			if printResults(log.OutLogger.Writer(), res) {
+				return errors.New("submission checks failed")
+			}
+			log.DoneMsgln("All submission checks passed.")
+			return nil
+		},
+	}
+	submitcheck.Flags().Bool("offline", false, "Don't check that the privacy policy URL is reachable.")
+	root.AddCommand(submitcheck)
+}
+
+// printResults writes res to w, and returns true if any check failed.
+func printResults(w io.Writer, res []result) bool {
+	failed := false
+	for _, r := range res {
+		status := "PASS"
+		if !r.passed {
+			status = "FAIL"
+			failed = true
+		}
+		fmt.Fprintf(w, "[%v] %v: %v\n", status, r.name, r.message)
+	}
+	return failed
+}
@@ -0,0 +1,284 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rules of "types expand", which add variants of the synonyms of a type.
+const (
+	rulePlurals      = "plurals"
+	ruleMisspellings = "misspellings"
+	ruleCasing       = "casing"
+)
+
+var allRules = []string{rulePlurals, ruleMisspellings, ruleCasing}
+
+var irregularPlurals = map[string]string{
+	"child":  "children",
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"mouse":  "mice",
+	"foot":   "feet",
+	"tooth":  "teeth",
+	"goose":  "geese",
+	"knife":  "knives",
+	"leaf":   "leaves",
+	"life":   "lives",
+	"wife":   "wives",
+}
+
+// Words which are the same in the singular and in the plural.
+var uncountable = map[string]bool{
+	"fish":      true,
+	"sheep":     true,
+	"deer":      true,
+	"series":    true,
+	"species":   true,
+	"news":      true,
+	"rice":      true,
+	"music":     true,
+	"furniture": true,
+	"equipment": true,
+}
+
+func isVowel(r byte) bool {
+	return strings.IndexByte("aeiou", r) >= 0
+}
+
+// pluralize returns the English plural of a lowercase word.
+func pluralize(w string) string {
+	if p, ok := irregularPlurals[w]; ok {
+		return p
+	}
+	switch {
+	case strings.HasSuffix(w, "s"), strings.HasSuffix(w, "x"), strings.HasSuffix(w, "z"),
+		strings.HasSuffix(w, "ch"), strings.HasSuffix(w, "sh"):
+		return w + "es"
+	case len(w) > 1 && strings.HasSuffix(w, "y") && !isVowel(w[len(w)-2]):
+		return w[:len(w)-1] + "ies"
+	}
+	return w + "s"
+}
+
+// singularize returns the English singular of a lowercase word, or an empty string if the word
+// doesn't look like a plural.
+func singularize(w string) string {
+	for k, v := range irregularPlurals {
+		if v == w {
+			return k
+		}
+	}
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "xes"), strings.HasSuffix(w, "zes"),
+		strings.HasSuffix(w, "ches"), strings.HasSuffix(w, "shes"):
+		return w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") &&
+		!strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		return w[:len(w)-1]
+	}
+	return ""
+}
+
+// pluralVariants returns the plural of a synonym, or its singular if it's a plural. Only the
+// last word of the synonym is changed, e.g. "ice cream" becomes "ice creams".
+func pluralVariants(s string) []string {
+	i := strings.LastIndexByte(s, ' ') + 1
+	w := strings.ToLower(s[i:])
+	if len(w) < 2 || uncountable[w] || !isLetters(w) {
+		return nil
+	}
+	var res []string
+	if sg := singularize(w); sg != "" {
+		res = append(res, s[:i]+matchCase(s[i:], sg))
+	}
+	if _, ok := irregularPlurals[w]; ok || singularize(w) == "" {
+		res = append(res, s[:i]+matchCase(s[i:], pluralize(w)))
+	}
+	return res
+}
+
+// matchCase returns the lowercase word w in the case of orig, which is lowercase, uppercase or
+// capitalized.
+func matchCase(orig, w string) string {
+	switch {
+	case orig == strings.ToUpper(orig):
+		return strings.ToUpper(w)
+	case unicode.IsUpper(rune(orig[0])):
+		return strings.ToUpper(w[:1]) + w[1:]
+	}
+	return w
+}
+
+// misspellings returns common misspellings of a synonym: a doubled letter written once,
+// "ie" and "ei" swapped, and "ph" written as "f". Words shorter than four letters are kept.
+func misspellings(s string) []string {
+	var res []string
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		if len(w) < 4 || !isLetters(w) {
+			continue
+		}
+		replace := func(v string) {
+			ws := append([]string{}, words...)
+			ws[i] = v
+			res = append(res, strings.Join(ws, " "))
+		}
+		for j := 1; j < len(w); j++ {
+			if w[j] == w[j-1] && (j < 2 || w[j-2] != w[j]) {
+				replace(w[:j] + w[j+1:])
+			}
+		}
+		for _, p := range [][2]string{{"ie", "ei"}, {"ei", "ie"}, {"ph", "f"}} {
+			if j := strings.Index(w, p[0]); j >= 0 {
+				replace(w[:j] + p[1] + w[j+len(p[0]):])
+			}
+		}
+	}
+	return res
+}
+
+// casingVariants returns the lowercase and title case versions of a synonym.
+func casingVariants(s string) []string {
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return []string{strings.ToLower(s), strings.Join(words, " ")}
+}
+
+func isLetters(w string) bool {
+	for _, r := range w {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// suggest returns variants of synonyms which are not synonyms yet, generated by rules. Plural
+// and misspelling variants are skipped if they only differ in case from an existing synonym.
+func suggest(synonyms []string, rules map[string]bool) []string {
+	exact := map[string]bool{}
+	folded := map[string]bool{}
+	for _, s := range synonyms {
+		exact[s] = true
+		folded[strings.ToLower(s)] = true
+	}
+	var res []string
+	add := func(v string, caseSensitive bool) {
+		if v == "" || exact[v] || (!caseSensitive && folded[strings.ToLower(v)]) {
+			return
+		}
+		exact[v] = true
+		folded[strings.ToLower(v)] = true
+		res = append(res, v)
+	}
+	for _, s := range synonyms {
+		if rules[ruleCasing] {
+			for _, v := range casingVariants(s) {
+				add(v, true)
+			}
+		}
+		if rules[rulePlurals] {
+			for _, v := range pluralVariants(s) {
+				add(v, false)
+			}
+		}
+		if rules[ruleMisspellings] {
+			for _, v := range misspellings(s) {
+				add(v, false)
+			}
+		}
+	}
+	return res
+}
+
+// expandType appends suggested synonyms to the entities of the synonym type in b. It returns
+// the updated type file and the added synonyms of each entity.
+func expandType(b []byte, rules map[string]bool) ([]byte, map[string][]string, error) {
+	var t map[string]interface{}
+	if err := yaml.Unmarshal(b, &t); err != nil {
+		return nil, nil, fmt.Errorf("incorrect syntax: %v", err)
+	}
+	syn, ok := t["synonym"].(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errors.New("not a synonym type")
+	}
+	ents, ok := syn["entities"].(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errors.New("no entities")
+	}
+	added := map[string][]string{}
+	for k, v := range ents {
+		ent, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("incorrect entity %v", k)
+		}
+		raw, _ := ent["synonyms"].([]interface{})
+		var synonyms []string
+		for _, s := range raw {
+			if s, ok := s.(string); ok {
+				synonyms = append(synonyms, s)
+			}
+		}
+		if len(synonyms) == 0 {
+			synonyms = []string{fmt.Sprint(k)}
+		}
+		if res := suggest(synonyms, rules); len(res) > 0 {
+			ent["synonyms"] = append(synonyms, res...)
+			added[fmt.Sprint(k)] = res
+		}
+	}
+	if len(added) == 0 {
+		return b, nil, nil
+	}
+	out, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, added, nil
+}
+
+// parseRules returns the set of rules named in names.
+func parseRules(names []string) (map[string]bool, error) {
+	rules := map[string]bool{}
+	for _, v := range names {
+		v = strings.TrimSpace(v)
+		known := false
+		for _, r := range allRules {
+			known = known || r == v
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown rule %q: use %v", v, strings.Join(allRules, ", "))
+		}
+		rules[v] = true
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no rules are set: use %v", strings.Join(allRules, ", "))
+	}
+	return rules, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPluralVariants(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "apple", want: []string{"apples"}},
+		{in: "apples", want: []string{"apple"}},
+		{in: "box", want: []string{"boxes"}},
+		{in: "boxes", want: []string{"box"}},
+		{in: "berry", want: []string{"berries"}},
+		{in: "berries", want: []string{"berry"}},
+		{in: "day", want: []string{"days"}},
+		{in: "ice cream", want: []string{"ice creams"}},
+		{in: "child", want: []string{"children"}},
+		{in: "children", want: []string{"child"}},
+		{in: "glass", want: []string{"glasses"}},
+		{in: "cactus", want: []string{"cactuses"}},
+		{in: "fish"},
+		{in: "7up"},
+	}
+	for _, tc := range tests {
+		if diff := cmp.Diff(tc.want, pluralVariants(tc.in)); diff != "" {
+			t.Errorf("pluralVariants(%q) returned diff (-want, +got)\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestMisspellings(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "coffee", want: []string{"cofee", "coffe"}},
+		{in: "receipt", want: []string{"reciept"}},
+		{in: "iced coffee", want: []string{"iced cofee", "iced coffe"}},
+		{in: "phone", want: []string{"fone"}},
+		{in: "tea"},
+	}
+	for _, tc := range tests {
+		if diff := cmp.Diff(tc.want, misspellings(tc.in)); diff != "" {
+			t.Errorf("misspellings(%q) returned diff (-want, +got)\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name     string
+		synonyms []string
+		rules    []string
+		want     []string
+	}{
+		{
+			name:     "all rules",
+			synonyms: []string{"Latte"},
+			rules:    allRules,
+			want:     []string{"latte", "Lattes", "Late"},
+		},
+		{
+			name:     "skips existing synonyms",
+			synonyms: []string{"latte", "lattes"},
+			rules:    []string{rulePlurals, ruleMisspellings},
+			want:     []string{"late", "lates"},
+		},
+		{
+			name:     "skips variants differing in case",
+			synonyms: []string{"Cups", "cup"},
+			rules:    []string{rulePlurals},
+		},
+		{
+			name:     "casing",
+			synonyms: []string{"new york"},
+			rules:    []string{ruleCasing},
+			want:     []string{"New York"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := parseRules(tc.rules)
+			if err != nil {
+				t.Fatalf("parseRules returned %v, want %v", err, nil)
+			}
+			if diff := cmp.Diff(tc.want, suggest(tc.synonyms, rules)); diff != "" {
+				t.Errorf("suggest returned diff (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExpandType(t *testing.T) {
+	in := `synonym:
+  entities:
+    small:
+      synonyms:
+      - small
+      - little
+    large:
+      synonyms:
+      - large
+  matchType: EXACT_MATCH
+`
+	rules, err := parseRules([]string{rulePlurals})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, added, err := expandType([]byte(in), rules)
+	if err != nil {
+		t.Fatalf("expandType returned %v, want %v", err, nil)
+	}
+	wantAdded := map[string][]string{
+		"small": {"smalls", "littles"},
+		"large": {"larges"},
+	}
+	if diff := cmp.Diff(wantAdded, added); diff != "" {
+		t.Errorf("expandType returned incorrect added synonyms: diff (-want, +got)\n%s", diff)
+	}
+	var got map[string]interface{}
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"synonym": map[interface{}]interface{}{
+			"matchType": "EXACT_MATCH",
+			"entities": map[interface{}]interface{}{
+				"small": map[interface{}]interface{}{"synonyms": []interface{}{"small", "little", "smalls", "littles"}},
+				"large": map[interface{}]interface{}{"synonyms": []interface{}{"large", "larges"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("expandType returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestExpandTypeErrors(t *testing.T) {
+	rules, err := parseRules(allRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{"regularExpressions:\n  entities: {}\n", "synonym: [", "synonym:\n  matchType: EXACT_MATCH\n"} {
+		if _, _, err := expandType([]byte(in), rules); err == nil {
+			t.Errorf("expandType(%q) returned nil, want an error", in)
+		}
+	}
+	if _, err := parseRules([]string{"typos"}); err == nil {
+		t.Errorf("parseRules returned nil for an unknown rule, want an error")
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types provides an implementation of "gactions types" command.
+package types
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var typeNameRegExp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// AddCommand adds the types sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	types := &cobra.Command{
+		Use:   "types",
+		Short: "This is the main command for working with the types of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the types of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	imp := &cobra.Command{
+		Use:   "import",
+		Short: "Create or update synonym types from a CSV or JSON file, or a Google Sheet.",
+		Long: "This command creates or updates synonym types in custom/types from a CSV or JSON file, or a Google Sheet. " +
+			"A CSV file needs a header row with the columns \"type\" and \"value\", and optionally \"locale\" and \"synonyms\" (separated by \";\"). " +
+			"A JSON file contains an array of objects with the same fields, where \"synonyms\" is an array. " +
+			"Entities of an existing type are updated, and entities that are not in the file are kept.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			fname, err := cmd.Flags().GetString("file")
+			if err != nil {
+				return err
+			}
+			sheet, err := cmd.Flags().GetString("from-sheet")
+			if err != nil {
+				return err
+			}
+			var entries []entry
+			switch {
+			case fname != "" && sheet != "":
+				return errors.New("only one of --file and --from-sheet can be set")
+			case sheet != "":
+				tab, err := cmd.Flags().GetString("sheet-tab")
+				if err != nil {
+					return err
+				}
+				rows, err := sdk.ReadSheetJSON(ctx, proj, sheet, tab)
+				if err != nil {
+					return err
+				}
+				if entries, err = entriesFromRows(rows); err != nil {
+					return fmt.Errorf("can not read tab %q of sheet %v: %v", tab, sheet, err)
+				}
+			case fname != "":
+				format, err := cmd.Flags().GetString("file-format")
+				if err != nil {
+					return err
+				}
+				if format == "" {
+					format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fname)), ".")
+				}
+				f, err := os.Open(fname)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if entries, err = readEntries(f, format); err != nil {
+					return fmt.Errorf("can not read %v: %v", fname, err)
+				}
+			default:
+				return errors.New("one of --file and --from-sheet must be set")
+			}
+			if err := validateEntries(entries); err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			out, err := mergeEntries(files, entries)
+			if err != nil {
+				return err
+			}
+			var names []string
+			for k := range out {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			for _, k := range names {
+				if _, ok := files[k]; ok {
+					log.Outf("Updating %v\n", k)
+				} else {
+					log.Outf("Creating %v\n", k)
+				}
+				if err := studio.WriteToDisk(proj, k, "", out[k], true); err != nil {
+					return err
+				}
+			}
+			log.DoneMsgln(fmt.Sprintf("Imported %v entities into %v type files.", len(entries), len(out)))
+			return nil
+		},
+	}
+	imp.Flags().String("file", "", "Path to the CSV or JSON file with the entities.")
+	imp.Flags().String("file-format", "", "Format of the file, csv or json. By default, the format is determined by the file extension.")
+	imp.Flags().String("from-sheet", "", "ID of a Google Sheet with the entities, in the same columns as a CSV file. The sheet must be shared with your Google account.")
+	imp.Flags().String("sheet-tab", "types", "Name of the tab of the Google Sheet with the entities.")
+	expand := &cobra.Command{
+		Use:   "expand <type>",
+		Short: "Add plurals, common misspellings and casing variants to the synonyms of a type.",
+		Long: "This command suggests variants of the synonyms of each entity of a synonym type, and appends them to the type file. " +
+			"The variants are generated offline by rules: \"plurals\" adds the plural or singular of the last word, \"misspellings\" writes doubled letters once and swaps \"ie\" and \"ei\", " +
+			"and \"casing\" adds lowercase and title case versions. Plurals and misspellings are only suggested for English locales. Review the added synonyms before pushing.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			name := args[0]
+			if !typeNameRegExp.MatchString(name) {
+				return fmt.Errorf("invalid type name %q", name)
+			}
+			locale, err := cmd.Flags().GetString("locale")
+			if err != nil {
+				return err
+			}
+			names, err := cmd.Flags().GetStringSlice("rules")
+			if err != nil {
+				return err
+			}
+			rules, err := parseRules(names)
+			if err != nil {
+				return err
+			}
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			defaultLocale, err := studio.DefaultLocale(files)
+			if err != nil {
+				return err
+			}
+			if locale == "" {
+				locale = defaultLocale
+			}
+			if !strings.HasPrefix(locale, "en") && (rules[rulePlurals] || rules[ruleMisspellings]) {
+				log.Warnf("Plurals and misspellings are only suggested for English, skipping them for %v.\n", locale)
+				delete(rules, rulePlurals)
+				delete(rules, ruleMisspellings)
+			}
+			p := typePath(name, locale, defaultLocale)
+			b, ok := files[p]
+			if !ok {
+				return fmt.Errorf("type %v doesn't exist: %v not found", name, p)
+			}
+			out, added, err := expandType(b, rules)
+			if err != nil {
+				return fmt.Errorf("can not expand %v: %v", p, err)
+			}
+			var values []string
+			count := 0
+			for k, v := range added {
+				values = append(values, k)
+				count += len(v)
+			}
+			sort.Strings(values)
+			for _, k := range values {
+				log.Outf("%v: + %v\n", k, strings.Join(added[k], ", "))
+			}
+			switch {
+			case count == 0:
+				log.DoneMsgln(fmt.Sprintf("No synonyms to add to %v.", name))
+			case dryRun:
+				log.DoneMsgln(fmt.Sprintf("Would add %v synonyms to %v entities of %v.", count, len(values), name))
+			default:
+				if err := studio.WriteToDisk(proj, p, "", out, true); err != nil {
+					return err
+				}
+				log.DoneMsgln(fmt.Sprintf("Added %v synonyms to %v entities of %v.", count, len(values), name))
+			}
+			return nil
+		},
+	}
+	expand.Flags().String("locale", "", "Locale of the type file to expand. By default, the type file of the default locale is expanded.")
+	expand.Flags().StringSlice("rules", allRules, "Comma separated rules which generate the variants: plurals, misspellings and casing.")
+	expand.Flags().Bool("dry-run", false, "Print the suggested synonyms without changing the type file.")
+	types.AddCommand(imp)
+	types.AddCommand(expand)
+	root.AddCommand(types)
+}
+
+// entry is a value of a synonym type in a given locale. An empty locale stands
+// for the default locale of the project.
+type entry struct {
+	Type     string   `json:"type"`
+	Locale   string   `json:"locale"`
+	Value    string   `json:"value"`
+	Synonyms []string `json:"synonyms"`
+}
+
+func readEntries(r io.Reader, format string) ([]entry, error) {
+	var entries []entry
+	switch format {
+	case "csv":
+		var err error
+		if entries, err = readCSV(r); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q: use csv or json", format)
+	}
+	return entries, nil
+}
+
+// validateEntries checks entries, and uses the value of an entry as its
+// synonym if no synonyms are given.
+func validateEntries(entries []entry) error {
+	for i, v := range entries {
+		if !typeNameRegExp.MatchString(v.Type) {
+			return fmt.Errorf("entity %v has invalid type name %q", i+1, v.Type)
+		}
+		if v.Value == "" {
+			return fmt.Errorf("entity %v of type %v has no value", i+1, v.Type)
+		}
+		if len(v.Synonyms) == 0 {
+			entries[i].Synonyms = []string{v.Value}
+		}
+	}
+	return nil
+}
+
+func readCSV(r io.Reader) ([]entry, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return entriesFromRows(rows)
+}
+
+// entriesFromRows returns the entries of a table, where the first row is a header.
+func entriesFromRows(rows [][]string) ([]entry, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("header row is missing")
+	}
+	cols := map[string]int{}
+	for i, v := range rows[0] {
+		cols[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+	for _, v := range []string{"type", "value"} {
+		if _, ok := cols[v]; !ok {
+			return nil, fmt.Errorf("column %q is missing from the header", v)
+		}
+	}
+	col := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var entries []entry
+	for _, rec := range rows[1:] {
+		e := entry{
+			Type:   col(rec, "type"),
+			Locale: col(rec, "locale"),
+			Value:  col(rec, "value"),
+		}
+		for _, s := range strings.Split(col(rec, "synonyms"), ";") {
+			if s = strings.TrimSpace(s); s != "" {
+				e.Synonyms = append(e.Synonyms, s)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// typePath returns the location of a type file relative to the project root.
+func typePath(name, locale, defaultLocale string) string {
+	if locale == "" || locale == defaultLocale {
+		return path.Join("custom", "types", name+".yaml")
+	}
+	return path.Join("custom", "types", locale, name+".yaml")
+}
+
+// mergeEntries adds entries to the type files of a project, and returns the
+// content of the type files that were created or updated.
+func mergeEntries(files map[string][]byte, entries []entry) (map[string][]byte, error) {
+	defaultLocale, err := studio.DefaultLocale(files)
+	if err != nil {
+		return nil, err
+	}
+	types := map[string]map[string]interface{}{}
+	for _, e := range entries {
+		p := typePath(e.Type, e.Locale, defaultLocale)
+		t, ok := types[p]
+		if !ok {
+			t = map[string]interface{}{}
+			if b, ok := files[p]; ok {
+				if err := yaml.Unmarshal(b, &t); err != nil {
+					return nil, fmt.Errorf("%v has incorrect syntax: %v", p, err)
+				}
+			} else if p == typePath(e.Type, "", defaultLocale) {
+				t["synonym"] = map[interface{}]interface{}{"matchType": "EXACT_MATCH"}
+			}
+			types[p] = t
+		}
+		if t["synonym"] == nil {
+			if len(t) > 0 {
+				return nil, fmt.Errorf("%v is not a synonym type", p)
+			}
+			t["synonym"] = map[interface{}]interface{}{}
+		}
+		syn, ok := t["synonym"].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v has incorrect synonym field", p)
+		}
+		if syn["entities"] == nil {
+			syn["entities"] = map[interface{}]interface{}{}
+		}
+		ents, ok := syn["entities"].(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v has incorrect entities field", p)
+		}
+		ent, ok := ents[e.Value].(map[interface{}]interface{})
+		if !ok {
+			ent = map[interface{}]interface{}{}
+			ents[e.Value] = ent
+		}
+		ent["synonyms"] = e.Synonyms
+	}
+	out := map[string][]byte{}
+	for p, t := range types {
+		base := typePath(strings.TrimSuffix(path.Base(p), ".yaml"), "", defaultLocale)
+		if _, ok := types[base]; !ok {
+			if _, ok := files[base]; !ok {
+				return nil, fmt.Errorf("%v has no entities in the default locale %v", strings.TrimSuffix(path.Base(p), ".yaml"), defaultLocale)
+			}
+		}
+		b, err := yaml.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		out[p] = b
+	}
+	return out, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestReadEntries(t *testing.T) {
+	want := []entry{
+		{Type: "size", Value: "large", Synonyms: []string{"large", "big"}},
+		{Type: "size", Locale: "fr", Value: "large", Synonyms: []string{"grande"}},
+		{Type: "size", Value: "small", Synonyms: []string{"small"}},
+	}
+	tests := []struct {
+		format string
+		in     string
+	}{
+		{
+			format: "csv",
+			in: `Type,Value,Locale,Synonyms
+size,large,,large; big
+size,large,fr,grande
+size,small,,
+`,
+		},
+		{
+			format: "json",
+			in: `[
+  {"type": "size", "value": "large", "synonyms": ["large", "big"]},
+  {"type": "size", "locale": "fr", "value": "large", "synonyms": ["grande"]},
+  {"type": "size", "value": "small"}
+]`,
+		},
+	}
+	for _, tc := range tests {
+		got, err := readEntries(strings.NewReader(tc.in), tc.format)
+		if err != nil {
+			t.Errorf("readEntries returned %v for %v, want %v", err, tc.format, nil)
+		}
+		if err := validateEntries(got); err != nil {
+			t.Errorf("validateEntries returned %v for %v, want %v", err, tc.format, nil)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("readEntries returned incorrect entries for %v: diff (-want, +got)\n%s", tc.format, diff)
+		}
+	}
+}
+
+func TestReadEntriesInvalid(t *testing.T) {
+	tests := []struct {
+		format string
+		in     string
+	}{
+		{format: "xml", in: ""},
+		{format: "csv", in: "type,synonyms\nsize,big\n"},
+		{format: "csv", in: "type,value\nsi ze,big\n"},
+		{format: "csv", in: "type,value\nsize,\n"},
+		{format: "json", in: `{"type": "size"}`},
+	}
+	for _, tc := range tests {
+		entries, err := readEntries(strings.NewReader(tc.in), tc.format)
+		if err == nil {
+			err = validateEntries(entries)
+		}
+		if err == nil {
+			t.Errorf("readEntries(%q, %v) returned %v, but want an error", tc.in, tc.format, err)
+		}
+	}
+}
+
+func TestMergeEntries(t *testing.T) {
+	files := map[string][]byte{
+		"settings/settings.yaml": []byte("defaultLocale: en\nprojectId: foo\n"),
+		"custom/types/size.yaml": []byte(`synonym:
+  entities:
+    large:
+      synonyms:
+      - large
+    small:
+      synonyms:
+      - small
+  matchType: FUZZY_MATCH
+`),
+	}
+	entries := []entry{
+		{Type: "size", Value: "large", Synonyms: []string{"large", "big"}},
+		{Type: "size", Locale: "fr", Value: "large", Synonyms: []string{"grande"}},
+		{Type: "color", Locale: "en", Value: "red", Synonyms: []string{"red"}},
+	}
+	got, err := mergeEntries(files, entries)
+	if err != nil {
+		t.Fatalf("mergeEntries returned %v, want %v", err, nil)
+	}
+	want := map[string]string{
+		"custom/types/size.yaml": `
+synonym:
+  entities:
+    large:
+      synonyms: [large, big]
+    small:
+      synonyms: [small]
+  matchType: FUZZY_MATCH
+`,
+		"custom/types/fr/size.yaml": `
+synonym:
+  entities:
+    large:
+      synonyms: [grande]
+`,
+		"custom/types/color.yaml": `
+synonym:
+  entities:
+    red:
+      synonyms: [red]
+  matchType: EXACT_MATCH
+`,
+	}
+	if len(got) != len(want) {
+		t.Errorf("mergeEntries returned %v files, want %v", len(got), len(want))
+	}
+	for k, v := range want {
+		var w, g interface{}
+		if err := yaml.Unmarshal([]byte(v), &w); err != nil {
+			t.Fatalf("Can't unmarshal %v: %v", v, err)
+		}
+		if err := yaml.Unmarshal(got[k], &g); err != nil {
+			t.Errorf("mergeEntries returned %v with incorrect syntax: %v", k, err)
+		}
+		if diff := cmp.Diff(w, g); diff != "" {
+			t.Errorf("mergeEntries returned incorrect %v: diff (-want, +got)\n%s", k, diff)
+		}
+	}
+}
+
+func TestMergeEntriesErrors(t *testing.T) {
+	settings := []byte("defaultLocale: en\nprojectId: foo\n")
+	tests := []struct {
+		name    string
+		files   map[string][]byte
+		entries []entry
+	}{
+		{
+			name: "not a synonym type",
+			files: map[string][]byte{
+				"settings/settings.yaml": settings,
+				"custom/types/code.yaml": []byte("regularExpression:\n  entities: {}\n"),
+			},
+			entries: []entry{{Type: "code", Value: "a", Synonyms: []string{"a"}}},
+		},
+		{
+			name: "no default locale entities",
+			files: map[string][]byte{
+				"settings/settings.yaml": settings,
+			},
+			entries: []entry{{Type: "size", Locale: "fr", Value: "large", Synonyms: []string{"grande"}}},
+		},
+	}
+	for _, tc := range tests {
+		if _, err := mergeEntries(tc.files, tc.entries); err == nil {
+			t.Errorf("mergeEntries returned %v for %v, but want an error", err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validate provides an implementation of "gactions validate" command.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/lsp"
+	"github.com/actions-on-google/gactions/cmd/gactions/cli/schema"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/spf13/cobra"
+)
+
+// problem is a problem found in a config file by any of the checks.
+type problem struct {
+	file string
+	// line is a one-based line number, or 0 if the problem isn't at a line.
+	line int
+	// path is the location of the value with the problem in the file, if known.
+	path    string
+	warning bool
+	message string
+}
+
+// check returns the syntax errors, schema mismatches and undefined references in the config
+// files of a project, ordered by file and line.
+func check(files map[string][]byte) []problem {
+	var res []problem
+	for _, p := range lsp.Check(files) {
+		res = append(res, problem{file: p.File, line: p.Line, warning: p.Warning, message: p.Message})
+	}
+	for _, p := range schema.Validate(files) {
+		res = append(res, problem{file: p.File, path: p.Path, warning: p.Warning, message: p.Message})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].file != res[j].file {
+			return res[i].file < res[j].file
+		}
+		return res[i].line < res[j].line
+	})
+	return res
+}
+
+// printProblems writes problems to w, and returns the number of errors and warnings.
+func printProblems(w io.Writer, problems []problem) (int, int) {
+	errs, warnings := 0, 0
+	for _, p := range problems {
+		sev := "error"
+		if p.warning {
+			sev = "warning"
+			warnings++
+		} else {
+			errs++
+		}
+		loc := p.file
+		if p.line > 0 {
+			loc = fmt.Sprintf("%v:%d", loc, p.line)
+		}
+		if p.path != "" {
+			fmt.Fprintf(w, "%v: %v: %v: %v\n", loc, sev, p.path, p.message)
+		} else {
+			fmt.Fprintf(w, "%v: %v: %v\n", loc, sev, p.message)
+		}
+	}
+	return errs, warnings
+}
+
+// AddCommand adds the validate sub-command to the passed in root command.
+func AddCommand(root *cobra.Command, proj project.Project) {
+	validate := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config files of the project offline.",
+		Long: "This command checks the YAML config files of the project locally, without calling the Actions API: " +
+			"syntax errors, values which don't match the schema of the manifest, settings, scenes, intents, types, prompts and webhooks, and references to undefined scenes, intents, prompts and types. " +
+			"Unknown fields are reported as warnings. It fails if any errors are found, so CI pipelines can catch them before a push.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			files, err := proj.Files()
+			if err != nil {
+				return err
+			}
+			errs, warnings := printProblems(log.OutLogger.Writer(), check(files))
+			if errs > 0 {
+				return fmt.Errorf("found %d errors and %d warnings", errs, warnings)
+			}
+			log.DoneMsgln(fmt.Sprintf("No errors found, %d warnings.", warnings))
+			return nil
+		},
+	}
+	root.AddCommand(validate)
+}
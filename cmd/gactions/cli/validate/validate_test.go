@@ -0,0 +1,71 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	files := map[string][]byte{
+		"custom/scenes/Main.yaml": []byte(`onEnter:
+  staticPromptName: welcome
+intentEvents:
+- intent: help
+  transitionToScene: actions.scene.END_CONVERSATION
+  transitonToScene: Main
+`),
+		"custom/prompts/welcome.yaml": []byte("candidates:\n- promptResponse:\n    firstSimple:\n      variants:\n      - speech: 42\n"),
+		"custom/global/broken.yaml":   []byte("transitionToScene: [Main\n"),
+	}
+	var got []problem
+	for _, p := range check(files) {
+		if p.file == "custom/global/broken.yaml" {
+			// The message of a syntax error comes from the YAML parser.
+			p.message = ""
+		}
+		got = append(got, p)
+	}
+	want := []problem{
+		{file: "custom/global/broken.yaml", line: 1},
+		{file: "custom/prompts/welcome.yaml", path: "candidates[0].promptResponse.firstSimple.variants[0].speech", message: "want string, got integer"},
+		{file: "custom/scenes/Main.yaml", path: "intentEvents[0].transitonToScene", warning: true, message: `unknown field "transitonToScene"`},
+		{file: "custom/scenes/Main.yaml", line: 4, message: `intent "help" is not defined in the project`},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(problem{})); diff != "" {
+		t.Errorf("check returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestPrintProblems(t *testing.T) {
+	problems := []problem{
+		{file: "custom/scenes/Main.yaml", line: 4, message: `intent "help" is not defined in the project`},
+		{file: "custom/scenes/Main.yaml", path: "intentEvents[0].transitonToScene", warning: true, message: `unknown field "transitonToScene"`},
+	}
+	var b bytes.Buffer
+	errs, warnings := printProblems(&b, problems)
+	if errs != 1 || warnings != 1 {
+		t.Errorf("printProblems returned %v errors and %v warnings, want 1 and 1", errs, warnings)
+	}
+	want := `custom/scenes/Main.yaml:4: error: intent "help" is not defined in the project
+custom/scenes/Main.yaml: warning: intentEvents[0].transitonToScene: unknown field "transitonToScene"
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("printProblems wrote diff (-want, +got)\n%s", diff)
+	}
+}
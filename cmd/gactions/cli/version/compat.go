@@ -0,0 +1,128 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"gopkg.in/yaml.v2"
+)
+
+// compatURL is the latest copy of compat.yaml, which lists the breaking changes of the Actions API.
+const compatURL = "https://raw.githubusercontent.com/actions-on-google/gactions/main/cmd/gactions/cli/version/compat.yaml"
+
+// compatEntry is a breaking change of the Actions API, which is only supported by the CLI since
+// MinCliVersion.
+type compatEntry struct {
+	API           string `yaml:"api"`
+	MinCliVersion string `yaml:"minCliVersion"`
+	Description   string `yaml:"description"`
+}
+
+func parseCompat(b []byte) ([]compatEntry, error) {
+	var res []compatEntry
+	if err := yaml.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	for _, v := range res {
+		if _, err := parseVersion(v.MinCliVersion); err != nil {
+			return nil, fmt.Errorf("entry %q has invalid minCliVersion: %v", v.API, err)
+		}
+	}
+	return res, nil
+}
+
+// parseVersion returns the major, minor and patch numbers of a semantic version, such as 3.2.0.
+func parseVersion(v string) ([3]int, error) {
+	var res [3]int
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) != 3 {
+		return res, fmt.Errorf("%q is not a semantic version", v)
+	}
+	// Ignore pre-release and build metadata, e.g. 3.2.0-rc1.
+	if i := strings.IndexAny(parts[2], "-+"); i >= 0 {
+		parts[2] = parts[2][:i]
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return res, fmt.Errorf("%q is not a semantic version", v)
+		}
+		res[i] = n
+	}
+	return res, nil
+}
+
+func less(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// unsupported returns the entries of the matrix which cliVersion predates.
+func unsupported(cliVersion string, matrix []compatEntry) ([]compatEntry, error) {
+	cur, err := parseVersion(cliVersion)
+	if err != nil {
+		return nil, err
+	}
+	var res []compatEntry
+	for _, v := range matrix {
+		min, err := parseVersion(v.MinCliVersion)
+		if err != nil {
+			return nil, err
+		}
+		if less(cur, min) {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+// fetchCompat returns the latest compatibility matrix published at url. The request uses the
+// proxy and the CAs of the Config of ctx.
+func fetchCompat(ctx context.Context, url string) ([]compatEntry, error) {
+	t, err := sdk.ConfigFrom(ctx).NewTransport()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: t, Timeout: 10 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v returned %v", url, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseCompat(b)
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testMatrix = []compatEntry{
+	{API: "v2", MinCliVersion: "3.1.0"},
+	{API: "v3", MinCliVersion: "4.0.0"},
+}
+
+func TestUnsupported(t *testing.T) {
+	tests := []struct {
+		cliVersion string
+		want       []compatEntry
+		wantErr    bool
+	}{
+		{
+			cliVersion: "3.0.2",
+			want:       testMatrix,
+		},
+		{
+			cliVersion: "3.2.0",
+			want:       []compatEntry{testMatrix[1]},
+		},
+		{
+			cliVersion: "4.0.0-rc1",
+			want:       nil,
+		},
+		{
+			cliVersion: "gactions_debug",
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tests {
+		got, err := unsupported(tc.cliVersion, testMatrix)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("unsupported(%v) returned %v, want error %v", tc.cliVersion, err, tc.wantErr)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("unsupported(%v) returned diff (-want, +got)\n%s", tc.cliVersion, diff)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    [3]int
+		wantErr bool
+	}{
+		{in: "3.2.0", want: [3]int{3, 2, 0}},
+		{in: "v10.0.12+build", want: [3]int{10, 0, 12}},
+		{in: "3.2", wantErr: true},
+		{in: "3.2.", wantErr: true},
+		{in: "3.x.0", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseVersion(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("parseVersion(%q) returned %v, want error %v", tc.in, err, tc.wantErr)
+		}
+		if err == nil && got != tc.want {
+			t.Errorf("parseVersion(%q) returned %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestEmbeddedCompat(t *testing.T) {
+	// compat.yaml is also published on GitHub, so it must always parse.
+	if _, err := parseCompat(compatYAML); err != nil {
+		t.Errorf("parseCompat returned %v, want %v", err, nil)
+	}
+}
+
+func TestFetchCompat(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/compat.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "- api: v3\n  minCliVersion: 4.0.0\n  description: d\n")
+	}))
+	defer ts.Close()
+	got, err := fetchCompat(context.Background(), ts.URL+"/compat.yaml")
+	if err != nil {
+		t.Fatalf("fetchCompat returned %v", err)
+	}
+	want := []compatEntry{{API: "v3", MinCliVersion: "4.0.0", Description: "d"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("fetchCompat returned diff (-want, +got)\n%s", diff)
+	}
+	if _, err := fetchCompat(context.Background(), ts.URL+"/missing.yaml"); err == nil {
+		t.Errorf("fetchCompat with a missing file returned nil, want an error")
+	}
+}
@@ -16,22 +16,63 @@
 package version
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/versions"
 	"github.com/spf13/cobra"
 )
 
 // AddCommand adds the push sub-command to the passed in root command.
-func AddCommand(root *cobra.Command) {
+func AddCommand(ctx context.Context, root *cobra.Command) {
 	version := &cobra.Command{
 		Use:   "version",
 		Short: "Prints current version of the CLI.",
-		Long:  "Prints current version of the CLI.",
+		Long: "Prints current version of the CLI. With --check-compat, also checks that the CLI supports the current Actions API, " +
+			"using the latest list of breaking changes of the API published on GitHub, or the list built into the CLI if GitHub can't be reached.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.Outf("%s\n", versions.CliVersion)
-			return nil
+			check, err := cmd.Flags().GetBool("check-compat")
+			if err != nil {
+				return err
+			}
+			if !check {
+				return nil
+			}
+			return checkCompat(ctx, versions.CliVersion)
 		},
 		Args: cobra.NoArgs,
 	}
+	version.Flags().Bool("check-compat", false, "Check that this version of the CLI supports the current Actions API.")
 	root.AddCommand(version)
 }
+
+func checkCompat(ctx context.Context, cliVersion string) error {
+	if _, err := parseVersion(cliVersion); err != nil {
+		log.Warnf("Can't check the compatibility of %v, which is not a released version of the CLI: %v\n", cliVersion, err)
+		return nil
+	}
+	matrix, err := fetchCompat(ctx, compatURL)
+	if err != nil {
+		log.Warnf("Can't get the latest compatibility information, using the one built into the CLI: %v\n", err)
+		// compatYAML comes from go_embed_data rule in the BUILD file.
+		matrix, err = parseCompat(compatYAML)
+		if err != nil {
+			return err
+		}
+	}
+	res, err := unsupported(cliVersion, matrix)
+	if err != nil {
+		return err
+	}
+	if len(res) == 0 {
+		log.DoneMsgln(fmt.Sprintf("gactions %v supports the current Actions API.", cliVersion))
+		return nil
+	}
+	for _, v := range res {
+		log.Warnf("gactions %v predates a breaking change of the Actions API (%v), which requires version %v or later: %v\n", cliVersion, v.API, v.MinCliVersion, v.Description)
+	}
+	return errors.New("this version of the CLI doesn't support the current Actions API; update to the latest version of gactions")
+}
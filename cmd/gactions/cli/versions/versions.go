@@ -18,11 +18,14 @@ package versions
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
 	"github.com/actions-on-google/gactions/project"
 	"github.com/actions-on-google/gactions/project/studio"
 	"github.com/spf13/cobra"
@@ -59,25 +62,129 @@ func AddCommand(ctx context.Context, root *cobra.Command, project project.Projec
 			if err != nil {
 				return err
 			}
-			return printVersions(res)
+			return printVersions(os.Stdout, res)
 		},
 	}
 	list.Flags().String("project-id", "", "List versions of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
 	versions.AddCommand(list)
+	get := &cobra.Command{
+		Use:   "get <version>",
+		Short: "This command shows the metadata of a version.",
+		Long:  "This command shows the metadata of a version: its review state and status, who created it and when, and the release channels it is current or pending on. The version is specified by its ID shown by \"versions list\".",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			studioProj, ok := project.(studio.Studio)
+			if !ok {
+				return fmt.Errorf("can not convert %T to %T", project, studio.Studio{})
+			}
+			pid, err := cmd.Flags().GetString("project-id")
+			if err != nil {
+				return err
+			}
+			if err := (&studioProj).SetProjectID(pid); err != nil {
+				return err
+			}
+			res, err := sdk.GetVersionJSON(ctx, studioProj, args[0])
+			if err != nil {
+				return err
+			}
+			return printVersion(os.Stdout, res)
+		},
+	}
+	get.Flags().String("project-id", "", "Get the version of the project specified by the ID. The value provided in this flag will overwrite the value from settings file, if present.")
+	versions.AddCommand(get)
 	root.AddCommand(versions)
 }
 
-func printVersions(versions []project.Version) error {
-	w := new(tabwriter.Writer)
-	// Format in tab-separated columns with a tab stop of 8.
-	w.Init(os.Stdout, 20, 8, 1, '\t', 0)
-	fmt.Fprintln(w, "Version\tStatus\tLast Modified By\tModified On\t")
+// versionOutput is a version in the json and yaml output formats. ModifiedOn
+// keeps the RFC 3339 timestamp of the API, which scripts can parse.
+type versionOutput struct {
+	Version        string `json:"version" yaml:"version"`
+	Status         string `json:"status" yaml:"status"`
+	LastModifiedBy string `json:"lastModifiedBy" yaml:"lastModifiedBy"`
+	ModifiedOn     string `json:"modifiedOn" yaml:"modifiedOn"`
+}
+
+func printVersions(out io.Writer, versions []project.Version) error {
+	res := []versionOutput{}
 	for _, version := range versions {
-		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t\n", versionID(version.ID), version.State.Message, version.LastModifiedBy, formatModifiedOn(version.ModifiedOn))
+		res = append(res, versionOutput{
+			Version:        versionID(version.ID),
+			Status:         version.State.Message,
+			LastModifiedBy: version.LastModifiedBy,
+			ModifiedOn:     version.ModifiedOn,
+		})
+	}
+	return log.Render(out, res, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		// Format in tab-separated columns with a tab stop of 8.
+		w.Init(out, 20, 8, 1, '\t', 0)
+		fmt.Fprintln(w, "Version\tStatus\tLast Modified By\tModified On\t")
+		for _, version := range res {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t\n", version.Version, version.Status, version.LastModifiedBy, formatModifiedOn(version.ModifiedOn))
+		}
+		fmt.Fprintf(w, "To learn more about release channels, visit https://developers.google.com/assistant/actionssdk/reference/rest/Shared.Types/ReleaseChannel.")
+		fmt.Fprintln(w)
+		return w.Flush()
+	})
+}
+
+// versionDetailsOutput is a version with its release channels in the json and yaml output formats.
+type versionDetailsOutput struct {
+	Version        string   `json:"version" yaml:"version"`
+	State          string   `json:"state" yaml:"state"`
+	Status         string   `json:"status" yaml:"status"`
+	LastModifiedBy string   `json:"lastModifiedBy" yaml:"lastModifiedBy"`
+	ModifiedOn     string   `json:"modifiedOn" yaml:"modifiedOn"`
+	CurrentOn      []string `json:"currentOn" yaml:"currentOn"`
+	PendingOn      []string `json:"pendingOn" yaml:"pendingOn"`
+}
+
+func printVersion(out io.Writer, version sdk.VersionDetails) error {
+	res := versionDetailsOutput{
+		Version:        versionID(version.ID),
+		State:          version.State.State,
+		Status:         version.State.Message,
+		LastModifiedBy: version.LastModifiedBy,
+		ModifiedOn:     version.ModifiedOn,
+		CurrentOn:      []string{},
+		PendingOn:      []string{},
+	}
+	for _, c := range version.CurrentOn {
+		res.CurrentOn = append(res.CurrentOn, channelName(c))
+	}
+	for _, c := range version.PendingOn {
+		res.PendingOn = append(res.PendingOn, channelName(c))
+	}
+	return log.Render(out, res, func(out io.Writer) error {
+		w := new(tabwriter.Writer)
+		w.Init(out, 20, 8, 1, '\t', 0)
+		fmt.Fprintf(w, "Version:\t%v\t\n", res.Version)
+		fmt.Fprintf(w, "State:\t%v\t\n", orNA(res.State))
+		fmt.Fprintf(w, "Status:\t%v\t\n", orNA(res.Status))
+		fmt.Fprintf(w, "Last Modified By:\t%v\t\n", orNA(res.LastModifiedBy))
+		fmt.Fprintf(w, "Modified On:\t%v\t\n", formatModifiedOn(res.ModifiedOn))
+		fmt.Fprintf(w, "Current On:\t%v\t\n", orNA(strings.Join(res.CurrentOn, ", ")))
+		fmt.Fprintf(w, "Pending On:\t%v\t\n", orNA(strings.Join(res.PendingOn, ", ")))
+		return w.Flush()
+	})
+}
+
+// channelName returns the name of a release channel shown to users, i.e. prod for
+// projects/{projectID}/releaseChannels/actions.channels.Production.
+func channelName(name string) string {
+	id := name[strings.LastIndex(name, "/")+1:]
+	if short, ok := sdk.BuiltInReleaseChannels[id]; ok {
+		return short
+	}
+	return strings.TrimPrefix(id, "actions.channels.")
+}
+
+func orNA(s string) string {
+	if s == "" {
+		return "N/A"
 	}
-	fmt.Fprintf(w, "To learn more about release channels, visit https://developers.google.com/assistant/actionssdk/reference/rest/Shared.Types/ReleaseChannel.")
-	fmt.Fprintln(w)
-	return w.Flush()
+	return s
 }
 
 func versionID(version string) string {
@@ -0,0 +1,87 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versions
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+func TestPrintVersion(t *testing.T) {
+	version := sdk.VersionDetails{
+		Version: project.Version{
+			ID:             "projects/hello-world/versions/4",
+			State:          project.VersionState{State: "UNDER_REVIEW", Message: "In review"},
+			LastModifiedBy: "dev@example.com",
+			ModifiedOn:     "2021-03-04T05:06:07.123456Z",
+		},
+		CurrentOn: []string{"projects/hello-world/releaseChannels/actions.channels.ClosedBeta"},
+		PendingOn: []string{"projects/hello-world/releaseChannels/actions.channels.Production"},
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: log.YAMLFormat,
+			want: `version: "4"
+state: UNDER_REVIEW
+status: In review
+lastModifiedBy: dev@example.com
+modifiedOn: "2021-03-04T05:06:07.123456Z"
+currentOn:
+- ClosedBeta
+pendingOn:
+- prod
+`,
+		},
+	}
+	og := log.Format
+	defer func() { log.Format = og }()
+	for _, tc := range tests {
+		log.Format = tc.format
+		var b bytes.Buffer
+		if err := printVersion(&b, version); err != nil {
+			t.Errorf("printVersion returned %v in %v format, want %v", err, tc.format, nil)
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("printVersion wrote %q in %v format, want %q", got, tc.format, tc.want)
+		}
+	}
+}
+
+func TestPrintVersionText(t *testing.T) {
+	version := sdk.VersionDetails{
+		Version:   project.Version{ID: "projects/hello-world/versions/4"},
+		PendingOn: []string{"projects/hello-world/releaseChannels/actions.channels.Production"},
+	}
+	og := log.Format
+	defer func() { log.Format = og }()
+	log.Format = log.TextFormat
+	var b bytes.Buffer
+	if err := printVersion(&b, version); err != nil {
+		t.Errorf("printVersion returned %v, want %v", err, nil)
+	}
+	for _, want := range []string{"Version:\t\t4\t", "State:\t\t\tN/A\t", "Current On:\t\tN/A\t", "Pending On:\t\tprod\t"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("printVersion wrote %q, want it to contain %q", b.String(), want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/yamlutils"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"gopkg.in/yaml.v2"
+)
+
+// inlineFunction is an inline cloud function of a project.
+type inlineFunction struct {
+	// name of the webhook, i.e. the name of webhooks/<name>.yaml.
+	name string
+	// entryPoint is the function exported by the code of the webhook.
+	entryPoint string
+}
+
+// source returns the directory of the code of f, relative to the project root.
+func (f inlineFunction) source() string {
+	return path.Join("webhooks", f.name)
+}
+
+// inlineFunctions returns the inline cloud functions declared in files, sorted by name.
+func inlineFunctions(files map[string][]byte) ([]inlineFunction, error) {
+	var res []inlineFunction
+	for k, v := range files {
+		if !studio.IsWebhookDefinition(k) {
+			continue
+		}
+		mp, err := yamlutils.UnmarshalYAMLToMap(v)
+		if err != nil {
+			return nil, fmt.Errorf("%v has incorrect syntax: %v", k, err)
+		}
+		inline, ok := mp["inlineCloudFunction"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fn, _ := inline["executeFunction"].(string)
+		if fn == "" {
+			return nil, fmt.Errorf("%v doesn't specify executeFunction of the inline cloud function", k)
+		}
+		base := path.Base(k)
+		res = append(res, inlineFunction{name: strings.TrimSuffix(base, path.Ext(base)), entryPoint: fn})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].name < res[j].name })
+	return res, nil
+}
+
+// selectFunction returns the inline function called name, or the only inline function
+// of a project if name is empty.
+func selectFunction(fns []inlineFunction, name string) (inlineFunction, error) {
+	if len(fns) == 0 {
+		return inlineFunction{}, errors.New("the project doesn't have an inline cloud function")
+	}
+	if name == "" {
+		if len(fns) > 1 {
+			var names []string
+			for _, v := range fns {
+				names = append(names, v.name)
+			}
+			return inlineFunction{}, fmt.Errorf("the project has several inline cloud functions, select one with --webhook: %v", strings.Join(names, ", "))
+		}
+		return fns[0], nil
+	}
+	for _, v := range fns {
+		if v.name == name {
+			return v, nil
+		}
+	}
+	return inlineFunction{}, fmt.Errorf("inline cloud function %q is not found", name)
+}
+
+// firebaseConfig returns the content of firebase.json, updated so that the Functions emulator
+// serves the code in source. It returns false if the content didn't need to change.
+func firebaseConfig(existing []byte, source string) ([]byte, bool, error) {
+	cfg := map[string]interface{}{}
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &cfg); err != nil {
+			return nil, false, fmt.Errorf("firebase.json has incorrect syntax: %v", err)
+		}
+	}
+	fns, ok := cfg["functions"].(map[string]interface{})
+	if !ok {
+		fns = map[string]interface{}{}
+		cfg["functions"] = fns
+	}
+	if fns["source"] == source {
+		return existing, false, nil
+	}
+	fns["source"] = source
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, false, err
+	}
+	return append(b, '\n'), true, nil
+}
+
+// emulatorInfo is the address of an emulator, as listed by the emulator hub.
+type emulatorInfo struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// functionsEmulator returns the base URL of the Functions emulator registered with the emulator
+// hub at hubAddr.
+func functionsEmulator(hubAddr string) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get("http://" + hubAddr + "/emulators")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("emulator hub at %v returned %v", hubAddr, resp.Status)
+	}
+	emulators := map[string]emulatorInfo{}
+	if err := json.Unmarshal(body, &emulators); err != nil {
+		return "", err
+	}
+	fn, ok := emulators["functions"]
+	if !ok {
+		return "", fmt.Errorf("functions emulator is not running in the emulator suite at %v", hubAddr)
+	}
+	return "http://" + net.JoinHostPort(fn.Host, strconv.Itoa(fn.Port)), nil
+}
+
+// functionURL returns the URL at which the Functions emulator serves fn.
+func functionURL(base, projectID, region string, fn inlineFunction) string {
+	return strings.TrimSuffix(base, "/") + "/" + projectID + "/" + region + "/" + fn.entryPoint
+}
+
+// startEmulator starts the Functions emulator of the Firebase CLI in dir. The emulator
+// stops when ctx is done.
+var startEmulator = func(ctx context.Context, dir, projectID string) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, "firebase", "emulators:start", "--only", "functions", "--project", projectID)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can not start the Firebase emulator, check that the Firebase CLI is installed: %v", err)
+	}
+	return cmd, nil
+}
+
+// waitForEmulator polls the emulator hub until the Functions emulator is registered,
+// or timeout passes.
+func waitForEmulator(hubAddr string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		base, err := functionsEmulator(hubAddr)
+		if err == nil {
+			return base, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("functions emulator didn't start in %v: %v", timeout, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// emulatedProject is a project whose inline cloud function is replaced by an HTTPS
+// endpoint, so the fulfillment of the preview is served by the emulator.
+type emulatedProject struct {
+	project.Project
+	fn  inlineFunction
+	url string
+}
+
+// Files returns the files of the project, where the definition of the inline function
+// points to the emulator and its code is left out.
+func (p emulatedProject) Files() (map[string][]byte, error) {
+	files, err := p.Project.Files()
+	if err != nil {
+		return nil, err
+	}
+	def := path.Join("webhooks", p.fn.name+".yaml")
+	mp, err := yamlutils.UnmarshalYAMLToMap(files[def])
+	if err != nil {
+		return nil, fmt.Errorf("%v has incorrect syntax: %v", def, err)
+	}
+	delete(mp, "inlineCloudFunction")
+	mp["httpsEndpoint"] = map[string]interface{}{"baseUrl": p.url}
+	b, err := yaml.Marshal(mp)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]byte{}
+	for k, v := range files {
+		if strings.HasPrefix(k, p.fn.source()+"/") {
+			continue
+		}
+		res[k] = v
+	}
+	res[def] = b
+	return res, nil
+}
+
+// FileRefs returns the files of Files. They are held in memory, because the code of the
+// inline function, which is most of the files, is left out anyway.
+func (p emulatedProject) FileRefs() (map[string]project.File, error) {
+	files, err := p.Files()
+	if err != nil {
+		return nil, err
+	}
+	return project.InMemoryFiles(files), nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gopkg.in/yaml.v2"
+)
+
+type mockProject struct {
+	project.Project
+	files map[string][]byte
+}
+
+func (p mockProject) Files() (map[string][]byte, error) {
+	return p.files, nil
+}
+
+func (p mockProject) FileRefs() (map[string]project.File, error) {
+	return project.InMemoryFiles(p.files), nil
+}
+
+var webhookFiles = map[string][]byte{
+	"settings/settings.yaml": []byte("projectId: foo\n"),
+	"webhooks/ActionsOnGoogleFulfillment.yaml": []byte(`handlers:
+- name: greeting
+inlineCloudFunction:
+  executeFunction: ActionsOnGoogleFulfillment
+`),
+	"webhooks/ActionsOnGoogleFulfillment/index.js":     []byte("exports.ActionsOnGoogleFulfillment = null;"),
+	"webhooks/ActionsOnGoogleFulfillment/package.json": []byte("{}"),
+	"webhooks/external.yaml":                           []byte("httpsEndpoint:\n  baseUrl: https://example.com\n"),
+}
+
+func TestInlineFunctions(t *testing.T) {
+	fns, err := inlineFunctions(webhookFiles)
+	if err != nil {
+		t.Fatalf("inlineFunctions returned %v, want %v", err, nil)
+	}
+	want := []inlineFunction{{name: "ActionsOnGoogleFulfillment", entryPoint: "ActionsOnGoogleFulfillment"}}
+	if diff := cmp.Diff(want, fns, cmp.AllowUnexported(inlineFunction{})); diff != "" {
+		t.Errorf("inlineFunctions returned incorrect functions: diff (-want, +got)\n%s", diff)
+	}
+	if _, err := selectFunction(fns, "other"); err == nil {
+		t.Errorf("selectFunction returned %v for a missing function, but want an error", err)
+	}
+	if _, err := selectFunction(append(fns, inlineFunction{name: "b"}), ""); err == nil {
+		t.Errorf("selectFunction returned %v for several functions, but want an error", err)
+	}
+	if _, err := selectFunction(nil, ""); err == nil {
+		t.Errorf("selectFunction returned %v without functions, but want an error", err)
+	}
+}
+
+func TestFirebaseConfig(t *testing.T) {
+	tests := []struct {
+		existing    string
+		want        map[string]interface{}
+		wantChanged bool
+	}{
+		{
+			existing: "",
+			want: map[string]interface{}{
+				"functions": map[string]interface{}{"source": "webhooks/a"},
+			},
+			wantChanged: true,
+		},
+		{
+			existing: `{"hosting": {"public": "canvas"}, "functions": {"source": "functions", "runtime": "nodejs12"}}`,
+			want: map[string]interface{}{
+				"hosting":   map[string]interface{}{"public": "canvas"},
+				"functions": map[string]interface{}{"source": "webhooks/a", "runtime": "nodejs12"},
+			},
+			wantChanged: true,
+		},
+		{
+			existing: `{"functions": {"source": "webhooks/a"}}`,
+			want: map[string]interface{}{
+				"functions": map[string]interface{}{"source": "webhooks/a"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		b, changed, err := firebaseConfig([]byte(tc.existing), "webhooks/a")
+		if err != nil {
+			t.Errorf("firebaseConfig returned %v for %q, want %v", err, tc.existing, nil)
+		}
+		if changed != tc.wantChanged {
+			t.Errorf("firebaseConfig returned changed = %v for %q, want %v", changed, tc.existing, tc.wantChanged)
+		}
+		got := map[string]interface{}{}
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Errorf("firebaseConfig returned invalid JSON %q: %v", b, err)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("firebaseConfig returned incorrect config for %q: diff (-want, +got)\n%s", tc.existing, diff)
+		}
+	}
+}
+
+func TestFunctionsEmulator(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/emulators" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"hub": {"host": "localhost", "port": 4400}, "functions": {"host": "localhost", "port": 5001}}`)
+	}))
+	defer ts.Close()
+	base, err := functionsEmulator(strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("functionsEmulator returned %v, want %v", err, nil)
+	}
+	if want := "http://localhost:5001"; base != want {
+		t.Errorf("functionsEmulator returned %v, want %v", base, want)
+	}
+	fn := inlineFunction{name: "a", entryPoint: "fulfill"}
+	if got, want := functionURL(base+"/", "foo", "us-central1", fn), "http://localhost:5001/foo/us-central1/fulfill"; got != want {
+		t.Errorf("functionURL returned %v, want %v", got, want)
+	}
+}
+
+func TestEmulatedProjectFiles(t *testing.T) {
+	fn := inlineFunction{name: "ActionsOnGoogleFulfillment", entryPoint: "ActionsOnGoogleFulfillment"}
+	p := emulatedProject{Project: mockProject{files: webhookFiles}, fn: fn, url: "https://tunnel.example.com/foo/us-central1/ActionsOnGoogleFulfillment"}
+	files, err := p.Files()
+	if err != nil {
+		t.Fatalf("Files returned %v, want %v", err, nil)
+	}
+	var names []string
+	for k := range files {
+		names = append(names, k)
+	}
+	want := []string{"settings/settings.yaml", "webhooks/ActionsOnGoogleFulfillment.yaml", "webhooks/external.yaml"}
+	if diff := cmp.Diff(want, names, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("Files returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+	var got, wantDef interface{}
+	if err := yaml.Unmarshal(files["webhooks/ActionsOnGoogleFulfillment.yaml"], &got); err != nil {
+		t.Fatalf("Files returned a webhook with incorrect syntax: %v", err)
+	}
+	if err := yaml.Unmarshal([]byte(`handlers:
+- name: greeting
+httpsEndpoint:
+  baseUrl: https://tunnel.example.com/foo/us-central1/ActionsOnGoogleFulfillment
+`), &wantDef); err != nil {
+		t.Fatalf("Can't unmarshal the webhook: %v", err)
+	}
+	if diff := cmp.Diff(wantDef, got); diff != "" {
+		t.Errorf("Files returned incorrect webhook: diff (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// logsPollInterval is how often Cloud Logging is polled for new entries with --follow. It keeps
+// the requests well below the quota of entries:list.
+const logsPollInterval = 5 * time.Second
+
+// Severities are the severities of Cloud Logging, lowest first.
+var Severities = []string{"DEFAULT", "DEBUG", "INFO", "NOTICE", "WARNING", "ERROR", "CRITICAL", "ALERT", "EMERGENCY"}
+
+// logCursor tracks the entries already printed while following the logs.
+type logCursor struct {
+	last time.Time
+	// seen are the IDs of the printed entries with the timestamp last.
+	seen map[string]bool
+}
+
+// next returns the entries which weren't printed yet, and moves the cursor past them. The next
+// query starts at the time of the last entry, so the API returns the entries at that time again.
+func (c *logCursor) next(entries []sdk.LogEntry) []sdk.LogEntry {
+	var res []sdk.LogEntry
+	for _, e := range entries {
+		if e.Timestamp.Before(c.last) || (e.Timestamp.Equal(c.last) && c.seen[e.InsertID]) {
+			continue
+		}
+		if !e.Timestamp.Equal(c.last) {
+			c.last = e.Timestamp
+			c.seen = map[string]bool{}
+		}
+		c.seen[e.InsertID] = true
+		res = append(res, e)
+	}
+	return res
+}
+
+// printLogEntries writes entries to w, one line per line of their messages.
+func printLogEntries(w io.Writer, entries []sdk.LogEntry) {
+	for _, e := range entries {
+		prefix := fmt.Sprintf("%v %-8v %v", e.Timestamp.Local().Format("2006-01-02 15:04:05.000"), e.Severity, e.Function)
+		if e.ExecutionID != "" {
+			prefix += " [" + e.ExecutionID + "]"
+		}
+		for _, line := range strings.Split(e.Message, "\n") {
+			fmt.Fprintf(w, "%v %v\n", prefix, line)
+		}
+	}
+}
+
+// Logs prints the entries written to Cloud Logging in the last since by the inline cloud
+// function name, or by all inline cloud functions of proj if name is empty. With follow, new
+// entries are printed until interrupted.
+func Logs(ctx context.Context, proj project.Project, name string, since time.Duration, severity, text string, follow bool) error {
+	if severity != "" {
+		severity = strings.ToUpper(severity)
+		valid := false
+		for _, v := range Severities {
+			valid = valid || v == severity
+		}
+		if !valid {
+			return fmt.Errorf("severity must be one of %v, got %q", strings.Join(Severities, ", "), severity)
+		}
+	}
+	files, err := proj.Files()
+	if err != nil {
+		return err
+	}
+	fns, err := inlineFunctions(files)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		fn, err := selectFunction(fns, name)
+		if err != nil {
+			return err
+		}
+		fns = []inlineFunction{fn}
+	}
+	if len(fns) == 0 {
+		return errors.New("the project doesn't have an inline cloud function")
+	}
+	q := sdk.LogQuery{Since: time.Now().Add(-since), Severity: severity, Text: text}
+	for _, fn := range fns {
+		q.Functions = append(q.Functions, fn.entryPoint)
+	}
+	cursor := &logCursor{}
+	entries, err := sdk.ListLogsJSON(ctx, proj, q)
+	if err != nil {
+		return err
+	}
+	printLogEntries(log.OutLogger.Writer(), cursor.next(entries))
+	if !follow {
+		return nil
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+		if !cursor.last.IsZero() {
+			q.Since = cursor.last
+		}
+		entries, err := sdk.ListLogsJSON(ctx, proj, q)
+		if err != nil {
+			return err
+		}
+		printLogEntries(log.OutLogger.Writer(), cursor.next(entries))
+	}
+}
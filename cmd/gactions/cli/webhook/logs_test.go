@@ -0,0 +1,55 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLogCursorNext(t *testing.T) {
+	t0 := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+	t2 := t0.Add(2 * time.Second)
+	c := &logCursor{}
+	first := []sdk.LogEntry{
+		{InsertID: "a", Timestamp: t0},
+		{InsertID: "b", Timestamp: t1},
+		{InsertID: "c", Timestamp: t1},
+	}
+	if diff := cmp.Diff(first, c.next(first)); diff != "" {
+		t.Errorf("next returned incorrect entries of the first query: diff (-want, +got)\n%s", diff)
+	}
+	// The second query starts at t1, so it returns b and c again.
+	second := []sdk.LogEntry{
+		{InsertID: "b", Timestamp: t1},
+		{InsertID: "c", Timestamp: t1},
+		{InsertID: "d", Timestamp: t1},
+		{InsertID: "e", Timestamp: t2},
+	}
+	want := []sdk.LogEntry{
+		{InsertID: "d", Timestamp: t1},
+		{InsertID: "e", Timestamp: t2},
+	}
+	if diff := cmp.Diff(want, c.next(second)); diff != "" {
+		t.Errorf("next returned incorrect entries of the second query: diff (-want, +got)\n%s", diff)
+	}
+	if got := c.next(second[3:]); len(got) != 0 {
+		t.Errorf("next returned %v for printed entries, want none", got)
+	}
+}
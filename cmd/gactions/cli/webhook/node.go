@@ -0,0 +1,231 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+)
+
+// nodeStartTimeout is how long to wait for Node.js to listen on the port of the webhook.
+const nodeStartTimeout = 30 * time.Second
+
+// nodeWrapper is the script run by Node.js to serve an inline cloud function over HTTP.
+// Like Cloud Functions, it calls the exported function with Express-like request and
+// response objects, with the JSON body of the request already parsed.
+const nodeWrapper = `'use strict';
+const http = require('http');
+const path = require('path');
+
+const source = path.resolve(process.env.GACTIONS_SOURCE);
+const entryPoint = process.env.GACTIONS_ENTRY_POINT;
+const fn = require(source)[entryPoint];
+if (typeof fn !== 'function') {
+  console.error(entryPoint + ' is not a function exported by ' + source);
+  process.exit(1);
+}
+
+http.createServer((req, res) => {
+  const chunks = [];
+  req.on('data', (chunk) => chunks.push(chunk));
+  req.on('end', () => {
+    const raw = Buffer.concat(chunks).toString();
+    try {
+      req.body = raw ? JSON.parse(raw) : {};
+    } catch (e) {
+      req.body = raw;
+    }
+    req.get = (name) => req.headers[name.toLowerCase()];
+    res.status = (code) => {
+      res.statusCode = code;
+      return res;
+    };
+    res.set = (field, value) => {
+      if (typeof field === 'object') {
+        Object.keys(field).forEach((k) => res.setHeader(k, field[k]));
+      } else {
+        res.setHeader(field, value);
+      }
+      return res;
+    };
+    res.json = (body) => {
+      res.setHeader('Content-Type', 'application/json');
+      res.end(JSON.stringify(body));
+      return res;
+    };
+    res.send = (body) => {
+      if (body !== null && typeof body === 'object' && !Buffer.isBuffer(body)) {
+        return res.json(body);
+      }
+      res.end(body);
+      return res;
+    };
+    Promise.resolve().then(() => fn(req, res)).catch((err) => {
+      console.error(err);
+      if (!res.headersSent) {
+        res.statusCode = 500;
+      }
+      res.end();
+    });
+  });
+}).listen(Number(process.env.PORT), process.env.GACTIONS_HOST);
+`
+
+// nodeEnv returns the environment of the wrapper serving fn from dir on host:port.
+func nodeEnv(dir string, fn inlineFunction, host string, port int) []string {
+	return append(os.Environ(),
+		"GACTIONS_SOURCE="+dir,
+		"GACTIONS_ENTRY_POINT="+fn.entryPoint,
+		"GACTIONS_HOST="+host,
+		"PORT="+strconv.Itoa(port),
+	)
+}
+
+// startNode runs the wrapper with Node.js to serve fn from its source directory under root.
+// The wrapper is written to a temporary directory, which the returned function removes.
+var startNode = func(ctx context.Context, root string, fn inlineFunction, host string, port int) (*exec.Cmd, func(), error) {
+	dir, err := ioutil.TempDir("", "gactions-dev")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	wrapper := filepath.Join(dir, "wrapper.js")
+	if err := ioutil.WriteFile(wrapper, []byte(nodeWrapper), 0640); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	src := filepath.Join(root, filepath.FromSlash(fn.source()))
+	cmd := exec.CommandContext(ctx, "node", wrapper)
+	cmd.Dir = src
+	cmd.Env = nodeEnv(src, fn, host, port)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("can not start Node.js, check that it is installed: %v", err)
+	}
+	return cmd, cleanup, nil
+}
+
+// waitForPort waits until addr accepts connections. It fails if exited receives the
+// result of the server process, or timeout passes.
+func waitForPort(addr string, timeout time.Duration, exited <-chan error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("webhook didn't listen on %v in %v: %v", addr, timeout, err)
+		}
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("process exited")
+			}
+			return fmt.Errorf("webhook stopped before listening on %v: %v", addr, err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// Dev serves the inline cloud function name (or the only one of the project) with Node.js
+// on localhost:port until interrupted. If publicURL is set, the preview is deployed with
+// the webhook pointing to publicURL, which must forward to the port.
+func Dev(ctx context.Context, proj project.Project, name string, port int, publicURL string, sandbox bool) error {
+	if port < 1 {
+		return fmt.Errorf("port must be at least 1, got %v", port)
+	}
+	studioProj, ok := proj.(studio.Studio)
+	if !ok {
+		return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+	}
+	files, err := studio.ReadConfigFiles(studioProj)
+	if err != nil {
+		return err
+	}
+	fns, err := inlineFunctions(files)
+	if err != nil {
+		return err
+	}
+	fn, err := selectFunction(fns, name)
+	if err != nil {
+		return err
+	}
+	root := studioProj.ProjectRoot()
+	if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(fn.source()), "node_modules")); os.IsNotExist(err) {
+		log.Warnf("%v doesn't have node_modules. Run \"npm install\" in %v if the webhook has dependencies.\n", fn.source(), fn.source())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	node, cleanup, err := startNode(ctx, root, fn, "localhost", port)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- node.Wait()
+	}()
+	addr := net.JoinHostPort("localhost", strconv.Itoa(port))
+	if err := waitForPort(addr, nodeStartTimeout, exited); err != nil {
+		return err
+	}
+	log.Outf("Serving %v at http://%v\n", fn.name, addr)
+
+	if publicURL != "" {
+		if err := (&studioProj).SetProjectID(""); err != nil {
+			return err
+		}
+		if _, err := sdk.WritePreviewJSON(ctx, emulatedProject{Project: studioProj, fn: fn, url: publicURL}, sandbox); err != nil {
+			return err
+		}
+		log.Outf("The preview now sends webhook requests to %v. Run \"gactions deploy preview\" to restore the inline cloud function.\n", publicURL)
+	}
+
+	log.Outf("Press Ctrl+C to stop.\n")
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+	select {
+	case err := <-exited:
+		if err != nil {
+			return fmt.Errorf("webhook stopped: %v", err)
+		}
+		return errors.New("webhook stopped")
+	case <-stop:
+	}
+	cancel()
+	<-exited
+	log.DoneMsgln("Webhook stopped.")
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForPortProcessExited(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	exited := make(chan error, 1)
+	exited <- errors.New("exit status 1")
+	if err := waitForPort(addr, time.Minute, exited); err == nil {
+		t.Errorf("waitForPort returned %v after the process exited, but want an error", err)
+	}
+}
+
+func TestNodeWrapper(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is not installed")
+	}
+	root, err := ioutil.TempDir("", "gactions-dev-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	fn := inlineFunction{name: "fulfillment", entryPoint: "ActionsOnGoogleFulfillment"}
+	src := filepath.Join(root, "webhooks", fn.name)
+	if err := os.MkdirAll(src, 0750); err != nil {
+		t.Fatal(err)
+	}
+	code := `exports.ActionsOnGoogleFulfillment = (req, res) => {
+  res.status(200).set({'X-Handler': req.body.handler.name}).send({prompt: req.get('Content-Type')});
+};
+`
+	if err := ioutil.WriteFile(filepath.Join(src, "index.js"), []byte(code), 0640); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	node, cleanup, err := startNode(ctx, root, fn, "localhost", port)
+	if err != nil {
+		t.Fatalf("startNode returned %v, want %v", err, nil)
+	}
+	defer cleanup()
+	exited := make(chan error, 1)
+	go func() {
+		exited <- node.Wait()
+	}()
+	addr := net.JoinHostPort("localhost", strconv.Itoa(port))
+	if err := waitForPort(addr, 10*time.Second, exited); err != nil {
+		t.Fatalf("waitForPort returned %v, want %v", err, nil)
+	}
+	resp, err := http.Post("http://"+addr, "application/json", strings.NewReader(`{"handler": {"name": "greeting"}}`))
+	if err != nil {
+		t.Fatalf("request to the webhook returned %v, want %v", err, nil)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("X-Handler"); got != "greeting" {
+		t.Errorf("webhook returned header %q, want %q", got, "greeting")
+	}
+	if want := `{"prompt":"application/json"}`; string(body) != want {
+		t.Errorf("webhook returned body %s, want %s", body, want)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+)
+
+// pingTimeout is how long to wait for a response to a ping request. It allows for a cold start
+// of the function, which can take several seconds.
+const pingTimeout = 60 * time.Second
+
+// pingClient sends the ping requests.
+var pingClient = &http.Client{Timeout: pingTimeout}
+
+// pingResult is the outcome of a ping request sent to a webhook.
+type pingResult struct {
+	status  string
+	latency time.Duration
+	err     error
+}
+
+// deployedFunctionURL returns the URL at which Cloud Functions serves fn once deployed with the project.
+func deployedFunctionURL(projectID, region string, fn inlineFunction) string {
+	return "https://" + region + "-" + projectID + ".cloudfunctions.net/" + fn.entryPoint
+}
+
+// pingRequest returns the body of a webhook request which invokes handler.
+func pingRequest(handler string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"handler": map[string]interface{}{"name": handler},
+		"intent":  map[string]interface{}{"name": "actions.intent.MAIN", "params": map[string]interface{}{}, "query": ""},
+		"scene":   map[string]interface{}{"name": "actions.scene.START_CONVERSATION"},
+		"session": map[string]interface{}{"id": "gactions-ping", "params": map[string]interface{}{}, "languageCode": ""},
+		"user":    map[string]interface{}{"locale": "en-US", "params": map[string]interface{}{}},
+		"device":  map[string]interface{}{"capabilities": []string{"SPEECH", "RICH_RESPONSE"}},
+	})
+}
+
+// ping sends a webhook request invoking handler to url, and returns the status and latency
+// of the response. A response with a status other than 2xx is returned as an error.
+func ping(ctx context.Context, client *http.Client, url, handler string) pingResult {
+	body, err := pingRequest(handler)
+	if err != nil {
+		return pingResult{err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return pingResult{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return pingResult{latency: time.Since(start), err: err}
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	res := pingResult{status: resp.Status, latency: time.Since(start), err: err}
+	if res.err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		res.err = fmt.Errorf("function returned %v: %v", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return res
+}
+
+// pingFunctions sends two ping requests to each of fns at the URL given by url: the first one
+// measures the cold start of a freshly deployed function, the second one a warm instance.
+// It writes the results to w, and returns the names of the webhooks which failed a request.
+func pingFunctions(ctx context.Context, w io.Writer, client *http.Client, fns []inlineFunction, url func(inlineFunction) string, handler string) []string {
+	var failed []string
+	for _, fn := range fns {
+		u := url(fn)
+		fmt.Fprintf(w, "Pinging webhook %v at %v\n", fn.name, u)
+		ok := true
+		for _, attempt := range []string{"cold start", "warm"} {
+			res := ping(ctx, client, u, handler)
+			if res.err != nil {
+				fmt.Fprintf(w, "  %v: failed after %v: %v\n", attempt, res.latency.Round(time.Millisecond), res.err)
+				ok = false
+				break
+			}
+			fmt.Fprintf(w, "  %v: %v (%v)\n", attempt, res.latency.Round(time.Millisecond), res.status)
+		}
+		if !ok {
+			failed = append(failed, fn.name)
+		}
+	}
+	return failed
+}
+
+// Ping invokes the deployed inline cloud functions of proj with a ping request calling handler,
+// and reports their cold start latency and errors. The project ID of proj must be set.
+// It returns an error if any of the functions didn't serve the request.
+func Ping(ctx context.Context, proj project.Project, region, handler string) error {
+	files, err := proj.Files()
+	if err != nil {
+		return err
+	}
+	fns, err := inlineFunctions(files)
+	if err != nil {
+		return err
+	}
+	if len(fns) == 0 {
+		log.Warnf("The project doesn't have an inline cloud function to ping.\n")
+		return nil
+	}
+	url := func(fn inlineFunction) string {
+		return deployedFunctionURL(proj.ProjectID(), region, fn)
+	}
+	failed := pingFunctions(ctx, log.OutLogger.Writer(), pingClient, fns, url, handler)
+	if len(failed) > 0 {
+		return fmt.Errorf("webhooks didn't serve the ping request: %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
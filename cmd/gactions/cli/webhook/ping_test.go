@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPingFunctions(t *testing.T) {
+	var handlers []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Handler struct {
+				Name string `json:"name"`
+			} `json:"handler"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("webhook request has incorrect syntax: %v", err)
+		}
+		handlers = append(handlers, req.Handler.Name)
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			http.Error(w, "Handler not found", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+
+	fns := []inlineFunction{
+		{name: "a", entryPoint: "ok"},
+		{name: "b", entryPoint: "broken"},
+	}
+	url := func(fn inlineFunction) string { return ts.URL + "/" + fn.entryPoint }
+	var out bytes.Buffer
+	failed := pingFunctions(context.Background(), &out, ts.Client(), fns, url, "ping")
+	if diff := cmp.Diff([]string{"b"}, failed); diff != "" {
+		t.Errorf("pingFunctions returned incorrect failed webhooks: diff (-want, +got)\n%s", diff)
+	}
+	// The working function is pinged cold and warm, the broken one only once.
+	if diff := cmp.Diff([]string{"ping", "ping", "ping"}, handlers); diff != "" {
+		t.Errorf("pingFunctions sent incorrect requests: diff (-want, +got)\n%s", diff)
+	}
+	for _, want := range []string{"cold start:", "warm:", "Handler not found"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("pingFunctions wrote %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
+
+func TestDeployedFunctionURL(t *testing.T) {
+	got := deployedFunctionURL("foo", "us-central1", inlineFunction{name: "a", entryPoint: "ActionsOnGoogleFulfillment"})
+	want := "https://us-central1-foo.cloudfunctions.net/ActionsOnGoogleFulfillment"
+	if got != want {
+		t.Errorf("deployedFunctionURL returned %v, want %v", got, want)
+	}
+}
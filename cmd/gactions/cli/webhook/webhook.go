@@ -0,0 +1,172 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook provides an implementation of "gactions webhook" command.
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/sdk"
+	"github.com/actions-on-google/gactions/log"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/actions-on-google/gactions/project/studio"
+	"github.com/spf13/cobra"
+)
+
+// emulatorStartTimeout is how long to wait for the Functions emulator started by the CLI.
+const emulatorStartTimeout = 90 * time.Second
+
+// AddCommand adds the webhook sub-command to the passed in root command.
+func AddCommand(ctx context.Context, root *cobra.Command, proj project.Project) {
+	webhook := &cobra.Command{
+		Use:   "webhook",
+		Short: "This is the main command for working with the webhooks of a project. See below for a complete list of sub-commands.",
+		Long:  "This is the main command for working with the webhooks of a project. See below for a complete list of sub-commands.",
+		Args:  cobra.MinimumNArgs(1),
+	}
+	run := &cobra.Command{
+		Use:   "run",
+		Short: "Run the inline cloud function locally and use it as fulfillment of the preview.",
+		Long: "This command runs the inline cloud function of the project in a local emulator, and deploys the project for preview with the fulfillment served by the emulator. " +
+			"The emulator must be reachable from Google through the URL given by --public-url, for example a tunnel to the port of the emulator. " +
+			"Run \"gactions deploy preview\" to restore the inline cloud function in the preview.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if proj.ProjectRoot() == "" {
+				log.Errorf(`Can't find a project root. This may be because (1) %q was not found in this or any of the parent folders, or (2) if %q was found, but the key "sdkPath" was missing, or (3) if %q and manifest.yaml were both not found.`, project.ConfigName, project.ConfigName, project.ConfigName)
+				return errors.New("can not determine project root")
+			}
+			firebase, err := cmd.Flags().GetBool("firebase-emulator")
+			if err != nil {
+				return err
+			}
+			if !firebase {
+				return errors.New("only the Firebase emulator is supported, run with --firebase-emulator")
+			}
+			return runFirebaseEmulator(ctx, cmd, proj)
+		},
+	}
+	run.Flags().Bool("firebase-emulator", false, "Serve the inline cloud function with the Functions emulator of the Firebase Local Emulator Suite.")
+	run.Flags().String("webhook", "", "Name of the inline cloud function to run (i.e. the name of its file in the webhooks directory). Required if the project has several inline cloud functions.")
+	run.Flags().String("emulator-hub", "localhost:4400", "Address of the hub of a running Firebase Local Emulator Suite. If the hub isn't running, the CLI starts the Functions emulator with the Firebase CLI.")
+	run.Flags().String("region", "us-central1", "Region in the URL of the function served by the emulator.")
+	run.Flags().String("public-url", "", "Public HTTPS URL that forwards to the Functions emulator, e.g. a tunnel to http://localhost:5001. Required, because the preview can't reach your computer directly.")
+	run.Flags().Bool("sandbox", true, "Indicates whether or not to run certain operations, such as transactions, in sandbox mode. The default value is set to true")
+	webhook.AddCommand(run)
+	root.AddCommand(webhook)
+}
+
+func runFirebaseEmulator(ctx context.Context, cmd *cobra.Command, proj project.Project) error {
+	name, err := cmd.Flags().GetString("webhook")
+	if err != nil {
+		return err
+	}
+	hub, err := cmd.Flags().GetString("emulator-hub")
+	if err != nil {
+		return err
+	}
+	region, err := cmd.Flags().GetString("region")
+	if err != nil {
+		return err
+	}
+	publicURL, err := cmd.Flags().GetString("public-url")
+	if err != nil {
+		return err
+	}
+	sandbox, err := cmd.Flags().GetBool("sandbox")
+	if err != nil {
+		return err
+	}
+	if publicURL == "" {
+		return errors.New("--public-url must be set to a URL that forwards to the Functions emulator")
+	}
+	studioProj, ok := proj.(studio.Studio)
+	if !ok {
+		return fmt.Errorf("can not convert %T to %T", proj, studio.Studio{})
+	}
+	if err := (&studioProj).SetProjectID(""); err != nil {
+		return err
+	}
+	files, err := studioProj.Files()
+	if err != nil {
+		return err
+	}
+	fns, err := inlineFunctions(files)
+	if err != nil {
+		return err
+	}
+	fn, err := selectFunction(fns, name)
+	if err != nil {
+		return err
+	}
+
+	// Functions emulator serves the functions from the source in firebase.json.
+	cfgPath := filepath.Join(studioProj.ProjectRoot(), "firebase.json")
+	existing, err := ioutil.ReadFile(cfgPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	cfg, changed, err := firebaseConfig(existing, fn.source())
+	if err != nil {
+		return err
+	}
+	if changed {
+		log.Outf("Setting the source of functions in %v to %v\n", cfgPath, fn.source())
+		if err := ioutil.WriteFile(cfgPath, cfg, 0640); err != nil {
+			return err
+		}
+	}
+
+	var emulator *exec.Cmd
+	base, err := functionsEmulator(hub)
+	switch {
+	case err == nil && changed:
+		return fmt.Errorf("the Firebase emulator at %v was started with a different source of functions; restart it to serve %v", hub, fn.source())
+	case err == nil:
+		log.Outf("Using the Functions emulator at %v\n", base)
+	default:
+		log.Infof("Can not reach the emulator hub at %v: %v\n", hub, err)
+		log.Outf("Starting the Functions emulator for %v\n", fn.source())
+		if emulator, err = startEmulator(ctx, studioProj.ProjectRoot(), studioProj.ProjectID()); err != nil {
+			return err
+		}
+		if base, err = waitForEmulator(hub, emulatorStartTimeout); err != nil {
+			emulator.Process.Kill()
+			return err
+		}
+	}
+	log.Outf("The emulator serves the webhook at %v\n", functionURL(base, studioProj.ProjectID(), region, fn))
+
+	url := functionURL(publicURL, studioProj.ProjectID(), region, fn)
+	if _, err := sdk.WritePreviewJSON(ctx, emulatedProject{Project: studioProj, fn: fn, url: url}, sandbox); err != nil {
+		if emulator != nil {
+			emulator.Process.Kill()
+		}
+		return err
+	}
+	log.Outf("The preview now sends webhook requests to %v. Run \"gactions deploy preview\" to restore the inline cloud function.\n", url)
+	if emulator == nil {
+		return nil
+	}
+	log.Outf("Press Ctrl+C to stop the emulator.\n")
+	return emulator.Wait()
+}
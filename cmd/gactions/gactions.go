@@ -26,5 +26,5 @@ import (
 func main() {
 	ctx := context.Background()
 	cmd := cli.Command(ctx, "gactions", false, versions.CliVersion)
-	os.Exit(cli.Execute(cmd))
+	os.Exit(cli.Execute(cmd, os.Args[1:]))
 }
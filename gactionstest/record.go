@@ -0,0 +1,168 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gactionstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/actions-on-google/gactions/log"
+)
+
+// Fixture is a response of the Actions API recorded by a recorder. Fixtures are saved as JSON
+// files named after the order of the requests, e.g. 001.json, so they can be edited by hand.
+type Fixture struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// NewRecorder returns a handler which forwards the requests to the Actions API at target, e.g.
+// https://actions.googleapis.com, and saves each response as a Fixture in dir. The requests keep
+// their headers, so the credentials of the caller are used, but only the responses are saved.
+func NewRecorder(target, dir string) (http.Handler, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("target must be a URL, e.g. https://actions.googleapis.com, got %q", target)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	r := &recorder{dir: dir}
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = u.Host
+	}
+	proxy.ModifyResponse = r.record
+	return proxy, nil
+}
+
+type recorder struct {
+	dir string
+	mu  sync.Mutex
+	n   int
+}
+
+// record saves resp as the next fixture, and restores its body for the caller.
+func (r *recorder) record(resp *http.Response) error {
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	f := Fixture{Method: resp.Request.Method, Path: resp.Request.URL.Path, Status: resp.StatusCode, Body: string(b)}
+	out, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.n++
+	name := filepath.Join(r.dir, fmt.Sprintf("%03d.json", r.n))
+	log.Infof("Recording %v %v in %v\n", f.Method, f.Path, name)
+	return ioutil.WriteFile(name, out, 0640)
+}
+
+// ReadFixtures returns the fixtures saved in dir, in the order they were recorded.
+func ReadFixtures(dir string) ([]Fixture, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var res []Fixture
+	for _, name := range names {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("%v is not a fixture: %v", name, err)
+		}
+		res = append(res, f)
+	}
+	return res, nil
+}
+
+// NewReplayHandler returns a handler which responds with the fixtures saved in dir. The n-th
+// request to an endpoint gets the n-th fixture recorded for it; once they are used up, it gets
+// the last one again. Requests to endpoints without fixtures fail with 404.
+func NewReplayHandler(dir string) (http.Handler, error) {
+	fixtures, err := ReadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &replayer{fixtures: map[string][]Fixture{}}
+	for _, f := range fixtures {
+		k := f.Method + " " + f.Path
+		r.fixtures[k] = append(r.fixtures[k], f)
+	}
+	return r, nil
+}
+
+// NewReplayServer starts a server with the handler of NewReplayHandler. The caller must close the
+// server.
+func NewReplayServer(dir string) (*httptest.Server, error) {
+	h, err := NewReplayHandler(dir)
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(h), nil
+}
+
+type replayer struct {
+	mu       sync.Mutex
+	fixtures map[string][]Fixture
+}
+
+func (r *replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// The request is read in full, as the client may block until its stream is consumed.
+	ioutil.ReadAll(req.Body)
+	k := req.Method + " " + req.URL.Path
+	r.mu.Lock()
+	fs := r.fixtures[k]
+	if len(fs) == 0 {
+		r.mu.Unlock()
+		writeError(w, &statusError{code: http.StatusNotFound, message: fmt.Sprintf("%v has no recorded fixture", k)}, strings.HasSuffix(req.URL.Path, ":read"))
+		return
+	}
+	f := fs[0]
+	if len(fs) > 1 {
+		r.fixtures[k] = fs[1:]
+	}
+	r.mu.Unlock()
+	log.Infof("Replaying %v\n", k)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Status)
+	if _, err := w.Write([]byte(f.Body)); err != nil {
+		log.Warnf("Failed to write a response: %v\n", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gactionstest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/request"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	api := NewServer()
+	defer api.Close()
+	dir := t.TempDir()
+	rec, err := NewRecorder(api.URL, dir)
+	if err != nil {
+		t.Fatalf("NewRecorder returned %v, want %v", err, nil)
+	}
+	recSrv := httptest.NewServer(rec)
+	defer recSrv.Close()
+
+	body := stream(t, testConfigFiles, nil, func() map[string]interface{} { return request.WriteDraft("my-project", false) }, 1000)
+	if code, b := post(t, recSrv.URL+"/v2/projects/my-project/draft:write", body); code != http.StatusOK {
+		t.Fatalf("draft:write through the recorder returned %v %s, want %v", code, b, http.StatusOK)
+	}
+	req, err := json.Marshal(request.ReadDraft("my-project", ""))
+	if err != nil {
+		t.Fatalf("json.Marshal returned %v", err)
+	}
+	code, recorded := post(t, recSrv.URL+"/v2/projects/my-project/draft:read", req)
+	if code != http.StatusOK {
+		t.Fatalf("draft:read through the recorder returned %v %s, want %v", code, recorded, http.StatusOK)
+	}
+
+	fixtures, err := ReadFixtures(dir)
+	if err != nil {
+		t.Fatalf("ReadFixtures returned %v, want %v", err, nil)
+	}
+	var got []string
+	for _, f := range fixtures {
+		got = append(got, f.Method+" "+f.Path)
+	}
+	want := []string{"POST /v2/projects/my-project/draft:write", "POST /v2/projects/my-project/draft:read"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadFixtures returned diff (-want, +got)\n%s", diff)
+	}
+
+	replay, err := NewReplayServer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayServer returned %v, want %v", err, nil)
+	}
+	defer replay.Close()
+	// The fixture is served again once it's used up.
+	for i := 0; i < 2; i++ {
+		code, b := post(t, replay.URL+"/v2/projects/my-project/draft:read", req)
+		if code != http.StatusOK {
+			t.Fatalf("draft:read from the replay server returned %v %s, want %v", code, b, http.StatusOK)
+		}
+		if string(b) != string(recorded) {
+			t.Errorf("draft:read from the replay server returned %s, want %s", b, recorded)
+		}
+	}
+	if code, b := post(t, replay.URL+"/v2/projects/my-project/preview:write", body); code != http.StatusNotFound {
+		t.Errorf("preview:write without a fixture returned %v %s, want %v", code, b, http.StatusNotFound)
+	}
+}
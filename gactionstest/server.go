@@ -0,0 +1,489 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gactionstest provides fake Actions APIs for tests of the CLI and of programs which
+// embed it: an in-memory server of the draft, preview and version endpoints, and a recorder which
+// captures the responses of the real API as fixtures, which a replay server serves back.
+package gactionstest
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/request"
+	"github.com/actions-on-google/gactions/log"
+)
+
+const (
+	settingsPath = "settings/settings.yaml"
+	manifestPath = "manifest.yaml"
+	// listProjectsPath is the path of the endpoint which lists the projects.
+	listProjectsPath = "/v2/projects"
+	// defaultChannel is the release channel of versions created without one.
+	defaultChannel = "actions.channels.Production"
+	// timeFormat is the format of timestamps in the responses of the API.
+	timeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+var (
+	writeRegExp       = regexp.MustCompile(`^/v2/projects/([^/]+)/(draft:write|preview:write|versions:create)$`)
+	readDraftRegExp   = regexp.MustCompile(`^/v2/projects/([^/]+)/draft:read$`)
+	readVersionRegExp = regexp.MustCompile(`^/v2/projects/([^/]+)/versions/([^/]+):read$`)
+	listRegExp        = regexp.MustCompile(`^/v2/projects/([^/]+)/(releaseChannels|versions)$`)
+	// builtInChannels are the release channels every project has.
+	builtInChannels = []string{"actions.channels.Production", "actions.channels.ClosedBeta", "actions.channels.Alpha"}
+)
+
+// dataFile is a data file of a project, as sent in a stream.
+type dataFile struct {
+	FilePath    string `json:"filePath"`
+	ContentType string `json:"contentType"`
+	Payload     []byte `json:"payload"`
+}
+
+// files holds the config files (as sent in a stream) and data files of a project by path.
+type files struct {
+	configFiles map[string]map[string]interface{}
+	dataFiles   map[string]dataFile
+}
+
+func newFiles() files {
+	return files{configFiles: map[string]map[string]interface{}{}, dataFiles: map[string]dataFile{}}
+}
+
+type version struct {
+	id      string
+	channel string
+	files   files
+	created time.Time
+}
+
+// mockProject is the state of a project on the mock server.
+type mockProject struct {
+	draft   files
+	preview files
+	// versions holds the versions in the order they were created, so the ID of a version is
+	// its index plus one.
+	versions []version
+}
+
+// server is an in-memory implementation of the endpoints of Actions API used by the CLI.
+type server struct {
+	mu       sync.Mutex
+	projects map[string]*mockProject
+	// now returns the current time. It's replaced in tests.
+	now func() time.Time
+}
+
+func newServer() *server {
+	return &server{projects: map[string]*mockProject{}, now: time.Now}
+}
+
+// NewHandler returns a handler which serves the endpoints of the Actions API used by the CLI to
+// write and read the draft, write the preview, create, read and list versions, and list the
+// projects. The projects are kept in memory. The handler accepts the same streamed requests as
+// the API, and streams the files of the read endpoints like it does.
+func NewHandler() http.Handler {
+	return newServer()
+}
+
+// NewServer starts a server with the handler of NewHandler. Its URL is the endpoint of the API
+// for the --api-endpoint flag of the CLI, or the Endpoint option of the client package. The
+// caller must close the server.
+func NewServer() *httptest.Server {
+	return httptest.NewServer(NewHandler())
+}
+
+// project returns the state of the project with id, creating an empty one if it doesn't exist.
+// s.mu must be held.
+func (s *server) project(id string) *mockProject {
+	p, ok := s.projects[id]
+	if !ok {
+		p = &mockProject{draft: newFiles(), preview: newFiles()}
+		s.projects[id] = p
+	}
+	return p
+}
+
+// streamRecord is a request in a stream sent by the CLI.
+type streamRecord struct {
+	ReleaseChannel string `json:"release_channel"`
+	ValidateOnly   bool   `json:"validateOnly"`
+	Files          struct {
+		ConfigFiles *struct {
+			ConfigFiles []map[string]interface{} `json:"configFiles"`
+		} `json:"configFiles"`
+		DataFiles *struct {
+			DataFiles []dataFile `json:"dataFiles"`
+		} `json:"dataFiles"`
+	} `json:"files"`
+}
+
+// statusError is an error with the HTTP status to respond with.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func invalidArgument(format string, a ...interface{}) error {
+	return &statusError{code: http.StatusBadRequest, message: fmt.Sprintf(format, a...)}
+}
+
+// streamOptions are the options of a stream, which are set by its first request.
+type streamOptions struct {
+	channel      string
+	validateOnly bool
+}
+
+// readStream reads the files from a stream of requests, which is a JSON array like the one
+// sent by the CLI. It returns the options set by the first request.
+func readStream(body io.Reader) (files, streamOptions, error) {
+	res := newFiles()
+	var opts streamOptions
+	dec := json.NewDecoder(body)
+	t, err := dec.Token()
+	if err != nil {
+		return res, opts, invalidArgument("request is not a JSON array: %v", err)
+	}
+	if t != json.Delim('[') {
+		return res, opts, invalidArgument("expected [ got %v", t)
+	}
+	first := true
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return res, opts, invalidArgument("malformed request in the stream: %v", err)
+		}
+		if len(raw) > request.MaxChunkSizeBytes {
+			return res, opts, invalidArgument("request in the stream is %v bytes, which exceeds the limit of %v bytes", len(raw), request.MaxChunkSizeBytes)
+		}
+		var rec streamRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return res, opts, invalidArgument("malformed request in the stream: %v", err)
+		}
+		if rec.Files.ConfigFiles != nil {
+			for _, v := range rec.Files.ConfigFiles.ConfigFiles {
+				fp, _ := v["filePath"].(string)
+				if fp == "" {
+					return res, opts, invalidArgument("config file without filePath")
+				}
+				res.configFiles[fp] = v
+			}
+		}
+		if rec.Files.DataFiles != nil {
+			for _, v := range rec.Files.DataFiles.DataFiles {
+				if v.FilePath == "" {
+					return res, opts, invalidArgument("data file without filePath")
+				}
+				res.dataFiles[v.FilePath] = v
+			}
+		}
+		if first {
+			// Like the API, settings and manifest must be in the first request of the stream.
+			if _, ok := res.configFiles[settingsPath]; !ok {
+				return res, opts, invalidArgument("%v must be in the first request of the stream", settingsPath)
+			}
+			if _, ok := res.configFiles[manifestPath]; !ok {
+				return res, opts, invalidArgument("%v must be in the first request of the stream", manifestPath)
+			}
+			opts = streamOptions{channel: rec.ReleaseChannel, validateOnly: rec.ValidateOnly}
+			first = false
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return res, opts, invalidArgument("request is not terminated with ]: %v", err)
+	}
+	if first {
+		return res, opts, invalidArgument("stream has no requests")
+	}
+	return res, opts, nil
+}
+
+// writeStream writes files as a stream of responses, like the read endpoints of the API: the
+// config files in the first response, followed by a response for each data file.
+func writeStream(w io.Writer, f files) error {
+	var cfgs []map[string]interface{}
+	var names []string
+	for k := range f.configFiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		cfgs = append(cfgs, f.configFiles[k])
+	}
+	resps := []interface{}{
+		map[string]interface{}{"files": map[string]interface{}{"configFiles": map[string]interface{}{"configFiles": cfgs}}},
+	}
+	names = nil
+	for k := range f.dataFiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		resps = append(resps, map[string]interface{}{"files": map[string]interface{}{"dataFiles": map[string]interface{}{"dataFiles": []dataFile{f.dataFiles[k]}}}})
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i, v := range resps {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warnf("Failed to write a response: %v\n", err)
+	}
+}
+
+// writeError responds with err in the format of the errors of the API. Errors of the read
+// endpoints are wrapped in an array, because the responses of these endpoints are streamed.
+func writeError(w http.ResponseWriter, err error, streamed bool) {
+	code := http.StatusInternalServerError
+	var se *statusError
+	if errors.As(err, &se) {
+		code = se.code
+	}
+	e := map[string]interface{}{"error": map[string]interface{}{"code": code, "message": err.Error()}}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	var v interface{} = e
+	if streamed {
+		v = []interface{}{e}
+	}
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Infof("%v %v\n", r.Method, r.URL.Path)
+	if m := writeRegExp.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodPost {
+		s.handleWrite(w, r, m[1], m[2])
+		return
+	}
+	if m := readDraftRegExp.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodPost {
+		s.handleReadDraft(w, m[1])
+		return
+	}
+	if m := readVersionRegExp.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodPost {
+		s.handleReadVersion(w, m[1], m[2])
+		return
+	}
+	if m := listRegExp.FindStringSubmatch(r.URL.Path); m != nil && r.Method == http.MethodGet {
+		s.handleList(w, m[1], m[2])
+		return
+	}
+	if r.URL.Path == listProjectsPath && r.Method == http.MethodGet {
+		s.handleListProjects(w)
+		return
+	}
+	writeError(w, &statusError{code: http.StatusNotFound, message: fmt.Sprintf("%v %v is not supported by the mock server", r.Method, r.URL.Path)}, false)
+}
+
+// requestBody returns the body of r, decompressed if it's sent with gzip like pushes of the CLI.
+// Other content encodings are rejected with 415, as RFC 7694 recommends.
+func requestBody(r *http.Request) (io.Reader, error) {
+	switch enc := r.Header.Get("Content-Encoding"); enc {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, invalidArgument("request is not gzip compressed: %v", err)
+		}
+		return zr, nil
+	default:
+		return nil, &statusError{code: http.StatusUnsupportedMediaType, message: fmt.Sprintf("content encoding %q is not supported", enc)}
+	}
+}
+
+func (s *server) handleWrite(w http.ResponseWriter, r *http.Request, projectID, method string) {
+	body, err := requestBody(r)
+	if err != nil {
+		writeError(w, err, false)
+		return
+	}
+	f, opts, err := readStream(body)
+	if err != nil {
+		writeError(w, err, false)
+		return
+	}
+	if opts.validateOnly && method != "versions:create" {
+		// The mock server doesn't validate the files, so it has no issues to return.
+		log.Outf("Validated %v files for the %v of %q.\n", len(f.configFiles)+len(f.dataFiles), strings.TrimSuffix(method, ":write"), projectID)
+		writeJSON(w, map[string]interface{}{})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.project(projectID)
+	switch method {
+	case "draft:write":
+		// The files of the stream replace the draft, as they do on the server.
+		p.draft = f
+		log.Outf("Wrote %v files to the draft of %q.\n", len(f.configFiles)+len(f.dataFiles), projectID)
+		writeJSON(w, map[string]interface{}{"name": fmt.Sprintf("projects/%v/draft", projectID)})
+	case "preview:write":
+		p.preview = f
+		log.Outf("Wrote %v files to the preview of %q.\n", len(f.configFiles)+len(f.dataFiles), projectID)
+		writeJSON(w, map[string]interface{}{
+			"name":         fmt.Sprintf("projects/%v/preview", projectID),
+			"simulatorUrl": fmt.Sprintf("http://%v/simulator/%v", r.Host, projectID),
+		})
+	case "versions:create":
+		channel := opts.channel
+		if channel == "" {
+			channel = defaultChannel
+		}
+		v := version{id: strconv.Itoa(len(p.versions) + 1), channel: channel, files: f, created: s.now()}
+		p.versions = append(p.versions, v)
+		log.Outf("Created version %v of %q in %q.\n", v.id, projectID, channel)
+		writeJSON(w, map[string]interface{}{"name": versionName(projectID, v.id)})
+	}
+}
+
+func (s *server) handleReadDraft(w http.ResponseWriter, projectID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeStream(w, s.project(projectID).draft); err != nil {
+		log.Warnf("Failed to write a response: %v\n", err)
+	}
+}
+
+func (s *server) handleReadVersion(w http.ResponseWriter, projectID, versionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.project(projectID)
+	n, err := strconv.Atoi(versionID)
+	if err != nil || n < 1 || n > len(p.versions) {
+		writeError(w, &statusError{code: http.StatusNotFound, message: fmt.Sprintf("version %v of project %v was not found", versionID, projectID)}, true)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := writeStream(w, p.versions[n-1].files); err != nil {
+		log.Warnf("Failed to write a response: %v\n", err)
+	}
+}
+
+func (s *server) handleList(w http.ResponseWriter, projectID, collection string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.project(projectID)
+	if collection == "versions" {
+		vs := []interface{}{}
+		for i := len(p.versions) - 1; i >= 0; i-- {
+			v := p.versions[i]
+			vs = append(vs, map[string]interface{}{
+				"name":         versionName(projectID, v.id),
+				"versionState": map[string]string{"message": "Deployed"},
+				"creator":      "mock-server",
+				"updateTime":   v.created.UTC().Format(timeFormat),
+			})
+		}
+		writeJSON(w, map[string]interface{}{"versions": vs})
+		return
+	}
+	channels := append([]string{}, builtInChannels...)
+	current := map[string]string{}
+	for _, v := range p.versions {
+		if _, ok := current[v.channel]; !ok && !contains(builtInChannels, v.channel) {
+			channels = append(channels, v.channel)
+		}
+		current[v.channel] = v.id
+	}
+	var cs []interface{}
+	for _, c := range channels {
+		rc := map[string]string{"name": fmt.Sprintf("projects/%v/releaseChannels/%v", projectID, c)}
+		if id, ok := current[c]; ok {
+			rc["currentVersion"] = versionName(projectID, id)
+		}
+		cs = append(cs, rc)
+	}
+	writeJSON(w, map[string]interface{}{"releaseChannels": cs})
+}
+
+// handleListProjects lists the projects which were written to, sorted by ID.
+func (s *server) handleListProjects(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.projects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	ps := []interface{}{}
+	for _, id := range ids {
+		p := s.projects[id]
+		v := map[string]string{
+			"name":        "projects/" + id,
+			"projectId":   id,
+			"displayName": displayName(p.draft),
+		}
+		if n := len(p.versions); n > 0 {
+			v["lastDeployTime"] = p.versions[n-1].created.UTC().Format(timeFormat)
+		}
+		ps = append(ps, v)
+	}
+	writeJSON(w, map[string]interface{}{"projects": ps})
+}
+
+// displayName returns the display name in the settings of f, or "" if it has none.
+func displayName(f files) string {
+	settings, _ := f.configFiles[settingsPath]["settings"].(map[string]interface{})
+	localized, _ := settings["localizedSettings"].(map[string]interface{})
+	name, _ := localized["displayName"].(string)
+	return name
+}
+
+func versionName(projectID, id string) string {
+	return fmt.Sprintf("projects/%v/versions/%v", projectID, id)
+}
+
+func contains(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
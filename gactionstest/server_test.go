@@ -0,0 +1,243 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gactionstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/request"
+	"github.com/google/go-cmp/cmp"
+)
+
+var testConfigFiles = map[string][]byte{
+	"settings/settings.yaml":  []byte("projectId: my-project\n"),
+	"manifest.yaml":           []byte("version: \"1.0\"\n"),
+	"custom/scenes/Main.yaml": []byte("onEnter:\n  staticPromptName: welcome\n"),
+}
+
+// stream returns the stream of requests the CLI sends for files, with chunks of chunkSize bytes.
+func stream(t *testing.T, configFiles, dataFiles map[string][]byte, makeRequest func() map[string]interface{}, chunkSize int) []byte {
+	t.Helper()
+	s := request.NewStreamer(configFiles, request.InMemoryDataFiles(dataFiles), makeRequest, "", chunkSize)
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for first := true; s.HasNext(); first = false {
+		req, err := s.Next()
+		if err != nil {
+			t.Fatalf("Next returned %v", err)
+		}
+		if !first {
+			buf.WriteString(",")
+		}
+		if _, err := request.WriteJSON(&buf, req); err != nil {
+			t.Fatalf("WriteJSON returned %v", err)
+		}
+	}
+	buf.WriteString("]")
+	return buf.Bytes()
+}
+
+func post(t *testing.T, url string, body []byte) (int, []byte) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("http.Post(%v) returned %v", url, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ioutil.ReadAll returned %v", err)
+	}
+	return resp.StatusCode, b
+}
+
+// filesIn returns the paths of the files in a response stream.
+func filesIn(t *testing.T, body []byte) []string {
+	t.Helper()
+	var recs []streamRecord
+	if err := json.Unmarshal(body, &recs); err != nil {
+		t.Fatalf("json.Unmarshal(%s) returned %v", body, err)
+	}
+	var res []string
+	for _, r := range recs {
+		if r.Files.ConfigFiles != nil {
+			for _, v := range r.Files.ConfigFiles.ConfigFiles {
+				res = append(res, v["filePath"].(string))
+			}
+		}
+		if r.Files.DataFiles != nil {
+			for _, v := range r.Files.DataFiles.DataFiles {
+				res = append(res, v.FilePath)
+			}
+		}
+	}
+	return res
+}
+
+func TestDraft(t *testing.T) {
+	ts := httptest.NewServer(newServer())
+	defer ts.Close()
+	dataFiles := map[string][]byte{"resources/images/a.png": []byte("png")}
+	// A small chunk size splits the files across several requests.
+	body := stream(t, testConfigFiles, dataFiles, func() map[string]interface{} { return request.WriteDraft("my-project", false) }, 200)
+	code, b := post(t, ts.URL+"/v2/projects/my-project/draft:write", body)
+	if code != http.StatusOK {
+		t.Fatalf("draft:write returned %v %s, want %v", code, b, http.StatusOK)
+	}
+	// Files which aren't sent again are removed from the draft.
+	changed := map[string][]byte{
+		"settings/settings.yaml": testConfigFiles["settings/settings.yaml"],
+		"manifest.yaml":          testConfigFiles["manifest.yaml"],
+		"custom/intents/a.yaml":  []byte("trainingPhrases:\n- a\n"),
+	}
+	body = stream(t, changed, nil, func() map[string]interface{} { return request.WriteDraft("my-project", false) }, 1000)
+	if code, b := post(t, ts.URL+"/v2/projects/my-project/draft:write", body); code != http.StatusOK {
+		t.Fatalf("draft:write returned %v %s, want %v", code, b, http.StatusOK)
+	}
+	// Files which are only validated aren't written to the draft.
+	changed["custom/intents/b.yaml"] = []byte("trainingPhrases:\n- b\n")
+	body = stream(t, changed, nil, func() map[string]interface{} { return request.WriteDraft("my-project", true) }, 1000)
+	if code, b := post(t, ts.URL+"/v2/projects/my-project/draft:write", body); code != http.StatusOK {
+		t.Fatalf("draft:write with validateOnly returned %v %s, want %v", code, b, http.StatusOK)
+	}
+
+	req, err := json.Marshal(request.ReadDraft("my-project", ""))
+	if err != nil {
+		t.Fatalf("json.Marshal returned %v", err)
+	}
+	code, b = post(t, ts.URL+"/v2/projects/my-project/draft:read", req)
+	if code != http.StatusOK {
+		t.Fatalf("draft:read returned %v %s, want %v", code, b, http.StatusOK)
+	}
+	want := []string{"custom/intents/a.yaml", "manifest.yaml", "settings/settings.yaml"}
+	if diff := cmp.Diff(want, filesIn(t, b)); diff != "" {
+		t.Errorf("draft:read returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	s := newServer()
+	s.now = func() time.Time { return time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC) }
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	for _, ch := range []string{"actions.channels.Alpha", "actions.channels.Production"} {
+		channel := ch
+		body := stream(t, testConfigFiles, nil, func() map[string]interface{} { return request.CreateVersion("my-project", channel) }, 1000)
+		if code, b := post(t, ts.URL+"/v2/projects/my-project/versions:create", body); code != http.StatusOK {
+			t.Fatalf("versions:create returned %v %s, want %v", code, b, http.StatusOK)
+		}
+	}
+
+	code, b := post(t, ts.URL+"/v2/projects/my-project/versions/2:read", []byte("{}"))
+	if code != http.StatusOK {
+		t.Fatalf("versions/2:read returned %v %s, want %v", code, b, http.StatusOK)
+	}
+	if diff := cmp.Diff([]string{"custom/scenes/Main.yaml", "manifest.yaml", "settings/settings.yaml"}, filesIn(t, b)); diff != "" {
+		t.Errorf("versions/2:read returned diff (-want, +got)\n%s", diff)
+	}
+	if code, b := post(t, ts.URL+"/v2/projects/my-project/versions/3:read", []byte("{}")); code != http.StatusNotFound {
+		t.Errorf("versions/3:read returned %v %s, want %v", code, b, http.StatusNotFound)
+	}
+
+	resp, err := http.Get(ts.URL + "/v2/projects/my-project/releaseChannels?pageToken=")
+	if err != nil {
+		t.Fatalf("http.Get returned %v", err)
+	}
+	defer resp.Body.Close()
+	var got struct {
+		ReleaseChannels []struct {
+			Name           string `json:"name"`
+			CurrentVersion string `json:"currentVersion"`
+		} `json:"releaseChannels"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("Decode returned %v", err)
+	}
+	want := map[string]string{
+		"projects/my-project/releaseChannels/actions.channels.Production": "projects/my-project/versions/2",
+		"projects/my-project/releaseChannels/actions.channels.ClosedBeta": "",
+		"projects/my-project/releaseChannels/actions.channels.Alpha":      "projects/my-project/versions/1",
+	}
+	gotChannels := map[string]string{}
+	for _, v := range got.ReleaseChannels {
+		gotChannels[v.Name] = v.CurrentVersion
+	}
+	if diff := cmp.Diff(want, gotChannels); diff != "" {
+		t.Errorf("releaseChannels returned diff (-want, +got)\n%s", diff)
+	}
+
+	resp, err = http.Get(ts.URL + "/v2/projects?pageToken=")
+	if err != nil {
+		t.Fatalf("http.Get returned %v", err)
+	}
+	defer resp.Body.Close()
+	var gotProjects struct {
+		Projects []map[string]string `json:"projects"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&gotProjects); err != nil {
+		t.Fatalf("Decode returned %v", err)
+	}
+	wantProjects := []map[string]string{{
+		"name":           "projects/my-project",
+		"projectId":      "my-project",
+		"displayName":    "",
+		"lastDeployTime": "2021-03-01T10:00:00.000000Z",
+	}}
+	if diff := cmp.Diff(wantProjects, gotProjects.Projects); diff != "" {
+		t.Errorf("projects returned diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	ts := httptest.NewServer(newServer())
+	defer ts.Close()
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "not an array",
+			body:    `{"parent": "projects/my-project"}`,
+			wantErr: "expected [",
+		},
+		{
+			name:    "empty stream",
+			body:    `[]`,
+			wantErr: "stream has no requests",
+		},
+		{
+			name:    "manifest missing in the first request",
+			body:    `[{"files": {"configFiles": {"configFiles": [{"filePath": "settings/settings.yaml", "settings": {}}]}}}]`,
+			wantErr: "manifest.yaml must be in the first request",
+		},
+	}
+	for _, tc := range tests {
+		code, b := post(t, ts.URL+"/v2/projects/my-project/preview:write", []byte(tc.body))
+		if code != http.StatusBadRequest {
+			t.Errorf("preview:write with %v returned %v, want %v", tc.name, code, http.StatusBadRequest)
+		}
+		if !strings.Contains(string(b), tc.wantErr) {
+			t.Errorf("preview:write with %v returned %s, want an error containing %q", tc.name, b, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of list commands.
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+	YAMLFormat = "yaml"
+)
+
+// Formats are the supported output formats.
+var Formats = []string{TextFormat, JSONFormat, YAMLFormat}
+
+// Format is the output format of list commands, set by the --format flag.
+var Format = TextFormat
+
+// Render writes v to w in the current Format. The text format is written by
+// text, so commands keep their human readable tables; json and yaml marshal v,
+// which should tag its fields for both.
+func Render(w io.Writer, v interface{}, text func(w io.Writer) error) error {
+	switch Format {
+	case TextFormat:
+		return text(w)
+	case JSONFormat:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case YAMLFormat:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q", Format)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	type item struct {
+		Name    string `json:"name" yaml:"name"`
+		Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	}
+	v := []item{{Name: "prod", Version: "2"}, {Name: "beta"}}
+	text := func(w io.Writer) error {
+		_, err := io.WriteString(w, "prod\t2\nbeta\t\n")
+		return err
+	}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: TextFormat,
+			want:   "prod\t2\nbeta\t\n",
+		},
+		{
+			format: JSONFormat,
+			want: `[
+  {
+    "name": "prod",
+    "version": "2"
+  },
+  {
+    "name": "beta"
+  }
+]
+`,
+		},
+		{
+			format: YAMLFormat,
+			want: `- name: prod
+  version: "2"
+- name: beta
+`,
+		},
+	}
+	og := Format
+	defer func() { Format = og }()
+	for _, tc := range tests {
+		Format = tc.format
+		var b bytes.Buffer
+		if err := Render(&b, v, text); err != nil {
+			t.Errorf("Render returned %v in %v format, want %v", err, tc.format, nil)
+		}
+		if got := b.String(); got != tc.want {
+			t.Errorf("Render wrote %q in %v format, want %q", got, tc.format, tc.want)
+		}
+	}
+}
+
+func TestRenderUnsupportedFormat(t *testing.T) {
+	og := Format
+	defer func() { Format = og }()
+	Format = "xml"
+	if err := Render(ioutil.Discard, nil, func(io.Writer) error { return nil }); err == nil {
+		t.Errorf("Render returned %v in xml format, want an error", err)
+	}
+}
@@ -17,9 +17,11 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/fatih/color"
 )
@@ -50,8 +52,71 @@ var (
 	ErrorLogger = log.New(os.Stderr, colorMaybe("[ERROR] ", color.RedString), 0)
 	// Severity can be set to restrict level of log messages.
 	Severity = WarnLevel
+
+	// outputMu guards the writers below.
+	outputMu sync.Mutex
+	// stdout and stderr are the writers set by SetOutput.
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+	// fileLogger writes all messages to the file set by SetLogFile, or is nil.
+	fileLogger *log.Logger
 )
 
+// SetOutput sets the writers of the loggers: output, info and debug messages are written to
+// stdout, and warnings and errors to stderr. Progress bars are drawn on stderr, if it's a
+// terminal. By default, they are os.Stdout and os.Stderr.
+func SetOutput(out, err io.Writer) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	stdout, stderr = out, err
+	setWriters()
+}
+
+// Output returns the writers set by SetOutput.
+func Output() (out, err io.Writer) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return stdout, stderr
+}
+
+// SetLogFile tees all messages to w, including the info and debug messages hidden by Severity.
+// Messages are written without colors, and with their time and level, except the output of
+// commands. A nil w stops the tee.
+func SetLogFile(w io.Writer) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	fileLogger = nil
+	if w != nil {
+		fileLogger = log.New(w, "", log.Ldate|log.Ltime|log.Lmicroseconds)
+	}
+	setWriters()
+}
+
+// setWriters sets the writers of the loggers. outputMu must be held.
+func setWriters() {
+	out := stdout
+	if fileLogger != nil {
+		// The output is teed as is, because commands write tables and other output
+		// directly to the writer of OutLogger.
+		out = io.MultiWriter(stdout, fileLogger.Writer())
+	}
+	OutLogger.SetOutput(out)
+	InfoLogger.SetOutput(stdout)
+	DebugLogger.SetOutput(stdout)
+	WarnLogger.SetOutput(stderr)
+	ErrorLogger.SetOutput(stderr)
+	ProgressOutput = stderr
+}
+
+// toFile writes msg to the log file, if one is set, with the prefix of its level.
+func toFile(level, msg string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if fileLogger != nil {
+		fileLogger.Output(3, level+msg)
+	}
+}
+
 func colorMaybe(s string, f func(format string, a ...interface{}) string) string {
 	if runtime.GOOS == "windows" {
 		return s
@@ -72,89 +137,114 @@ func DoneMsgln(msg string) {
 // Debugf calls Output to print to the DebugLogger.
 // Arguments are handled in the manner of fmt.Printf.
 func Debugf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	toFile("[DEBUG] ", msg)
 	if Severity > DebugLevel {
 		return
 	}
-	DebugLogger.Output(2, fmt.Sprintf(format, v...))
+	DebugLogger.Output(2, msg)
 }
 
 // Debugln calls Output to print to the DebugLogger.
 // Arguments are handled in the manner of fmt.Println.
 func Debugln(v ...interface{}) {
+	msg := fmt.Sprintln(v...)
+	toFile("[DEBUG] ", msg)
 	if Severity > DebugLevel {
 		return
 	}
-	DebugLogger.Output(2, fmt.Sprintln(v...))
+	DebugLogger.Output(2, msg)
 }
 
 // Out calls Output to print to the OutLogger.
 // Arguments are handled in the manner of fmt.Print.
 func Out(v ...interface{}) {
+	clearProgress()
 	OutLogger.Output(2, fmt.Sprint(v...))
 }
 
 // Outf calls Output to print to the OutLogger.
 // Arguments are handled in the manner of fmt.Printf.
 func Outf(format string, v ...interface{}) {
+	clearProgress()
 	OutLogger.Output(2, fmt.Sprintf(format, v...))
 }
 
 // Outln calls Output to print to the OutLogger.
 // Arguments are handled in the manner of fmt.Println.
 func Outln(v ...interface{}) {
+	clearProgress()
 	OutLogger.Output(2, fmt.Sprintln(v...))
 }
 
 // Infoln calls Output to print to the InfoLogger.
 // Arguments are handled in the manner of fmt.Println.
 func Infoln(v ...interface{}) {
+	msg := fmt.Sprintln(v...)
+	toFile("[INFO] ", msg)
 	if Severity > InfoLevel {
 		return
 	}
-	InfoLogger.Output(2, fmt.Sprintln(v...))
+	clearProgress()
+	InfoLogger.Output(2, msg)
 }
 
 // Infof calls Output to print to the InfoLogger.
 // Arguments are handled in the manner of fmt.Printf.
 func Infof(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	toFile("[INFO] ", msg)
 	if Severity > InfoLevel {
 		return
 	}
-	InfoLogger.Output(2, fmt.Sprintf(format, v...))
+	clearProgress()
+	InfoLogger.Output(2, msg)
 }
 
 // Error calls Output to print to the ErrorLogger.
 // Arguments are handled in the manner of fmt.Print.
 func Error(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	toFile("[ERROR] ", msg)
 	if Severity > ErrorLevel {
 		return
 	}
-	ErrorLogger.Output(2, fmt.Sprint(v...))
+	clearProgress()
+	ErrorLogger.Output(2, msg)
 }
 
 // Errorf calls Output to print to the ErrorLogger.
 // Arguments are handled in the manner of fmt.Printf.
 func Errorf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	toFile("[ERROR] ", msg)
 	if Severity > ErrorLevel {
 		return
 	}
-	ErrorLogger.Output(2, fmt.Sprintf(format, v...))
+	clearProgress()
+	ErrorLogger.Output(2, msg)
 }
 
 // Warnf calls Output to print to the WarnLogger.
 // Arguments are handled in the manner of fmt.Printf.
 func Warnf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	toFile("[WARNING] ", msg)
 	if Severity > WarnLevel {
 		return
 	}
-	WarnLogger.Output(2, fmt.Sprintf(format, v...))
+	clearProgress()
+	WarnLogger.Output(2, msg)
 }
 
 // Warnln calls Output to print to the WarnLogger.
 // Arguments are handled in the manner of fmt.Println.
 func Warnln(v ...interface{}) {
+	msg := fmt.Sprintln(v...)
+	toFile("[WARNING] ", msg)
 	if Severity > WarnLevel {
 		return
 	}
-	WarnLogger.Output(2, fmt.Sprintln(v...))
+	clearProgress()
+	WarnLogger.Output(2, msg)
 }
@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func restoreOutput(t *testing.T) {
+	oldSeverity := Severity
+	t.Cleanup(func() {
+		SetLogFile(nil)
+		SetOutput(os.Stdout, os.Stderr)
+		Severity = oldSeverity
+	})
+}
+
+func TestSetOutput(t *testing.T) {
+	restoreOutput(t)
+	var out, errOut bytes.Buffer
+	SetOutput(&out, &errOut)
+	Severity = InfoLevel
+	Outln("output")
+	Infof("info\n")
+	Warnf("warning\n")
+	Errorf("error\n")
+	Debugf("debug\n")
+
+	if got := out.String(); !strings.Contains(got, "output\n") || !strings.Contains(got, "info\n") || strings.Contains(got, "warning") {
+		t.Errorf("SetOutput(out, err) wrote %q to out, want output and info messages", got)
+	}
+	if got := errOut.String(); !strings.Contains(got, "warning\n") || !strings.Contains(got, "error\n") || strings.Contains(got, "output") {
+		t.Errorf("SetOutput(out, err) wrote %q to err, want warnings and errors", got)
+	}
+	if strings.Contains(out.String()+errOut.String(), "debug") {
+		t.Errorf("SetOutput(out, err) wrote a debug message hidden by the severity")
+	}
+	if gotOut, gotErr := Output(); gotOut != &out || gotErr != &errOut {
+		t.Errorf("Output() returned (%p, %p), want (%p, %p)", gotOut, gotErr, &out, &errOut)
+	}
+	if ProgressOutput != &errOut {
+		t.Errorf("SetOutput(out, err) set ProgressOutput to %p, want %p", ProgressOutput, &errOut)
+	}
+}
+
+func TestSetLogFile(t *testing.T) {
+	restoreOutput(t)
+	var out, errOut, file bytes.Buffer
+	SetOutput(&out, &errOut)
+	SetLogFile(&file)
+	Severity = WarnLevel
+	Outf("output\n")
+	OutLogger.Writer().Write([]byte("table\n"))
+	Debugf("debug\n")
+	Infof("info\n")
+	Warnf("warning\n")
+	Error("error")
+	SetLogFile(nil)
+	Outln("after")
+
+	stamp := `\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}\.\d{6} `
+	want := regexp.MustCompile(`^output\ntable\n` +
+		stamp + `\[DEBUG\] debug\n` +
+		stamp + `\[INFO\] info\n` +
+		stamp + `\[WARNING\] warning\n` +
+		stamp + `\[ERROR\] error\n$`)
+	if !want.Match(file.Bytes()) {
+		t.Errorf("SetLogFile wrote %q, want a match of %v", file.String(), want)
+	}
+	if got, want := out.String(), "output\ntable\nafter\n"; got != want {
+		t.Errorf("SetLogFile changed the output to %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// progressWidth is the number of characters between the brackets of a progress bar.
+	progressWidth = 30
+	// progressInterval is the shortest time between redraws of a progress bar.
+	progressInterval = 100 * time.Millisecond
+)
+
+var (
+	// Quiet hides progress bars. It is set by the --quiet flag.
+	Quiet = false
+	// ProgressOutput is where progress bars are drawn. They are only drawn if it's a terminal,
+	// so they don't end up in redirected output and CI logs. It's set by SetOutput.
+	ProgressOutput io.Writer = os.Stderr
+
+	// isTerminal is replaced in tests.
+	isTerminal = func(w io.Writer) bool {
+		f, ok := w.(*os.File)
+		if !ok {
+			return false
+		}
+		fi, err := f.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0
+	}
+
+	// progressMu guards activeProgress and the state of all progress bars, which are
+	// updated from several goroutines.
+	progressMu     sync.Mutex
+	activeProgress *Progress
+)
+
+// Progress is a progress bar of a transfer of files, showing the bytes and the files
+// transferred so far. The bar stays on the last line of the terminal: messages logged
+// while it's drawn clear it, and the next update draws it again.
+type Progress struct {
+	w          io.Writer
+	label      string
+	totalBytes int64
+	totalFiles int
+	bytes      int64
+	files      int
+	drawn      time.Time
+	// width is the length of the line drawn last, which is overwritten by spaces to clear it.
+	width int
+}
+
+// NewProgress returns a progress bar labeled label for a transfer of totalBytes in totalFiles.
+// If the totals aren't known in advance, they are 0 and only the counts are shown. If Quiet is
+// set or ProgressOutput isn't a terminal, the bar draws nothing.
+func NewProgress(label string, totalBytes int64, totalFiles int) *Progress {
+	p := &Progress{label: label, totalBytes: totalBytes, totalFiles: totalFiles}
+	if Quiet || !isTerminal(ProgressOutput) {
+		return p
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeProgress != nil {
+		activeProgress.clear()
+	}
+	p.w = ProgressOutput
+	activeProgress = p
+	return p
+}
+
+// Add records bytes and files more transferred, and redraws the bar.
+func (p *Progress) Add(bytes int64, files int) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p.bytes += bytes
+	p.files += files
+	if p.w == nil || time.Since(p.drawn) < progressInterval {
+		return
+	}
+	line := p.line()
+	pad := ""
+	if n := p.width - len(line); n > 0 {
+		pad = strings.Repeat(" ", n)
+	}
+	fmt.Fprintf(p.w, "\r%s%s", line, pad)
+	p.width = len(line)
+	p.drawn = time.Now()
+}
+
+// Done removes the bar from the terminal. The bar draws nothing after Done.
+func (p *Progress) Done() {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p.clear()
+	if activeProgress == p {
+		activeProgress = nil
+	}
+	p.w = nil
+}
+
+// clear overwrites the bar with spaces. progressMu must be held.
+func (p *Progress) clear() {
+	if p.w == nil || p.width == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", p.width))
+	p.width = 0
+	// Draw the bar again on the next update.
+	p.drawn = time.Time{}
+}
+
+// line returns the text of the bar.
+func (p *Progress) line() string {
+	if p.totalBytes <= 0 {
+		return fmt.Sprintf("%s %s, %d files", p.label, FormatBytes(p.bytes), p.files)
+	}
+	// Requests have some overhead over the size of the files, so the count can exceed the total.
+	frac := float64(p.bytes) / float64(p.totalBytes)
+	if frac > 1 {
+		frac = 1
+	}
+	filled := int(frac * progressWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+	return fmt.Sprintf("%s [%s] %3d%% %s/%s, %d/%d files", p.label, bar, int(frac*100), FormatBytes(p.bytes), FormatBytes(p.totalBytes), p.files, p.totalFiles)
+}
+
+// clearProgress removes the active progress bar before a message is logged.
+func clearProgress() {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeProgress != nil {
+		activeProgress.clear()
+	}
+}
+
+// FormatBytes returns n in the largest binary unit in which it's at least 1, e.g. 1.5 MiB.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	stdlog "log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProgressLine(t *testing.T) {
+	tests := []struct {
+		p    Progress
+		want string
+	}{
+		{
+			p:    Progress{label: "Sending", totalBytes: 4 * 1024 * 1024, totalFiles: 10, bytes: 1024 * 1024, files: 3},
+			want: "Sending [=======                       ]  25% 1.0 MiB/4.0 MiB, 3/10 files",
+		},
+		{
+			// Overhead of the requests exceeds the total.
+			p:    Progress{label: "Sending", totalBytes: 100, totalFiles: 1, bytes: 120, files: 1},
+			want: "Sending [==============================] 100% 120 B/100 B, 1/1 files",
+		},
+		{
+			p:    Progress{label: "Receiving", bytes: 1536, files: 2},
+			want: "Receiving 1.5 KiB, 2 files",
+		},
+	}
+	for _, tc := range tests {
+		if got := tc.p.line(); got != tc.want {
+			t.Errorf("line returned %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestProgressOutput(t *testing.T) {
+	f, err := ioutil.TempFile("", "progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	oldOutput, oldTerminal, oldLogger := ProgressOutput, isTerminal, OutLogger
+	defer func() { ProgressOutput, isTerminal, OutLogger = oldOutput, oldTerminal, oldLogger }()
+	ProgressOutput = f
+	isTerminal = func(io.Writer) bool { return true }
+	var out bytes.Buffer
+	OutLogger = stdlog.New(&out, "", 0)
+
+	p := NewProgress("Receiving", 0, 0)
+	p.Add(10, 1)
+	Outln("message")
+	p.Add(10, 1)
+	p.Done()
+	p.Add(10, 1)
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := "Receiving 10 B, 1 files"
+	blank := "\r" + strings.Repeat(" ", len(line)) + "\r"
+	// The bar is cleared by the message, drawn again by the next update, and cleared by Done.
+	want := "\r" + line + blank + "\rReceiving 20 B, 2 files" + blank
+	if string(b) != want {
+		t.Errorf("progress bar wrote %q, want %q", b, want)
+	}
+	if out.String() != "message\n" {
+		t.Errorf("Outln wrote %q, want %q", out.String(), "message\n")
+	}
+}
+
+func TestProgressQuiet(t *testing.T) {
+	oldQuiet, oldTerminal := Quiet, isTerminal
+	defer func() { Quiet, isTerminal = oldQuiet, oldTerminal }()
+	Quiet = true
+	isTerminal = func(io.Writer) bool { return true }
+	p := NewProgress("Sending", 100, 1)
+	if p.w != nil {
+		t.Errorf("NewProgress returned a bar drawing to %v with Quiet set, want a bar drawing nothing", p.w)
+	}
+	p.Add(100, 1)
+	p.Done()
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InGitHubActions returns true if the CLI runs in a GitHub Actions workflow.
+func InGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Annotate prints a workflow command, which GitHub Actions shows as an annotation
+// of the file in a pull request. Level is "error", "warning" or "notice", and file
+// may be empty. It does nothing outside of GitHub Actions.
+func Annotate(level, file, msg string) {
+	if !InGitHubActions() {
+		return
+	}
+	props := ""
+	if file != "" {
+		props = " file=" + escapeProperty(workspacePath(file))
+	}
+	OutLogger.Printf("::%s%s::%s\n", level, props, escapeData(msg))
+}
+
+// SetStepOutput sets an output of the current step of a GitHub Actions workflow, so later
+// steps can use it. It does nothing outside of GitHub Actions.
+func SetStepOutput(name, value string) error {
+	if !InGitHubActions() {
+		return nil
+	}
+	fn := os.Getenv("GITHUB_OUTPUT")
+	if fn == "" {
+		// Older runners only support the set-output command.
+		OutLogger.Printf("::set-output name=%s::%s\n", escapeProperty(name), escapeData(value))
+		return nil
+	}
+	f, err := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s=%s\n", name, value); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// workspacePath returns path of file relative to the checked out repository, which
+// is how GitHub identifies files of annotations.
+func workspacePath(file string) string {
+	ws := os.Getenv("GITHUB_WORKSPACE")
+	if ws == "" {
+		return filepath.ToSlash(file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(ws, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
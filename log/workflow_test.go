@@ -0,0 +1,88 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	ws, err := ioutil.TempDir("", "gactions-workspace")
+	if err != nil {
+		t.Fatalf("Can't create temp directory: %v", err)
+	}
+	defer os.RemoveAll(ws)
+	old := OutLogger
+	defer func() { OutLogger = old }()
+	var b bytes.Buffer
+	OutLogger = log.New(&b, "", 0)
+
+	setenv(t, "GITHUB_ACTIONS", "")
+	Annotate("warning", "", "not in a workflow")
+	if b.Len() != 0 {
+		t.Errorf("Annotate printed %q outside of GitHub Actions, want nothing", b.String())
+	}
+
+	setenv(t, "GITHUB_ACTIONS", "true")
+	setenv(t, "GITHUB_WORKSPACE", ws)
+	Annotate("warning", filepath.Join(ws, "sdk", "custom", "scenes", "a,b.yaml"), "100% wrong\nsecond line")
+	Annotate("error", "", "failed: push")
+	want := "::warning file=sdk/custom/scenes/a%2Cb.yaml::100%25 wrong%0Asecond line\n::error::failed: push\n"
+	if got := b.String(); got != want {
+		t.Errorf("Annotate printed %q, want %q", got, want)
+	}
+}
+
+func TestSetStepOutput(t *testing.T) {
+	f, err := ioutil.TempFile("", "gactions-output")
+	if err != nil {
+		t.Fatalf("Can't create temp file: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	setenv(t, "GITHUB_ACTIONS", "true")
+	setenv(t, "GITHUB_OUTPUT", f.Name())
+	if err := SetStepOutput("version-id", "3"); err != nil {
+		t.Errorf("SetStepOutput returned %v, want %v", err, nil)
+	}
+	if err := SetStepOutput("simulator-url", "https://console.actions.google.com/project/foo/simulator"); err != nil {
+		t.Errorf("SetStepOutput returned %v, want %v", err, nil)
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("Can't read %v: %v", f.Name(), err)
+	}
+	want := "version-id=3\nsimulator-url=https://console.actions.google.com/project/foo/simulator\n"
+	if got := string(b); got != want {
+		t.Errorf("SetStepOutput wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/actions-on-google/gactions/project"
+)
+
+// Archive is a project read from an archive stream, such as stdin, instead of a directory. It
+// has no project root, so nothing is written to disk for it.
+type Archive struct {
+	files            map[string][]byte
+	clientSecretJSON []byte
+	projectID        string
+}
+
+// ArchiveFormats are the formats of archives read by NewArchive.
+var ArchiveFormats = []string{"tar", "tgz"}
+
+// NewArchive reads the files of a project from the archive r in format, which is one of
+// ArchiveFormats. Paths in the archive are relative to the project root. Like for a project
+// directory, hidden files and the canvas directory are skipped. env selects the account linking
+// secret of an environment, as for Studio.SetEnv.
+func NewArchive(r io.Reader, format string, secret []byte, env string) (Archive, error) {
+	switch format {
+	case "tar":
+	case "tgz":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return Archive{}, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return Archive{}, fmt.Errorf("unsupported archive format %q: use %v", format, strings.Join(ArchiveFormats, " or "))
+	}
+	files, err := readTar(r)
+	if err != nil {
+		return Archive{}, err
+	}
+	if len(files) == 0 {
+		return Archive{}, errors.New("archive has no project files")
+	}
+	if err := selectEnvSecret(files, env); err != nil {
+		return Archive{}, err
+	}
+	return Archive{files: files, clientSecretJSON: secret}, nil
+}
+
+func readTar(r io.Reader) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(h.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("archive has a file outside of the project: %v", h.Name)
+		}
+		if isHidden(name) || inSkippedDir(name) || name == CanvasDir || strings.HasPrefix(name, CanvasDir+"/") {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = b
+	}
+}
+
+// Download isn't supported, because an archive isn't a directory.
+func (a Archive) Download(sample project.SampleProject, dest string) error {
+	return errors.New("can not download a sample into an archive")
+}
+
+// AlreadySetup returns false, because an archive isn't a directory.
+func (a Archive) AlreadySetup(pathToWorkDir string) bool {
+	return false
+}
+
+// Files returns the files read from the archive.
+func (a Archive) Files() (map[string][]byte, error) {
+	return a.files, nil
+}
+
+// FileRefs returns the files read from the archive, which are held in memory.
+func (a Archive) FileRefs() (map[string]project.File, error) {
+	return project.InMemoryFiles(a.files), nil
+}
+
+// ClientSecretJSON returns a client secret used to communicate with an external API.
+func (a Archive) ClientSecretJSON() ([]byte, error) {
+	return a.clientSecretJSON, nil
+}
+
+// ProjectRoot returns an empty string, because the archive isn't on disk.
+func (a Archive) ProjectRoot() string {
+	return ""
+}
+
+// ProjectID returns a Google Project ID associated with developer's Action, which should be safe to insert into the URL.
+func (a Archive) ProjectID() string {
+	return url.PathEscape(a.projectID)
+}
+
+// SetProjectID sets the project ID of the archive to flag, or to the projectId in its settings
+// if flag is empty.
+func (a *Archive) SetProjectID(flag string) error {
+	if a.projectID != "" {
+		return errors.New("can not reset the project ID")
+	}
+	if flag != "" {
+		a.projectID = flag
+		return nil
+	}
+	pid, err := ProjectID(a)
+	if err != nil {
+		return err
+	}
+	if pid == "" {
+		return errors.New("no project ID is specified: projectId is missing in settings/settings.yaml of the archive")
+	}
+	a.projectID = pid
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeTar(t *testing.T, w io.Writer, files map[string]string) {
+	t.Helper()
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0750}); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: k, Typeflag: tar.TypeReg, Mode: 0640, Size: int64(len(v))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewArchive(t *testing.T) {
+	in := map[string]string{
+		"./manifest.yaml":                            "version: \"1.0\"\n",
+		"./settings/settings.yaml":                   "projectId: my-project\n",
+		"settings/accountLinkingSecret.yaml":         "encryptedClientSecret: default\n",
+		"settings/accountLinkingSecret.staging.yaml": "encryptedClientSecret: staging\n",
+		"./.gactions/push.json":                      "{}",
+		"canvas/index.html":                          "<html></html>",
+		"webhooks/hello/node_modules/a/index.js":     "module.exports = {};",
+	}
+	want := map[string][]byte{
+		"manifest.yaml":                              []byte("version: \"1.0\"\n"),
+		"settings/settings.yaml":                     []byte("projectId: my-project\n"),
+		"settings/accountLinkingSecret.yaml":         []byte("encryptedClientSecret: staging\n"),
+		"settings/accountLinkingSecret.staging.yaml": []byte("encryptedClientSecret: staging\n"),
+	}
+	for _, format := range ArchiveFormats {
+		t.Run(format, func(t *testing.T) {
+			var b bytes.Buffer
+			if format == "tgz" {
+				zw := gzip.NewWriter(&b)
+				writeTar(t, zw, in)
+				if err := zw.Close(); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				writeTar(t, &b, in)
+			}
+			a, err := NewArchive(&b, format, []byte("secret"), "staging")
+			if err != nil {
+				t.Fatalf("NewArchive returned %v, want %v", err, nil)
+			}
+			got, err := a.Files()
+			if err != nil {
+				t.Fatalf("Files returned %v, want %v", err, nil)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Files returned diff (-want, +got)\n%s", diff)
+			}
+			if a.ProjectRoot() != "" {
+				t.Errorf("ProjectRoot returned %q, want %q", a.ProjectRoot(), "")
+			}
+			if err := (&a).SetProjectID(""); err != nil {
+				t.Fatalf("SetProjectID returned %v, want %v", err, nil)
+			}
+			if a.ProjectID() != "my-project" {
+				t.Errorf("ProjectID returned %q, want %q", a.ProjectID(), "my-project")
+			}
+		})
+	}
+}
+
+func TestNewArchiveErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		format string
+	}{
+		{name: "outside of project", files: map[string]string{"../manifest.yaml": "version: \"1.0\"\n"}, format: "tar"},
+		{name: "absolute path", files: map[string]string{"/etc/passwd": "root"}, format: "tar"},
+		{name: "empty", files: map[string]string{".hidden": ""}, format: "tar"},
+		{name: "unsupported format", files: map[string]string{"manifest.yaml": ""}, format: "zip"},
+		{name: "not gzip", files: map[string]string{"manifest.yaml": ""}, format: "tgz"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			writeTar(t, &b, tc.files)
+			if _, err := NewArchive(&b, tc.format, nil, ""); err == nil {
+				t.Errorf("NewArchive returned nil, want an error")
+			}
+		})
+	}
+}
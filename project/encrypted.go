@@ -0,0 +1,100 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// EncryptedTag marks an encrypted scalar value in a config file, e.g. "apiKey: !encrypted CiQA...".
+// The values are encrypted with the same key as the account linking secret, and are decrypted
+// when the files are pushed, so the plain text is never kept in the project.
+const EncryptedTag = "!encrypted"
+
+// encryptedValueRegExp matches a tagged value after a key, a list item or a flow collection
+// delimiter, so a tag mentioned in a comment isn't matched.
+var encryptedValueRegExp = regexp.MustCompile(`(?m)((?:^|[:\-\[{,])[ \t]*)` + EncryptedTag + `[ \t]+("[^"\n]*"|'[^'\n]*'|[^\s#,\]}]+)`)
+
+// EncryptedValue returns ciphertext as an encrypted value to put in a config file.
+func EncryptedValue(ciphertext string) string {
+	return EncryptedTag + " " + ciphertext
+}
+
+func unquote(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		var v string
+		err := json.Unmarshal([]byte(s), &v)
+		return v, err
+	case strings.HasPrefix(s, "'"):
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return s, nil
+}
+
+// DecryptValues returns files with the encrypted values of the YAML files replaced with their
+// plain text, decrypted by decrypt, and the number of decrypted values. Each distinct ciphertext
+// is decrypted once. If no values are encrypted, files is returned as is.
+func DecryptValues(files map[string][]byte, decrypt func(ciphertext string) (string, error)) (map[string][]byte, int, error) {
+	res := files
+	copied := false
+	n := 0
+	plain := map[string]string{}
+	for k, v := range files {
+		if path.Ext(k) != ".yaml" || !strings.Contains(string(v), EncryptedTag) {
+			continue
+		}
+		var err error
+		b := encryptedValueRegExp.ReplaceAllFunc(v, func(m []byte) []byte {
+			if err != nil {
+				return m
+			}
+			sub := encryptedValueRegExp.FindSubmatch(m)
+			var c string
+			if c, err = unquote(string(sub[2])); err != nil {
+				err = fmt.Errorf("%v has an incorrect encrypted value %s: %v", k, sub[2], err)
+				return m
+			}
+			p, ok := plain[c]
+			if !ok {
+				if p, err = decrypt(c); err != nil {
+					err = fmt.Errorf("can't decrypt a value in %v: %v", k, err)
+					return m
+				}
+				plain[c] = p
+			}
+			n++
+			// A JSON string is a double-quoted YAML scalar.
+			q, _ := json.Marshal(p)
+			return append(append([]byte{}, sub[1]...), q...)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if !copied {
+			res = map[string][]byte{}
+			for k2, v2 := range files {
+				res[k2] = v2
+			}
+			copied = true
+		}
+		res[k] = b
+	}
+	return res, n, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecryptValues(t *testing.T) {
+	files := map[string][]byte{
+		"webhooks/ActionsOnGoogleFulfillment.yaml": []byte("httpsEndpoint:\n  baseUrl: https://example.com\n  # Use !encrypted values for keys.\n  apiKey: !encrypted Q2lRQQ==\n  keys: [!encrypted 'Q2lRQg==', plain]\n  other:\n  - !encrypted \"Q2lRQQ==\" # same key\n"),
+		"settings/settings.yaml":                   []byte("projectId: my-project\n"),
+		"resources/images/!encrypted.png":          []byte("!encrypted abc"),
+	}
+	calls := 0
+	decrypt := func(c string) (string, error) {
+		calls++
+		switch c {
+		case "Q2lRQQ==":
+			return `se"cret`, nil
+		case "Q2lRQg==":
+			return "other", nil
+		}
+		return "", errors.New("unknown ciphertext")
+	}
+	got, n, err := DecryptValues(files, decrypt)
+	if err != nil {
+		t.Fatalf("DecryptValues returned %v, want %v", err, nil)
+	}
+	want := map[string][]byte{
+		"webhooks/ActionsOnGoogleFulfillment.yaml": []byte("httpsEndpoint:\n  baseUrl: https://example.com\n  # Use !encrypted values for keys.\n  apiKey: \"se\\\"cret\"\n  keys: [\"other\", plain]\n  other:\n  - \"se\\\"cret\" # same key\n"),
+		"settings/settings.yaml":                   []byte("projectId: my-project\n"),
+		"resources/images/!encrypted.png":          []byte("!encrypted abc"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DecryptValues returned diff (-want, +got)\n%s", diff)
+	}
+	if n != 3 || calls != 2 {
+		t.Errorf("DecryptValues decrypted %v values with %v calls, want 3 values with 2 calls", n, calls)
+	}
+	if !strings.Contains(string(files["webhooks/ActionsOnGoogleFulfillment.yaml"]), "!encrypted Q2lRQQ==") {
+		t.Errorf("DecryptValues changed its input")
+	}
+	if _, _, err := DecryptValues(map[string][]byte{"a.yaml": []byte("k: !encrypted bad\n")}, decrypt); err == nil {
+		t.Errorf("DecryptValues returned nil for an unknown ciphertext, want an error")
+	}
+}
+
+func TestDecryptValuesWithoutValues(t *testing.T) {
+	files := map[string][]byte{"settings/settings.yaml": []byte("projectId: my-project\n")}
+	got, n, err := DecryptValues(files, func(string) (string, error) {
+		return "", errors.New("unexpected call")
+	})
+	if err != nil || n != 0 {
+		t.Fatalf("DecryptValues returned %v values and %v, want 0 and %v", n, err, nil)
+	}
+	if diff := cmp.Diff(files, got); diff != "" {
+		t.Errorf("DecryptValues returned diff (-want, +got)\n%s", diff)
+	}
+}
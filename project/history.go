@@ -0,0 +1,222 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/actions-on-google/gactions/project"
+	"gopkg.in/yaml.v2"
+)
+
+const historyFilename = "history.jsonl"
+
+// HistoryEntry records a push, pull or deploy performed by the CLI.
+type HistoryEntry struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	ProjectID string    `json:"projectId"`
+	User      string    `json:"user"`
+	// Version is "draft", "preview" or an ID of a version.
+	Version string `json:"version"`
+	Channel string `json:"channel,omitempty"`
+	// Digests are the digests of the project files, as sent or received.
+	Digests map[string]string `json:"digests"`
+	// Changes are the files which changed since the previous entry of the project. It's nil for
+	// entries recorded before changes were tracked.
+	Changes *Changes `json:"changes,omitempty"`
+	// ReleaseNotes are the release notes of a created version, if any.
+	ReleaseNotes string `json:"releaseNotes,omitempty"`
+}
+
+// Changes is a set of files which were added, modified or removed.
+type Changes struct {
+	Added    []string `json:"added,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+}
+
+// Empty returns true if no files changed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// diffDigests returns the files which changed from prev to cur, which map file names to digests.
+func diffDigests(prev, cur map[string]string) Changes {
+	var c Changes
+	for k, v := range cur {
+		old, ok := prev[k]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, k)
+		case old != v:
+			c.Modified = append(c.Modified, k)
+		}
+	}
+	for k := range prev {
+		if _, ok := cur[k]; !ok {
+			c.Removed = append(c.Removed, k)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Modified)
+	sort.Strings(c.Removed)
+	return c
+}
+
+// SetChanges sets the changes of e since the latest entry of the same project in history. If
+// there is no such entry, all of the files of e are added.
+func (e *HistoryEntry) SetChanges(history []HistoryEntry) {
+	var prev map[string]string
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ProjectID == e.ProjectID {
+			prev = history[i].Digests
+			break
+		}
+	}
+	c := diffDigests(prev, e.Digests)
+	e.Changes = &c
+}
+
+// NewHistoryEntry returns a HistoryEntry for an operation on files of the project with projectID,
+// performed by the current user.
+func NewHistoryEntry(operation, projectID, version, channel string, files map[string][]byte) HistoryEntry {
+	return NewHistoryEntryDigests(operation, projectID, version, channel, NewPushState(projectID, files).Digests)
+}
+
+// NewHistoryEntryDigests is like NewHistoryEntry, but takes the digests of the files, as
+// returned by FileDigests.
+func NewHistoryEntryDigests(operation, projectID, version, channel string, digests map[string]string) HistoryEntry {
+	return HistoryEntry{
+		Time:      time.Now().UTC(),
+		Operation: operation,
+		ProjectID: projectID,
+		User:      currentUser(),
+		Version:   version,
+		Channel:   channel,
+		Digests:   digests,
+	}
+}
+
+// Digest returns a digest of all files of the entry, which is the same for entries with the
+// same file contents.
+func (e HistoryEntry) Digest() string {
+	var names []string
+	for k := range e.Digests {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b []byte
+	for _, k := range names {
+		b = append(b, fmt.Sprintf("%s %s\n", e.Digests[k], k)...)
+	}
+	return Digest(b)
+}
+
+func currentUser() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
+
+// AppendHistory appends e to the history stored under the project root.
+func AppendHistory(root string, e HistoryEntry) error {
+	dir := filepath.Join(root, StateDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, historyFilename), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadHistory reads the history stored under the project root, oldest entry first. It returns
+// no entries, without an error, if the history doesn't exist.
+func ReadHistory(root string) ([]HistoryEntry, error) {
+	f, err := os.Open(filepath.Join(root, StateDir, historyFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var res []HistoryEntry
+	s := bufio.NewScanner(f)
+	// Entries hold a digest per file, so lines can be long.
+	s.Buffer(nil, 16*1024*1024)
+	for n := 1; s.Scan(); n++ {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%v has incorrect syntax on line %d: %v", historyFilename, n, err)
+		}
+		res = append(res, e)
+	}
+	return res, s.Err()
+}
+
+// CLIConfigPath returns the path of the CLI config (.gactionsrc.yaml) in the current or any of
+// the parent directories, and an error if the CLI config doesn't exist.
+func CLIConfigPath() (string, error) {
+	dir, err := findFileUp(project.ConfigName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, project.ConfigName), nil
+}
+
+// ReadCLIConfig reads the CLI config (.gactionsrc.yaml) in the current or any of the parent
+// directories. It returns an empty config if the CLI config doesn't exist.
+func ReadCLIConfig() (project.CLIConfig, error) {
+	cfg := project.CLIConfig{}
+	dir, err := findFileUp(project.ConfigName)
+	if err != nil {
+		return cfg, nil
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, project.ConfigName))
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%v has incorrect syntax: %v", project.ConfigName, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/testutils"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAppendAndReadHistory(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	got, err := ReadHistory(dirName)
+	if err != nil || got != nil {
+		t.Errorf("ReadHistory returned (%v, %v) for a missing history, want (nil, nil)", got, err)
+	}
+	files := map[string][]byte{"manifest.yaml": []byte("version: 1.0")}
+	want := []HistoryEntry{
+		NewHistoryEntry("push", "hello-world", "draft", "", files),
+		NewHistoryEntry("deploy", "hello-world", "2", "prod", files),
+	}
+	for _, e := range want {
+		if err := AppendHistory(dirName, e); err != nil {
+			t.Fatalf("AppendHistory returned %v, want %v", err, nil)
+		}
+	}
+	got, err = ReadHistory(dirName)
+	if err != nil {
+		t.Fatalf("ReadHistory returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadHistory returned an incorrect history: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestHistoryEntryDigest(t *testing.T) {
+	a := NewHistoryEntry("push", "hello-world", "draft", "", map[string][]byte{
+		"manifest.yaml":          []byte("version: 1.0"),
+		"settings/settings.yaml": []byte("projectId: hello-world"),
+	})
+	b := NewHistoryEntry("pull", "hello-world", "draft", "", map[string][]byte{
+		"settings/settings.yaml": []byte("projectId: hello-world"),
+		"manifest.yaml":          []byte("version: 1.0"),
+	})
+	c := NewHistoryEntry("push", "hello-world", "draft", "", map[string][]byte{
+		"manifest.yaml": []byte("version: 1.0"),
+	})
+	if a.Digest() != b.Digest() {
+		t.Errorf("Digest returned %v and %v for the same files, want equal digests", a.Digest(), b.Digest())
+	}
+	if a.Digest() == c.Digest() {
+		t.Errorf("Digest returned %v for different files, want different digests", a.Digest())
+	}
+}
+
+func TestSetChanges(t *testing.T) {
+	history := []HistoryEntry{
+		NewHistoryEntry("push", "hello-world", "draft", "", map[string][]byte{
+			"manifest.yaml":         []byte("version: 1.0"),
+			"custom/intents/a.yaml": []byte("a"),
+			"custom/intents/b.yaml": []byte("b"),
+		}),
+		NewHistoryEntry("push", "other-project", "draft", "", map[string][]byte{
+			"manifest.yaml": []byte("version: 3.0"),
+		}),
+	}
+	e := NewHistoryEntry("pull", "hello-world", "draft", "", map[string][]byte{
+		"manifest.yaml":         []byte("version: 2.0"),
+		"custom/intents/a.yaml": []byte("a"),
+		"custom/intents/c.yaml": []byte("c"),
+	})
+	e.SetChanges(history)
+	want := &Changes{
+		Added:    []string{"custom/intents/c.yaml"},
+		Modified: []string{"manifest.yaml"},
+		Removed:  []string{"custom/intents/b.yaml"},
+	}
+	if diff := cmp.Diff(want, e.Changes); diff != "" {
+		t.Errorf("SetChanges returned diff (-want, +got)\n%s", diff)
+	}
+
+	// The first entry of a project adds all of its files.
+	e = NewHistoryEntry("push", "new-project", "draft", "", map[string][]byte{"manifest.yaml": []byte("version: 1.0")})
+	e.SetChanges(history)
+	if diff := cmp.Diff(&Changes{Added: []string{"manifest.yaml"}}, e.Changes); diff != "" {
+		t.Errorf("SetChanges without a previous entry returned diff (-want, +got)\n%s", diff)
+	}
+}
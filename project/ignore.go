@@ -0,0 +1,185 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile is a file in the project root, which lists patterns of files that aren't sent to
+// Actions Console, like build artifacts or node_modules. The syntax is the one of .gitignore.
+const IgnoreFile = ".gactionsignore"
+
+// ignoreRule is a pattern of IgnoreFile.
+type ignoreRule struct {
+	re *regexp.Regexp
+	// negate re-includes the paths matched by the pattern (i.e. !pattern).
+	negate bool
+	// dirOnly matches only directories (i.e. pattern/).
+	dirOnly bool
+}
+
+// ignoreList is the list of the patterns in IgnoreFile. Later patterns take precedence.
+type ignoreList []ignoreRule
+
+// readIgnoreList returns the patterns of IgnoreFile in root, or nil if there is no such file.
+func readIgnoreList(root string) (ignoreList, error) {
+	b, err := ioutil.ReadFile(filepath.Join(root, IgnoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIgnoreList(b)
+}
+
+func parseIgnoreList(b []byte) (ignoreList, error) {
+	var res ignoreList
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile(ignorePatternRegExp(line))
+		if err != nil {
+			return nil, fmt.Errorf("%v:%v: invalid pattern %q: %v", IgnoreFile, n, s.Text(), err)
+		}
+		r.re = re
+		res = append(res, r)
+	}
+	return res, s.Err()
+}
+
+// ignorePatternRegExp converts a pattern of .gitignore to a regular expression matching slash
+// separated paths relative to the project root. A pattern without a slash matches a name at
+// any level, and ** matches any number of directories.
+func ignorePatternRegExp(pattern string) string {
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			j := strings.IndexByte(pattern[i:], ']')
+			if j < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += j
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// ignored returns true if the slash separated relPath is excluded by the patterns.
+func (l ignoreList) ignored(relPath string, isDir bool) bool {
+	res := false
+	for _, r := range l {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(relPath) {
+			res = !r.negate
+		}
+	}
+	return res
+}
+
+// PathFilter selects project files by glob patterns with the syntax of the patterns of
+// IgnoreFile, e.g. custom/intents/** or settings/*. A nil PathFilter matches all files.
+type PathFilter []*regexp.Regexp
+
+// NewPathFilter returns a PathFilter matching any of patterns, or nil if there are none.
+func NewPathFilter(patterns []string) (PathFilter, error) {
+	var res PathFilter
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile(ignorePatternRegExp(p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// Match returns true if the slash separated relPath, or one of its parent directories, matches
+// any of the patterns of f.
+func (f PathFilter) Match(relPath string) bool {
+	if f == nil {
+		return true
+	}
+	for p := relPath; p != "." && p != "/"; p = path.Dir(p) {
+		for _, re := range f {
+			if re.MatchString(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/testutils"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIgnoreListIgnored(t *testing.T) {
+	l, err := parseIgnoreList([]byte(`# Build artifacts
+node_modules/
+*.log
+!keep.log
+/build
+webhooks/**/test
+resources/audio/draft?.mp3
+\#notes.txt
+`))
+	if err != nil {
+		t.Fatalf("parseIgnoreList returned %v, want %v", err, nil)
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "node_modules", isDir: true, want: true},
+		{path: "webhooks/ActionsOnGoogleFulfillment/node_modules", isDir: true, want: true},
+		{path: "node_modules", isDir: false, want: false},
+		{path: "debug.log", want: true},
+		{path: "webhooks/debug.log", want: true},
+		{path: "keep.log", want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "webhooks/build", isDir: true, want: false},
+		{path: "webhooks/test", isDir: true, want: true},
+		{path: "webhooks/ActionsOnGoogleFulfillment/test", isDir: true, want: true},
+		{path: "resources/audio/draft1.mp3", want: true},
+		{path: "resources/audio/draft10.mp3", want: false},
+		{path: "#notes.txt", want: true},
+		{path: "manifest.yaml", want: false},
+	}
+	for _, tc := range tests {
+		if got := l.ignored(tc.path, tc.isDir); got != tc.want {
+			t.Errorf("ignored(%q, %v) returned %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+}
+
+func TestFilesSkipsIgnored(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	files := map[string]string{
+		IgnoreFile:      "node_modules/\n*.log\n",
+		"manifest.yaml": "hello",
+		filepath.Join("webhooks", "ActionsOnGoogleFulfillment", "index.js"):                  "hello",
+		filepath.Join("webhooks", "ActionsOnGoogleFulfillment", "node_modules", "a", "a.js"): "hello",
+		filepath.Join("webhooks", "ActionsOnGoogleFulfillment", "npm-debug.log"):             "hello",
+	}
+	for k, v := range files {
+		fp := filepath.Join(dirName, k)
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatalf("Can't create a directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(v), 0666); err != nil {
+			t.Fatalf("Can't write %q: %v", fp, err)
+		}
+	}
+	got, err := New([]byte("secret"), dirName).Files()
+	if err != nil {
+		t.Errorf("Files got %v, want %v\n", err, nil)
+	}
+	want := map[string][]byte{
+		"manifest.yaml": []byte("hello"),
+		"webhooks/ActionsOnGoogleFulfillment/index.js": []byte("hello"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Files returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestFileRefsMatchesFiles(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	files := map[string]string{
+		IgnoreFile:      "*.log\n",
+		"manifest.yaml": "hello",
+		filepath.Join("resources", "audio", "a.mp3"):                             "audio",
+		filepath.Join("webhooks", "ActionsOnGoogleFulfillment", "npm-debug.log"): "hello",
+	}
+	for k, v := range files {
+		fp := filepath.Join(dirName, k)
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatalf("Can't create a directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(v), 0666); err != nil {
+			t.Fatalf("Can't write %q: %v", fp, err)
+		}
+	}
+	p := New([]byte("secret"), dirName)
+	want, err := p.Files()
+	if err != nil {
+		t.Fatalf("Files got %v, want %v\n", err, nil)
+	}
+	refs, err := p.FileRefs()
+	if err != nil {
+		t.Fatalf("FileRefs got %v, want %v\n", err, nil)
+	}
+	if got := refs["resources/audio/a.mp3"]; got.Path != filepath.Join(dirName, "resources", "audio", "a.mp3") || got.Size != 5 {
+		t.Errorf("FileRefs returned %+v for resources/audio/a.mp3, want a reference to the file on disk", got)
+	}
+	got, err := ReadFiles(refs, func(string) bool { return true })
+	if err != nil {
+		t.Errorf("ReadFiles got %v, want %v\n", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FileRefs returned different files than Files: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestParseIgnoreListInvalidPattern(t *testing.T) {
+	if _, err := parseIgnoreList([]byte("a[z-a]\n")); err == nil {
+		t.Errorf("parseIgnoreList returned %v for an invalid range, want an error", err)
+	}
+}
+
+func TestPathFilterMatch(t *testing.T) {
+	f, err := NewPathFilter([]string{"custom/intents/**", "settings/*", "webhooks/ActionsOnGoogleFulfillment"})
+	if err != nil {
+		t.Fatalf("NewPathFilter returned %v, want %v", err, nil)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "custom/intents/yes.yaml", want: true},
+		{path: "custom/intents/fr/yes.yaml", want: true},
+		{path: "custom/scenes/Main.yaml", want: false},
+		{path: "settings/settings.yaml", want: true},
+		{path: "settings/fr/settings.yaml", want: true},
+		{path: "webhooks/ActionsOnGoogleFulfillment/index.js", want: true},
+		{path: "webhooks/ActionsOnGoogleFulfillment.yaml", want: false},
+		{path: "manifest.yaml", want: false},
+	}
+	for _, tc := range tests {
+		if got := f.Match(tc.path); got != tc.want {
+			t.Errorf("Match(%q) returned %v, want %v", tc.path, got, tc.want)
+		}
+	}
+	var all PathFilter
+	if !all.Match("manifest.yaml") {
+		t.Errorf("Match of a nil PathFilter returned false, want true")
+	}
+	if _, err := NewPathFilter([]string{"a[z-a]"}); err == nil {
+		t.Errorf("NewPathFilter returned %v for an invalid range, want an error", err)
+	}
+}
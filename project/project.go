@@ -15,6 +15,13 @@
 // Package project contains an interface for an AoG project.
 package project
 
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
 const (
 	// ConfigName is filename of the file containing CLIConfig.
 	ConfigName = ".gactionsrc.yaml"
@@ -23,6 +30,33 @@ const (
 // CLIConfig represents a config file for CLI to read parameters from.
 type CLIConfig struct {
 	SdkPath string `yaml:"sdkPath"`
+	// CloudAuditLog enables recording of pushes, pulls and deploys to Cloud Logging in the developer's project.
+	CloudAuditLog bool `yaml:"cloudAuditLog"`
+	// Profile is the login profile whose credentials are used for the project, as in
+	// "gactions login --profile <profile>". The default credentials are used if it's empty.
+	Profile string `yaml:"profile"`
+	// SnapshotsToKeep is the number of the latest snapshots kept by "gactions snapshot prune", and
+	// after each "gactions snapshot create". All snapshots are kept if it's 0.
+	SnapshotsToKeep int `yaml:"snapshotsToKeep"`
+	// ContentTypes maps the extensions of resource files, e.g. ".glb", to their content types.
+	// It extends and overrides the content types known to the CLI.
+	ContentTypes map[string]string `yaml:"contentTypes"`
+	// UseADC makes the CLI use Application Default Credentials when it isn't logged in, as with
+	// the --use-adc flag.
+	UseADC bool `yaml:"useADC"`
+	// ClientSecretFile is the path of the secret of an OAuth client which "gactions login" uses
+	// instead of the client of the CLI, as with the --client-secret-file flag. A relative path is
+	// relative to the directory of the CLI config.
+	ClientSecretFile string `yaml:"clientSecretFile"`
+}
+
+// UserConfig represents the config of the user of the CLI, which applies to all of the projects.
+type UserConfig struct {
+	// Aliases maps a name to the command and flags it stands for, e.g. dp: deploy preview --sandbox=false.
+	Aliases map[string]string `yaml:"aliases"`
+	// Defaults maps a command, e.g. "deploy preview", to the flags added to each run of the command.
+	// Flags set in the command line take priority.
+	Defaults map[string]string `yaml:"defaults"`
 }
 
 // SampleProject has information about sample projects that CLI supports.
@@ -31,6 +65,17 @@ type SampleProject struct {
 	HostedURL string `json:"hostedUrl"`
 }
 
+// ActionsProject has information about an Actions project the user has access to.
+type ActionsProject struct {
+	// Name is the resource name of the project, i.e. projects/{projectID}.
+	Name        string `json:"name"`
+	ProjectID   string `json:"projectId"`
+	DisplayName string `json:"displayName"`
+	// LastDeployTime is the time of the last version of the project, or empty if it was never
+	// deployed.
+	LastDeployTime string `json:"lastDeployTime"`
+}
+
 // ReleaseChannel has information about release channels for the project
 // and their current and pending versions.
 type ReleaseChannel struct {
@@ -41,6 +86,8 @@ type ReleaseChannel struct {
 
 // VersionState has information about state of the version.
 type VersionState struct {
+	// State is the review state of the version, e.g. APPROVED or CREATION_FAILED.
+	State   string `json:"state"`
 	Message string `json:"message"`
 }
 
@@ -65,6 +112,9 @@ type Project interface {
 	// Files returns project files as a (filename string, content []byte) pair, where
 	// filename is a relative path starting from the root of the project.
 	Files() (map[string][]byte, error)
+	// FileRefs returns the same files as Files, but refers to their contents instead of
+	// reading them, so large files (e.g. audio resources) are read only when they are sent.
+	FileRefs() (map[string]File, error)
 	// ClientSecretJSON returns a client secret used to communicate with an external API.
 	ClientSecretJSON() ([]byte, error)
 	// ProjectRoot returns a root directory of a project. If root directory is not found,
@@ -74,3 +124,48 @@ type Project interface {
 	// ProjectID returns a Google Project ID associated with developer's Action, which should be safe to insert into the URL.
 	ProjectID() string
 }
+
+// File refers to a file of a project. Its content is read only when the file is opened.
+type File struct {
+	// Path is a location of the file on disk. It's empty when the content is held in memory.
+	Path string
+	// Size is a size of the content in bytes.
+	Size    int64
+	content []byte
+}
+
+// DiskFile returns a File which refers to a file of the given size on disk.
+func DiskFile(path string, size int64) File {
+	return File{Path: path, Size: size}
+}
+
+// InMemoryFile returns a File with the content held in memory. This is used for the files
+// which don't exist on disk, e.g. the files of an archive or the files generated by CLI.
+func InMemoryFile(content []byte) File {
+	return File{Size: int64(len(content)), content: content}
+}
+
+// InMemoryFiles converts each of the files into a File with the content held in memory.
+func InMemoryFiles(files map[string][]byte) map[string]File {
+	m := map[string]File{}
+	for k, v := range files {
+		m[k] = InMemoryFile(v)
+	}
+	return m
+}
+
+// Open returns a reader of the content of f. The caller must close it.
+func (f File) Open() (io.ReadCloser, error) {
+	if f.Path == "" {
+		return ioutil.NopCloser(bytes.NewReader(f.content)), nil
+	}
+	return os.Open(f.Path)
+}
+
+// ReadAll returns the content of f.
+func (f File) ReadAll() ([]byte, error) {
+	if f.Path == "" {
+		return f.content, nil
+	}
+	return ioutil.ReadFile(f.Path)
+}
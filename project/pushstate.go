@@ -0,0 +1,115 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/actions-on-google/gactions/project"
+)
+
+const (
+	// StateDir is a directory, relative to the project root, where CLI keeps its local state.
+	// The directory is hidden, so its content is never sent to Actions Console.
+	StateDir          = ".gactions"
+	pushStateFilename = "push-state.json"
+)
+
+// PushState records digests of the files sent to Actions Console during the last successful push.
+type PushState struct {
+	ProjectID string            `json:"projectId"`
+	Digests   map[string]string `json:"digests"`
+}
+
+// Digest returns a hex encoded SHA-256 digest of content.
+func Digest(content []byte) string {
+	h := sha256.Sum256(content)
+	return hex.EncodeToString(h[:])
+}
+
+// NewPushState returns a PushState for files that were pushed to the project with projectID.
+func NewPushState(projectID string, files map[string][]byte) PushState {
+	digests := map[string]string{}
+	for k, v := range files {
+		digests[k] = Digest(v)
+	}
+	return PushState{ProjectID: projectID, Digests: digests}
+}
+
+// FileDigests returns the same digests of files as NewPushState, reading the files one at a
+// time instead of holding all of them in memory.
+func FileDigests(files map[string]project.File) (map[string]string, error) {
+	digests := map[string]string{}
+	for k, f := range files {
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		digests[k] = hex.EncodeToString(h.Sum(nil))
+	}
+	return digests, nil
+}
+
+// ReadPushState reads the push state stored under the project root. It returns nil, without
+// an error, if the state doesn't exist (i.e. files were never pushed from this directory).
+func ReadPushState(root string) (*PushState, error) {
+	b, err := ioutil.ReadFile(filepath.Join(root, StateDir, pushStateFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &PushState{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WritePushState writes s under the project root, replacing the previous state, if any.
+func WritePushState(root string, s PushState) error {
+	dir := filepath.Join(root, StateDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, pushStateFilename), b, 0640)
+}
+
+// RemovePushState removes the push state stored under the project root, so the next
+// incremental push sends all files.
+func RemovePushState(root string) error {
+	err := os.Remove(filepath.Join(root, StateDir, pushStateFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/actions-on-google/gactions/api/testutils"
+	"github.com/actions-on-google/gactions/project"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadPushStateWhenMissing(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	got, err := ReadPushState(dirName)
+	if err != nil {
+		t.Errorf("ReadPushState returned %v, want %v", err, nil)
+	}
+	if got != nil {
+		t.Errorf("ReadPushState returned %v, want %v", got, nil)
+	}
+}
+
+func TestWriteAndReadPushState(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	want := NewPushState("hello-world", map[string][]byte{
+		"manifest.yaml":          []byte("version: 1.0"),
+		"resources/images/a.png": []byte("abc123"),
+	})
+	if err := WritePushState(dirName, want); err != nil {
+		t.Fatalf("WritePushState returned %v, want %v", err, nil)
+	}
+	got, err := ReadPushState(dirName)
+	if err != nil {
+		t.Fatalf("ReadPushState returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, *got); diff != "" {
+		t.Errorf("ReadPushState returned an incorrect state: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestFileDigests(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	fp := filepath.Join(dirName, "a.png")
+	if err := ioutil.WriteFile(fp, []byte("abc123"), 0666); err != nil {
+		t.Fatalf("Can't write %q: %v", fp, err)
+	}
+	files := map[string][]byte{
+		"manifest.yaml":          []byte("version: 1.0"),
+		"resources/images/a.png": []byte("abc123"),
+	}
+	refs := map[string]project.File{
+		"manifest.yaml":          project.InMemoryFile(files["manifest.yaml"]),
+		"resources/images/a.png": project.DiskFile(fp, 6),
+	}
+	got, err := FileDigests(refs)
+	if err != nil {
+		t.Errorf("FileDigests returned %v, want %v", err, nil)
+	}
+	want := NewPushState("hello-world", files).Digests
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FileDigests returned incorrect digests: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestZipFilesIsDeterministic(t *testing.T) {
+	files := map[string][]byte{
+		"webhooks/webhook1/index.js":     []byte("exports.hello = functions.https.onRequest(app);"),
+		"webhooks/webhook1/package.json": []byte("{}"),
+		"webhooks/webhook1/util.js":      []byte("module.exports = {};"),
+	}
+	first, err := zipFiles(files)
+	if err != nil {
+		t.Fatalf("zipFiles returned %v, want %v", err, nil)
+	}
+	for i := 0; i < 10; i++ {
+		got, err := zipFiles(files)
+		if err != nil {
+			t.Fatalf("zipFiles returned %v, want %v", err, nil)
+		}
+		if Digest(got) != Digest(first) {
+			t.Errorf("zipFiles returned different archives for the same files")
+		}
+	}
+}
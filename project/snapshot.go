@@ -0,0 +1,156 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	snapshotsDir         = "snapshots"
+	snapshotMetaFilename = "snapshot.json"
+	snapshotFilesDir     = "files"
+	// snapshotIDFormat is the format of the time a snapshot was created, which is its ID.
+	snapshotIDFormat = "20060102T150405Z"
+)
+
+// Snapshot is a local copy of the draft of a project in Actions Console. The files of a snapshot
+// are laid out like the files of a project, and kept in the state directory of the project.
+type Snapshot struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	ProjectID string    `json:"projectId"`
+	User      string    `json:"user"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// NewSnapshot returns a Snapshot of the draft of the project with projectID, taken now by the
+// current user.
+func NewSnapshot(projectID, message string) Snapshot {
+	t := time.Now().UTC().Truncate(time.Second)
+	return Snapshot{
+		ID:        t.Format(snapshotIDFormat),
+		Time:      t,
+		ProjectID: projectID,
+		User:      currentUser(),
+		Message:   message,
+	}
+}
+
+func snapshotDir(root, id string) string {
+	return filepath.Join(root, StateDir, snapshotsDir, id)
+}
+
+// SnapshotFilesDir returns the directory with the files of the snapshot with id, which can be
+// used as a project root.
+func SnapshotFilesDir(root, id string) string {
+	return filepath.Join(snapshotDir(root, id), snapshotFilesDir)
+}
+
+// WriteSnapshot records s under the project root. It must be called after the files of the
+// snapshot are written to SnapshotFilesDir, so incomplete snapshots aren't listed.
+func WriteSnapshot(root string, s Snapshot) error {
+	dir := snapshotDir(root, s.ID)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, snapshotMetaFilename), b, 0640)
+}
+
+// ReadSnapshot returns the snapshot with id recorded under the project root.
+func ReadSnapshot(root, id string) (Snapshot, error) {
+	b, err := ioutil.ReadFile(filepath.Join(snapshotDir(root, id), snapshotMetaFilename))
+	if os.IsNotExist(err) {
+		return Snapshot{}, fmt.Errorf("snapshot %q was not found", id)
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	s := Snapshot{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot %q is corrupted: %v", id, err)
+	}
+	return s, nil
+}
+
+// ListSnapshots returns the snapshots recorded under the project root, oldest first.
+func ListSnapshots(root string) ([]Snapshot, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(root, StateDir, snapshotsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var res []Snapshot
+	for _, v := range infos {
+		if !v.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(snapshotDir(root, v.Name()), snapshotMetaFilename)); os.IsNotExist(err) {
+			// The snapshot wasn't completed.
+			continue
+		}
+		s, err := ReadSnapshot(root, v.Name())
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time.Before(res[j].Time)
+	})
+	return res, nil
+}
+
+// RemoveSnapshot removes the snapshot with id, including its files.
+func RemoveSnapshot(root, id string) error {
+	return os.RemoveAll(snapshotDir(root, id))
+}
+
+// PruneSnapshots removes all but keep of the latest snapshots recorded under the project root,
+// and returns the removed snapshots. Nothing is removed if dryRun is true.
+func PruneSnapshots(root string, keep int, dryRun bool) ([]Snapshot, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("number of snapshots to keep must not be negative, got %v", keep)
+	}
+	snapshots, err := ListSnapshots(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) <= keep {
+		return nil, nil
+	}
+	old := snapshots[:len(snapshots)-keep]
+	if dryRun {
+		return old, nil
+	}
+	for i, s := range old {
+		if err := RemoveSnapshot(root, s.ID); err != nil {
+			return old[:i], err
+		}
+	}
+	return old, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/actions-on-google/gactions/api/testutils"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteAndListSnapshots(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	got, err := ListSnapshots(dirName)
+	if err != nil || got != nil {
+		t.Errorf("ListSnapshots returned (%v, %v) without snapshots, want (nil, nil)", got, err)
+	}
+	older := NewSnapshot("hello-world", "before release")
+	older.Time = older.Time.Add(-time.Hour)
+	older.ID = older.Time.Format(snapshotIDFormat)
+	newer := NewSnapshot("hello-world", "")
+	for _, s := range []Snapshot{newer, older} {
+		if err := os.MkdirAll(SnapshotFilesDir(dirName, s.ID), 0750); err != nil {
+			t.Fatalf("os.MkdirAll returned %v", err)
+		}
+		if err := WriteSnapshot(dirName, s); err != nil {
+			t.Fatalf("WriteSnapshot returned %v, want %v", err, nil)
+		}
+	}
+	// A snapshot whose files are still being written isn't listed.
+	if err := os.MkdirAll(SnapshotFilesDir(dirName, "incomplete"), 0750); err != nil {
+		t.Fatalf("os.MkdirAll returned %v", err)
+	}
+	got, err = ListSnapshots(dirName)
+	if err != nil {
+		t.Fatalf("ListSnapshots returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff([]Snapshot{older, newer}, got); diff != "" {
+		t.Errorf("ListSnapshots returned incorrect snapshots: diff (-want, +got)\n%s", diff)
+	}
+
+	if err := RemoveSnapshot(dirName, older.ID); err != nil {
+		t.Fatalf("RemoveSnapshot returned %v, want %v", err, nil)
+	}
+	if _, err := ReadSnapshot(dirName, older.ID); err == nil {
+		t.Errorf("ReadSnapshot returned nil for a removed snapshot, want an error")
+	}
+	if _, err := os.Stat(filepath.Join(dirName, StateDir, snapshotsDir, older.ID)); !os.IsNotExist(err) {
+		t.Errorf("RemoveSnapshot left the directory of the snapshot: %v", err)
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	var all []Snapshot
+	for i := 3; i > 0; i-- {
+		s := NewSnapshot("hello-world", "")
+		s.Time = s.Time.Add(-time.Duration(i) * time.Hour)
+		s.ID = s.Time.Format(snapshotIDFormat)
+		if err := WriteSnapshot(dirName, s); err != nil {
+			t.Fatalf("WriteSnapshot returned %v, want %v", err, nil)
+		}
+		all = append(all, s)
+	}
+	got, err := PruneSnapshots(dirName, 1, true)
+	if err != nil {
+		t.Fatalf("PruneSnapshots returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(all[:2], got); diff != "" {
+		t.Errorf("PruneSnapshots with dryRun returned diff (-want, +got)\n%s", diff)
+	}
+	if left, _ := ListSnapshots(dirName); len(left) != 3 {
+		t.Errorf("PruneSnapshots with dryRun left %v snapshots, want 3", len(left))
+	}
+	got, err = PruneSnapshots(dirName, 1, false)
+	if err != nil {
+		t.Fatalf("PruneSnapshots returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(all[:2], got); diff != "" {
+		t.Errorf("PruneSnapshots returned diff (-want, +got)\n%s", diff)
+	}
+	left, err := ListSnapshots(dirName)
+	if err != nil {
+		t.Fatalf("ListSnapshots returned %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(all[2:], left); diff != "" {
+		t.Errorf("PruneSnapshots left diff (-want, +got)\n%s", diff)
+	}
+	if got, err := PruneSnapshots(dirName, 5, false); err != nil || got != nil {
+		t.Errorf("PruneSnapshots with fewer snapshots than kept returned (%v, %v), want (nil, nil)", got, err)
+	}
+}
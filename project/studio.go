@@ -30,6 +30,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/actions-on-google/gactions/api/yamlutils"
 	"github.com/actions-on-google/gactions/log"
@@ -37,12 +38,16 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// readWorkers is the number of files read from disk in parallel by Files.
+var readWorkers = runtime.NumCPU()
+
 // Studio is an implementation of the AoG Studio project.
 type Studio struct {
 	files            map[string][]byte
 	clientSecretJSON []byte
 	root             string
 	projectID        string
+	env              string
 }
 
 // New returns a new instance of Studio.
@@ -52,10 +57,45 @@ func New(secret []byte, projectRoot string) Studio {
 }
 
 // Download places the files from sample project into dest. Returns an error if any.
+// HostedURL is either a URL of a zip archive of the sample, or a local template directory.
 func (p Studio) Download(sample project.SampleProject, dest string) error {
+	if fi, err := os.Stat(sample.HostedURL); err == nil && fi.IsDir() {
+		return copyTemplateDir(sample.HostedURL, dest)
+	}
 	return downloadFromGit(sample.Name, sample.HostedURL, dest)
 }
 
+// copyTemplateDir copies the files of the template directory src into dest. The .git
+// directory of the template isn't copied.
+func copyTemplateDir(src, dest string) error {
+	return filepath.Walk(src, func(fp string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, fp)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if info.IsDir() {
+			if fp != src && info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0750)
+		}
+		if !info.Mode().IsRegular() {
+			log.Warnf("Skipping %v, which is not a regular file.\n", fp)
+			return nil
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			return err
+		}
+		log.Infof("Writing %v\n", target)
+		return ioutil.WriteFile(target, b, 0640)
+	})
+}
+
 func downloadFromGit(projectTitle, url, dest string) error {
 	resp, err := http.Get(url)
 	if err != nil {
@@ -227,12 +267,27 @@ func IsResourceBundle(filename string) bool {
 	return strings.HasPrefix(filename, path.Join("resources", "strings")) && path.Ext(filename) == ".yaml"
 }
 
+// CanvasDir is the directory of an Interactive Canvas web app in a project. The web app is
+// deployed separately from the project, so its files are not part of the project files.
+const CanvasDir = "canvas"
+
 // IsAccountLinkingSecret returns true if the file contains an account linking secret. The file
 // must have the name settings/accountLinkingSecret.yaml.
 func IsAccountLinkingSecret(filename string) bool {
 	return strings.HasPrefix(filename, path.Join("settings", "accountLinkingSecret.yaml"))
 }
 
+// AccountLinkingSecretPath returns the path of the account linking secret of the environment
+// env, relative to the project root. The secret of an environment is kept in
+// settings/accountLinkingSecret.<env>.yaml, which isn't pushed unless env is selected.
+// If env is empty, it returns settings/accountLinkingSecret.yaml.
+func AccountLinkingSecretPath(env string) string {
+	if env == "" {
+		return path.Join("settings", "accountLinkingSecret.yaml")
+	}
+	return path.Join("settings", fmt.Sprintf("accountLinkingSecret.%s.yaml", env))
+}
+
 // ConfigFiles finds configuration files from the files of a project.
 func ConfigFiles(files map[string][]byte) map[string][]byte {
 	configFiles := map[string][]byte{}
@@ -261,11 +316,9 @@ var askYesNo = func(msg string) (string, error) {
 	return "", fmt.Errorf("invalid option specified: %v", ans)
 }
 
-// WriteToDisk writes content into path located in local file system. Path is relative
-// to project root (i.e. same level as manifest.yaml). This function will appropriately
-// combine value of path with project root to write the file in an appropriate location.
-// ContentType needs to be non-empty for data files; config files can have an empty string.
-func WriteToDisk(proj project.Project, path string, contentType string, payload []byte, force bool) error {
+// diskPath returns a location in local file system for the file at path, relative to the
+// project root. Zipped cloud functions are located in a directory named after the zip file.
+func diskPath(proj project.Project, path string, contentType string) string {
 	path = filepath.FromSlash(path)
 	if proj.ProjectRoot() != "" {
 		path = filepath.Join(proj.ProjectRoot(), path)
@@ -273,23 +326,41 @@ func WriteToDisk(proj project.Project, path string, contentType string, payload
 	if contentType == "application/zip;zip_type=cloud_function" {
 		path = path[:len(path)-len(".zip")]
 	}
+	return path
+}
+
+// ConfirmOverwrite returns true if the file at path (see WriteToDisk) can be written to disk.
+// If the file already exists, user is asked whether to overwrite it, unless force is true.
+func ConfirmOverwrite(proj project.Project, path string, contentType string, force bool) (bool, error) {
+	path = diskPath(proj, path, contentType)
+	if !exists(path) || force {
+		return true, nil
+	}
+	ans, err := askYesNo(fmt.Sprintf("%v already exists. Would you like to overwrite it?", path))
+	if err != nil {
+		return false, err
+	}
+	if ans != "yes" {
+		log.Infof("Skipping %v\n", path)
+		return false, nil
+	}
+	return true, nil
+}
+
+// WriteToDisk writes content into path located in local file system. Path is relative
+// to project root (i.e. same level as manifest.yaml). This function will appropriately
+// combine value of path with project root to write the file in an appropriate location.
+// ContentType needs to be non-empty for data files; config files can have an empty string.
+func WriteToDisk(proj project.Project, path string, contentType string, payload []byte, force bool) error {
+	ok, err := ConfirmOverwrite(proj, path, contentType, force)
+	if err != nil || !ok {
+		return err
+	}
+	path = diskPath(proj, path, contentType)
 	if exists(path) {
-		var ans string
-		if !force {
-			r, err := askYesNo(fmt.Sprintf("%v already exists. Would you like to overwrite it?", path))
-			if err != nil {
-				return err
-			}
-			ans = r
-		}
-		if ans == "yes" || force {
-			log.Infof("Removing %v\n", path)
-			if err := os.RemoveAll(path); err != nil {
-				return err
-			}
-		} else {
-			log.Infof("Skipping %v\n", path)
-			return nil
+		log.Infof("Removing %v\n", path)
+		if err := os.RemoveAll(path); err != nil {
+			return err
 		}
 	}
 	// proj.ProjectRoot() already exists, but old value of path may have project-specific subdirs that need to be created.
@@ -335,14 +406,20 @@ func unzipFiles(dir string, content []byte) error {
 func zipFiles(files map[string][]byte) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	w := zip.NewWriter(buf)
-	for name, content := range files {
+	// Sort names, so that the same files always produce the same archive.
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
 		// Server expects Cloud Functions to have the filePath stripped
 		// (i.e. webhooks/myfunction/index.js -> ./index.js)
 		f, err := w.Create(path.Base(name))
 		if err != nil {
 			return nil, err
 		}
-		_, err = f.Write(content)
+		_, err = f.Write(files[name])
 		if err != nil {
 			return nil, err
 		}
@@ -421,6 +498,59 @@ func DataFiles(p project.Project) (map[string][]byte, error) {
 	return dataFiles, nil
 }
 
+// ReadFiles reads the contents of the files for which keep returns true.
+func ReadFiles(files map[string]project.File, keep func(filename string) bool) (map[string][]byte, error) {
+	res := map[string][]byte{}
+	for k, f := range files {
+		if !keep(k) {
+			continue
+		}
+		b, err := f.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		res[k] = b
+	}
+	return res, nil
+}
+
+// ReadConfigFiles reads the configuration files of a project, leaving out the contents of
+// data files.
+func ReadConfigFiles(p project.Project) (map[string][]byte, error) {
+	files, err := p.FileRefs()
+	if err != nil {
+		return nil, err
+	}
+	return ReadFiles(files, isConfigFile)
+}
+
+// DataFileRefs is like DataFiles, but refers to the data files instead of reading them. Only
+// the zipped inline cloud functions, which are generated by CLI, are held in memory.
+func DataFileRefs(p project.Project) (map[string]project.File, error) {
+	files, err := p.FileRefs()
+	if err != nil {
+		return nil, err
+	}
+	dataFiles := map[string]project.File{}
+	for k, f := range files {
+		if strings.HasPrefix(k, "resources/") && !IsResourceBundle(k) {
+			dataFiles[k] = f
+		}
+	}
+	webhooks, err := ReadFiles(files, IsWebhook)
+	if err != nil {
+		return nil, err
+	}
+	zipped := map[string][]byte{}
+	if err := addInlineWebhooks(zipped, webhooks, p.ProjectRoot()); err != nil {
+		return nil, err
+	}
+	for k, v := range zipped {
+		dataFiles[k] = project.InMemoryFile(v)
+	}
+	return dataFiles, nil
+}
+
 // ProjectID finds a project id of a project.
 func ProjectID(proj project.Project) (string, error) {
 	// Note: `k` may have some parent subpath that is hard to predict, so
@@ -451,6 +581,24 @@ func ProjectID(proj project.Project) (string, error) {
 	return "", errors.New("can't find a project id: settings.yaml not found")
 }
 
+// DefaultLocale finds the default locale in the settings of project files.
+func DefaultLocale(files map[string][]byte) (string, error) {
+	for k, v := range files {
+		if path.Base(k) == "settings.yaml" && !isLocalizedSettings(k) {
+			mp, err := yamlutils.UnmarshalYAMLToMap(v)
+			if err != nil {
+				return "", fmt.Errorf("%v has incorrect syntax: %v", k, err)
+			}
+			locale, ok := mp["defaultLocale"].(string)
+			if !ok || locale == "" {
+				return "", errors.New("defaultLocale is not present in the settings file")
+			}
+			return locale, nil
+		}
+	}
+	return "", errors.New("can't find the default locale: settings.yaml not found")
+}
+
 // AlreadySetup returns true if pathToWorkDir already contains a complete
 // studio project.
 func (p Studio) AlreadySetup(pathToWorkDir string) bool {
@@ -505,40 +653,171 @@ func isHidden(path string) bool {
 	return false
 }
 
-// Files returns project files as a (filename string, content []byte) pair.
-func (p Studio) Files() (map[string][]byte, error) {
-	if p.files != nil {
-		return p.files, nil
+// skippedDirs are the directories which the project is never read from, besides hidden ones
+// such as .git. They hold dependencies, which aren't sent to SDK server and can have many files.
+var skippedDirs = map[string]bool{"node_modules": true}
+
+// inSkippedDir returns true if slashed, a slash-separated path, is in a directory of skippedDirs.
+func inSkippedDir(slashed string) bool {
+	parts := strings.Split(slashed, "/")
+	for _, v := range parts[:len(parts)-1] {
+		if skippedDirs[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// walkFiles calls fn for each file of the project in root, by the path relative to root which
+// is sent to SDK server. Hidden files and the files matched by the patterns in IgnoreFile are
+// skipped. The walk doesn't descend into hidden directories and skippedDirs at all.
+func walkFiles(root string, fn func(relPath, path string, info os.FileInfo) error) error {
+	ignore, err := readIgnoreList(root)
+	if err != nil {
+		return err
 	}
-	var m = make(map[string][]byte)
-	err := filepath.Walk(p.ProjectRoot(), func(path string, info os.FileInfo, err error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		relPath, err := relativePath(p.ProjectRoot(), path)
+		relPath, err := relativePath(root, path)
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && !isHidden(relPath) {
-			// SDK server expects filepath to be separated using a '/'.
-			if runtime.GOOS == "windows" {
-				m[winToUnix(relPath)], err = ioutil.ReadFile(path)
-			} else {
-				// Do not convert a Unix path because it may have a mix of \\ and / in the path
-				// as Linux allows it (i.e. mkdir hello\\world is valid on Linux)
-				m[relPath], err = ioutil.ReadFile(path)
+		if info.IsDir() && relPath != "." && (filepath.ToSlash(relPath) == CanvasDir || isHidden(info.Name()) || skippedDirs[info.Name()]) {
+			return filepath.SkipDir
+		}
+		if relPath != "." && ignore.ignored(filepath.ToSlash(relPath), info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
-			return err
+			return nil
+		}
+		if info.IsDir() || isHidden(relPath) {
+			return nil
 		}
+		// SDK server expects filepath to be separated using a '/'.
+		if runtime.GOOS == "windows" {
+			return fn(winToUnix(relPath), path, info)
+		}
+		// Do not convert a Unix path because it may have a mix of \\ and / in the path
+		// as Linux allows it (i.e. mkdir hello\\world is valid on Linux)
+		return fn(relPath, path, info)
+	})
+}
+
+// Files returns project files as a (filename string, content []byte) pair. Hidden files and
+// the files matched by the patterns in IgnoreFile are skipped.
+func (p Studio) Files() (map[string][]byte, error) {
+	if p.files != nil {
+		return p.files, nil
+	}
+	paths := map[string]string{}
+	err := walkFiles(p.ProjectRoot(), func(relPath, path string, info os.FileInfo) error {
+		paths[relPath] = path
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	m, err := readFiles(paths, readWorkers)
+	if err != nil {
+		return nil, err
+	}
+	if err := selectEnvSecret(m, p.env); err != nil {
+		return nil, err
+	}
 	p.files = m
 	return m, nil
 }
 
+// readFiles returns the contents of the files at paths, keyed by their paths in the project,
+// reading up to n files in parallel.
+func readFiles(paths map[string]string, n int) (map[string][]byte, error) {
+	if n < 1 {
+		n = 1
+	}
+	m := make(map[string][]byte, len(paths))
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	jobs := make(chan string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for relPath := range jobs {
+				mu.Lock()
+				failed := firstErr != nil
+				mu.Unlock()
+				if failed {
+					// Drain the queue, so the sender isn't blocked.
+					continue
+				}
+				b, err := ioutil.ReadFile(paths[relPath])
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				} else if err == nil {
+					m[relPath] = b
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for k := range paths {
+		jobs <- k
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return m, nil
+}
+
+// FileRefs returns the same files as Files, referring to the files on disk instead of
+// reading them.
+func (p Studio) FileRefs() (map[string]project.File, error) {
+	if p.files != nil {
+		return project.InMemoryFiles(p.files), nil
+	}
+	m := map[string]project.File{}
+	err := walkFiles(p.ProjectRoot(), func(relPath, path string, info os.FileInfo) error {
+		m[relPath] = project.DiskFile(path, info.Size())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.env != "" {
+		envPath := AccountLinkingSecretPath(p.env)
+		f, ok := m[envPath]
+		if !ok {
+			return nil, fmt.Errorf("%v was not found. Try running \"gactions encrypt --env %v\" first", envPath, p.env)
+		}
+		m[AccountLinkingSecretPath("")] = f
+	}
+	return m, nil
+}
+
+// selectEnvSecret replaces the account linking secret in files with the secret of env, so it
+// is sent in place of the default one. An empty env keeps the default secret.
+func selectEnvSecret(files map[string][]byte, env string) error {
+	if env == "" {
+		return nil
+	}
+	envPath := AccountLinkingSecretPath(env)
+	b, ok := files[envPath]
+	if !ok {
+		return fmt.Errorf("%v was not found. Try running \"gactions encrypt --env %v\" first", envPath, env)
+	}
+	files[AccountLinkingSecretPath("")] = b
+	return nil
+}
+
 // ClientSecretJSON returns a client secret used to communicate with an external API.
 func (p Studio) ClientSecretJSON() ([]byte, error) {
 	return p.clientSecretJSON, nil
@@ -585,6 +864,16 @@ func (p *Studio) SetProjectID(flag string) error {
 	return nil
 }
 
+// SetEnv selects the environment of the project, so Files returns the account linking secret
+// of env as settings/accountLinkingSecret.yaml. An empty env selects the default secret.
+func (p *Studio) SetEnv(env string) error {
+	if strings.ContainsAny(env, `/\.`) {
+		return fmt.Errorf("invalid environment name %q", env)
+	}
+	p.env = env
+	return nil
+}
+
 // SetProjectRoot sets project a root for studio project. It should only be called
 // if project root doesn't yet exist, but will be created as a result of a subroutine
 // that called SetProjectRoot. In this case, project root will become current working directory.
@@ -85,6 +85,10 @@ func (p MockStudio) Files() (map[string][]byte, error) {
 	return p.files, nil
 }
 
+func (p MockStudio) FileRefs() (map[string]project.File, error) {
+	return project.InMemoryFiles(p.files), nil
+}
+
 func (MockStudio) ClientSecretJSON() ([]byte, error) {
 	return []byte{}, nil
 }
@@ -203,6 +207,42 @@ func TestAlreadySetup(t *testing.T) {
 	}
 }
 
+func TestDownloadFromTemplateDir(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"manifest.yaml":          "version: \"1.0\"",
+		"settings/settings.yaml": "projectId: placeholder_project",
+		".git/HEAD":              "ref: refs/heads/main",
+	}
+	for name, content := range files {
+		fp := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dest := filepath.Join(t.TempDir(), "project")
+	proj := New([]byte{}, ".")
+	if err := proj.Download(project.SampleProject{Name: "template", HostedURL: src}, dest); err != nil {
+		t.Fatalf("Download returned %v, want %v", err, nil)
+	}
+	for _, name := range []string{"manifest.yaml", "settings/settings.yaml"} {
+		b, err := ioutil.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("Download didn't write %v: %v", name, err)
+			continue
+		}
+		if string(b) != files[name] {
+			t.Errorf("Download wrote %q to %v, want %q", b, name, files[name])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, ".git")); !os.IsNotExist(err) {
+		t.Errorf("Download copied .git of the template: %v", err)
+	}
+}
+
 func TestFilesWhenDirectoryManifestPresent(t *testing.T) {
 	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
 	if err != nil {
@@ -238,6 +278,174 @@ func TestFilesWhenDirectoryManifestPresent(t *testing.T) {
 	}
 }
 
+func TestFilesSkipsCanvas(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	for _, v := range []string{"manifest.yaml", filepath.Join("canvas", "index.html"), filepath.Join("canvas", "node_modules", "a.js")} {
+		fp := filepath.Join(dirName, v)
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatalf("Can't create a directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte("hello"), 0666); err != nil {
+			t.Fatalf("Can't write %q: %v", fp, err)
+		}
+	}
+	got, err := New([]byte("secret"), dirName).Files()
+	if err != nil {
+		t.Errorf("Files got %v, want %v\n", err, nil)
+	}
+	want := map[string][]byte{
+		"manifest.yaml": []byte("hello"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Files returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestFilesSkipsHiddenDirsAndDependencies(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	for _, v := range []string{
+		"manifest.yaml",
+		".hidden.yaml",
+		filepath.Join(".git", "HEAD"),
+		filepath.Join("webhooks", "hello", "index.js"),
+		filepath.Join("webhooks", "hello", "node_modules", "a", "index.js"),
+	} {
+		fp := filepath.Join(dirName, v)
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatalf("Can't create a directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte("hello"), 0666); err != nil {
+			t.Fatalf("Can't write %q: %v", fp, err)
+		}
+	}
+	got, err := New([]byte("secret"), dirName).Files()
+	if err != nil {
+		t.Fatalf("Files got %v, want %v\n", err, nil)
+	}
+	want := map[string][]byte{
+		"manifest.yaml":           []byte("hello"),
+		"webhooks/hello/index.js": []byte("hello"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Files returned incorrect files: diff (-want, +got)\n%s", diff)
+	}
+}
+
+func TestReadFiles(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	paths := map[string]string{}
+	want := map[string][]byte{}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("resources/images/%02d.png", i)
+		fp := filepath.Join(dirName, fmt.Sprintf("%02d.png", i))
+		if err := ioutil.WriteFile(fp, []byte(name), 0666); err != nil {
+			t.Fatalf("Can't write %q: %v", fp, err)
+		}
+		paths[name] = fp
+		want[name] = []byte(name)
+	}
+	for _, n := range []int{0, 1, 3} {
+		got, err := readFiles(paths, n)
+		if err != nil {
+			t.Fatalf("readFiles with %v workers returned %v, want %v", n, err, nil)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("readFiles with %v workers returned incorrect files: diff (-want, +got)\n%s", n, diff)
+		}
+	}
+	paths["resources/images/missing.png"] = filepath.Join(dirName, "missing.png")
+	if got, err := readFiles(paths, 3); err == nil {
+		t.Errorf("readFiles with a missing file returned %v, want an error", got)
+	}
+}
+
+func TestFilesWithEnv(t *testing.T) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	files := map[string]string{
+		"manifest.yaml": "hello",
+		filepath.Join("settings", "accountLinkingSecret.yaml"):         "prod",
+		filepath.Join("settings", "accountLinkingSecret.staging.yaml"): "staging",
+	}
+	for k, v := range files {
+		fp := filepath.Join(dirName, k)
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			t.Fatalf("Can't create a directory for %q: %v", fp, err)
+		}
+		if err := ioutil.WriteFile(fp, []byte(v), 0666); err != nil {
+			t.Fatalf("Can't write %q: %v", fp, err)
+		}
+	}
+	tests := []struct {
+		env        string
+		wantSecret string
+		wantErr    bool
+	}{
+		{env: "", wantSecret: "prod"},
+		{env: "staging", wantSecret: "staging"},
+		{env: "dev", wantErr: true},
+	}
+	for _, tc := range tests {
+		p := New([]byte("secret"), dirName)
+		if err := p.SetEnv(tc.env); err != nil {
+			t.Fatalf("SetEnv(%q) returned %v", tc.env, err)
+		}
+		got, err := p.Files()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Files with env %q returned %v, want error %v", tc.env, err, tc.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if s := string(got["settings/accountLinkingSecret.yaml"]); s != tc.wantSecret {
+			t.Errorf("Files with env %q returned %q as the secret, want %q", tc.env, s, tc.wantSecret)
+		}
+	}
+}
+
+func TestSetEnv(t *testing.T) {
+	for _, env := range []string{"../prod", "a.b", `a\b`} {
+		p := New([]byte("secret"), "")
+		if err := p.SetEnv(env); err == nil {
+			t.Errorf("SetEnv(%q) returned nil, want an error", env)
+		}
+	}
+}
+
+func TestAccountLinkingSecretPath(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{env: "", want: "settings/accountLinkingSecret.yaml"},
+		{env: "staging", want: "settings/accountLinkingSecret.staging.yaml"},
+	}
+	for _, tc := range tests {
+		got := AccountLinkingSecretPath(tc.env)
+		if got != tc.want {
+			t.Errorf("AccountLinkingSecretPath(%q) returned %v, want %v", tc.env, got, tc.want)
+		}
+		if IsAccountLinkingSecret(got) != (tc.env == "") {
+			t.Errorf("IsAccountLinkingSecret(%v) returned %v, want %v", got, !(tc.env == ""), tc.env == "")
+		}
+	}
+}
+
 func TestClientSecretJSON(t *testing.T) {
 	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
 	if err != nil {
@@ -312,6 +520,25 @@ func TestDataFiles(t *testing.T) {
 	}
 }
 
+func TestDataFileRefs(t *testing.T) {
+	p := NewMock(".")
+	want, err := DataFiles(p)
+	if err != nil {
+		t.Fatalf("DataFiles got %v, want %v", err, nil)
+	}
+	refs, err := DataFileRefs(p)
+	if err != nil {
+		t.Errorf("DataFileRefs got %v, want %v", err, nil)
+	}
+	got, err := ReadFiles(refs, func(string) bool { return true })
+	if err != nil {
+		t.Errorf("ReadFiles got %v, want %v", err, nil)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DataFileRefs returned different files than DataFiles: diff (-want, +got)\n%s", diff)
+	}
+}
+
 func TestAddInlineWebhooksReturnsErrorWithInvalidWebhookYaml(t *testing.T) {
 	p := NewMock(".")
 	p.files["webhooks/malformed_webhook.yaml"] = []byte(
@@ -361,6 +588,43 @@ func TestProjectIDSNotFound(t *testing.T) {
 	}
 }
 
+func TestDefaultLocale(t *testing.T) {
+	tests := []struct {
+		files   map[string][]byte
+		want    string
+		wantErr bool
+	}{
+		{
+			files: map[string][]byte{
+				"settings/settings.yaml":    []byte("defaultLocale: en\nprojectId: foo"),
+				"settings/fr/settings.yaml": []byte("displayName: bar"),
+			},
+			want: "en",
+		},
+		{
+			files: map[string][]byte{
+				"settings/settings.yaml": []byte("projectId: foo"),
+			},
+			wantErr: true,
+		},
+		{
+			files: map[string][]byte{
+				"manifest.yaml": []byte("version: 1"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		got, err := DefaultLocale(tc.files)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("DefaultLocale returned %v, but wantErr is %v", err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("DefaultLocale returned %v, want %v", got, tc.want)
+		}
+	}
+}
+
 func TestUnixPath(t *testing.T) {
 	tests := []struct {
 		in   string
@@ -536,6 +800,89 @@ func TestWriteToDiskToNonEmptyDir(t *testing.T) {
 	}
 }
 
+func TestConfirmOverwrite(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		user        string
+		force       bool
+		want        bool
+		wantAsked   string
+		name        string
+	}{
+		{
+			path:      "manifest.yaml",
+			user:      "no",
+			want:      false,
+			wantAsked: "manifest.yaml",
+			name:      "User says no",
+		},
+		{
+			path:      "manifest.yaml",
+			user:      "yes",
+			want:      true,
+			wantAsked: "manifest.yaml",
+			name:      "User says yes",
+		},
+		{
+			path:  "manifest.yaml",
+			force: true,
+			want:  true,
+			name:  "Force is true",
+		},
+		{
+			path: "actions/actions.yaml",
+			want: true,
+			name: "File doesn't exist",
+		},
+		{
+			path:        "webhooks/webhook1.zip",
+			contentType: "application/zip;zip_type=cloud_function",
+			user:        "no",
+			want:        false,
+			wantAsked:   "webhooks/webhook1",
+			name:        "Cloud function folder exists",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+			if err != nil {
+				t.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+			}
+			defer os.RemoveAll(dirName)
+			if err := ioutil.WriteFile(filepath.Join(dirName, "manifest.yaml"), []byte("version:2.0"), 0640); err != nil {
+				t.Fatalf("Can't write %v: %v", filepath.Join(dirName, "manifest.yaml"), err)
+			}
+			if err := os.MkdirAll(filepath.Join(dirName, "webhooks", "webhook1"), 0750); err != nil {
+				t.Fatalf("Can't create %v: %v", filepath.Join(dirName, "webhooks", "webhook1"), err)
+			}
+			var asked string
+			og := askYesNo
+			askYesNo = func(msg string) (string, error) {
+				asked = msg
+				return tc.user, nil
+			}
+			defer func() {
+				askYesNo = og
+			}()
+			got, err := ConfirmOverwrite(NewMock(dirName), tc.path, tc.contentType, tc.force)
+			if err != nil {
+				t.Errorf("ConfirmOverwrite returned %v, want %v", err, nil)
+			}
+			if got != tc.want {
+				t.Errorf("ConfirmOverwrite returned %v, want %v", got, tc.want)
+			}
+			if tc.wantAsked == "" && asked != "" {
+				t.Errorf("ConfirmOverwrite asked %q, but shouldn't ask the user", asked)
+			}
+			if tc.wantAsked != "" && !strings.Contains(asked, filepath.Join(dirName, filepath.FromSlash(tc.wantAsked))) {
+				t.Errorf("ConfirmOverwrite asked %q, want the question about %v", asked, tc.wantAsked)
+			}
+		})
+	}
+}
+
 func TestWriteToDiskToEmptyDir(t *testing.T) {
 	tests := []struct {
 		path        string
@@ -767,3 +1114,27 @@ func TestFindProjectRootWithoutConfig(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkFiles(b *testing.B) {
+	dirName, err := ioutil.TempDir(testutils.TestTmpDir, "actions-sdk-cli-project-folder")
+	if err != nil {
+		b.Fatalf("Can't create temporary directory under %q: %v", testutils.TestTmpDir, err)
+	}
+	defer os.RemoveAll(dirName)
+	for i := 0; i < 100; i++ {
+		fp := filepath.Join(dirName, "resources", "audio", fmt.Sprintf("audio%d.mp3", i))
+		if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+			b.Fatalf("Can't create %v: %v", filepath.Dir(fp), err)
+		}
+		if err := ioutil.WriteFile(fp, bytes.Repeat([]byte{byte(i)}, 100*1024), 0640); err != nil {
+			b.Fatalf("Can't write %v: %v", fp, err)
+		}
+	}
+	p := New([]byte{}, dirName)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Files(); err != nil {
+			b.Fatalf("Files returned %v, want %v", err, nil)
+		}
+	}
+}
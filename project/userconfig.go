@@ -0,0 +1,51 @@
+// Copyright 2020 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package studio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/actions-on-google/gactions/project"
+	"gopkg.in/yaml.v2"
+)
+
+// UserConfigPath returns the path of the user config of the CLI, which is config.yaml in the
+// gactions directory of the user's config directory (e.g. ~/.config/gactions on Linux).
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gactions", "config.yaml"), nil
+}
+
+// ReadUserConfig reads the user config at path. It returns an empty config if the file doesn't exist.
+func ReadUserConfig(path string) (project.UserConfig, error) {
+	cfg := project.UserConfig{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%v has incorrect syntax: %v", path, err)
+	}
+	return cfg, nil
+}